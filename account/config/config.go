@@ -0,0 +1,326 @@
+// Package config loads and validates the configuration of the account microservice.
+//
+// Values are resolved in order of increasing precedence:
+//  1. the defaults returned by Default()
+//  2. the optional YAML file pointed to by the CONFIG_FILE environment variable
+//  3. environment variables
+//
+// Load fails if a required field is missing or a value is malformed, so a misconfigured
+// service refuses to start instead of silently falling back to a default.
+package config
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+type Config struct {
+	GRPCPort            int           `yaml:"grpc_port"`
+	AdminPort           int           `yaml:"admin_port"` // serves /debug/config and /metrics. 0 disables the admin server.
+	HealthCheckInterval time.Duration `yaml:"health_check_interval"`
+	ShutdownTimeout     time.Duration `yaml:"shutdown_timeout"`
+	MaxRetries          int           `yaml:"max_retries"`     // attempts for transactions that hit a serialization failure or a deadlock
+	WatchHeartbeat      time.Duration `yaml:"watch_heartbeat"` // WatchAccount sends a heartbeat after this long without any event
+	// first 4 digits of the numbers of the accounts opened by this instance, e.g. the code of its branch
+	AccountNumberPrefix int `yaml:"account_number_prefix"`
+	// how long a hold reserves funds when its request doesn't say, after which the janitor releases it
+	HoldTTL time.Duration `yaml:"hold_ttl"`
+
+	TLS   TLSConfig   `yaml:"tls"`
+	JWT   JWTConfig   `yaml:"jwt"`
+	DB    DBConfig    `yaml:"db"`
+	Redis RedisConfig `yaml:"redis"`
+
+	Janitor JanitorConfig `yaml:"janitor"`
+}
+
+// TLSConfig points to the PEM files of the mTLS credentials shared by the internal services.
+// TLS is disabled when all of them are empty, which is only meant for local development.
+type TLSConfig struct {
+	CAFile   string `yaml:"ca_file"`
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+}
+
+// JWTConfig holds the key used to verify the access tokens forwarded by the API Gateway.
+type JWTConfig struct {
+	SecretKey string `yaml:"secret_key"` // base64 encoded HMAC key shared with the auth service
+
+	key []byte // SecretKey decoded once by Validate
+}
+
+type DBConfig struct {
+	Host     string `yaml:"host"`
+	Port     string `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	Name     string `yaml:"name"`
+	SSLMode  string `yaml:"ssl_mode"`
+}
+
+type RedisConfig struct {
+	Mode         string        `yaml:"mode"` // "single" or "cluster"
+	SingleAddr   string        `yaml:"single_addr"`
+	SinglePort   string        `yaml:"single_port"`
+	ClusterAddrs []string      `yaml:"cluster_addrs"`
+	Password     string        `yaml:"password"`
+	CacheTTL     time.Duration `yaml:"cache_ttl"`
+	// number of events kept per account for the watchers to resume from
+	EventRetention int `yaml:"event_retention"`
+}
+
+// JanitorConfig sets how the janitor purges the rows that outlived their use and flags the dormant accounts.
+type JanitorConfig struct {
+	Interval  time.Duration `yaml:"interval"`   // between scheduled runs. 0 disables them, leaving only `main janitor`.
+	BatchSize int           `yaml:"batch_size"` // rows deleted or updated per statement
+	// how long idempotency keys are kept once expired. Their requests are still replayed until they are deleted.
+	IdempotencyKeyRetention time.Duration `yaml:"idempotency_key_retention"`
+	// how long an active account goes without any transaction before it is flagged as dormant. 0 never flags them.
+	DormancyPeriod time.Duration `yaml:"dormancy_period"`
+}
+
+func Default() *Config {
+	return &Config{
+		GRPCPort:            50002,
+		AdminPort:           9102,
+		HealthCheckInterval: 5 * time.Second,
+		ShutdownTimeout:     30 * time.Second,
+		MaxRetries:          3,
+		WatchHeartbeat:      15 * time.Second,
+		AccountNumberPrefix: 1000,
+		HoldTTL:             7 * 24 * time.Hour,
+		DB: DBConfig{
+			SSLMode: "disable",
+		},
+		Redis: RedisConfig{
+			CacheTTL:       5 * time.Second,
+			EventRetention: 1000,
+		},
+		Janitor: JanitorConfig{
+			Interval:       10 * time.Minute,
+			BatchSize:      1000,
+			DormancyPeriod: 365 * 24 * time.Hour,
+		},
+	}
+}
+
+// Load builds the config from the defaults, the optional CONFIG_FILE and the environment, then validates it.
+func Load() (*Config, error) {
+	cfg := Default()
+
+	var env envLoader
+	var path string
+	env.string(&path, "CONFIG_FILE")
+	if path != "" {
+		if err := loadFile(path, cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	env.int(&cfg.GRPCPort, "GRPC_PORT")
+	env.int(&cfg.AdminPort, "ADMIN_HTTP_PORT")
+	env.duration(&cfg.HealthCheckInterval, "HEALTH_CHECK_INTERVAL")
+	env.duration(&cfg.ShutdownTimeout, "SHUTDOWN_TIMEOUT")
+	env.int(&cfg.MaxRetries, "MAX_RETRIES")
+	env.duration(&cfg.WatchHeartbeat, "WATCH_HEARTBEAT")
+	env.int(&cfg.AccountNumberPrefix, "ACCOUNT_NUMBER_PREFIX")
+	env.duration(&cfg.HoldTTL, "HOLD_TTL")
+	env.string(&cfg.TLS.CAFile, "TLS_CA_FILE")
+	env.string(&cfg.TLS.CertFile, "TLS_CERT_FILE")
+	env.string(&cfg.TLS.KeyFile, "TLS_KEY_FILE")
+	env.string(&cfg.JWT.SecretKey, "JWT_SECRET_KEY")
+	cfg.DB.loadEnv(&env)
+
+	env.string(&cfg.Redis.Mode, "REDIS_MODE")
+	env.string(&cfg.Redis.SingleAddr, "REDIS_SINGLE_ADDR")
+	env.string(&cfg.Redis.SinglePort, "REDIS_SINGLE_PORT")
+	env.list(&cfg.Redis.ClusterAddrs, "REDIS_CLUSTER_ADDRS")
+	env.string(&cfg.Redis.Password, "REDIS_PASSWORD")
+	env.duration(&cfg.Redis.CacheTTL, "CACHE_TTL")
+	env.int(&cfg.Redis.EventRetention, "EVENT_RETENTION")
+
+	env.duration(&cfg.Janitor.Interval, "JANITOR_INTERVAL")
+	env.int(&cfg.Janitor.BatchSize, "JANITOR_BATCH_SIZE")
+	env.duration(&cfg.Janitor.IdempotencyKeyRetention, "JANITOR_IDEMPOTENCY_KEY_RETENTION")
+	env.duration(&cfg.Janitor.DormancyPeriod, "JANITOR_DORMANCY_PERIOD")
+
+	if err := errors.Join(env.errs...); err != nil {
+		return nil, fmt.Errorf("invalid environment: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	return cfg, nil
+}
+
+// LoadDB loads only the database section. It is meant for integration tests that don't need the rest of the service.
+func LoadDB() (DBConfig, error) {
+	cfg := Default().DB
+	var env envLoader
+	cfg.loadEnv(&env)
+	if err := errors.Join(env.errs...); err != nil {
+		return cfg, err
+	}
+	return cfg, cfg.Validate()
+}
+
+func (c *DBConfig) loadEnv(env *envLoader) {
+	env.string(&c.Host, "ACCOUNT_DB_HOST")
+	env.string(&c.Port, "ACCOUNT_DB_PORT")
+	env.string(&c.User, "ACCOUNT_DB_USER")
+	env.string(&c.Password, "ACCOUNT_DB_PASSWORD")
+	env.string(&c.Name, "ACCOUNT_DB_NAME")
+	env.string(&c.SSLMode, "ACCOUNT_DB_SSL_MODE")
+}
+
+func (c *Config) Validate() error {
+	var errs []error
+	errs = append(errs, validatePort("grpc_port", c.GRPCPort))
+	if c.AdminPort != 0 {
+		errs = append(errs, validatePort("admin_port", c.AdminPort))
+	}
+	if c.HealthCheckInterval <= 0 {
+		errs = append(errs, errors.New("health_check_interval must be positive"))
+	}
+	if c.ShutdownTimeout <= 0 {
+		errs = append(errs, errors.New("shutdown_timeout must be positive"))
+	}
+	if c.MaxRetries < 1 {
+		errs = append(errs, errors.New("max_retries must be at least 1"))
+	}
+	if c.WatchHeartbeat <= 0 {
+		errs = append(errs, errors.New("watch_heartbeat must be positive"))
+	}
+	if c.AccountNumberPrefix < 1000 || c.AccountNumberPrefix > 9999 {
+		errs = append(errs, errors.New("account_number_prefix must be a 4-digit number"))
+	}
+	if c.HoldTTL <= 0 {
+		errs = append(errs, errors.New("hold_ttl must be positive"))
+	}
+	errs = append(errs, c.TLS.Validate(), c.JWT.Validate(), c.DB.Validate(), c.Redis.Validate(), c.Janitor.Validate())
+	return errors.Join(errs...)
+}
+
+func (c *DBConfig) Validate() error {
+	var errs []error
+	required := []struct{ name, value string }{
+		{"db.host", c.Host},
+		{"db.port", c.Port},
+		{"db.user", c.User},
+		{"db.password", c.Password},
+		{"db.name", c.Name},
+	}
+	for _, field := range required {
+		if field.value == "" {
+			errs = append(errs, fmt.Errorf("%s is required", field.name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c *RedisConfig) Validate() error {
+	var errs []error
+	switch c.Mode {
+	case "single":
+		if c.SingleAddr == "" || c.SinglePort == "" {
+			errs = append(errs, errors.New("redis.single_addr and redis.single_port are required in single mode"))
+		}
+	case "cluster":
+		if len(c.ClusterAddrs) == 0 {
+			errs = append(errs, errors.New("redis.cluster_addrs is required in cluster mode"))
+		}
+	case "":
+		errs = append(errs, errors.New("redis.mode is required"))
+	default:
+		errs = append(errs, fmt.Errorf("redis.mode must be \"single\" or \"cluster\", got %q", c.Mode))
+	}
+	if c.CacheTTL <= 0 {
+		errs = append(errs, errors.New("redis.cache_ttl must be positive"))
+	}
+	if c.EventRetention < 1 {
+		errs = append(errs, errors.New("redis.event_retention must be at least 1"))
+	}
+	return errors.Join(errs...)
+}
+
+func (c *JanitorConfig) Validate() error {
+	var errs []error
+	if c.Interval < 0 {
+		errs = append(errs, errors.New("janitor.interval must not be negative"))
+	}
+	if c.BatchSize < 1 {
+		errs = append(errs, errors.New("janitor.batch_size must be at least 1"))
+	}
+	if c.IdempotencyKeyRetention < 0 {
+		errs = append(errs, errors.New("janitor.idempotency_key_retention must not be negative"))
+	}
+	if c.DormancyPeriod < 0 {
+		errs = append(errs, errors.New("janitor.dormancy_period must not be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+func (c *TLSConfig) Enabled() bool {
+	return c.CAFile != "" || c.CertFile != "" || c.KeyFile != ""
+}
+
+func (c *TLSConfig) Validate() error {
+	if c.Enabled() && (c.CAFile == "" || c.CertFile == "" || c.KeyFile == "") {
+		return errors.New("tls.ca_file, tls.cert_file and tls.key_file must be set together")
+	}
+	return nil
+}
+
+// Validate also decodes the secret key, so Key() is only usable after a successful Validate.
+func (c *JWTConfig) Validate() error {
+	if c.SecretKey == "" {
+		return errors.New("jwt.secret_key is required")
+	}
+	key, err := base64.StdEncoding.DecodeString(c.SecretKey)
+	if err != nil {
+		return errors.New("jwt.secret_key must be base64 encoded")
+	}
+	c.key = key
+	return nil
+}
+
+// Key returns the decoded HMAC key used to verify access tokens.
+func (c *JWTConfig) Key() []byte {
+	return c.key
+}
+
+// DSN returns the lib/pq connection string.
+func (c *DBConfig) DSN() string {
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+		c.Host, c.Port, c.User, c.Password, c.Name, c.SSLMode)
+}
+
+// Redacted returns a copy of the config with every secret masked.
+func (c *Config) Redacted() *Config {
+	cp := *c
+	cp.DB.Password = redact(c.DB.Password)
+	cp.Redis.Password = redact(c.Redis.Password)
+	cp.JWT.SecretKey = redact(c.JWT.SecretKey)
+	cp.JWT.key = nil
+	cp.Redis.ClusterAddrs = append([]string(nil), c.Redis.ClusterAddrs...)
+	return &cp
+}
+
+// DumpHandler serves the redacted effective config as YAML, in the same format accepted by CONFIG_FILE.
+func (c *Config) DumpHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		out, err := yaml.Marshal(c.Redacted())
+		if err != nil {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/yaml")
+		w.Header().Set("Cache-Control", "no-store")
+		w.Write(out)
+	})
+}
@@ -1,24 +1,16 @@
 package initialize
 
 import (
-	"fmt"
+	"account/config"
 	"log"
-	"os"
 
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
 )
 
-func ConnectDB() *sqlx.DB {
+func ConnectDB(cfg config.DBConfig) *sqlx.DB {
 	// connect to the database
-	dbConnectString := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		os.Getenv("ACCOUNT_DB_HOST"),
-		os.Getenv("ACCOUNT_DB_PORT"),
-		os.Getenv("ACCOUNT_DB_USER"),
-		os.Getenv("ACCOUNT_DB_PASSWORD"),
-		os.Getenv("ACCOUNT_DB_NAME"),
-	)
-	db, err := sqlx.Connect("postgres", dbConnectString)
+	db, err := sqlx.Connect("postgres", cfg.DSN())
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
@@ -1,6 +1,6 @@
 -- name: CreateAccount :one
-INSERT INTO accounts (id, account_number, user_id, balance)
-VALUES ($1, $2, $3, $4)
+INSERT INTO accounts (id, account_number, user_id, balance, product_code, account_type, matures_at, currency)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 RETURNING *;
 
 -- name: GetAccountByID :one
@@ -9,6 +9,9 @@ SELECT * FROM accounts WHERE id = $1;
 -- name: GetAccountsByUserID :many
 SELECT * FROM accounts WHERE user_id = $1;
 
+-- name: GetAccountByIDForUpdate :one
+SELECT * FROM accounts WHERE id = $1 FOR UPDATE;
+
 -- name: GetAccountByAccountNumber :one
 SELECT * FROM accounts WHERE account_number = $1;
 
@@ -17,13 +20,60 @@ SELECT * FROM accounts ORDER BY id LIMIT $1;
 
 -- name: AddToAccountBalance :one
 UPDATE accounts
-SET balance = balance + sqlc.arg(amount)
+SET balance = balance + sqlc.arg(amount), version = version + 1, last_activity_at = NOW()
 WHERE account_number = sqlc.arg(account_number)
 RETURNING *;
 
+-- name: AddToAccountBalanceWithoutActivity :one
+-- for the transactions the bank posts itself, such as interest and fees: unlike AddToAccountBalance, they don't count as
+-- activity, so that they don't keep dormant accounts alive
+UPDATE accounts
+SET balance = balance + sqlc.arg(amount), version = version + 1
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
 -- name: DeleteAccountByAccountNumber :exec
+-- accounts are closed rather than deleted, this is only for the tests to clean up. It fails if the account has
+-- transactions.
 DELETE FROM accounts
-WHERE account_number = $1
+WHERE account_number = $1;
+
+-- name: UpdateAccountStatus :one
+-- only updates the account if it still has the status from_status, so that concurrent changes can't skip a check of
+-- model.CanTransition. Changing the status counts as activity, so that a reactivated account doesn't go dormant again
+-- right away.
+UPDATE accounts
+SET status = sqlc.arg(status), status_changed_at = NOW(), last_activity_at = NOW()
+WHERE id = sqlc.arg(id) AND status = sqlc.arg(from_status)
 RETURNING *;
 
+-- name: CloseAccount :one
+-- only closes the account if it still has the status from_status, a zero balance and no active hold
+UPDATE accounts
+SET status = 'CLOSED', status_changed_at = NOW(), closure_reason = sqlc.arg(closure_reason), closed_at = NOW()
+WHERE id = sqlc.arg(id) AND status = sqlc.arg(from_status) AND balance = 0 AND held = 0
+RETURNING *;
+
+-- name: MarkDormantAccounts :execrows
+UPDATE accounts
+SET status = 'DORMANT', status_changed_at = NOW()
+WHERE id IN (
+    SELECT id FROM accounts
+    WHERE status = 'ACTIVE' AND last_activity_at < sqlc.arg(inactive_since)::timestamptz
+    LIMIT sqlc.arg(batch_size)
+    FOR UPDATE SKIP LOCKED
+);
+
+
+
+-- name: NextAccountNumberSerial :one
+-- returns no row once the 10,000,000 serials of the prefix are used
+INSERT INTO account_number_serials (prefix, next_serial)
+VALUES (sqlc.arg(prefix)::int, 1)
+ON CONFLICT (prefix) DO UPDATE
+SET next_serial = account_number_serials.next_serial + 1
+WHERE account_number_serials.next_serial < 10000000
+RETURNING (next_serial - 1)::bigint AS serial;
 
+-- name: GetAccountProductByCode :one
+SELECT * FROM account_products WHERE code = $1;
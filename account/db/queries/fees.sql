@@ -0,0 +1,32 @@
+-- name: ListFeeRules :many
+-- the active rules of trigger that apply to the accounts of a product in a currency
+SELECT * FROM fee_rules
+WHERE active
+  AND trigger = sqlc.arg(trigger)
+  AND currency = sqlc.arg(currency)
+  AND (product_code IS NULL OR product_code = sqlc.arg(product_code)::text)
+ORDER BY code;
+
+-- name: GetFeeRuleByCode :one
+SELECT * FROM fee_rules WHERE code = $1;
+
+-- name: ListMaintenanceFeesToCharge :many
+-- the monthly fees not charged yet for the month starting on period, on the accounts opened before the month ended
+SELECT a.id AS account_id, r.code AS fee_rule
+FROM accounts a
+JOIN fee_rules r ON r.trigger = 'MONTHLY_MAINTENANCE' AND r.active AND r.currency = a.currency
+    AND (r.product_code IS NULL OR r.product_code = a.product_code)
+WHERE a.status IN ('ACTIVE', 'FROZEN', 'DORMANT')
+  AND a.created_at < (sqlc.arg(period)::date + INTERVAL '1 month')
+  AND NOT EXISTS (
+    SELECT 1 FROM maintenance_fee_periods m
+    WHERE m.account_id = a.id AND m.fee_rule = r.code AND m.period = sqlc.arg(period)::date
+  )
+ORDER BY a.id, r.code
+LIMIT sqlc.arg(batch_size);
+
+-- name: CreateMaintenanceFeePeriod :execrows
+-- does nothing if the fee was charged for the month already
+INSERT INTO maintenance_fee_periods (account_id, fee_rule, period, transaction_id)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (account_id, fee_rule, period) DO NOTHING;
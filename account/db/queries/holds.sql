@@ -0,0 +1,34 @@
+-- name: CreateHold :one
+INSERT INTO holds (id, account_id, amount, currency, transaction_type, transfer_id, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: GetHoldByID :one
+SELECT * FROM holds WHERE id = $1;
+
+-- name: SettleHold :one
+-- only settles the hold if it is still ACTIVE, as CAPTURED, VOIDED or EXPIRED
+UPDATE holds
+SET status = sqlc.arg(status), captured = sqlc.arg(captured)
+WHERE id = sqlc.arg(id) AND status = 'ACTIVE'
+RETURNING *;
+
+-- name: AddToAccountHeld :one
+-- holds don't change the balance, so they don't change the version of the account either
+UPDATE accounts
+SET held = held + sqlc.arg(amount)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: ListExpiredHolds :many
+SELECT * FROM holds
+WHERE status = 'ACTIVE' AND expires_at < sqlc.arg(expired_before)::timestamptz
+ORDER BY expires_at
+LIMIT sqlc.arg(batch_size);
+
+-- name: CountActiveHoldsSince :one
+-- the active holds are debits that aren't posted yet
+SELECT COUNT(*) FROM holds
+WHERE account_id = sqlc.arg(account_id)
+  AND status = 'ACTIVE'
+  AND created_at >= sqlc.arg(since)::timestamptz;
@@ -15,9 +15,15 @@ WHERE key_id = $1;
 DELETE FROM idempotency_keys
 WHERE user_id = $1;
 
--- name: DeleteIdempotencyKeysByExpiredAt :exec
+-- name: DeleteIdempotencyKeysByExpiredAt :execrows
+-- Deletes at most batch_size keys that expired before expired_before, so that the janitor purges large backlogs in
+-- short transactions.
 DELETE FROM idempotency_keys
-WHERE expired_at < NOW();
+WHERE (key_id, user_id) IN (
+    SELECT key_id, user_id FROM idempotency_keys
+    WHERE expired_at < sqlc.arg(expired_before)::timestamptz
+    LIMIT sqlc.arg(batch_size)
+);
 
 
 -- name: GetOrClaimIdempotencyKey :one
@@ -25,38 +31,61 @@ INSERT INTO idempotency_keys (
      -- Insert a new idempotency key. If concurrenct transactions already created the key, its status should be "COMPLETED" or "FAILED". 
      -- Else, we're the first to create it, and we set it to "PENDING".
      -- This statement will block if there are concurrent transactions inserting the same row, even if they haven't been committed/rollbacked.
+     -- The claim is ours if the returned claim_id is the one we passed.
     key_id,
     user_id,
+    rpc_name,
+    request_hash,
+    claim_id,
     status,            
     response_message,     
     created_at,
     updated_at,
-    expired_at
+    expired_at,
+    lease_expires_at
 )
 VALUES (
-    $1,  
-    $2,  
+    sqlc.arg(key_id),
+    sqlc.arg(user_id),
+    sqlc.arg(rpc_name),
+    sqlc.arg(request_hash),
+    sqlc.arg(claim_id),
     'PENDING',
     'placeholder', 
     NOW(),
     NOW(),
-    NOW() + interval '24 hours'
+    NOW() + interval '24 hours',
+    NOW() + sqlc.arg(lease_seconds)::float8 * interval '1 second'
 )
 ON CONFLICT (key_id, user_id)
 DO UPDATE SET
-    -- Only touch 'updated_at' if the existing status is 'PENDING', to signify this transaction is actively looking at it.
-    -- If it's already 'COMPLETED' or 'FAILED', we don't want to modify it here; RETURNING * will give us its state.
-    -- The 'status' is NOT changed here by the DO UPDATE clause if it was already terminal.
-    -- If it was PENDING, it remains PENDING. If it was a new insert, it gets the $3 status.
+    -- A key still 'PENDING' after its lease expired was abandoned by the attempt that claimed it: take it over.
+    -- Otherwise the key is left as is, RETURNING * gives its state: 'COMPLETED' or 'FAILED' if the request already ran,
+    -- or 'PENDING' with the claim_id of another attempt if it is still running.
+    claim_id = CASE
+                    WHEN idempotency_keys.status = 'PENDING' AND idempotency_keys.lease_expires_at < NOW()
+                        THEN EXCLUDED.claim_id
+                    ELSE idempotency_keys.claim_id
+               END,
+    lease_expires_at = CASE
+                    WHEN idempotency_keys.status = 'PENDING' AND idempotency_keys.lease_expires_at < NOW()
+                        THEN EXCLUDED.lease_expires_at
+                    ELSE idempotency_keys.lease_expires_at
+                 END,
     updated_at = CASE
-                    WHEN idempotency_keys.status = 'PENDING' -- $3 is 'PENDING'
+                    WHEN idempotency_keys.status = 'PENDING' AND idempotency_keys.lease_expires_at < NOW()
                         THEN NOW()
-                    ELSE idempotency_keys.updated_at -- Keep existing updated_at if it was COMPLETED/FAILED or different pending
+                    ELSE idempotency_keys.updated_at
                  END
 RETURNING *;
 
+-- name: SetLockTimeout :exec
+-- Sets lock_timeout until the end of the current transaction, e.g. '30000ms', or '0' to wait forever.
+SELECT set_config('lock_timeout', sqlc.arg(timeout)::text, true);
+
 -- name: UpdateIdempotencyKey :one
+-- Only the attempt holding the claim may complete the key, a late attempt whose claim was taken over updates nothing.
 UPDATE idempotency_keys
 SET status = $1, response_message = $2, updated_at = NOW(), expired_at = NOW() + interval '24 hours'
-WHERE key_id = $3
+WHERE key_id = $3 AND claim_id = $4
 RETURNING *;
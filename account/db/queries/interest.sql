@@ -0,0 +1,68 @@
+-- name: ListProductInterestRates :many
+SELECT * FROM product_interest_rates WHERE product_code = $1 ORDER BY effective_from;
+
+-- name: ListAccountsToAccrue :many
+-- the accounts of a product with interest rates that can still accrue before through: interest accrues from the day
+-- an account is approved until the day before it is closed.
+SELECT a.id FROM accounts a
+WHERE a.status IN ('ACTIVE', 'FROZEN', 'DORMANT')
+  AND COALESCE(a.interest_accrued_through, (a.created_at AT TIME ZONE 'UTC')::date - 1) < sqlc.arg(through)::date
+  AND EXISTS (SELECT 1 FROM product_interest_rates r WHERE r.product_code = a.product_code)
+ORDER BY a.id
+LIMIT sqlc.arg(batch_size);
+
+-- name: GetEndOfDayBalance :one
+-- balance of a locked account at day_end, before the transactions created since
+SELECT (a.balance - COALESCE((
+    SELECT SUM(t.amount) FROM transactions t
+    WHERE t.account_id = a.id AND t.created_at >= sqlc.arg(day_end)::timestamptz
+), 0))::bigint AS balance
+FROM accounts a
+WHERE a.id = sqlc.arg(account_id);
+
+-- name: CreateInterestAccrual :exec
+INSERT INTO interest_accruals (account_id, accrual_date, eod_balance, annual_rate, amount)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: SetInterestAccruedThrough :exec
+UPDATE accounts
+SET interest_accrued_through = sqlc.arg(through)::date
+WHERE id = sqlc.arg(id);
+
+-- name: ListPeriodsToPost :many
+-- the months before sqlc.arg(before) with accruals of an account that aren't posted yet. A month is only posted once the
+-- account has accrued for all its days, or was closed.
+SELECT ia.account_id, date_trunc('month', ia.accrual_date)::date AS period
+FROM interest_accruals ia
+JOIN accounts a ON a.id = ia.account_id
+WHERE ia.accrual_date < sqlc.arg(before)::date
+  AND (a.status = 'CLOSED'
+       OR a.interest_accrued_through >= (date_trunc('month', ia.accrual_date) + INTERVAL '1 month')::date - 1)
+  AND NOT EXISTS (
+    SELECT 1 FROM interest_postings p
+    WHERE p.account_id = ia.account_id AND p.period = date_trunc('month', ia.accrual_date)::date
+  )
+GROUP BY ia.account_id, date_trunc('month', ia.accrual_date)::date
+ORDER BY period, ia.account_id
+LIMIT sqlc.arg(batch_size);
+
+-- name: SumInterestAccruals :one
+SELECT COALESCE(SUM(amount), 0)::text AS amount FROM interest_accruals
+WHERE account_id = sqlc.arg(account_id)
+  AND accrual_date >= sqlc.arg(period)::date
+  AND accrual_date < (sqlc.arg(period)::date + INTERVAL '1 month')::date;
+
+-- name: GetLastInterestPosting :one
+SELECT * FROM interest_postings
+WHERE account_id = sqlc.arg(account_id) AND period < sqlc.arg(period)::date
+ORDER BY period DESC
+LIMIT 1;
+
+-- name: CreateInterestPosting :execrows
+-- does nothing if the period is posted already
+INSERT INTO interest_postings (account_id, period, accrued, posted, carried, transaction_id)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (account_id, period) DO NOTHING;
+
+-- name: GetInterestPostingsByAccountID :many
+SELECT * FROM interest_postings WHERE account_id = $1 ORDER BY period;
@@ -0,0 +1,69 @@
+-- name: ListAccountLimits :many
+SELECT * FROM account_limits WHERE account_id = $1;
+
+-- name: UpsertAccountLimits :one
+INSERT INTO account_limits (account_id, level, max_single_debit, daily_debit_total, daily_debit_count, monthly_debit_total)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (account_id, level) DO UPDATE
+SET max_single_debit = EXCLUDED.max_single_debit,
+    daily_debit_total = EXCLUDED.daily_debit_total,
+    daily_debit_count = EXCLUDED.daily_debit_count,
+    monthly_debit_total = EXCLUDED.monthly_debit_total
+RETURNING *;
+
+-- name: SumWithdrawalsSince :one
+-- the withdrawals counted by CountWithdrawalsSince, as a positive amount
+SELECT COALESCE(SUM(-t.amount), 0)::bigint FROM transactions t
+LEFT JOIN holds h ON h.id = t.hold_id
+WHERE t.account_id = sqlc.arg(account_id)
+  AND t.amount < 0
+  AND t.transaction_type IN ('DEBIT', 'TRANSFER_DEBIT', 'REVERSAL')
+  AND t.status <> 'FAILED'
+  AND COALESCE(h.created_at, t.created_at) >= sqlc.arg(since)::timestamptz;
+
+-- name: SumActiveHoldsSince :one
+SELECT COALESCE(SUM(amount), 0)::bigint FROM holds
+WHERE account_id = sqlc.arg(account_id)
+  AND status = 'ACTIVE'
+  AND created_at >= sqlc.arg(since)::timestamptz;
+
+-- name: GetUserLimits :one
+SELECT * FROM user_limits WHERE user_id = $1 AND currency = $2;
+
+-- name: CreateUserLimitsIfNotExists :exec
+-- the limits of a user who set none have no limit, so that there is always a row to lock
+INSERT INTO user_limits (user_id, currency) VALUES ($1, $2)
+ON CONFLICT (user_id, currency) DO NOTHING;
+
+-- name: GetUserLimitsForUpdate :one
+SELECT * FROM user_limits WHERE user_id = $1 AND currency = $2 FOR UPDATE;
+
+-- name: UpsertUserLimits :one
+INSERT INTO user_limits (user_id, currency, max_single_debit, daily_debit_total, daily_debit_count, monthly_debit_total)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (user_id, currency) DO UPDATE
+SET max_single_debit = EXCLUDED.max_single_debit,
+    daily_debit_total = EXCLUDED.daily_debit_total,
+    daily_debit_count = EXCLUDED.daily_debit_count,
+    monthly_debit_total = EXCLUDED.monthly_debit_total
+RETURNING *;
+
+-- name: SumUserWithdrawalsSince :one
+-- the withdrawals of SumWithdrawalsSince of all the accounts of the user in currency, and how many they are
+SELECT COALESCE(SUM(-t.amount), 0)::bigint AS total, COUNT(*) AS count FROM transactions t
+JOIN accounts a ON a.id = t.account_id
+LEFT JOIN holds h ON h.id = t.hold_id
+WHERE a.user_id = sqlc.arg(user_id)
+  AND a.currency = sqlc.arg(currency)
+  AND t.amount < 0
+  AND t.transaction_type IN ('DEBIT', 'TRANSFER_DEBIT', 'REVERSAL')
+  AND t.status <> 'FAILED'
+  AND COALESCE(h.created_at, t.created_at) >= sqlc.arg(since)::timestamptz;
+
+-- name: SumUserActiveHoldsSince :one
+SELECT COALESCE(SUM(h.amount), 0)::bigint AS total, COUNT(*) AS count FROM holds h
+JOIN accounts a ON a.id = h.account_id
+WHERE a.user_id = sqlc.arg(user_id)
+  AND a.currency = sqlc.arg(currency)
+  AND h.status = 'ACTIVE'
+  AND h.created_at >= sqlc.arg(since)::timestamptz;
@@ -1,6 +1,6 @@
 -- name: CreateTransaction :one
-INSERT INTO transactions (id, account_id, amount, transaction_type, status, transfer_id)
-VALUES ($1, $2, $3, $4, $5, $6)
+INSERT INTO transactions (id, account_id, amount, transaction_type, status, transfer_id, currency, caused_by, fee_rule, memo, hold_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 RETURNING *;
 
 -- name: GetTransactionByID :one
@@ -26,6 +26,21 @@ DELETE FROM transactions
 WHERE id = $1
 RETURNING *;
 
-
-
-
+-- name: CountWithdrawalsSince :one
+-- the fees charged by the bank aren't withdrawals. A reversed withdrawal still counts: the reversal is a refund, not a
+-- cancellation. A credit given back is a withdrawal, its reversal being a debit. A debit capturing a hold dates from
+-- the hold, which counted as a withdrawal while it was ACTIVE.
+SELECT COUNT(*) FROM transactions t
+LEFT JOIN holds h ON h.id = t.hold_id
+WHERE t.account_id = sqlc.arg(account_id)
+  AND t.amount < 0
+  AND t.transaction_type IN ('DEBIT', 'TRANSFER_DEBIT', 'REVERSAL')
+  AND t.status <> 'FAILED'
+  AND COALESCE(h.created_at, t.created_at) >= sqlc.arg(since)::timestamptz;
+
+-- name: GetFeeReversal :one
+SELECT * FROM transactions WHERE caused_by = $1 AND transaction_type = 'FEE_REVERSAL';
+
+-- name: SumReversals :one
+-- the reversals of a transaction offset it, so their sum has the opposite sign
+SELECT COALESCE(SUM(amount), 0)::bigint FROM transactions WHERE caused_by = $1 AND transaction_type = 'REVERSAL';
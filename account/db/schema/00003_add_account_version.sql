@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- version is incremented by every balance change, so that the account events published after the commit
+-- can be ordered even if two transactions on the same account publish them in the reverse order of their commits.
+ALTER TABLE accounts ADD COLUMN version BIGINT NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE accounts DROP COLUMN version;
+-- +goose StatementEnd
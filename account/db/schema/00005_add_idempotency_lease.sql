@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- claim_id identifies the attempt holding a PENDING key, and lease_expires_at is when another attempt may take the
+-- key over if it is still PENDING, so that a claim abandoned by a crashed or stuck attempt doesn't block the key
+-- until it expires.
+ALTER TABLE idempotency_keys ADD COLUMN claim_id UUID;
+ALTER TABLE idempotency_keys ADD COLUMN lease_expires_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE idempotency_keys DROP COLUMN lease_expires_at;
+ALTER TABLE idempotency_keys DROP COLUMN claim_id;
+-- +goose StatementEnd
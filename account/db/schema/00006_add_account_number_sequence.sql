@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- serials of the account numbers, permuted and prefixed by the account service (see internal/accountnumber).
+-- A serial is never handed out twice, so account numbers can't collide. Serials of rolled back transactions are lost.
+CREATE SEQUENCE account_number_serial_seq AS bigint MINVALUE 0 MAXVALUE 9999999 START WITH 0 NO CYCLE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP SEQUENCE account_number_serial_seq;
+-- +goose StatementEnd
@@ -0,0 +1,53 @@
+-- +goose Up
+-- +goose StatementBegin
+-- account_products are what accounts are opened as. Their rules apply to the debits of the accounts:
+-- min_balance is the lowest balance a debit may leave, monthly_withdrawal_limit the number of debits allowed per
+-- calendar month (UTC, 0 for no limit), and lock_up_days how long after opening the account can't be debited at all.
+CREATE TABLE account_products (
+    code TEXT PRIMARY KEY,
+    account_type VARCHAR(30) NOT NULL CHECK (account_type IN ('CHECKING', 'SAVINGS', 'TERM_DEPOSIT')),
+    display_name TEXT NOT NULL,
+    min_balance BIGINT NOT NULL DEFAULT 0 CHECK (min_balance >= 0),
+    monthly_withdrawal_limit INT NOT NULL DEFAULT 0 CHECK (monthly_withdrawal_limit >= 0),
+    lock_up_days INT NOT NULL DEFAULT 0 CHECK (lock_up_days >= 0),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+
+    -- referenced by accounts along with the code, so that the type copied on an account is always the one of its product
+    UNIQUE (code, account_type)
+);
+
+CREATE TRIGGER trigger_update_timestamp_account_products
+BEFORE UPDATE ON account_products
+FOR EACH ROW EXECUTE FUNCTION update_timestamp();
+
+INSERT INTO account_products (code, account_type, display_name, min_balance, monthly_withdrawal_limit, lock_up_days)
+VALUES
+    ('CHECKING', 'CHECKING', 'Everyday Checking', 0, 0, 0),
+    ('SAVINGS', 'SAVINGS', 'Savings', 0, 6, 0),
+    ('TERM_DEPOSIT_12M', 'TERM_DEPOSIT', '12-Month Term Deposit', 1000, 0, 365);
+
+-- the accounts opened before products existed are checking accounts.
+-- matures_at is when the lock-up period of a term deposit ends, fixed when the account is opened.
+ALTER TABLE accounts ADD COLUMN product_code TEXT NOT NULL DEFAULT 'CHECKING';
+ALTER TABLE accounts ADD COLUMN account_type VARCHAR(30) NOT NULL DEFAULT 'CHECKING';
+ALTER TABLE accounts ADD COLUMN matures_at TIMESTAMPTZ;
+ALTER TABLE accounts ALTER COLUMN product_code DROP DEFAULT;
+ALTER TABLE accounts ALTER COLUMN account_type DROP DEFAULT;
+ALTER TABLE accounts ADD CONSTRAINT fk_accounts_product
+    FOREIGN KEY (product_code, account_type) REFERENCES account_products (code, account_type);
+
+-- the withdrawal limit counts the debits of an account since the start of the month
+CREATE INDEX idx_transaction_account_id_created_at ON transactions (account_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_transaction_account_id_created_at;
+ALTER TABLE accounts DROP CONSTRAINT fk_accounts_product;
+ALTER TABLE accounts DROP COLUMN matures_at;
+ALTER TABLE accounts DROP COLUMN account_type;
+ALTER TABLE accounts DROP COLUMN product_code;
+DROP TRIGGER trigger_update_timestamp_account_products ON account_products;
+DROP TABLE account_products;
+-- +goose StatementEnd
@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+-- status is the state of the account in its lifecycle, see model.CanTransition:
+-- PENDING accounts are awaiting approval, FROZEN ones can't be debited, DORMANT ones were flagged by the janitor after
+-- a long inactivity, and CLOSED ones keep their row and their transactions but can't be used anymore.
+ALTER TABLE accounts ADD COLUMN status VARCHAR(30) NOT NULL DEFAULT 'ACTIVE'
+    CHECK (status IN ('PENDING', 'ACTIVE', 'FROZEN', 'DORMANT', 'CLOSED'));
+ALTER TABLE accounts ADD COLUMN status_changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+ALTER TABLE accounts ADD COLUMN closure_reason TEXT;
+ALTER TABLE accounts ADD COLUMN closed_at TIMESTAMPTZ;
+-- time of the last transaction of the account, or of its opening
+ALTER TABLE accounts ADD COLUMN last_activity_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+UPDATE accounts SET last_activity_at = COALESCE(
+    (SELECT MAX(created_at) FROM transactions WHERE transactions.account_id = accounts.id),
+    accounts.created_at,
+    NOW()
+);
+ALTER TABLE accounts ADD CONSTRAINT chk_accounts_closed
+    CHECK ((status = 'CLOSED') = (closed_at IS NOT NULL) AND (closed_at IS NULL OR balance = 0));
+
+-- the janitor looks up the active accounts inactive for too long
+CREATE INDEX idx_account_last_activity_at ON accounts (last_activity_at) WHERE status = 'ACTIVE';
+
+-- accounts are closed rather than deleted, and deleting one must not destroy its history either
+ALTER TABLE transactions DROP CONSTRAINT transactions_account_id_fkey;
+ALTER TABLE transactions ADD CONSTRAINT transactions_account_id_fkey
+    FOREIGN KEY (account_id) REFERENCES accounts(id) ON DELETE RESTRICT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE transactions DROP CONSTRAINT transactions_account_id_fkey;
+ALTER TABLE transactions ADD CONSTRAINT transactions_account_id_fkey
+    FOREIGN KEY (account_id) REFERENCES accounts(id) ON DELETE CASCADE;
+DROP INDEX idx_account_last_activity_at;
+ALTER TABLE accounts DROP CONSTRAINT chk_accounts_closed;
+ALTER TABLE accounts DROP COLUMN last_activity_at;
+ALTER TABLE accounts DROP COLUMN closed_at;
+ALTER TABLE accounts DROP COLUMN closure_reason;
+ALTER TABLE accounts DROP COLUMN status_changed_at;
+ALTER TABLE accounts DROP COLUMN status;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+-- currency is the ISO 4217 code of the amounts of an account and of its transactions, which are minor units of it
+-- (see package money). The accounts and transactions that existed before currencies are in USD.
+ALTER TABLE accounts ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD' CHECK (currency ~ '^[A-Z]{3}$');
+ALTER TABLE accounts ALTER COLUMN currency DROP DEFAULT;
+ALTER TABLE transactions ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD' CHECK (currency ~ '^[A-Z]{3}$');
+ALTER TABLE transactions ALTER COLUMN currency DROP DEFAULT;
+
+-- a transaction is always in the currency of its account
+ALTER TABLE accounts ADD CONSTRAINT uq_accounts_id_currency UNIQUE (id, currency);
+ALTER TABLE transactions ADD CONSTRAINT fk_transactions_account_currency
+    FOREIGN KEY (account_id, currency) REFERENCES accounts (id, currency) ON DELETE RESTRICT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE transactions DROP CONSTRAINT fk_transactions_account_currency;
+ALTER TABLE accounts DROP CONSTRAINT uq_accounts_id_currency;
+ALTER TABLE transactions DROP COLUMN currency;
+ALTER TABLE accounts DROP COLUMN currency;
+-- +goose StatementEnd
@@ -0,0 +1,77 @@
+-- +goose Up
+-- +goose StatementBegin
+-- INTEREST transactions credit the interest accrued by an account, see package interest
+ALTER TABLE transactions DROP CONSTRAINT transactions_transaction_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_transaction_type_check
+    CHECK (transaction_type IN ('CREDIT', 'DEBIT', 'TRANSFER_DEBIT', 'TRANSFER_CREDIT', 'INTEREST'));
+
+-- day_count is the day-count convention interest accrues with
+ALTER TABLE account_products ADD COLUMN day_count VARCHAR(10) NOT NULL DEFAULT 'ACT/365'
+    CHECK (day_count IN ('ACT/365', '30/360'));
+UPDATE account_products SET day_count = '30/360' WHERE account_type = 'TERM_DEPOSIT';
+
+-- product_interest_rates is the history of the annual rates of the products (0.025 for 2.5%): a rate applies from
+-- effective_from until the next one of the same product, so that changing a rate doesn't change the interest already
+-- accrued. Products without any rate, such as checking accounts, earn no interest.
+CREATE TABLE product_interest_rates (
+    product_code TEXT NOT NULL REFERENCES account_products (code),
+    effective_from DATE NOT NULL,
+    annual_rate NUMERIC(12, 8) NOT NULL CHECK (annual_rate >= 0),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+
+    PRIMARY KEY (product_code, effective_from)
+);
+
+INSERT INTO product_interest_rates (product_code, effective_from, annual_rate)
+VALUES
+    ('SAVINGS', CURRENT_DATE, 0.02),
+    ('TERM_DEPOSIT_12M', CURRENT_DATE, 0.045);
+
+-- interest_accrued_through is the last day (UTC) interest accrued for, NULL if none did yet: new accounts accrue from
+-- the day they are opened, and the existing ones from today on.
+ALTER TABLE accounts ADD COLUMN interest_accrued_through DATE;
+UPDATE accounts SET interest_accrued_through = CURRENT_DATE - 1;
+
+-- interest_accruals is the interest earned by an account every day, in fractions of minor units of its currency
+CREATE TABLE interest_accruals (
+    account_id UUID NOT NULL REFERENCES accounts (id) ON DELETE RESTRICT,
+    accrual_date DATE NOT NULL,
+    eod_balance BIGINT NOT NULL,
+    annual_rate NUMERIC(12, 8) NOT NULL,
+    amount NUMERIC(30, 12) NOT NULL CHECK (amount >= 0),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+
+    PRIMARY KEY (account_id, accrual_date)
+);
+
+-- interest_postings are the monthly postings of the accrued interest. The primary key makes a period posted at most
+-- once. accrued is the interest of the period plus the remainder carried from the previous one, posted the whole minor
+-- units of it, and carried the rest.
+CREATE TABLE interest_postings (
+    account_id UUID NOT NULL REFERENCES accounts (id) ON DELETE RESTRICT,
+    period DATE NOT NULL, -- first day of the month
+    accrued NUMERIC(30, 12) NOT NULL,
+    posted BIGINT NOT NULL CHECK (posted >= 0),
+    carried NUMERIC(30, 12) NOT NULL CHECK (carried >= 0),
+    -- the INTEREST transaction, NULL if nothing was posted
+    transaction_id UUID UNIQUE REFERENCES transactions (id) DEFERRABLE INITIALLY DEFERRED,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+
+    PRIMARY KEY (account_id, period),
+    CHECK ((posted > 0) = (transaction_id IS NOT NULL))
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE interest_postings;
+DROP TABLE interest_accruals;
+ALTER TABLE accounts DROP COLUMN interest_accrued_through;
+DROP TABLE product_interest_rates;
+ALTER TABLE account_products DROP COLUMN day_count;
+-- kept as credits, which they are, so that the balances still add up
+UPDATE transactions SET transaction_type = 'CREDIT' WHERE transaction_type = 'INTEREST';
+ALTER TABLE transactions DROP CONSTRAINT transactions_transaction_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_transaction_type_check
+    CHECK (transaction_type IN ('CREDIT', 'DEBIT', 'TRANSFER_DEBIT', 'TRANSFER_CREDIT'));
+-- +goose StatementEnd
@@ -0,0 +1,86 @@
+-- +goose Up
+-- +goose StatementBegin
+-- FEE transactions charge a fee, see package fee, and FEE_REVERSAL ones refund a fee reversed by the support staff
+ALTER TABLE transactions DROP CONSTRAINT transactions_transaction_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_transaction_type_check
+    CHECK (transaction_type IN ('CREDIT', 'DEBIT', 'TRANSFER_DEBIT', 'TRANSFER_CREDIT', 'INTEREST', 'FEE', 'FEE_REVERSAL'));
+
+-- overdraft_limit is how far below zero the debits may take the balance. Products with an overdraft have no minimum
+-- balance.
+ALTER TABLE account_products ADD COLUMN overdraft_limit BIGINT NOT NULL DEFAULT 0 CHECK (overdraft_limit >= 0);
+ALTER TABLE account_products ADD CONSTRAINT chk_account_products_overdraft CHECK (overdraft_limit = 0 OR min_balance = 0);
+
+-- fee_rules are the fees charged on the accounts of a product, or of every product if product_code is NULL, in
+-- currency. When trigger happens, the fee is fixed_amount plus percentage_bps of the base amount of the trigger, at
+-- least min_amount and at most max_amount if set. It is waived if the balance of the account is at least
+-- waive_balance_at_least. Amounts are minor units of currency.
+CREATE TABLE fee_rules (
+    code TEXT PRIMARY KEY,
+    trigger VARCHAR(30) NOT NULL CHECK (trigger IN ('MONTHLY_MAINTENANCE', 'OVERDRAFT', 'TRANSFER')),
+    product_code TEXT REFERENCES account_products (code),
+    currency CHAR(3) NOT NULL CHECK (currency ~ '^[A-Z]{3}$'),
+    fixed_amount BIGINT NOT NULL DEFAULT 0 CHECK (fixed_amount >= 0),
+    percentage_bps INT NOT NULL DEFAULT 0 CHECK (percentage_bps >= 0),
+    min_amount BIGINT NOT NULL DEFAULT 0 CHECK (min_amount >= 0),
+    max_amount BIGINT CHECK (max_amount >= min_amount),
+    waive_balance_at_least BIGINT,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE TRIGGER trigger_update_timestamp_fee_rules
+BEFORE UPDATE ON fee_rules
+FOR EACH ROW EXECUTE FUNCTION update_timestamp();
+
+INSERT INTO fee_rules (code, trigger, product_code, currency, fixed_amount, percentage_bps, min_amount, max_amount, waive_balance_at_least)
+VALUES
+    ('CHECKING_MAINTENANCE_USD', 'MONTHLY_MAINTENANCE', 'CHECKING', 'USD', 500, 0, 0, NULL, 150000),
+    ('OVERDRAFT_USD', 'OVERDRAFT', NULL, 'USD', 2500, 0, 0, NULL, NULL),
+    ('TRANSFER_USD', 'TRANSFER', NULL, 'USD', 25, 10, 0, 500, 500000);
+
+-- caused_by is the transaction a FEE was charged for, NULL for the monthly fees, or the FEE a FEE_REVERSAL refunds.
+-- fee_rule is the rule of a FEE, and memo is free text about the transaction, such as the reason of a fee reversal.
+ALTER TABLE transactions ADD COLUMN caused_by UUID REFERENCES transactions (id);
+ALTER TABLE transactions ADD COLUMN fee_rule TEXT REFERENCES fee_rules (code);
+ALTER TABLE transactions ADD COLUMN memo TEXT;
+ALTER TABLE transactions ADD CONSTRAINT chk_transactions_fee
+    CHECK ((transaction_type = 'FEE') = (fee_rule IS NOT NULL)
+           AND (transaction_type <> 'FEE_REVERSAL' OR caused_by IS NOT NULL));
+CREATE INDEX idx_transaction_caused_by ON transactions (caused_by);
+-- a fee is refunded at most once
+CREATE UNIQUE INDEX uq_transactions_fee_reversal ON transactions (caused_by) WHERE transaction_type = 'FEE_REVERSAL';
+
+-- maintenance_fee_periods records the months a monthly fee was charged for, so that it is charged at most once per
+-- month. transaction_id is NULL if the fee was waived.
+CREATE TABLE maintenance_fee_periods (
+    account_id UUID NOT NULL REFERENCES accounts (id) ON DELETE RESTRICT,
+    fee_rule TEXT NOT NULL REFERENCES fee_rules (code),
+    period DATE NOT NULL, -- first day of the month
+    transaction_id UUID UNIQUE REFERENCES transactions (id) DEFERRABLE INITIALLY DEFERRED,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+
+    PRIMARY KEY (account_id, fee_rule, period)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE maintenance_fee_periods;
+DROP INDEX uq_transactions_fee_reversal;
+DROP INDEX idx_transaction_caused_by;
+ALTER TABLE transactions DROP CONSTRAINT chk_transactions_fee;
+ALTER TABLE transactions DROP COLUMN memo;
+ALTER TABLE transactions DROP COLUMN fee_rule;
+ALTER TABLE transactions DROP COLUMN caused_by;
+DROP TRIGGER trigger_update_timestamp_fee_rules ON fee_rules;
+DROP TABLE fee_rules;
+ALTER TABLE account_products DROP CONSTRAINT chk_account_products_overdraft;
+ALTER TABLE account_products DROP COLUMN overdraft_limit;
+-- kept as debits and credits, which they are, so that the balances still add up
+UPDATE transactions SET transaction_type = 'DEBIT' WHERE transaction_type = 'FEE';
+UPDATE transactions SET transaction_type = 'CREDIT' WHERE transaction_type = 'FEE_REVERSAL';
+ALTER TABLE transactions DROP CONSTRAINT transactions_transaction_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_transaction_type_check
+    CHECK (transaction_type IN ('CREDIT', 'DEBIT', 'TRANSFER_DEBIT', 'TRANSFER_CREDIT', 'INTEREST'));
+-- +goose StatementEnd
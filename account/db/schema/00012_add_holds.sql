@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+-- held is the sum of the ACTIVE holds of the account: the available balance is balance - held
+ALTER TABLE accounts ADD COLUMN held BIGINT NOT NULL DEFAULT 0 CHECK (held >= 0);
+
+-- holds reserve amount of an account until they are captured, voided or expire. Capturing a hold posts a transaction
+-- of transaction_type for the captured amount, at most amount, and releases the rest.
+CREATE TABLE holds (
+    id UUID PRIMARY KEY,
+    account_id UUID NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    currency CHAR(3) NOT NULL,
+    transaction_type VARCHAR(20) NOT NULL CHECK (transaction_type IN ('DEBIT', 'TRANSFER_DEBIT')),
+    transfer_id UUID,
+    status VARCHAR(20) NOT NULL DEFAULT 'ACTIVE' CHECK (status IN ('ACTIVE', 'CAPTURED', 'VOIDED', 'EXPIRED')),
+    captured BIGINT NOT NULL DEFAULT 0 CHECK (captured >= 0 AND captured <= amount),
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+
+    -- a hold is always in the currency of its account
+    FOREIGN KEY (account_id, currency) REFERENCES accounts (id, currency) ON DELETE RESTRICT,
+    CHECK ((status = 'CAPTURED') = (captured > 0))
+);
+
+CREATE INDEX idx_holds_account_id ON holds (account_id);
+CREATE INDEX idx_holds_expires_at ON holds (expires_at) WHERE status = 'ACTIVE';
+
+CREATE TRIGGER trigger_update_timestamp_holds
+BEFORE UPDATE ON holds
+FOR EACH ROW EXECUTE FUNCTION update_timestamp();
+
+-- hold_id is the hold a transaction captured, which it captures at most once
+ALTER TABLE transactions ADD COLUMN hold_id UUID UNIQUE REFERENCES holds (id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE transactions DROP COLUMN hold_id;
+DROP TRIGGER trigger_update_timestamp_holds ON holds;
+DROP TABLE holds;
+ALTER TABLE accounts DROP COLUMN held;
+-- +goose StatementEnd
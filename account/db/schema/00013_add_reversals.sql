@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- REVERSAL transactions offset all or part of the transaction they reverse, caused_by, which is then REVERSED
+ALTER TABLE transactions DROP CONSTRAINT transactions_transaction_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_transaction_type_check
+    CHECK (transaction_type IN ('CREDIT', 'DEBIT', 'TRANSFER_DEBIT', 'TRANSFER_CREDIT', 'INTEREST', 'FEE', 'FEE_REVERSAL', 'REVERSAL'));
+ALTER TABLE transactions ADD CONSTRAINT chk_transactions_reversal
+    CHECK (transaction_type <> 'REVERSAL' OR caused_by IS NOT NULL);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE transactions DROP CONSTRAINT chk_transactions_reversal;
+-- kept as debits and credits, which they are, so that the balances still add up
+UPDATE transactions SET transaction_type = CASE WHEN amount > 0 THEN 'CREDIT' ELSE 'DEBIT' END
+WHERE transaction_type = 'REVERSAL';
+ALTER TABLE transactions DROP CONSTRAINT transactions_transaction_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_transaction_type_check
+    CHECK (transaction_type IN ('CREDIT', 'DEBIT', 'TRANSFER_DEBIT', 'TRANSFER_CREDIT', 'INTEREST', 'FEE', 'FEE_REVERSAL'));
+-- +goose StatementEnd
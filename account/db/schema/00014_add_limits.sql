@@ -0,0 +1,47 @@
+-- +goose Up
+-- +goose StatementBegin
+-- the limits of the debits of the accounts of a product, in minor units of the currency of the account, 0 for no
+-- limit: max_single_debit per debit, daily_debit_total and daily_debit_count per calendar day (UTC), and
+-- monthly_debit_total per calendar month (UTC)
+ALTER TABLE account_products ADD COLUMN max_single_debit BIGINT NOT NULL DEFAULT 0 CHECK (max_single_debit >= 0);
+ALTER TABLE account_products ADD COLUMN daily_debit_total BIGINT NOT NULL DEFAULT 0 CHECK (daily_debit_total >= 0);
+ALTER TABLE account_products ADD COLUMN daily_debit_count INT NOT NULL DEFAULT 0 CHECK (daily_debit_count >= 0);
+ALTER TABLE account_products ADD COLUMN monthly_debit_total BIGINT NOT NULL DEFAULT 0 CHECK (monthly_debit_total >= 0);
+
+UPDATE account_products
+SET max_single_debit = 1000000, daily_debit_total = 2000000, daily_debit_count = 50, monthly_debit_total = 10000000
+WHERE code = 'CHECKING';
+UPDATE account_products
+SET max_single_debit = 500000, daily_debit_total = 1000000, daily_debit_count = 10, monthly_debit_total = 5000000
+WHERE code = 'SAVINGS';
+
+-- account_limits override the limits of the product of an account: the ACCOUNT ones are set by the support staff and
+-- replace the limits of the product, the USER ones are set by the owner of the account and can only lower them.
+-- NULL keeps the limit of the level below.
+CREATE TABLE account_limits (
+    account_id UUID NOT NULL REFERENCES accounts (id) ON DELETE CASCADE,
+    level VARCHAR(10) NOT NULL CHECK (level IN ('ACCOUNT', 'USER')),
+    max_single_debit BIGINT CHECK (max_single_debit > 0),
+    daily_debit_total BIGINT CHECK (daily_debit_total > 0),
+    daily_debit_count INT CHECK (daily_debit_count > 0),
+    monthly_debit_total BIGINT CHECK (monthly_debit_total > 0),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+
+    PRIMARY KEY (account_id, level)
+);
+
+CREATE TRIGGER trigger_update_timestamp_account_limits
+BEFORE UPDATE ON account_limits
+FOR EACH ROW EXECUTE FUNCTION update_timestamp();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER trigger_update_timestamp_account_limits ON account_limits;
+DROP TABLE account_limits;
+ALTER TABLE account_products DROP COLUMN monthly_debit_total;
+ALTER TABLE account_products DROP COLUMN daily_debit_count;
+ALTER TABLE account_products DROP COLUMN daily_debit_total;
+ALTER TABLE account_products DROP COLUMN max_single_debit;
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+-- the next serial of the account numbers of each prefix (see internal/accountnumber). Every branch has its own
+-- 10,000,000 serials, instead of sharing the ones of account_number_serial_seq. Serials of rolled back transactions
+-- are handed out again, since the row is locked until the account is created.
+CREATE TABLE account_number_serials (
+    prefix INT PRIMARY KEY CHECK (prefix BETWEEN 1000 AND 9999),
+    next_serial BIGINT NOT NULL CHECK (next_serial BETWEEN 0 AND 10000000)
+);
+
+-- the serials of the sequence were shared by every prefix, so its next value is above every serial already used
+INSERT INTO account_number_serials (prefix, next_serial)
+SELECT DISTINCT account_number / 100000000,
+    (SELECT CASE WHEN is_called THEN last_value + 1 ELSE last_value END FROM account_number_serial_seq)
+FROM accounts
+WHERE account_number >= 100000000000;
+
+DROP SEQUENCE account_number_serial_seq;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE SEQUENCE account_number_serial_seq AS bigint MINVALUE 0 MAXVALUE 9999999 START WITH 0 NO CYCLE;
+SELECT setval('account_number_serial_seq', max(next_serial), false)
+FROM account_number_serials
+HAVING max(next_serial) IS NOT NULL;
+DROP TABLE account_number_serials;
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+-- user_limits cap the debits of all the accounts of a user in currency together, on top of the limits of each account,
+-- in minor units of currency. They are set by the user or the support staff. NULL is no limit.
+CREATE TABLE user_limits (
+    user_id UUID NOT NULL,
+    currency CHAR(3) NOT NULL CHECK (currency ~ '^[A-Z]{3}$'),
+    max_single_debit BIGINT CHECK (max_single_debit > 0),
+    daily_debit_total BIGINT CHECK (daily_debit_total > 0),
+    daily_debit_count INT CHECK (daily_debit_count > 0),
+    monthly_debit_total BIGINT CHECK (monthly_debit_total > 0),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+
+    PRIMARY KEY (user_id, currency)
+);
+
+CREATE TRIGGER trigger_update_timestamp_user_limits
+BEFORE UPDATE ON user_limits
+FOR EACH ROW EXECUTE FUNCTION update_timestamp();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER trigger_update_timestamp_user_limits ON user_limits;
+DROP TABLE user_limits;
+-- +goose StatementEnd
@@ -7,15 +7,17 @@ package sqlc
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 const addToAccountBalance = `-- name: AddToAccountBalance :one
 UPDATE accounts
-SET balance = balance + $1
+SET balance = balance + $1, version = version + 1, last_activity_at = NOW()
 WHERE account_number = $2
-RETURNING id, user_id, account_number, balance, created_at, updated_at
+RETURNING id, user_id, account_number, balance, created_at, updated_at, version, product_code, account_type, matures_at, status, status_changed_at, closure_reason, closed_at, last_activity_at, currency, interest_accrued_through, held
 `
 
 type AddToAccountBalanceParams struct {
@@ -33,21 +35,117 @@ func (q *Queries) AddToAccountBalance(ctx context.Context, arg AddToAccountBalan
 		&i.Balance,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
+		&i.ProductCode,
+		&i.AccountType,
+		&i.MaturesAt,
+		&i.Status,
+		&i.StatusChangedAt,
+		&i.ClosureReason,
+		&i.ClosedAt,
+		&i.LastActivityAt,
+		&i.Currency,
+		&i.InterestAccruedThrough,
+		&i.Held,
+	)
+	return i, err
+}
+
+const addToAccountBalanceWithoutActivity = `-- name: AddToAccountBalanceWithoutActivity :one
+UPDATE accounts
+SET balance = balance + $1, version = version + 1
+WHERE id = $2
+RETURNING id, user_id, account_number, balance, created_at, updated_at, version, product_code, account_type, matures_at, status, status_changed_at, closure_reason, closed_at, last_activity_at, currency, interest_accrued_through, held
+`
+
+type AddToAccountBalanceWithoutActivityParams struct {
+	Amount int64     `json:"amount"`
+	ID     uuid.UUID `json:"id"`
+}
+
+// for the transactions the bank posts itself, such as interest and fees: unlike AddToAccountBalance, they don't count as
+// activity, so that they don't keep dormant accounts alive
+func (q *Queries) AddToAccountBalanceWithoutActivity(ctx context.Context, arg AddToAccountBalanceWithoutActivityParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, addToAccountBalanceWithoutActivity, arg.Amount, arg.ID)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.AccountNumber,
+		&i.Balance,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.ProductCode,
+		&i.AccountType,
+		&i.MaturesAt,
+		&i.Status,
+		&i.StatusChangedAt,
+		&i.ClosureReason,
+		&i.ClosedAt,
+		&i.LastActivityAt,
+		&i.Currency,
+		&i.InterestAccruedThrough,
+		&i.Held,
+	)
+	return i, err
+}
+
+const closeAccount = `-- name: CloseAccount :one
+UPDATE accounts
+SET status = 'CLOSED', status_changed_at = NOW(), closure_reason = $1, closed_at = NOW()
+WHERE id = $2 AND status = $3 AND balance = 0 AND held = 0
+RETURNING id, user_id, account_number, balance, created_at, updated_at, version, product_code, account_type, matures_at, status, status_changed_at, closure_reason, closed_at, last_activity_at, currency, interest_accrued_through, held
+`
+
+type CloseAccountParams struct {
+	ClosureReason sql.NullString `json:"closure_reason"`
+	ID            uuid.UUID      `json:"id"`
+	FromStatus    string         `json:"from_status"`
+}
+
+// only closes the account if it still has the status from_status, a zero balance and no active hold
+func (q *Queries) CloseAccount(ctx context.Context, arg CloseAccountParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, closeAccount, arg.ClosureReason, arg.ID, arg.FromStatus)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.AccountNumber,
+		&i.Balance,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.ProductCode,
+		&i.AccountType,
+		&i.MaturesAt,
+		&i.Status,
+		&i.StatusChangedAt,
+		&i.ClosureReason,
+		&i.ClosedAt,
+		&i.LastActivityAt,
+		&i.Currency,
+		&i.InterestAccruedThrough,
+		&i.Held,
 	)
 	return i, err
 }
 
 const createAccount = `-- name: CreateAccount :one
-INSERT INTO accounts (id, account_number, user_id, balance)
-VALUES ($1, $2, $3, $4)
-RETURNING id, user_id, account_number, balance, created_at, updated_at
+INSERT INTO accounts (id, account_number, user_id, balance, product_code, account_type, matures_at, currency)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id, user_id, account_number, balance, created_at, updated_at, version, product_code, account_type, matures_at, status, status_changed_at, closure_reason, closed_at, last_activity_at, currency, interest_accrued_through, held
 `
 
 type CreateAccountParams struct {
-	ID            uuid.UUID `json:"id"`
-	AccountNumber int64     `json:"account_number"`
-	UserID        uuid.UUID `json:"user_id"`
-	Balance       int64     `json:"balance"`
+	ID            uuid.UUID    `json:"id"`
+	AccountNumber int64        `json:"account_number"`
+	UserID        uuid.UUID    `json:"user_id"`
+	Balance       int64        `json:"balance"`
+	ProductCode   string       `json:"product_code"`
+	AccountType   string       `json:"account_type"`
+	MaturesAt     sql.NullTime `json:"matures_at"`
+	Currency      string       `json:"currency"`
 }
 
 func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error) {
@@ -56,6 +154,10 @@ func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (A
 		arg.AccountNumber,
 		arg.UserID,
 		arg.Balance,
+		arg.ProductCode,
+		arg.AccountType,
+		arg.MaturesAt,
+		arg.Currency,
 	)
 	var i Account
 	err := row.Scan(
@@ -65,6 +167,18 @@ func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (A
 		&i.Balance,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
+		&i.ProductCode,
+		&i.AccountType,
+		&i.MaturesAt,
+		&i.Status,
+		&i.StatusChangedAt,
+		&i.ClosureReason,
+		&i.ClosedAt,
+		&i.LastActivityAt,
+		&i.Currency,
+		&i.InterestAccruedThrough,
+		&i.Held,
 	)
 	return i, err
 }
@@ -72,16 +186,17 @@ func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (A
 const deleteAccountByAccountNumber = `-- name: DeleteAccountByAccountNumber :exec
 DELETE FROM accounts
 WHERE account_number = $1
-RETURNING id, user_id, account_number, balance, created_at, updated_at
 `
 
+// accounts are closed rather than deleted, this is only for the tests to clean up. It fails if the account has
+// transactions.
 func (q *Queries) DeleteAccountByAccountNumber(ctx context.Context, accountNumber int64) error {
 	_, err := q.db.ExecContext(ctx, deleteAccountByAccountNumber, accountNumber)
 	return err
 }
 
 const getAccountByAccountNumber = `-- name: GetAccountByAccountNumber :one
-SELECT id, user_id, account_number, balance, created_at, updated_at FROM accounts WHERE account_number = $1
+SELECT id, user_id, account_number, balance, created_at, updated_at, version, product_code, account_type, matures_at, status, status_changed_at, closure_reason, closed_at, last_activity_at, currency, interest_accrued_through, held FROM accounts WHERE account_number = $1
 `
 
 func (q *Queries) GetAccountByAccountNumber(ctx context.Context, accountNumber int64) (Account, error) {
@@ -94,12 +209,24 @@ func (q *Queries) GetAccountByAccountNumber(ctx context.Context, accountNumber i
 		&i.Balance,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
+		&i.ProductCode,
+		&i.AccountType,
+		&i.MaturesAt,
+		&i.Status,
+		&i.StatusChangedAt,
+		&i.ClosureReason,
+		&i.ClosedAt,
+		&i.LastActivityAt,
+		&i.Currency,
+		&i.InterestAccruedThrough,
+		&i.Held,
 	)
 	return i, err
 }
 
 const getAccountByID = `-- name: GetAccountByID :one
-SELECT id, user_id, account_number, balance, created_at, updated_at FROM accounts WHERE id = $1
+SELECT id, user_id, account_number, balance, created_at, updated_at, version, product_code, account_type, matures_at, status, status_changed_at, closure_reason, closed_at, last_activity_at, currency, interest_accrued_through, held FROM accounts WHERE id = $1
 `
 
 func (q *Queries) GetAccountByID(ctx context.Context, id uuid.UUID) (Account, error) {
@@ -112,12 +239,80 @@ func (q *Queries) GetAccountByID(ctx context.Context, id uuid.UUID) (Account, er
 		&i.Balance,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
+		&i.ProductCode,
+		&i.AccountType,
+		&i.MaturesAt,
+		&i.Status,
+		&i.StatusChangedAt,
+		&i.ClosureReason,
+		&i.ClosedAt,
+		&i.LastActivityAt,
+		&i.Currency,
+		&i.InterestAccruedThrough,
+		&i.Held,
+	)
+	return i, err
+}
+
+const getAccountByIDForUpdate = `-- name: GetAccountByIDForUpdate :one
+SELECT id, user_id, account_number, balance, created_at, updated_at, version, product_code, account_type, matures_at, status, status_changed_at, closure_reason, closed_at, last_activity_at, currency, interest_accrued_through, held FROM accounts WHERE id = $1 FOR UPDATE
+`
+
+func (q *Queries) GetAccountByIDForUpdate(ctx context.Context, id uuid.UUID) (Account, error) {
+	row := q.db.QueryRowContext(ctx, getAccountByIDForUpdate, id)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.AccountNumber,
+		&i.Balance,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.ProductCode,
+		&i.AccountType,
+		&i.MaturesAt,
+		&i.Status,
+		&i.StatusChangedAt,
+		&i.ClosureReason,
+		&i.ClosedAt,
+		&i.LastActivityAt,
+		&i.Currency,
+		&i.InterestAccruedThrough,
+		&i.Held,
+	)
+	return i, err
+}
+
+const getAccountProductByCode = `-- name: GetAccountProductByCode :one
+SELECT code, account_type, display_name, min_balance, monthly_withdrawal_limit, lock_up_days, created_at, updated_at, day_count, overdraft_limit, max_single_debit, daily_debit_total, daily_debit_count, monthly_debit_total FROM account_products WHERE code = $1
+`
+
+func (q *Queries) GetAccountProductByCode(ctx context.Context, code string) (AccountProduct, error) {
+	row := q.db.QueryRowContext(ctx, getAccountProductByCode, code)
+	var i AccountProduct
+	err := row.Scan(
+		&i.Code,
+		&i.AccountType,
+		&i.DisplayName,
+		&i.MinBalance,
+		&i.MonthlyWithdrawalLimit,
+		&i.LockUpDays,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DayCount,
+		&i.OverdraftLimit,
+		&i.MaxSingleDebit,
+		&i.DailyDebitTotal,
+		&i.DailyDebitCount,
+		&i.MonthlyDebitTotal,
 	)
 	return i, err
 }
 
 const getAccountsByUserID = `-- name: GetAccountsByUserID :many
-SELECT id, user_id, account_number, balance, created_at, updated_at FROM accounts WHERE user_id = $1
+SELECT id, user_id, account_number, balance, created_at, updated_at, version, product_code, account_type, matures_at, status, status_changed_at, closure_reason, closed_at, last_activity_at, currency, interest_accrued_through, held FROM accounts WHERE user_id = $1
 `
 
 func (q *Queries) GetAccountsByUserID(ctx context.Context, userID uuid.UUID) ([]Account, error) {
@@ -136,6 +331,18 @@ func (q *Queries) GetAccountsByUserID(ctx context.Context, userID uuid.UUID) ([]
 			&i.Balance,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Version,
+			&i.ProductCode,
+			&i.AccountType,
+			&i.MaturesAt,
+			&i.Status,
+			&i.StatusChangedAt,
+			&i.ClosureReason,
+			&i.ClosedAt,
+			&i.LastActivityAt,
+			&i.Currency,
+			&i.InterestAccruedThrough,
+			&i.Held,
 		); err != nil {
 			return nil, err
 		}
@@ -151,7 +358,7 @@ func (q *Queries) GetAccountsByUserID(ctx context.Context, userID uuid.UUID) ([]
 }
 
 const listAccounts = `-- name: ListAccounts :many
-SELECT id, user_id, account_number, balance, created_at, updated_at FROM accounts ORDER BY id LIMIT $1
+SELECT id, user_id, account_number, balance, created_at, updated_at, version, product_code, account_type, matures_at, status, status_changed_at, closure_reason, closed_at, last_activity_at, currency, interest_accrued_through, held FROM accounts ORDER BY id LIMIT $1
 `
 
 func (q *Queries) ListAccounts(ctx context.Context, limit int32) ([]Account, error) {
@@ -170,6 +377,18 @@ func (q *Queries) ListAccounts(ctx context.Context, limit int32) ([]Account, err
 			&i.Balance,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Version,
+			&i.ProductCode,
+			&i.AccountType,
+			&i.MaturesAt,
+			&i.Status,
+			&i.StatusChangedAt,
+			&i.ClosureReason,
+			&i.ClosedAt,
+			&i.LastActivityAt,
+			&i.Currency,
+			&i.InterestAccruedThrough,
+			&i.Held,
 		); err != nil {
 			return nil, err
 		}
@@ -183,3 +402,86 @@ func (q *Queries) ListAccounts(ctx context.Context, limit int32) ([]Account, err
 	}
 	return items, nil
 }
+
+const markDormantAccounts = `-- name: MarkDormantAccounts :execrows
+UPDATE accounts
+SET status = 'DORMANT', status_changed_at = NOW()
+WHERE id IN (
+    SELECT id FROM accounts
+    WHERE status = 'ACTIVE' AND last_activity_at < $1::timestamptz
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+)
+`
+
+type MarkDormantAccountsParams struct {
+	InactiveSince time.Time `json:"inactive_since"`
+	BatchSize     int32     `json:"batch_size"`
+}
+
+func (q *Queries) MarkDormantAccounts(ctx context.Context, arg MarkDormantAccountsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markDormantAccounts, arg.InactiveSince, arg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const nextAccountNumberSerial = `-- name: NextAccountNumberSerial :one
+INSERT INTO account_number_serials (prefix, next_serial)
+VALUES ($1::int, 1)
+ON CONFLICT (prefix) DO UPDATE
+SET next_serial = account_number_serials.next_serial + 1
+WHERE account_number_serials.next_serial < 10000000
+RETURNING (next_serial - 1)::bigint AS serial
+`
+
+// returns no row once the 10,000,000 serials of the prefix are used
+func (q *Queries) NextAccountNumberSerial(ctx context.Context, prefix int32) (int64, error) {
+	row := q.db.QueryRowContext(ctx, nextAccountNumberSerial, prefix)
+	var serial int64
+	err := row.Scan(&serial)
+	return serial, err
+}
+
+const updateAccountStatus = `-- name: UpdateAccountStatus :one
+UPDATE accounts
+SET status = $1, status_changed_at = NOW(), last_activity_at = NOW()
+WHERE id = $2 AND status = $3
+RETURNING id, user_id, account_number, balance, created_at, updated_at, version, product_code, account_type, matures_at, status, status_changed_at, closure_reason, closed_at, last_activity_at, currency, interest_accrued_through, held
+`
+
+type UpdateAccountStatusParams struct {
+	Status     string    `json:"status"`
+	ID         uuid.UUID `json:"id"`
+	FromStatus string    `json:"from_status"`
+}
+
+// only updates the account if it still has the status from_status, so that concurrent changes can't skip a check of
+// model.CanTransition. Changing the status counts as activity, so that a reactivated account doesn't go dormant again
+// right away.
+func (q *Queries) UpdateAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, updateAccountStatus, arg.Status, arg.ID, arg.FromStatus)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.AccountNumber,
+		&i.Balance,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.ProductCode,
+		&i.AccountType,
+		&i.MaturesAt,
+		&i.Status,
+		&i.StatusChangedAt,
+		&i.ClosureReason,
+		&i.ClosedAt,
+		&i.LastActivityAt,
+		&i.Currency,
+		&i.InterestAccruedThrough,
+		&i.Held,
+	)
+	return i, err
+}
@@ -0,0 +1,165 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: fees.sql
+
+package sqlc
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const createMaintenanceFeePeriod = `-- name: CreateMaintenanceFeePeriod :execrows
+INSERT INTO maintenance_fee_periods (account_id, fee_rule, period, transaction_id)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (account_id, fee_rule, period) DO NOTHING
+`
+
+type CreateMaintenanceFeePeriodParams struct {
+	AccountID     uuid.UUID     `json:"account_id"`
+	FeeRule       string        `json:"fee_rule"`
+	Period        time.Time     `json:"period"`
+	TransactionID uuid.NullUUID `json:"transaction_id"`
+}
+
+// does nothing if the fee was charged for the month already
+func (q *Queries) CreateMaintenanceFeePeriod(ctx context.Context, arg CreateMaintenanceFeePeriodParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, createMaintenanceFeePeriod,
+		arg.AccountID,
+		arg.FeeRule,
+		arg.Period,
+		arg.TransactionID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getFeeRuleByCode = `-- name: GetFeeRuleByCode :one
+SELECT code, trigger, product_code, currency, fixed_amount, percentage_bps, min_amount, max_amount, waive_balance_at_least, active, created_at, updated_at FROM fee_rules WHERE code = $1
+`
+
+func (q *Queries) GetFeeRuleByCode(ctx context.Context, code string) (FeeRule, error) {
+	row := q.db.QueryRowContext(ctx, getFeeRuleByCode, code)
+	var i FeeRule
+	err := row.Scan(
+		&i.Code,
+		&i.Trigger,
+		&i.ProductCode,
+		&i.Currency,
+		&i.FixedAmount,
+		&i.PercentageBps,
+		&i.MinAmount,
+		&i.MaxAmount,
+		&i.WaiveBalanceAtLeast,
+		&i.Active,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listFeeRules = `-- name: ListFeeRules :many
+SELECT code, trigger, product_code, currency, fixed_amount, percentage_bps, min_amount, max_amount, waive_balance_at_least, active, created_at, updated_at FROM fee_rules
+WHERE active
+  AND trigger = $1
+  AND currency = $2
+  AND (product_code IS NULL OR product_code = $3::text)
+ORDER BY code
+`
+
+type ListFeeRulesParams struct {
+	Trigger     string `json:"trigger"`
+	Currency    string `json:"currency"`
+	ProductCode string `json:"product_code"`
+}
+
+// the active rules of trigger that apply to the accounts of a product in a currency
+func (q *Queries) ListFeeRules(ctx context.Context, arg ListFeeRulesParams) ([]FeeRule, error) {
+	rows, err := q.db.QueryContext(ctx, listFeeRules, arg.Trigger, arg.Currency, arg.ProductCode)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FeeRule
+	for rows.Next() {
+		var i FeeRule
+		if err := rows.Scan(
+			&i.Code,
+			&i.Trigger,
+			&i.ProductCode,
+			&i.Currency,
+			&i.FixedAmount,
+			&i.PercentageBps,
+			&i.MinAmount,
+			&i.MaxAmount,
+			&i.WaiveBalanceAtLeast,
+			&i.Active,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMaintenanceFeesToCharge = `-- name: ListMaintenanceFeesToCharge :many
+SELECT a.id AS account_id, r.code AS fee_rule
+FROM accounts a
+JOIN fee_rules r ON r.trigger = 'MONTHLY_MAINTENANCE' AND r.active AND r.currency = a.currency
+    AND (r.product_code IS NULL OR r.product_code = a.product_code)
+WHERE a.status IN ('ACTIVE', 'FROZEN', 'DORMANT')
+  AND a.created_at < ($1::date + INTERVAL '1 month')
+  AND NOT EXISTS (
+    SELECT 1 FROM maintenance_fee_periods m
+    WHERE m.account_id = a.id AND m.fee_rule = r.code AND m.period = $1::date
+  )
+ORDER BY a.id, r.code
+LIMIT $2
+`
+
+type ListMaintenanceFeesToChargeParams struct {
+	Period    time.Time `json:"period"`
+	BatchSize int32     `json:"batch_size"`
+}
+
+type ListMaintenanceFeesToChargeRow struct {
+	AccountID uuid.UUID `json:"account_id"`
+	FeeRule   string    `json:"fee_rule"`
+}
+
+// the monthly fees not charged yet for the month starting on period, on the accounts opened before the month ended
+func (q *Queries) ListMaintenanceFeesToCharge(ctx context.Context, arg ListMaintenanceFeesToChargeParams) ([]ListMaintenanceFeesToChargeRow, error) {
+	rows, err := q.db.QueryContext(ctx, listMaintenanceFeesToCharge, arg.Period, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListMaintenanceFeesToChargeRow
+	for rows.Next() {
+		var i ListMaintenanceFeesToChargeRow
+		if err := rows.Scan(&i.AccountID, &i.FeeRule); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,218 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: holds.sql
+
+package sqlc
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const addToAccountHeld = `-- name: AddToAccountHeld :one
+UPDATE accounts
+SET held = held + $1
+WHERE id = $2
+RETURNING id, user_id, account_number, balance, created_at, updated_at, version, product_code, account_type, matures_at, status, status_changed_at, closure_reason, closed_at, last_activity_at, currency, interest_accrued_through, held
+`
+
+type AddToAccountHeldParams struct {
+	Amount int64     `json:"amount"`
+	ID     uuid.UUID `json:"id"`
+}
+
+// holds don't change the balance, so they don't change the version of the account either
+func (q *Queries) AddToAccountHeld(ctx context.Context, arg AddToAccountHeldParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, addToAccountHeld, arg.Amount, arg.ID)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.AccountNumber,
+		&i.Balance,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.ProductCode,
+		&i.AccountType,
+		&i.MaturesAt,
+		&i.Status,
+		&i.StatusChangedAt,
+		&i.ClosureReason,
+		&i.ClosedAt,
+		&i.LastActivityAt,
+		&i.Currency,
+		&i.InterestAccruedThrough,
+		&i.Held,
+	)
+	return i, err
+}
+
+const countActiveHoldsSince = `-- name: CountActiveHoldsSince :one
+SELECT COUNT(*) FROM holds
+WHERE account_id = $1
+  AND status = 'ACTIVE'
+  AND created_at >= $2::timestamptz
+`
+
+type CountActiveHoldsSinceParams struct {
+	AccountID uuid.UUID `json:"account_id"`
+	Since     time.Time `json:"since"`
+}
+
+// the active holds are debits that aren't posted yet
+func (q *Queries) CountActiveHoldsSince(ctx context.Context, arg CountActiveHoldsSinceParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countActiveHoldsSince, arg.AccountID, arg.Since)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createHold = `-- name: CreateHold :one
+INSERT INTO holds (id, account_id, amount, currency, transaction_type, transfer_id, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, account_id, amount, currency, transaction_type, transfer_id, status, captured, expires_at, created_at, updated_at
+`
+
+type CreateHoldParams struct {
+	ID              uuid.UUID     `json:"id"`
+	AccountID       uuid.UUID     `json:"account_id"`
+	Amount          int64         `json:"amount"`
+	Currency        string        `json:"currency"`
+	TransactionType string        `json:"transaction_type"`
+	TransferID      uuid.NullUUID `json:"transfer_id"`
+	ExpiresAt       time.Time     `json:"expires_at"`
+}
+
+func (q *Queries) CreateHold(ctx context.Context, arg CreateHoldParams) (Hold, error) {
+	row := q.db.QueryRowContext(ctx, createHold,
+		arg.ID,
+		arg.AccountID,
+		arg.Amount,
+		arg.Currency,
+		arg.TransactionType,
+		arg.TransferID,
+		arg.ExpiresAt,
+	)
+	var i Hold
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.TransactionType,
+		&i.TransferID,
+		&i.Status,
+		&i.Captured,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getHoldByID = `-- name: GetHoldByID :one
+SELECT id, account_id, amount, currency, transaction_type, transfer_id, status, captured, expires_at, created_at, updated_at FROM holds WHERE id = $1
+`
+
+func (q *Queries) GetHoldByID(ctx context.Context, id uuid.UUID) (Hold, error) {
+	row := q.db.QueryRowContext(ctx, getHoldByID, id)
+	var i Hold
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.TransactionType,
+		&i.TransferID,
+		&i.Status,
+		&i.Captured,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listExpiredHolds = `-- name: ListExpiredHolds :many
+SELECT id, account_id, amount, currency, transaction_type, transfer_id, status, captured, expires_at, created_at, updated_at FROM holds
+WHERE status = 'ACTIVE' AND expires_at < $1::timestamptz
+ORDER BY expires_at
+LIMIT $2
+`
+
+type ListExpiredHoldsParams struct {
+	ExpiredBefore time.Time `json:"expired_before"`
+	BatchSize     int32     `json:"batch_size"`
+}
+
+func (q *Queries) ListExpiredHolds(ctx context.Context, arg ListExpiredHoldsParams) ([]Hold, error) {
+	rows, err := q.db.QueryContext(ctx, listExpiredHolds, arg.ExpiredBefore, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Hold
+	for rows.Next() {
+		var i Hold
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.Currency,
+			&i.TransactionType,
+			&i.TransferID,
+			&i.Status,
+			&i.Captured,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const settleHold = `-- name: SettleHold :one
+UPDATE holds
+SET status = $1, captured = $2
+WHERE id = $3 AND status = 'ACTIVE'
+RETURNING id, account_id, amount, currency, transaction_type, transfer_id, status, captured, expires_at, created_at, updated_at
+`
+
+type SettleHoldParams struct {
+	Status   string    `json:"status"`
+	Captured int64     `json:"captured"`
+	ID       uuid.UUID `json:"id"`
+}
+
+// only settles the hold if it is still ACTIVE, as CAPTURED, VOIDED or EXPIRED
+func (q *Queries) SettleHold(ctx context.Context, arg SettleHoldParams) (Hold, error) {
+	row := q.db.QueryRowContext(ctx, settleHold, arg.Status, arg.Captured, arg.ID)
+	var i Hold
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.TransactionType,
+		&i.TransferID,
+		&i.Status,
+		&i.Captured,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,309 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: interest.sql
+
+package sqlc
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const createInterestAccrual = `-- name: CreateInterestAccrual :exec
+INSERT INTO interest_accruals (account_id, accrual_date, eod_balance, annual_rate, amount)
+VALUES ($1, $2, $3, $4, $5)
+`
+
+type CreateInterestAccrualParams struct {
+	AccountID   uuid.UUID `json:"account_id"`
+	AccrualDate time.Time `json:"accrual_date"`
+	EodBalance  int64     `json:"eod_balance"`
+	AnnualRate  string    `json:"annual_rate"`
+	Amount      string    `json:"amount"`
+}
+
+func (q *Queries) CreateInterestAccrual(ctx context.Context, arg CreateInterestAccrualParams) error {
+	_, err := q.db.ExecContext(ctx, createInterestAccrual,
+		arg.AccountID,
+		arg.AccrualDate,
+		arg.EodBalance,
+		arg.AnnualRate,
+		arg.Amount,
+	)
+	return err
+}
+
+const createInterestPosting = `-- name: CreateInterestPosting :execrows
+INSERT INTO interest_postings (account_id, period, accrued, posted, carried, transaction_id)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (account_id, period) DO NOTHING
+`
+
+type CreateInterestPostingParams struct {
+	AccountID     uuid.UUID     `json:"account_id"`
+	Period        time.Time     `json:"period"`
+	Accrued       string        `json:"accrued"`
+	Posted        int64         `json:"posted"`
+	Carried       string        `json:"carried"`
+	TransactionID uuid.NullUUID `json:"transaction_id"`
+}
+
+// does nothing if the period is posted already
+func (q *Queries) CreateInterestPosting(ctx context.Context, arg CreateInterestPostingParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, createInterestPosting,
+		arg.AccountID,
+		arg.Period,
+		arg.Accrued,
+		arg.Posted,
+		arg.Carried,
+		arg.TransactionID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getEndOfDayBalance = `-- name: GetEndOfDayBalance :one
+SELECT (a.balance - COALESCE((
+    SELECT SUM(t.amount) FROM transactions t
+    WHERE t.account_id = a.id AND t.created_at >= $1::timestamptz
+), 0))::bigint AS balance
+FROM accounts a
+WHERE a.id = $2
+`
+
+type GetEndOfDayBalanceParams struct {
+	DayEnd    time.Time `json:"day_end"`
+	AccountID uuid.UUID `json:"account_id"`
+}
+
+// balance of a locked account at day_end, before the transactions created since
+func (q *Queries) GetEndOfDayBalance(ctx context.Context, arg GetEndOfDayBalanceParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getEndOfDayBalance, arg.DayEnd, arg.AccountID)
+	var balance int64
+	err := row.Scan(&balance)
+	return balance, err
+}
+
+const getInterestPostingsByAccountID = `-- name: GetInterestPostingsByAccountID :many
+SELECT account_id, period, accrued, posted, carried, transaction_id, created_at FROM interest_postings WHERE account_id = $1 ORDER BY period
+`
+
+func (q *Queries) GetInterestPostingsByAccountID(ctx context.Context, accountID uuid.UUID) ([]InterestPosting, error) {
+	rows, err := q.db.QueryContext(ctx, getInterestPostingsByAccountID, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []InterestPosting
+	for rows.Next() {
+		var i InterestPosting
+		if err := rows.Scan(
+			&i.AccountID,
+			&i.Period,
+			&i.Accrued,
+			&i.Posted,
+			&i.Carried,
+			&i.TransactionID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLastInterestPosting = `-- name: GetLastInterestPosting :one
+SELECT account_id, period, accrued, posted, carried, transaction_id, created_at FROM interest_postings
+WHERE account_id = $1 AND period < $2::date
+ORDER BY period DESC
+LIMIT 1
+`
+
+type GetLastInterestPostingParams struct {
+	AccountID uuid.UUID `json:"account_id"`
+	Period    time.Time `json:"period"`
+}
+
+func (q *Queries) GetLastInterestPosting(ctx context.Context, arg GetLastInterestPostingParams) (InterestPosting, error) {
+	row := q.db.QueryRowContext(ctx, getLastInterestPosting, arg.AccountID, arg.Period)
+	var i InterestPosting
+	err := row.Scan(
+		&i.AccountID,
+		&i.Period,
+		&i.Accrued,
+		&i.Posted,
+		&i.Carried,
+		&i.TransactionID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listAccountsToAccrue = `-- name: ListAccountsToAccrue :many
+SELECT a.id FROM accounts a
+WHERE a.status IN ('ACTIVE', 'FROZEN', 'DORMANT')
+  AND COALESCE(a.interest_accrued_through, (a.created_at AT TIME ZONE 'UTC')::date - 1) < $1::date
+  AND EXISTS (SELECT 1 FROM product_interest_rates r WHERE r.product_code = a.product_code)
+ORDER BY a.id
+LIMIT $2
+`
+
+type ListAccountsToAccrueParams struct {
+	Through   time.Time `json:"through"`
+	BatchSize int32     `json:"batch_size"`
+}
+
+// the accounts of a product with interest rates that can still accrue before through: interest accrues from the day
+// an account is approved until the day before it is closed.
+func (q *Queries) ListAccountsToAccrue(ctx context.Context, arg ListAccountsToAccrueParams) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountsToAccrue, arg.Through, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPeriodsToPost = `-- name: ListPeriodsToPost :many
+SELECT ia.account_id, date_trunc('month', ia.accrual_date)::date AS period
+FROM interest_accruals ia
+JOIN accounts a ON a.id = ia.account_id
+WHERE ia.accrual_date < $1::date
+  AND (a.status = 'CLOSED'
+       OR a.interest_accrued_through >= (date_trunc('month', ia.accrual_date) + INTERVAL '1 month')::date - 1)
+  AND NOT EXISTS (
+    SELECT 1 FROM interest_postings p
+    WHERE p.account_id = ia.account_id AND p.period = date_trunc('month', ia.accrual_date)::date
+  )
+GROUP BY ia.account_id, date_trunc('month', ia.accrual_date)::date
+ORDER BY period, ia.account_id
+LIMIT $2
+`
+
+type ListPeriodsToPostParams struct {
+	Before    time.Time `json:"before"`
+	BatchSize int32     `json:"batch_size"`
+}
+
+type ListPeriodsToPostRow struct {
+	AccountID uuid.UUID `json:"account_id"`
+	Period    time.Time `json:"period"`
+}
+
+// the months before sqlc.arg(before) with accruals of an account that aren't posted yet. A month is only posted once the
+// account has accrued for all its days, or was closed.
+func (q *Queries) ListPeriodsToPost(ctx context.Context, arg ListPeriodsToPostParams) ([]ListPeriodsToPostRow, error) {
+	rows, err := q.db.QueryContext(ctx, listPeriodsToPost, arg.Before, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPeriodsToPostRow
+	for rows.Next() {
+		var i ListPeriodsToPostRow
+		if err := rows.Scan(&i.AccountID, &i.Period); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listProductInterestRates = `-- name: ListProductInterestRates :many
+SELECT product_code, effective_from, annual_rate, created_at FROM product_interest_rates WHERE product_code = $1 ORDER BY effective_from
+`
+
+func (q *Queries) ListProductInterestRates(ctx context.Context, productCode string) ([]ProductInterestRate, error) {
+	rows, err := q.db.QueryContext(ctx, listProductInterestRates, productCode)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ProductInterestRate
+	for rows.Next() {
+		var i ProductInterestRate
+		if err := rows.Scan(
+			&i.ProductCode,
+			&i.EffectiveFrom,
+			&i.AnnualRate,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setInterestAccruedThrough = `-- name: SetInterestAccruedThrough :exec
+UPDATE accounts
+SET interest_accrued_through = $1::date
+WHERE id = $2
+`
+
+type SetInterestAccruedThroughParams struct {
+	Through time.Time `json:"through"`
+	ID      uuid.UUID `json:"id"`
+}
+
+func (q *Queries) SetInterestAccruedThrough(ctx context.Context, arg SetInterestAccruedThroughParams) error {
+	_, err := q.db.ExecContext(ctx, setInterestAccruedThrough, arg.Through, arg.ID)
+	return err
+}
+
+const sumInterestAccruals = `-- name: SumInterestAccruals :one
+SELECT COALESCE(SUM(amount), 0)::text AS amount FROM interest_accruals
+WHERE account_id = $1
+  AND accrual_date >= $2::date
+  AND accrual_date < ($2::date + INTERVAL '1 month')::date
+`
+
+type SumInterestAccrualsParams struct {
+	AccountID uuid.UUID `json:"account_id"`
+	Period    time.Time `json:"period"`
+}
+
+func (q *Queries) SumInterestAccruals(ctx context.Context, arg SumInterestAccrualsParams) (string, error) {
+	row := q.db.QueryRowContext(ctx, sumInterestAccruals, arg.AccountID, arg.Period)
+	var amount string
+	err := row.Scan(&amount)
+	return amount, err
+}
@@ -0,0 +1,302 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: limits.sql
+
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const createUserLimitsIfNotExists = `-- name: CreateUserLimitsIfNotExists :exec
+INSERT INTO user_limits (user_id, currency) VALUES ($1, $2)
+ON CONFLICT (user_id, currency) DO NOTHING
+`
+
+type CreateUserLimitsIfNotExistsParams struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Currency string    `json:"currency"`
+}
+
+// the limits of a user who set none have no limit, so that there is always a row to lock
+func (q *Queries) CreateUserLimitsIfNotExists(ctx context.Context, arg CreateUserLimitsIfNotExistsParams) error {
+	_, err := q.db.ExecContext(ctx, createUserLimitsIfNotExists, arg.UserID, arg.Currency)
+	return err
+}
+
+const getUserLimits = `-- name: GetUserLimits :one
+SELECT user_id, currency, max_single_debit, daily_debit_total, daily_debit_count, monthly_debit_total, created_at, updated_at FROM user_limits WHERE user_id = $1 AND currency = $2
+`
+
+type GetUserLimitsParams struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Currency string    `json:"currency"`
+}
+
+func (q *Queries) GetUserLimits(ctx context.Context, arg GetUserLimitsParams) (UserLimit, error) {
+	row := q.db.QueryRowContext(ctx, getUserLimits, arg.UserID, arg.Currency)
+	var i UserLimit
+	err := row.Scan(
+		&i.UserID,
+		&i.Currency,
+		&i.MaxSingleDebit,
+		&i.DailyDebitTotal,
+		&i.DailyDebitCount,
+		&i.MonthlyDebitTotal,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getUserLimitsForUpdate = `-- name: GetUserLimitsForUpdate :one
+SELECT user_id, currency, max_single_debit, daily_debit_total, daily_debit_count, monthly_debit_total, created_at, updated_at FROM user_limits WHERE user_id = $1 AND currency = $2 FOR UPDATE
+`
+
+type GetUserLimitsForUpdateParams struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Currency string    `json:"currency"`
+}
+
+func (q *Queries) GetUserLimitsForUpdate(ctx context.Context, arg GetUserLimitsForUpdateParams) (UserLimit, error) {
+	row := q.db.QueryRowContext(ctx, getUserLimitsForUpdate, arg.UserID, arg.Currency)
+	var i UserLimit
+	err := row.Scan(
+		&i.UserID,
+		&i.Currency,
+		&i.MaxSingleDebit,
+		&i.DailyDebitTotal,
+		&i.DailyDebitCount,
+		&i.MonthlyDebitTotal,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listAccountLimits = `-- name: ListAccountLimits :many
+SELECT account_id, level, max_single_debit, daily_debit_total, daily_debit_count, monthly_debit_total, created_at, updated_at FROM account_limits WHERE account_id = $1
+`
+
+func (q *Queries) ListAccountLimits(ctx context.Context, accountID uuid.UUID) ([]AccountLimit, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountLimits, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AccountLimit
+	for rows.Next() {
+		var i AccountLimit
+		if err := rows.Scan(
+			&i.AccountID,
+			&i.Level,
+			&i.MaxSingleDebit,
+			&i.DailyDebitTotal,
+			&i.DailyDebitCount,
+			&i.MonthlyDebitTotal,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const sumActiveHoldsSince = `-- name: SumActiveHoldsSince :one
+SELECT COALESCE(SUM(amount), 0)::bigint FROM holds
+WHERE account_id = $1
+  AND status = 'ACTIVE'
+  AND created_at >= $2::timestamptz
+`
+
+type SumActiveHoldsSinceParams struct {
+	AccountID uuid.UUID `json:"account_id"`
+	Since     time.Time `json:"since"`
+}
+
+func (q *Queries) SumActiveHoldsSince(ctx context.Context, arg SumActiveHoldsSinceParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, sumActiveHoldsSince, arg.AccountID, arg.Since)
+	var column_1 int64
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const sumUserActiveHoldsSince = `-- name: SumUserActiveHoldsSince :one
+SELECT COALESCE(SUM(h.amount), 0)::bigint AS total, COUNT(*) AS count FROM holds h
+JOIN accounts a ON a.id = h.account_id
+WHERE a.user_id = $1
+  AND a.currency = $2
+  AND h.status = 'ACTIVE'
+  AND h.created_at >= $3::timestamptz
+`
+
+type SumUserActiveHoldsSinceParams struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Currency string    `json:"currency"`
+	Since    time.Time `json:"since"`
+}
+
+type SumUserActiveHoldsSinceRow struct {
+	Total int64 `json:"total"`
+	Count int64 `json:"count"`
+}
+
+func (q *Queries) SumUserActiveHoldsSince(ctx context.Context, arg SumUserActiveHoldsSinceParams) (SumUserActiveHoldsSinceRow, error) {
+	row := q.db.QueryRowContext(ctx, sumUserActiveHoldsSince, arg.UserID, arg.Currency, arg.Since)
+	var i SumUserActiveHoldsSinceRow
+	err := row.Scan(&i.Total, &i.Count)
+	return i, err
+}
+
+const sumUserWithdrawalsSince = `-- name: SumUserWithdrawalsSince :one
+SELECT COALESCE(SUM(-t.amount), 0)::bigint AS total, COUNT(*) AS count FROM transactions t
+JOIN accounts a ON a.id = t.account_id
+LEFT JOIN holds h ON h.id = t.hold_id
+WHERE a.user_id = $1
+  AND a.currency = $2
+  AND t.amount < 0
+  AND t.transaction_type IN ('DEBIT', 'TRANSFER_DEBIT', 'REVERSAL')
+  AND t.status <> 'FAILED'
+  AND COALESCE(h.created_at, t.created_at) >= $3::timestamptz
+`
+
+type SumUserWithdrawalsSinceParams struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Currency string    `json:"currency"`
+	Since    time.Time `json:"since"`
+}
+
+type SumUserWithdrawalsSinceRow struct {
+	Total int64 `json:"total"`
+	Count int64 `json:"count"`
+}
+
+// the withdrawals of SumWithdrawalsSince of all the accounts of the user in currency, and how many they are
+func (q *Queries) SumUserWithdrawalsSince(ctx context.Context, arg SumUserWithdrawalsSinceParams) (SumUserWithdrawalsSinceRow, error) {
+	row := q.db.QueryRowContext(ctx, sumUserWithdrawalsSince, arg.UserID, arg.Currency, arg.Since)
+	var i SumUserWithdrawalsSinceRow
+	err := row.Scan(&i.Total, &i.Count)
+	return i, err
+}
+
+const sumWithdrawalsSince = `-- name: SumWithdrawalsSince :one
+SELECT COALESCE(SUM(-t.amount), 0)::bigint FROM transactions t
+LEFT JOIN holds h ON h.id = t.hold_id
+WHERE t.account_id = $1
+  AND t.amount < 0
+  AND t.transaction_type IN ('DEBIT', 'TRANSFER_DEBIT', 'REVERSAL')
+  AND t.status <> 'FAILED'
+  AND COALESCE(h.created_at, t.created_at) >= $2::timestamptz
+`
+
+type SumWithdrawalsSinceParams struct {
+	AccountID uuid.UUID `json:"account_id"`
+	Since     time.Time `json:"since"`
+}
+
+// the withdrawals counted by CountWithdrawalsSince, as a positive amount
+func (q *Queries) SumWithdrawalsSince(ctx context.Context, arg SumWithdrawalsSinceParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, sumWithdrawalsSince, arg.AccountID, arg.Since)
+	var column_1 int64
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const upsertAccountLimits = `-- name: UpsertAccountLimits :one
+INSERT INTO account_limits (account_id, level, max_single_debit, daily_debit_total, daily_debit_count, monthly_debit_total)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (account_id, level) DO UPDATE
+SET max_single_debit = EXCLUDED.max_single_debit,
+    daily_debit_total = EXCLUDED.daily_debit_total,
+    daily_debit_count = EXCLUDED.daily_debit_count,
+    monthly_debit_total = EXCLUDED.monthly_debit_total
+RETURNING account_id, level, max_single_debit, daily_debit_total, daily_debit_count, monthly_debit_total, created_at, updated_at
+`
+
+type UpsertAccountLimitsParams struct {
+	AccountID         uuid.UUID     `json:"account_id"`
+	Level             string        `json:"level"`
+	MaxSingleDebit    sql.NullInt64 `json:"max_single_debit"`
+	DailyDebitTotal   sql.NullInt64 `json:"daily_debit_total"`
+	DailyDebitCount   sql.NullInt32 `json:"daily_debit_count"`
+	MonthlyDebitTotal sql.NullInt64 `json:"monthly_debit_total"`
+}
+
+func (q *Queries) UpsertAccountLimits(ctx context.Context, arg UpsertAccountLimitsParams) (AccountLimit, error) {
+	row := q.db.QueryRowContext(ctx, upsertAccountLimits,
+		arg.AccountID,
+		arg.Level,
+		arg.MaxSingleDebit,
+		arg.DailyDebitTotal,
+		arg.DailyDebitCount,
+		arg.MonthlyDebitTotal,
+	)
+	var i AccountLimit
+	err := row.Scan(
+		&i.AccountID,
+		&i.Level,
+		&i.MaxSingleDebit,
+		&i.DailyDebitTotal,
+		&i.DailyDebitCount,
+		&i.MonthlyDebitTotal,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const upsertUserLimits = `-- name: UpsertUserLimits :one
+INSERT INTO user_limits (user_id, currency, max_single_debit, daily_debit_total, daily_debit_count, monthly_debit_total)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (user_id, currency) DO UPDATE
+SET max_single_debit = EXCLUDED.max_single_debit,
+    daily_debit_total = EXCLUDED.daily_debit_total,
+    daily_debit_count = EXCLUDED.daily_debit_count,
+    monthly_debit_total = EXCLUDED.monthly_debit_total
+RETURNING user_id, currency, max_single_debit, daily_debit_total, daily_debit_count, monthly_debit_total, created_at, updated_at
+`
+
+type UpsertUserLimitsParams struct {
+	UserID            uuid.UUID     `json:"user_id"`
+	Currency          string        `json:"currency"`
+	MaxSingleDebit    sql.NullInt64 `json:"max_single_debit"`
+	DailyDebitTotal   sql.NullInt64 `json:"daily_debit_total"`
+	DailyDebitCount   sql.NullInt32 `json:"daily_debit_count"`
+	MonthlyDebitTotal sql.NullInt64 `json:"monthly_debit_total"`
+}
+
+func (q *Queries) UpsertUserLimits(ctx context.Context, arg UpsertUserLimitsParams) (UserLimit, error) {
+	row := q.db.QueryRowContext(ctx, upsertUserLimits,
+		arg.UserID,
+		arg.Currency,
+		arg.MaxSingleDebit,
+		arg.DailyDebitTotal,
+		arg.DailyDebitCount,
+		arg.MonthlyDebitTotal,
+	)
+	var i UserLimit
+	err := row.Scan(
+		&i.UserID,
+		&i.Currency,
+		&i.MaxSingleDebit,
+		&i.DailyDebitTotal,
+		&i.DailyDebitCount,
+		&i.MonthlyDebitTotal,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
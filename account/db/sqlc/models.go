@@ -6,36 +6,165 @@ package sqlc
 
 import (
 	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type Account struct {
-	ID            uuid.UUID    `json:"id"`
-	UserID        uuid.UUID    `json:"user_id"`
-	AccountNumber int64        `json:"account_number"`
-	Balance       int64        `json:"balance"`
-	CreatedAt     sql.NullTime `json:"created_at"`
-	UpdatedAt     sql.NullTime `json:"updated_at"`
+	ID                     uuid.UUID      `json:"id"`
+	UserID                 uuid.UUID      `json:"user_id"`
+	AccountNumber          int64          `json:"account_number"`
+	Balance                int64          `json:"balance"`
+	CreatedAt              sql.NullTime   `json:"created_at"`
+	UpdatedAt              sql.NullTime   `json:"updated_at"`
+	Version                int64          `json:"version"`
+	ProductCode            string         `json:"product_code"`
+	AccountType            string         `json:"account_type"`
+	MaturesAt              sql.NullTime   `json:"matures_at"`
+	Status                 string         `json:"status"`
+	StatusChangedAt        time.Time      `json:"status_changed_at"`
+	ClosureReason          sql.NullString `json:"closure_reason"`
+	ClosedAt               sql.NullTime   `json:"closed_at"`
+	LastActivityAt         time.Time      `json:"last_activity_at"`
+	Currency               string         `json:"currency"`
+	InterestAccruedThrough sql.NullTime   `json:"interest_accrued_through"`
+	Held                   int64          `json:"held"`
 }
 
-type IdempotencyKey struct {
-	KeyID           string       `json:"key_id"`
-	UserID          uuid.UUID    `json:"user_id"`
-	Status          string       `json:"status"`
-	ResponseMessage string       `json:"response_message"`
-	CreatedAt       sql.NullTime `json:"created_at"`
-	UpdatedAt       sql.NullTime `json:"updated_at"`
-	ExpiredAt       sql.NullTime `json:"expired_at"`
+type AccountLimit struct {
+	AccountID         uuid.UUID     `json:"account_id"`
+	Level             string        `json:"level"`
+	MaxSingleDebit    sql.NullInt64 `json:"max_single_debit"`
+	DailyDebitTotal   sql.NullInt64 `json:"daily_debit_total"`
+	DailyDebitCount   sql.NullInt32 `json:"daily_debit_count"`
+	MonthlyDebitTotal sql.NullInt64 `json:"monthly_debit_total"`
+	CreatedAt         sql.NullTime  `json:"created_at"`
+	UpdatedAt         sql.NullTime  `json:"updated_at"`
 }
 
-type Transaction struct {
+type AccountNumberSerial struct {
+	Prefix     int32 `json:"prefix"`
+	NextSerial int64 `json:"next_serial"`
+}
+
+type AccountProduct struct {
+	Code                   string       `json:"code"`
+	AccountType            string       `json:"account_type"`
+	DisplayName            string       `json:"display_name"`
+	MinBalance             int64        `json:"min_balance"`
+	MonthlyWithdrawalLimit int32        `json:"monthly_withdrawal_limit"`
+	LockUpDays             int32        `json:"lock_up_days"`
+	CreatedAt              sql.NullTime `json:"created_at"`
+	UpdatedAt              sql.NullTime `json:"updated_at"`
+	DayCount               string       `json:"day_count"`
+	OverdraftLimit         int64        `json:"overdraft_limit"`
+	MaxSingleDebit         int64        `json:"max_single_debit"`
+	DailyDebitTotal        int64        `json:"daily_debit_total"`
+	DailyDebitCount        int32        `json:"daily_debit_count"`
+	MonthlyDebitTotal      int64        `json:"monthly_debit_total"`
+}
+
+type FeeRule struct {
+	Code                string         `json:"code"`
+	Trigger             string         `json:"trigger"`
+	ProductCode         sql.NullString `json:"product_code"`
+	Currency            string         `json:"currency"`
+	FixedAmount         int64          `json:"fixed_amount"`
+	PercentageBps       int32          `json:"percentage_bps"`
+	MinAmount           int64          `json:"min_amount"`
+	MaxAmount           sql.NullInt64  `json:"max_amount"`
+	WaiveBalanceAtLeast sql.NullInt64  `json:"waive_balance_at_least"`
+	Active              bool           `json:"active"`
+	CreatedAt           sql.NullTime   `json:"created_at"`
+	UpdatedAt           sql.NullTime   `json:"updated_at"`
+}
+
+type Hold struct {
 	ID              uuid.UUID     `json:"id"`
 	AccountID       uuid.UUID     `json:"account_id"`
-	TransactionType string        `json:"transaction_type"`
 	Amount          int64         `json:"amount"`
-	Status          string        `json:"status"`
+	Currency        string        `json:"currency"`
+	TransactionType string        `json:"transaction_type"`
 	TransferID      uuid.NullUUID `json:"transfer_id"`
+	Status          string        `json:"status"`
+	Captured        int64         `json:"captured"`
+	ExpiresAt       time.Time     `json:"expires_at"`
 	CreatedAt       sql.NullTime  `json:"created_at"`
 	UpdatedAt       sql.NullTime  `json:"updated_at"`
 }
+
+type IdempotencyKey struct {
+	KeyID           string        `json:"key_id"`
+	UserID          uuid.UUID     `json:"user_id"`
+	Status          string        `json:"status"`
+	ResponseMessage string        `json:"response_message"`
+	CreatedAt       sql.NullTime  `json:"created_at"`
+	UpdatedAt       sql.NullTime  `json:"updated_at"`
+	ExpiredAt       sql.NullTime  `json:"expired_at"`
+	RpcName         string        `json:"rpc_name"`
+	RequestHash     string        `json:"request_hash"`
+	ClaimID         uuid.NullUUID `json:"claim_id"`
+	LeaseExpiresAt  time.Time     `json:"lease_expires_at"`
+}
+
+type InterestAccrual struct {
+	AccountID   uuid.UUID    `json:"account_id"`
+	AccrualDate time.Time    `json:"accrual_date"`
+	EodBalance  int64        `json:"eod_balance"`
+	AnnualRate  string       `json:"annual_rate"`
+	Amount      string       `json:"amount"`
+	CreatedAt   sql.NullTime `json:"created_at"`
+}
+
+type InterestPosting struct {
+	AccountID     uuid.UUID     `json:"account_id"`
+	Period        time.Time     `json:"period"`
+	Accrued       string        `json:"accrued"`
+	Posted        int64         `json:"posted"`
+	Carried       string        `json:"carried"`
+	TransactionID uuid.NullUUID `json:"transaction_id"`
+	CreatedAt     sql.NullTime  `json:"created_at"`
+}
+
+type MaintenanceFeePeriod struct {
+	AccountID     uuid.UUID     `json:"account_id"`
+	FeeRule       string        `json:"fee_rule"`
+	Period        time.Time     `json:"period"`
+	TransactionID uuid.NullUUID `json:"transaction_id"`
+	CreatedAt     sql.NullTime  `json:"created_at"`
+}
+
+type ProductInterestRate struct {
+	ProductCode   string       `json:"product_code"`
+	EffectiveFrom time.Time    `json:"effective_from"`
+	AnnualRate    string       `json:"annual_rate"`
+	CreatedAt     sql.NullTime `json:"created_at"`
+}
+
+type Transaction struct {
+	ID              uuid.UUID      `json:"id"`
+	AccountID       uuid.UUID      `json:"account_id"`
+	TransactionType string         `json:"transaction_type"`
+	Amount          int64          `json:"amount"`
+	Status          string         `json:"status"`
+	TransferID      uuid.NullUUID  `json:"transfer_id"`
+	CreatedAt       sql.NullTime   `json:"created_at"`
+	UpdatedAt       sql.NullTime   `json:"updated_at"`
+	Currency        string         `json:"currency"`
+	CausedBy        uuid.NullUUID  `json:"caused_by"`
+	FeeRule         sql.NullString `json:"fee_rule"`
+	Memo            sql.NullString `json:"memo"`
+	HoldID          uuid.NullUUID  `json:"hold_id"`
+}
+
+type UserLimit struct {
+	UserID            uuid.UUID     `json:"user_id"`
+	Currency          string        `json:"currency"`
+	MaxSingleDebit    sql.NullInt64 `json:"max_single_debit"`
+	DailyDebitTotal   sql.NullInt64 `json:"daily_debit_total"`
+	DailyDebitCount   sql.NullInt32 `json:"daily_debit_count"`
+	MonthlyDebitTotal sql.NullInt64 `json:"monthly_debit_total"`
+	CreatedAt         sql.NullTime  `json:"created_at"`
+	UpdatedAt         sql.NullTime  `json:"updated_at"`
+}
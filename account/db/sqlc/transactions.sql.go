@@ -7,23 +7,55 @@ package sqlc
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+const countWithdrawalsSince = `-- name: CountWithdrawalsSince :one
+SELECT COUNT(*) FROM transactions t
+LEFT JOIN holds h ON h.id = t.hold_id
+WHERE t.account_id = $1
+  AND t.amount < 0
+  AND t.transaction_type IN ('DEBIT', 'TRANSFER_DEBIT', 'REVERSAL')
+  AND t.status <> 'FAILED'
+  AND COALESCE(h.created_at, t.created_at) >= $2::timestamptz
+`
+
+type CountWithdrawalsSinceParams struct {
+	AccountID uuid.UUID `json:"account_id"`
+	Since     time.Time `json:"since"`
+}
+
+// the fees charged by the bank aren't withdrawals. A reversed withdrawal still counts: the reversal is a refund, not a
+// cancellation. A credit given back is a withdrawal, its reversal being a debit. A debit capturing a hold dates from
+// the hold, which counted as a withdrawal while it was ACTIVE.
+func (q *Queries) CountWithdrawalsSince(ctx context.Context, arg CountWithdrawalsSinceParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countWithdrawalsSince, arg.AccountID, arg.Since)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createTransaction = `-- name: CreateTransaction :one
-INSERT INTO transactions (id, account_id, amount, transaction_type, status, transfer_id)
-VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING id, account_id, transaction_type, amount, status, transfer_id, created_at, updated_at
+INSERT INTO transactions (id, account_id, amount, transaction_type, status, transfer_id, currency, caused_by, fee_rule, memo, hold_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+RETURNING id, account_id, transaction_type, amount, status, transfer_id, created_at, updated_at, currency, caused_by, fee_rule, memo, hold_id
 `
 
 type CreateTransactionParams struct {
-	ID              uuid.UUID     `json:"id"`
-	AccountID       uuid.UUID     `json:"account_id"`
-	Amount          int64         `json:"amount"`
-	TransactionType string        `json:"transaction_type"`
-	Status          string        `json:"status"`
-	TransferID      uuid.NullUUID `json:"transfer_id"`
+	ID              uuid.UUID      `json:"id"`
+	AccountID       uuid.UUID      `json:"account_id"`
+	Amount          int64          `json:"amount"`
+	TransactionType string         `json:"transaction_type"`
+	Status          string         `json:"status"`
+	TransferID      uuid.NullUUID  `json:"transfer_id"`
+	Currency        string         `json:"currency"`
+	CausedBy        uuid.NullUUID  `json:"caused_by"`
+	FeeRule         sql.NullString `json:"fee_rule"`
+	Memo            sql.NullString `json:"memo"`
+	HoldID          uuid.NullUUID  `json:"hold_id"`
 }
 
 func (q *Queries) CreateTransaction(ctx context.Context, arg CreateTransactionParams) (Transaction, error) {
@@ -34,6 +66,11 @@ func (q *Queries) CreateTransaction(ctx context.Context, arg CreateTransactionPa
 		arg.TransactionType,
 		arg.Status,
 		arg.TransferID,
+		arg.Currency,
+		arg.CausedBy,
+		arg.FeeRule,
+		arg.Memo,
+		arg.HoldID,
 	)
 	var i Transaction
 	err := row.Scan(
@@ -45,6 +82,11 @@ func (q *Queries) CreateTransaction(ctx context.Context, arg CreateTransactionPa
 		&i.TransferID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Currency,
+		&i.CausedBy,
+		&i.FeeRule,
+		&i.Memo,
+		&i.HoldID,
 	)
 	return i, err
 }
@@ -52,7 +94,7 @@ func (q *Queries) CreateTransaction(ctx context.Context, arg CreateTransactionPa
 const deleteTransactionByID = `-- name: DeleteTransactionByID :exec
 DELETE FROM transactions
 WHERE id = $1
-RETURNING id, account_id, transaction_type, amount, status, transfer_id, created_at, updated_at
+RETURNING id, account_id, transaction_type, amount, status, transfer_id, created_at, updated_at, currency, caused_by, fee_rule, memo, hold_id
 `
 
 func (q *Queries) DeleteTransactionByID(ctx context.Context, id uuid.UUID) error {
@@ -60,8 +102,33 @@ func (q *Queries) DeleteTransactionByID(ctx context.Context, id uuid.UUID) error
 	return err
 }
 
+const getFeeReversal = `-- name: GetFeeReversal :one
+SELECT id, account_id, transaction_type, amount, status, transfer_id, created_at, updated_at, currency, caused_by, fee_rule, memo, hold_id FROM transactions WHERE caused_by = $1 AND transaction_type = 'FEE_REVERSAL'
+`
+
+func (q *Queries) GetFeeReversal(ctx context.Context, causedBy uuid.NullUUID) (Transaction, error) {
+	row := q.db.QueryRowContext(ctx, getFeeReversal, causedBy)
+	var i Transaction
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.TransactionType,
+		&i.Amount,
+		&i.Status,
+		&i.TransferID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Currency,
+		&i.CausedBy,
+		&i.FeeRule,
+		&i.Memo,
+		&i.HoldID,
+	)
+	return i, err
+}
+
 const getTransactionByID = `-- name: GetTransactionByID :one
-SELECT id, account_id, transaction_type, amount, status, transfer_id, created_at, updated_at FROM transactions WHERE id = $1
+SELECT id, account_id, transaction_type, amount, status, transfer_id, created_at, updated_at, currency, caused_by, fee_rule, memo, hold_id FROM transactions WHERE id = $1
 `
 
 func (q *Queries) GetTransactionByID(ctx context.Context, id uuid.UUID) (Transaction, error) {
@@ -76,12 +143,17 @@ func (q *Queries) GetTransactionByID(ctx context.Context, id uuid.UUID) (Transac
 		&i.TransferID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Currency,
+		&i.CausedBy,
+		&i.FeeRule,
+		&i.Memo,
+		&i.HoldID,
 	)
 	return i, err
 }
 
 const getTransactionByTransferID = `-- name: GetTransactionByTransferID :many
-SELECT id, account_id, transaction_type, amount, status, transfer_id, created_at, updated_at FROM transactions WHERE transfer_id = $1
+SELECT id, account_id, transaction_type, amount, status, transfer_id, created_at, updated_at, currency, caused_by, fee_rule, memo, hold_id FROM transactions WHERE transfer_id = $1
 `
 
 func (q *Queries) GetTransactionByTransferID(ctx context.Context, transferID uuid.NullUUID) ([]Transaction, error) {
@@ -102,6 +174,11 @@ func (q *Queries) GetTransactionByTransferID(ctx context.Context, transferID uui
 			&i.TransferID,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Currency,
+			&i.CausedBy,
+			&i.FeeRule,
+			&i.Memo,
+			&i.HoldID,
 		); err != nil {
 			return nil, err
 		}
@@ -117,7 +194,7 @@ func (q *Queries) GetTransactionByTransferID(ctx context.Context, transferID uui
 }
 
 const getTransactionsByAccountID = `-- name: GetTransactionsByAccountID :many
-SELECT id, account_id, transaction_type, amount, status, transfer_id, created_at, updated_at FROM transactions WHERE account_id = $1
+SELECT id, account_id, transaction_type, amount, status, transfer_id, created_at, updated_at, currency, caused_by, fee_rule, memo, hold_id FROM transactions WHERE account_id = $1
 `
 
 func (q *Queries) GetTransactionsByAccountID(ctx context.Context, accountID uuid.UUID) ([]Transaction, error) {
@@ -138,6 +215,11 @@ func (q *Queries) GetTransactionsByAccountID(ctx context.Context, accountID uuid
 			&i.TransferID,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Currency,
+			&i.CausedBy,
+			&i.FeeRule,
+			&i.Memo,
+			&i.HoldID,
 		); err != nil {
 			return nil, err
 		}
@@ -153,7 +235,7 @@ func (q *Queries) GetTransactionsByAccountID(ctx context.Context, accountID uuid
 }
 
 const listTransactions = `-- name: ListTransactions :many
-SELECT id, account_id, transaction_type, amount, status, transfer_id, created_at, updated_at FROM transactions ORDER BY updated_at LIMIT $1
+SELECT id, account_id, transaction_type, amount, status, transfer_id, created_at, updated_at, currency, caused_by, fee_rule, memo, hold_id FROM transactions ORDER BY updated_at LIMIT $1
 `
 
 func (q *Queries) ListTransactions(ctx context.Context, limit int32) ([]Transaction, error) {
@@ -174,6 +256,11 @@ func (q *Queries) ListTransactions(ctx context.Context, limit int32) ([]Transact
 			&i.TransferID,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Currency,
+			&i.CausedBy,
+			&i.FeeRule,
+			&i.Memo,
+			&i.HoldID,
 		); err != nil {
 			return nil, err
 		}
@@ -188,11 +275,23 @@ func (q *Queries) ListTransactions(ctx context.Context, limit int32) ([]Transact
 	return items, nil
 }
 
+const sumReversals = `-- name: SumReversals :one
+SELECT COALESCE(SUM(amount), 0)::bigint FROM transactions WHERE caused_by = $1 AND transaction_type = 'REVERSAL'
+`
+
+// the reversals of a transaction offset it, so their sum has the opposite sign
+func (q *Queries) SumReversals(ctx context.Context, causedBy uuid.NullUUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, sumReversals, causedBy)
+	var column_1 int64
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
 const updateTransactionStatus = `-- name: UpdateTransactionStatus :exec
 UPDATE transactions
 SET status = $1
 WHERE id = $2
-RETURNING id, account_id, transaction_type, amount, status, transfer_id, created_at, updated_at
+RETURNING id, account_id, transaction_type, amount, status, transfer_id, created_at, updated_at, currency, caused_by, fee_rule, memo, hold_id
 `
 
 type UpdateTransactionStatusParams struct {
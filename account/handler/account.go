@@ -1,36 +1,223 @@
 package handler
 
 import (
+	"account/internal/identity"
+	"account/internal/mtls"
 	"account/model"
+	"account/money"
 	"account/proto"
 	"account/service"
 	"context"
 	"log"
+	"time"
 
 	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 type AccountHandler struct {
 	proto.UnimplementedAccountServiceServer
 	service *service.AccountService
+	// WatchAccount sends a heartbeat after this long without any event
+	watchHeartbeat time.Duration
+	// closed by Shutdown to end the open watches
+	shutdown chan struct{}
 }
 
-func NewAccountHandler(service *service.AccountService) *AccountHandler {
-	return &AccountHandler{service: service}
+func NewAccountHandler(service *service.AccountService, watchHeartbeat time.Duration) *AccountHandler {
+	return &AccountHandler{service: service, watchHeartbeat: watchHeartbeat, shutdown: make(chan struct{})}
+}
+
+// Shutdown ends every open WatchAccount stream with UNAVAILABLE so that the callers reconnect to another instance,
+// since a graceful stop would otherwise wait for them until the shutdown timeout. It must be called only once.
+func (h *AccountHandler) Shutdown() {
+	close(h.shutdown)
+}
+
+func convertToProtoAccount(account *model.Account) *proto.Account {
+	return &proto.Account{
+		AccountId:        account.AccountID.String(),
+		AccountNumber:    account.AccountNumber,
+		Balance:          toProtoMoney(account.Currency, account.Balance),
+		LedgerBalance:    toProtoMoney(account.Currency, account.Balance),
+		AvailableBalance: toProtoMoney(account.Currency, account.Available()),
+		UserId:           account.UserID.String(),
+		AccountType:      account.AccountType,
+		ProductCode:      account.ProductCode,
+		MaturesAt:        unixOrZero(account.MaturesAt),
+		Status:           account.Status,
+		StatusChangedAt:  unixOrZero(account.StatusChangedAt),
+		ClosureReason:    account.ClosureReason,
+		ClosedAt:         unixOrZero(account.ClosedAt),
+	}
+}
+
+func convertToProtoTransaction(transaction *model.Transaction) *proto.Transaction {
+	return &proto.Transaction{
+		TransactionId:   transaction.TransactionID.String(),
+		AccountId:       transaction.AccountID.String(),
+		Amount:          toProtoMoney(transaction.Currency, transaction.Amount),
+		TransactionType: transaction.TransactionType,
+		Status:          transaction.Status,
+		TransferId:      transaction.TransferID.UUID.String(),
+		CausedBy:        uuidOrEmpty(transaction.CausedBy),
+		FeeRule:         transaction.FeeRule,
+		Memo:            transaction.Memo,
+		HoldId:          uuidOrEmpty(transaction.HoldID),
+	}
+}
+
+func convertToProtoHold(hold *model.Hold) *proto.Hold {
+	return &proto.Hold{
+		HoldId:          hold.HoldID.String(),
+		AccountId:       hold.AccountID.String(),
+		Amount:          toProtoMoney(hold.Currency, hold.Amount),
+		TransactionType: hold.TransactionType,
+		TransferId:      uuidOrEmpty(hold.TransferID),
+		Status:          hold.Status,
+		Captured:        toProtoMoney(hold.Currency, hold.Captured),
+		ExpiresAt:       unixOrZero(hold.ExpiresAt),
+		CreatedAt:       unixOrZero(hold.CreatedAt),
+	}
+}
+
+func convertToProtoAccountLimits(accountID uuid.UUID, limits *model.AccountLimits) *proto.AccountLimits {
+	return &proto.AccountLimits{
+		AccountId: accountID.String(),
+		Effective: convertToProtoLimits(limits.Currency, limits.Effective),
+		User:      convertToProtoLimits(limits.Currency, limits.User),
+		Ceiling:   convertToProtoLimits(limits.Currency, limits.Ceiling),
+		Used:      convertToProtoLimitUsage(limits.Currency, limits.Usage),
+		Remaining: remainingLimitUsage(limits.Currency, limits.Effective, limits.Usage),
+	}
+}
+
+func convertToProtoUserLimits(limits *model.UserLimits) *proto.UserLimits {
+	return &proto.UserLimits{
+		UserId:    limits.UserID.String(),
+		Currency:  limits.Currency,
+		Limits:    convertToProtoLimits(limits.Currency, limits.Limits),
+		Used:      convertToProtoLimitUsage(limits.Currency, limits.Usage),
+		Remaining: remainingLimitUsage(limits.Currency, limits.Limits, limits.Usage),
+	}
+}
+
+func convertToProtoLimitUsage(currency string, usage model.LimitUsage) *proto.LimitUsage {
+	return &proto.LimitUsage{
+		DailyDebitTotal:   toProtoMoney(currency, usage.DailyDebitTotal),
+		DailyDebitCount:   usage.DailyDebitCount,
+		MonthlyDebitTotal: toProtoMoney(currency, usage.MonthlyDebitTotal),
+	}
+}
+
+// remainingLimitUsage returns what remains of limits once usage is subtracted, leaving unset the limits that are 0.
+func remainingLimitUsage(currency string, limits model.Limits, usage model.LimitUsage) *proto.LimitUsage {
+	remaining := &proto.LimitUsage{}
+	if limits.DailyDebitTotal > 0 {
+		remaining.DailyDebitTotal = toProtoMoney(currency, max(0, limits.DailyDebitTotal-usage.DailyDebitTotal))
+	}
+	if limits.DailyDebitCount > 0 {
+		remaining.DailyDebitCount = max(0, int64(limits.DailyDebitCount)-usage.DailyDebitCount)
+	}
+	if limits.MonthlyDebitTotal > 0 {
+		remaining.MonthlyDebitTotal = toProtoMoney(currency, max(0, limits.MonthlyDebitTotal-usage.MonthlyDebitTotal))
+	}
+	return remaining
+}
+
+// convertToProtoLimits leaves the limits that are 0 unset.
+func convertToProtoLimits(currency string, limits model.Limits) *proto.Limits {
+	res := &proto.Limits{DailyDebitCount: limits.DailyDebitCount}
+	if limits.MaxSingleDebit > 0 {
+		res.MaxSingleDebit = toProtoMoney(currency, limits.MaxSingleDebit)
+	}
+	if limits.DailyDebitTotal > 0 {
+		res.DailyDebitTotal = toProtoMoney(currency, limits.DailyDebitTotal)
+	}
+	if limits.MonthlyDebitTotal > 0 {
+		res.MonthlyDebitTotal = toProtoMoney(currency, limits.MonthlyDebitTotal)
+	}
+	return res
+}
+
+// fromProtoLimits returns limits, and the currency of their amounts, empty if they have none. The amounts must all be
+// in the same currency.
+func fromProtoLimits(limits *proto.Limits) (model.Limits, string, error) {
+	res := model.Limits{DailyDebitCount: limits.GetDailyDebitCount()}
+	currency := ""
+	for _, l := range []struct {
+		m    *proto.Money
+		dest *int64
+	}{
+		{limits.GetMaxSingleDebit(), &res.MaxSingleDebit},
+		{limits.GetDailyDebitTotal(), &res.DailyDebitTotal},
+		{limits.GetMonthlyDebitTotal(), &res.MonthlyDebitTotal},
+	} {
+		if l.m == nil {
+			continue
+		}
+		amount, err := fromProtoMoney(l.m)
+		if err != nil {
+			return model.Limits{}, "", err
+		}
+		if currency != "" && amount.Currency != currency {
+			return model.Limits{}, "", model.ErrCurrencyMismatch
+		}
+		currency = amount.Currency
+		*l.dest = amount.Units
+	}
+	return res, currency, nil
+}
+
+// uuidOrEmpty returns id, or an empty string if it is invalid.
+func uuidOrEmpty(id uuid.NullUUID) string {
+	if !id.Valid {
+		return ""
+	}
+	return id.UUID.String()
+}
+
+func toProtoMoney(currency string, units int64) *proto.Money {
+	return &proto.Money{Currency: currency, Units: units}
+}
+
+// fromProtoMoney returns m, or ErrUnsupportedCurrency if its currency isn't supported.
+func fromProtoMoney(m *proto.Money) (money.Money, error) {
+	res, err := money.New(m.GetCurrency(), m.GetUnits())
+	if err != nil {
+		return money.Money{}, model.ErrUnsupportedCurrency
+	}
+	return res, nil
+}
+
+// unixOrZero returns the unix time of t, or 0 if t is the zero time.
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
 }
 
 func (h *AccountHandler) CreateAccount(ctx context.Context, req *proto.CreateAccountRequest) (*proto.CreateAccountResponse, error) {
-	userID, err := uuid.Parse(req.UserId)
+	userID, err := identity.UserID(ctx)
 	if err != nil {
-		log.Printf("gRPC CreateAccount: Failed to parse user ID: %v\n", err)
-		return nil, model.ErrInvalidArgument
+		log.Printf("gRPC CreateAccount: %v\n", err)
+		return nil, err
+	}
+	balance, err := fromProtoMoney(req.Balance)
+	if err != nil {
+		log.Printf("gRPC CreateAccount: Unsupported currency %q\n", req.Balance.GetCurrency())
+		return nil, err
 	}
 	user := &model.User{
-		UserID:  userID,
-		Balance: req.Balance,
+		UserID:   userID,
+		Balance:  balance.Units,
+		Currency: balance.Currency,
 	}
 
-	account, err := h.service.CreateAccount(ctx, user, req.IdempotencyKey, userID)
+	account, err := h.service.CreateAccount(ctx, user, req.ProductCode, req.IdempotencyKey, userID)
 	if err != nil {
 		log.Printf("gRPC CreateAccount: Failed to create account: %v\n", err)
 		return nil, err
@@ -43,10 +230,10 @@ func (h *AccountHandler) CreateAccount(ctx context.Context, req *proto.CreateAcc
 }
 
 func (h *AccountHandler) GetAccountsByUserId(ctx context.Context, req *proto.GetAccountsByUserIdRequest) (*proto.GetAccountsByUserIdResponse, error) {
-	userID, err := uuid.Parse(req.UserId)
+	userID, err := identity.UserID(ctx)
 	if err != nil {
-		log.Printf("gRPC GetAccountsByUserID: Failed to parse user ID: %v\n", err)
-		return nil, model.ErrInvalidArgument
+		log.Printf("gRPC GetAccountsByUserID: %v\n", err)
+		return nil, err
 	}
 
 	accounts, err := h.service.GetAccountsByUserID(ctx, userID)
@@ -57,12 +244,7 @@ func (h *AccountHandler) GetAccountsByUserId(ctx context.Context, req *proto.Get
 
 	grpcAccounts := make([]*proto.Account, len(accounts))
 	for i, account := range accounts {
-		grpcAccounts[i] = &proto.Account{
-			AccountId:     account.AccountID.String(),
-			AccountNumber: account.AccountNumber,
-			Balance:       account.Balance,
-			UserId:        account.UserID.String(),
-		}
+		grpcAccounts[i] = convertToProtoAccount(account)
 	}
 
 	return &proto.GetAccountsByUserIdResponse{
@@ -71,10 +253,10 @@ func (h *AccountHandler) GetAccountsByUserId(ctx context.Context, req *proto.Get
 }
 
 func (h *AccountHandler) GetAccountByAccountNumber(ctx context.Context, req *proto.GetAccountByAccountNumberRequest) (*proto.Account, error) {
-	userID, err := uuid.Parse(req.UserId)
+	userID, err := identity.UserID(ctx)
 	if err != nil {
-		log.Printf("gRPC GetAccountByAccountNumber: Failed to parse user ID: %v\n", err)
-		return nil, model.ErrInvalidArgument
+		log.Printf("gRPC GetAccountByAccountNumber: %v\n", err)
+		return nil, err
 	}
 
 	account, err := h.service.GetAccountByAccountNumber(ctx, req.AccountNumber, userID)
@@ -83,19 +265,14 @@ func (h *AccountHandler) GetAccountByAccountNumber(ctx context.Context, req *pro
 		return nil, err
 	}
 
-	return &proto.Account{
-		AccountId:     account.AccountID.String(),
-		AccountNumber: account.AccountNumber,
-		Balance:       account.Balance,
-		UserId:        account.UserID.String(),
-	}, nil
+	return convertToProtoAccount(account), nil
 }
 
 func (h *AccountHandler) GetAccountByAccountId(ctx context.Context, req *proto.GetAccountByAccountIdRequest) (*proto.Account, error) {
-	userID, err := uuid.Parse(req.UserId)
+	userID, err := identity.UserID(ctx)
 	if err != nil {
-		log.Printf("gRPC GetAccountByAccountNumber: Failed to parse user ID: %v\n", err)
-		return nil, model.ErrInvalidArgument
+		log.Printf("gRPC GetAccountByAccountNumber: %v\n", err)
+		return nil, err
 	}
 
 	accountID, err := uuid.Parse(req.AccountId)
@@ -104,42 +281,60 @@ func (h *AccountHandler) GetAccountByAccountId(ctx context.Context, req *proto.G
 		return nil, model.ErrInvalidArgument
 	}
 
-	account, err := h.service.GetAccount(ctx, accountID, userID)
+	caller, _ := mtls.Identity(ctx)
+	account, err := h.service.GetAccount(ctx, accountID, userID, caller)
 	if err != nil {
 		log.Printf("gRPC GetAccountByAccountNumber: Failed to get account: %v\n", err)
 		return nil, err
 	}
 
-	return &proto.Account{
-		AccountId:     account.AccountID.String(),
-		AccountNumber: account.AccountNumber,
-		Balance:       account.Balance,
-		UserId:        account.UserID.String(),
-	}, nil
+	return convertToProtoAccount(account), nil
 }
 
 func (h *AccountHandler) DeleteAccountByAccountNumber(ctx context.Context, req *proto.DeleteAccountByAccountNumberRequest) (*proto.DeleteAccountByAccountNumberResponse, error) {
-	userID, err := uuid.Parse(req.UserId)
+	userID, err := identity.UserID(ctx)
 	if err != nil {
-		log.Printf("gRPC DeleteAccountByAccountNumber: Failed to parse user ID: %v\n", err)
-		return nil, model.ErrInvalidArgument
+		log.Printf("gRPC DeleteAccountByAccountNumber: %v\n", err)
+		return nil, err
 	}
 
-	if err = h.service.DeleteAccountByAccountNumber(ctx, req.AccountNumber, req.IdempotencyKey, userID); err != nil {
-		log.Printf("gRPC DeleteAccountByAccountNumber: Failed to delete account: %v\n", err)
+	if err = h.service.CloseAccountByAccountNumber(ctx, req.AccountNumber, req.ClosureReason, req.IdempotencyKey, userID); err != nil {
+		log.Printf("gRPC DeleteAccountByAccountNumber: Failed to close account: %v\n", err)
 		return nil, err
 	}
 
 	return &proto.DeleteAccountByAccountNumberResponse{}, nil
 }
 
-func (h *AccountHandler) CreateTransaction(ctx context.Context, req *proto.CreateTransactionRequest) (*proto.CreateTransactionResponse, error) {
-	userID, err := uuid.Parse(req.UserId)
+func (h *AccountHandler) UpdateAccountStatus(ctx context.Context, req *proto.UpdateAccountStatusRequest) (*proto.Account, error) {
+	userID, err := identity.UserID(ctx)
 	if err != nil {
-		log.Printf("gRPC CreateTransaction: Failed to parse user ID: %v\n", err)
+		log.Printf("gRPC UpdateAccountStatus: %v\n", err)
+		return nil, err
+	}
+
+	accountID, err := uuid.Parse(req.AccountId)
+	if err != nil {
+		log.Printf("gRPC UpdateAccountStatus: Failed to parse account ID: %v\n", err)
 		return nil, model.ErrInvalidArgument
 	}
 
+	account, err := h.service.UpdateAccountStatus(ctx, accountID, req.Status, req.IdempotencyKey, userID)
+	if err != nil {
+		log.Printf("gRPC UpdateAccountStatus: Failed to update account status: %v\n", err)
+		return nil, err
+	}
+
+	return convertToProtoAccount(account), nil
+}
+
+func (h *AccountHandler) CreateTransaction(ctx context.Context, req *proto.CreateTransactionRequest) (*proto.CreateTransactionResponse, error) {
+	userID, err := identity.UserID(ctx)
+	if err != nil {
+		log.Printf("gRPC CreateTransaction: %v\n", err)
+		return nil, err
+	}
+
 	accountID, err := uuid.Parse(req.AccountId)
 	if err != nil {
 		log.Printf("gRPC CreateTransaction: Failed to parse account ID: %v\n", err)
@@ -156,17 +351,28 @@ func (h *AccountHandler) CreateTransaction(ctx context.Context, req *proto.Creat
 		transferID = uuid.NullUUID{UUID: transferUUID, Valid: true}
 	}
 
+	amount, err := fromProtoMoney(req.Amount)
+	if err != nil {
+		log.Printf("gRPC CreateTransaction: Unsupported currency %q\n", req.Amount.GetCurrency())
+		return nil, err
+	}
+	// the request amount is always positive, the balance is debited by storing a negative amount
+	if isDebit(req.TransactionType) {
+		if amount, err = amount.Neg(); err != nil {
+			return nil, model.ErrInvalidArgument
+		}
+	}
+
 	transaction := &model.Transaction{
 		AccountID:       accountID,
-		Amount:          req.Amount,
+		Amount:          amount.Units,
+		Currency:        amount.Currency,
 		TransactionType: req.TransactionType,
-		Status:          "PENDING",
 		TransferID:      transferID,
 	}
 
-	
-
-	createdTransaction, err := h.service.CreateTransaction(ctx, transaction, req.IdempotencyKey, userID)
+	caller, _ := mtls.Identity(ctx)
+	createdTransaction, err := h.service.CreateTransaction(ctx, transaction, req.IdempotencyKey, userID, caller)
 	if err != nil {
 		log.Printf("gRPC CreateTransaction: Failed to create transaction: %v\n", err)
 		return nil, err
@@ -178,10 +384,10 @@ func (h *AccountHandler) CreateTransaction(ctx context.Context, req *proto.Creat
 }
 
 func (h *AccountHandler) GetTransactionsByAccountId(ctx context.Context, req *proto.GetTransactionsByAccountIdRequest) (*proto.GetTransactionsByAccountIdResponse, error) {
-	userID, err := uuid.Parse(req.UserId)
+	userID, err := identity.UserID(ctx)
 	if err != nil {
-		log.Printf("gRPC GetTransactionsByAccountId: Failed to parse user ID: %v\n", err)
-		return nil, model.ErrInvalidArgument
+		log.Printf("gRPC GetTransactionsByAccountId: %v\n", err)
+		return nil, err
 	}
 
 	accountID, err := uuid.Parse(req.AccountId)
@@ -198,14 +404,7 @@ func (h *AccountHandler) GetTransactionsByAccountId(ctx context.Context, req *pr
 
 	grpcTransactions := make([]*proto.Transaction, len(transactions))
 	for i, transaction := range transactions {
-		grpcTransactions[i] = &proto.Transaction{
-			TransactionId:   transaction.TransactionID.String(),
-			AccountId:       transaction.AccountID.String(),
-			Amount:          transaction.Amount,
-			TransactionType: transaction.TransactionType,
-			Status:          transaction.Status,
-			TransferId:      transaction.TransferID.UUID.String(),
-		}
+		grpcTransactions[i] = convertToProtoTransaction(transaction)
 	}
 
 	return &proto.GetTransactionsByAccountIdResponse{
@@ -214,10 +413,10 @@ func (h *AccountHandler) GetTransactionsByAccountId(ctx context.Context, req *pr
 }
 
 func (h *AccountHandler) ValidateAccountNumber(ctx context.Context, req *proto.ValidateAccountNumberRequest) (*proto.ValidateAccountNumberResponse, error) {
-	userID, err := uuid.Parse(req.UserId)
+	userID, err := identity.UserID(ctx)
 	if err != nil {
-		log.Printf("gRPC ValidateAccountNumber: Failed to parse user ID: %v\n", err)
-		return nil, model.ErrInvalidArgument
+		log.Printf("gRPC ValidateAccountNumber: %v\n", err)
+		return nil, err
 	}
 
 	valid, err := h.service.ValidateAccountNumber(ctx, req.AccountNumber, userID)
@@ -232,13 +431,19 @@ func (h *AccountHandler) ValidateAccountNumber(ctx context.Context, req *proto.V
 }
 
 func (h *AccountHandler) HasSufficientBalance(ctx context.Context, req *proto.HasSufficientBalanceRequest) (*proto.HasSufficientBalanceResponse, error) {
-	userID, err := uuid.Parse(req.UserId)
+	userID, err := identity.UserID(ctx)
 	if err != nil {
-		log.Printf("gRPC HasSufficientBalance: Failed to parse user ID: %v\n", err)
-		return nil, model.ErrInvalidArgument
+		log.Printf("gRPC HasSufficientBalance: %v\n", err)
+		return nil, err
+	}
+
+	amount, err := fromProtoMoney(req.Amount)
+	if err != nil {
+		log.Printf("gRPC HasSufficientBalance: Unsupported currency %q\n", req.Amount.GetCurrency())
+		return nil, err
 	}
 
-	sufficient, err := h.service.HasSufficientBalance(ctx, req.AccountNumber, req.Amount, userID)
+	sufficient, err := h.service.HasSufficientBalance(ctx, req.AccountNumber, amount, userID)
 	if err != nil {
 		log.Printf("gRPC HasSufficientBalance: Failed to check balance: %v\n", err)
 		return nil, err
@@ -248,3 +453,358 @@ func (h *AccountHandler) HasSufficientBalance(ctx context.Context, req *proto.Ha
 		Sufficient: sufficient,
 	}, nil
 }
+
+func (h *AccountHandler) PlaceHold(ctx context.Context, req *proto.PlaceHoldRequest) (*proto.Hold, error) {
+	userID, err := identity.UserID(ctx)
+	if err != nil {
+		log.Printf("gRPC PlaceHold: %v\n", err)
+		return nil, err
+	}
+
+	accountID, err := uuid.Parse(req.AccountId)
+	if err != nil {
+		log.Printf("gRPC PlaceHold: Failed to parse account ID: %v\n", err)
+		return nil, model.ErrInvalidArgument
+	}
+
+	var transferID uuid.NullUUID
+	if req.TransferId != "" {
+		transferUUID, err := uuid.Parse(req.TransferId)
+		if err != nil {
+			log.Printf("gRPC PlaceHold: Failed to parse transfer ID: %v\n", err)
+			return nil, model.ErrInvalidArgument
+		}
+		transferID = uuid.NullUUID{UUID: transferUUID, Valid: true}
+	}
+
+	amount, err := fromProtoMoney(req.Amount)
+	if err != nil {
+		log.Printf("gRPC PlaceHold: Unsupported currency %q\n", req.Amount.GetCurrency())
+		return nil, err
+	}
+
+	hold := &model.Hold{
+		AccountID:       accountID,
+		Amount:          amount.Units,
+		Currency:        amount.Currency,
+		TransactionType: req.TransactionType,
+		TransferID:      transferID,
+	}
+	ttl := time.Duration(req.ExpiresInSeconds) * time.Second
+
+	createdHold, err := h.service.PlaceHold(ctx, hold, ttl, req.IdempotencyKey, userID)
+	if err != nil {
+		log.Printf("gRPC PlaceHold: Failed to place hold: %v\n", err)
+		return nil, err
+	}
+	return convertToProtoHold(createdHold), nil
+}
+
+func (h *AccountHandler) CaptureHold(ctx context.Context, req *proto.CaptureHoldRequest) (*proto.Transaction, error) {
+	userID, err := identity.UserID(ctx)
+	if err != nil {
+		log.Printf("gRPC CaptureHold: %v\n", err)
+		return nil, err
+	}
+
+	holdID, err := uuid.Parse(req.HoldId)
+	if err != nil {
+		log.Printf("gRPC CaptureHold: Failed to parse hold ID: %v\n", err)
+		return nil, model.ErrInvalidArgument
+	}
+
+	// the currency is checked against the hold, which only the service knows
+	var amount money.Money
+	if req.Amount != nil {
+		if amount, err = fromProtoMoney(req.Amount); err != nil {
+			log.Printf("gRPC CaptureHold: Unsupported currency %q\n", req.Amount.GetCurrency())
+			return nil, err
+		}
+	}
+	caller, _ := mtls.Identity(ctx)
+
+	transaction, err := h.service.CaptureHold(ctx, holdID, amount, req.IdempotencyKey, userID, caller)
+	if err != nil {
+		log.Printf("gRPC CaptureHold: Failed to capture hold: %v\n", err)
+		return nil, err
+	}
+	return convertToProtoTransaction(transaction), nil
+}
+
+func (h *AccountHandler) VoidHold(ctx context.Context, req *proto.VoidHoldRequest) (*proto.Hold, error) {
+	userID, err := identity.UserID(ctx)
+	if err != nil {
+		log.Printf("gRPC VoidHold: %v\n", err)
+		return nil, err
+	}
+
+	holdID, err := uuid.Parse(req.HoldId)
+	if err != nil {
+		log.Printf("gRPC VoidHold: Failed to parse hold ID: %v\n", err)
+		return nil, model.ErrInvalidArgument
+	}
+	caller, _ := mtls.Identity(ctx)
+
+	hold, err := h.service.VoidHold(ctx, holdID, req.IdempotencyKey, userID, caller)
+	if err != nil {
+		log.Printf("gRPC VoidHold: Failed to void hold: %v\n", err)
+		return nil, err
+	}
+	return convertToProtoHold(hold), nil
+}
+
+// ReverseFee is made by the support console, which is authorized by its certificate: there is no end user.
+func (h *AccountHandler) ReverseFee(ctx context.Context, req *proto.ReverseFeeRequest) (*proto.Transaction, error) {
+	feeID, err := uuid.Parse(req.TransactionId)
+	if err != nil {
+		log.Printf("gRPC ReverseFee: Failed to parse transaction ID: %v\n", err)
+		return nil, model.ErrInvalidArgument
+	}
+
+	reversal, err := h.service.ReverseFee(ctx, feeID, req.Reason)
+	if err != nil {
+		log.Printf("gRPC ReverseFee: Failed to reverse fee: %v\n", err)
+		return nil, err
+	}
+	return convertToProtoTransaction(reversal), nil
+}
+
+// ReverseTransaction is made by the support console, authorized by its certificate, or by an end user through the API
+// Gateway, authorized by the forwarded access token.
+func (h *AccountHandler) ReverseTransaction(ctx context.Context, req *proto.ReverseTransactionRequest) (*proto.Transaction, error) {
+	caller, _ := mtls.Identity(ctx)
+	userID := uuid.Nil
+	if caller != mtls.SupportConsole {
+		var err error
+		if userID, err = identity.UserID(ctx); err != nil {
+			log.Printf("gRPC ReverseTransaction: %v\n", err)
+			return nil, err
+		}
+	}
+
+	transactionID, err := uuid.Parse(req.TransactionId)
+	if err != nil {
+		log.Printf("gRPC ReverseTransaction: Failed to parse transaction ID: %v\n", err)
+		return nil, model.ErrInvalidArgument
+	}
+	var amount money.Money
+	if req.Amount != nil {
+		if amount, err = fromProtoMoney(req.Amount); err != nil {
+			log.Printf("gRPC ReverseTransaction: Unsupported currency %q\n", req.Amount.GetCurrency())
+			return nil, err
+		}
+	}
+
+	reversal, err := h.service.ReverseTransaction(ctx, transactionID, amount, req.Reason, req.IdempotencyKey, userID, caller)
+	if err != nil {
+		log.Printf("gRPC ReverseTransaction: Failed to reverse transaction: %v\n", err)
+		return nil, err
+	}
+	return convertToProtoTransaction(reversal), nil
+}
+
+// GetAccountLimits is made by the support console, authorized by its certificate, or by an end user through the API
+// Gateway, authorized by the forwarded access token.
+func (h *AccountHandler) GetAccountLimits(ctx context.Context, req *proto.GetAccountLimitsRequest) (*proto.AccountLimits, error) {
+	caller, _ := mtls.Identity(ctx)
+	userID := uuid.Nil
+	if caller != mtls.SupportConsole {
+		var err error
+		if userID, err = identity.UserID(ctx); err != nil {
+			log.Printf("gRPC GetAccountLimits: %v\n", err)
+			return nil, err
+		}
+	}
+
+	accountID, err := uuid.Parse(req.AccountId)
+	if err != nil {
+		log.Printf("gRPC GetAccountLimits: Failed to parse account ID: %v\n", err)
+		return nil, model.ErrInvalidArgument
+	}
+
+	limits, err := h.service.GetAccountLimits(ctx, accountID, userID, caller)
+	if err != nil {
+		log.Printf("gRPC GetAccountLimits: Failed to get limits: %v\n", err)
+		return nil, err
+	}
+	return convertToProtoAccountLimits(accountID, limits), nil
+}
+
+// UpdateAccountLimits is made by the support console, authorized by its certificate, or by an end user through the
+// API Gateway, authorized by the forwarded access token.
+func (h *AccountHandler) UpdateAccountLimits(ctx context.Context, req *proto.UpdateAccountLimitsRequest) (*proto.AccountLimits, error) {
+	caller, _ := mtls.Identity(ctx)
+	userID := uuid.Nil
+	if caller != mtls.SupportConsole {
+		var err error
+		if userID, err = identity.UserID(ctx); err != nil {
+			log.Printf("gRPC UpdateAccountLimits: %v\n", err)
+			return nil, err
+		}
+	}
+
+	accountID, err := uuid.Parse(req.AccountId)
+	if err != nil {
+		log.Printf("gRPC UpdateAccountLimits: Failed to parse account ID: %v\n", err)
+		return nil, model.ErrInvalidArgument
+	}
+	limits, currency, err := fromProtoLimits(req.Limits)
+	if err != nil {
+		log.Printf("gRPC UpdateAccountLimits: Invalid limits: %v\n", err)
+		return nil, err
+	}
+
+	updated, err := h.service.UpdateAccountLimits(ctx, accountID, limits, currency, req.IdempotencyKey, userID, caller)
+	if err != nil {
+		log.Printf("gRPC UpdateAccountLimits: Failed to update limits: %v\n", err)
+		return nil, err
+	}
+	return convertToProtoAccountLimits(accountID, updated), nil
+}
+
+// GetUserLimits is made by the support console, authorized by its certificate, or by an end user through the API
+// Gateway, authorized by the forwarded access token.
+func (h *AccountHandler) GetUserLimits(ctx context.Context, req *proto.GetUserLimitsRequest) (*proto.UserLimits, error) {
+	userID, err := limitsUser(ctx, req.UserId)
+	if err != nil {
+		log.Printf("gRPC GetUserLimits: %v\n", err)
+		return nil, err
+	}
+
+	limits, err := h.service.GetUserLimits(ctx, userID, req.Currency)
+	if err != nil {
+		log.Printf("gRPC GetUserLimits: Failed to get limits: %v\n", err)
+		return nil, err
+	}
+	return convertToProtoUserLimits(limits), nil
+}
+
+// UpdateUserLimits is made by the support console, authorized by its certificate, or by an end user through the API
+// Gateway, authorized by the forwarded access token.
+func (h *AccountHandler) UpdateUserLimits(ctx context.Context, req *proto.UpdateUserLimitsRequest) (*proto.UserLimits, error) {
+	userID, err := limitsUser(ctx, req.UserId)
+	if err != nil {
+		log.Printf("gRPC UpdateUserLimits: %v\n", err)
+		return nil, err
+	}
+	limits, currency, err := fromProtoLimits(req.Limits)
+	if err != nil {
+		log.Printf("gRPC UpdateUserLimits: Invalid limits: %v\n", err)
+		return nil, err
+	}
+	if currency != "" && currency != req.Currency {
+		log.Printf("gRPC UpdateUserLimits: Limits in %q for %q\n", currency, req.Currency)
+		return nil, model.ErrCurrencyMismatch
+	}
+
+	updated, err := h.service.UpdateUserLimits(ctx, userID, req.Currency, limits, req.IdempotencyKey)
+	if err != nil {
+		log.Printf("gRPC UpdateUserLimits: Failed to update limits: %v\n", err)
+		return nil, err
+	}
+	return convertToProtoUserLimits(updated), nil
+}
+
+// limitsUser returns the user whose limits a request is about. The support console names them with userID, end users
+// can only be the user of their access token.
+func limitsUser(ctx context.Context, userID string) (uuid.UUID, error) {
+	if caller, _ := mtls.Identity(ctx); caller == mtls.SupportConsole {
+		id, err := uuid.Parse(userID)
+		if err != nil {
+			return uuid.Nil, model.ErrInvalidArgument
+		}
+		return id, nil
+	}
+
+	tokenUserID, err := identity.UserID(ctx)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if userID != "" && userID != tokenUserID.String() {
+		return uuid.Nil, model.ErrNotAuthorized
+	}
+	return tokenUserID, nil
+}
+
+func (h *AccountHandler) WatchAccount(req *proto.WatchAccountRequest, stream proto.AccountService_WatchAccountServer) error {
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+	go func() {
+		select {
+		case <-h.shutdown:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	userID, err := identity.UserID(ctx)
+	if err != nil {
+		log.Printf("gRPC WatchAccount: %v\n", err)
+		return err
+	}
+
+	accountID, err := uuid.Parse(req.AccountId)
+	if err != nil {
+		log.Printf("gRPC WatchAccount: Failed to parse account ID: %v\n", err)
+		return model.ErrInvalidArgument
+	}
+
+	watch, err := h.service.WatchAccount(ctx, accountID, req.AfterEventId, userID)
+	if err != nil {
+		log.Printf("gRPC WatchAccount: Failed to watch account: %v\n", err)
+		return err
+	}
+	// the headers tell the caller that the watch is authorized without waiting for the first event
+	if err = stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	for _, event := range watch.Initial {
+		if err = stream.Send(toProtoEvent(event)); err != nil {
+			return err
+		}
+	}
+	for {
+		events, err := watch.Next(ctx, h.watchHeartbeat)
+		if err != nil {
+			select {
+			case <-h.shutdown:
+				return status.Error(codes.Unavailable, "server is shutting down")
+			default:
+			}
+			if ctx.Err() != nil {
+				return status.FromContextError(ctx.Err()).Err()
+			}
+			log.Printf("gRPC WatchAccount: Failed to read events: %v\n", err)
+			return err
+		}
+		if len(events) == 0 {
+			events = append(events, &model.AccountEvent{Type: model.EventHeartbeat, AccountID: accountID})
+		}
+		for _, event := range events {
+			if err = stream.Send(toProtoEvent(event)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func toProtoEvent(event *model.AccountEvent) *proto.AccountEvent {
+	res := &proto.AccountEvent{
+		EventId:   event.EventID,
+		Type:      event.Type,
+		AccountId: event.AccountID.String(),
+		Version:   event.Version,
+	}
+	if event.Type != model.EventHeartbeat {
+		res.Balance = toProtoMoney(event.Currency, event.Balance)
+	}
+	if event.Transaction != nil {
+		res.Transaction = convertToProtoTransaction(event.Transaction)
+	}
+	return res
+}
+
+func isDebit(transactionType string) bool {
+	return transactionType == "DEBIT" || transactionType == "TRANSFER_DEBIT"
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"account/internal/mtls"
+	"account/model"
+	"account/proto"
+	"context"
+	"log"
+	"slices"
+)
+
+// Policy lists which services may call each RPC of the account service.
+// The API Gateway serves the end users, the transfer service moves money between accounts, and the support console
+// serves the support staff.
+var Policy = mtls.Policy{
+	proto.AccountService_CreateAccount_FullMethodName:                {mtls.APIGateway},
+	proto.AccountService_GetAccountsByUserId_FullMethodName:          {mtls.APIGateway},
+	proto.AccountService_GetAccountByAccountNumber_FullMethodName:    {mtls.APIGateway, mtls.TransferService},
+	proto.AccountService_GetAccountByAccountId_FullMethodName:        {mtls.APIGateway, mtls.TransferService},
+	proto.AccountService_DeleteAccountByAccountNumber_FullMethodName: {mtls.APIGateway},
+	proto.AccountService_UpdateAccountStatus_FullMethodName:          {mtls.APIGateway},
+	proto.AccountService_CreateTransaction_FullMethodName:            {mtls.APIGateway, mtls.TransferService},
+	proto.AccountService_GetTransactionsByAccountId_FullMethodName:   {mtls.APIGateway},
+	proto.AccountService_PlaceHold_FullMethodName:                    {mtls.APIGateway, mtls.TransferService},
+	proto.AccountService_CaptureHold_FullMethodName:                  {mtls.APIGateway, mtls.TransferService},
+	proto.AccountService_VoidHold_FullMethodName:                     {mtls.APIGateway, mtls.TransferService},
+	proto.AccountService_ValidateAccountNumber_FullMethodName:        {mtls.TransferService},
+	proto.AccountService_HasSufficientBalance_FullMethodName:         {mtls.TransferService},
+	proto.AccountService_WatchAccount_FullMethodName:                 {mtls.APIGateway},
+	proto.AccountService_ReverseFee_FullMethodName:                   {mtls.SupportConsole},
+	proto.AccountService_ReverseTransaction_FullMethodName:           {mtls.APIGateway, mtls.SupportConsole},
+	proto.AccountService_GetAccountLimits_FullMethodName:             {mtls.APIGateway, mtls.SupportConsole},
+	proto.AccountService_UpdateAccountLimits_FullMethodName:          {mtls.APIGateway, mtls.SupportConsole},
+	proto.AccountService_GetUserLimits_FullMethodName:                {mtls.APIGateway, mtls.SupportConsole},
+	proto.AccountService_UpdateUserLimits_FullMethodName:             {mtls.APIGateway, mtls.SupportConsole},
+}
+
+// StaffMethods are the RPCs made by the support console for the support staff. They carry no access token, since they
+// aren't made on behalf of a user: the certificate of the console is what authorizes them. ReverseTransaction and the
+// limits RPCs are also made by the API Gateway, with the access token of the user.
+var StaffMethods = []string{
+	proto.AccountService_ReverseFee_FullMethodName,
+	proto.AccountService_ReverseTransaction_FullMethodName,
+	proto.AccountService_GetAccountLimits_FullMethodName,
+	proto.AccountService_UpdateAccountLimits_FullMethodName,
+	proto.AccountService_GetUserLimits_FullMethodName,
+	proto.AccountService_UpdateUserLimits_FullMethodName,
+}
+
+// MayOmitToken reports whether an RPC to fullMethod may be made without an access token: only the StaffMethods, and
+// only by the support console. Without TLS no caller is verified, so they all need a token.
+func MayOmitToken(ctx context.Context, fullMethod string) bool {
+	caller, ok := mtls.Identity(ctx)
+	return ok && caller == mtls.SupportConsole && slices.Contains(StaffMethods, fullMethod)
+}
+
+// MayDelegate reports whether the caller may make RPCs on behalf of a user without their access token, see
+// identity.DelegateKey. Only the transfer service may, to execute the standing orders of the users on schedule, and
+// only within the RPCs its Policy allows.
+func MayDelegate(ctx context.Context) bool {
+	caller, _ := mtls.Identity(ctx)
+	return caller == mtls.TransferService
+}
+
+// AuthorizeRequest enforces the rules that depend on the content of the request.
+// Only the transfer service may create the two legs of a transfer. Otherwise, anyone able to reach
+// CreateTransaction through the API Gateway could mint TRANSFER_CREDIT transactions out of thin air.
+// The same goes for the holds of transfers, whose capture and void are checked by the service since only the hold
+// tells whether it belongs to a transfer.
+// ReverseFee has no end user to check, so it is rejected unless the support console calls it, even when TLS is
+// disabled and the Policy is skipped.
+func AuthorizeRequest(ctx context.Context, identity string, req any) error {
+	switch req := req.(type) {
+	case *proto.CreateTransactionRequest:
+		if isTransferLeg(req.TransactionType) && identity != mtls.TransferService {
+			log.Printf("AuthorizeRequest: %q may not create %s transactions\n", identity, req.TransactionType)
+			return model.ErrNotAuthorized
+		}
+	case *proto.ReverseFeeRequest:
+		if identity != mtls.SupportConsole {
+			log.Printf("AuthorizeRequest: %q may not reverse fees\n", identity)
+			return model.ErrNotAuthorized
+		}
+	case *proto.PlaceHoldRequest:
+		if isTransferLeg(req.TransactionType) && identity != mtls.TransferService {
+			log.Printf("AuthorizeRequest: %q may not place %s holds\n", identity, req.TransactionType)
+			return model.ErrNotAuthorized
+		}
+	}
+	return nil
+}
+
+func isTransferLeg(transactionType string) bool {
+	return transactionType == "TRANSFER_DEBIT" || transactionType == "TRANSFER_CREDIT"
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"account/internal/mtls"
+	"account/model"
+	"account/proto"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// callerContext returns a context whose peer presented a verified certificate of the service identity.
+func callerContext(identity string) context.Context {
+	cert := &x509.Certificate{URIs: []*url.URL{{Scheme: "spiffe", Host: mtls.TrustDomain, Path: "/" + identity}}}
+	return peer.NewContext(context.Background(), &peer.Peer{
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}},
+	})
+}
+
+func TestMayOmitToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		method string
+		want   bool
+	}{
+		{"support console", callerContext(mtls.SupportConsole), proto.AccountService_ReverseFee_FullMethodName, true},
+		{"support console, user method", callerContext(mtls.SupportConsole), proto.AccountService_CreateTransaction_FullMethodName, false},
+		{"api gateway", callerContext(mtls.APIGateway), proto.AccountService_ReverseTransaction_FullMethodName, false},
+		// without TLS there is no verified caller
+		{"no certificate", context.Background(), proto.AccountService_ReverseFee_FullMethodName, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, MayOmitToken(tt.ctx, tt.method))
+		})
+	}
+}
+
+func TestAuthorizeRequest(t *testing.T) {
+	tests := []struct {
+		name     string
+		identity string
+		req      any
+		want     error
+	}{
+		{"credit", mtls.APIGateway, &proto.CreateTransactionRequest{TransactionType: "CREDIT"}, nil},
+		{"transfer leg", mtls.TransferService, &proto.CreateTransactionRequest{TransactionType: "TRANSFER_CREDIT"}, nil},
+		{"transfer leg by the gateway", mtls.APIGateway, &proto.CreateTransactionRequest{TransactionType: "TRANSFER_CREDIT"}, model.ErrNotAuthorized},
+		{"transfer leg without TLS", "", &proto.CreateTransactionRequest{TransactionType: "TRANSFER_DEBIT"}, model.ErrNotAuthorized},
+		{"hold", mtls.APIGateway, &proto.PlaceHoldRequest{TransactionType: "DEBIT"}, nil},
+		{"transfer hold", mtls.TransferService, &proto.PlaceHoldRequest{TransactionType: "TRANSFER_DEBIT"}, nil},
+		{"transfer hold by the gateway", mtls.APIGateway, &proto.PlaceHoldRequest{TransactionType: "TRANSFER_DEBIT"}, model.ErrNotAuthorized},
+		{"fee reversal", mtls.SupportConsole, &proto.ReverseFeeRequest{}, nil},
+		{"fee reversal by the gateway", mtls.APIGateway, &proto.ReverseFeeRequest{}, model.ErrNotAuthorized},
+		{"fee reversal without TLS", "", &proto.ReverseFeeRequest{}, model.ErrNotAuthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := AuthorizeRequest(context.Background(), tt.identity, tt.req)
+			if tt.want == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,290 @@
+package handler
+
+import (
+	"account/config"
+	"account/db/initialize"
+	"account/internal/events"
+	"account/internal/identity"
+	"account/internal/redis"
+	"account/model"
+	"account/proto"
+	"account/repository"
+	"account/service"
+	"account/utils"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// fakeRedis keeps the event streams in memory, and misses the cache.
+type fakeRedis struct {
+	redis.RedisClient
+
+	mu      sync.Mutex
+	streams map[string][]goredis.XMessage
+	added   chan struct{} // closed and replaced whenever an event is added
+}
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{streams: map[string][]goredis.XMessage{}, added: make(chan struct{})}
+}
+
+// add publishes an event of the account, as events.Publish does, and returns its ID.
+func (f *fakeRedis) add(t *testing.T, account *model.Account, version int64) string {
+	data, err := json.Marshal(&model.Transaction{TransactionID: uuid.New(), AccountID: account.AccountID, TransactionType: "CREDIT"})
+	require.NoError(t, err)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := events.StreamKey(account.AccountID)
+	id := fmt.Sprintf("%d-0", len(f.streams[key])+1)
+	f.streams[key] = append(f.streams[key], goredis.XMessage{ID: id, Values: map[string]any{
+		"balance":     strconv.FormatInt(account.Balance, 10),
+		"currency":    account.Currency,
+		"version":     strconv.FormatInt(version, 10),
+		"transaction": string(data),
+	}})
+	close(f.added)
+	f.added = make(chan struct{})
+	return id
+}
+
+func (f *fakeRedis) Get(context.Context, string) *goredis.StringCmd {
+	return goredis.NewStringResult("", goredis.Nil)
+}
+
+func (f *fakeRedis) Set(context.Context, string, any, time.Duration) *goredis.StatusCmd {
+	return goredis.NewStatusResult("OK", nil)
+}
+
+func (f *fakeRedis) Del(context.Context, ...string) *goredis.IntCmd {
+	return goredis.NewIntResult(0, nil)
+}
+
+func (f *fakeRedis) XRangeN(_ context.Context, stream, _, _ string, count int64) *goredis.XMessageSliceCmd {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	messages := f.streams[stream]
+	return goredis.NewXMessageSliceCmdResult(messages[:min(int64(len(messages)), count)], nil)
+}
+
+func (f *fakeRedis) XRevRangeN(_ context.Context, stream, _, _ string, count int64) *goredis.XMessageSliceCmd {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var messages []goredis.XMessage
+	for i := len(f.streams[stream]) - 1; i >= 0 && int64(len(messages)) < count; i-- {
+		messages = append(messages, f.streams[stream][i])
+	}
+	return goredis.NewXMessageSliceCmdResult(messages, nil)
+}
+
+func (f *fakeRedis) XRead(ctx context.Context, a *goredis.XReadArgs) *goredis.XStreamSliceCmd {
+	stream, after := a.Streams[0], a.Streams[1]
+	timeout := time.After(a.Block)
+	for {
+		f.mu.Lock()
+		var messages []goredis.XMessage
+		for _, msg := range f.streams[stream] {
+			if after == "0" || compareIDs(msg.ID, after) > 0 {
+				messages = append(messages, msg)
+			}
+		}
+		added := f.added
+		f.mu.Unlock()
+		if len(messages) > 0 {
+			return goredis.NewXStreamSliceCmdResult([]goredis.XStream{{Stream: stream, Messages: messages}}, nil)
+		}
+		select {
+		case <-added:
+		case <-timeout:
+			return goredis.NewXStreamSliceCmdResult(nil, goredis.Nil)
+		case <-ctx.Done():
+			return goredis.NewXStreamSliceCmdResult(nil, ctx.Err())
+		}
+	}
+}
+
+// compareIDs orders the IDs of the fake, whose sequence is always 0.
+func compareIDs(a, b string) int {
+	var aMs, bMs int
+	fmt.Sscanf(a, "%d-", &aMs)
+	fmt.Sscanf(b, "%d-", &bMs)
+	return aMs - bMs
+}
+
+// fakeWatchStream is the server side of a WatchAccount stream. The events sent are received on events.
+type fakeWatchStream struct {
+	grpc.ServerStream
+	ctx        context.Context
+	events     chan *proto.AccountEvent
+	headerSent bool
+}
+
+func newFakeWatchStream(ctx context.Context) *fakeWatchStream {
+	return &fakeWatchStream{ctx: ctx, events: make(chan *proto.AccountEvent, 100)}
+}
+
+func (s *fakeWatchStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *fakeWatchStream) SendHeader(metadata.MD) error {
+	s.headerSent = true
+	return nil
+}
+
+func (s *fakeWatchStream) Send(event *proto.AccountEvent) error {
+	s.events <- event
+	return nil
+}
+
+func (s *fakeWatchStream) next(t *testing.T) *proto.AccountEvent {
+	t.Helper()
+	select {
+	case event := <-s.events:
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event sent")
+		return nil
+	}
+}
+
+// setupWatch returns a handler sending heartbeats every 50ms, and a new account watched through the fake Redis.
+func setupWatch(t *testing.T) (*AccountHandler, *model.Account, *fakeRedis) {
+	cfg, err := config.LoadDB()
+	require.NoError(t, err)
+	db := initialize.ConnectDB(cfg)
+	t.Cleanup(func() { require.NoError(t, db.Close()) })
+
+	fake := newFakeRedis()
+	previous := redis.Client
+	redis.Client = fake
+	t.Cleanup(func() { redis.Client = previous })
+
+	svc := service.NewAccountService(repository.NewAccountRepository(db), db)
+	user := utils.RandomUser()
+	key := utils.RandomIdempotencyKey()
+	account, err := svc.CreateAccount(context.Background(), user, "", key, user.UserID)
+	require.NoError(t, err)
+	require.NoError(t, svc.DeleteIdempotencyKeyByID(context.Background(), key))
+	t.Cleanup(func() {
+		for _, query := range []string{
+			"DELETE FROM transactions WHERE account_id = $1",
+			"DELETE FROM accounts WHERE id = $1",
+		} {
+			_, err := db.ExecContext(context.Background(), query, account.AccountID)
+			require.NoError(t, err)
+		}
+	})
+	return NewAccountHandler(svc, 50*time.Millisecond), account, fake
+}
+
+// watch runs WatchAccount in the background as the user, and returns its stream, a function cancelling it as the
+// client would, and the channel of its error.
+func watch(h *AccountHandler, userID uuid.UUID, req *proto.WatchAccountRequest) (*fakeWatchStream, context.CancelFunc, <-chan error) {
+	ctx, cancel := context.WithCancel(identity.NewContext(context.Background(), identity.Principal{UserID: userID}))
+	stream := newFakeWatchStream(ctx)
+	done := make(chan error, 1)
+	go func() { done <- h.WatchAccount(req, stream) }()
+	return stream, cancel, done
+}
+
+func requireEnded(t *testing.T, done <-chan error, want codes.Code) {
+	t.Helper()
+	select {
+	case err := <-done:
+		require.Equal(t, want, status.Code(err), "%v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch didn't end")
+	}
+}
+
+func TestWatchAccount_Ownership(t *testing.T) {
+	h, account, _ := setupWatch(t)
+
+	// another user
+	stream, cancel, done := watch(h, uuid.New(), &proto.WatchAccountRequest{AccountId: account.AccountID.String()})
+	defer cancel()
+	requireEnded(t, done, codes.PermissionDenied)
+	require.False(t, stream.headerSent)
+	require.Empty(t, stream.events)
+
+	// no access token
+	stream = newFakeWatchStream(context.Background())
+	err := h.WatchAccount(&proto.WatchAccountRequest{AccountId: account.AccountID.String()}, stream)
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+	require.False(t, stream.headerSent)
+}
+
+// A new watch starts with a snapshot, then sends the events as they are published, and heartbeats in between.
+func TestWatchAccount_Events(t *testing.T) {
+	h, account, fake := setupWatch(t)
+	stream, cancel, done := watch(h, account.UserID, &proto.WatchAccountRequest{AccountId: account.AccountID.String()})
+
+	snapshot := stream.next(t)
+	require.True(t, stream.headerSent)
+	require.Equal(t, model.EventSnapshot, snapshot.Type)
+	require.Equal(t, "0-0", snapshot.EventId)
+	require.Equal(t, account.Balance, snapshot.Balance.GetUnits())
+
+	heartbeat := stream.next(t)
+	require.Equal(t, model.EventHeartbeat, heartbeat.Type)
+	require.Nil(t, heartbeat.Balance)
+
+	id := fake.add(t, account, account.Version+1)
+	event := stream.next(t)
+	for event.Type == model.EventHeartbeat {
+		event = stream.next(t)
+	}
+	require.Equal(t, model.EventTransaction, event.Type)
+	require.Equal(t, id, event.EventId)
+	require.NotNil(t, event.Transaction)
+
+	// the client going away ends the stream
+	cancel()
+	requireEnded(t, done, codes.Canceled)
+}
+
+// A watch resuming after a retained event sends the events after it instead of a snapshot.
+func TestWatchAccount_Resume(t *testing.T) {
+	h, account, fake := setupWatch(t)
+	first := fake.add(t, account, account.Version+1)
+	second := fake.add(t, account, account.Version+2)
+
+	stream, cancel, done := watch(h, account.UserID, &proto.WatchAccountRequest{AccountId: account.AccountID.String(), AfterEventId: first})
+	event := stream.next(t)
+	require.Equal(t, model.EventTransaction, event.Type)
+	require.Equal(t, second, event.EventId)
+	cancel()
+	requireEnded(t, done, codes.Canceled)
+
+	// an event that isn't retained anymore, or never was, starts over with a snapshot
+	stream, cancel, done = watch(h, account.UserID, &proto.WatchAccountRequest{AccountId: account.AccountID.String(), AfterEventId: "99-0"})
+	event = stream.next(t)
+	require.Equal(t, model.EventSnapshot, event.Type)
+	require.Equal(t, second, event.EventId)
+	cancel()
+	requireEnded(t, done, codes.Canceled)
+}
+
+// Shutdown ends the open watches with UNAVAILABLE, so that the clients reconnect to another instance.
+func TestWatchAccount_Shutdown(t *testing.T) {
+	h, account, _ := setupWatch(t)
+	stream, cancel, done := watch(h, account.UserID, &proto.WatchAccountRequest{AccountId: account.AccountID.String()})
+	defer cancel()
+	stream.next(t)
+
+	h.Shutdown()
+	requireEnded(t, done, codes.Unavailable)
+}
@@ -0,0 +1,78 @@
+// Package accountnumber builds and validates account numbers.
+//
+// An account number has 12 digits: a 4-digit prefix identifying the branch, a 7-digit serial and a Luhn check digit,
+// which catches any single mistyped digit and most swaps of adjacent digits. The serials come from a counter per
+// prefix in Postgres, so that two accounts never get the same number, and are permuted so that accounts opened one
+// after the other don't get consecutive numbers. Each branch can open 10,000,000 accounts.
+//
+// Accounts opened before check digits were introduced keep their random 10-digit number, which Valid accepts as is.
+package accountnumber
+
+import (
+	"fmt"
+	"strconv"
+)
+
+const (
+	MinPrefix     = 1000
+	MaxPrefix     = 9999
+	DefaultPrefix = MinPrefix
+
+	// serials are below serialSpace, which is also the limit of the account_number_serials counters
+	serialSpace = 10_000_000
+	// serial -> (serial * permMultiplier + permOffset) % serialSpace is a permutation of the serials, since
+	// permMultiplier is coprime with serialSpace (neither divisible by 2 nor by 5)
+	permMultiplier = 7_368_787
+	permOffset     = 2_718_281
+
+	// bounds of the legacy account numbers, drawn at random before check digits
+	legacyMin = 1_000_000_000
+	legacyMax = 2_000_999_999
+)
+
+// New returns the account number of the given prefix and serial. It fails if either is out of range, e.g. once the
+// sequence of the serials is exhausted.
+func New(prefix int64, serial int64) (int64, error) {
+	if prefix < MinPrefix || prefix > MaxPrefix {
+		return 0, fmt.Errorf("accountnumber: prefix %d is not a 4-digit number", prefix)
+	}
+	if serial < 0 || serial >= serialSpace {
+		return 0, fmt.Errorf("accountnumber: serial %d is out of range", serial)
+	}
+	permuted := (serial*permMultiplier + permOffset) % serialSpace
+	payload := prefix*serialSpace + permuted
+	return payload*10 + checkDigit(payload), nil
+}
+
+// Valid reports whether n is a well-formed account number: 12 digits whose last one is the check digit of the
+// others, or a legacy 10-digit number. It doesn't tell whether the account exists.
+func Valid(n int64) bool {
+	if n >= legacyMin && n <= legacyMax {
+		return true
+	}
+	if n < MinPrefix*serialSpace*10 || n > (MaxPrefix+1)*serialSpace*10-1 {
+		return false
+	}
+	return checkDigit(n/10) == n%10
+}
+
+// checkDigit returns the Luhn check digit of payload: the digit that makes payload followed by it pass the Luhn check.
+func checkDigit(payload int64) int64 {
+	digits := strconv.FormatInt(payload, 10)
+	sum := 0
+	// the check digit will be the rightmost digit, so the doubled digits are the rightmost one of the payload and every
+	// other one from there
+	double := true
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return int64((10 - sum%10) % 10)
+}
@@ -0,0 +1,58 @@
+package accountnumber
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	seen := make(map[int64]bool)
+	for serial := range int64(1000) {
+		n, err := New(1234, serial)
+		require.NoError(t, err)
+		require.True(t, Valid(n), n)
+		require.Equal(t, int64(1234), n/(serialSpace*10), "the prefix leads the account number")
+		require.False(t, seen[n], "serial %d got the number of another serial", serial)
+		seen[n] = true
+	}
+
+	_, err := New(999, 0)
+	require.Error(t, err)
+	_, err = New(10_000, 0)
+	require.Error(t, err)
+	_, err = New(DefaultPrefix, serialSpace)
+	require.Error(t, err)
+	_, err = New(DefaultPrefix, -1)
+	require.Error(t, err)
+}
+
+func TestValid(t *testing.T) {
+	n, err := New(DefaultPrefix, 42)
+	require.NoError(t, err)
+	require.True(t, Valid(n))
+
+	// any single mistyped digit is caught
+	for pos, pow := 0, int64(1); pos < 12; pos, pow = pos+1, pow*10 {
+		digit := n / pow % 10
+		for d := range int64(10) {
+			if d == digit {
+				continue
+			}
+			typo := n + (d-digit)*pow
+			require.False(t, Valid(typo), "%d passed for %d", typo, n)
+		}
+	}
+
+	// legacy account numbers are accepted as is
+	require.True(t, Valid(legacyMin))
+	require.True(t, Valid(legacyMax))
+	require.False(t, Valid(0))
+	require.False(t, Valid(-n))
+	require.False(t, Valid(n*10))
+}
+
+func TestCheckDigit(t *testing.T) {
+	// the example of https://en.wikipedia.org/wiki/Luhn_algorithm
+	require.Equal(t, int64(3), checkDigit(7992739871))
+}
@@ -12,10 +12,10 @@ import (
 	"github.com/google/uuid"
 )
 
-const (
-	AccountKeyPrefix = "acct"
-	TTL              = 5 * time.Second
-)
+const AccountKeyPrefix = "acct"
+
+// TTL of cached accounts. Overridden at startup from config.RedisConfig.CacheTTL.
+var TTL = 5 * time.Second
 
 func AccountKey(accountID uuid.UUID) string {
 	return fmt.Sprintf("%s:{%s}", AccountKeyPrefix, accountID.String())
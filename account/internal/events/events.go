@@ -0,0 +1,168 @@
+// Package events publishes the committed transactions of every account to a Redis stream, and reads them back for
+// the WatchAccount RPC.
+//
+// Every account has its own stream, capped to its last Retention events. The IDs of the stream entries are the event
+// IDs sent to the clients, so a client can resume a broken stream from the last ID it received as long as the events
+// after it are still retained.
+package events
+
+import (
+	"account/internal/redis"
+	"account/model"
+	"account/money"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+const StreamKeyPrefix = "events"
+
+// Retention is the number of events kept per account. Overridden at startup from config.RedisConfig.EventRetention.
+var Retention int64 = 1000
+
+// TTL of the stream of an account without any new event.
+var TTL = 24 * time.Hour
+
+func StreamKey(accountID uuid.UUID) string {
+	return fmt.Sprintf("%s:{%s}", StreamKeyPrefix, accountID.String())
+}
+
+// Publish appends a committed transaction to the stream of its account, and returns the ID of the event.
+// account is the account as updated by the transaction.
+func Publish(ctx context.Context, account *model.Account, transaction *model.Transaction) (string, error) {
+	data, err := json.Marshal(transaction)
+	if err != nil {
+		return "", err
+	}
+	key := StreamKey(account.AccountID)
+	id, err := redis.Client.XAdd(ctx, &goredis.XAddArgs{
+		Stream: key,
+		MaxLen: Retention,
+		Approx: true,
+		Values: map[string]any{
+			"balance":     account.Balance,
+			"currency":    account.Currency,
+			"version":     account.Version,
+			"transaction": data,
+		},
+	}).Result()
+	if err != nil {
+		return "", err
+	}
+	return id, redis.Client.Expire(ctx, key, TTL).Err()
+}
+
+// Bounds returns the IDs of the oldest and of the latest retained events of an account.
+// Both are empty if the account has no retained event.
+func Bounds(ctx context.Context, accountID uuid.UUID) (first, last string, err error) {
+	key := StreamKey(accountID)
+	oldest, err := redis.Client.XRangeN(ctx, key, "-", "+", 1).Result()
+	if err != nil {
+		return "", "", err
+	}
+	latest, err := redis.Client.XRevRangeN(ctx, key, "+", "-", 1).Result()
+	if err != nil {
+		return "", "", err
+	}
+	if len(oldest) == 0 || len(latest) == 0 {
+		return "", "", nil
+	}
+	return oldest[0].ID, latest[0].ID, nil
+}
+
+// Retained reports whether every event after id is still retained, given the bounds returned by Bounds.
+// An id older than the oldest retained event may be followed by trimmed events, and an id newer than the latest one
+// was never published.
+func Retained(id, first, last string) bool {
+	if first == "" {
+		return false
+	}
+	return Compare(first, id) <= 0 && Compare(id, last) <= 0
+}
+
+// Read returns the events of an account published after afterID, waiting up to block for one if there is none yet.
+// It returns no event and no error if nothing was published in time. afterID "0" reads from the first retained event.
+func Read(ctx context.Context, accountID uuid.UUID, afterID string, block time.Duration) ([]*model.AccountEvent, error) {
+	if afterID == "" {
+		afterID = "0"
+	}
+	streams, err := redis.Client.XRead(ctx, &goredis.XReadArgs{
+		Streams: []string{StreamKey(accountID), afterID},
+		Count:   100,
+		Block:   block,
+	}).Result()
+	if errors.Is(err, goredis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var events []*model.AccountEvent
+	for _, stream := range streams {
+		for _, msg := range stream.Messages {
+			event, err := decode(accountID, msg)
+			if err != nil {
+				return nil, fmt.Errorf("event %s: %w", msg.ID, err)
+			}
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func decode(accountID uuid.UUID, msg goredis.XMessage) (*model.AccountEvent, error) {
+	event := &model.AccountEvent{
+		EventID:     msg.ID,
+		Type:        model.EventTransaction,
+		AccountID:   accountID,
+		Transaction: &model.Transaction{},
+	}
+	var err error
+	balance, _ := msg.Values["balance"].(string)
+	if event.Balance, err = strconv.ParseInt(balance, 10, 64); err != nil {
+		return nil, err
+	}
+	version, _ := msg.Values["version"].(string)
+	if event.Version, err = strconv.ParseInt(version, 10, 64); err != nil {
+		return nil, err
+	}
+	transaction, _ := msg.Values["transaction"].(string)
+	if err = json.Unmarshal([]byte(transaction), event.Transaction); err != nil {
+		return nil, err
+	}
+	// events published before accounts had a currency are in the currency of the older accounts
+	if event.Currency, _ = msg.Values["currency"].(string); event.Currency == "" {
+		event.Currency = money.DefaultCurrency
+	}
+	if event.Transaction.Currency == "" {
+		event.Transaction.Currency = event.Currency
+	}
+	return event, nil
+}
+
+// Compare orders two stream IDs of the form "<milliseconds>-<sequence>", and returns -1, 0 or +1.
+// Malformed IDs sort first.
+func Compare(a, b string) int {
+	aMs, aSeq := parseID(a)
+	bMs, bSeq := parseID(b)
+	if c := cmp.Compare(aMs, bMs); c != 0 {
+		return c
+	}
+	return cmp.Compare(aSeq, bSeq)
+}
+
+func parseID(id string) (ms, seq uint64) {
+	msPart, seqPart, _ := strings.Cut(id, "-")
+	ms, _ = strconv.ParseUint(msPart, 10, 64)
+	seq, _ = strconv.ParseUint(seqPart, 10, 64)
+	return ms, seq
+}
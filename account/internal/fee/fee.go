@@ -0,0 +1,60 @@
+// Package fee computes the fees charged on the accounts, from the rules of their product.
+//
+// A rule is triggered by an event, such as the debit of a transfer, which has a base amount, such as the transferred
+// amount. The fee is a fixed amount plus a percentage of the base amount, rounded half up to a minor unit, then raised
+// to the minimum and capped to the maximum of the rule. It is waived altogether if the balance of the account is at
+// least the waiver threshold of the rule.
+package fee
+
+import (
+	"account/model"
+	"math/big"
+)
+
+// Compute returns the fee charged by rule on base, for an account with balance. It returns 0 if the fee is waived.
+// base is taken as its absolute value.
+func Compute(rule *model.FeeRule, base, balance int64) int64 {
+	if rule.WaiveBalanceAtLeast > 0 && balance >= rule.WaiveBalanceAtLeast {
+		return 0
+	}
+
+	// computed exactly, since base times the rate may overflow an int64
+	fee := new(big.Int).Abs(big.NewInt(base))
+	fee.Mul(fee, big.NewInt(int64(rule.PercentageBps)))
+	fee.Add(fee, big.NewInt(5_000))
+	fee.Quo(fee, big.NewInt(10_000))
+	fee.Add(fee, big.NewInt(rule.FixedAmount))
+
+	if fee.Cmp(big.NewInt(rule.MinAmount)) < 0 {
+		fee.SetInt64(rule.MinAmount)
+	}
+	if rule.MaxAmount > 0 && fee.Cmp(big.NewInt(rule.MaxAmount)) > 0 {
+		fee.SetInt64(rule.MaxAmount)
+	}
+	if !fee.IsInt64() {
+		// an uncapped percentage of an amount close to the limits
+		return maxInt64
+	}
+	return fee.Int64()
+}
+
+const maxInt64 = 1<<63 - 1
+
+// Payable returns the part of fee an account with balance can pay, going at most overdraftLimit below zero.
+// Fees never overdraw an account beyond its limit: the rest is forgone.
+func Payable(fee, balance, overdraftLimit int64) int64 {
+	available := balance + overdraftLimit
+	if available <= 0 {
+		return 0
+	}
+	return min(fee, available)
+}
+
+// OverdraftBase returns the base of the overdraft fee of a debit of amount (negative) that left the balance at
+// balance: the part of the debit below zero.
+func OverdraftBase(amount, balance int64) int64 {
+	if balance >= 0 {
+		return 0
+	}
+	return min(-balance, -amount)
+}
@@ -0,0 +1,50 @@
+package fee
+
+import (
+	"account/model"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompute(t *testing.T) {
+	transfer := &model.FeeRule{FixedAmount: 25, PercentageBps: 10, MaxAmount: 500, WaiveBalanceAtLeast: 500_000}
+	require.Equal(t, int64(25+10), Compute(transfer, -10_000, 0))
+	// 0.1% of 12.345 is 1.2345, rounded half up
+	require.Equal(t, int64(25+1), Compute(transfer, -1_234, 0))
+	require.Equal(t, int64(25+2), Compute(transfer, -1_500, 0))
+	// capped
+	require.Equal(t, int64(500), Compute(transfer, -10_000_000, 0))
+	require.Equal(t, int64(500), Compute(transfer, math.MinInt64, 0))
+	// waived
+	require.Equal(t, int64(0), Compute(transfer, -10_000, 500_000))
+	require.Equal(t, int64(35), Compute(transfer, -10_000, 499_999))
+
+	minimum := &model.FeeRule{PercentageBps: 100, MinAmount: 50}
+	require.Equal(t, int64(50), Compute(minimum, 1_000, 0))
+	require.Equal(t, int64(100), Compute(minimum, 10_000, 0))
+
+	uncapped := &model.FeeRule{PercentageBps: 20_000}
+	require.Equal(t, int64(math.MaxInt64), Compute(uncapped, math.MaxInt64, 0))
+
+	maintenance := &model.FeeRule{FixedAmount: 500, WaiveBalanceAtLeast: 150_000}
+	require.Equal(t, int64(500), Compute(maintenance, 0, -100))
+	require.Equal(t, int64(0), Compute(maintenance, 0, 150_000))
+}
+
+func TestPayable(t *testing.T) {
+	require.Equal(t, int64(500), Payable(500, 1_000, 0))
+	require.Equal(t, int64(300), Payable(500, 300, 0))
+	require.Equal(t, int64(0), Payable(500, 0, 0))
+	require.Equal(t, int64(0), Payable(500, -100, 0))
+	require.Equal(t, int64(500), Payable(500, -100, 10_000))
+	require.Equal(t, int64(100), Payable(500, -9_900, 10_000))
+}
+
+func TestOverdraftBase(t *testing.T) {
+	require.Equal(t, int64(0), OverdraftBase(-100, 0))
+	require.Equal(t, int64(40), OverdraftBase(-100, -40))
+	// the account was overdrawn already: only the debit counts
+	require.Equal(t, int64(100), OverdraftBase(-100, -500))
+}
@@ -0,0 +1,93 @@
+package health
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// CheckFunc reports whether a dependency (Postgres, Redis, ...) is reachable.
+type CheckFunc func(ctx context.Context) error
+
+// Checker wraps the standard grpc.health.v1 server and periodically probes the
+// dependencies of the service. If any dependency check fails, every registered
+// service (including the overall "" service) is reported as NOT_SERVING.
+type Checker struct {
+	server   *health.Server
+	services []string
+	interval time.Duration
+	timeout  time.Duration
+
+	mu     sync.Mutex
+	checks map[string]CheckFunc
+}
+
+// NewChecker creates a Checker for the given fully-qualified gRPC service names (e.g. "proto.AccountService").
+// The overall server health ("") is always tracked.
+func NewChecker(interval time.Duration, services ...string) *Checker {
+	return &Checker{
+		server:   health.NewServer(),
+		services: append([]string{""}, services...),
+		interval: interval,
+		timeout:  interval / 2,
+		checks:   make(map[string]CheckFunc),
+	}
+}
+
+// Server returns the grpc.health.v1 implementation to register on the gRPC server.
+func (c *Checker) Server() healthpb.HealthServer {
+	return c.server
+}
+
+// AddCheck registers a named dependency check.
+func (c *Checker) AddCheck(name string, fn CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks[name] = fn
+}
+
+// Run probes the dependencies once immediately, then every interval until ctx is cancelled.
+func (c *Checker) Run(ctx context.Context) {
+	c.probe(ctx)
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.probe(ctx)
+		}
+	}
+}
+
+// Shutdown sets every service to NOT_SERVING and ignores further updates.
+// Call it before draining the gRPC server so that load balancers stop routing new requests to us.
+func (c *Checker) Shutdown() {
+	c.server.Shutdown()
+}
+
+func (c *Checker) probe(ctx context.Context) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	status := healthpb.HealthCheckResponse_SERVING
+	for name, check := range c.checks {
+		checkCtx, cancel := context.WithTimeout(ctx, c.timeout)
+		err := check(checkCtx)
+		cancel()
+		if err != nil {
+			log.Printf("health: dependency %s is unreachable: %v\n", name, err)
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+	}
+
+	for _, service := range c.services {
+		c.server.SetServingStatus(service, status)
+	}
+}
@@ -0,0 +1,73 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const testService = "proto.AccountService"
+
+func requireStatus(t *testing.T, c *Checker, want healthpb.HealthCheckResponse_ServingStatus) {
+	t.Helper()
+	for _, service := range []string{"", testService} {
+		res, err := c.Server().Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+		require.NoError(t, err)
+		require.Equal(t, want, res.Status, "service %q", service)
+	}
+}
+
+func TestChecker(t *testing.T) {
+	var postgresErr, redisErr error
+	c := NewChecker(time.Second, testService)
+	c.AddCheck("postgres", func(context.Context) error { return postgresErr })
+	c.AddCheck("redis", func(context.Context) error { return redisErr })
+
+	c.probe(context.Background())
+	requireStatus(t, c, healthpb.HealthCheckResponse_SERVING)
+
+	// any dependency down takes every service out of rotation
+	postgresErr = errors.New("connection refused")
+	c.probe(context.Background())
+	requireStatus(t, c, healthpb.HealthCheckResponse_NOT_SERVING)
+
+	postgresErr = nil
+	redisErr = errors.New("connection refused")
+	c.probe(context.Background())
+	requireStatus(t, c, healthpb.HealthCheckResponse_NOT_SERVING)
+
+	// and they come back once it recovers
+	redisErr = nil
+	c.probe(context.Background())
+	requireStatus(t, c, healthpb.HealthCheckResponse_SERVING)
+
+	// checks that hang are cut short by the timeout
+	c.AddCheck("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	c.probe(context.Background())
+	requireStatus(t, c, healthpb.HealthCheckResponse_NOT_SERVING)
+}
+
+func TestChecker_Shutdown(t *testing.T) {
+	c := NewChecker(10*time.Millisecond, testService)
+	c.AddCheck("postgres", func(context.Context) error { return nil })
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx)
+
+	require.Eventually(t, func() bool {
+		res, err := c.Server().Check(context.Background(), &healthpb.HealthCheckRequest{Service: testService})
+		return err == nil && res.Status == healthpb.HealthCheckResponse_SERVING
+	}, time.Second, 5*time.Millisecond)
+
+	// the probes that keep running don't bring it back
+	c.Shutdown()
+	time.Sleep(30 * time.Millisecond)
+	requireStatus(t, c, healthpb.HealthCheckResponse_NOT_SERVING)
+}
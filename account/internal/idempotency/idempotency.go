@@ -0,0 +1,63 @@
+// Package idempotency identifies the requests an idempotency key is claimed for, and tells the callers of the mutating
+// RPCs whether their response was replayed from an idempotency key.
+//
+// A request retried with the idempotency key of a completed request gets the response of the first one, without being
+// executed again. The "idempotent-replayed" header of the RPC tells the API Gateway so, which relays it to the client as
+// the Idempotent-Replayed HTTP header. A request that failed for a reason retrying can't fix, e.g. insufficient funds,
+// records its error instead, which its retries get back the same way.
+//
+// A key reused for another request is rejected, since replaying the response of the first request would tell the
+// client a request succeeded when it never ran.
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// ReplayedHeader is the gRPC header set to "true" on the replayed responses.
+const ReplayedHeader = "idempotent-replayed"
+
+// MarkReplayed sets the ReplayedHeader of the RPC of ctx.
+func MarkReplayed(ctx context.Context) {
+	// fails only outside of a unary RPC, e.g. when the service is called by the tests
+	_ = grpc.SetHeader(ctx, metadata.Pairs(ReplayedHeader, "true"))
+}
+
+// RequestHash returns the canonical hash of the parameters of a request, stored with its idempotency key.
+// params should be a struct, whose fields are always encoded in the same order. Secrets such as passwords must not be
+// part of it, the hash being fast to brute-force.
+func RequestHash(params any) (string, error) {
+	encoded, err := json.Marshal(params)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// EncodeError encodes the status of a request that failed, stored as the response of its idempotency key.
+func EncodeError(err error) (string, error) {
+	encoded, err := protojson.Marshal(status.Convert(err).Proto())
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+// DecodeError returns the status encoded by EncodeError, error details included.
+func DecodeError(encoded string) (*status.Status, error) {
+	st := &spb.Status{}
+	if err := protojson.Unmarshal([]byte(encoded), st); err != nil {
+		return nil, err
+	}
+	return status.FromProto(st), nil
+}
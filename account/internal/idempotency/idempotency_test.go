@@ -0,0 +1,63 @@
+package idempotency
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestRequestHash(t *testing.T) {
+	type params struct {
+		AccountID string
+		Amount    int64
+	}
+	hash, err := RequestHash(params{"a", 100})
+	require.NoError(t, err)
+	require.Len(t, hash, 64)
+
+	same, err := RequestHash(params{"a", 100})
+	require.NoError(t, err)
+	require.Equal(t, hash, same)
+
+	for _, other := range []params{{"a", 101}, {"b", 100}, {}} {
+		otherHash, err := RequestHash(other)
+		require.NoError(t, err)
+		require.NotEqual(t, hash, otherHash, "%+v", other)
+	}
+
+	_, err = RequestHash(func() {})
+	require.Error(t, err)
+}
+
+func TestEncodeError(t *testing.T) {
+	info := &errdetails.ErrorInfo{Reason: "INSUFFICIENT_FUNDS", Domain: "account", Metadata: map[string]string{"balance": "10"}}
+	st, err := status.New(codes.FailedPrecondition, "insufficient funds").WithDetails(info)
+	require.NoError(t, err)
+
+	encoded, err := EncodeError(st.Err())
+	require.NoError(t, err)
+	decoded, err := DecodeError(encoded)
+	require.NoError(t, err)
+	require.Equal(t, codes.FailedPrecondition, decoded.Code())
+	require.Equal(t, "insufficient funds", decoded.Message())
+	require.Len(t, decoded.Details(), 1)
+	require.True(t, proto.Equal(info, decoded.Details()[0].(*errdetails.ErrorInfo)))
+
+	// errors that aren't statuses are stored as Unknown
+	encoded, err = EncodeError(errUnknown{})
+	require.NoError(t, err)
+	decoded, err = DecodeError(encoded)
+	require.NoError(t, err)
+	require.Equal(t, codes.Unknown, decoded.Code())
+
+	_, err = DecodeError("not json")
+	require.Error(t, err)
+}
+
+type errUnknown struct{}
+
+func (errUnknown) Error() string { return "unknown" }
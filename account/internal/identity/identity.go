@@ -0,0 +1,184 @@
+// Package identity verifies the end user on whose behalf an RPC is made.
+//
+// The API Gateway forwards the access token of the user in the "authorization" metadata of every RPC it makes for an
+// authenticated request. The token is verified again here instead of trusting a user_id field of the request, so that
+// reaching the gRPC port is not enough to impersonate a user. The fingerprint binding of the token is only checked by
+// the gateway, since it is the only one to see the fingerprint cookie.
+//
+// The few services trusted to act on behalf of a user without their token, such as the transfer service executing
+// standing orders on schedule, name the user in the "x-on-behalf-of" metadata instead.
+package identity
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// MetadataKey is the gRPC metadata key carrying "Bearer <access token>".
+	MetadataKey = "authorization"
+	// Issuer is the issuer of the access tokens, i.e. the auth service.
+	Issuer = "auth-service"
+	// DelegateKey is the gRPC metadata key carrying the ID of the user on whose behalf a trusted service makes an RPC
+	// without their access token.
+	DelegateKey = "x-on-behalf-of"
+)
+
+var (
+	ErrMissingToken     = status.Error(codes.Unauthenticated, "missing access token")
+	ErrInvalidToken     = status.Error(codes.Unauthenticated, "invalid access token")
+	ErrInvalidDelegate  = status.Error(codes.Unauthenticated, "invalid delegated user")
+	ErrDelegationDenied = status.Error(codes.PermissionDenied, "caller may not act on behalf of users")
+)
+
+// Principal is the authenticated end user of a request.
+type Principal struct {
+	UserID uuid.UUID
+}
+
+type principalKey struct{}
+
+func NewContext(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// UserID returns the ID of the authenticated user of the request, or an Unauthenticated error if there is none.
+func UserID(ctx context.Context) (uuid.UUID, error) {
+	p, ok := FromContext(ctx)
+	if !ok {
+		return uuid.Nil, ErrMissingToken
+	}
+	return p.UserID, nil
+}
+
+// Verify checks the signature, issuer and expiry of an access token and returns its subject.
+func Verify(token string, key []byte) (Principal, error) {
+	claims := &jwt.RegisteredClaims{}
+	_, err := jwt.ParseWithClaims(token, claims,
+		func(*jwt.Token) (any, error) { return key, nil },
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(Issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return Principal{}, err
+	}
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return Principal{}, errors.New("subject is not a user ID")
+	}
+	return Principal{UserID: userID}, nil
+}
+
+// UnaryServerInterceptor verifies the access token in the request metadata and injects the Principal into the context.
+// Every RPC requires a valid token, except those for which mayOmitToken reports that the caller needs none, which may
+// still carry one. mayOmitToken must only report so for callers authenticated otherwise, e.g. by their certificate.
+// An RPC without a token may name its user in the DelegateKey metadata instead, if mayDelegate reports that the caller
+// is trusted to. mayDelegate and mayOmitToken may be nil if no caller is.
+// key is the HMAC key shared with the auth service.
+func UnaryServerInterceptor(key []byte, mayDelegate func(context.Context) bool, mayOmitToken func(ctx context.Context, fullMethod string) bool) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if strings.HasPrefix(info.FullMethod, "/grpc.health.v1.Health/") {
+			return handler(ctx, req)
+		}
+
+		token, ok := tokenFromMetadata(ctx)
+		if !ok {
+			p, ok, err := delegate(ctx, mayDelegate)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				return handler(NewContext(ctx, p), req)
+			}
+			if mayOmitToken != nil && mayOmitToken(ctx, info.FullMethod) {
+				return handler(ctx, req)
+			}
+			return nil, ErrMissingToken
+		}
+		p, err := Verify(token, key)
+		if err != nil {
+			return nil, ErrInvalidToken
+		}
+		return handler(NewContext(ctx, p), req)
+	}
+}
+
+// StreamServerInterceptor is the UnaryServerInterceptor of streaming RPCs, e.g. WatchAccount.
+func StreamServerInterceptor(key []byte, public ...string) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if strings.HasPrefix(info.FullMethod, "/grpc.health.v1.Health/") {
+			return handler(srv, ss)
+		}
+
+		token, ok := tokenFromMetadata(ss.Context())
+		if !ok {
+			if slices.Contains(public, info.FullMethod) {
+				return handler(srv, ss)
+			}
+			return ErrMissingToken
+		}
+		p, err := Verify(token, key)
+		if err != nil {
+			return ErrInvalidToken
+		}
+		return handler(srv, &serverStream{ss, NewContext(ss.Context(), p)})
+	}
+}
+
+// serverStream overrides the context of a grpc.ServerStream.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// delegate returns the user named in the DelegateKey metadata, if the request names one and its caller may.
+func delegate(ctx context.Context, mayDelegate func(context.Context) bool) (Principal, bool, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(DelegateKey)
+	if len(values) == 0 {
+		return Principal{}, false, nil
+	}
+	if mayDelegate == nil || !mayDelegate(ctx) {
+		return Principal{}, false, ErrDelegationDenied
+	}
+	if len(values) != 1 {
+		return Principal{}, false, ErrInvalidDelegate
+	}
+	userID, err := uuid.Parse(values[0])
+	if err != nil || userID == uuid.Nil {
+		return Principal{}, false, ErrInvalidDelegate
+	}
+	return Principal{UserID: userID}, true, nil
+}
+
+func tokenFromMetadata(ctx context.Context) (string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", false
+	}
+	values := md.Get(MetadataKey)
+	if len(values) != 1 {
+		return "", false
+	}
+	token, ok := strings.CutPrefix(values[0], "Bearer ")
+	return token, ok && token != ""
+}
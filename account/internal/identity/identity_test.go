@@ -0,0 +1,130 @@
+package identity
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+var testKey = []byte("test-key")
+
+func signToken(t *testing.T, claims jwt.RegisteredClaims, method jwt.SigningMethod, key any) string {
+	token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	require.NoError(t, err)
+	return token
+}
+
+func validClaims(userID uuid.UUID) jwt.RegisteredClaims {
+	return jwt.RegisteredClaims{
+		Subject:   userID.String(),
+		Issuer:    Issuer,
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+	}
+}
+
+func TestVerify(t *testing.T) {
+	userID := uuid.New()
+	expired := validClaims(userID)
+	expired.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+	otherIssuer := validClaims(userID)
+	otherIssuer.Issuer = "someone-else"
+	noExpiry := validClaims(userID)
+	noExpiry.ExpiresAt = nil
+	notAUser := validClaims(userID)
+	notAUser.Subject = "admin"
+
+	tests := []struct {
+		name  string
+		token string
+		ok    bool
+	}{
+		{"valid", signToken(t, validClaims(userID), jwt.SigningMethodHS256, testKey), true},
+		{"other key", signToken(t, validClaims(userID), jwt.SigningMethodHS256, []byte("other-key")), false},
+		{"other algorithm", signToken(t, validClaims(userID), jwt.SigningMethodHS512, testKey), false},
+		{"expired", signToken(t, expired, jwt.SigningMethodHS256, testKey), false},
+		{"other issuer", signToken(t, otherIssuer, jwt.SigningMethodHS256, testKey), false},
+		{"no expiry", signToken(t, noExpiry, jwt.SigningMethodHS256, testKey), false},
+		{"subject not a user", signToken(t, notAUser, jwt.SigningMethodHS256, testKey), false},
+		{"malformed", "not-a-token", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Verify(tt.token, testKey)
+			if !tt.ok {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, userID, p.UserID)
+		})
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	const (
+		method      = "/account.AccountService/CreateTransaction"
+		staffMethod = "/account.AccountService/ReverseFee"
+	)
+	userID := uuid.New()
+	token := signToken(t, validClaims(userID), jwt.SigningMethodHS256, testKey)
+	withMetadata := func(kv ...string) context.Context {
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs(kv...))
+	}
+	type trustedKey struct{}
+	trusted := context.WithValue(context.Background(), trustedKey{}, true)
+	mayDelegate := func(ctx context.Context) bool {
+		return ctx.Value(trustedKey{}) != nil
+	}
+	mayOmitToken := func(ctx context.Context, fullMethod string) bool {
+		return ctx.Value(trustedKey{}) != nil && fullMethod == staffMethod
+	}
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		method string
+		user   uuid.UUID // uuid.Nil for no principal
+		err    error
+	}{
+		{"token", withMetadata(MetadataKey, "Bearer "+token), method, userID, nil},
+		{"invalid token", withMetadata(MetadataKey, "Bearer "+token+"x"), method, uuid.Nil, ErrInvalidToken},
+		{"not a bearer token", withMetadata(MetadataKey, token), method, uuid.Nil, ErrMissingToken},
+		{"two tokens", withMetadata(MetadataKey, "Bearer "+token, MetadataKey, "Bearer "+token), method, uuid.Nil, ErrMissingToken},
+		{"no token", context.Background(), method, uuid.Nil, ErrMissingToken},
+		{"health check", context.Background(), "/grpc.health.v1.Health/Check", uuid.Nil, nil},
+		{"staff method, trusted caller", trusted, staffMethod, uuid.Nil, nil},
+		{"staff method, untrusted caller", context.Background(), staffMethod, uuid.Nil, ErrMissingToken},
+		{"staff method with a token", withMetadata(MetadataKey, "Bearer "+token), staffMethod, userID, nil},
+		{"delegated", metadata.NewIncomingContext(trusted, metadata.Pairs(DelegateKey, userID.String())), method, userID, nil},
+		{"delegation denied", withMetadata(DelegateKey, userID.String()), method, uuid.Nil, ErrDelegationDenied},
+		{"invalid delegate", metadata.NewIncomingContext(trusted, metadata.Pairs(DelegateKey, "admin")), method, uuid.Nil, ErrInvalidDelegate},
+		{"nil delegate", metadata.NewIncomingContext(trusted, metadata.Pairs(DelegateKey, uuid.Nil.String())), method, uuid.Nil, ErrInvalidDelegate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var principal Principal
+			called := false
+			handler := func(ctx context.Context, _ any) (any, error) {
+				called = true
+				principal, _ = FromContext(ctx)
+				return nil, nil
+			}
+			interceptor := UnaryServerInterceptor(testKey, mayDelegate, mayOmitToken)
+			_, err := interceptor(tt.ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+			require.Equal(t, tt.err, err)
+			require.Equal(t, tt.err == nil, called)
+			require.Equal(t, tt.user, principal.UserID)
+		})
+	}
+
+	// no caller is trusted when the functions are nil
+	interceptor := UnaryServerInterceptor(testKey, nil, nil)
+	_, err := interceptor(trusted, nil, &grpc.UnaryServerInfo{FullMethod: staffMethod}, func(context.Context, any) (any, error) { return nil, nil })
+	require.Equal(t, ErrMissingToken, err)
+}
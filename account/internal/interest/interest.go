@@ -0,0 +1,119 @@
+// Package interest computes the interest earned by accounts, day by day.
+//
+// Interest accrues every day on the end-of-day balance, at the annual rate in effect that day and for the fraction of a
+// year the day counts for under the day-count convention of the product. Daily accruals are fractions of a minor unit:
+// they are computed exactly as rationals, and stored rounded to Decimals decimals. Only whole minor units are posted to
+// the account at the end of a period, and the remainder is carried to the next one, so that no fraction of interest is
+// lost or paid twice beyond that rounding, at most half of 10^-Decimals minor unit per stored amount.
+package interest
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+)
+
+// Day-count conventions
+const (
+	// ACT365 counts every day as 1/365 of a year, leap years included (ACT/365 Fixed).
+	ACT365 = "ACT/365"
+	// Thirty360 counts every month as 30 days of a 360-day year (30/360 bond basis): the 30th of a 31-day month counts
+	// for nothing and the 31st for 1/360, and the last day of February counts for the days up to the 30th.
+	Thirty360 = "30/360"
+)
+
+// Decimals is the number of decimals accruals and rates are rounded to when stored.
+const Decimals = 12
+
+var ErrUnknownConvention = errors.New("interest: unknown day-count convention")
+
+// Day returns the UTC date of t, at midnight.
+func Day(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// DayFraction returns the fraction of a year that day counts for under convention.
+func DayFraction(convention string, day time.Time) (*big.Rat, error) {
+	switch convention {
+	case ACT365:
+		return big.NewRat(1, 365), nil
+	case Thirty360:
+		return big.NewRat(days30360(day, day.AddDate(0, 0, 1)), 360), nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownConvention, convention)
+}
+
+// days30360 counts the days from start to end under the 30/360 bond basis.
+func days30360(start, end time.Time) int64 {
+	y1, m1, d1 := start.Date()
+	y2, m2, d2 := end.Date()
+	d1 = min(d1, 30)
+	if d1 == 30 {
+		d2 = min(d2, 30)
+	}
+	return int64(360*(y2-y1) + 30*(int(m2)-int(m1)) + (d2 - d1))
+}
+
+// Accrue returns the interest earned on day by balance, in minor units, at annualRate (0.025 for 2.5%).
+// Negative balances earn nothing.
+func Accrue(balance int64, annualRate *big.Rat, convention string, day time.Time) (*big.Rat, error) {
+	fraction, err := DayFraction(convention, day)
+	if err != nil {
+		return nil, err
+	}
+	if balance <= 0 {
+		return new(big.Rat), nil
+	}
+	accrued := new(big.Rat).SetInt64(balance)
+	accrued.Mul(accrued, annualRate)
+	return accrued.Mul(accrued, fraction), nil
+}
+
+// Split splits accrued, which must not be negative, into the whole minor units to post and the remainder to carry.
+func Split(accrued *big.Rat) (int64, *big.Rat) {
+	whole := new(big.Int).Quo(accrued.Num(), accrued.Denom())
+	remainder := new(big.Rat).Sub(accrued, new(big.Rat).SetInt(whole))
+	return whole.Int64(), remainder
+}
+
+// Parse parses a decimal amount or rate, as stored.
+func Parse(s string) (*big.Rat, error) {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return nil, fmt.Errorf("interest: invalid decimal %q", s)
+	}
+	return r, nil
+}
+
+// Format formats r as stored, rounded to Decimals decimals.
+func Format(r *big.Rat) string {
+	return r.FloatString(Decimals)
+}
+
+// Rate is an annual rate in effect from a day on, until the next rate of the same product.
+type Rate struct {
+	EffectiveFrom time.Time
+	AnnualRate    *big.Rat
+}
+
+// Schedule is the history of the rates of a product.
+type Schedule []Rate
+
+// NewSchedule returns the schedule of rates, in any order.
+func NewSchedule(rates []Rate) Schedule {
+	s := Schedule(rates)
+	sort.Slice(s, func(i, j int) bool { return s[i].EffectiveFrom.Before(s[j].EffectiveFrom) })
+	return s
+}
+
+// On returns the rate in effect on day, 0 before the first rate.
+func (s Schedule) On(day time.Time) *big.Rat {
+	i := sort.Search(len(s), func(i int) bool { return s[i].EffectiveFrom.After(day) })
+	if i == 0 {
+		return new(big.Rat)
+	}
+	return s[i-1].AnnualRate
+}
@@ -0,0 +1,86 @@
+package interest
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// sumFractions adds the fractions of the days from start to end excluded.
+func sumFractions(t *testing.T, convention string, start, end time.Time) *big.Rat {
+	sum := new(big.Rat)
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		f, err := DayFraction(convention, day)
+		require.NoError(t, err)
+		sum.Add(sum, f)
+	}
+	return sum
+}
+
+func TestDayFraction(t *testing.T) {
+	// every month counts for 30 days under 30/360, whatever its length
+	for _, month := range []time.Time{date(2026, 1, 1), date(2026, 2, 1), date(2028, 2, 1), date(2026, 4, 1), date(2026, 12, 1)} {
+		require.Equal(t, big.NewRat(1, 12), sumFractions(t, Thirty360, month, month.AddDate(0, 1, 0)), month)
+	}
+	f, err := DayFraction(Thirty360, date(2026, 1, 31))
+	require.NoError(t, err)
+	require.Equal(t, big.NewRat(1, 360), f)
+	f, err = DayFraction(Thirty360, date(2026, 1, 30))
+	require.NoError(t, err)
+	require.Equal(t, 0, f.Sign())
+	f, err = DayFraction(Thirty360, date(2026, 2, 28))
+	require.NoError(t, err)
+	require.Equal(t, big.NewRat(3, 360), f)
+
+	// a leap year counts for 366/365 under ACT/365
+	require.Equal(t, big.NewRat(366, 365), sumFractions(t, ACT365, date(2028, 1, 1), date(2029, 1, 1)))
+
+	_, err = DayFraction("ACT/ACT", date(2026, 1, 1))
+	require.ErrorIs(t, err, ErrUnknownConvention)
+}
+
+func TestAccrueAndSplit(t *testing.T) {
+	rate := big.NewRat(365, 10_000) // 3.65%
+	accrued, err := Accrue(100_000, rate, ACT365, date(2026, 3, 1))
+	require.NoError(t, err)
+	require.Equal(t, big.NewRat(10, 1), accrued)
+
+	accrued, err = Accrue(1_000, rate, ACT365, date(2026, 3, 1))
+	require.NoError(t, err)
+	require.Equal(t, big.NewRat(1, 10), accrued)
+	// the fractions add up over the days rather than being lost
+	total := new(big.Rat)
+	for range 25 {
+		total.Add(total, accrued)
+	}
+	posted, carried := Split(total)
+	require.Equal(t, int64(2), posted)
+	require.Equal(t, big.NewRat(1, 2), carried)
+
+	accrued, err = Accrue(-1_000, rate, ACT365, date(2026, 3, 1))
+	require.NoError(t, err)
+	require.Equal(t, 0, accrued.Sign())
+
+	r, err := Parse(Format(big.NewRat(1, 3)))
+	require.NoError(t, err)
+	require.Equal(t, "0.333333333333", Format(r))
+}
+
+func TestSchedule(t *testing.T) {
+	s := NewSchedule([]Rate{
+		{EffectiveFrom: date(2026, 3, 15), AnnualRate: big.NewRat(3, 100)},
+		{EffectiveFrom: date(2026, 1, 1), AnnualRate: big.NewRat(2, 100)},
+	})
+	require.Equal(t, 0, s.On(date(2025, 12, 31)).Sign())
+	require.Equal(t, big.NewRat(2, 100), s.On(date(2026, 1, 1)))
+	require.Equal(t, big.NewRat(2, 100), s.On(date(2026, 3, 14)))
+	// a rate change applies from its day on, in the middle of the month
+	require.Equal(t, big.NewRat(3, 100), s.On(date(2026, 3, 15)))
+	require.Equal(t, big.NewRat(3, 100), s.On(date(2026, 4, 1)))
+}
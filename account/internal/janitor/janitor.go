@@ -0,0 +1,170 @@
+// Package janitor periodically purges the rows that outlived their use, such as expired idempotency keys, and flags
+// the accounts inactive for too long as dormant. It also runs the periodic jobs of the accounts, such as accruing and
+// posting their interest, charging their monthly fees and releasing their expired holds.
+//
+// Every replica runs the janitor, but a run only proceeds in the replica that takes a Postgres advisory lock, so the
+// replicas don't delete the same rows concurrently. Rows are deleted and processed in batches, each in its own short
+// transaction, so that working through a large backlog doesn't hold many row locks or block the requests for long.
+package janitor
+
+import (
+	"account/config"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// lockID is the key of the advisory lock held by the replica running the janitor: "janitor" in ASCII, then 1 for the
+// account database.
+const lockID int64 = 0x6a616e69746f7201
+
+// ErrNotLeader is returned by RunOnce when another replica holds the lock and is purging already.
+var ErrNotLeader = errors.New("janitor: another instance is running")
+
+// PurgeFunc deletes at most batchSize rows that expired before expiredBefore, and returns how many it deleted.
+// Tasks that update rows rather than deleting them, such as flagging dormant accounts, must no longer match the rows
+// they updated.
+type PurgeFunc func(ctx context.Context, expiredBefore time.Time, batchSize int32) (int64, error)
+
+// JobFunc processes at most batchSize of the items due at now, e.g. the holds expired by then, and returns how many it
+// processed. Like a PurgeFunc, it must no longer match the items it processed.
+type JobFunc func(ctx context.Context, now time.Time, batchSize int32) (int64, error)
+
+// task is a purge of the rows of a table, or a job.
+type task struct {
+	name      string
+	job       bool
+	retention time.Duration
+	run       func(ctx context.Context, cutoff time.Time, batchSize int32) (int64, error)
+}
+
+type Janitor struct {
+	db        *sqlx.DB
+	interval  time.Duration
+	batchSize int32
+	tasks     []task
+}
+
+// New creates a Janitor with the schedule and batch size of cfg. Register what it purges with AddTask, and what else
+// it runs with AddJob.
+func New(db *sqlx.DB, cfg config.JanitorConfig) *Janitor {
+	return &Janitor{
+		db:        db,
+		interval:  cfg.Interval,
+		batchSize: int32(cfg.BatchSize),
+	}
+}
+
+// AddTask registers purge, which deletes the rows of table expired for longer than retention.
+func (j *Janitor) AddTask(table string, retention time.Duration, purge PurgeFunc) {
+	j.tasks = append(j.tasks, task{name: table, retention: retention, run: purge})
+}
+
+// AddJob registers job under name. Tasks run in the order they were added, purges and jobs alike.
+func (j *Janitor) AddJob(name string, job JobFunc) {
+	j.tasks = append(j.tasks, task{name: name, job: true, run: job})
+}
+
+// Run runs the tasks every interval until ctx is cancelled. It returns right away if the scheduled runs are disabled.
+func (j *Janitor) Run(ctx context.Context) {
+	if j.interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := j.RunOnce(ctx); err != nil && !errors.Is(err, ErrNotLeader) && ctx.Err() == nil {
+				log.Printf("janitor: %v\n", err)
+			}
+		}
+	}
+}
+
+// RunOnce runs every task until it has nothing left to purge or process, and fails with ErrNotLeader if another replica is
+// running them already.
+func (j *Janitor) RunOnce(ctx context.Context) error {
+	// session-level advisory locks belong to a connection, so the lock is taken and released on a dedicated one.
+	// If the connection breaks, Postgres releases the lock with the session.
+	conn, err := j.db.Conn(ctx)
+	if err != nil {
+		runs.WithLabelValues("failed").Inc()
+		return fmt.Errorf("failed to get a connection: %w", err)
+	}
+	defer conn.Close()
+
+	var leader bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", lockID).Scan(&leader); err != nil {
+		runs.WithLabelValues("failed").Inc()
+		return fmt.Errorf("failed to take the advisory lock: %w", err)
+	}
+	if !leader {
+		runs.WithLabelValues("skipped").Inc()
+		return ErrNotLeader
+	}
+	defer func() {
+		// released even if ctx was cancelled, the connection goes back to the pool
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockID); err != nil {
+			log.Printf("janitor: Failed to release the advisory lock: %v\n", err)
+		}
+	}()
+
+	var errs []error
+	for _, t := range j.tasks {
+		if err := j.runTask(ctx, t); err != nil {
+			if t.job {
+				errs = append(errs, fmt.Errorf("failed to run %s: %w", t.name, err))
+			} else {
+				errs = append(errs, fmt.Errorf("failed to purge %s: %w", t.name, err))
+			}
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		runs.WithLabelValues("failed").Inc()
+		return err
+	}
+	runs.WithLabelValues("succeeded").Inc()
+	lastSuccess.SetToCurrentTime()
+	return nil
+}
+
+// runTask runs t batch by batch, until a batch comes back short.
+func (j *Janitor) runTask(ctx context.Context, t task) error {
+	// fixed for the whole run, so that rows expiring meanwhile don't keep the loop going
+	cutoff := time.Now().Add(-t.retention)
+	counter := rowsPurged.WithLabelValues(t.name)
+	if t.job {
+		counter = itemsProcessed.WithLabelValues(t.name)
+	}
+	var total int64
+	for {
+		n, err := t.run(ctx, cutoff, j.batchSize)
+		if err != nil {
+			return err
+		}
+		total += n
+		counter.Add(float64(n))
+		if n < int64(j.batchSize) {
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+	switch {
+	case total == 0:
+	case t.job:
+		log.Printf("janitor: %s processed %d items\n", t.name, total)
+	default:
+		log.Printf("janitor: purged %d rows of %s\n", total, t.name)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package janitor
+
+import (
+	"account/config"
+	"account/db/initialize"
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTestDB(t *testing.T) *sqlx.DB {
+	cfg, err := config.LoadDB()
+	require.NoError(t, err)
+	db := initialize.ConnectDB(cfg)
+	t.Cleanup(func() { require.NoError(t, db.Close()) })
+	return db
+}
+
+func TestPurge_Batches(t *testing.T) {
+	j := &Janitor{batchSize: 10}
+	var calls []time.Time
+	remaining := int64(25)
+	before := time.Now()
+	err := j.runTask(context.Background(), task{name: "test", retention: time.Hour, run: func(_ context.Context, expiredBefore time.Time, batchSize int32) (int64, error) {
+		calls = append(calls, expiredBefore)
+		n := min(remaining, int64(batchSize))
+		remaining -= n
+		return n, nil
+	}})
+	require.NoError(t, err)
+
+	// until a batch comes back short, with the same cutoff for the whole run
+	require.Len(t, calls, 3)
+	require.Zero(t, remaining)
+	for _, expiredBefore := range calls {
+		require.Equal(t, calls[0], expiredBefore)
+	}
+	require.WithinRange(t, calls[0], before.Add(-time.Hour), time.Now().Add(-time.Hour))
+}
+
+func TestRunTask_Job(t *testing.T) {
+	j := &Janitor{batchSize: 10}
+	var calls []time.Time
+	j.AddJob("test", func(_ context.Context, now time.Time, _ int32) (int64, error) {
+		calls = append(calls, now)
+		return 1, nil
+	})
+	before := time.Now()
+	require.NoError(t, j.runTask(context.Background(), j.tasks[0]))
+
+	// a job runs with the current time rather than a retention cutoff, until a batch comes back short
+	require.Len(t, calls, 1)
+	require.WithinRange(t, calls[0], before, time.Now())
+}
+
+func TestRunOnce_NotLeader(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	var runs atomic.Int32
+	j := New(db, config.JanitorConfig{BatchSize: 10})
+	j.AddTask("test", time.Hour, func(context.Context, time.Time, int32) (int64, error) {
+		runs.Add(1)
+		return 0, nil
+	})
+
+	// another replica holds the lock
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockID)
+	require.NoError(t, err)
+	require.ErrorIs(t, j.RunOnce(ctx), ErrNotLeader)
+	require.Zero(t, runs.Load())
+
+	_, err = conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", lockID)
+	require.NoError(t, err)
+	require.NoError(t, j.RunOnce(ctx))
+	require.Equal(t, int32(1), runs.Load())
+
+	// the lock is released after each run
+	require.NoError(t, j.RunOnce(ctx))
+	require.Equal(t, int32(2), runs.Load())
+}
+
+func TestRunOnce_Concurrent(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	leader := New(db, config.JanitorConfig{BatchSize: 10})
+	leader.AddTask("test", time.Hour, func(context.Context, time.Time, int32) (int64, error) {
+		close(started)
+		<-release
+		return 0, nil
+	})
+	done := make(chan error)
+	go func() { done <- leader.RunOnce(ctx) }()
+	<-started
+
+	// the other replicas skip their run while the leader purges
+	var runs atomic.Int32
+	replica := New(db, config.JanitorConfig{BatchSize: 10})
+	replica.AddTask("test", time.Hour, func(context.Context, time.Time, int32) (int64, error) {
+		runs.Add(1)
+		return 0, nil
+	})
+	require.ErrorIs(t, replica.RunOnce(ctx), ErrNotLeader)
+	require.Zero(t, runs.Load())
+
+	close(release)
+	require.NoError(t, <-done)
+	require.NoError(t, replica.RunOnce(ctx))
+	require.Equal(t, int32(1), runs.Load())
+}
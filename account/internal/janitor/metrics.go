@@ -0,0 +1,28 @@
+package janitor
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	rowsPurged = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "account_janitor_rows_purged_total",
+		Help: "Rows deleted by the janitor, or updated for the accounts flagged as dormant, per table.",
+	}, []string{"table"})
+
+	itemsProcessed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "account_janitor_items_processed_total",
+		Help: "Items processed by the periodic jobs of the janitor, e.g. accounts accrued or holds released, per job.",
+	}, []string{"job"})
+
+	runs = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "account_janitor_runs_total",
+		Help: "Janitor runs by result: succeeded, failed, or skipped because another replica held the lock.",
+	}, []string{"result"})
+
+	lastSuccess = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "account_janitor_last_success_timestamp_seconds",
+		Help: "Unix time of the last janitor run that purged every table and ran every job.",
+	})
+)
@@ -0,0 +1,163 @@
+package mtls
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// peerContext returns a context whose peer presented a verified certificate with the given URI SANs.
+func peerContext(uris ...string) context.Context {
+	cert := &x509.Certificate{}
+	for _, u := range uris {
+		parsed, err := url.Parse(u)
+		if err != nil {
+			panic(err)
+		}
+		cert.URIs = append(cert.URIs, parsed)
+	}
+	return peer.NewContext(context.Background(), &peer.Peer{
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}},
+	})
+}
+
+func TestIdentity(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+		ok   bool
+	}{
+		{"no peer", context.Background(), "", false},
+		{"no TLS", peer.NewContext(context.Background(), &peer.Peer{}), "", false},
+		{"unverified", peer.NewContext(context.Background(), &peer.Peer{AuthInfo: credentials.TLSInfo{}}), "", false},
+		{"service", peerContext("spiffe://banking-app/api-gateway"), APIGateway, true},
+		{"other trust domain", peerContext("spiffe://elsewhere/api-gateway"), "", false},
+		{"other scheme", peerContext("https://banking-app/api-gateway"), "", false},
+		{"no identity", peerContext("spiffe://banking-app/"), "", false},
+		{"second SAN", peerContext("https://example.com", "spiffe://banking-app/support-console"), SupportConsole, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, ok := Identity(tt.ctx)
+			require.Equal(t, tt.ok, ok)
+			require.Equal(t, tt.want, id)
+		})
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	const method = "/account.AccountService/CreateTransaction"
+	policy := Policy{method: {APIGateway, TransferService}}
+	errDenied := status.Error(codes.PermissionDenied, "denied by check")
+	check := func(_ context.Context, identity string, req any) error {
+		if req == "privileged" && identity != TransferService {
+			return errDenied
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		method     string
+		req        any
+		tlsEnabled bool
+		want       codes.Code
+	}{
+		{"allowed", peerContext("spiffe://banking-app/api-gateway"), method, "", true, codes.OK},
+		{"not in policy", peerContext("spiffe://banking-app/auth-service"), method, "", true, codes.PermissionDenied},
+		{"method not in policy", peerContext("spiffe://banking-app/api-gateway"), "/account.AccountService/Unknown", "", true, codes.PermissionDenied},
+		{"no certificate", context.Background(), method, "", true, codes.Unauthenticated},
+		{"health check", context.Background(), "/grpc.health.v1.Health/Check", "", true, codes.OK},
+		{"check passes", peerContext("spiffe://banking-app/transfer-service"), method, "privileged", true, codes.OK},
+		{"check fails", peerContext("spiffe://banking-app/api-gateway"), method, "privileged", true, codes.PermissionDenied},
+		// without TLS the policy is skipped, but the check still runs with no identity
+		{"TLS disabled", context.Background(), method, "", false, codes.OK},
+		{"TLS disabled, privileged", context.Background(), method, "privileged", false, codes.PermissionDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			handler := func(context.Context, any) (any, error) {
+				called = true
+				return nil, nil
+			}
+			interceptor := UnaryServerInterceptor(policy, tt.tlsEnabled, check)
+			_, err := interceptor(tt.ctx, tt.req, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+			require.Equal(t, tt.want, status.Code(err))
+			require.Equal(t, tt.want == codes.OK, called)
+		})
+	}
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	const method = "/account.AccountService/WatchAccount"
+	policy := Policy{method: {APIGateway}}
+	errCheck := errors.New("check failed")
+	check := func(_ context.Context, identity string, req any) error {
+		if identity != APIGateway {
+			return errCheck
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want codes.Code
+	}{
+		{"allowed", peerContext("spiffe://banking-app/api-gateway"), codes.OK},
+		{"not in policy", peerContext("spiffe://banking-app/transfer-service"), codes.PermissionDenied},
+		{"no certificate", context.Background(), codes.Unauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stream grpc.ServerStream
+			handler := func(_ any, ss grpc.ServerStream) error {
+				stream = ss
+				return nil
+			}
+			interceptor := StreamServerInterceptor(policy, true, check)
+			err := interceptor(nil, &fakeStream{ctx: tt.ctx}, &grpc.StreamServerInfo{FullMethod: method}, handler)
+			require.Equal(t, tt.want, status.Code(err))
+			if tt.want == codes.OK {
+				// the check runs on the received messages
+				require.NoError(t, stream.RecvMsg(nil))
+			}
+		})
+	}
+
+	// without TLS, messages are checked with no identity
+	var stream grpc.ServerStream
+	interceptor := StreamServerInterceptor(policy, false, check)
+	err := interceptor(nil, &fakeStream{ctx: context.Background()}, &grpc.StreamServerInfo{FullMethod: method}, func(_ any, ss grpc.ServerStream) error {
+		stream = ss
+		return nil
+	})
+	require.NoError(t, err)
+	require.ErrorIs(t, stream.RecvMsg(nil), errCheck)
+}
+
+type fakeStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *fakeStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *fakeStream) RecvMsg(any) error {
+	return nil
+}
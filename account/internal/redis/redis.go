@@ -1,10 +1,9 @@
 package redis
 
 import (
+	"account/config"
 	"context"
 	"fmt"
-	"os"
-	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -14,6 +13,15 @@ type RedisClient interface {
 	Get(ctx context.Context, key string) *redis.StringCmd
 	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd
 	Del(ctx context.Context, keys ...string) *redis.IntCmd
+	Ping(ctx context.Context) *redis.StatusCmd
+	Close() error
+
+	// streams carrying the account events, see package events
+	XAdd(ctx context.Context, a *redis.XAddArgs) *redis.StringCmd
+	XRead(ctx context.Context, a *redis.XReadArgs) *redis.XStreamSliceCmd
+	XRangeN(ctx context.Context, stream, start, stop string, count int64) *redis.XMessageSliceCmd
+	XRevRangeN(ctx context.Context, stream, start, stop string, count int64) *redis.XMessageSliceCmd
+	Expire(ctx context.Context, key string, expiration time.Duration) *redis.BoolCmd
 }
 type (
 	singleClient  struct{ *redis.Client }
@@ -32,6 +40,34 @@ func (c *singleClient) Del(ctx context.Context, keys ...string) *redis.IntCmd {
 	return c.Client.Del(ctx, keys...)
 }
 
+func (c *singleClient) Ping(ctx context.Context) *redis.StatusCmd {
+	return c.Client.Ping(ctx)
+}
+
+func (c *singleClient) Close() error {
+	return c.Client.Close()
+}
+
+func (c *singleClient) XAdd(ctx context.Context, a *redis.XAddArgs) *redis.StringCmd {
+	return c.Client.XAdd(ctx, a)
+}
+
+func (c *singleClient) XRead(ctx context.Context, a *redis.XReadArgs) *redis.XStreamSliceCmd {
+	return c.Client.XRead(ctx, a)
+}
+
+func (c *singleClient) XRangeN(ctx context.Context, stream, start, stop string, count int64) *redis.XMessageSliceCmd {
+	return c.Client.XRangeN(ctx, stream, start, stop, count)
+}
+
+func (c *singleClient) XRevRangeN(ctx context.Context, stream, start, stop string, count int64) *redis.XMessageSliceCmd {
+	return c.Client.XRevRangeN(ctx, stream, start, stop, count)
+}
+
+func (c *singleClient) Expire(ctx context.Context, key string, expiration time.Duration) *redis.BoolCmd {
+	return c.Client.Expire(ctx, key, expiration)
+}
+
 func (c *clusterClient) Get(ctx context.Context, key string) *redis.StringCmd {
 	return c.ClusterClient.Get(ctx, key)
 }
@@ -44,34 +80,46 @@ func (c *clusterClient) Del(ctx context.Context, keys ...string) *redis.IntCmd {
 	return c.ClusterClient.Del(ctx, keys...)
 }
 
-var Client RedisClient
+func (c *clusterClient) Ping(ctx context.Context) *redis.StatusCmd {
+	return c.ClusterClient.Ping(ctx)
+}
 
-func Init(ctx context.Context) error {
-	mode := os.Getenv("REDIS_MODE")
-	if mode == "" {
-		mode = "single"
-	}
+func (c *clusterClient) Close() error {
+	return c.ClusterClient.Close()
+}
 
-	password := os.Getenv("REDIS_PASSWORD")
+func (c *clusterClient) XAdd(ctx context.Context, a *redis.XAddArgs) *redis.StringCmd {
+	return c.ClusterClient.XAdd(ctx, a)
+}
+
+func (c *clusterClient) XRead(ctx context.Context, a *redis.XReadArgs) *redis.XStreamSliceCmd {
+	return c.ClusterClient.XRead(ctx, a)
+}
+
+func (c *clusterClient) XRangeN(ctx context.Context, stream, start, stop string, count int64) *redis.XMessageSliceCmd {
+	return c.ClusterClient.XRangeN(ctx, stream, start, stop, count)
+}
+
+func (c *clusterClient) XRevRangeN(ctx context.Context, stream, start, stop string, count int64) *redis.XMessageSliceCmd {
+	return c.ClusterClient.XRevRangeN(ctx, stream, start, stop, count)
+}
+
+func (c *clusterClient) Expire(ctx context.Context, key string, expiration time.Duration) *redis.BoolCmd {
+	return c.ClusterClient.Expire(ctx, key, expiration)
+}
+
+var Client RedisClient
+
+// Init connects to Redis in either "single" or "cluster" mode. The config is expected to be validated already.
+func Init(ctx context.Context, cfg config.RedisConfig) error {
+	mode := cfg.Mode
+	password := cfg.Password
 
 	var addrs []string
 	if mode == "single" {
-		host := os.Getenv("REDIS_SINGLE_ADDR")
-		if host == "" {
-			host = "redis-single"
-		}
-
-		port := os.Getenv("REDIS_SINGLE_PORT")
-		if port == "" {
-			port = "6379"
-		}
-		addrs = []string{fmt.Sprintf("%s:%s", host, port)}
+		addrs = []string{fmt.Sprintf("%s:%s", cfg.SingleAddr, cfg.SinglePort)}
 	} else {
-		clusterAddrs := os.Getenv("REDIS_CLUSTER_ADDRS")
-		if clusterAddrs == "" {
-			clusterAddrs = "redis-node1:6380,redis-node2:6381,redis-node3:6382"
-		}
-		addrs = strings.Split(clusterAddrs, ",")
+		addrs = cfg.ClusterAddrs
 	}
 
 	var err error
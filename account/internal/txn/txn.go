@@ -0,0 +1,95 @@
+// Package txn runs closures in database transactions, and retries the ones that failed only because they conflicted
+// with concurrent transactions.
+package txn
+
+import (
+	"account/model"
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"math/rand/v2"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// Postgres error codes of the transactions that may succeed if they are retried:
+// https://www.postgresql.org/docs/current/mvcc-serialization-failure-handling.html
+const (
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+)
+
+// bounds of the delay between attempts
+const (
+	baseDelay = 20 * time.Millisecond
+	maxDelay  = time.Second
+)
+
+// Runner runs closures in transactions, making at most maxAttempts attempts for each.
+type Runner struct {
+	db          *sqlx.DB
+	maxAttempts int
+}
+
+func NewRunner(db *sqlx.DB, maxAttempts int) *Runner {
+	return &Runner{db: db, maxAttempts: maxAttempts}
+}
+
+// Retryable reports whether err, or an error it wraps, is a serialization failure or a deadlock.
+// The DB errors must be wrapped (see model.Internal) rather than replaced for Run to retry them.
+func Retryable(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && (pqErr.Code == serializationFailure || pqErr.Code == deadlockDetected)
+}
+
+// Run calls fn in a transaction at isolation, and commits the transaction if fn succeeds. Otherwise the transaction is
+// rolled back, unless fn committed it itself to keep what it did despite failing (see failIdempotencyKey).
+// While fn or the commit fail with a Retryable error, Run retries in a new transaction after a delay with decorrelated
+// jitter. It gives up after maxAttempts attempts, or once the next one can't start before the deadline of ctx, and
+// returns the last error.
+func (r *Runner) Run(ctx context.Context, isolation sql.IsolationLevel, fn func(tx *sql.Tx) error) error {
+	delay := baseDelay
+	for attempt := 1; ; attempt++ {
+		err := r.run(ctx, isolation, fn)
+		if err == nil || !Retryable(err) || attempt >= r.maxAttempts {
+			return err
+		}
+
+		// decorrelated jitter: random between the base and thrice the previous delay, so that the transactions that
+		// conflicted with each other don't retry in lockstep
+		delay = min(maxDelay, baseDelay+rand.N(3*delay-baseDelay))
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return err
+		}
+		log.Printf("txn: retrying in %v (attempt %d of %d): %v\n", delay, attempt+1, r.maxAttempts, err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+func (r *Runner) run(ctx context.Context, isolation sql.IsolationLevel, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: isolation})
+	if err != nil {
+		log.Printf("txn: Failed to begin transaction: %v\n", err)
+		return model.Internal(err)
+	}
+	defer tx.Rollback()
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		log.Printf("txn: Failed to commit transaction: %v\n", err)
+		return model.Internal(err)
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package txn
+
+import (
+	"account/model"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryable(t *testing.T) {
+	serializationErr := &pq.Error{Code: "40001"}
+	deadlockErr := &pq.Error{Code: "40P01"}
+	uniqueErr := &pq.Error{Code: "23505"}
+
+	require.True(t, Retryable(serializationErr))
+	require.True(t, Retryable(deadlockErr))
+	// found even once wrapped, while the client only gets ErrInternalServer
+	require.True(t, Retryable(model.Internal(serializationErr)))
+	require.True(t, Retryable(fmt.Errorf("commit: %w", model.Internal(deadlockErr))))
+	require.True(t, errors.Is(model.Internal(serializationErr), model.ErrInternalServer))
+
+	require.False(t, Retryable(nil))
+	require.False(t, Retryable(uniqueErr))
+	require.False(t, Retryable(model.Internal(uniqueErr)))
+	require.False(t, Retryable(model.ErrInternalServer))
+}
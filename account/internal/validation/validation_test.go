@@ -0,0 +1,73 @@
+package validation
+
+import (
+	"account/proto"
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func validTransaction() *proto.CreateTransactionRequest {
+	return &proto.CreateTransactionRequest{
+		AccountId:       uuid.NewString(),
+		TransactionType: "CREDIT",
+		IdempotencyKey:  uuid.NewString(),
+		Amount:          &proto.Money{Currency: "USD", Units: 100},
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	info := &grpc.UnaryServerInfo{FullMethod: "/account.AccountService/CreateTransaction"}
+	interceptor := UnaryServerInterceptor()
+
+	t.Run("valid", func(t *testing.T) {
+		called := false
+		_, err := interceptor(context.Background(), validTransaction(), info, func(context.Context, any) (any, error) {
+			called = true
+			return nil, nil
+		})
+		require.NoError(t, err)
+		require.True(t, called)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		req := validTransaction()
+		req.AccountId = "not-a-uuid"
+		req.TransactionType = "REFUND"
+		req.Amount.Units = 0
+
+		_, err := interceptor(context.Background(), req, info, func(context.Context, any) (any, error) {
+			t.Fatal("handler called with an invalid request")
+			return nil, nil
+		})
+		st := status.Convert(err)
+		require.Equal(t, codes.InvalidArgument, st.Code())
+		require.Len(t, st.Details(), 1)
+		badRequest, ok := st.Details()[0].(*errdetails.BadRequest)
+		require.True(t, ok, "%T", st.Details()[0])
+
+		reasons := map[string]string{}
+		for _, v := range badRequest.GetFieldViolations() {
+			require.NotEmpty(t, v.GetDescription())
+			reasons[v.GetField()] = v.GetReason()
+		}
+		require.Equal(t, map[string]string{
+			"account_id":       "STRING_UUID",
+			"transaction_type": "STRING_IN",
+			"amount":           "AMOUNT_POSITIVE",
+		}, reasons)
+	})
+}
+
+func TestReason(t *testing.T) {
+	require.Equal(t, "STRING_UUID", reason("string.uuid"))
+	require.Equal(t, "AMOUNT_POSITIVE", reason("amount.positive"))
+	require.Equal(t, "STRING_MIN_LEN", reason("string.min_len"))
+	require.Equal(t, "REQUIRED", reason("required"))
+}
@@ -1,60 +1,244 @@
 package main
 
 import (
+	"account/config"
 	"account/db/initialize"
 	"account/handler"
+	"account/internal/cache"
+	"account/internal/events"
+	"account/internal/health"
+	"account/internal/identity"
+	"account/internal/janitor"
+	"account/internal/mtls"
 	"account/internal/redis"
+	"account/internal/validation"
 	"account/proto"
 	"account/repository"
 	"account/service"
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net"
+	"net/http"
 	"os"
-	"strconv"
+	"os/signal"
+	"syscall"
+	"time"
 
 	_ "github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 func main() {
-	db := initialize.ConnectDB()
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	// `main healthcheck` is used by the docker-compose health check
+	if len(os.Args) > 1 && os.Args[1] == "healthcheck" {
+		os.Exit(runHealthcheck(cfg))
+	}
+
+	db := initialize.ConnectDB(cfg.DB)
 	defer db.Close()
 	accountRepo := repository.NewAccountRepository(db)
 	if accountRepo == nil {
 		log.Fatalf("Failed to create account repository")
 	}
-	if err := redis.Init(context.Background()); err != nil {
+	accountJanitor := janitor.New(db, cfg.Janitor)
+	accountJanitor.AddTask("idempotency_keys", cfg.Janitor.IdempotencyKeyRetention, accountRepo.DeleteExpiredIdempotencyKeys)
+	if cfg.Janitor.DormancyPeriod > 0 {
+		accountJanitor.AddTask("accounts", cfg.Janitor.DormancyPeriod, accountRepo.MarkDormantAccounts)
+	}
+
+	if err := redis.Init(context.Background(), cfg.Redis); err != nil {
 		log.Fatalf("Failed to init Redis: %s", err)
 	}
-	accountService := service.NewAccountService(accountRepo, db)
+	defer redis.Client.Close()
+	cache.TTL = cfg.Redis.CacheTTL
+	events.Retention = int64(cfg.Redis.EventRetention)
+	accountService := service.NewAccountService(accountRepo, db).
+		WithMaxRetries(cfg.MaxRetries).
+		WithAccountNumberPrefix(cfg.AccountNumberPrefix).
+		WithHoldTTL(cfg.HoldTTL)
 	if accountService == nil {
 		log.Fatalf("Failed to create account service")
 	}
-	accountHandler := handler.NewAccountHandler(accountService)
+	// interest accrues for the days that have ended, then the months that have ended are posted
+	accountJanitor.AddJob("interest_accruals", accountService.AccrueInterest)
+	accountJanitor.AddJob("interest_postings", accountService.PostInterest)
+	accountJanitor.AddJob("maintenance_fees", accountService.ChargeMaintenanceFees)
+	accountJanitor.AddJob("holds", accountService.ExpireHolds)
+
+	// `main janitor` purges the expired rows, flags the dormant accounts, accrues and posts interest, charges the
+	// monthly fees and releases the expired holds once, e.g. from a cron job when the scheduled runs are disabled
+	if len(os.Args) > 1 && os.Args[1] == "janitor" {
+		os.Exit(runJanitor(accountJanitor))
+	}
+	accountHandler := handler.NewAccountHandler(accountService, cfg.WatchHeartbeat)
 	if accountHandler == nil {
 		log.Fatalf("Failed to create account handler")
 	}
 
-	_port := os.Getenv("GRPC_PORT")
-	var port int
-	var err error
-	if port, err = strconv.Atoi(_port); err != nil {
-		port = 50002
+	// cancelled on SIGINT/SIGTERM so that we can drain in-flight RPCs before exiting
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// report NOT_SERVING whenever Postgres or Redis is unreachable
+	healthChecker := health.NewChecker(cfg.HealthCheckInterval, proto.AccountService_ServiceDesc.ServiceName)
+	healthChecker.AddCheck("postgres", db.PingContext)
+	healthChecker.AddCheck("redis", func(ctx context.Context) error {
+		return redis.Client.Ping(ctx).Err()
+	})
+	go healthChecker.Run(ctx)
+
+	// purge expired rows in the background, in one replica at a time
+	go accountJanitor.Run(ctx)
+
+	// internal-only admin server exposing the redacted effective config and the metrics
+	adminServer := newAdminServer(cfg)
+	if adminServer != nil {
+		go func() {
+			if err := adminServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Printf("Admin server failed: %v", err)
+			}
+		}()
 	}
 
 	// start the server
-	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.GRPCPort))
 	if err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 	defer listener.Close()
 
-	grpcServer := grpc.NewServer()
+	// mTLS between internal services. Callers are authorized by the identity in their client certificate,
+	// and the end user by the access token the caller forwards in the request metadata, or named by the transfer service
+	// for the standing orders it executes.
+	// Requests are then checked against the buf.validate rules of the .proto file before reaching the handlers.
+	serverOpts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(
+			mtls.UnaryServerInterceptor(handler.Policy, cfg.TLS.Enabled(), handler.AuthorizeRequest),
+			identity.UnaryServerInterceptor(cfg.JWT.Key(), handler.MayDelegate, handler.MayOmitToken),
+			validation.UnaryServerInterceptor(),
+		),
+		// the same checks for WatchAccount
+		grpc.ChainStreamInterceptor(
+			mtls.StreamServerInterceptor(handler.Policy, cfg.TLS.Enabled(), handler.AuthorizeRequest),
+			identity.StreamServerInterceptor(cfg.JWT.Key()),
+			validation.StreamServerInterceptor(),
+		),
+	}
+	if cfg.TLS.Enabled() {
+		creds, err := mtls.ServerCredentials(cfg.TLS)
+		if err != nil {
+			log.Fatalf("Failed to load TLS credentials: %v", err)
+		}
+		serverOpts = append(serverOpts, grpc.Creds(creds))
+	} else {
+		log.Println("WARNING: TLS is disabled, callers can't be authenticated")
+	}
+	grpcServer := grpc.NewServer(serverOpts...)
 	proto.RegisterAccountServiceServer(grpcServer, accountHandler)
-	fmt.Printf("Server started on port %d\n", port)
-	if grpcServer.Serve(listener); err != nil {
+	healthpb.RegisterHealthServer(grpcServer, healthChecker.Server())
+
+	serveErr := make(chan error, 1)
+	go func() {
+		fmt.Printf("Server started on port %d\n", cfg.GRPCPort)
+		serveErr <- grpcServer.Serve(listener)
+	}()
+
+	select {
+	case err := <-serveErr:
 		log.Fatalf("Failed to serve: %v", err)
+	case <-ctx.Done():
+	}
+
+	log.Println("Shutting down: draining in-flight RPCs")
+	healthChecker.Shutdown()
+	accountHandler.Shutdown()
+	gracefulStop(grpcServer, cfg.ShutdownTimeout)
+	if adminServer != nil {
+		adminServer.Close()
+	}
+	log.Println("Server stopped")
+}
+
+// newAdminServer returns the internal admin HTTP server, or nil if it is disabled.
+func newAdminServer(cfg *config.Config) *http.Server {
+	if cfg.AdminPort == 0 {
+		return nil
+	}
+	mux := http.NewServeMux()
+	mux.Handle("GET /debug/config", cfg.DumpHandler())
+	mux.Handle("GET /metrics", promhttp.Handler())
+	return &http.Server{
+		Addr:    fmt.Sprintf(":%d", cfg.AdminPort),
+		Handler: mux,
+	}
+}
+
+// gracefulStop stops accepting new RPCs and waits for in-flight RPCs (e.g. money movements) to finish.
+// If they don't finish within the timeout, the remaining RPCs are cancelled.
+func gracefulStop(grpcServer *grpc.Server, timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		log.Printf("Graceful shutdown timed out after %v, forcing stop\n", timeout)
+		grpcServer.Stop()
+	}
+}
+
+// runHealthcheck queries the grpc.health.v1 service of the local server and returns the process exit code.
+// With TLS enabled, we present our own certificate, which the server accepts since health checks bypass the authorization policy.
+func runHealthcheck(cfg *config.Config) int {
+	creds := insecure.NewCredentials()
+	if cfg.TLS.Enabled() {
+		var err error
+		if creds, err = mtls.ClientCredentials(cfg.TLS, mtls.AccountService); err != nil {
+			log.Printf("healthcheck: %v\n", err)
+			return 1
+		}
+	}
+	conn, err := grpc.NewClient(fmt.Sprintf("localhost:%d", cfg.GRPCPort), grpc.WithTransportCredentials(creds))
+	if err != nil {
+		log.Printf("healthcheck: %v\n", err)
+		return 1
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	res, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		log.Printf("healthcheck: %v\n", err)
+		return 1
+	}
+	if res.Status != healthpb.HealthCheckResponse_SERVING {
+		log.Printf("healthcheck: status %v\n", res.Status)
+		return 1
+	}
+	return 0
+}
+
+// runJanitor runs the janitor tasks once and returns the process exit code.
+func runJanitor(j *janitor.Janitor) int {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	if err := j.RunOnce(ctx); err != nil {
+		log.Printf("janitor: %v\n", err)
+		return 1
 	}
+	return 0
 }
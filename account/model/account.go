@@ -1,45 +1,190 @@
 package model
 
 import (
+	"slices"
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 )
 
 type User struct {
 	UserID  uuid.UUID `json:"user_id"`
 	Balance int64     `json:"balance"`
+	// Currency is the ISO 4217 code of the account to open, Balance being minor units of it
+	Currency string `json:"currency"`
 }
 
 type Account struct {
 	AccountID     uuid.UUID `json:"account_id"`
 	UserID        uuid.UUID `json:"user_id"`
-	Balance       int64     `json:"balance"`
-	AccountNumber int32     `json:"account_number"`
+	Balance       int64     `json:"balance"` // in minor units of Currency
+	Currency      string    `json:"currency"`
+	AccountNumber int64     `json:"account_number"`
+	Version       int64     `json:"version"` // incremented by every balance change
+	ProductCode   string    `json:"product_code"`
+	AccountType   string    `json:"account_type"` // type of the product, copied when the account is opened
+	MaturesAt     time.Time `json:"matures_at"`   // end of the lock-up period, zero if the product has none
+	Status        string    `json:"status"`
+	// StatusChangedAt is when the account got its current status
+	StatusChangedAt time.Time `json:"status_changed_at"`
+	ClosureReason   string    `json:"closure_reason,omitempty"`
+	ClosedAt        time.Time `json:"closed_at"` // zero unless the account is closed
+	CreatedAt       time.Time `json:"created_at"`
+	// InterestAccruedThrough is the last day interest accrued for, zero if none did yet
+	InterestAccruedThrough time.Time `json:"interest_accrued_through"`
+	// Held is the amount reserved by the active holds of the account, see Hold
+	Held int64 `json:"held"`
+}
+
+// Available returns the balance the account can be debited from: its balance minus the amount of its active holds.
+func (a *Account) Available() int64 {
+	return a.Balance - a.Held
+}
+
+// Statuses of Account
+const (
+	AccountStatusPending = "PENDING" // awaiting approval
+	AccountStatusActive  = "ACTIVE"
+	AccountStatusFrozen  = "FROZEN"  // can be credited but not debited
+	AccountStatusDormant = "DORMANT" // inactive for too long, can be credited but not debited until reactivated
+	AccountStatusClosed  = "CLOSED"  // kept with its history, but can't be used anymore
+)
+
+// accountTransitions lists the statuses an account may go to from each status. CLOSED is final.
+var accountTransitions = map[string][]string{
+	AccountStatusPending: {AccountStatusActive, AccountStatusClosed},
+	AccountStatusActive:  {AccountStatusFrozen, AccountStatusDormant, AccountStatusClosed},
+	AccountStatusFrozen:  {AccountStatusActive, AccountStatusClosed},
+	AccountStatusDormant: {AccountStatusActive, AccountStatusFrozen, AccountStatusClosed},
+}
+
+// CanTransition reports whether an account with the status from may go to the status to.
+func CanTransition(from, to string) bool {
+	return slices.Contains(accountTransitions[from], to)
+}
+
+// Types of Account
+const (
+	AccountChecking    = "CHECKING"
+	AccountSavings     = "SAVINGS"
+	AccountTermDeposit = "TERM_DEPOSIT"
+)
+
+// DefaultProductCode is the product of the accounts opened without choosing one.
+const DefaultProductCode = "CHECKING"
+
+// AccountProduct is what an account is opened as. Its rules apply to the debits of the account.
+type AccountProduct struct {
+	Code        string `json:"code"`
+	AccountType string `json:"account_type"`
+	DisplayName string `json:"display_name"`
+	// MinBalance is the lowest balance a debit may leave, and the lowest initial balance, in minor units of the
+	// currency of the account.
+	MinBalance int64 `json:"min_balance"`
+	// MonthlyWithdrawalLimit is the number of debits allowed per calendar month (UTC), 0 for no limit.
+	MonthlyWithdrawalLimit int32 `json:"monthly_withdrawal_limit"`
+	// LockUpDays is how long after opening the account can't be debited.
+	LockUpDays int32 `json:"lock_up_days"`
+	// DayCount is the day-count convention interest accrues with, see package interest.
+	DayCount string `json:"day_count"`
+	// OverdraftLimit is how far below zero the debits may take the balance. Products with an overdraft have no
+	// minimum balance.
+	OverdraftLimit int64 `json:"overdraft_limit"`
+	// Limits are the limits of the debits of the accounts, unless overridden for an account.
+	Limits Limits `json:"limits"`
 }
 
 type Transaction struct {
 	TransactionID   uuid.UUID     `json:"transaction_id"`
 	AccountID       uuid.UUID     `json:"account_id"`
-	Amount          int64         `json:"amount"`
-	TransactionType string        `json:"transaction_type"` // DEPOSIT, WITHDRAWAL, TRANSFER_DEBIT, TRANSFER_CREDIT
+	Amount          int64         `json:"amount"`           // in minor units of Currency
+	Currency        string        `json:"currency"`         // always the currency of the account
+	TransactionType string        `json:"transaction_type"` // CREDIT, DEBIT, TRANSFER_DEBIT, TRANSFER_CREDIT, INTEREST, FEE, FEE_REVERSAL, REVERSAL
 	Status          string        `json:"status"`
 	TransferID      uuid.NullUUID `json:"transfer_id"`
+	// CausedBy is the transaction a FEE was charged for, the FEE a FEE_REVERSAL refunds, or the transaction a
+	// REVERSAL reverses
+	CausedBy uuid.NullUUID `json:"caused_by"`
+	FeeRule  string        `json:"fee_rule,omitempty"` // code of the rule of a FEE
+	Memo     string        `json:"memo,omitempty"`     // e.g. the reason of a reversal
+	HoldID   uuid.NullUUID `json:"hold_id"`            // the hold the transaction captured
+}
+
+// Types of AccountEvent
+const (
+	EventSnapshot    = "SNAPSHOT"
+	EventTransaction = "TRANSACTION"
+	EventHeartbeat   = "HEARTBEAT"
+)
+
+// AccountEvent is a change of an account, published once the transaction causing it is committed.
+type AccountEvent struct {
+	EventID     string       `json:"event_id"`
+	Type        string       `json:"type"`
+	AccountID   uuid.UUID    `json:"account_id"`
+	Balance     int64        `json:"balance"` // balance after the event
+	Currency    string       `json:"currency"`
+	Version     int64        `json:"version"` // version of the account after the event
+	Transaction *Transaction `json:"transaction,omitempty"`
 }
 
 type IdempotencyKey struct {
 	KeyID  string    `json:"key_id"`
 	UserID uuid.UUID `json:"user_id"`
+	// RPCName and RequestHash identify the request the key was claimed for, see idempotency.RequestHash
+	RPCName     string `json:"rpc_name"`
+	RequestHash string `json:"request_hash"`
+	// ClaimID identifies the attempt holding the key while it is PENDING
+	ClaimID uuid.UUID `json:"claim_id"`
 
 	Status          string `json:"status"`
 	ResponseMessage string `json:"response_body"`
 }
 
+// Matches reports whether the key was claimed by the same RPC with the same parameters.
+// Keys claimed before the request hash was stored match any request.
+func (k *IdempotencyKey) Matches(rpcName, requestHash string) bool {
+	if k.RequestHash == "" {
+		return true
+	}
+	return k.RPCName == rpcName && k.RequestHash == requestHash
+}
+
 var (
-	ErrInternalServer   error = status.Error(codes.Internal, "internal server error")
-	ErrInvalidArgument  error = status.Error(codes.InvalidArgument, "invalid argument")
-	ErrNotAuthorized    error = status.Error(codes.PermissionDenied, "not authorized")
-	ErrNotAuthenticated error = status.Error(codes.Unauthenticated, "not authenticated")
-	ErrCacheMiss        error = redis.Nil
+	ErrInternalServer           error = newError(codes.Internal, "INTERNAL", "internal server error")
+	ErrInvalidArgument          error = newError(codes.InvalidArgument, "INVALID_ARGUMENT", "invalid argument")
+	ErrAccountNotFound          error = newError(codes.NotFound, "ACCOUNT_NOT_FOUND", "account not found")
+	ErrInvalidAccountNumber     error = newError(codes.InvalidArgument, "INVALID_ACCOUNT_NUMBER", "invalid account number")
+	ErrInsufficientFunds        error = newError(codes.FailedPrecondition, "INSUFFICIENT_FUNDS", "insufficient funds")
+	ErrUnsupportedCurrency      error = newError(codes.InvalidArgument, "UNSUPPORTED_CURRENCY", "unsupported currency")
+	ErrCurrencyMismatch         error = newError(codes.InvalidArgument, "CURRENCY_MISMATCH", "currency doesn't match the currency of the account")
+	ErrUnknownProduct           error = newError(codes.InvalidArgument, "UNKNOWN_PRODUCT", "unknown account product")
+	ErrAccountNumbersExhausted  error = newError(codes.ResourceExhausted, "ACCOUNT_NUMBERS_EXHAUSTED", "no account number is left for the branch")
+	ErrMinimumBalance           error = newError(codes.FailedPrecondition, "MINIMUM_BALANCE_REQUIRED", "balance would fall below the minimum balance of the account")
+	ErrWithdrawalLimitExceeded  error = newError(codes.FailedPrecondition, "WITHDRAWAL_LIMIT_EXCEEDED", "monthly withdrawal limit of the account exceeded")
+	ErrLimitIncreaseNotAllowed  error = newError(codes.FailedPrecondition, "LIMIT_INCREASE_NOT_ALLOWED", "limits can't be raised above the ceiling of the account, the support staff can raise it")
+	ErrAccountLocked            error = newError(codes.FailedPrecondition, "ACCOUNT_LOCKED", "account can't be debited before it matures")
+	ErrAccountNotActive         error = newError(codes.FailedPrecondition, "ACCOUNT_NOT_ACTIVE", "account is awaiting approval")
+	ErrAccountFrozen            error = newError(codes.FailedPrecondition, "ACCOUNT_FROZEN", "account is frozen")
+	ErrAccountDormant           error = newError(codes.FailedPrecondition, "ACCOUNT_DORMANT", "account is dormant and must be reactivated")
+	ErrAccountClosed            error = newError(codes.FailedPrecondition, "ACCOUNT_CLOSED", "account is closed")
+	ErrNonZeroBalance           error = newError(codes.FailedPrecondition, "NONZERO_BALANCE", "account balance must be zero to close it")
+	ErrActiveHolds              error = newError(codes.FailedPrecondition, "ACTIVE_HOLDS", "account has active holds, which must be captured or voided to close it")
+	ErrInvalidStatusTransition  error = newError(codes.FailedPrecondition, "INVALID_STATUS_TRANSITION", "account can't go to this status from its current one")
+	ErrTransactionNotFound      error = newError(codes.NotFound, "TRANSACTION_NOT_FOUND", "transaction not found")
+	ErrNotAFee                  error = newError(codes.FailedPrecondition, "NOT_A_FEE", "transaction is not a fee")
+	ErrNotReversible            error = newError(codes.FailedPrecondition, "NOT_REVERSIBLE", "transaction can't be reversed")
+	ErrAlreadyReversed          error = newError(codes.FailedPrecondition, "ALREADY_REVERSED", "transaction was already reversed in full")
+	ErrReversalExceedsRemaining error = newError(codes.FailedPrecondition, "REVERSAL_EXCEEDS_REMAINING", "reversed amount is more than what remains to reverse of the transaction")
+	ErrHoldNotFound             error = newError(codes.NotFound, "HOLD_NOT_FOUND", "hold not found")
+	ErrHoldNotActive            error = newError(codes.FailedPrecondition, "HOLD_NOT_ACTIVE", "hold was already captured or voided")
+	ErrHoldExpired              error = newError(codes.FailedPrecondition, "HOLD_EXPIRED", "hold expired")
+	ErrCaptureExceedsHold       error = newError(codes.FailedPrecondition, "CAPTURE_EXCEEDS_HOLD", "captured amount is more than the amount of the hold")
+	ErrIdempotencyKeyReused     error = newError(codes.FailedPrecondition, "IDEMPOTENCY_KEY_REUSED", "idempotency key was already used for a different request")
+	ErrIdempotencyKeyInProgress error = newError(codes.Aborted, "IDEMPOTENCY_KEY_IN_PROGRESS", "a request with the same idempotency key is in progress")
+	ErrNotAuthorized            error = newError(codes.PermissionDenied, "NOT_AUTHORIZED", "not authorized")
+	ErrNotAuthenticated         error = newError(codes.Unauthenticated, "NOT_AUTHENTICATED", "not authenticated")
+	ErrCacheMiss                error = redis.Nil
 )
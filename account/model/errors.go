@@ -0,0 +1,48 @@
+package model
+
+import (
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorDomain is the domain of the google.rpc.ErrorInfo attached to the errors of the account service.
+const ErrorDomain = "account.banking-app"
+
+// newError builds a status error carrying a google.rpc.ErrorInfo, so that clients can branch on a stable reason
+// such as "INSUFFICIENT_FUNDS" instead of parsing the message.
+func newError(code codes.Code, reason, message string) error {
+	st, err := status.New(code, message).WithDetails(&errdetails.ErrorInfo{Reason: reason, Domain: ErrorDomain})
+	if err != nil {
+		panic(err) // only fails if the detail can't be marshalled, which a static ErrorInfo always can
+	}
+	return st.Err()
+}
+
+// Internal returns ErrInternalServer caused by err. Clients only get ErrInternalServer, but err stays in the chain of
+// the returned error, so that errors.As still finds e.g. the *pq.Error of a serialization failure worth retrying.
+func Internal(err error) error {
+	return &internalError{cause: err}
+}
+
+type internalError struct {
+	cause error
+}
+
+func (e *internalError) Error() string {
+	return ErrInternalServer.Error() + ": " + e.cause.Error()
+}
+
+func (e *internalError) Unwrap() error {
+	return e.cause
+}
+
+// Is makes errors.Is(err, ErrInternalServer) hold for the errors returned by Internal.
+func (e *internalError) Is(target error) bool {
+	return target == ErrInternalServer
+}
+
+// GRPCStatus is the status sent to the client, which doesn't leak the cause.
+func (e *internalError) GRPCStatus() *status.Status {
+	return status.Convert(ErrInternalServer)
+}
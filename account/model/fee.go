@@ -0,0 +1,29 @@
+package model
+
+// Types of the transactions of the fees. They are only created by the account service, never through the API.
+const (
+	TransactionFee         = "FEE"
+	TransactionFeeReversal = "FEE_REVERSAL"
+)
+
+// Triggers of FeeRule
+const (
+	FeeTriggerMonthlyMaintenance = "MONTHLY_MAINTENANCE" // charged once a month, on the balance
+	FeeTriggerOverdraft          = "OVERDRAFT"           // charged on the debits overdrawing an account, on the overdrawn amount
+	FeeTriggerTransfer           = "TRANSFER"            // charged on the debit of a transfer, on the transferred amount
+)
+
+// FeeRule is a fee charged on the accounts of a product, or of every product if ProductCode is empty, in Currency.
+// Amounts are minor units of Currency, see package fee for how the fee is computed.
+type FeeRule struct {
+	Code          string `json:"code"`
+	Trigger       string `json:"trigger"`
+	ProductCode   string `json:"product_code"`
+	Currency      string `json:"currency"`
+	FixedAmount   int64  `json:"fixed_amount"`
+	PercentageBps int32  `json:"percentage_bps"` // of the base amount of the trigger
+	MinAmount     int64  `json:"min_amount"`
+	MaxAmount     int64  `json:"max_amount"` // cap, 0 for none
+	// WaiveBalanceAtLeast waives the fee when the balance of the account is at least this, 0 for never
+	WaiveBalanceAtLeast int64 `json:"waive_balance_at_least"`
+}
@@ -0,0 +1,30 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Statuses of Hold
+const (
+	HoldStatusActive   = "ACTIVE"   // reserves its amount
+	HoldStatusCaptured = "CAPTURED" // posted as a transaction, the rest of its amount released
+	HoldStatusVoided   = "VOIDED"   // released by its owner
+	HoldStatusExpired  = "EXPIRED"  // released because it wasn't captured in time
+)
+
+// Hold reserves Amount of an account until it is captured, voided or expires: it lowers the available balance of the
+// account but not its balance. Capturing it posts a transaction of TransactionType for at most Amount.
+type Hold struct {
+	HoldID          uuid.UUID     `json:"hold_id"`
+	AccountID       uuid.UUID     `json:"account_id"`
+	Amount          int64         `json:"amount"` // positive, in minor units of Currency
+	Currency        string        `json:"currency"`
+	TransactionType string        `json:"transaction_type"` // DEBIT or TRANSFER_DEBIT
+	TransferID      uuid.NullUUID `json:"transfer_id"`
+	Status          string        `json:"status"`
+	Captured        int64         `json:"captured"` // amount posted by the capture, 0 unless CAPTURED
+	ExpiresAt       time.Time     `json:"expires_at"`
+	CreatedAt       time.Time     `json:"created_at"`
+}
@@ -0,0 +1,38 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TransactionInterest is the type of the transactions posting the interest of an account. They are only created by the
+// interest job, never through the API.
+const TransactionInterest = "INTEREST"
+
+// InterestRate is the annual rate of a product from EffectiveFrom until its next rate.
+type InterestRate struct {
+	ProductCode   string    `json:"product_code"`
+	EffectiveFrom time.Time `json:"effective_from"`
+	AnnualRate    string    `json:"annual_rate"` // decimal, 0.025 for 2.5%
+}
+
+// InterestAccrual is the interest earned by an account on a day (UTC), in fractions of minor units of its currency.
+type InterestAccrual struct {
+	AccountID   uuid.UUID `json:"account_id"`
+	AccrualDate time.Time `json:"accrual_date"`
+	EODBalance  int64     `json:"eod_balance"` // balance at the end of the day
+	AnnualRate  string    `json:"annual_rate"`
+	Amount      string    `json:"amount"` // decimal
+}
+
+// InterestPosting is the posting of the interest accrued by an account over a month.
+type InterestPosting struct {
+	AccountID uuid.UUID `json:"account_id"`
+	Period    time.Time `json:"period"`  // first day of the month
+	Accrued   string    `json:"accrued"` // interest of the month plus the remainder carried from the previous posting
+	Posted    int64     `json:"posted"`  // whole minor units of Accrued credited to the account
+	Carried   string    `json:"carried"` // remainder carried to the next posting
+	// TransactionID is the INTEREST transaction, invalid if nothing was posted
+	TransactionID uuid.NullUUID `json:"transaction_id"`
+}
@@ -0,0 +1,143 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/google/uuid"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Levels of the overrides of the limits of an account
+const (
+	LimitLevelAccount = "ACCOUNT" // set by the support staff, replacing the limits of the product
+	LimitLevelUser    = "USER"    // set by the owner of the account, at most the limits of the level below
+)
+
+// Names of the limits, as reported by LimitExceededError
+const (
+	LimitMaxSingleDebit    = "MAX_SINGLE_DEBIT"
+	LimitDailyDebitTotal   = "DAILY_DEBIT_TOTAL"
+	LimitDailyDebitCount   = "DAILY_DEBIT_COUNT"
+	LimitMonthlyDebitTotal = "MONTHLY_DEBIT_TOTAL"
+)
+
+// Names of the limits of all the accounts of a user together, see UserLimits
+const (
+	LimitUserMaxSingleDebit    = "USER_MAX_SINGLE_DEBIT"
+	LimitUserDailyDebitTotal   = "USER_DAILY_DEBIT_TOTAL"
+	LimitUserDailyDebitCount   = "USER_DAILY_DEBIT_COUNT"
+	LimitUserMonthlyDebitTotal = "USER_MONTHLY_DEBIT_TOTAL"
+)
+
+// Limits caps the debits of an account, in minor units of the currency of the account. Days and months are calendar
+// days and months (UTC). A limit is 0 if there is none, or, in an override, if it isn't overridden.
+type Limits struct {
+	MaxSingleDebit    int64 `json:"max_single_debit"`
+	DailyDebitTotal   int64 `json:"daily_debit_total"`
+	DailyDebitCount   int32 `json:"daily_debit_count"`
+	MonthlyDebitTotal int64 `json:"monthly_debit_total"`
+}
+
+// Override returns l with the limits set by o replaced.
+func (l Limits) Override(o Limits) Limits {
+	return Limits{
+		MaxSingleDebit:    override(l.MaxSingleDebit, o.MaxSingleDebit),
+		DailyDebitTotal:   override(l.DailyDebitTotal, o.DailyDebitTotal),
+		DailyDebitCount:   override(l.DailyDebitCount, o.DailyDebitCount),
+		MonthlyDebitTotal: override(l.MonthlyDebitTotal, o.MonthlyDebitTotal),
+	}
+}
+
+// Lower returns l with the limits set by o, lowered to o where o is lower.
+func (l Limits) Lower(o Limits) Limits {
+	return Limits{
+		MaxSingleDebit:    lower(l.MaxSingleDebit, o.MaxSingleDebit),
+		DailyDebitTotal:   lower(l.DailyDebitTotal, o.DailyDebitTotal),
+		DailyDebitCount:   lower(l.DailyDebitCount, o.DailyDebitCount),
+		MonthlyDebitTotal: lower(l.MonthlyDebitTotal, o.MonthlyDebitTotal),
+	}
+}
+
+// Raises reports whether o sets a limit higher than the one of l. Setting a limit that l doesn't have lowers it.
+func (l Limits) Raises(o Limits) bool {
+	return raises(l.MaxSingleDebit, o.MaxSingleDebit) || raises(l.DailyDebitTotal, o.DailyDebitTotal) ||
+		raises(l.DailyDebitCount, o.DailyDebitCount) || raises(l.MonthlyDebitTotal, o.MonthlyDebitTotal)
+}
+
+func override[T int32 | int64](limit, o T) T {
+	if o > 0 {
+		return o
+	}
+	return limit
+}
+
+func raises[T int32 | int64](limit, o T) bool {
+	return o > 0 && limit > 0 && o > limit
+}
+
+func lower[T int32 | int64](limit, o T) T {
+	if o > 0 && (limit == 0 || o < limit) {
+		return o
+	}
+	return limit
+}
+
+// LimitUsage is how much of its limits an account has used: the debits, and the holds that are still active, of the
+// current day and month. A captured hold counts on the day it was placed, not the day it was captured.
+type LimitUsage struct {
+	DailyDebitTotal   int64 `json:"daily_debit_total"`
+	DailyDebitCount   int64 `json:"daily_debit_count"`
+	MonthlyDebitTotal int64 `json:"monthly_debit_total"`
+}
+
+// AccountLimits are the limits applying to the debits of an account, and what is left of them.
+type AccountLimits struct {
+	Currency  string     `json:"currency"`
+	Effective Limits     `json:"effective"`
+	Ceiling   Limits     `json:"ceiling"` // of the product as replaced by the support staff, which User can't exceed
+	User      Limits     `json:"user"`    // set by the owner of the account
+	Usage     LimitUsage `json:"usage"`
+}
+
+// UserLimits cap the debits of all the accounts of UserID in Currency together, on top of the limits of each account.
+// Usage is the usage of all these accounts.
+type UserLimits struct {
+	UserID   uuid.UUID  `json:"user_id"`
+	Currency string     `json:"currency"`
+	Limits   Limits     `json:"limits"`
+	Usage    LimitUsage `json:"usage"`
+}
+
+// LimitExceededError is returned for a debit exceeding Limit, Allowed, of which Remaining was left before the debit.
+// The amounts are in minor units of the currency of the account, or numbers of debits for the daily debit counts.
+type LimitExceededError struct {
+	Limit     string
+	Allowed   int64
+	Remaining int64
+}
+
+func (e *LimitExceededError) Error() string {
+	return fmt.Sprintf("%s limit exceeded: %d allowed, %d remaining", e.Limit, e.Allowed, e.Remaining)
+}
+
+// GRPCStatus carries the limit and the remaining allowance in the metadata of the google.rpc.ErrorInfo, so that
+// clients can tell the user how much they can still debit. The code is RESOURCE_EXHAUSTED, the quota of the user being
+// used up, and the ErrorInfo tells it apart from the service running out of resources.
+func (e *LimitExceededError) GRPCStatus() *status.Status {
+	st, err := status.New(codes.ResourceExhausted, e.Error()).WithDetails(&errdetails.ErrorInfo{
+		Reason: "LIMIT_EXCEEDED",
+		Domain: ErrorDomain,
+		Metadata: map[string]string{
+			"limit":     e.Limit,
+			"allowed":   strconv.FormatInt(e.Allowed, 10),
+			"remaining": strconv.FormatInt(e.Remaining, 10),
+		},
+	})
+	if err != nil {
+		panic(err) // only fails if the detail can't be marshalled, which an ErrorInfo always can
+	}
+	return st
+}
@@ -0,0 +1,6 @@
+package model
+
+// TransactionReversal is the type of the transactions offsetting all or part of another transaction, their CausedBy.
+// They are only created by ReverseTransaction, which marks the reversed transaction REVERSED once its reversals add up
+// to its amount.
+const TransactionReversal = "REVERSAL"
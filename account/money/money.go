@@ -0,0 +1,134 @@
+// Package money represents amounts of money exactly, as an integer number of minor units of an ISO 4217 currency:
+// cents for USD, EUR or GBP, whole yens for JPY. Amounts never go through floating point, and the arithmetic fails
+// rather than overflowing or mixing currencies.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is the currency of the accounts opened before accounts had a currency.
+const DefaultCurrency = "USD"
+
+var (
+	ErrUnknownCurrency  = errors.New("money: unknown currency")
+	ErrCurrencyMismatch = errors.New("money: currencies don't match")
+	ErrOverflow         = errors.New("money: amount out of range")
+)
+
+// minorUnits is the number of digits after the decimal separator of the supported currencies, from ISO 4217.
+var minorUnits = map[string]int{
+	"AUD": 2,
+	"BHD": 3,
+	"CAD": 2,
+	"CHF": 2,
+	"CNY": 2,
+	"DKK": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"HKD": 2,
+	"JPY": 0,
+	"KWD": 3,
+	"NOK": 2,
+	"NZD": 2,
+	"SEK": 2,
+	"SGD": 2,
+	"USD": 2,
+}
+
+// Money is an amount of Units minor units of Currency, an ISO 4217 code.
+type Money struct {
+	Currency string `json:"currency"`
+	Units    int64  `json:"units"`
+}
+
+// New returns units minor units of currency, and fails with ErrUnknownCurrency if the currency isn't supported.
+func New(currency string, units int64) (Money, error) {
+	if !Supported(currency) {
+		return Money{}, fmt.Errorf("%w: %q", ErrUnknownCurrency, currency)
+	}
+	return Money{Currency: currency, Units: units}, nil
+}
+
+// Supported reports whether currency is a supported ISO 4217 code.
+func Supported(currency string) bool {
+	_, ok := minorUnits[currency]
+	return ok
+}
+
+// MinorUnits returns the number of digits after the decimal separator of currency, 2 for the cents of EUR.
+func MinorUnits(currency string) (int, error) {
+	n, ok := minorUnits[currency]
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", ErrUnknownCurrency, currency)
+	}
+	return n, nil
+}
+
+// Add returns m + n, which must have the same currency.
+func (m Money) Add(n Money) (Money, error) {
+	if m.Currency != n.Currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, n.Currency)
+	}
+	if (n.Units > 0 && m.Units > math.MaxInt64-n.Units) || (n.Units < 0 && m.Units < math.MinInt64-n.Units) {
+		return Money{}, ErrOverflow
+	}
+	return Money{Currency: m.Currency, Units: m.Units + n.Units}, nil
+}
+
+// Sub returns m - n, which must have the same currency.
+func (m Money) Sub(n Money) (Money, error) {
+	neg, err := n.Neg()
+	if err != nil {
+		return Money{}, err
+	}
+	return m.Add(neg)
+}
+
+// Neg returns -m.
+func (m Money) Neg() (Money, error) {
+	if m.Units == math.MinInt64 {
+		return Money{}, ErrOverflow
+	}
+	return Money{Currency: m.Currency, Units: -m.Units}, nil
+}
+
+// Cmp returns -1, 0 or 1 if m is less than, equal to or greater than n, which must have the same currency.
+func (m Money) Cmp(n Money) (int, error) {
+	if m.Currency != n.Currency {
+		return 0, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, n.Currency)
+	}
+	switch {
+	case m.Units < n.Units:
+		return -1, nil
+	case m.Units > n.Units:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// String formats m in major units followed by its currency, such as "-12.34 EUR". Amounts of unknown currencies are
+// formatted in minor units.
+func (m Money) String() string {
+	digits, ok := minorUnits[m.Currency]
+	if !ok || digits == 0 {
+		return strconv.FormatInt(m.Units, 10) + " " + m.Currency
+	}
+
+	// formatted from the absolute value as unsigned, which also holds the one of math.MinInt64
+	abs := uint64(m.Units)
+	sign := ""
+	if m.Units < 0 {
+		abs = -abs
+		sign = "-"
+	}
+	s := strconv.FormatUint(abs, 10)
+	if len(s) <= digits {
+		s = strings.Repeat("0", digits-len(s)+1) + s
+	}
+	return sign + s[:len(s)-digits] + "." + s[len(s)-digits:] + " " + m.Currency
+}
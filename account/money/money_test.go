@@ -0,0 +1,75 @@
+package money
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	m, err := New("EUR", 1234)
+	require.NoError(t, err)
+	require.Equal(t, Money{Currency: "EUR", Units: 1234}, m)
+
+	for _, currency := range []string{"", "eur", "XXX", "EURO"} {
+		_, err = New(currency, 1)
+		require.ErrorIs(t, err, ErrUnknownCurrency, currency)
+	}
+}
+
+func TestMinorUnits(t *testing.T) {
+	for currency, expected := range map[string]int{"USD": 2, "EUR": 2, "GBP": 2, "JPY": 0, "BHD": 3} {
+		n, err := MinorUnits(currency)
+		require.NoError(t, err)
+		require.Equal(t, expected, n, currency)
+	}
+	_, err := MinorUnits("XXX")
+	require.ErrorIs(t, err, ErrUnknownCurrency)
+}
+
+func TestArithmetic(t *testing.T) {
+	a := Money{Currency: "GBP", Units: 1000}
+	b := Money{Currency: "GBP", Units: 250}
+
+	sum, err := a.Add(b)
+	require.NoError(t, err)
+	require.Equal(t, Money{Currency: "GBP", Units: 1250}, sum)
+	diff, err := b.Sub(a)
+	require.NoError(t, err)
+	require.Equal(t, Money{Currency: "GBP", Units: -750}, diff)
+	cmp, err := a.Cmp(b)
+	require.NoError(t, err)
+	require.Equal(t, 1, cmp)
+
+	// currencies are never mixed
+	eur := Money{Currency: "EUR", Units: 1}
+	_, err = a.Add(eur)
+	require.ErrorIs(t, err, ErrCurrencyMismatch)
+	_, err = a.Sub(eur)
+	require.ErrorIs(t, err, ErrCurrencyMismatch)
+	_, err = a.Cmp(eur)
+	require.ErrorIs(t, err, ErrCurrencyMismatch)
+
+	// nor overflow
+	_, err = Money{Currency: "GBP", Units: math.MaxInt64}.Add(Money{Currency: "GBP", Units: 1})
+	require.ErrorIs(t, err, ErrOverflow)
+	_, err = Money{Currency: "GBP", Units: math.MinInt64}.Sub(Money{Currency: "GBP", Units: 1})
+	require.ErrorIs(t, err, ErrOverflow)
+	_, err = Money{Currency: "GBP", Units: 0}.Sub(Money{Currency: "GBP", Units: math.MinInt64})
+	require.ErrorIs(t, err, ErrOverflow)
+}
+
+func TestString(t *testing.T) {
+	for expected, m := range map[string]Money{
+		"12.34 EUR":                 {Currency: "EUR", Units: 1234},
+		"-0.05 USD":                 {Currency: "USD", Units: -5},
+		"0.00 GBP":                  {Currency: "GBP", Units: 0},
+		"1500 JPY":                  {Currency: "JPY", Units: 1500},
+		"1.005 BHD":                 {Currency: "BHD", Units: 1005},
+		"42 XXX":                    {Currency: "XXX", Units: 42},
+		"-92233720368547758.08 USD": {Currency: "USD", Units: math.MinInt64},
+	} {
+		require.Equal(t, expected, m.String())
+	}
+}
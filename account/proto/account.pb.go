@@ -8,6 +8,7 @@ package proto
 
 import (
 	_ "buf.build/gen/go/bufbuild/protovalidate/protocolbuffers/go/buf/validate"
+	_ "google.golang.org/genproto/googleapis/api/annotations"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	reflect "reflect"
@@ -23,13 +24,22 @@ const (
 )
 
 type Account struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	AccountId     string                 `protobuf:"bytes,1,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
-	AccountNumber int32                  `protobuf:"varint,2,opt,name=account_number,json=accountNumber,proto3" json:"account_number,omitempty"`
-	Balance       int64                  `protobuf:"varint,3,opt,name=balance,proto3" json:"balance,omitempty"`
-	UserId        string                 `protobuf:"bytes,4,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	AccountId        string                 `protobuf:"bytes,1,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	AccountNumber    int64                  `protobuf:"varint,2,opt,name=account_number,json=accountNumber,proto3" json:"account_number,omitempty"`
+	UserId           string                 `protobuf:"bytes,4,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	AccountType      string                 `protobuf:"bytes,5,opt,name=account_type,json=accountType,proto3" json:"account_type,omitempty"`                 // "CHECKING", "SAVINGS" or "TERM_DEPOSIT", the type of the product
+	ProductCode      string                 `protobuf:"bytes,6,opt,name=product_code,json=productCode,proto3" json:"product_code,omitempty"`                 // product the account was opened as
+	MaturesAt        int64                  `protobuf:"varint,7,opt,name=matures_at,json=maturesAt,proto3" json:"matures_at,omitempty"`                      // unix time the account can be debited from, 0 if the product has no lock-up period
+	Status           string                 `protobuf:"bytes,8,opt,name=status,proto3" json:"status,omitempty"`                                              // "PENDING", "ACTIVE", "FROZEN", "DORMANT" or "CLOSED"
+	StatusChangedAt  int64                  `protobuf:"varint,9,opt,name=status_changed_at,json=statusChangedAt,proto3" json:"status_changed_at,omitempty"`  // unix time the account got its current status
+	ClosureReason    string                 `protobuf:"bytes,10,opt,name=closure_reason,json=closureReason,proto3" json:"closure_reason,omitempty"`          // for closed accounts
+	ClosedAt         int64                  `protobuf:"varint,11,opt,name=closed_at,json=closedAt,proto3" json:"closed_at,omitempty"`                        // unix time the account was closed, 0 if it isn't
+	Balance          *Money                 `protobuf:"bytes,12,opt,name=balance,proto3" json:"balance,omitempty"`                                           // same as ledger_balance
+	LedgerBalance    *Money                 `protobuf:"bytes,13,opt,name=ledger_balance,json=ledgerBalance,proto3" json:"ledger_balance,omitempty"`          // sum of the posted transactions
+	AvailableBalance *Money                 `protobuf:"bytes,14,opt,name=available_balance,json=availableBalance,proto3" json:"available_balance,omitempty"` // ledger_balance minus the amount reserved by the active holds, what can be debited
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
 }
 
 func (x *Account) Reset() {
@@ -69,38 +79,110 @@ func (x *Account) GetAccountId() string {
 	return ""
 }
 
-func (x *Account) GetAccountNumber() int32 {
+func (x *Account) GetAccountNumber() int64 {
 	if x != nil {
 		return x.AccountNumber
 	}
 	return 0
 }
 
-func (x *Account) GetBalance() int64 {
+func (x *Account) GetUserId() string {
 	if x != nil {
-		return x.Balance
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *Account) GetAccountType() string {
+	if x != nil {
+		return x.AccountType
+	}
+	return ""
+}
+
+func (x *Account) GetProductCode() string {
+	if x != nil {
+		return x.ProductCode
+	}
+	return ""
+}
+
+func (x *Account) GetMaturesAt() int64 {
+	if x != nil {
+		return x.MaturesAt
 	}
 	return 0
 }
 
-func (x *Account) GetUserId() string {
+func (x *Account) GetStatus() string {
 	if x != nil {
-		return x.UserId
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Account) GetStatusChangedAt() int64 {
+	if x != nil {
+		return x.StatusChangedAt
+	}
+	return 0
+}
+
+func (x *Account) GetClosureReason() string {
+	if x != nil {
+		return x.ClosureReason
 	}
 	return ""
 }
 
+func (x *Account) GetClosedAt() int64 {
+	if x != nil {
+		return x.ClosedAt
+	}
+	return 0
+}
+
+func (x *Account) GetBalance() *Money {
+	if x != nil {
+		return x.Balance
+	}
+	return nil
+}
+
+func (x *Account) GetLedgerBalance() *Money {
+	if x != nil {
+		return x.LedgerBalance
+	}
+	return nil
+}
+
+func (x *Account) GetAvailableBalance() *Money {
+	if x != nil {
+		return x.AvailableBalance
+	}
+	return nil
+}
+
 type Transaction struct {
-	state           protoimpl.MessageState `protogen:"open.v1"`
-	TransactionId   string                 `protobuf:"bytes,1,opt,name=transaction_id,json=transactionId,proto3" json:"transaction_id,omitempty"`
-	AccountId       string                 `protobuf:"bytes,2,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
-	Amount          int64                  `protobuf:"varint,3,opt,name=amount,proto3" json:"amount,omitempty"`
-	Timestamp       int64                  `protobuf:"varint,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
-	TransactionType string                 `protobuf:"bytes,5,opt,name=transaction_type,json=transactionType,proto3" json:"transaction_type,omitempty"` // "CREDIT" or "DEBIT" or "TRANSFER_CREDIT" or "TRANSFER_DEBIT"
-	Status          string                 `protobuf:"bytes,6,opt,name=status,proto3" json:"status,omitempty"`                                          // "PENDING", "COMPLETED", "FAILED"
-	TransferId      string                 `protobuf:"bytes,7,opt,name=transfer_id,json=transferId,proto3" json:"transfer_id,omitempty"`                // for transfer transactions, this is the id of the other transaction
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TransactionId string                 `protobuf:"bytes,1,opt,name=transaction_id,json=transactionId,proto3" json:"transaction_id,omitempty"`
+	AccountId     string                 `protobuf:"bytes,2,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	// "CREDIT" or "DEBIT" or "TRANSFER_CREDIT" or "TRANSFER_DEBIT", or posted by the bank: "INTEREST" for the interest
+	// posted monthly, "FEE" for a fee and "FEE_REVERSAL" for the refund of a fee, or "REVERSAL" for the reversal of a
+	// transaction
+	TransactionType string `protobuf:"bytes,5,opt,name=transaction_type,json=transactionType,proto3" json:"transaction_type,omitempty"`
+	Status          string `protobuf:"bytes,6,opt,name=status,proto3" json:"status,omitempty"`                           // "PENDING", "COMPLETED", "FAILED", or "REVERSED" for a fee or transaction reversed in full
+	TransferId      string `protobuf:"bytes,7,opt,name=transfer_id,json=transferId,proto3" json:"transfer_id,omitempty"` // for transfer transactions, this is the id of the other transaction
+	Amount          *Money `protobuf:"bytes,8,opt,name=amount,proto3" json:"amount,omitempty"`                           // in the currency of the account
+	// the transaction a FEE was charged for, empty for a monthly fee, the FEE a FEE_REVERSAL refunds, or the transaction
+	// a REVERSAL reverses
+	CausedBy      string `protobuf:"bytes,9,opt,name=caused_by,json=causedBy,proto3" json:"caused_by,omitempty"`
+	FeeRule       string `protobuf:"bytes,10,opt,name=fee_rule,json=feeRule,proto3" json:"fee_rule,omitempty"` // code of the rule that charged a FEE
+	Memo          string `protobuf:"bytes,11,opt,name=memo,proto3" json:"memo,omitempty"`                      // e.g. the reason of a reversal
+	HoldId        string `protobuf:"bytes,12,opt,name=hold_id,json=holdId,proto3" json:"hold_id,omitempty"`    // the hold the transaction captured
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *Transaction) Reset() {
@@ -147,13 +229,6 @@ func (x *Transaction) GetAccountId() string {
 	return ""
 }
 
-func (x *Transaction) GetAmount() int64 {
-	if x != nil {
-		return x.Amount
-	}
-	return 0
-}
-
 func (x *Transaction) GetTimestamp() int64 {
 	if x != nil {
 		return x.Timestamp
@@ -182,19 +257,168 @@ func (x *Transaction) GetTransferId() string {
 	return ""
 }
 
-// user_id is the ID of the user associated with the JWT token validated at the API Gateway
+func (x *Transaction) GetAmount() *Money {
+	if x != nil {
+		return x.Amount
+	}
+	return nil
+}
+
+func (x *Transaction) GetCausedBy() string {
+	if x != nil {
+		return x.CausedBy
+	}
+	return ""
+}
+
+func (x *Transaction) GetFeeRule() string {
+	if x != nil {
+		return x.FeeRule
+	}
+	return ""
+}
+
+func (x *Transaction) GetMemo() string {
+	if x != nil {
+		return x.Memo
+	}
+	return ""
+}
+
+func (x *Transaction) GetHoldId() string {
+	if x != nil {
+		return x.HoldId
+	}
+	return ""
+}
+
+type Hold struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	HoldId          string                 `protobuf:"bytes,1,opt,name=hold_id,json=holdId,proto3" json:"hold_id,omitempty"`
+	AccountId       string                 `protobuf:"bytes,2,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	Amount          *Money                 `protobuf:"bytes,3,opt,name=amount,proto3" json:"amount,omitempty"`                                          // reserved amount, in the currency of the account
+	TransactionType string                 `protobuf:"bytes,4,opt,name=transaction_type,json=transactionType,proto3" json:"transaction_type,omitempty"` // "DEBIT" or "TRANSFER_DEBIT", the type of the transaction capturing the hold
+	TransferId      string                 `protobuf:"bytes,5,opt,name=transfer_id,json=transferId,proto3" json:"transfer_id,omitempty"`                // for the holds of transfers
+	Status          string                 `protobuf:"bytes,6,opt,name=status,proto3" json:"status,omitempty"`                                          // "ACTIVE", "CAPTURED", "VOIDED" or "EXPIRED"
+	Captured        *Money                 `protobuf:"bytes,7,opt,name=captured,proto3" json:"captured,omitempty"`                                      // amount debited by the capture, zero unless CAPTURED
+	ExpiresAt       int64                  `protobuf:"varint,8,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`                  // unix time the hold expires if it is still ACTIVE
+	CreatedAt       int64                  `protobuf:"varint,9,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`                  // unix time
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Hold) Reset() {
+	*x = Hold{}
+	mi := &file_account_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Hold) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Hold) ProtoMessage() {}
+
+func (x *Hold) ProtoReflect() protoreflect.Message {
+	mi := &file_account_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Hold.ProtoReflect.Descriptor instead.
+func (*Hold) Descriptor() ([]byte, []int) {
+	return file_account_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Hold) GetHoldId() string {
+	if x != nil {
+		return x.HoldId
+	}
+	return ""
+}
+
+func (x *Hold) GetAccountId() string {
+	if x != nil {
+		return x.AccountId
+	}
+	return ""
+}
+
+func (x *Hold) GetAmount() *Money {
+	if x != nil {
+		return x.Amount
+	}
+	return nil
+}
+
+func (x *Hold) GetTransactionType() string {
+	if x != nil {
+		return x.TransactionType
+	}
+	return ""
+}
+
+func (x *Hold) GetTransferId() string {
+	if x != nil {
+		return x.TransferId
+	}
+	return ""
+}
+
+func (x *Hold) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Hold) GetCaptured() *Money {
+	if x != nil {
+		return x.Captured
+	}
+	return nil
+}
+
+func (x *Hold) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+func (x *Hold) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+// user_id is deprecated and ignored: the user is taken from the access token forwarded in the request metadata.
+// balance must be at least the minimum balance of the product, or the request fails with MINIMUM_BALANCE_REQUIRED.
+// Its currency is the currency of the account.
 type CreateAccountRequest struct {
-	state          protoimpl.MessageState `protogen:"open.v1"`
-	UserId         string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	Balance        int64                  `protobuf:"varint,2,opt,name=balance,proto3" json:"balance,omitempty"`
-	IdempotencyKey string                 `protobuf:"bytes,3,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
-	unknownFields  protoimpl.UnknownFields
-	sizeCache      protoimpl.SizeCache
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Deprecated: Marked as deprecated in account.proto.
+	UserId         string `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	IdempotencyKey string `protobuf:"bytes,3,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	// "CHECKING", "SAVINGS" or "TERM_DEPOSIT_12M". Empty for "CHECKING".
+	// Unknown products fail with UNKNOWN_PRODUCT.
+	ProductCode   string `protobuf:"bytes,4,opt,name=product_code,json=productCode,proto3" json:"product_code,omitempty"`
+	Balance       *Money `protobuf:"bytes,5,opt,name=balance,proto3" json:"balance,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *CreateAccountRequest) Reset() {
 	*x = CreateAccountRequest{}
-	mi := &file_account_proto_msgTypes[2]
+	mi := &file_account_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -206,7 +430,7 @@ func (x *CreateAccountRequest) String() string {
 func (*CreateAccountRequest) ProtoMessage() {}
 
 func (x *CreateAccountRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_account_proto_msgTypes[2]
+	mi := &file_account_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -219,9 +443,10 @@ func (x *CreateAccountRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CreateAccountRequest.ProtoReflect.Descriptor instead.
 func (*CreateAccountRequest) Descriptor() ([]byte, []int) {
-	return file_account_proto_rawDescGZIP(), []int{2}
+	return file_account_proto_rawDescGZIP(), []int{3}
 }
 
+// Deprecated: Marked as deprecated in account.proto.
 func (x *CreateAccountRequest) GetUserId() string {
 	if x != nil {
 		return x.UserId
@@ -229,31 +454,38 @@ func (x *CreateAccountRequest) GetUserId() string {
 	return ""
 }
 
-func (x *CreateAccountRequest) GetBalance() int64 {
+func (x *CreateAccountRequest) GetIdempotencyKey() string {
 	if x != nil {
-		return x.Balance
+		return x.IdempotencyKey
 	}
-	return 0
+	return ""
 }
 
-func (x *CreateAccountRequest) GetIdempotencyKey() string {
+func (x *CreateAccountRequest) GetProductCode() string {
 	if x != nil {
-		return x.IdempotencyKey
+		return x.ProductCode
 	}
 	return ""
 }
 
+func (x *CreateAccountRequest) GetBalance() *Money {
+	if x != nil {
+		return x.Balance
+	}
+	return nil
+}
+
 type CreateAccountResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	AccountId     string                 `protobuf:"bytes,1,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
-	AccountNumber int32                  `protobuf:"varint,2,opt,name=account_number,json=accountNumber,proto3" json:"account_number,omitempty"`
+	AccountNumber int64                  `protobuf:"varint,2,opt,name=account_number,json=accountNumber,proto3" json:"account_number,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *CreateAccountResponse) Reset() {
 	*x = CreateAccountResponse{}
-	mi := &file_account_proto_msgTypes[3]
+	mi := &file_account_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -265,7 +497,7 @@ func (x *CreateAccountResponse) String() string {
 func (*CreateAccountResponse) ProtoMessage() {}
 
 func (x *CreateAccountResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_account_proto_msgTypes[3]
+	mi := &file_account_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -278,7 +510,7 @@ func (x *CreateAccountResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CreateAccountResponse.ProtoReflect.Descriptor instead.
 func (*CreateAccountResponse) Descriptor() ([]byte, []int) {
-	return file_account_proto_rawDescGZIP(), []int{3}
+	return file_account_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *CreateAccountResponse) GetAccountId() string {
@@ -288,24 +520,25 @@ func (x *CreateAccountResponse) GetAccountId() string {
 	return ""
 }
 
-func (x *CreateAccountResponse) GetAccountNumber() int32 {
+func (x *CreateAccountResponse) GetAccountNumber() int64 {
 	if x != nil {
 		return x.AccountNumber
 	}
 	return 0
 }
 
-// user_id is the ID of the user associated with the JWT token validated at the API Gateway
+// user_id is deprecated and ignored: the user is taken from the access token forwarded in the request metadata.
 type GetAccountsByUserIdRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Deprecated: Marked as deprecated in account.proto.
+	UserId        string `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *GetAccountsByUserIdRequest) Reset() {
 	*x = GetAccountsByUserIdRequest{}
-	mi := &file_account_proto_msgTypes[4]
+	mi := &file_account_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -317,7 +550,7 @@ func (x *GetAccountsByUserIdRequest) String() string {
 func (*GetAccountsByUserIdRequest) ProtoMessage() {}
 
 func (x *GetAccountsByUserIdRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_account_proto_msgTypes[4]
+	mi := &file_account_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -330,9 +563,10 @@ func (x *GetAccountsByUserIdRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetAccountsByUserIdRequest.ProtoReflect.Descriptor instead.
 func (*GetAccountsByUserIdRequest) Descriptor() ([]byte, []int) {
-	return file_account_proto_rawDescGZIP(), []int{4}
+	return file_account_proto_rawDescGZIP(), []int{5}
 }
 
+// Deprecated: Marked as deprecated in account.proto.
 func (x *GetAccountsByUserIdRequest) GetUserId() string {
 	if x != nil {
 		return x.UserId
@@ -349,7 +583,7 @@ type GetAccountsByUserIdResponse struct {
 
 func (x *GetAccountsByUserIdResponse) Reset() {
 	*x = GetAccountsByUserIdResponse{}
-	mi := &file_account_proto_msgTypes[5]
+	mi := &file_account_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -361,7 +595,7 @@ func (x *GetAccountsByUserIdResponse) String() string {
 func (*GetAccountsByUserIdResponse) ProtoMessage() {}
 
 func (x *GetAccountsByUserIdResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_account_proto_msgTypes[5]
+	mi := &file_account_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -374,7 +608,7 @@ func (x *GetAccountsByUserIdResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetAccountsByUserIdResponse.ProtoReflect.Descriptor instead.
 func (*GetAccountsByUserIdResponse) Descriptor() ([]byte, []int) {
-	return file_account_proto_rawDescGZIP(), []int{5}
+	return file_account_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *GetAccountsByUserIdResponse) GetAccounts() []*Account {
@@ -384,18 +618,19 @@ func (x *GetAccountsByUserIdResponse) GetAccounts() []*Account {
 	return nil
 }
 
-// user_id is the ID of the user associated with the JWT token validated at the API Gateway
+// user_id is deprecated and ignored: the user is taken from the access token forwarded in the request metadata.
 type GetAccountByAccountNumberRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	AccountNumber int32                  `protobuf:"varint,2,opt,name=account_number,json=accountNumber,proto3" json:"account_number,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Deprecated: Marked as deprecated in account.proto.
+	UserId        string `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	AccountNumber int64  `protobuf:"varint,2,opt,name=account_number,json=accountNumber,proto3" json:"account_number,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *GetAccountByAccountNumberRequest) Reset() {
 	*x = GetAccountByAccountNumberRequest{}
-	mi := &file_account_proto_msgTypes[6]
+	mi := &file_account_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -407,7 +642,7 @@ func (x *GetAccountByAccountNumberRequest) String() string {
 func (*GetAccountByAccountNumberRequest) ProtoMessage() {}
 
 func (x *GetAccountByAccountNumberRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_account_proto_msgTypes[6]
+	mi := &file_account_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -420,9 +655,10 @@ func (x *GetAccountByAccountNumberRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetAccountByAccountNumberRequest.ProtoReflect.Descriptor instead.
 func (*GetAccountByAccountNumberRequest) Descriptor() ([]byte, []int) {
-	return file_account_proto_rawDescGZIP(), []int{6}
+	return file_account_proto_rawDescGZIP(), []int{7}
 }
 
+// Deprecated: Marked as deprecated in account.proto.
 func (x *GetAccountByAccountNumberRequest) GetUserId() string {
 	if x != nil {
 		return x.UserId
@@ -430,25 +666,26 @@ func (x *GetAccountByAccountNumberRequest) GetUserId() string {
 	return ""
 }
 
-func (x *GetAccountByAccountNumberRequest) GetAccountNumber() int32 {
+func (x *GetAccountByAccountNumberRequest) GetAccountNumber() int64 {
 	if x != nil {
 		return x.AccountNumber
 	}
 	return 0
 }
 
-// user_id is the ID of the user associated with the JWT token validated at the API Gateway
+// user_id is deprecated and ignored: the user is taken from the access token forwarded in the request metadata.
 type GetAccountByAccountIdRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	AccountId     string                 `protobuf:"bytes,2,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Deprecated: Marked as deprecated in account.proto.
+	UserId        string `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	AccountId     string `protobuf:"bytes,2,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *GetAccountByAccountIdRequest) Reset() {
 	*x = GetAccountByAccountIdRequest{}
-	mi := &file_account_proto_msgTypes[7]
+	mi := &file_account_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -460,7 +697,7 @@ func (x *GetAccountByAccountIdRequest) String() string {
 func (*GetAccountByAccountIdRequest) ProtoMessage() {}
 
 func (x *GetAccountByAccountIdRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_account_proto_msgTypes[7]
+	mi := &file_account_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -473,9 +710,10 @@ func (x *GetAccountByAccountIdRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetAccountByAccountIdRequest.ProtoReflect.Descriptor instead.
 func (*GetAccountByAccountIdRequest) Descriptor() ([]byte, []int) {
-	return file_account_proto_rawDescGZIP(), []int{7}
+	return file_account_proto_rawDescGZIP(), []int{8}
 }
 
+// Deprecated: Marked as deprecated in account.proto.
 func (x *GetAccountByAccountIdRequest) GetUserId() string {
 	if x != nil {
 		return x.UserId
@@ -490,19 +728,21 @@ func (x *GetAccountByAccountIdRequest) GetAccountId() string {
 	return ""
 }
 
-// user_id is the ID of the user associated with the JWT token validated at the API Gateway
+// user_id is deprecated and ignored: the user is taken from the access token forwarded in the request metadata.
 type DeleteAccountByAccountNumberRequest struct {
-	state          protoimpl.MessageState `protogen:"open.v1"`
-	UserId         string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	AccountNumber  int32                  `protobuf:"varint,2,opt,name=account_number,json=accountNumber,proto3" json:"account_number,omitempty"`
-	IdempotencyKey string                 `protobuf:"bytes,3,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Deprecated: Marked as deprecated in account.proto.
+	UserId         string `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	AccountNumber  int64  `protobuf:"varint,2,opt,name=account_number,json=accountNumber,proto3" json:"account_number,omitempty"`
+	IdempotencyKey string `protobuf:"bytes,3,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	ClosureReason  string `protobuf:"bytes,4,opt,name=closure_reason,json=closureReason,proto3" json:"closure_reason,omitempty"` // optional
 	unknownFields  protoimpl.UnknownFields
 	sizeCache      protoimpl.SizeCache
 }
 
 func (x *DeleteAccountByAccountNumberRequest) Reset() {
 	*x = DeleteAccountByAccountNumberRequest{}
-	mi := &file_account_proto_msgTypes[8]
+	mi := &file_account_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -514,7 +754,7 @@ func (x *DeleteAccountByAccountNumberRequest) String() string {
 func (*DeleteAccountByAccountNumberRequest) ProtoMessage() {}
 
 func (x *DeleteAccountByAccountNumberRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_account_proto_msgTypes[8]
+	mi := &file_account_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -527,9 +767,10 @@ func (x *DeleteAccountByAccountNumberRequest) ProtoReflect() protoreflect.Messag
 
 // Deprecated: Use DeleteAccountByAccountNumberRequest.ProtoReflect.Descriptor instead.
 func (*DeleteAccountByAccountNumberRequest) Descriptor() ([]byte, []int) {
-	return file_account_proto_rawDescGZIP(), []int{8}
+	return file_account_proto_rawDescGZIP(), []int{9}
 }
 
+// Deprecated: Marked as deprecated in account.proto.
 func (x *DeleteAccountByAccountNumberRequest) GetUserId() string {
 	if x != nil {
 		return x.UserId
@@ -537,7 +778,7 @@ func (x *DeleteAccountByAccountNumberRequest) GetUserId() string {
 	return ""
 }
 
-func (x *DeleteAccountByAccountNumberRequest) GetAccountNumber() int32 {
+func (x *DeleteAccountByAccountNumberRequest) GetAccountNumber() int64 {
 	if x != nil {
 		return x.AccountNumber
 	}
@@ -551,6 +792,13 @@ func (x *DeleteAccountByAccountNumberRequest) GetIdempotencyKey() string {
 	return ""
 }
 
+func (x *DeleteAccountByAccountNumberRequest) GetClosureReason() string {
+	if x != nil {
+		return x.ClosureReason
+	}
+	return ""
+}
+
 type DeleteAccountByAccountNumberResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	unknownFields protoimpl.UnknownFields
@@ -559,7 +807,7 @@ type DeleteAccountByAccountNumberResponse struct {
 
 func (x *DeleteAccountByAccountNumberResponse) Reset() {
 	*x = DeleteAccountByAccountNumberResponse{}
-	mi := &file_account_proto_msgTypes[9]
+	mi := &file_account_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -571,7 +819,7 @@ func (x *DeleteAccountByAccountNumberResponse) String() string {
 func (*DeleteAccountByAccountNumberResponse) ProtoMessage() {}
 
 func (x *DeleteAccountByAccountNumberResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_account_proto_msgTypes[9]
+	mi := &file_account_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -584,38 +832,882 @@ func (x *DeleteAccountByAccountNumberResponse) ProtoReflect() protoreflect.Messa
 
 // Deprecated: Use DeleteAccountByAccountNumberResponse.ProtoReflect.Descriptor instead.
 func (*DeleteAccountByAccountNumberResponse) Descriptor() ([]byte, []int) {
-	return file_account_proto_rawDescGZIP(), []int{9}
+	return file_account_proto_rawDescGZIP(), []int{10}
+}
+
+type UpdateAccountStatusRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	AccountId      string                 `protobuf:"bytes,1,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	Status         string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	IdempotencyKey string                 `protobuf:"bytes,3,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *UpdateAccountStatusRequest) Reset() {
+	*x = UpdateAccountStatusRequest{}
+	mi := &file_account_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateAccountStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateAccountStatusRequest) ProtoMessage() {}
+
+func (x *UpdateAccountStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_account_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateAccountStatusRequest.ProtoReflect.Descriptor instead.
+func (*UpdateAccountStatusRequest) Descriptor() ([]byte, []int) {
+	return file_account_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *UpdateAccountStatusRequest) GetAccountId() string {
+	if x != nil {
+		return x.AccountId
+	}
+	return ""
+}
+
+func (x *UpdateAccountStatusRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *UpdateAccountStatusRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+// user_id is deprecated and ignored: the user is taken from the access token forwarded in the request metadata.
+// amount is always positive, the transaction_type decides whether it is added to or subtracted from the balance.
+// Debits must follow the rules of the product of the account, or fail with ACCOUNT_LOCKED before a term deposit
+// matures, MINIMUM_BALANCE_REQUIRED or WITHDRAWAL_LIMIT_EXCEEDED. Debits of FROZEN or DORMANT accounts fail with
+// ACCOUNT_FROZEN or ACCOUNT_DORMANT, and any transaction of a CLOSED account fails with ACCOUNT_CLOSED.
+// amount must be in the currency of the account, or the request fails with CURRENCY_MISMATCH.
+type CreateTransactionRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Deprecated: Marked as deprecated in account.proto.
+	UserId          string `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	AccountId       string `protobuf:"bytes,2,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	TransactionType string `protobuf:"bytes,4,opt,name=transaction_type,json=transactionType,proto3" json:"transaction_type,omitempty"`
+	// for transfer transactions, this is the id of the other transaction
+	TransferId string `protobuf:"bytes,5,opt,name=transfer_id,json=transferId,proto3" json:"transfer_id,omitempty"`
+	// ignored, new transactions are always "COMPLETED"
+	Status         string `protobuf:"bytes,6,opt,name=status,proto3" json:"status,omitempty"`
+	IdempotencyKey string `protobuf:"bytes,7,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	Amount         *Money `protobuf:"bytes,8,opt,name=amount,proto3" json:"amount,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *CreateTransactionRequest) Reset() {
+	*x = CreateTransactionRequest{}
+	mi := &file_account_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateTransactionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateTransactionRequest) ProtoMessage() {}
+
+func (x *CreateTransactionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_account_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateTransactionRequest.ProtoReflect.Descriptor instead.
+func (*CreateTransactionRequest) Descriptor() ([]byte, []int) {
+	return file_account_proto_rawDescGZIP(), []int{12}
+}
+
+// Deprecated: Marked as deprecated in account.proto.
+func (x *CreateTransactionRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *CreateTransactionRequest) GetAccountId() string {
+	if x != nil {
+		return x.AccountId
+	}
+	return ""
+}
+
+func (x *CreateTransactionRequest) GetTransactionType() string {
+	if x != nil {
+		return x.TransactionType
+	}
+	return ""
+}
+
+func (x *CreateTransactionRequest) GetTransferId() string {
+	if x != nil {
+		return x.TransferId
+	}
+	return ""
+}
+
+func (x *CreateTransactionRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *CreateTransactionRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+func (x *CreateTransactionRequest) GetAmount() *Money {
+	if x != nil {
+		return x.Amount
+	}
+	return nil
+}
+
+type CreateTransactionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TransactionId string                 `protobuf:"bytes,1,opt,name=transaction_id,json=transactionId,proto3" json:"transaction_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateTransactionResponse) Reset() {
+	*x = CreateTransactionResponse{}
+	mi := &file_account_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateTransactionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateTransactionResponse) ProtoMessage() {}
+
+func (x *CreateTransactionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_account_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateTransactionResponse.ProtoReflect.Descriptor instead.
+func (*CreateTransactionResponse) Descriptor() ([]byte, []int) {
+	return file_account_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *CreateTransactionResponse) GetTransactionId() string {
+	if x != nil {
+		return x.TransactionId
+	}
+	return ""
+}
+
+// amount must be in the currency of the account, or the request fails with CURRENCY_MISMATCH.
+// The hold expires after expires_in_seconds, or after the default TTL of the account service if it is 0.
+type PlaceHoldRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	AccountId       string                 `protobuf:"bytes,1,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	Amount          *Money                 `protobuf:"bytes,2,opt,name=amount,proto3" json:"amount,omitempty"`
+	TransactionType string                 `protobuf:"bytes,3,opt,name=transaction_type,json=transactionType,proto3" json:"transaction_type,omitempty"`
+	// for the holds of transfers, set on the transaction capturing the hold
+	TransferId       string `protobuf:"bytes,4,opt,name=transfer_id,json=transferId,proto3" json:"transfer_id,omitempty"`
+	IdempotencyKey   string `protobuf:"bytes,5,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	ExpiresInSeconds int64  `protobuf:"varint,6,opt,name=expires_in_seconds,json=expiresInSeconds,proto3" json:"expires_in_seconds,omitempty"` // at most 30 days
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *PlaceHoldRequest) Reset() {
+	*x = PlaceHoldRequest{}
+	mi := &file_account_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlaceHoldRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlaceHoldRequest) ProtoMessage() {}
+
+func (x *PlaceHoldRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_account_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlaceHoldRequest.ProtoReflect.Descriptor instead.
+func (*PlaceHoldRequest) Descriptor() ([]byte, []int) {
+	return file_account_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *PlaceHoldRequest) GetAccountId() string {
+	if x != nil {
+		return x.AccountId
+	}
+	return ""
+}
+
+func (x *PlaceHoldRequest) GetAmount() *Money {
+	if x != nil {
+		return x.Amount
+	}
+	return nil
+}
+
+func (x *PlaceHoldRequest) GetTransactionType() string {
+	if x != nil {
+		return x.TransactionType
+	}
+	return ""
+}
+
+func (x *PlaceHoldRequest) GetTransferId() string {
+	if x != nil {
+		return x.TransferId
+	}
+	return ""
+}
+
+func (x *PlaceHoldRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+func (x *PlaceHoldRequest) GetExpiresInSeconds() int64 {
+	if x != nil {
+		return x.ExpiresInSeconds
+	}
+	return 0
+}
+
+// amount is in the currency of the account. The whole hold is captured if it isn't set.
+type CaptureHoldRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	HoldId         string                 `protobuf:"bytes,1,opt,name=hold_id,json=holdId,proto3" json:"hold_id,omitempty"`
+	Amount         *Money                 `protobuf:"bytes,2,opt,name=amount,proto3" json:"amount,omitempty"`
+	IdempotencyKey string                 `protobuf:"bytes,3,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *CaptureHoldRequest) Reset() {
+	*x = CaptureHoldRequest{}
+	mi := &file_account_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CaptureHoldRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CaptureHoldRequest) ProtoMessage() {}
+
+func (x *CaptureHoldRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_account_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CaptureHoldRequest.ProtoReflect.Descriptor instead.
+func (*CaptureHoldRequest) Descriptor() ([]byte, []int) {
+	return file_account_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *CaptureHoldRequest) GetHoldId() string {
+	if x != nil {
+		return x.HoldId
+	}
+	return ""
+}
+
+func (x *CaptureHoldRequest) GetAmount() *Money {
+	if x != nil {
+		return x.Amount
+	}
+	return nil
+}
+
+func (x *CaptureHoldRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+type VoidHoldRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	HoldId         string                 `protobuf:"bytes,1,opt,name=hold_id,json=holdId,proto3" json:"hold_id,omitempty"`
+	IdempotencyKey string                 `protobuf:"bytes,2,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *VoidHoldRequest) Reset() {
+	*x = VoidHoldRequest{}
+	mi := &file_account_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VoidHoldRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VoidHoldRequest) ProtoMessage() {}
+
+func (x *VoidHoldRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_account_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VoidHoldRequest.ProtoReflect.Descriptor instead.
+func (*VoidHoldRequest) Descriptor() ([]byte, []int) {
+	return file_account_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *VoidHoldRequest) GetHoldId() string {
+	if x != nil {
+		return x.HoldId
+	}
+	return ""
+}
+
+func (x *VoidHoldRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+// reason is recorded with the reversal, for the audit of the support staff.
+type ReverseFeeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TransactionId string                 `protobuf:"bytes,1,opt,name=transaction_id,json=transactionId,proto3" json:"transaction_id,omitempty"`
+	Reason        string                 `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReverseFeeRequest) Reset() {
+	*x = ReverseFeeRequest{}
+	mi := &file_account_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReverseFeeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReverseFeeRequest) ProtoMessage() {}
+
+func (x *ReverseFeeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_account_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReverseFeeRequest.ProtoReflect.Descriptor instead.
+func (*ReverseFeeRequest) Descriptor() ([]byte, []int) {
+	return file_account_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ReverseFeeRequest) GetTransactionId() string {
+	if x != nil {
+		return x.TransactionId
+	}
+	return ""
+}
+
+func (x *ReverseFeeRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+// amount is in the currency of the account. What remains to reverse of the transaction is reversed if it isn't set.
+// reason is recorded with the reversal.
+type ReverseTransactionRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	TransactionId  string                 `protobuf:"bytes,1,opt,name=transaction_id,json=transactionId,proto3" json:"transaction_id,omitempty"`
+	Amount         *Money                 `protobuf:"bytes,2,opt,name=amount,proto3" json:"amount,omitempty"`
+	Reason         string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	IdempotencyKey string                 `protobuf:"bytes,4,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ReverseTransactionRequest) Reset() {
+	*x = ReverseTransactionRequest{}
+	mi := &file_account_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReverseTransactionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReverseTransactionRequest) ProtoMessage() {}
+
+func (x *ReverseTransactionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_account_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReverseTransactionRequest.ProtoReflect.Descriptor instead.
+func (*ReverseTransactionRequest) Descriptor() ([]byte, []int) {
+	return file_account_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *ReverseTransactionRequest) GetTransactionId() string {
+	if x != nil {
+		return x.TransactionId
+	}
+	return ""
+}
+
+func (x *ReverseTransactionRequest) GetAmount() *Money {
+	if x != nil {
+		return x.Amount
+	}
+	return nil
+}
+
+func (x *ReverseTransactionRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *ReverseTransactionRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+type GetAccountLimitsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AccountId     string                 `protobuf:"bytes,1,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAccountLimitsRequest) Reset() {
+	*x = GetAccountLimitsRequest{}
+	mi := &file_account_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAccountLimitsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAccountLimitsRequest) ProtoMessage() {}
+
+func (x *GetAccountLimitsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_account_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAccountLimitsRequest.ProtoReflect.Descriptor instead.
+func (*GetAccountLimitsRequest) Descriptor() ([]byte, []int) {
+	return file_account_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *GetAccountLimitsRequest) GetAccountId() string {
+	if x != nil {
+		return x.AccountId
+	}
+	return ""
+}
+
+// The limits in limits that are unset go back to the ceiling of the account, or to the ones of the product for the
+// support staff. Amounts are in the currency of the account.
+type UpdateAccountLimitsRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	AccountId      string                 `protobuf:"bytes,1,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	Limits         *Limits                `protobuf:"bytes,2,opt,name=limits,proto3" json:"limits,omitempty"`
+	IdempotencyKey string                 `protobuf:"bytes,3,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *UpdateAccountLimitsRequest) Reset() {
+	*x = UpdateAccountLimitsRequest{}
+	mi := &file_account_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateAccountLimitsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateAccountLimitsRequest) ProtoMessage() {}
+
+func (x *UpdateAccountLimitsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_account_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateAccountLimitsRequest.ProtoReflect.Descriptor instead.
+func (*UpdateAccountLimitsRequest) Descriptor() ([]byte, []int) {
+	return file_account_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *UpdateAccountLimitsRequest) GetAccountId() string {
+	if x != nil {
+		return x.AccountId
+	}
+	return ""
+}
+
+func (x *UpdateAccountLimitsRequest) GetLimits() *Limits {
+	if x != nil {
+		return x.Limits
+	}
+	return nil
+}
+
+func (x *UpdateAccountLimitsRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+// Limits of the debits of an account, unset if there is none. Days and months are calendar days and months (UTC).
+type Limits struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	MaxSingleDebit    *Money                 `protobuf:"bytes,1,opt,name=max_single_debit,json=maxSingleDebit,proto3" json:"max_single_debit,omitempty"`
+	DailyDebitTotal   *Money                 `protobuf:"bytes,2,opt,name=daily_debit_total,json=dailyDebitTotal,proto3" json:"daily_debit_total,omitempty"`
+	DailyDebitCount   int32                  `protobuf:"varint,3,opt,name=daily_debit_count,json=dailyDebitCount,proto3" json:"daily_debit_count,omitempty"` // 0 if there is no limit
+	MonthlyDebitTotal *Money                 `protobuf:"bytes,4,opt,name=monthly_debit_total,json=monthlyDebitTotal,proto3" json:"monthly_debit_total,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *Limits) Reset() {
+	*x = Limits{}
+	mi := &file_account_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Limits) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Limits) ProtoMessage() {}
+
+func (x *Limits) ProtoReflect() protoreflect.Message {
+	mi := &file_account_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Limits.ProtoReflect.Descriptor instead.
+func (*Limits) Descriptor() ([]byte, []int) {
+	return file_account_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *Limits) GetMaxSingleDebit() *Money {
+	if x != nil {
+		return x.MaxSingleDebit
+	}
+	return nil
+}
+
+func (x *Limits) GetDailyDebitTotal() *Money {
+	if x != nil {
+		return x.DailyDebitTotal
+	}
+	return nil
+}
+
+func (x *Limits) GetDailyDebitCount() int32 {
+	if x != nil {
+		return x.DailyDebitCount
+	}
+	return 0
+}
+
+func (x *Limits) GetMonthlyDebitTotal() *Money {
+	if x != nil {
+		return x.MonthlyDebitTotal
+	}
+	return nil
+}
+
+// LimitUsage is what the debits of the day and of the month add up to, the active holds included.
+type LimitUsage struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	DailyDebitTotal   *Money                 `protobuf:"bytes,1,opt,name=daily_debit_total,json=dailyDebitTotal,proto3" json:"daily_debit_total,omitempty"`
+	DailyDebitCount   int64                  `protobuf:"varint,2,opt,name=daily_debit_count,json=dailyDebitCount,proto3" json:"daily_debit_count,omitempty"`
+	MonthlyDebitTotal *Money                 `protobuf:"bytes,3,opt,name=monthly_debit_total,json=monthlyDebitTotal,proto3" json:"monthly_debit_total,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *LimitUsage) Reset() {
+	*x = LimitUsage{}
+	mi := &file_account_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LimitUsage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LimitUsage) ProtoMessage() {}
+
+func (x *LimitUsage) ProtoReflect() protoreflect.Message {
+	mi := &file_account_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LimitUsage.ProtoReflect.Descriptor instead.
+func (*LimitUsage) Descriptor() ([]byte, []int) {
+	return file_account_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *LimitUsage) GetDailyDebitTotal() *Money {
+	if x != nil {
+		return x.DailyDebitTotal
+	}
+	return nil
+}
+
+func (x *LimitUsage) GetDailyDebitCount() int64 {
+	if x != nil {
+		return x.DailyDebitCount
+	}
+	return 0
+}
+
+func (x *LimitUsage) GetMonthlyDebitTotal() *Money {
+	if x != nil {
+		return x.MonthlyDebitTotal
+	}
+	return nil
+}
+
+type AccountLimits struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AccountId     string                 `protobuf:"bytes,1,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	Effective     *Limits                `protobuf:"bytes,2,opt,name=effective,proto3" json:"effective,omitempty"` // the ceiling, lowered by the user
+	User          *Limits                `protobuf:"bytes,3,opt,name=user,proto3" json:"user,omitempty"`           // the limits set by the user
+	Used          *LimitUsage            `protobuf:"bytes,4,opt,name=used,proto3" json:"used,omitempty"`
+	Remaining     *LimitUsage            `protobuf:"bytes,5,opt,name=remaining,proto3" json:"remaining,omitempty"` // unset where there is no limit
+	Ceiling       *Limits                `protobuf:"bytes,6,opt,name=ceiling,proto3" json:"ceiling,omitempty"`     // the limits of the product, as overridden by the support staff
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AccountLimits) Reset() {
+	*x = AccountLimits{}
+	mi := &file_account_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AccountLimits) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AccountLimits) ProtoMessage() {}
+
+func (x *AccountLimits) ProtoReflect() protoreflect.Message {
+	mi := &file_account_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AccountLimits.ProtoReflect.Descriptor instead.
+func (*AccountLimits) Descriptor() ([]byte, []int) {
+	return file_account_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *AccountLimits) GetAccountId() string {
+	if x != nil {
+		return x.AccountId
+	}
+	return ""
 }
 
-// user_id is the ID of the user associated with the JWT token validated at the API Gateway
-type CreateTransactionRequest struct {
-	state           protoimpl.MessageState `protogen:"open.v1"`
-	UserId          string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	AccountId       string                 `protobuf:"bytes,2,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
-	Amount          int64                  `protobuf:"varint,3,opt,name=amount,proto3" json:"amount,omitempty"`
-	TransactionType string                 `protobuf:"bytes,4,opt,name=transaction_type,json=transactionType,proto3" json:"transaction_type,omitempty"` // "CREDIT" or "DEBIT" or "TRANSFER_CREDIT" or "TRANSFER_DEBIT"
-	TransferId      string                 `protobuf:"bytes,5,opt,name=transfer_id,json=transferId,proto3" json:"transfer_id,omitempty"`                // for transfer transactions, this is the id of the other transaction
-	Status          string                 `protobuf:"bytes,6,opt,name=status,proto3" json:"status,omitempty"`                                          // "PENDING", "COMPLETED", "FAILED"
-	IdempotencyKey  string                 `protobuf:"bytes,7,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
+func (x *AccountLimits) GetEffective() *Limits {
+	if x != nil {
+		return x.Effective
+	}
+	return nil
 }
 
-func (x *CreateTransactionRequest) Reset() {
-	*x = CreateTransactionRequest{}
-	mi := &file_account_proto_msgTypes[10]
+func (x *AccountLimits) GetUser() *Limits {
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+func (x *AccountLimits) GetUsed() *LimitUsage {
+	if x != nil {
+		return x.Used
+	}
+	return nil
+}
+
+func (x *AccountLimits) GetRemaining() *LimitUsage {
+	if x != nil {
+		return x.Remaining
+	}
+	return nil
+}
+
+func (x *AccountLimits) GetCeiling() *Limits {
+	if x != nil {
+		return x.Ceiling
+	}
+	return nil
+}
+
+// user_id is only for the support staff, the user is otherwise taken from the access token.
+type GetUserLimitsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Currency      string                 `protobuf:"bytes,1,opt,name=currency,proto3" json:"currency,omitempty"` // ISO 4217 code
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUserLimitsRequest) Reset() {
+	*x = GetUserLimitsRequest{}
+	mi := &file_account_proto_msgTypes[24]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CreateTransactionRequest) String() string {
+func (x *GetUserLimitsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CreateTransactionRequest) ProtoMessage() {}
+func (*GetUserLimitsRequest) ProtoMessage() {}
 
-func (x *CreateTransactionRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_account_proto_msgTypes[10]
+func (x *GetUserLimitsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_account_proto_msgTypes[24]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -626,82 +1718,121 @@ func (x *CreateTransactionRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CreateTransactionRequest.ProtoReflect.Descriptor instead.
-func (*CreateTransactionRequest) Descriptor() ([]byte, []int) {
-	return file_account_proto_rawDescGZIP(), []int{10}
+// Deprecated: Use GetUserLimitsRequest.ProtoReflect.Descriptor instead.
+func (*GetUserLimitsRequest) Descriptor() ([]byte, []int) {
+	return file_account_proto_rawDescGZIP(), []int{24}
 }
 
-func (x *CreateTransactionRequest) GetUserId() string {
+func (x *GetUserLimitsRequest) GetCurrency() string {
 	if x != nil {
-		return x.UserId
+		return x.Currency
 	}
 	return ""
 }
 
-func (x *CreateTransactionRequest) GetAccountId() string {
+func (x *GetUserLimitsRequest) GetUserId() string {
 	if x != nil {
-		return x.AccountId
+		return x.UserId
 	}
 	return ""
 }
 
-func (x *CreateTransactionRequest) GetAmount() int64 {
+// The limits in limits that are unset are removed. Amounts are in currency.
+// user_id is only for the support staff, the user is otherwise taken from the access token.
+type UpdateUserLimitsRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Currency       string                 `protobuf:"bytes,1,opt,name=currency,proto3" json:"currency,omitempty"` // ISO 4217 code
+	Limits         *Limits                `protobuf:"bytes,2,opt,name=limits,proto3" json:"limits,omitempty"`
+	UserId         string                 `protobuf:"bytes,3,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	IdempotencyKey string                 `protobuf:"bytes,4,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *UpdateUserLimitsRequest) Reset() {
+	*x = UpdateUserLimitsRequest{}
+	mi := &file_account_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateUserLimitsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateUserLimitsRequest) ProtoMessage() {}
+
+func (x *UpdateUserLimitsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_account_proto_msgTypes[25]
 	if x != nil {
-		return x.Amount
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return 0
+	return mi.MessageOf(x)
 }
 
-func (x *CreateTransactionRequest) GetTransactionType() string {
+// Deprecated: Use UpdateUserLimitsRequest.ProtoReflect.Descriptor instead.
+func (*UpdateUserLimitsRequest) Descriptor() ([]byte, []int) {
+	return file_account_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *UpdateUserLimitsRequest) GetCurrency() string {
 	if x != nil {
-		return x.TransactionType
+		return x.Currency
 	}
 	return ""
 }
 
-func (x *CreateTransactionRequest) GetTransferId() string {
+func (x *UpdateUserLimitsRequest) GetLimits() *Limits {
 	if x != nil {
-		return x.TransferId
+		return x.Limits
 	}
-	return ""
+	return nil
 }
 
-func (x *CreateTransactionRequest) GetStatus() string {
+func (x *UpdateUserLimitsRequest) GetUserId() string {
 	if x != nil {
-		return x.Status
+		return x.UserId
 	}
 	return ""
 }
 
-func (x *CreateTransactionRequest) GetIdempotencyKey() string {
+func (x *UpdateUserLimitsRequest) GetIdempotencyKey() string {
 	if x != nil {
 		return x.IdempotencyKey
 	}
 	return ""
 }
 
-type CreateTransactionResponse struct {
+type UserLimits struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	TransactionId string                 `protobuf:"bytes,1,opt,name=transaction_id,json=transactionId,proto3" json:"transaction_id,omitempty"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Currency      string                 `protobuf:"bytes,2,opt,name=currency,proto3" json:"currency,omitempty"`
+	Limits        *Limits                `protobuf:"bytes,3,opt,name=limits,proto3" json:"limits,omitempty"` // unset where there is no limit
+	Used          *LimitUsage            `protobuf:"bytes,4,opt,name=used,proto3" json:"used,omitempty"`
+	Remaining     *LimitUsage            `protobuf:"bytes,5,opt,name=remaining,proto3" json:"remaining,omitempty"` // unset where there is no limit
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *CreateTransactionResponse) Reset() {
-	*x = CreateTransactionResponse{}
-	mi := &file_account_proto_msgTypes[11]
+func (x *UserLimits) Reset() {
+	*x = UserLimits{}
+	mi := &file_account_proto_msgTypes[26]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CreateTransactionResponse) String() string {
+func (x *UserLimits) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CreateTransactionResponse) ProtoMessage() {}
+func (*UserLimits) ProtoMessage() {}
 
-func (x *CreateTransactionResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_account_proto_msgTypes[11]
+func (x *UserLimits) ProtoReflect() protoreflect.Message {
+	mi := &file_account_proto_msgTypes[26]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -712,30 +1843,59 @@ func (x *CreateTransactionResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CreateTransactionResponse.ProtoReflect.Descriptor instead.
-func (*CreateTransactionResponse) Descriptor() ([]byte, []int) {
-	return file_account_proto_rawDescGZIP(), []int{11}
+// Deprecated: Use UserLimits.ProtoReflect.Descriptor instead.
+func (*UserLimits) Descriptor() ([]byte, []int) {
+	return file_account_proto_rawDescGZIP(), []int{26}
 }
 
-func (x *CreateTransactionResponse) GetTransactionId() string {
+func (x *UserLimits) GetUserId() string {
 	if x != nil {
-		return x.TransactionId
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *UserLimits) GetCurrency() string {
+	if x != nil {
+		return x.Currency
 	}
 	return ""
 }
 
-// user_id is the ID of the user associated with the JWT token validated at the API Gateway
+func (x *UserLimits) GetLimits() *Limits {
+	if x != nil {
+		return x.Limits
+	}
+	return nil
+}
+
+func (x *UserLimits) GetUsed() *LimitUsage {
+	if x != nil {
+		return x.Used
+	}
+	return nil
+}
+
+func (x *UserLimits) GetRemaining() *LimitUsage {
+	if x != nil {
+		return x.Remaining
+	}
+	return nil
+}
+
+// user_id is deprecated and ignored: the user is taken from the access token forwarded in the request metadata.
 type GetTransactionsByAccountIdRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	AccountId     string                 `protobuf:"bytes,2,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Deprecated: Marked as deprecated in account.proto.
+	UserId        string `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	AccountId     string `protobuf:"bytes,2,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *GetTransactionsByAccountIdRequest) Reset() {
 	*x = GetTransactionsByAccountIdRequest{}
-	mi := &file_account_proto_msgTypes[12]
+	mi := &file_account_proto_msgTypes[27]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -747,7 +1907,7 @@ func (x *GetTransactionsByAccountIdRequest) String() string {
 func (*GetTransactionsByAccountIdRequest) ProtoMessage() {}
 
 func (x *GetTransactionsByAccountIdRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_account_proto_msgTypes[12]
+	mi := &file_account_proto_msgTypes[27]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -760,9 +1920,10 @@ func (x *GetTransactionsByAccountIdRequest) ProtoReflect() protoreflect.Message
 
 // Deprecated: Use GetTransactionsByAccountIdRequest.ProtoReflect.Descriptor instead.
 func (*GetTransactionsByAccountIdRequest) Descriptor() ([]byte, []int) {
-	return file_account_proto_rawDescGZIP(), []int{12}
+	return file_account_proto_rawDescGZIP(), []int{27}
 }
 
+// Deprecated: Marked as deprecated in account.proto.
 func (x *GetTransactionsByAccountIdRequest) GetUserId() string {
 	if x != nil {
 		return x.UserId
@@ -786,7 +1947,7 @@ type GetTransactionsByAccountIdResponse struct {
 
 func (x *GetTransactionsByAccountIdResponse) Reset() {
 	*x = GetTransactionsByAccountIdResponse{}
-	mi := &file_account_proto_msgTypes[13]
+	mi := &file_account_proto_msgTypes[28]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -798,7 +1959,7 @@ func (x *GetTransactionsByAccountIdResponse) String() string {
 func (*GetTransactionsByAccountIdResponse) ProtoMessage() {}
 
 func (x *GetTransactionsByAccountIdResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_account_proto_msgTypes[13]
+	mi := &file_account_proto_msgTypes[28]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -811,7 +1972,7 @@ func (x *GetTransactionsByAccountIdResponse) ProtoReflect() protoreflect.Message
 
 // Deprecated: Use GetTransactionsByAccountIdResponse.ProtoReflect.Descriptor instead.
 func (*GetTransactionsByAccountIdResponse) Descriptor() ([]byte, []int) {
-	return file_account_proto_rawDescGZIP(), []int{13}
+	return file_account_proto_rawDescGZIP(), []int{28}
 }
 
 func (x *GetTransactionsByAccountIdResponse) GetTransactions() []*Transaction {
@@ -821,18 +1982,19 @@ func (x *GetTransactionsByAccountIdResponse) GetTransactions() []*Transaction {
 	return nil
 }
 
-// user_id is the ID of the user associated with the JWT token validated at the API Gateway
+// user_id is deprecated and ignored: the user is taken from the access token forwarded in the request metadata.
 type ValidateAccountNumberRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	AccountNumber int32                  `protobuf:"varint,2,opt,name=account_number,json=accountNumber,proto3" json:"account_number,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Deprecated: Marked as deprecated in account.proto.
+	UserId        string `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	AccountNumber int64  `protobuf:"varint,2,opt,name=account_number,json=accountNumber,proto3" json:"account_number,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ValidateAccountNumberRequest) Reset() {
 	*x = ValidateAccountNumberRequest{}
-	mi := &file_account_proto_msgTypes[14]
+	mi := &file_account_proto_msgTypes[29]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -844,7 +2006,7 @@ func (x *ValidateAccountNumberRequest) String() string {
 func (*ValidateAccountNumberRequest) ProtoMessage() {}
 
 func (x *ValidateAccountNumberRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_account_proto_msgTypes[14]
+	mi := &file_account_proto_msgTypes[29]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -857,9 +2019,10 @@ func (x *ValidateAccountNumberRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ValidateAccountNumberRequest.ProtoReflect.Descriptor instead.
 func (*ValidateAccountNumberRequest) Descriptor() ([]byte, []int) {
-	return file_account_proto_rawDescGZIP(), []int{14}
+	return file_account_proto_rawDescGZIP(), []int{29}
 }
 
+// Deprecated: Marked as deprecated in account.proto.
 func (x *ValidateAccountNumberRequest) GetUserId() string {
 	if x != nil {
 		return x.UserId
@@ -867,7 +2030,7 @@ func (x *ValidateAccountNumberRequest) GetUserId() string {
 	return ""
 }
 
-func (x *ValidateAccountNumberRequest) GetAccountNumber() int32 {
+func (x *ValidateAccountNumberRequest) GetAccountNumber() int64 {
 	if x != nil {
 		return x.AccountNumber
 	}
@@ -883,7 +2046,7 @@ type ValidateAccountNumberResponse struct {
 
 func (x *ValidateAccountNumberResponse) Reset() {
 	*x = ValidateAccountNumberResponse{}
-	mi := &file_account_proto_msgTypes[15]
+	mi := &file_account_proto_msgTypes[30]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -895,7 +2058,7 @@ func (x *ValidateAccountNumberResponse) String() string {
 func (*ValidateAccountNumberResponse) ProtoMessage() {}
 
 func (x *ValidateAccountNumberResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_account_proto_msgTypes[15]
+	mi := &file_account_proto_msgTypes[30]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -908,7 +2071,7 @@ func (x *ValidateAccountNumberResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ValidateAccountNumberResponse.ProtoReflect.Descriptor instead.
 func (*ValidateAccountNumberResponse) Descriptor() ([]byte, []int) {
-	return file_account_proto_rawDescGZIP(), []int{15}
+	return file_account_proto_rawDescGZIP(), []int{30}
 }
 
 func (x *ValidateAccountNumberResponse) GetValid() bool {
@@ -918,19 +2081,21 @@ func (x *ValidateAccountNumberResponse) GetValid() bool {
 	return false
 }
 
-// user_id is the ID of the user associated with the JWT token validated at the API Gateway
+// user_id is deprecated and ignored: the user is taken from the access token forwarded in the request metadata.
+// amount must be in the currency of the account, or the request fails with CURRENCY_MISMATCH.
 type HasSufficientBalanceRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	AccountNumber int32                  `protobuf:"varint,2,opt,name=account_number,json=accountNumber,proto3" json:"account_number,omitempty"`
-	Amount        int64                  `protobuf:"varint,3,opt,name=amount,proto3" json:"amount,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Deprecated: Marked as deprecated in account.proto.
+	UserId        string `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	AccountNumber int64  `protobuf:"varint,2,opt,name=account_number,json=accountNumber,proto3" json:"account_number,omitempty"`
+	Amount        *Money `protobuf:"bytes,4,opt,name=amount,proto3" json:"amount,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *HasSufficientBalanceRequest) Reset() {
 	*x = HasSufficientBalanceRequest{}
-	mi := &file_account_proto_msgTypes[16]
+	mi := &file_account_proto_msgTypes[31]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -942,7 +2107,7 @@ func (x *HasSufficientBalanceRequest) String() string {
 func (*HasSufficientBalanceRequest) ProtoMessage() {}
 
 func (x *HasSufficientBalanceRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_account_proto_msgTypes[16]
+	mi := &file_account_proto_msgTypes[31]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -955,9 +2120,10 @@ func (x *HasSufficientBalanceRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use HasSufficientBalanceRequest.ProtoReflect.Descriptor instead.
 func (*HasSufficientBalanceRequest) Descriptor() ([]byte, []int) {
-	return file_account_proto_rawDescGZIP(), []int{16}
+	return file_account_proto_rawDescGZIP(), []int{31}
 }
 
+// Deprecated: Marked as deprecated in account.proto.
 func (x *HasSufficientBalanceRequest) GetUserId() string {
 	if x != nil {
 		return x.UserId
@@ -965,18 +2131,18 @@ func (x *HasSufficientBalanceRequest) GetUserId() string {
 	return ""
 }
 
-func (x *HasSufficientBalanceRequest) GetAccountNumber() int32 {
+func (x *HasSufficientBalanceRequest) GetAccountNumber() int64 {
 	if x != nil {
 		return x.AccountNumber
 	}
 	return 0
 }
 
-func (x *HasSufficientBalanceRequest) GetAmount() int64 {
+func (x *HasSufficientBalanceRequest) GetAmount() *Money {
 	if x != nil {
 		return x.Amount
 	}
-	return 0
+	return nil
 }
 
 type HasSufficientBalanceResponse struct {
@@ -988,7 +2154,7 @@ type HasSufficientBalanceResponse struct {
 
 func (x *HasSufficientBalanceResponse) Reset() {
 	*x = HasSufficientBalanceResponse{}
-	mi := &file_account_proto_msgTypes[17]
+	mi := &file_account_proto_msgTypes[32]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1000,7 +2166,7 @@ func (x *HasSufficientBalanceResponse) String() string {
 func (*HasSufficientBalanceResponse) ProtoMessage() {}
 
 func (x *HasSufficientBalanceResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_account_proto_msgTypes[17]
+	mi := &file_account_proto_msgTypes[32]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1013,7 +2179,7 @@ func (x *HasSufficientBalanceResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use HasSufficientBalanceResponse.ProtoReflect.Descriptor instead.
 func (*HasSufficientBalanceResponse) Descriptor() ([]byte, []int) {
-	return file_account_proto_rawDescGZIP(), []int{17}
+	return file_account_proto_rawDescGZIP(), []int{32}
 }
 
 func (x *HasSufficientBalanceResponse) GetSufficient() bool {
@@ -1023,93 +2189,373 @@ func (x *HasSufficientBalanceResponse) GetSufficient() bool {
 	return false
 }
 
+type WatchAccountRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	AccountId string                 `protobuf:"bytes,1,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	// event_id of the last event the client received, to resume a broken stream without missing events.
+	// Empty to only receive the events committed from now on.
+	AfterEventId  string `protobuf:"bytes,2,opt,name=after_event_id,json=afterEventId,proto3" json:"after_event_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchAccountRequest) Reset() {
+	*x = WatchAccountRequest{}
+	mi := &file_account_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchAccountRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchAccountRequest) ProtoMessage() {}
+
+func (x *WatchAccountRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_account_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchAccountRequest.ProtoReflect.Descriptor instead.
+func (*WatchAccountRequest) Descriptor() ([]byte, []int) {
+	return file_account_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *WatchAccountRequest) GetAccountId() string {
+	if x != nil {
+		return x.AccountId
+	}
+	return ""
+}
+
+func (x *WatchAccountRequest) GetAfterEventId() string {
+	if x != nil {
+		return x.AfterEventId
+	}
+	return ""
+}
+
+// AccountEvent is one message of the WatchAccount stream.
+// The first message is a SNAPSHOT of the account, unless the stream resumes after an event that is still retained.
+// HEARTBEAT messages are sent while nothing happens on the account, so that idle connections aren't closed by proxies.
+type AccountEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EventId       string                 `protobuf:"bytes,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"` // empty for heartbeats. Events are ordered by their ID.
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`                      // "SNAPSHOT", "TRANSACTION" or "HEARTBEAT"
+	AccountId     string                 `protobuf:"bytes,3,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	Transaction   *Transaction           `protobuf:"bytes,5,opt,name=transaction,proto3" json:"transaction,omitempty"` // the committed transaction, for TRANSACTION events
+	Version       int64                  `protobuf:"varint,6,opt,name=version,proto3" json:"version,omitempty"`        // version of the account after the event, incremented by every transaction
+	Balance       *Money                 `protobuf:"bytes,7,opt,name=balance,proto3" json:"balance,omitempty"`         // balance of the account after the event, not set for heartbeats
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AccountEvent) Reset() {
+	*x = AccountEvent{}
+	mi := &file_account_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AccountEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AccountEvent) ProtoMessage() {}
+
+func (x *AccountEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_account_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AccountEvent.ProtoReflect.Descriptor instead.
+func (*AccountEvent) Descriptor() ([]byte, []int) {
+	return file_account_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *AccountEvent) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+func (x *AccountEvent) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *AccountEvent) GetAccountId() string {
+	if x != nil {
+		return x.AccountId
+	}
+	return ""
+}
+
+func (x *AccountEvent) GetTransaction() *Transaction {
+	if x != nil {
+		return x.Transaction
+	}
+	return nil
+}
+
+func (x *AccountEvent) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *AccountEvent) GetBalance() *Money {
+	if x != nil {
+		return x.Balance
+	}
+	return nil
+}
+
 var File_account_proto protoreflect.FileDescriptor
 
 const file_account_proto_rawDesc = "" +
 	"\n" +
-	"\raccount.proto\x12\x05proto\x1a\x1bbuf/validate/validate.proto\"\x96\x01\n" +
+	"\raccount.proto\x12\x05proto\x1a\x1bbuf/validate/validate.proto\x1a\x1cgoogle/api/annotations.proto\x1a\vmoney.proto\"\x87\x04\n" +
 	"\aAccount\x12'\n" +
 	"\n" +
 	"account_id\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\taccountId\x12%\n" +
-	"\x0eaccount_number\x18\x02 \x01(\x05R\raccountNumber\x12\x18\n" +
-	"\abalance\x18\x03 \x01(\x03R\abalance\x12!\n" +
-	"\auser_id\x18\x04 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\x06userId\"\x81\x02\n" +
+	"\x0eaccount_number\x18\x02 \x01(\x03R\raccountNumber\x12!\n" +
+	"\auser_id\x18\x04 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\x06userId\x12!\n" +
+	"\faccount_type\x18\x05 \x01(\tR\vaccountType\x12!\n" +
+	"\fproduct_code\x18\x06 \x01(\tR\vproductCode\x12\x1d\n" +
+	"\n" +
+	"matures_at\x18\a \x01(\x03R\tmaturesAt\x12\x16\n" +
+	"\x06status\x18\b \x01(\tR\x06status\x12*\n" +
+	"\x11status_changed_at\x18\t \x01(\x03R\x0fstatusChangedAt\x12%\n" +
+	"\x0eclosure_reason\x18\n" +
+	" \x01(\tR\rclosureReason\x12\x1b\n" +
+	"\tclosed_at\x18\v \x01(\x03R\bclosedAt\x12&\n" +
+	"\abalance\x18\f \x01(\v2\f.proto.MoneyR\abalance\x123\n" +
+	"\x0eledger_balance\x18\r \x01(\v2\f.proto.MoneyR\rledgerBalance\x129\n" +
+	"\x11available_balance\x18\x0e \x01(\v2\f.proto.MoneyR\x10availableBalanceJ\x04\b\x03\x10\x04\"\xfa\x02\n" +
 	"\vTransaction\x12/\n" +
 	"\x0etransaction_id\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\rtransactionId\x12'\n" +
 	"\n" +
-	"account_id\x18\x02 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\taccountId\x12\x16\n" +
-	"\x06amount\x18\x03 \x01(\x03R\x06amount\x12\x1c\n" +
+	"account_id\x18\x02 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\taccountId\x12\x1c\n" +
 	"\ttimestamp\x18\x04 \x01(\x03R\ttimestamp\x12)\n" +
 	"\x10transaction_type\x18\x05 \x01(\tR\x0ftransactionType\x12\x16\n" +
 	"\x06status\x18\x06 \x01(\tR\x06status\x12\x1f\n" +
 	"\vtransfer_id\x18\a \x01(\tR\n" +
-	"transferId\"\x86\x01\n" +
-	"\x14CreateAccountRequest\x12!\n" +
-	"\auser_id\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\x06userId\x12\x18\n" +
-	"\abalance\x18\x02 \x01(\x03R\abalance\x121\n" +
-	"\x0fidempotency_key\x18\x03 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\x0eidempotencyKey\"]\n" +
+	"transferId\x12$\n" +
+	"\x06amount\x18\b \x01(\v2\f.proto.MoneyR\x06amount\x12\x1b\n" +
+	"\tcaused_by\x18\t \x01(\tR\bcausedBy\x12\x19\n" +
+	"\bfee_rule\x18\n" +
+	" \x01(\tR\afeeRule\x12\x12\n" +
+	"\x04memo\x18\v \x01(\tR\x04memo\x12\x17\n" +
+	"\ahold_id\x18\f \x01(\tR\x06holdIdJ\x04\b\x03\x10\x04\"\xb0\x02\n" +
+	"\x04Hold\x12\x17\n" +
+	"\ahold_id\x18\x01 \x01(\tR\x06holdId\x12\x1d\n" +
+	"\n" +
+	"account_id\x18\x02 \x01(\tR\taccountId\x12$\n" +
+	"\x06amount\x18\x03 \x01(\v2\f.proto.MoneyR\x06amount\x12)\n" +
+	"\x10transaction_type\x18\x04 \x01(\tR\x0ftransactionType\x12\x1f\n" +
+	"\vtransfer_id\x18\x05 \x01(\tR\n" +
+	"transferId\x12\x16\n" +
+	"\x06status\x18\x06 \x01(\tR\x06status\x12(\n" +
+	"\bcaptured\x18\a \x01(\v2\f.proto.MoneyR\bcaptured\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\b \x01(\x03R\texpiresAt\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\t \x01(\x03R\tcreatedAt\"\xa4\x02\n" +
+	"\x14CreateAccountRequest\x12\x1b\n" +
+	"\auser_id\x18\x01 \x01(\tB\x02\x18\x01R\x06userId\x121\n" +
+	"\x0fidempotency_key\x18\x03 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\x0eidempotencyKey\x12>\n" +
+	"\fproduct_code\x18\x04 \x01(\tB\x1b\xbaH\x18\xd8\x01\x01r\x132\x11^[A-Z0-9_]{1,32}$R\vproductCode\x12v\n" +
+	"\abalance\x18\x05 \x01(\v2\f.proto.MoneyBN\xbaHK\xba\x01E\n" +
+	"\x14balance.non_negative\x12\x1cbalance must not be negative\x1a\x0fthis.units >= 0\xc8\x01\x01R\abalanceJ\x04\b\x02\x10\x03\"]\n" +
 	"\x15CreateAccountResponse\x12\x1d\n" +
 	"\n" +
 	"account_id\x18\x01 \x01(\tR\taccountId\x12%\n" +
-	"\x0eaccount_number\x18\x02 \x01(\x05R\raccountNumber\"?\n" +
-	"\x1aGetAccountsByUserIdRequest\x12!\n" +
-	"\auser_id\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\x06userId\"I\n" +
+	"\x0eaccount_number\x18\x02 \x01(\x03R\raccountNumber\"9\n" +
+	"\x1aGetAccountsByUserIdRequest\x12\x1b\n" +
+	"\auser_id\x18\x01 \x01(\tB\x02\x18\x01R\x06userId\"I\n" +
 	"\x1bGetAccountsByUserIdResponse\x12*\n" +
-	"\baccounts\x18\x01 \x03(\v2\x0e.proto.AccountR\baccounts\"l\n" +
-	" GetAccountByAccountNumberRequest\x12!\n" +
-	"\auser_id\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\x06userId\x12%\n" +
-	"\x0eaccount_number\x18\x02 \x01(\x05R\raccountNumber\"j\n" +
-	"\x1cGetAccountByAccountIdRequest\x12!\n" +
-	"\auser_id\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\x06userId\x12'\n" +
+	"\baccounts\x18\x01 \x03(\v2\x0e.proto.AccountR\baccounts\"o\n" +
+	" GetAccountByAccountNumberRequest\x12\x1b\n" +
+	"\auser_id\x18\x01 \x01(\tB\x02\x18\x01R\x06userId\x12.\n" +
+	"\x0eaccount_number\x18\x02 \x01(\x03B\a\xbaH\x04\"\x02 \x00R\raccountNumber\"d\n" +
+	"\x1cGetAccountByAccountIdRequest\x12\x1b\n" +
+	"\auser_id\x18\x01 \x01(\tB\x02\x18\x01R\x06userId\x12'\n" +
 	"\n" +
-	"account_id\x18\x02 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\taccountId\"\xa2\x01\n" +
-	"#DeleteAccountByAccountNumberRequest\x12!\n" +
-	"\auser_id\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\x06userId\x12%\n" +
-	"\x0eaccount_number\x18\x02 \x01(\x05R\raccountNumber\x121\n" +
-	"\x0fidempotency_key\x18\x03 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\x0eidempotencyKey\"&\n" +
-	"$DeleteAccountByAccountNumberResponse\"\x95\x02\n" +
-	"\x18CreateTransactionRequest\x12!\n" +
-	"\auser_id\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\x06userId\x12'\n" +
+	"account_id\x18\x02 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\taccountId\"\xd6\x01\n" +
+	"#DeleteAccountByAccountNumberRequest\x12\x1b\n" +
+	"\auser_id\x18\x01 \x01(\tB\x02\x18\x01R\x06userId\x12.\n" +
+	"\x0eaccount_number\x18\x02 \x01(\x03B\a\xbaH\x04\"\x02 \x00R\raccountNumber\x121\n" +
+	"\x0fidempotency_key\x18\x03 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\x0eidempotencyKey\x12/\n" +
+	"\x0eclosure_reason\x18\x04 \x01(\tB\b\xbaH\x05r\x03\x18\xc8\x01R\rclosureReason\"&\n" +
+	"$DeleteAccountByAccountNumberResponse\"\xa7\x01\n" +
+	"\x1aUpdateAccountStatusRequest\x12'\n" +
 	"\n" +
-	"account_id\x18\x02 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\taccountId\x12\x16\n" +
-	"\x06amount\x18\x03 \x01(\x03R\x06amount\x12)\n" +
-	"\x10transaction_type\x18\x04 \x01(\tR\x0ftransactionType\x12\x1f\n" +
-	"\vtransfer_id\x18\x05 \x01(\tR\n" +
-	"transferId\x12\x16\n" +
-	"\x06status\x18\x06 \x01(\tR\x06status\x121\n" +
-	"\x0fidempotency_key\x18\a \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\x0eidempotencyKey\"B\n" +
+	"account_id\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\taccountId\x12-\n" +
+	"\x06status\x18\x02 \x01(\tB\x15\xbaH\x12r\x10R\x06ACTIVER\x06FROZENR\x06status\x121\n" +
+	"\x0fidempotency_key\x18\x03 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\x0eidempotencyKey\"\xd2\x03\n" +
+	"\x18CreateTransactionRequest\x12\x1b\n" +
+	"\auser_id\x18\x01 \x01(\tB\x02\x18\x01R\x06userId\x12'\n" +
+	"\n" +
+	"account_id\x18\x02 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\taccountId\x12`\n" +
+	"\x10transaction_type\x18\x04 \x01(\tB5\xbaH2r0R\x06CREDITR\x05DEBITR\x0fTRANSFER_CREDITR\x0eTRANSFER_DEBITR\x0ftransactionType\x12,\n" +
+	"\vtransfer_id\x18\x05 \x01(\tB\v\xbaH\b\xd8\x01\x01r\x03\xb0\x01\x01R\n" +
+	"transferId\x12<\n" +
+	"\x06status\x18\x06 \x01(\tB$\xbaH!\xd8\x01\x01r\x1cR\aPENDINGR\tCOMPLETEDR\x06FAILEDR\x06status\x121\n" +
+	"\x0fidempotency_key\x18\a \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\x0eidempotencyKey\x12i\n" +
+	"\x06amount\x18\b \x01(\v2\f.proto.MoneyBC\xbaH@\xba\x01:\n" +
+	"\x0famount.positive\x12\x17amount must be positive\x1a\x0ethis.units > 0\xc8\x01\x01R\x06amountJ\x04\b\x03\x10\x04\"B\n" +
 	"\x19CreateTransactionResponse\x12%\n" +
-	"\x0etransaction_id\x18\x01 \x01(\tR\rtransactionId\"o\n" +
-	"!GetTransactionsByAccountIdRequest\x12!\n" +
-	"\auser_id\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\x06userId\x12'\n" +
+	"\x0etransaction_id\x18\x01 \x01(\tR\rtransactionId\"\x8c\x03\n" +
+	"\x10PlaceHoldRequest\x12'\n" +
+	"\n" +
+	"account_id\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\taccountId\x12i\n" +
+	"\x06amount\x18\x02 \x01(\v2\f.proto.MoneyBC\xbaH@\xba\x01:\n" +
+	"\x0famount.positive\x12\x17amount must be positive\x1a\x0ethis.units > 0\xc8\x01\x01R\x06amount\x12G\n" +
+	"\x10transaction_type\x18\x03 \x01(\tB\x1c\xbaH\x19r\x17R\x05DEBITR\x0eTRANSFER_DEBITR\x0ftransactionType\x12,\n" +
+	"\vtransfer_id\x18\x04 \x01(\tB\v\xbaH\b\xd8\x01\x01r\x03\xb0\x01\x01R\n" +
+	"transferId\x121\n" +
+	"\x0fidempotency_key\x18\x05 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\x0eidempotencyKey\x12:\n" +
+	"\x12expires_in_seconds\x18\x06 \x01(\x03B\f\xbaH\t\"\a\x18\x80\x9a\x9e\x01(\x00R\x10expiresInSeconds\"\xd2\x01\n" +
+	"\x12CaptureHoldRequest\x12!\n" +
+	"\ahold_id\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\x06holdId\x12f\n" +
+	"\x06amount\x18\x02 \x01(\v2\f.proto.MoneyB@\xbaH=\xba\x01:\n" +
+	"\x0famount.positive\x12\x17amount must be positive\x1a\x0ethis.units > 0R\x06amount\x121\n" +
+	"\x0fidempotency_key\x18\x03 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\x0eidempotencyKey\"g\n" +
+	"\x0fVoidHoldRequest\x12!\n" +
+	"\ahold_id\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\x06holdId\x121\n" +
+	"\x0fidempotency_key\x18\x02 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\x0eidempotencyKey\"h\n" +
+	"\x11ReverseFeeRequest\x12/\n" +
+	"\x0etransaction_id\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\rtransactionId\x12\"\n" +
+	"\x06reason\x18\x02 \x01(\tB\n" +
+	"\xbaH\ar\x05\x10\x01\x18\xf4\x03R\x06reason\"\x8b\x02\n" +
+	"\x19ReverseTransactionRequest\x12/\n" +
+	"\x0etransaction_id\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\rtransactionId\x12f\n" +
+	"\x06amount\x18\x02 \x01(\v2\f.proto.MoneyB@\xbaH=\xba\x01:\n" +
+	"\x0famount.positive\x12\x17amount must be positive\x1a\x0ethis.units > 0R\x06amount\x12\"\n" +
+	"\x06reason\x18\x03 \x01(\tB\n" +
+	"\xbaH\ar\x05\x10\x01\x18\xf4\x03R\x06reason\x121\n" +
+	"\x0fidempotency_key\x18\x04 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\x0eidempotencyKey\"B\n" +
+	"\x17GetAccountLimitsRequest\x12'\n" +
+	"\n" +
+	"account_id\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\taccountId\"\xa7\x01\n" +
+	"\x1aUpdateAccountLimitsRequest\x12'\n" +
+	"\n" +
+	"account_id\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\taccountId\x12-\n" +
+	"\x06limits\x18\x02 \x01(\v2\r.proto.LimitsB\x06\xbaH\x03\xc8\x01\x01R\x06limits\x121\n" +
+	"\x0fidempotency_key\x18\x03 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\x0eidempotencyKey\"\xfa\x03\n" +
+	"\x06Limits\x12\x8c\x01\n" +
+	"\x10max_single_debit\x18\x01 \x01(\v2\f.proto.MoneyBT\xbaHQ\xba\x01N\n" +
+	"\x19max_single_debit.positive\x12!max_single_debit must be positive\x1a\x0ethis.units > 0R\x0emaxSingleDebit\x12\x90\x01\n" +
+	"\x11daily_debit_total\x18\x02 \x01(\v2\f.proto.MoneyBV\xbaHS\xba\x01P\n" +
+	"\x1adaily_debit_total.positive\x12\"daily_debit_total must be positive\x1a\x0ethis.units > 0R\x0fdailyDebitTotal\x123\n" +
+	"\x11daily_debit_count\x18\x03 \x01(\x05B\a\xbaH\x04\x1a\x02(\x00R\x0fdailyDebitCount\x12\x98\x01\n" +
+	"\x13monthly_debit_total\x18\x04 \x01(\v2\f.proto.MoneyBZ\xbaHW\xba\x01T\n" +
+	"\x1cmonthly_debit_total.positive\x12$monthly_debit_total must be positive\x1a\x0ethis.units > 0R\x11monthlyDebitTotal\"\xb0\x01\n" +
+	"\n" +
+	"LimitUsage\x128\n" +
+	"\x11daily_debit_total\x18\x01 \x01(\v2\f.proto.MoneyR\x0fdailyDebitTotal\x12*\n" +
+	"\x11daily_debit_count\x18\x02 \x01(\x03R\x0fdailyDebitCount\x12<\n" +
+	"\x13monthly_debit_total\x18\x03 \x01(\v2\f.proto.MoneyR\x11monthlyDebitTotal\"\xff\x01\n" +
+	"\rAccountLimits\x12\x1d\n" +
+	"\n" +
+	"account_id\x18\x01 \x01(\tR\taccountId\x12+\n" +
+	"\teffective\x18\x02 \x01(\v2\r.proto.LimitsR\teffective\x12!\n" +
+	"\x04user\x18\x03 \x01(\v2\r.proto.LimitsR\x04user\x12%\n" +
+	"\x04used\x18\x04 \x01(\v2\x11.proto.LimitUsageR\x04used\x12/\n" +
+	"\tremaining\x18\x05 \x01(\v2\x11.proto.LimitUsageR\tremaining\x12'\n" +
+	"\aceiling\x18\x06 \x01(\v2\r.proto.LimitsR\aceiling\"k\n" +
+	"\x14GetUserLimitsRequest\x12-\n" +
+	"\bcurrency\x18\x01 \x01(\tB\x11\xbaH\x0er\f2\n" +
+	"^[A-Z]{3}$R\bcurrency\x12$\n" +
+	"\auser_id\x18\x02 \x01(\tB\v\xbaH\b\xd8\x01\x01r\x03\xb0\x01\x01R\x06userId\"\xd0\x01\n" +
+	"\x17UpdateUserLimitsRequest\x12-\n" +
+	"\bcurrency\x18\x01 \x01(\tB\x11\xbaH\x0er\f2\n" +
+	"^[A-Z]{3}$R\bcurrency\x12-\n" +
+	"\x06limits\x18\x02 \x01(\v2\r.proto.LimitsB\x06\xbaH\x03\xc8\x01\x01R\x06limits\x12$\n" +
+	"\auser_id\x18\x03 \x01(\tB\v\xbaH\b\xd8\x01\x01r\x03\xb0\x01\x01R\x06userId\x121\n" +
+	"\x0fidempotency_key\x18\x04 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\x0eidempotencyKey\"\xc0\x01\n" +
+	"\n" +
+	"UserLimits\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1a\n" +
+	"\bcurrency\x18\x02 \x01(\tR\bcurrency\x12%\n" +
+	"\x06limits\x18\x03 \x01(\v2\r.proto.LimitsR\x06limits\x12%\n" +
+	"\x04used\x18\x04 \x01(\v2\x11.proto.LimitUsageR\x04used\x12/\n" +
+	"\tremaining\x18\x05 \x01(\v2\x11.proto.LimitUsageR\tremaining\"i\n" +
+	"!GetTransactionsByAccountIdRequest\x12\x1b\n" +
+	"\auser_id\x18\x01 \x01(\tB\x02\x18\x01R\x06userId\x12'\n" +
 	"\n" +
 	"account_id\x18\x02 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\taccountId\"\\\n" +
 	"\"GetTransactionsByAccountIdResponse\x126\n" +
-	"\ftransactions\x18\x01 \x03(\v2\x12.proto.TransactionR\ftransactions\"h\n" +
-	"\x1cValidateAccountNumberRequest\x12!\n" +
-	"\auser_id\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\x06userId\x12%\n" +
-	"\x0eaccount_number\x18\x02 \x01(\x05R\raccountNumber\"5\n" +
+	"\ftransactions\x18\x01 \x03(\v2\x12.proto.TransactionR\ftransactions\"k\n" +
+	"\x1cValidateAccountNumberRequest\x12\x1b\n" +
+	"\auser_id\x18\x01 \x01(\tB\x02\x18\x01R\x06userId\x12.\n" +
+	"\x0eaccount_number\x18\x02 \x01(\x03B\a\xbaH\x04\"\x02 \x00R\raccountNumber\"5\n" +
 	"\x1dValidateAccountNumberResponse\x12\x14\n" +
-	"\x05valid\x18\x01 \x01(\bR\x05valid\"\x7f\n" +
-	"\x1bHasSufficientBalanceRequest\x12!\n" +
-	"\auser_id\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\x06userId\x12%\n" +
-	"\x0eaccount_number\x18\x02 \x01(\x05R\raccountNumber\x12\x16\n" +
-	"\x06amount\x18\x03 \x01(\x03R\x06amount\">\n" +
+	"\x05valid\x18\x01 \x01(\bR\x05valid\"\xdb\x01\n" +
+	"\x1bHasSufficientBalanceRequest\x12\x1b\n" +
+	"\auser_id\x18\x01 \x01(\tB\x02\x18\x01R\x06userId\x12.\n" +
+	"\x0eaccount_number\x18\x02 \x01(\x03B\a\xbaH\x04\"\x02 \x00R\raccountNumber\x12i\n" +
+	"\x06amount\x18\x04 \x01(\v2\f.proto.MoneyBC\xbaH@\xba\x01:\n" +
+	"\x0famount.positive\x12\x17amount must be positive\x1a\x0ethis.units > 0\xc8\x01\x01R\x06amountJ\x04\b\x03\x10\x04\">\n" +
 	"\x1cHasSufficientBalanceResponse\x12\x1e\n" +
 	"\n" +
 	"sufficient\x18\x01 \x01(\bR\n" +
-	"sufficient2\xf9\x06\n" +
-	"\x0eAccountService\x12L\n" +
-	"\rCreateAccount\x12\x1b.proto.CreateAccountRequest\x1a\x1c.proto.CreateAccountResponse\"\x00\x12^\n" +
-	"\x13GetAccountsByUserId\x12!.proto.GetAccountsByUserIdRequest\x1a\".proto.GetAccountsByUserIdResponse\"\x00\x12V\n" +
-	"\x19GetAccountByAccountNumber\x12'.proto.GetAccountByAccountNumberRequest\x1a\x0e.proto.Account\"\x00\x12N\n" +
-	"\x15GetAccountByAccountId\x12#.proto.GetAccountByAccountIdRequest\x1a\x0e.proto.Account\"\x00\x12y\n" +
-	"\x1cDeleteAccountByAccountNumber\x12*.proto.DeleteAccountByAccountNumberRequest\x1a+.proto.DeleteAccountByAccountNumberResponse\"\x00\x12X\n" +
-	"\x11CreateTransaction\x12\x1f.proto.CreateTransactionRequest\x1a .proto.CreateTransactionResponse\"\x00\x12s\n" +
-	"\x1aGetTransactionsByAccountId\x12(.proto.GetTransactionsByAccountIdRequest\x1a).proto.GetTransactionsByAccountIdResponse\"\x00\x12d\n" +
+	"sufficient\"\x7f\n" +
+	"\x13WatchAccountRequest\x12'\n" +
+	"\n" +
+	"account_id\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\taccountId\x12?\n" +
+	"\x0eafter_event_id\x18\x02 \x01(\tB\x19\xbaH\x16\xd8\x01\x01r\x112\x0f^[0-9]+-[0-9]+$R\fafterEventId\"\xda\x01\n" +
+	"\fAccountEvent\x12\x19\n" +
+	"\bevent_id\x18\x01 \x01(\tR\aeventId\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x1d\n" +
+	"\n" +
+	"account_id\x18\x03 \x01(\tR\taccountId\x124\n" +
+	"\vtransaction\x18\x05 \x01(\v2\x12.proto.TransactionR\vtransaction\x12\x18\n" +
+	"\aversion\x18\x06 \x01(\x03R\aversion\x12&\n" +
+	"\abalance\x18\a \x01(\v2\f.proto.MoneyR\abalanceJ\x04\b\x04\x10\x052\x87\x12\n" +
+	"\x0eAccountService\x12g\n" +
+	"\rCreateAccount\x12\x1b.proto.CreateAccountRequest\x1a\x1c.proto.CreateAccountResponse\"\x1b\x82\xd3\xe4\x93\x02\x15:\x01*\"\x10/api/v1/accounts\x12v\n" +
+	"\x13GetAccountsByUserId\x12!.proto.GetAccountsByUserIdRequest\x1a\".proto.GetAccountsByUserIdResponse\"\x18\x82\xd3\xe4\x93\x02\x12\x12\x10/api/v1/accounts\x12\x86\x01\n" +
+	"\x19GetAccountByAccountNumber\x12'.proto.GetAccountByAccountNumberRequest\x1a\x0e.proto.Account\"0\x82\xd3\xe4\x93\x02*\x12(/api/v1/account-numbers/{account_number}\x12s\n" +
+	"\x15GetAccountByAccountId\x12#.proto.GetAccountByAccountIdRequest\x1a\x0e.proto.Account\"%\x82\xd3\xe4\x93\x02\x1f\x12\x1d/api/v1/accounts/{account_id}\x12\xa9\x01\n" +
+	"\x1cDeleteAccountByAccountNumber\x12*.proto.DeleteAccountByAccountNumberRequest\x1a+.proto.DeleteAccountByAccountNumberResponse\"0\x82\xd3\xe4\x93\x02**(/api/v1/account-numbers/{account_number}\x12y\n" +
+	"\x13UpdateAccountStatus\x12!.proto.UpdateAccountStatusRequest\x1a\x0e.proto.Account\"/\x82\xd3\xe4\x93\x02):\x01*\"$/api/v1/accounts/{account_id}/status\x12\x8d\x01\n" +
+	"\x11CreateTransaction\x12\x1f.proto.CreateTransactionRequest\x1a .proto.CreateTransactionResponse\"5\x82\xd3\xe4\x93\x02/:\x01*\"*/api/v1/accounts/{account_id}/transactions\x12\xa5\x01\n" +
+	"\x1aGetTransactionsByAccountId\x12(.proto.GetTransactionsByAccountIdRequest\x1a).proto.GetTransactionsByAccountIdResponse\"2\x82\xd3\xe4\x93\x02,\x12*/api/v1/accounts/{account_id}/transactions\x12a\n" +
+	"\tPlaceHold\x12\x17.proto.PlaceHoldRequest\x1a\v.proto.Hold\".\x82\xd3\xe4\x93\x02(:\x01*\"#/api/v1/accounts/{account_id}/holds\x12h\n" +
+	"\vCaptureHold\x12\x19.proto.CaptureHoldRequest\x1a\x12.proto.Transaction\"*\x82\xd3\xe4\x93\x02$:\x01*\"\x1f/api/v1/holds/{hold_id}/capture\x12X\n" +
+	"\bVoidHold\x12\x16.proto.VoidHoldRequest\x1a\v.proto.Hold\"'\x82\xd3\xe4\x93\x02!:\x01*\"\x1c/api/v1/holds/{hold_id}/void\x12d\n" +
 	"\x15ValidateAccountNumber\x12#.proto.ValidateAccountNumberRequest\x1a$.proto.ValidateAccountNumberResponse\"\x00\x12a\n" +
-	"\x14HasSufficientBalance\x12\".proto.HasSufficientBalanceRequest\x1a#.proto.HasSufficientBalanceResponse\"\x00BV\n" +
-	"\tcom.protoB\fAccountProtoP\x01Z\a.;proto\xa2\x02\x03PXX\xaa\x02\x05Proto\xca\x02\x05Proto\xe2\x02\x11Proto\\GPBMetadata\xea\x02\x05Protob\x06proto3"
+	"\x14HasSufficientBalance\x12\".proto.HasSufficientBalanceRequest\x1a#.proto.HasSufficientBalanceResponse\"\x00\x12<\n" +
+	"\n" +
+	"ReverseFee\x12\x18.proto.ReverseFeeRequest\x1a\x12.proto.Transaction\"\x00\x12\x84\x01\n" +
+	"\x12ReverseTransaction\x12 .proto.ReverseTransactionRequest\x1a\x12.proto.Transaction\"8\x82\xd3\xe4\x93\x022:\x01*\"-/api/v1/transactions/{transaction_id}/reverse\x12v\n" +
+	"\x10GetAccountLimits\x12\x1e.proto.GetAccountLimitsRequest\x1a\x14.proto.AccountLimits\",\x82\xd3\xe4\x93\x02&\x12$/api/v1/accounts/{account_id}/limits\x12\x7f\n" +
+	"\x13UpdateAccountLimits\x12!.proto.UpdateAccountLimitsRequest\x1a\x14.proto.AccountLimits\"/\x82\xd3\xe4\x93\x02):\x01*\"$/api/v1/accounts/{account_id}/limits\x12b\n" +
+	"\rGetUserLimits\x12\x1b.proto.GetUserLimitsRequest\x1a\x11.proto.UserLimits\"!\x82\xd3\xe4\x93\x02\x1b\x12\x19/api/v1/limits/{currency}\x12`\n" +
+	"\x10UpdateUserLimits\x12\x1e.proto.UpdateUserLimitsRequest\x1a\x11.proto.UserLimits\"\x19\x82\xd3\xe4\x93\x02\x13:\x01*\"\x0e/api/v1/limits\x12C\n" +
+	"\fWatchAccount\x12\x1a.proto.WatchAccountRequest\x1a\x13.proto.AccountEvent\"\x000\x01Bb\n" +
+	"\tcom.protoB\fAccountProtoP\x01Z\x13account/proto;proto\xa2\x02\x03PXX\xaa\x02\x05Proto\xca\x02\x05Proto\xe2\x02\x11Proto\\GPBMetadata\xea\x02\x05Protob\x06proto3"
 
 var (
 	file_account_proto_rawDescOnce sync.Once
@@ -1123,53 +2569,122 @@ func file_account_proto_rawDescGZIP() []byte {
 	return file_account_proto_rawDescData
 }
 
-var file_account_proto_msgTypes = make([]protoimpl.MessageInfo, 18)
+var file_account_proto_msgTypes = make([]protoimpl.MessageInfo, 35)
 var file_account_proto_goTypes = []any{
 	(*Account)(nil),                              // 0: proto.Account
 	(*Transaction)(nil),                          // 1: proto.Transaction
-	(*CreateAccountRequest)(nil),                 // 2: proto.CreateAccountRequest
-	(*CreateAccountResponse)(nil),                // 3: proto.CreateAccountResponse
-	(*GetAccountsByUserIdRequest)(nil),           // 4: proto.GetAccountsByUserIdRequest
-	(*GetAccountsByUserIdResponse)(nil),          // 5: proto.GetAccountsByUserIdResponse
-	(*GetAccountByAccountNumberRequest)(nil),     // 6: proto.GetAccountByAccountNumberRequest
-	(*GetAccountByAccountIdRequest)(nil),         // 7: proto.GetAccountByAccountIdRequest
-	(*DeleteAccountByAccountNumberRequest)(nil),  // 8: proto.DeleteAccountByAccountNumberRequest
-	(*DeleteAccountByAccountNumberResponse)(nil), // 9: proto.DeleteAccountByAccountNumberResponse
-	(*CreateTransactionRequest)(nil),             // 10: proto.CreateTransactionRequest
-	(*CreateTransactionResponse)(nil),            // 11: proto.CreateTransactionResponse
-	(*GetTransactionsByAccountIdRequest)(nil),    // 12: proto.GetTransactionsByAccountIdRequest
-	(*GetTransactionsByAccountIdResponse)(nil),   // 13: proto.GetTransactionsByAccountIdResponse
-	(*ValidateAccountNumberRequest)(nil),         // 14: proto.ValidateAccountNumberRequest
-	(*ValidateAccountNumberResponse)(nil),        // 15: proto.ValidateAccountNumberResponse
-	(*HasSufficientBalanceRequest)(nil),          // 16: proto.HasSufficientBalanceRequest
-	(*HasSufficientBalanceResponse)(nil),         // 17: proto.HasSufficientBalanceResponse
+	(*Hold)(nil),                                 // 2: proto.Hold
+	(*CreateAccountRequest)(nil),                 // 3: proto.CreateAccountRequest
+	(*CreateAccountResponse)(nil),                // 4: proto.CreateAccountResponse
+	(*GetAccountsByUserIdRequest)(nil),           // 5: proto.GetAccountsByUserIdRequest
+	(*GetAccountsByUserIdResponse)(nil),          // 6: proto.GetAccountsByUserIdResponse
+	(*GetAccountByAccountNumberRequest)(nil),     // 7: proto.GetAccountByAccountNumberRequest
+	(*GetAccountByAccountIdRequest)(nil),         // 8: proto.GetAccountByAccountIdRequest
+	(*DeleteAccountByAccountNumberRequest)(nil),  // 9: proto.DeleteAccountByAccountNumberRequest
+	(*DeleteAccountByAccountNumberResponse)(nil), // 10: proto.DeleteAccountByAccountNumberResponse
+	(*UpdateAccountStatusRequest)(nil),           // 11: proto.UpdateAccountStatusRequest
+	(*CreateTransactionRequest)(nil),             // 12: proto.CreateTransactionRequest
+	(*CreateTransactionResponse)(nil),            // 13: proto.CreateTransactionResponse
+	(*PlaceHoldRequest)(nil),                     // 14: proto.PlaceHoldRequest
+	(*CaptureHoldRequest)(nil),                   // 15: proto.CaptureHoldRequest
+	(*VoidHoldRequest)(nil),                      // 16: proto.VoidHoldRequest
+	(*ReverseFeeRequest)(nil),                    // 17: proto.ReverseFeeRequest
+	(*ReverseTransactionRequest)(nil),            // 18: proto.ReverseTransactionRequest
+	(*GetAccountLimitsRequest)(nil),              // 19: proto.GetAccountLimitsRequest
+	(*UpdateAccountLimitsRequest)(nil),           // 20: proto.UpdateAccountLimitsRequest
+	(*Limits)(nil),                               // 21: proto.Limits
+	(*LimitUsage)(nil),                           // 22: proto.LimitUsage
+	(*AccountLimits)(nil),                        // 23: proto.AccountLimits
+	(*GetUserLimitsRequest)(nil),                 // 24: proto.GetUserLimitsRequest
+	(*UpdateUserLimitsRequest)(nil),              // 25: proto.UpdateUserLimitsRequest
+	(*UserLimits)(nil),                           // 26: proto.UserLimits
+	(*GetTransactionsByAccountIdRequest)(nil),    // 27: proto.GetTransactionsByAccountIdRequest
+	(*GetTransactionsByAccountIdResponse)(nil),   // 28: proto.GetTransactionsByAccountIdResponse
+	(*ValidateAccountNumberRequest)(nil),         // 29: proto.ValidateAccountNumberRequest
+	(*ValidateAccountNumberResponse)(nil),        // 30: proto.ValidateAccountNumberResponse
+	(*HasSufficientBalanceRequest)(nil),          // 31: proto.HasSufficientBalanceRequest
+	(*HasSufficientBalanceResponse)(nil),         // 32: proto.HasSufficientBalanceResponse
+	(*WatchAccountRequest)(nil),                  // 33: proto.WatchAccountRequest
+	(*AccountEvent)(nil),                         // 34: proto.AccountEvent
+	(*Money)(nil),                                // 35: proto.Money
 }
 var file_account_proto_depIdxs = []int32{
-	0,  // 0: proto.GetAccountsByUserIdResponse.accounts:type_name -> proto.Account
-	1,  // 1: proto.GetTransactionsByAccountIdResponse.transactions:type_name -> proto.Transaction
-	2,  // 2: proto.AccountService.CreateAccount:input_type -> proto.CreateAccountRequest
-	4,  // 3: proto.AccountService.GetAccountsByUserId:input_type -> proto.GetAccountsByUserIdRequest
-	6,  // 4: proto.AccountService.GetAccountByAccountNumber:input_type -> proto.GetAccountByAccountNumberRequest
-	7,  // 5: proto.AccountService.GetAccountByAccountId:input_type -> proto.GetAccountByAccountIdRequest
-	8,  // 6: proto.AccountService.DeleteAccountByAccountNumber:input_type -> proto.DeleteAccountByAccountNumberRequest
-	10, // 7: proto.AccountService.CreateTransaction:input_type -> proto.CreateTransactionRequest
-	12, // 8: proto.AccountService.GetTransactionsByAccountId:input_type -> proto.GetTransactionsByAccountIdRequest
-	14, // 9: proto.AccountService.ValidateAccountNumber:input_type -> proto.ValidateAccountNumberRequest
-	16, // 10: proto.AccountService.HasSufficientBalance:input_type -> proto.HasSufficientBalanceRequest
-	3,  // 11: proto.AccountService.CreateAccount:output_type -> proto.CreateAccountResponse
-	5,  // 12: proto.AccountService.GetAccountsByUserId:output_type -> proto.GetAccountsByUserIdResponse
-	0,  // 13: proto.AccountService.GetAccountByAccountNumber:output_type -> proto.Account
-	0,  // 14: proto.AccountService.GetAccountByAccountId:output_type -> proto.Account
-	9,  // 15: proto.AccountService.DeleteAccountByAccountNumber:output_type -> proto.DeleteAccountByAccountNumberResponse
-	11, // 16: proto.AccountService.CreateTransaction:output_type -> proto.CreateTransactionResponse
-	13, // 17: proto.AccountService.GetTransactionsByAccountId:output_type -> proto.GetTransactionsByAccountIdResponse
-	15, // 18: proto.AccountService.ValidateAccountNumber:output_type -> proto.ValidateAccountNumberResponse
-	17, // 19: proto.AccountService.HasSufficientBalance:output_type -> proto.HasSufficientBalanceResponse
-	11, // [11:20] is the sub-list for method output_type
-	2,  // [2:11] is the sub-list for method input_type
-	2,  // [2:2] is the sub-list for extension type_name
-	2,  // [2:2] is the sub-list for extension extendee
-	0,  // [0:2] is the sub-list for field type_name
+	35, // 0: proto.Account.balance:type_name -> proto.Money
+	35, // 1: proto.Account.ledger_balance:type_name -> proto.Money
+	35, // 2: proto.Account.available_balance:type_name -> proto.Money
+	35, // 3: proto.Transaction.amount:type_name -> proto.Money
+	35, // 4: proto.Hold.amount:type_name -> proto.Money
+	35, // 5: proto.Hold.captured:type_name -> proto.Money
+	35, // 6: proto.CreateAccountRequest.balance:type_name -> proto.Money
+	0,  // 7: proto.GetAccountsByUserIdResponse.accounts:type_name -> proto.Account
+	35, // 8: proto.CreateTransactionRequest.amount:type_name -> proto.Money
+	35, // 9: proto.PlaceHoldRequest.amount:type_name -> proto.Money
+	35, // 10: proto.CaptureHoldRequest.amount:type_name -> proto.Money
+	35, // 11: proto.ReverseTransactionRequest.amount:type_name -> proto.Money
+	21, // 12: proto.UpdateAccountLimitsRequest.limits:type_name -> proto.Limits
+	35, // 13: proto.Limits.max_single_debit:type_name -> proto.Money
+	35, // 14: proto.Limits.daily_debit_total:type_name -> proto.Money
+	35, // 15: proto.Limits.monthly_debit_total:type_name -> proto.Money
+	35, // 16: proto.LimitUsage.daily_debit_total:type_name -> proto.Money
+	35, // 17: proto.LimitUsage.monthly_debit_total:type_name -> proto.Money
+	21, // 18: proto.AccountLimits.effective:type_name -> proto.Limits
+	21, // 19: proto.AccountLimits.user:type_name -> proto.Limits
+	22, // 20: proto.AccountLimits.used:type_name -> proto.LimitUsage
+	22, // 21: proto.AccountLimits.remaining:type_name -> proto.LimitUsage
+	21, // 22: proto.AccountLimits.ceiling:type_name -> proto.Limits
+	21, // 23: proto.UpdateUserLimitsRequest.limits:type_name -> proto.Limits
+	21, // 24: proto.UserLimits.limits:type_name -> proto.Limits
+	22, // 25: proto.UserLimits.used:type_name -> proto.LimitUsage
+	22, // 26: proto.UserLimits.remaining:type_name -> proto.LimitUsage
+	1,  // 27: proto.GetTransactionsByAccountIdResponse.transactions:type_name -> proto.Transaction
+	35, // 28: proto.HasSufficientBalanceRequest.amount:type_name -> proto.Money
+	1,  // 29: proto.AccountEvent.transaction:type_name -> proto.Transaction
+	35, // 30: proto.AccountEvent.balance:type_name -> proto.Money
+	3,  // 31: proto.AccountService.CreateAccount:input_type -> proto.CreateAccountRequest
+	5,  // 32: proto.AccountService.GetAccountsByUserId:input_type -> proto.GetAccountsByUserIdRequest
+	7,  // 33: proto.AccountService.GetAccountByAccountNumber:input_type -> proto.GetAccountByAccountNumberRequest
+	8,  // 34: proto.AccountService.GetAccountByAccountId:input_type -> proto.GetAccountByAccountIdRequest
+	9,  // 35: proto.AccountService.DeleteAccountByAccountNumber:input_type -> proto.DeleteAccountByAccountNumberRequest
+	11, // 36: proto.AccountService.UpdateAccountStatus:input_type -> proto.UpdateAccountStatusRequest
+	12, // 37: proto.AccountService.CreateTransaction:input_type -> proto.CreateTransactionRequest
+	27, // 38: proto.AccountService.GetTransactionsByAccountId:input_type -> proto.GetTransactionsByAccountIdRequest
+	14, // 39: proto.AccountService.PlaceHold:input_type -> proto.PlaceHoldRequest
+	15, // 40: proto.AccountService.CaptureHold:input_type -> proto.CaptureHoldRequest
+	16, // 41: proto.AccountService.VoidHold:input_type -> proto.VoidHoldRequest
+	29, // 42: proto.AccountService.ValidateAccountNumber:input_type -> proto.ValidateAccountNumberRequest
+	31, // 43: proto.AccountService.HasSufficientBalance:input_type -> proto.HasSufficientBalanceRequest
+	17, // 44: proto.AccountService.ReverseFee:input_type -> proto.ReverseFeeRequest
+	18, // 45: proto.AccountService.ReverseTransaction:input_type -> proto.ReverseTransactionRequest
+	19, // 46: proto.AccountService.GetAccountLimits:input_type -> proto.GetAccountLimitsRequest
+	20, // 47: proto.AccountService.UpdateAccountLimits:input_type -> proto.UpdateAccountLimitsRequest
+	24, // 48: proto.AccountService.GetUserLimits:input_type -> proto.GetUserLimitsRequest
+	25, // 49: proto.AccountService.UpdateUserLimits:input_type -> proto.UpdateUserLimitsRequest
+	33, // 50: proto.AccountService.WatchAccount:input_type -> proto.WatchAccountRequest
+	4,  // 51: proto.AccountService.CreateAccount:output_type -> proto.CreateAccountResponse
+	6,  // 52: proto.AccountService.GetAccountsByUserId:output_type -> proto.GetAccountsByUserIdResponse
+	0,  // 53: proto.AccountService.GetAccountByAccountNumber:output_type -> proto.Account
+	0,  // 54: proto.AccountService.GetAccountByAccountId:output_type -> proto.Account
+	10, // 55: proto.AccountService.DeleteAccountByAccountNumber:output_type -> proto.DeleteAccountByAccountNumberResponse
+	0,  // 56: proto.AccountService.UpdateAccountStatus:output_type -> proto.Account
+	13, // 57: proto.AccountService.CreateTransaction:output_type -> proto.CreateTransactionResponse
+	28, // 58: proto.AccountService.GetTransactionsByAccountId:output_type -> proto.GetTransactionsByAccountIdResponse
+	2,  // 59: proto.AccountService.PlaceHold:output_type -> proto.Hold
+	1,  // 60: proto.AccountService.CaptureHold:output_type -> proto.Transaction
+	2,  // 61: proto.AccountService.VoidHold:output_type -> proto.Hold
+	30, // 62: proto.AccountService.ValidateAccountNumber:output_type -> proto.ValidateAccountNumberResponse
+	32, // 63: proto.AccountService.HasSufficientBalance:output_type -> proto.HasSufficientBalanceResponse
+	1,  // 64: proto.AccountService.ReverseFee:output_type -> proto.Transaction
+	1,  // 65: proto.AccountService.ReverseTransaction:output_type -> proto.Transaction
+	23, // 66: proto.AccountService.GetAccountLimits:output_type -> proto.AccountLimits
+	23, // 67: proto.AccountService.UpdateAccountLimits:output_type -> proto.AccountLimits
+	26, // 68: proto.AccountService.GetUserLimits:output_type -> proto.UserLimits
+	26, // 69: proto.AccountService.UpdateUserLimits:output_type -> proto.UserLimits
+	34, // 70: proto.AccountService.WatchAccount:output_type -> proto.AccountEvent
+	51, // [51:71] is the sub-list for method output_type
+	31, // [31:51] is the sub-list for method input_type
+	31, // [31:31] is the sub-list for extension type_name
+	31, // [31:31] is the sub-list for extension extendee
+	0,  // [0:31] is the sub-list for field type_name
 }
 
 func init() { file_account_proto_init() }
@@ -1177,13 +2692,14 @@ func file_account_proto_init() {
 	if File_account_proto != nil {
 		return
 	}
+	file_money_proto_init()
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_account_proto_rawDesc), len(file_account_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   18,
+			NumMessages:   35,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
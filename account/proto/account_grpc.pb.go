@@ -24,25 +24,91 @@ const (
 	AccountService_GetAccountByAccountNumber_FullMethodName    = "/proto.AccountService/GetAccountByAccountNumber"
 	AccountService_GetAccountByAccountId_FullMethodName        = "/proto.AccountService/GetAccountByAccountId"
 	AccountService_DeleteAccountByAccountNumber_FullMethodName = "/proto.AccountService/DeleteAccountByAccountNumber"
+	AccountService_UpdateAccountStatus_FullMethodName          = "/proto.AccountService/UpdateAccountStatus"
 	AccountService_CreateTransaction_FullMethodName            = "/proto.AccountService/CreateTransaction"
 	AccountService_GetTransactionsByAccountId_FullMethodName   = "/proto.AccountService/GetTransactionsByAccountId"
+	AccountService_PlaceHold_FullMethodName                    = "/proto.AccountService/PlaceHold"
+	AccountService_CaptureHold_FullMethodName                  = "/proto.AccountService/CaptureHold"
+	AccountService_VoidHold_FullMethodName                     = "/proto.AccountService/VoidHold"
 	AccountService_ValidateAccountNumber_FullMethodName        = "/proto.AccountService/ValidateAccountNumber"
 	AccountService_HasSufficientBalance_FullMethodName         = "/proto.AccountService/HasSufficientBalance"
+	AccountService_ReverseFee_FullMethodName                   = "/proto.AccountService/ReverseFee"
+	AccountService_ReverseTransaction_FullMethodName           = "/proto.AccountService/ReverseTransaction"
+	AccountService_GetAccountLimits_FullMethodName             = "/proto.AccountService/GetAccountLimits"
+	AccountService_UpdateAccountLimits_FullMethodName          = "/proto.AccountService/UpdateAccountLimits"
+	AccountService_GetUserLimits_FullMethodName                = "/proto.AccountService/GetUserLimits"
+	AccountService_UpdateUserLimits_FullMethodName             = "/proto.AccountService/UpdateUserLimits"
+	AccountService_WatchAccount_FullMethodName                 = "/proto.AccountService/WatchAccount"
 )
 
 // AccountServiceClient is the client API for AccountService service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// The google.api.http annotations define the REST API served by the API Gateway under /api/v1.
+// Account numbers have 12 digits, the last one being a Luhn check digit, except the older accounts that kept their
+// 10-digit number. RPCs taking a malformed account number fail with INVALID_ACCOUNT_NUMBER without looking it up.
+// The amounts of an account and of its transactions are all in the currency the account was opened in.
+// ValidateAccountNumber and HasSufficientBalance are only called by the transfer service and have no REST binding.
+// ReverseFee is only called by the support console, on behalf of the support staff rather than of a user, and so is
+// ReverseTransaction when it doesn't come from the API Gateway.
+// Holds reserve funds for a debit to come, e.g. a card payment or the debit of a transfer: PlaceHold lowers the
+// available balance of the account but not its ledger balance, then CaptureHold debits the account and VoidHold
+// releases the funds. Holds that are neither captured nor voided expire. Only the transfer service may place, capture
+// or void the holds of TRANSFER_DEBIT transactions.
 type AccountServiceClient interface {
 	CreateAccount(ctx context.Context, in *CreateAccountRequest, opts ...grpc.CallOption) (*CreateAccountResponse, error)
 	GetAccountsByUserId(ctx context.Context, in *GetAccountsByUserIdRequest, opts ...grpc.CallOption) (*GetAccountsByUserIdResponse, error)
 	GetAccountByAccountNumber(ctx context.Context, in *GetAccountByAccountNumberRequest, opts ...grpc.CallOption) (*Account, error)
 	GetAccountByAccountId(ctx context.Context, in *GetAccountByAccountIdRequest, opts ...grpc.CallOption) (*Account, error)
+	// DeleteAccountByAccountNumber closes the account, which must have a zero balance. The account and its transactions
+	// are kept, with the CLOSED status.
 	DeleteAccountByAccountNumber(ctx context.Context, in *DeleteAccountByAccountNumberRequest, opts ...grpc.CallOption) (*DeleteAccountByAccountNumberResponse, error)
+	// UpdateAccountStatus lets the owner freeze an account, e.g. when a card is lost, and make a frozen or dormant account
+	// ACTIVE again. Frozen and dormant accounts can be credited but not debited.
+	UpdateAccountStatus(ctx context.Context, in *UpdateAccountStatusRequest, opts ...grpc.CallOption) (*Account, error)
 	CreateTransaction(ctx context.Context, in *CreateTransactionRequest, opts ...grpc.CallOption) (*CreateTransactionResponse, error)
 	GetTransactionsByAccountId(ctx context.Context, in *GetTransactionsByAccountIdRequest, opts ...grpc.CallOption) (*GetTransactionsByAccountIdResponse, error)
+	// PlaceHold fails the way a debit of the same amount would, e.g. with INSUFFICIENT_FUNDS if the available balance is
+	// too low, without debiting anything.
+	PlaceHold(ctx context.Context, in *PlaceHoldRequest, opts ...grpc.CallOption) (*Hold, error)
+	// CaptureHold debits the account with a transaction of the type of the hold, for all or part of it, and releases the
+	// rest. It returns the transaction. It fails with HOLD_NOT_ACTIVE or HOLD_EXPIRED if the hold isn't ACTIVE anymore,
+	// and with CAPTURE_EXCEEDS_HOLD if amount is more than the amount of the hold.
+	CaptureHold(ctx context.Context, in *CaptureHoldRequest, opts ...grpc.CallOption) (*Transaction, error)
+	// VoidHold releases the hold. Voiding a hold that was voided or expired already returns it as is, and voiding a
+	// captured hold fails with HOLD_NOT_ACTIVE.
+	VoidHold(ctx context.Context, in *VoidHoldRequest, opts ...grpc.CallOption) (*Hold, error)
 	ValidateAccountNumber(ctx context.Context, in *ValidateAccountNumberRequest, opts ...grpc.CallOption) (*ValidateAccountNumberResponse, error)
 	HasSufficientBalance(ctx context.Context, in *HasSufficientBalanceRequest, opts ...grpc.CallOption) (*HasSufficientBalanceResponse, error)
+	// ReverseFee refunds a FEE transaction with a FEE_REVERSAL one, and marks the fee REVERSED. It returns the reversal.
+	// A fee is reversed at most once: reversing it again returns the same reversal. Fails with NOT_A_FEE if the
+	// transaction isn't a fee.
+	ReverseFee(ctx context.Context, in *ReverseFeeRequest, opts ...grpc.CallOption) (*Transaction, error)
+	// ReverseTransaction offsets all or part of a CREDIT, DEBIT or INTEREST transaction with a REVERSAL one, whose
+	// caused_by is the reversed transaction, and marks the transaction REVERSED once it is reversed in full. It returns
+	// the reversal. The reversals of a transaction never add up to more than its amount: it fails with ALREADY_REVERSED
+	// once it was reversed in full, and with REVERSAL_EXCEEDS_REMAINING if amount is more than what remains to reverse.
+	// Users may only reverse the credits of their accounts, the support staff reverses the rest.
+	ReverseTransaction(ctx context.Context, in *ReverseTransactionRequest, opts ...grpc.CallOption) (*Transaction, error)
+	// GetAccountLimits returns the limits of the debits of an account, and how much of them is used today and this
+	// month. A debit or a hold exceeding a limit fails with RESOURCE_EXHAUSTED and the reason LIMIT_EXCEEDED, whose
+	// ErrorInfo metadata has the "limit", the "allowed" amount and the "remaining" allowance.
+	GetAccountLimits(ctx context.Context, in *GetAccountLimitsRequest, opts ...grpc.CallOption) (*AccountLimits, error)
+	// UpdateAccountLimits replaces the limits the user set on an account of theirs, and returns them as updated. The user
+	// may lower and raise them up to the ceiling of the account, above which it fails with LIMIT_INCREASE_NOT_ALLOWED:
+	// only the support staff can raise the ceiling, by replacing the limits of the product of the account.
+	UpdateAccountLimits(ctx context.Context, in *UpdateAccountLimitsRequest, opts ...grpc.CallOption) (*AccountLimits, error)
+	// GetUserLimits returns the limits of the debits of all the accounts of the user in a currency together, and how much
+	// of them is used today and this month. A debit or a hold exceeding them fails with LIMIT_EXCEEDED, like the limits of
+	// an account.
+	GetUserLimits(ctx context.Context, in *GetUserLimitsRequest, opts ...grpc.CallOption) (*UserLimits, error)
+	// UpdateUserLimits replaces the limits of the debits of all the accounts of the user in a currency together, and
+	// returns them as updated. The limits of each account still apply.
+	UpdateUserLimits(ctx context.Context, in *UpdateUserLimitsRequest, opts ...grpc.CallOption) (*UserLimits, error)
+	// WatchAccount streams the balance changes of an account as transactions are committed.
+	// It is relayed to browsers by the API Gateway over Server-Sent Events and WebSocket rather than through grpc-gateway.
+	WatchAccount(ctx context.Context, in *WatchAccountRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AccountEvent], error)
 }
 
 type accountServiceClient struct {
@@ -103,6 +169,16 @@ func (c *accountServiceClient) DeleteAccountByAccountNumber(ctx context.Context,
 	return out, nil
 }
 
+func (c *accountServiceClient) UpdateAccountStatus(ctx context.Context, in *UpdateAccountStatusRequest, opts ...grpc.CallOption) (*Account, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Account)
+	err := c.cc.Invoke(ctx, AccountService_UpdateAccountStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *accountServiceClient) CreateTransaction(ctx context.Context, in *CreateTransactionRequest, opts ...grpc.CallOption) (*CreateTransactionResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(CreateTransactionResponse)
@@ -123,6 +199,36 @@ func (c *accountServiceClient) GetTransactionsByAccountId(ctx context.Context, i
 	return out, nil
 }
 
+func (c *accountServiceClient) PlaceHold(ctx context.Context, in *PlaceHoldRequest, opts ...grpc.CallOption) (*Hold, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Hold)
+	err := c.cc.Invoke(ctx, AccountService_PlaceHold_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *accountServiceClient) CaptureHold(ctx context.Context, in *CaptureHoldRequest, opts ...grpc.CallOption) (*Transaction, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Transaction)
+	err := c.cc.Invoke(ctx, AccountService_CaptureHold_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *accountServiceClient) VoidHold(ctx context.Context, in *VoidHoldRequest, opts ...grpc.CallOption) (*Hold, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Hold)
+	err := c.cc.Invoke(ctx, AccountService_VoidHold_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *accountServiceClient) ValidateAccountNumber(ctx context.Context, in *ValidateAccountNumberRequest, opts ...grpc.CallOption) (*ValidateAccountNumberResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(ValidateAccountNumberResponse)
@@ -143,19 +249,153 @@ func (c *accountServiceClient) HasSufficientBalance(ctx context.Context, in *Has
 	return out, nil
 }
 
+func (c *accountServiceClient) ReverseFee(ctx context.Context, in *ReverseFeeRequest, opts ...grpc.CallOption) (*Transaction, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Transaction)
+	err := c.cc.Invoke(ctx, AccountService_ReverseFee_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *accountServiceClient) ReverseTransaction(ctx context.Context, in *ReverseTransactionRequest, opts ...grpc.CallOption) (*Transaction, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Transaction)
+	err := c.cc.Invoke(ctx, AccountService_ReverseTransaction_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *accountServiceClient) GetAccountLimits(ctx context.Context, in *GetAccountLimitsRequest, opts ...grpc.CallOption) (*AccountLimits, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AccountLimits)
+	err := c.cc.Invoke(ctx, AccountService_GetAccountLimits_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *accountServiceClient) UpdateAccountLimits(ctx context.Context, in *UpdateAccountLimitsRequest, opts ...grpc.CallOption) (*AccountLimits, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AccountLimits)
+	err := c.cc.Invoke(ctx, AccountService_UpdateAccountLimits_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *accountServiceClient) GetUserLimits(ctx context.Context, in *GetUserLimitsRequest, opts ...grpc.CallOption) (*UserLimits, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UserLimits)
+	err := c.cc.Invoke(ctx, AccountService_GetUserLimits_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *accountServiceClient) UpdateUserLimits(ctx context.Context, in *UpdateUserLimitsRequest, opts ...grpc.CallOption) (*UserLimits, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UserLimits)
+	err := c.cc.Invoke(ctx, AccountService_UpdateUserLimits_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *accountServiceClient) WatchAccount(ctx context.Context, in *WatchAccountRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AccountEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &AccountService_ServiceDesc.Streams[0], AccountService_WatchAccount_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchAccountRequest, AccountEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AccountService_WatchAccountClient = grpc.ServerStreamingClient[AccountEvent]
+
 // AccountServiceServer is the server API for AccountService service.
 // All implementations must embed UnimplementedAccountServiceServer
 // for forward compatibility.
+//
+// The google.api.http annotations define the REST API served by the API Gateway under /api/v1.
+// Account numbers have 12 digits, the last one being a Luhn check digit, except the older accounts that kept their
+// 10-digit number. RPCs taking a malformed account number fail with INVALID_ACCOUNT_NUMBER without looking it up.
+// The amounts of an account and of its transactions are all in the currency the account was opened in.
+// ValidateAccountNumber and HasSufficientBalance are only called by the transfer service and have no REST binding.
+// ReverseFee is only called by the support console, on behalf of the support staff rather than of a user, and so is
+// ReverseTransaction when it doesn't come from the API Gateway.
+// Holds reserve funds for a debit to come, e.g. a card payment or the debit of a transfer: PlaceHold lowers the
+// available balance of the account but not its ledger balance, then CaptureHold debits the account and VoidHold
+// releases the funds. Holds that are neither captured nor voided expire. Only the transfer service may place, capture
+// or void the holds of TRANSFER_DEBIT transactions.
 type AccountServiceServer interface {
 	CreateAccount(context.Context, *CreateAccountRequest) (*CreateAccountResponse, error)
 	GetAccountsByUserId(context.Context, *GetAccountsByUserIdRequest) (*GetAccountsByUserIdResponse, error)
 	GetAccountByAccountNumber(context.Context, *GetAccountByAccountNumberRequest) (*Account, error)
 	GetAccountByAccountId(context.Context, *GetAccountByAccountIdRequest) (*Account, error)
+	// DeleteAccountByAccountNumber closes the account, which must have a zero balance. The account and its transactions
+	// are kept, with the CLOSED status.
 	DeleteAccountByAccountNumber(context.Context, *DeleteAccountByAccountNumberRequest) (*DeleteAccountByAccountNumberResponse, error)
+	// UpdateAccountStatus lets the owner freeze an account, e.g. when a card is lost, and make a frozen or dormant account
+	// ACTIVE again. Frozen and dormant accounts can be credited but not debited.
+	UpdateAccountStatus(context.Context, *UpdateAccountStatusRequest) (*Account, error)
 	CreateTransaction(context.Context, *CreateTransactionRequest) (*CreateTransactionResponse, error)
 	GetTransactionsByAccountId(context.Context, *GetTransactionsByAccountIdRequest) (*GetTransactionsByAccountIdResponse, error)
+	// PlaceHold fails the way a debit of the same amount would, e.g. with INSUFFICIENT_FUNDS if the available balance is
+	// too low, without debiting anything.
+	PlaceHold(context.Context, *PlaceHoldRequest) (*Hold, error)
+	// CaptureHold debits the account with a transaction of the type of the hold, for all or part of it, and releases the
+	// rest. It returns the transaction. It fails with HOLD_NOT_ACTIVE or HOLD_EXPIRED if the hold isn't ACTIVE anymore,
+	// and with CAPTURE_EXCEEDS_HOLD if amount is more than the amount of the hold.
+	CaptureHold(context.Context, *CaptureHoldRequest) (*Transaction, error)
+	// VoidHold releases the hold. Voiding a hold that was voided or expired already returns it as is, and voiding a
+	// captured hold fails with HOLD_NOT_ACTIVE.
+	VoidHold(context.Context, *VoidHoldRequest) (*Hold, error)
 	ValidateAccountNumber(context.Context, *ValidateAccountNumberRequest) (*ValidateAccountNumberResponse, error)
 	HasSufficientBalance(context.Context, *HasSufficientBalanceRequest) (*HasSufficientBalanceResponse, error)
+	// ReverseFee refunds a FEE transaction with a FEE_REVERSAL one, and marks the fee REVERSED. It returns the reversal.
+	// A fee is reversed at most once: reversing it again returns the same reversal. Fails with NOT_A_FEE if the
+	// transaction isn't a fee.
+	ReverseFee(context.Context, *ReverseFeeRequest) (*Transaction, error)
+	// ReverseTransaction offsets all or part of a CREDIT, DEBIT or INTEREST transaction with a REVERSAL one, whose
+	// caused_by is the reversed transaction, and marks the transaction REVERSED once it is reversed in full. It returns
+	// the reversal. The reversals of a transaction never add up to more than its amount: it fails with ALREADY_REVERSED
+	// once it was reversed in full, and with REVERSAL_EXCEEDS_REMAINING if amount is more than what remains to reverse.
+	// Users may only reverse the credits of their accounts, the support staff reverses the rest.
+	ReverseTransaction(context.Context, *ReverseTransactionRequest) (*Transaction, error)
+	// GetAccountLimits returns the limits of the debits of an account, and how much of them is used today and this
+	// month. A debit or a hold exceeding a limit fails with RESOURCE_EXHAUSTED and the reason LIMIT_EXCEEDED, whose
+	// ErrorInfo metadata has the "limit", the "allowed" amount and the "remaining" allowance.
+	GetAccountLimits(context.Context, *GetAccountLimitsRequest) (*AccountLimits, error)
+	// UpdateAccountLimits replaces the limits the user set on an account of theirs, and returns them as updated. The user
+	// may lower and raise them up to the ceiling of the account, above which it fails with LIMIT_INCREASE_NOT_ALLOWED:
+	// only the support staff can raise the ceiling, by replacing the limits of the product of the account.
+	UpdateAccountLimits(context.Context, *UpdateAccountLimitsRequest) (*AccountLimits, error)
+	// GetUserLimits returns the limits of the debits of all the accounts of the user in a currency together, and how much
+	// of them is used today and this month. A debit or a hold exceeding them fails with LIMIT_EXCEEDED, like the limits of
+	// an account.
+	GetUserLimits(context.Context, *GetUserLimitsRequest) (*UserLimits, error)
+	// UpdateUserLimits replaces the limits of the debits of all the accounts of the user in a currency together, and
+	// returns them as updated. The limits of each account still apply.
+	UpdateUserLimits(context.Context, *UpdateUserLimitsRequest) (*UserLimits, error)
+	// WatchAccount streams the balance changes of an account as transactions are committed.
+	// It is relayed to browsers by the API Gateway over Server-Sent Events and WebSocket rather than through grpc-gateway.
+	WatchAccount(*WatchAccountRequest, grpc.ServerStreamingServer[AccountEvent]) error
 	mustEmbedUnimplementedAccountServiceServer()
 }
 
@@ -181,18 +421,51 @@ func (UnimplementedAccountServiceServer) GetAccountByAccountId(context.Context,
 func (UnimplementedAccountServiceServer) DeleteAccountByAccountNumber(context.Context, *DeleteAccountByAccountNumberRequest) (*DeleteAccountByAccountNumberResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteAccountByAccountNumber not implemented")
 }
+func (UnimplementedAccountServiceServer) UpdateAccountStatus(context.Context, *UpdateAccountStatusRequest) (*Account, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateAccountStatus not implemented")
+}
 func (UnimplementedAccountServiceServer) CreateTransaction(context.Context, *CreateTransactionRequest) (*CreateTransactionResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CreateTransaction not implemented")
 }
 func (UnimplementedAccountServiceServer) GetTransactionsByAccountId(context.Context, *GetTransactionsByAccountIdRequest) (*GetTransactionsByAccountIdResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetTransactionsByAccountId not implemented")
 }
+func (UnimplementedAccountServiceServer) PlaceHold(context.Context, *PlaceHoldRequest) (*Hold, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PlaceHold not implemented")
+}
+func (UnimplementedAccountServiceServer) CaptureHold(context.Context, *CaptureHoldRequest) (*Transaction, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CaptureHold not implemented")
+}
+func (UnimplementedAccountServiceServer) VoidHold(context.Context, *VoidHoldRequest) (*Hold, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VoidHold not implemented")
+}
 func (UnimplementedAccountServiceServer) ValidateAccountNumber(context.Context, *ValidateAccountNumberRequest) (*ValidateAccountNumberResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ValidateAccountNumber not implemented")
 }
 func (UnimplementedAccountServiceServer) HasSufficientBalance(context.Context, *HasSufficientBalanceRequest) (*HasSufficientBalanceResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method HasSufficientBalance not implemented")
 }
+func (UnimplementedAccountServiceServer) ReverseFee(context.Context, *ReverseFeeRequest) (*Transaction, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReverseFee not implemented")
+}
+func (UnimplementedAccountServiceServer) ReverseTransaction(context.Context, *ReverseTransactionRequest) (*Transaction, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReverseTransaction not implemented")
+}
+func (UnimplementedAccountServiceServer) GetAccountLimits(context.Context, *GetAccountLimitsRequest) (*AccountLimits, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAccountLimits not implemented")
+}
+func (UnimplementedAccountServiceServer) UpdateAccountLimits(context.Context, *UpdateAccountLimitsRequest) (*AccountLimits, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateAccountLimits not implemented")
+}
+func (UnimplementedAccountServiceServer) GetUserLimits(context.Context, *GetUserLimitsRequest) (*UserLimits, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUserLimits not implemented")
+}
+func (UnimplementedAccountServiceServer) UpdateUserLimits(context.Context, *UpdateUserLimitsRequest) (*UserLimits, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateUserLimits not implemented")
+}
+func (UnimplementedAccountServiceServer) WatchAccount(*WatchAccountRequest, grpc.ServerStreamingServer[AccountEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchAccount not implemented")
+}
 func (UnimplementedAccountServiceServer) mustEmbedUnimplementedAccountServiceServer() {}
 func (UnimplementedAccountServiceServer) testEmbeddedByValue()                        {}
 
@@ -304,6 +577,24 @@ func _AccountService_DeleteAccountByAccountNumber_Handler(srv interface{}, ctx c
 	return interceptor(ctx, in, info, handler)
 }
 
+func _AccountService_UpdateAccountStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateAccountStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AccountServiceServer).UpdateAccountStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AccountService_UpdateAccountStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AccountServiceServer).UpdateAccountStatus(ctx, req.(*UpdateAccountStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _AccountService_CreateTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(CreateTransactionRequest)
 	if err := dec(in); err != nil {
@@ -340,6 +631,60 @@ func _AccountService_GetTransactionsByAccountId_Handler(srv interface{}, ctx con
 	return interceptor(ctx, in, info, handler)
 }
 
+func _AccountService_PlaceHold_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PlaceHoldRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AccountServiceServer).PlaceHold(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AccountService_PlaceHold_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AccountServiceServer).PlaceHold(ctx, req.(*PlaceHoldRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AccountService_CaptureHold_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CaptureHoldRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AccountServiceServer).CaptureHold(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AccountService_CaptureHold_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AccountServiceServer).CaptureHold(ctx, req.(*CaptureHoldRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AccountService_VoidHold_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VoidHoldRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AccountServiceServer).VoidHold(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AccountService_VoidHold_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AccountServiceServer).VoidHold(ctx, req.(*VoidHoldRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _AccountService_ValidateAccountNumber_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(ValidateAccountNumberRequest)
 	if err := dec(in); err != nil {
@@ -376,6 +721,125 @@ func _AccountService_HasSufficientBalance_Handler(srv interface{}, ctx context.C
 	return interceptor(ctx, in, info, handler)
 }
 
+func _AccountService_ReverseFee_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReverseFeeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AccountServiceServer).ReverseFee(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AccountService_ReverseFee_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AccountServiceServer).ReverseFee(ctx, req.(*ReverseFeeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AccountService_ReverseTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReverseTransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AccountServiceServer).ReverseTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AccountService_ReverseTransaction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AccountServiceServer).ReverseTransaction(ctx, req.(*ReverseTransactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AccountService_GetAccountLimits_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAccountLimitsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AccountServiceServer).GetAccountLimits(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AccountService_GetAccountLimits_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AccountServiceServer).GetAccountLimits(ctx, req.(*GetAccountLimitsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AccountService_UpdateAccountLimits_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateAccountLimitsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AccountServiceServer).UpdateAccountLimits(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AccountService_UpdateAccountLimits_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AccountServiceServer).UpdateAccountLimits(ctx, req.(*UpdateAccountLimitsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AccountService_GetUserLimits_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUserLimitsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AccountServiceServer).GetUserLimits(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AccountService_GetUserLimits_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AccountServiceServer).GetUserLimits(ctx, req.(*GetUserLimitsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AccountService_UpdateUserLimits_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateUserLimitsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AccountServiceServer).UpdateUserLimits(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AccountService_UpdateUserLimits_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AccountServiceServer).UpdateUserLimits(ctx, req.(*UpdateUserLimitsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AccountService_WatchAccount_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchAccountRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AccountServiceServer).WatchAccount(m, &grpc.GenericServerStream[WatchAccountRequest, AccountEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AccountService_WatchAccountServer = grpc.ServerStreamingServer[AccountEvent]
+
 // AccountService_ServiceDesc is the grpc.ServiceDesc for AccountService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -403,6 +867,10 @@ var AccountService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteAccountByAccountNumber",
 			Handler:    _AccountService_DeleteAccountByAccountNumber_Handler,
 		},
+		{
+			MethodName: "UpdateAccountStatus",
+			Handler:    _AccountService_UpdateAccountStatus_Handler,
+		},
 		{
 			MethodName: "CreateTransaction",
 			Handler:    _AccountService_CreateTransaction_Handler,
@@ -411,6 +879,18 @@ var AccountService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetTransactionsByAccountId",
 			Handler:    _AccountService_GetTransactionsByAccountId_Handler,
 		},
+		{
+			MethodName: "PlaceHold",
+			Handler:    _AccountService_PlaceHold_Handler,
+		},
+		{
+			MethodName: "CaptureHold",
+			Handler:    _AccountService_CaptureHold_Handler,
+		},
+		{
+			MethodName: "VoidHold",
+			Handler:    _AccountService_VoidHold_Handler,
+		},
 		{
 			MethodName: "ValidateAccountNumber",
 			Handler:    _AccountService_ValidateAccountNumber_Handler,
@@ -419,7 +899,37 @@ var AccountService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "HasSufficientBalance",
 			Handler:    _AccountService_HasSufficientBalance_Handler,
 		},
+		{
+			MethodName: "ReverseFee",
+			Handler:    _AccountService_ReverseFee_Handler,
+		},
+		{
+			MethodName: "ReverseTransaction",
+			Handler:    _AccountService_ReverseTransaction_Handler,
+		},
+		{
+			MethodName: "GetAccountLimits",
+			Handler:    _AccountService_GetAccountLimits_Handler,
+		},
+		{
+			MethodName: "UpdateAccountLimits",
+			Handler:    _AccountService_UpdateAccountLimits_Handler,
+		},
+		{
+			MethodName: "GetUserLimits",
+			Handler:    _AccountService_GetUserLimits_Handler,
+		},
+		{
+			MethodName: "UpdateUserLimits",
+			Handler:    _AccountService_UpdateUserLimits_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchAccount",
+			Handler:       _AccountService_WatchAccount_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "account.proto",
 }
@@ -3,9 +3,10 @@ package repository
 import (
 	"account/db/sqlc"
 	"account/model"
-	"account/utils"
 	"context"
 	"database/sql"
+	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
@@ -31,10 +32,41 @@ func (r *AccountRepository) WithTx(tx *sql.Tx) *AccountRepository {
 
 func convertToModelAccount(account sqlc.Account) *model.Account {
 	return &model.Account{
-		AccountID:     account.ID,
-		UserID:        account.UserID,
-		Balance:       account.Balance,
-		AccountNumber: int32(account.AccountNumber),
+		AccountID:              account.ID,
+		UserID:                 account.UserID,
+		Balance:                account.Balance,
+		Currency:               account.Currency,
+		AccountNumber:          account.AccountNumber,
+		Version:                account.Version,
+		ProductCode:            account.ProductCode,
+		AccountType:            account.AccountType,
+		MaturesAt:              account.MaturesAt.Time,
+		Status:                 account.Status,
+		StatusChangedAt:        account.StatusChangedAt,
+		ClosureReason:          account.ClosureReason.String,
+		ClosedAt:               account.ClosedAt.Time,
+		CreatedAt:              account.CreatedAt.Time,
+		InterestAccruedThrough: account.InterestAccruedThrough.Time,
+		Held:                   account.Held,
+	}
+}
+
+func convertToModelAccountProduct(product sqlc.AccountProduct) *model.AccountProduct {
+	return &model.AccountProduct{
+		Code:                   product.Code,
+		AccountType:            product.AccountType,
+		DisplayName:            product.DisplayName,
+		MinBalance:             product.MinBalance,
+		MonthlyWithdrawalLimit: product.MonthlyWithdrawalLimit,
+		LockUpDays:             product.LockUpDays,
+		DayCount:               product.DayCount,
+		OverdraftLimit:         product.OverdraftLimit,
+		Limits: model.Limits{
+			MaxSingleDebit:    product.MaxSingleDebit,
+			DailyDebitTotal:   product.DailyDebitTotal,
+			DailyDebitCount:   product.DailyDebitCount,
+			MonthlyDebitTotal: product.MonthlyDebitTotal,
+		},
 	}
 }
 
@@ -43,9 +75,14 @@ func convertToModelTransaction(transaction sqlc.Transaction) *model.Transaction
 		TransactionID:   transaction.ID,
 		AccountID:       transaction.AccountID,
 		Amount:          transaction.Amount,
+		Currency:        transaction.Currency,
 		TransactionType: transaction.TransactionType,
 		Status:          transaction.Status,
 		TransferID:      transaction.TransferID,
+		CausedBy:        transaction.CausedBy,
+		FeeRule:         transaction.FeeRule.String,
+		Memo:            transaction.Memo.String,
+		HoldID:          transaction.HoldID,
 	}
 }
 
@@ -54,9 +91,14 @@ func convertToCreateTransactionParams(transaction *model.Transaction) *sqlc.Crea
 		ID:              transaction.TransactionID,
 		AccountID:       transaction.AccountID,
 		Amount:          transaction.Amount,
+		Currency:        transaction.Currency,
 		TransactionType: transaction.TransactionType,
 		Status:          transaction.Status,
 		TransferID:      transaction.TransferID,
+		CausedBy:        transaction.CausedBy,
+		FeeRule:         sql.NullString{String: transaction.FeeRule, Valid: transaction.FeeRule != ""},
+		Memo:            sql.NullString{String: transaction.Memo, Valid: transaction.Memo != ""},
+		HoldID:          transaction.HoldID,
 	}
 }
 
@@ -65,16 +107,30 @@ func convertToCreateAccountParams(account *model.Account) *sqlc.CreateAccountPar
 		ID:            account.AccountID,
 		UserID:        account.UserID,
 		Balance:       account.Balance,
-		AccountNumber: int64(account.AccountNumber),
+		Currency:      account.Currency,
+		AccountNumber: account.AccountNumber,
+		ProductCode:   account.ProductCode,
+		AccountType:   account.AccountType,
+		MaturesAt:     sql.NullTime{Time: account.MaturesAt, Valid: !account.MaturesAt.IsZero()},
 	}
 }
 
-func (r *AccountRepository) CreateAccount(ctx context.Context, user *model.User) (*model.Account, error) {
+// CreateAccount opens an account of product for user, numbered accountNumber (see NextAccountNumberSerial).
+// The account matures once the lock-up period of the product, counted from now, is over.
+func (r *AccountRepository) CreateAccount(ctx context.Context, user *model.User, accountNumber int64, product *model.AccountProduct) (*model.Account, error) {
+	var maturesAt sql.NullTime
+	if product.LockUpDays > 0 {
+		maturesAt = sql.NullTime{Time: time.Now().AddDate(0, 0, int(product.LockUpDays)), Valid: true}
+	}
 	createdAccount, err := r.queries.CreateAccount(ctx, sqlc.CreateAccountParams{
 		ID:            uuid.New(),
 		UserID:        user.UserID,
 		Balance:       user.Balance,
-		AccountNumber: int64(utils.RandomAccountNumber()),
+		Currency:      user.Currency,
+		AccountNumber: accountNumber,
+		ProductCode:   product.Code,
+		AccountType:   product.AccountType,
+		MaturesAt:     maturesAt,
 	})
 	if err != nil {
 		return nil, err
@@ -82,8 +138,23 @@ func (r *AccountRepository) CreateAccount(ctx context.Context, user *model.User)
 	return convertToModelAccount(createdAccount), nil
 }
 
-func (r *AccountRepository) GetAccountByAccountNumber(ctx context.Context, accountNumber int32) (*model.Account, error) {
-	account, err := r.queries.GetAccountByAccountNumber(ctx, int64(accountNumber))
+// NextAccountNumberSerial returns the next serial of prefix, to build the number of a new account. The prefix is
+// locked until the transaction ends, so that a serial is only handed out again if the transaction is rolled back.
+// It returns sql.ErrNoRows once every serial of the prefix is used.
+func (r *AccountRepository) NextAccountNumberSerial(ctx context.Context, prefix int64) (int64, error) {
+	return r.queries.NextAccountNumberSerial(ctx, int32(prefix))
+}
+
+func (r *AccountRepository) GetAccountProductByCode(ctx context.Context, code string) (*model.AccountProduct, error) {
+	product, err := r.queries.GetAccountProductByCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	return convertToModelAccountProduct(product), nil
+}
+
+func (r *AccountRepository) GetAccountByAccountNumber(ctx context.Context, accountNumber int64) (*model.Account, error) {
+	account, err := r.queries.GetAccountByAccountNumber(ctx, accountNumber)
 	if err != nil {
 		return nil, err
 	}
@@ -98,6 +169,16 @@ func (r *AccountRepository) GetAccountByID(ctx context.Context, id uuid.UUID) (*
 	return convertToModelAccount(account), nil
 }
 
+// GetAccountByIDForUpdate gets the account and locks it until the end of the transaction, so that its status can't
+// change in the meantime. It must be called in a transaction.
+func (r *AccountRepository) GetAccountByIDForUpdate(ctx context.Context, id uuid.UUID) (*model.Account, error) {
+	account, err := r.queries.GetAccountByIDForUpdate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return convertToModelAccount(account), nil
+}
+
 func (r *AccountRepository) GetAccountsByUserID(ctx context.Context, userID uuid.UUID) ([]*model.Account, error) {
 	accounts, err := r.queries.GetAccountsByUserID(ctx, userID)
 	if err != nil {
@@ -111,9 +192,9 @@ func (r *AccountRepository) GetAccountsByUserID(ctx context.Context, userID uuid
 }
 
 // I think AddToAccountBalance is clearer than UpdateAccountBalance cause Update can mean "set" it to this amount instead of adding/substracting to it
-func (r *AccountRepository) AddToAccountBalance(ctx context.Context, accountNumber int32, amount int64) (*model.Account, error) {
+func (r *AccountRepository) AddToAccountBalance(ctx context.Context, accountNumber int64, amount int64) (*model.Account, error) {
 	account, err := r.queries.AddToAccountBalance(ctx, sqlc.AddToAccountBalanceParams{
-		AccountNumber: int64(accountNumber),
+		AccountNumber: accountNumber,
 		Amount:        amount,
 	})
 	if err != nil {
@@ -122,29 +203,90 @@ func (r *AccountRepository) AddToAccountBalance(ctx context.Context, accountNumb
 	return convertToModelAccount(account), nil
 }
 
-func (r *AccountRepository) DeleteAccountByAccountNumber(ctx context.Context, accountNumber int32) error {
-	err := r.queries.DeleteAccountByAccountNumber(ctx, int64(accountNumber))
+// AddToAccountBalanceWithoutActivity adds amount to the balance of an account for a transaction the bank posts itself,
+// such as interest or a fee. Unlike AddToAccountBalance, it doesn't count as activity.
+func (r *AccountRepository) AddToAccountBalanceWithoutActivity(ctx context.Context, accountID uuid.UUID, amount int64) (*model.Account, error) {
+	account, err := r.queries.AddToAccountBalanceWithoutActivity(ctx, sqlc.AddToAccountBalanceWithoutActivityParams{
+		Amount: amount,
+		ID:     accountID,
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	return convertToModelAccount(account), nil
 }
 
-func (r *AccountRepository) CreateTransaction(ctx context.Context, transaction *model.Transaction) (*model.Transaction, error) {
-	createdTransaction, err := r.queries.CreateTransaction(ctx, sqlc.CreateTransactionParams{
-		ID:              uuid.New(),
-		AccountID:       transaction.AccountID,
-		Amount:          transaction.Amount,
-		TransactionType: transaction.TransactionType,
-		Status:          transaction.Status,
-		TransferID:      transaction.TransferID,
+// UpdateAccountStatus moves the account from the status from to the status to. It fails with sql.ErrNoRows if the
+// account doesn't have the status from anymore.
+func (r *AccountRepository) UpdateAccountStatus(ctx context.Context, id uuid.UUID, from, to string) (*model.Account, error) {
+	account, err := r.queries.UpdateAccountStatus(ctx, sqlc.UpdateAccountStatusParams{
+		ID:         id,
+		FromStatus: from,
+		Status:     to,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return convertToModelAccount(account), nil
+}
+
+// CloseAccount closes the account, which has the status from, for reason. Its row and transactions are kept.
+// It fails with sql.ErrNoRows if the account doesn't have the status from anymore or its balance isn't zero.
+func (r *AccountRepository) CloseAccount(ctx context.Context, id uuid.UUID, from string, reason string) (*model.Account, error) {
+	account, err := r.queries.CloseAccount(ctx, sqlc.CloseAccountParams{
+		ID:            id,
+		FromStatus:    from,
+		ClosureReason: sql.NullString{String: reason, Valid: true},
 	})
 	if err != nil {
 		return nil, err
 	}
+	return convertToModelAccount(account), nil
+}
+
+// MarkDormantAccounts flags at most batchSize active accounts without any activity since inactiveSince as dormant,
+// and returns how many it flagged. The accounts locked by a transaction are skipped until the next run.
+func (r *AccountRepository) MarkDormantAccounts(ctx context.Context, inactiveSince time.Time, batchSize int32) (int64, error) {
+	return r.queries.MarkDormantAccounts(ctx, sqlc.MarkDormantAccountsParams{
+		InactiveSince: inactiveSince,
+		BatchSize:     batchSize,
+	})
+}
+
+// CreateTransaction creates the transaction with its ID, or a new one if it has none.
+func (r *AccountRepository) CreateTransaction(ctx context.Context, transaction *model.Transaction) (*model.Transaction, error) {
+	params := convertToCreateTransactionParams(transaction)
+	if params.ID == uuid.Nil {
+		params.ID = uuid.New()
+	}
+	createdTransaction, err := r.queries.CreateTransaction(ctx, *params)
+	if err != nil {
+		return nil, err
+	}
 	return convertToModelTransaction(createdTransaction), err
 }
 
+// CountWithdrawalsSince returns how many debits of the account that didn't fail were created since since, reversed or not.
+// The active holds created since then count as debits, since they are debits that aren't posted yet. The debit
+// capturing a hold is created when the hold was, so that the hold is counted once, on the day its limits were checked.
+func (r *AccountRepository) CountWithdrawalsSince(ctx context.Context, accountID uuid.UUID, since time.Time) (int64, error) {
+	debits, err := r.queries.CountWithdrawalsSince(ctx, sqlc.CountWithdrawalsSinceParams{
+		AccountID: accountID,
+		Since:     since,
+	})
+	if err != nil {
+		return 0, err
+	}
+	holds, err := r.queries.CountActiveHoldsSince(ctx, sqlc.CountActiveHoldsSinceParams{
+		AccountID: accountID,
+		Since:     since,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return debits + holds, nil
+}
+
 func (r *AccountRepository) GetTransactionByID(ctx context.Context, id uuid.UUID) (*model.Transaction, error) {
 	transaction, err := r.queries.GetTransactionByID(ctx, id)
 	if err != nil {
@@ -153,6 +295,19 @@ func (r *AccountRepository) GetTransactionByID(ctx context.Context, id uuid.UUID
 	return convertToModelTransaction(transaction), nil
 }
 
+func (r *AccountRepository) UpdateTransactionStatus(ctx context.Context, id uuid.UUID, status string) error {
+	return r.queries.UpdateTransactionStatus(ctx, sqlc.UpdateTransactionStatusParams{
+		Status: status,
+		ID:     id,
+	})
+}
+
+// SumReversals returns the sum of the REVERSAL transactions of a transaction, 0 if it wasn't reversed. It has the
+// opposite sign of the transaction.
+func (r *AccountRepository) SumReversals(ctx context.Context, transactionID uuid.UUID) (int64, error) {
+	return r.queries.SumReversals(ctx, uuid.NullUUID{UUID: transactionID, Valid: true})
+}
+
 func (r *AccountRepository) GetTransactionsByAccountID(ctx context.Context, accountID uuid.UUID) ([]*model.Transaction, error) {
 	transactions, err := r.queries.GetTransactionsByAccountID(ctx, accountID)
 	if err != nil {
@@ -165,25 +320,47 @@ func (r *AccountRepository) GetTransactionsByAccountID(ctx context.Context, acco
 	return modelTransactions, nil
 }
 
-func (r *AccountRepository) GetOrClaimIdempotencyKey(ctx context.Context, idempotencyKey *model.IdempotencyKey) (*model.IdempotencyKey, error) {
+// GetOrClaimIdempotencyKey claims idempotencyKey for the attempt idempotencyKey.ClaimID, or returns the key as is if
+// another attempt claimed it: the claim is ours if the returned ClaimID is the one we passed.
+// lease is how long the claim holds before another attempt may take over the key if it is still PENDING. It also
+// bounds how long the call waits for a concurrent transaction claiming the same key, after which it fails with the
+// lock_not_available error (55P03). It must be called in a transaction.
+func (r *AccountRepository) GetOrClaimIdempotencyKey(ctx context.Context, idempotencyKey *model.IdempotencyKey, lease time.Duration) (*model.IdempotencyKey, error) {
+	if err := r.queries.SetLockTimeout(ctx, fmt.Sprintf("%dms", lease.Milliseconds())); err != nil {
+		return nil, err
+	}
 	key, err := r.queries.GetOrClaimIdempotencyKey(ctx, sqlc.GetOrClaimIdempotencyKeyParams{
-		KeyID:  idempotencyKey.KeyID,
-		UserID: idempotencyKey.UserID,
+		KeyID:        idempotencyKey.KeyID,
+		UserID:       idempotencyKey.UserID,
+		RpcName:      idempotencyKey.RPCName,
+		RequestHash:  idempotencyKey.RequestHash,
+		ClaimID:      uuid.NullUUID{UUID: idempotencyKey.ClaimID, Valid: true},
+		LeaseSeconds: lease.Seconds(),
 	})
 	if err != nil {
 		return nil, err
 	}
+	// the timeout only applies to the claim, the rest of the transaction waits for its locks as usual
+	if err = r.queries.SetLockTimeout(ctx, "0"); err != nil {
+		return nil, err
+	}
 	return &model.IdempotencyKey{
 		KeyID:           key.KeyID,
 		UserID:          key.UserID,
+		RPCName:         key.RpcName,
+		RequestHash:     key.RequestHash,
+		ClaimID:         key.ClaimID.UUID,
 		Status:          key.Status,
 		ResponseMessage: key.ResponseMessage,
 	}, nil
 }
 
+// UpdateIdempotencyKey sets the outcome of a key claimed by the attempt idempotencyKey.ClaimID.
+// It fails with sql.ErrNoRows if another attempt took the key over.
 func (r *AccountRepository) UpdateIdempotencyKey(ctx context.Context, idempotencyKey *model.IdempotencyKey) (*model.IdempotencyKey, error) {
 	_, err := r.queries.UpdateIdempotencyKey(ctx, sqlc.UpdateIdempotencyKeyParams{
 		KeyID:           idempotencyKey.KeyID,
+		ClaimID:         uuid.NullUUID{UUID: idempotencyKey.ClaimID, Valid: true},
 		Status:          idempotencyKey.Status,
 		ResponseMessage: idempotencyKey.ResponseMessage,
 	})
@@ -201,6 +378,15 @@ func (r *AccountRepository) DeleteIdempotencyKeyByID(ctx context.Context, idempo
 	return nil
 }
 
+// DeleteExpiredIdempotencyKeys deletes at most batchSize idempotency keys that expired before expiredBefore, and returns
+// how many it deleted.
+func (r *AccountRepository) DeleteExpiredIdempotencyKeys(ctx context.Context, expiredBefore time.Time, batchSize int32) (int64, error) {
+	return r.queries.DeleteIdempotencyKeysByExpiredAt(ctx, sqlc.DeleteIdempotencyKeysByExpiredAtParams{
+		ExpiredBefore: expiredBefore,
+		BatchSize:     batchSize,
+	})
+}
+
 func (r *AccountRepository) DeleteIdempotencyKeyByUserID(ctx context.Context, userID uuid.UUID) error {
 	err := r.queries.DeleteIdempotencyKeysByUserID(ctx, userID)
 	if err != nil {
@@ -1,19 +1,27 @@
 package repository
 
 import (
+	"account/config"
 	"account/db/initialize"
 	"account/model"
 	"account/utils"
 	"context"
+	"database/sql"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"github.com/stretchr/testify/require"
 )
 
+// seeded by the migrations
+var checkingProduct = &model.AccountProduct{Code: "CHECKING", AccountType: model.AccountChecking}
+
 func setupTestDB(t *testing.T) (*sqlx.DB, func()) {
-	db := initialize.ConnectDB()
+	cfg, err := config.LoadDB()
+	require.NoError(t, err)
+	db := initialize.ConnectDB(cfg)
 	return db, func() {
 		err := db.Close()
 		require.NoError(t, err)
@@ -43,7 +51,7 @@ func TestCreateAccount_Success(t *testing.T) {
 
 			txRepo := repo.WithTx(tx)
 			user := utils.RandomUser()
-			account, err := txRepo.CreateAccount(context.Background(), user)
+			account, err := txRepo.CreateAccount(context.Background(), user, utils.RandomAccountNumber(), checkingProduct)
 			if err != nil {
 				errChan <- err
 				return
@@ -70,7 +78,7 @@ func TestCreateAccount_Success(t *testing.T) {
 
 	// Cleanup the accounts we created to test
 	for _, account := range createdAccounts {
-		err := repo.DeleteAccountByAccountNumber(context.Background(), account.AccountNumber)
+		err := repo.queries.DeleteAccountByAccountNumber(context.Background(), account.AccountNumber)
 		require.NoError(t, err)
 	}
 }
@@ -95,7 +103,7 @@ func TestCreateAccount_Fail(t *testing.T) {
 
 	// Cleanup the account we created to test
 	// Cleanup the accounts we created to test
-	err = repo.DeleteAccountByAccountNumber(context.Background(), account.AccountNumber)
+	err = repo.queries.DeleteAccountByAccountNumber(context.Background(), account.AccountNumber)
 	require.NoError(t, err)
 }
 
@@ -113,7 +121,7 @@ func TestGetAccountByAccountNumber_Success(t *testing.T) {
 
 	var createdAccount *model.Account
 	user := utils.RandomUser()
-	createdAccount, err = txRepo.CreateAccount(context.Background(), user)
+	createdAccount, err = txRepo.CreateAccount(context.Background(), user, utils.RandomAccountNumber(), checkingProduct)
 	require.NoError(t, err)
 
 	retrievedAccount, err := txRepo.GetAccountByAccountNumber(context.Background(), createdAccount.AccountNumber)
@@ -134,7 +142,7 @@ func TestAddToAccountBalance_Success(t *testing.T) {
 	defer tx.Rollback()
 	txRepo := repo.WithTx(tx)
 	user := utils.RandomUser()
-	createdAccount, err = txRepo.CreateAccount(context.Background(), user)
+	createdAccount, err = txRepo.CreateAccount(context.Background(), user, utils.RandomAccountNumber(), checkingProduct)
 	require.NoError(t, err)
 	err = tx.Commit()
 	require.NoError(t, err)
@@ -182,33 +190,47 @@ func TestAddToAccountBalance_Success(t *testing.T) {
 	require.Equal(t, expectedBalance, finalAccount.Balance)
 
 	// Cleanup the account we created to test
-	err = repo.DeleteAccountByAccountNumber(context.Background(), createdAccount.AccountNumber)
+	err = repo.queries.DeleteAccountByAccountNumber(context.Background(), createdAccount.AccountNumber)
 	require.NoError(t, err)
 }
 
-func TestDeleteAccountByAccountNumber_Success(t *testing.T) {
+// closing an account requires a zero balance and keeps the account
+func TestCloseAccount_Success(t *testing.T) {
 	t.Parallel()
 
 	db, teardown := setupTestDB(t)
 	defer teardown()
 	repo := NewAccountRepository(db)
 
-	var createdAccount *model.Account
 	tx, err := db.BeginTx(context.Background(), nil)
 	require.NoError(t, err)
 	defer tx.Rollback()
 	txRepo := repo.WithTx(tx)
 
 	user := utils.RandomUser()
-	createdAccount, err = txRepo.CreateAccount(context.Background(), user)
+	user.Balance = 100
+	createdAccount, err := txRepo.CreateAccount(context.Background(), user, utils.RandomAccountNumber(), checkingProduct)
 	require.NoError(t, err)
+	require.Equal(t, model.AccountStatusActive, createdAccount.Status)
+
+	_, err = txRepo.CloseAccount(context.Background(), createdAccount.AccountID, model.AccountStatusActive, "test")
+	require.ErrorIs(t, err, sql.ErrNoRows)
 
-	err = txRepo.queries.DeleteAccountByAccountNumber(context.Background(), int64(createdAccount.AccountNumber))
+	_, err = txRepo.AddToAccountBalance(context.Background(), createdAccount.AccountNumber, -user.Balance)
 	require.NoError(t, err)
+	// the status must still be the one the caller checked
+	_, err = txRepo.CloseAccount(context.Background(), createdAccount.AccountID, model.AccountStatusFrozen, "test")
+	require.ErrorIs(t, err, sql.ErrNoRows)
 
-	res, err := repo.GetAccountByAccountNumber(context.Background(), createdAccount.AccountNumber)
-	require.Error(t, err)
-	require.Nil(t, res)
+	closedAccount, err := txRepo.CloseAccount(context.Background(), createdAccount.AccountID, model.AccountStatusActive, "test")
+	require.NoError(t, err)
+	require.Equal(t, model.AccountStatusClosed, closedAccount.Status)
+	require.Equal(t, "test", closedAccount.ClosureReason)
+	require.False(t, closedAccount.ClosedAt.IsZero())
+
+	retrievedAccount, err := txRepo.GetAccountByAccountNumber(context.Background(), createdAccount.AccountNumber)
+	require.NoError(t, err)
+	require.Equal(t, closedAccount, retrievedAccount)
 }
 
 // Create 1 single transaction
@@ -226,7 +248,7 @@ func TestCreateTransaction_Success(t *testing.T) {
 	txRepo := repo.WithTx(tx)
 
 	user := utils.RandomUser()
-	createdAccount, err = txRepo.CreateAccount(context.Background(), user)
+	createdAccount, err = txRepo.CreateAccount(context.Background(), user, utils.RandomAccountNumber(), checkingProduct)
 	require.NoError(t, err)
 
 	transaction := utils.RandomTransaction()
@@ -252,7 +274,7 @@ func TestCreateMultipleTransactions_Success(t *testing.T) {
 	defer tx.Rollback()
 	txRepo := repo.WithTx(tx)
 	user := utils.RandomUser()
-	createdAccount, err = txRepo.CreateAccount(context.Background(), user)
+	createdAccount, err = txRepo.CreateAccount(context.Background(), user, utils.RandomAccountNumber(), checkingProduct)
 	require.NoError(t, err)
 	err = tx.Commit()
 	require.NoError(t, err)
@@ -318,7 +340,7 @@ func TestCreateMultipleTransactions_Success(t *testing.T) {
 			return
 		}
 	}
-	err = txRepo.queries.DeleteAccountByAccountNumber(context.Background(), int64(createdAccount.AccountNumber))
+	err = txRepo.queries.DeleteAccountByAccountNumber(context.Background(), createdAccount.AccountNumber)
 	require.NoError(t, err)
 	err = tx.Commit()
 	require.NoError(t, err)
@@ -337,7 +359,7 @@ func TestCreateTransactionWithTransferID_Success(t *testing.T) {
 	defer tx.Rollback()
 	txRepo := repo.WithTx(tx)
 	user := utils.RandomUser()
-	createdAccount, err = txRepo.CreateAccount(context.Background(), user)
+	createdAccount, err = txRepo.CreateAccount(context.Background(), user, utils.RandomAccountNumber(), checkingProduct)
 	require.NoError(t, err)
 
 	transferID := uuid.New()
@@ -345,6 +367,7 @@ func TestCreateTransactionWithTransferID_Success(t *testing.T) {
 		TransactionID:   uuid.New(),
 		AccountID:       createdAccount.AccountID,
 		Amount:          100,
+		Currency:        createdAccount.Currency,
 		TransactionType: "TRANSFER_CREDIT",
 		Status:          "COMPLETED",
 		TransferID:      uuid.NullUUID{UUID: transferID, Valid: true},
@@ -356,3 +379,84 @@ func TestCreateTransactionWithTransferID_Success(t *testing.T) {
 	require.NotEmpty(t, createdTransaction)
 	require.Equal(t, transaction, createdTransaction)
 }
+
+// expired keys are deleted at most batchSize at a time, and the ones not expired yet are kept.
+func TestDeleteExpiredIdempotencyKeys_Success(t *testing.T) {
+	t.Parallel()
+
+	db, teardown := setupTestDB(t)
+	defer teardown()
+	repo := NewAccountRepository(db)
+
+	tx, err := db.BeginTx(context.Background(), nil)
+	require.NoError(t, err)
+	defer tx.Rollback()
+	txRepo := repo.WithTx(tx)
+
+	// far enough in the past that no other key of the database expired before
+	expiredBefore := time.Date(2000, 1, 2, 0, 0, 0, 0, time.UTC)
+	userID := uuid.New()
+	for _, expiredAt := range []time.Time{
+		expiredBefore.Add(-3 * time.Hour),
+		expiredBefore.Add(-2 * time.Hour),
+		expiredBefore.Add(-time.Hour),
+		expiredBefore.Add(time.Hour),
+	} {
+		_, err = tx.ExecContext(context.Background(),
+			"INSERT INTO idempotency_keys (key_id, user_id, status, response_message, expired_at) VALUES ($1, $2, 'COMPLETED', '', $3)",
+			uuid.NewString(), userID, expiredAt)
+		require.NoError(t, err)
+	}
+
+	deleted, err := txRepo.DeleteExpiredIdempotencyKeys(context.Background(), expiredBefore, 2)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), deleted)
+	deleted, err = txRepo.DeleteExpiredIdempotencyKeys(context.Background(), expiredBefore, 2)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), deleted)
+
+	var remaining int
+	err = tx.QueryRowContext(context.Background(), "SELECT COUNT(*) FROM idempotency_keys WHERE user_id = $1", userID).Scan(&remaining)
+	require.NoError(t, err)
+	require.Equal(t, 1, remaining)
+}
+
+func TestMarkDormantAccounts_Success(t *testing.T) {
+	t.Parallel()
+
+	db, teardown := setupTestDB(t)
+	defer teardown()
+	repo := NewAccountRepository(db)
+
+	tx, err := db.BeginTx(context.Background(), nil)
+	require.NoError(t, err)
+	defer tx.Rollback()
+	txRepo := repo.WithTx(tx)
+
+	// far enough in the past that no other account of the database was inactive since before
+	inactiveSince := time.Date(2000, 1, 2, 0, 0, 0, 0, time.UTC)
+	var inactive, active *model.Account
+	for _, lastActivityAt := range []time.Time{inactiveSince.Add(-time.Hour), inactiveSince.Add(time.Hour)} {
+		account, err := txRepo.CreateAccount(context.Background(), utils.RandomUser(), utils.RandomAccountNumber(), checkingProduct)
+		require.NoError(t, err)
+		_, err = tx.ExecContext(context.Background(),
+			"UPDATE accounts SET last_activity_at = $1 WHERE id = $2", lastActivityAt, account.AccountID)
+		require.NoError(t, err)
+		if inactive == nil {
+			inactive = account
+		} else {
+			active = account
+		}
+	}
+
+	flagged, err := txRepo.MarkDormantAccounts(context.Background(), inactiveSince, 10)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), flagged)
+
+	account, err := txRepo.GetAccountByID(context.Background(), inactive.AccountID)
+	require.NoError(t, err)
+	require.Equal(t, model.AccountStatusDormant, account.Status)
+	account, err = txRepo.GetAccountByID(context.Background(), active.AccountID)
+	require.NoError(t, err)
+	require.Equal(t, model.AccountStatusActive, account.Status)
+}
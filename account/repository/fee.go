@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"account/db/sqlc"
+	"account/model"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func convertToModelFeeRule(rule sqlc.FeeRule) *model.FeeRule {
+	return &model.FeeRule{
+		Code:                rule.Code,
+		Trigger:             rule.Trigger,
+		ProductCode:         rule.ProductCode.String,
+		Currency:            rule.Currency,
+		FixedAmount:         rule.FixedAmount,
+		PercentageBps:       rule.PercentageBps,
+		MinAmount:           rule.MinAmount,
+		MaxAmount:           rule.MaxAmount.Int64,
+		WaiveBalanceAtLeast: rule.WaiveBalanceAtLeast.Int64,
+	}
+}
+
+// ListFeeRules returns the active rules of trigger that apply to an account, ordered by code.
+func (r *AccountRepository) ListFeeRules(ctx context.Context, trigger string, account *model.Account) ([]*model.FeeRule, error) {
+	rules, err := r.queries.ListFeeRules(ctx, sqlc.ListFeeRulesParams{
+		Trigger:     trigger,
+		Currency:    account.Currency,
+		ProductCode: account.ProductCode,
+	})
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*model.FeeRule, len(rules))
+	for i, rule := range rules {
+		res[i] = convertToModelFeeRule(rule)
+	}
+	return res, nil
+}
+
+func (r *AccountRepository) GetFeeRuleByCode(ctx context.Context, code string) (*model.FeeRule, error) {
+	rule, err := r.queries.GetFeeRuleByCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	return convertToModelFeeRule(rule), nil
+}
+
+// MaintenanceFee is a monthly fee of an account, see ListMaintenanceFeesToCharge.
+type MaintenanceFee struct {
+	AccountID uuid.UUID
+	FeeRule   string
+}
+
+// ListMaintenanceFeesToCharge returns at most batchSize monthly fees not charged yet for the month starting on period.
+func (r *AccountRepository) ListMaintenanceFeesToCharge(ctx context.Context, period time.Time, batchSize int32) ([]MaintenanceFee, error) {
+	rows, err := r.queries.ListMaintenanceFeesToCharge(ctx, sqlc.ListMaintenanceFeesToChargeParams{
+		Period:    period,
+		BatchSize: batchSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+	fees := make([]MaintenanceFee, len(rows))
+	for i, row := range rows {
+		fees[i] = MaintenanceFee{AccountID: row.AccountID, FeeRule: row.FeeRule}
+	}
+	return fees, nil
+}
+
+// CreateMaintenanceFeePeriod records that the monthly fee rule was charged on an account for the month starting on
+// period, by the transaction transactionID or waived if it is invalid. It reports false if it was charged already.
+func (r *AccountRepository) CreateMaintenanceFeePeriod(ctx context.Context, accountID uuid.UUID, rule string, period time.Time, transactionID uuid.NullUUID) (bool, error) {
+	n, err := r.queries.CreateMaintenanceFeePeriod(ctx, sqlc.CreateMaintenanceFeePeriodParams{
+		AccountID:     accountID,
+		FeeRule:       rule,
+		Period:        period,
+		TransactionID: transactionID,
+	})
+	return n > 0, err
+}
+
+// GetFeeReversal returns the FEE_REVERSAL of a fee, sql.ErrNoRows if it wasn't reversed.
+func (r *AccountRepository) GetFeeReversal(ctx context.Context, feeID uuid.UUID) (*model.Transaction, error) {
+	transaction, err := r.queries.GetFeeReversal(ctx, uuid.NullUUID{UUID: feeID, Valid: true})
+	if err != nil {
+		return nil, err
+	}
+	return convertToModelTransaction(transaction), nil
+}
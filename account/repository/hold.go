@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"account/db/sqlc"
+	"account/model"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func convertToModelHold(hold sqlc.Hold) *model.Hold {
+	return &model.Hold{
+		HoldID:          hold.ID,
+		AccountID:       hold.AccountID,
+		Amount:          hold.Amount,
+		Currency:        hold.Currency,
+		TransactionType: hold.TransactionType,
+		TransferID:      hold.TransferID,
+		Status:          hold.Status,
+		Captured:        hold.Captured,
+		ExpiresAt:       hold.ExpiresAt,
+		CreatedAt:       hold.CreatedAt.Time,
+	}
+}
+
+// CreateHold creates an ACTIVE hold. It doesn't reserve its amount, see AddToAccountHeld.
+func (r *AccountRepository) CreateHold(ctx context.Context, hold *model.Hold) (*model.Hold, error) {
+	createdHold, err := r.queries.CreateHold(ctx, sqlc.CreateHoldParams{
+		ID:              uuid.New(),
+		AccountID:       hold.AccountID,
+		Amount:          hold.Amount,
+		Currency:        hold.Currency,
+		TransactionType: hold.TransactionType,
+		TransferID:      hold.TransferID,
+		ExpiresAt:       hold.ExpiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return convertToModelHold(createdHold), nil
+}
+
+func (r *AccountRepository) GetHoldByID(ctx context.Context, id uuid.UUID) (*model.Hold, error) {
+	hold, err := r.queries.GetHoldByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return convertToModelHold(hold), nil
+}
+
+// SettleHold gives an ACTIVE hold its final status, with the amount it captured if it is CAPTURED. It returns
+// sql.ErrNoRows if the hold isn't ACTIVE anymore.
+func (r *AccountRepository) SettleHold(ctx context.Context, id uuid.UUID, status string, captured int64) (*model.Hold, error) {
+	hold, err := r.queries.SettleHold(ctx, sqlc.SettleHoldParams{
+		ID:       id,
+		Status:   status,
+		Captured: captured,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return convertToModelHold(hold), nil
+}
+
+// AddToAccountHeld adds amount to the amount held on an account: positive to reserve it, negative to release it.
+func (r *AccountRepository) AddToAccountHeld(ctx context.Context, accountID uuid.UUID, amount int64) (*model.Account, error) {
+	account, err := r.queries.AddToAccountHeld(ctx, sqlc.AddToAccountHeldParams{
+		ID:     accountID,
+		Amount: amount,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return convertToModelAccount(account), nil
+}
+
+// ListExpiredHolds returns at most batchSize ACTIVE holds that expired before expiredBefore, the oldest first.
+func (r *AccountRepository) ListExpiredHolds(ctx context.Context, expiredBefore time.Time, batchSize int32) ([]*model.Hold, error) {
+	holds, err := r.queries.ListExpiredHolds(ctx, sqlc.ListExpiredHoldsParams{
+		ExpiredBefore: expiredBefore,
+		BatchSize:     batchSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*model.Hold, len(holds))
+	for i, hold := range holds {
+		res[i] = convertToModelHold(hold)
+	}
+	return res, nil
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"account/db/sqlc"
+	"account/model"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func convertToModelInterestPosting(posting sqlc.InterestPosting) *model.InterestPosting {
+	return &model.InterestPosting{
+		AccountID:     posting.AccountID,
+		Period:        posting.Period,
+		Accrued:       posting.Accrued,
+		Posted:        posting.Posted,
+		Carried:       posting.Carried,
+		TransactionID: posting.TransactionID,
+	}
+}
+
+// ListProductInterestRates returns the rates of a product, oldest first. It returns none if the product earns no
+// interest.
+func (r *AccountRepository) ListProductInterestRates(ctx context.Context, productCode string) ([]*model.InterestRate, error) {
+	rates, err := r.queries.ListProductInterestRates(ctx, productCode)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*model.InterestRate, len(rates))
+	for i, rate := range rates {
+		res[i] = &model.InterestRate{
+			ProductCode:   rate.ProductCode,
+			EffectiveFrom: rate.EffectiveFrom,
+			AnnualRate:    rate.AnnualRate,
+		}
+	}
+	return res, nil
+}
+
+// ListAccountsToAccrue returns the IDs of at most batchSize accounts earning interest that haven't accrued through the
+// day through yet.
+func (r *AccountRepository) ListAccountsToAccrue(ctx context.Context, through time.Time, batchSize int32) ([]uuid.UUID, error) {
+	return r.queries.ListAccountsToAccrue(ctx, sqlc.ListAccountsToAccrueParams{
+		Through:   through,
+		BatchSize: batchSize,
+	})
+}
+
+// GetEndOfDayBalance returns the balance of an account at dayEnd. The account must be locked, so that its balance and
+// its transactions don't change meanwhile.
+func (r *AccountRepository) GetEndOfDayBalance(ctx context.Context, accountID uuid.UUID, dayEnd time.Time) (int64, error) {
+	return r.queries.GetEndOfDayBalance(ctx, sqlc.GetEndOfDayBalanceParams{
+		DayEnd:    dayEnd,
+		AccountID: accountID,
+	})
+}
+
+func (r *AccountRepository) CreateInterestAccrual(ctx context.Context, accrual *model.InterestAccrual) error {
+	return r.queries.CreateInterestAccrual(ctx, sqlc.CreateInterestAccrualParams{
+		AccountID:   accrual.AccountID,
+		AccrualDate: accrual.AccrualDate,
+		EodBalance:  accrual.EODBalance,
+		AnnualRate:  accrual.AnnualRate,
+		Amount:      accrual.Amount,
+	})
+}
+
+func (r *AccountRepository) SetInterestAccruedThrough(ctx context.Context, accountID uuid.UUID, through time.Time) error {
+	return r.queries.SetInterestAccruedThrough(ctx, sqlc.SetInterestAccruedThroughParams{
+		Through: through,
+		ID:      accountID,
+	})
+}
+
+// InterestPeriod is a month of interest of an account, see ListPeriodsToPost.
+type InterestPeriod struct {
+	AccountID uuid.UUID
+	Period    time.Time
+}
+
+// ListPeriodsToPost returns at most batchSize months before the one of before, oldest first, with interest accrued by
+// an account that isn't posted yet.
+func (r *AccountRepository) ListPeriodsToPost(ctx context.Context, before time.Time, batchSize int32) ([]InterestPeriod, error) {
+	rows, err := r.queries.ListPeriodsToPost(ctx, sqlc.ListPeriodsToPostParams{
+		Before:    before,
+		BatchSize: batchSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+	periods := make([]InterestPeriod, len(rows))
+	for i, row := range rows {
+		periods[i] = InterestPeriod{AccountID: row.AccountID, Period: row.Period}
+	}
+	return periods, nil
+}
+
+// SumInterestAccruals returns the interest accrued by an account over the month starting on period.
+func (r *AccountRepository) SumInterestAccruals(ctx context.Context, accountID uuid.UUID, period time.Time) (string, error) {
+	return r.queries.SumInterestAccruals(ctx, sqlc.SumInterestAccrualsParams{
+		AccountID: accountID,
+		Period:    period,
+	})
+}
+
+// GetLastInterestPosting returns the latest posting of an account before period, sql.ErrNoRows if there is none.
+func (r *AccountRepository) GetLastInterestPosting(ctx context.Context, accountID uuid.UUID, period time.Time) (*model.InterestPosting, error) {
+	posting, err := r.queries.GetLastInterestPosting(ctx, sqlc.GetLastInterestPostingParams{
+		AccountID: accountID,
+		Period:    period,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return convertToModelInterestPosting(posting), nil
+}
+
+// CreateInterestPosting records a posting, and reports false if the period was posted already.
+func (r *AccountRepository) CreateInterestPosting(ctx context.Context, posting *model.InterestPosting) (bool, error) {
+	n, err := r.queries.CreateInterestPosting(ctx, sqlc.CreateInterestPostingParams{
+		AccountID:     posting.AccountID,
+		Period:        posting.Period,
+		Accrued:       posting.Accrued,
+		Posted:        posting.Posted,
+		Carried:       posting.Carried,
+		TransactionID: posting.TransactionID,
+	})
+	return n > 0, err
+}
+
+func (r *AccountRepository) GetInterestPostingsByAccountID(ctx context.Context, accountID uuid.UUID) ([]*model.InterestPosting, error) {
+	postings, err := r.queries.GetInterestPostingsByAccountID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*model.InterestPosting, len(postings))
+	for i, posting := range postings {
+		res[i] = convertToModelInterestPosting(posting)
+	}
+	return res, nil
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"account/db/sqlc"
+	"account/model"
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func convertToModelLimits(limits sqlc.AccountLimit) model.Limits {
+	return model.Limits{
+		MaxSingleDebit:    limits.MaxSingleDebit.Int64,
+		DailyDebitTotal:   limits.DailyDebitTotal.Int64,
+		DailyDebitCount:   limits.DailyDebitCount.Int32,
+		MonthlyDebitTotal: limits.MonthlyDebitTotal.Int64,
+	}
+}
+
+// GetAccountLimits returns the overrides of the limits of an account by level, see model.LimitLevelAccount. The levels
+// without overrides are missing.
+func (r *AccountRepository) GetAccountLimits(ctx context.Context, accountID uuid.UUID) (map[string]model.Limits, error) {
+	rows, err := r.queries.ListAccountLimits(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]model.Limits, len(rows))
+	for _, row := range rows {
+		res[row.Level] = convertToModelLimits(row)
+	}
+	return res, nil
+}
+
+// SetAccountLimits replaces the overrides of the limits of an account at level. The limits that are 0 aren't
+// overridden.
+func (r *AccountRepository) SetAccountLimits(ctx context.Context, accountID uuid.UUID, level string, limits model.Limits) (model.Limits, error) {
+	row, err := r.queries.UpsertAccountLimits(ctx, sqlc.UpsertAccountLimitsParams{
+		AccountID:         accountID,
+		Level:             level,
+		MaxSingleDebit:    sql.NullInt64{Int64: limits.MaxSingleDebit, Valid: limits.MaxSingleDebit > 0},
+		DailyDebitTotal:   sql.NullInt64{Int64: limits.DailyDebitTotal, Valid: limits.DailyDebitTotal > 0},
+		DailyDebitCount:   sql.NullInt32{Int32: limits.DailyDebitCount, Valid: limits.DailyDebitCount > 0},
+		MonthlyDebitTotal: sql.NullInt64{Int64: limits.MonthlyDebitTotal, Valid: limits.MonthlyDebitTotal > 0},
+	})
+	if err != nil {
+		return model.Limits{}, err
+	}
+	return convertToModelLimits(row), nil
+}
+
+// SumWithdrawalsSince returns the total of the debits counted by CountWithdrawalsSince, as a positive amount. The
+// active holds created since then count as well.
+func (r *AccountRepository) SumWithdrawalsSince(ctx context.Context, accountID uuid.UUID, since time.Time) (int64, error) {
+	debits, err := r.queries.SumWithdrawalsSince(ctx, sqlc.SumWithdrawalsSinceParams{
+		AccountID: accountID,
+		Since:     since,
+	})
+	if err != nil {
+		return 0, err
+	}
+	holds, err := r.queries.SumActiveHoldsSince(ctx, sqlc.SumActiveHoldsSinceParams{
+		AccountID: accountID,
+		Since:     since,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return debits + holds, nil
+}
+
+func convertToModelUserLimits(limits sqlc.UserLimit) model.Limits {
+	return model.Limits{
+		MaxSingleDebit:    limits.MaxSingleDebit.Int64,
+		DailyDebitTotal:   limits.DailyDebitTotal.Int64,
+		DailyDebitCount:   limits.DailyDebitCount.Int32,
+		MonthlyDebitTotal: limits.MonthlyDebitTotal.Int64,
+	}
+}
+
+// GetUserLimits returns the limits of the accounts of a user in currency together, or sql.ErrNoRows if none were set.
+func (r *AccountRepository) GetUserLimits(ctx context.Context, userID uuid.UUID, currency string) (model.Limits, error) {
+	row, err := r.queries.GetUserLimits(ctx, sqlc.GetUserLimitsParams{UserID: userID, Currency: currency})
+	if err != nil {
+		return model.Limits{}, err
+	}
+	return convertToModelUserLimits(row), nil
+}
+
+// GetUserLimitsForUpdate is GetUserLimits, locking the limits until the transaction ends. The limits are created
+// without any limit first if none were set, so that there is always a row to lock, and it never returns sql.ErrNoRows.
+func (r *AccountRepository) GetUserLimitsForUpdate(ctx context.Context, userID uuid.UUID, currency string) (model.Limits, error) {
+	err := r.queries.CreateUserLimitsIfNotExists(ctx, sqlc.CreateUserLimitsIfNotExistsParams{UserID: userID, Currency: currency})
+	if err != nil {
+		return model.Limits{}, err
+	}
+	row, err := r.queries.GetUserLimitsForUpdate(ctx, sqlc.GetUserLimitsForUpdateParams{UserID: userID, Currency: currency})
+	if err != nil {
+		return model.Limits{}, err
+	}
+	return convertToModelUserLimits(row), nil
+}
+
+// SetUserLimits replaces the limits of the accounts of a user in currency. The limits that are 0 are removed.
+func (r *AccountRepository) SetUserLimits(ctx context.Context, userID uuid.UUID, currency string, limits model.Limits) (model.Limits, error) {
+	row, err := r.queries.UpsertUserLimits(ctx, sqlc.UpsertUserLimitsParams{
+		UserID:            userID,
+		Currency:          currency,
+		MaxSingleDebit:    sql.NullInt64{Int64: limits.MaxSingleDebit, Valid: limits.MaxSingleDebit > 0},
+		DailyDebitTotal:   sql.NullInt64{Int64: limits.DailyDebitTotal, Valid: limits.DailyDebitTotal > 0},
+		DailyDebitCount:   sql.NullInt32{Int32: limits.DailyDebitCount, Valid: limits.DailyDebitCount > 0},
+		MonthlyDebitTotal: sql.NullInt64{Int64: limits.MonthlyDebitTotal, Valid: limits.MonthlyDebitTotal > 0},
+	})
+	if err != nil {
+		return model.Limits{}, err
+	}
+	return convertToModelUserLimits(row), nil
+}
+
+// SumUserWithdrawalsSince returns the total and the number of the withdrawals of SumWithdrawalsSince of all the
+// accounts of a user in currency, the active holds included.
+func (r *AccountRepository) SumUserWithdrawalsSince(ctx context.Context, userID uuid.UUID, currency string, since time.Time) (int64, int64, error) {
+	debits, err := r.queries.SumUserWithdrawalsSince(ctx, sqlc.SumUserWithdrawalsSinceParams{
+		UserID:   userID,
+		Currency: currency,
+		Since:    since,
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	holds, err := r.queries.SumUserActiveHoldsSince(ctx, sqlc.SumUserActiveHoldsSinceParams{
+		UserID:   userID,
+		Currency: currency,
+		Since:    since,
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return debits.Total + holds.Total, debits.Count + holds.Count, nil
+}
@@ -1,8 +1,13 @@
 package service
 
 import (
+	"account/internal/accountnumber"
 	"account/internal/cache"
+	"account/internal/idempotency"
+	"account/internal/mtls"
+	"account/internal/txn"
 	"account/model"
+	"account/money"
 	"account/repository"
 	"context"
 	"database/sql"
@@ -13,103 +18,165 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
-	"github.com/lib/pq"
 )
 
-var maxRetries = 3
+const defaultMaxRetries = 3
+
+// defaultClosureReason is recorded for the accounts closed without a reason.
+const defaultClosureReason = "closed by the owner"
 
 type AccountService struct {
-	repo *repository.AccountRepository
-	db   *sqlx.DB
+	repo                *repository.AccountRepository
+	db                  *sqlx.DB
+	txRunner            *txn.Runner
+	idempotencyLease    time.Duration
+	accountNumberPrefix int64
+	holdTTL             time.Duration
 }
 
 // r and db should be created in the main function and passed to the service
 // sqlx.DB object maintains a connection pool internally, and will attempt to connect when a connection is first needed.
 func NewAccountService(r *repository.AccountRepository, db *sqlx.DB) *AccountService {
-	return &AccountService{repo: r, db: db}
+	return &AccountService{
+		repo:                r,
+		db:                  db,
+		txRunner:            txn.NewRunner(db, defaultMaxRetries),
+		idempotencyLease:    defaultIdempotencyLease,
+		accountNumberPrefix: accountnumber.DefaultPrefix,
+		holdTTL:             defaultHoldTTL,
+	}
+}
+
+// WithMaxRetries returns a new AccountService that makes at most n attempts for transactions that hit a serialization
+// failure or a deadlock.
+func (s *AccountService) WithMaxRetries(n int) *AccountService {
+	cp := *s
+	cp.txRunner = txn.NewRunner(s.db, n)
+	return &cp
+}
+
+// WithIdempotencyLease returns a new AccountService whose attempts hold the idempotency key of their request for lease.
+func (s *AccountService) WithIdempotencyLease(lease time.Duration) *AccountService {
+	cp := *s
+	cp.idempotencyLease = lease
+	return &cp
+}
+
+// WithAccountNumberPrefix returns a new AccountService that opens the accounts with numbers starting with prefix, the
+// 4-digit code of the branch.
+func (s *AccountService) WithAccountNumberPrefix(prefix int) *AccountService {
+	cp := *s
+	cp.accountNumberPrefix = int64(prefix)
+	return &cp
 }
 
+// WithHoldTTL returns a new AccountService whose holds expire after ttl, unless their request sets when.
+func (s *AccountService) WithHoldTTL(ttl time.Duration) *AccountService {
+	cp := *s
+	cp.holdTTL = ttl
+	return &cp
+}
+
+// CreateAccount opens an account of the product productCode, or of the default product if it is empty, with the
+// balance of user as initial balance. The account is in the currency of user, or in money.DefaultCurrency if it is empty.
 // userID is the ID of the user who initiated the request
-func (s *AccountService) CreateAccount(ctx context.Context, user *model.User, idempotencyKey string, userID uuid.UUID) (*model.Account, error) {
+func (s *AccountService) CreateAccount(ctx context.Context, user *model.User, productCode string, idempotencyKey string, userID uuid.UUID) (*model.Account, error) {
 	// Check if the user ID in the request matches the user ID in the context
 	if userID != user.UserID {
 		log.Printf("CreateAccount: User ID mismatch: %v != %v\n", userID, user.UserID)
 		return nil, model.ErrNotAuthorized
 	}
+	if productCode == "" {
+		productCode = model.DefaultProductCode
+	}
+	if user.Currency == "" {
+		withCurrency := *user
+		withCurrency.Currency = money.DefaultCurrency
+		user = &withCurrency
+	}
+	if !money.Supported(user.Currency) {
+		log.Printf("CreateAccount: Unsupported currency %q\n", user.Currency)
+		return nil, model.ErrUnsupportedCurrency
+	}
 
-	var (
-		attempt int
-		backoff int
-		res     *model.Account
-		err     error
-	)
-
-	backoff = 2
-
-	for attempt = range maxRetries {
-		res, err = s.createAccountTx(ctx, user, idempotencyKey, userID)
-		if err == nil {
-			return res, nil
-		}
-		// Check for serialization failure (Postgres error code 40001: https://www.postgresql.org/docs/current/mvcc-serialization-failure-handling.html)
-		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "40001" {
-			log.Printf("Serialization failure, retrying CreateAccount (attempt %d): %v", attempt+1, err)
-			time.Sleep(time.Duration(backoff) * 100 * time.Millisecond) // Exponential backoff
-			backoff *= 2
-			continue
-		}
-		break // Non-retryable error
+	var res *model.Account
+	err := s.txRunner.Run(ctx, sql.LevelReadCommitted, func(tx *sql.Tx) error {
+		var err error
+		res, err = s.createAccountTx(ctx, tx, user, productCode, idempotencyKey, userID)
+		return err
+	})
+	if err != nil {
+		log.Printf("CreateAccount: Failed to create account: %v\n", err)
+		return nil, err
 	}
-	log.Printf("CreateAccount: Failed to create account after %d attempts: %v\n", attempt+1, err)
-	return nil, err
+	return res, nil
 }
 
 // userID is the ID of the user who initiated the request
-func (s *AccountService) createAccountTx(ctx context.Context, user *model.User, idempotencyKey string, userID uuid.UUID) (*model.Account, error) {
-	var (
-		tx             *sql.Tx
-		err            error
-		createdAccount *model.Account
-	)
+func (s *AccountService) createAccountTx(ctx context.Context, tx *sql.Tx, user *model.User, productCode string, idempotencyKey string, userID uuid.UUID) (*model.Account, error) {
+	// Create a new repository with the transaction
+	txRepo := s.repo.WithTx(tx)
 
-	// Start a transaction
-	tx, err = s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	requestHash, err := idempotency.RequestHash(struct {
+		Balance     int64
+		Currency    string
+		ProductCode string
+	}{user.Balance, user.Currency, productCode})
 	if err != nil {
-		log.Printf("createAccountTx: Failed to begin transaction: %v\n", err)
+		log.Printf("createAccountTx: Failed to hash request: %v\n", err)
 		return nil, model.ErrInternalServer
 	}
-	defer tx.Rollback()
 
-	// Create a new repository with the transaction
-	txRepo := s.repo.WithTx(tx)
-
-	// Try to insert idempotency key with status "PENDING".
-	// the statement will block if another concurrent transactional already to inserts the same key, even if it hasn't committed yet.
-	key, err := txRepo.GetOrClaimIdempotencyKey(ctx, &model.IdempotencyKey{
-		KeyID:  idempotencyKey,
-		UserID: userID,
-		Status: "PENDING",
+	key, ran, err := s.claimIdempotencyKey(ctx, tx, txRepo, &model.IdempotencyKey{
+		KeyID:       idempotencyKey,
+		UserID:      userID,
+		RPCName:     "CreateAccount",
+		RequestHash: requestHash,
 	})
-	if err == nil {
-		if key.Status != "PENDING" { // "PENDING" implies that we (the current transaction) is the first one to create the idempotency key. Otherwise, we blocked while another transaction inserted the same key.
-			log.Printf("createAccountTx: idempotency key already exists: %v\n", err)
-			cachedAccount := &model.Account{}
-			err := json.Unmarshal([]byte(key.ResponseMessage), cachedAccount)
-			if err != nil {
-				log.Printf("createAccountTx: Failed to unmarshal account: %v\n", err)
-				return nil, model.ErrInternalServer
-			}
-			return cachedAccount, nil
+	if err != nil {
+		return nil, err
+	}
+	if !ran {
+		cachedAccount := &model.Account{}
+		err := json.Unmarshal([]byte(key.ResponseMessage), cachedAccount)
+		if err != nil {
+			log.Printf("createAccountTx: Failed to unmarshal account: %v\n", err)
+			return nil, model.ErrInternalServer
 		}
-	} else {
-		log.Printf("createAccountTx: Failed to get idempotency key: %v\n", err)
+		return cachedAccount, nil
+	}
+
+	product, err := txRepo.GetAccountProductByCode(ctx, productCode)
+	if err != nil {
+		log.Printf("createAccountTx: Failed to get product %v: %v\n", productCode, err)
+		if err == sql.ErrNoRows {
+			return nil, failIdempotencyKey(ctx, tx, txRepo, key, model.ErrUnknownProduct)
+		}
+		return nil, model.Internal(err)
+	}
+	if user.Balance < product.MinBalance {
+		log.Printf("createAccountTx: Initial balance below the minimum balance of product %v\n", product.Code)
+		return nil, failIdempotencyKey(ctx, tx, txRepo, key, model.ErrMinimumBalance)
+	}
+
+	serial, err := txRepo.NextAccountNumberSerial(ctx, s.accountNumberPrefix)
+	if err != nil {
+		log.Printf("createAccountTx: Failed to allocate account number: %v\n", err)
+		if err == sql.ErrNoRows {
+			return nil, model.ErrAccountNumbersExhausted
+		}
+		return nil, model.Internal(err)
+	}
+	accountNumber, err := accountnumber.New(s.accountNumberPrefix, serial)
+	if err != nil {
+		log.Printf("createAccountTx: Failed to build account number: %v\n", err)
 		return nil, model.ErrInternalServer
 	}
 
-	createdAccount, err = txRepo.CreateAccount(ctx, user)
+	createdAccount, err := txRepo.CreateAccount(ctx, user, accountNumber, product)
 	if err != nil {
 		log.Printf("createAccountTx: Failed to create account: %v\n", err)
-		return nil, model.ErrInternalServer
+		return nil, model.Internal(err)
 	}
 
 	// Update the idempotency key status
@@ -123,23 +190,20 @@ func (s *AccountService) createAccountTx(ctx context.Context, user *model.User,
 
 	if _, err = txRepo.UpdateIdempotencyKey(ctx, key); err != nil {
 		log.Printf("createAccountTx: Failed to update idempotency key: %v\n", err)
-		return nil, model.ErrInternalServer
-	}
-
-	if err = tx.Commit(); err != nil {
-		log.Printf("createAccountTx: Failed to commit transaction: %v\n", err)
-		return nil, model.ErrInternalServer
+		return nil, model.Internal(err)
 	}
 	return createdAccount, nil
 }
 
+// The transfer service may get the account of any user, to credit it with a transfer, caller being the identity of the
+// calling service.
 // userID is the ID of the user who initiated the request
-func (s *AccountService) GetAccount(ctx context.Context, accountID uuid.UUID, userID uuid.UUID) (*model.Account, error) {
+func (s *AccountService) GetAccount(ctx context.Context, accountID uuid.UUID, userID uuid.UUID, caller string) (*model.Account, error) {
 	var err error
 
 	cachedAcct, err := cache.Get(ctx, accountID)
 	if err == nil {
-		if cachedAcct.UserID != userID {
+		if caller != mtls.TransferService && cachedAcct.UserID != userID {
 			return nil, model.ErrNotAuthorized
 		}
 		log.Printf("\n\nCache hit!\n\n")
@@ -156,13 +220,13 @@ func (s *AccountService) GetAccount(ctx context.Context, accountID uuid.UUID, us
 	if err != nil {
 		log.Printf("GetAccount: Failed to get accounts: %v\n", err)
 		if err == sql.ErrNoRows {
-			return nil, model.ErrInvalidArgument
+			return nil, model.ErrAccountNotFound
 		}
 		return nil, model.ErrInternalServer
 	}
 
 	// Check if the user owns the account
-	if account.UserID != userID {
+	if caller != mtls.TransferService && account.UserID != userID {
 		log.Printf("GetAccount: Unauthorized access attempt for account id %v by user %v\n", accountID, userID)
 		return nil, model.ErrNotAuthorized
 	}
@@ -187,12 +251,17 @@ func (s *AccountService) GetAccountsByUserID(ctx context.Context, userID uuid.UU
 	return accounts, nil
 }
 
-func (s *AccountService) GetAccountByAccountNumber(ctx context.Context, accountNumber int32, userID uuid.UUID) (*model.Account, error) {
+func (s *AccountService) GetAccountByAccountNumber(ctx context.Context, accountNumber int64, userID uuid.UUID) (*model.Account, error) {
+	if !accountnumber.Valid(accountNumber) {
+		log.Printf("GetAccountByAccountNumber: Invalid account number %v\n", accountNumber)
+		return nil, model.ErrInvalidAccountNumber
+	}
+
 	account, err := s.repo.GetAccountByAccountNumber(ctx, accountNumber)
 	if err != nil {
 		log.Printf("GetAccountByAccountNumber: Failed to get account: %v\n", err)
 		if err == sql.ErrNoRows {
-			return nil, model.ErrInvalidArgument
+			return nil, model.ErrAccountNotFound
 		}
 		return nil, model.ErrInternalServer
 	}
@@ -207,289 +276,397 @@ func (s *AccountService) GetAccountByAccountNumber(ctx context.Context, accountN
 	return account, nil
 }
 
-// delete account by account number with exponential backoff retries
+// CloseAccountByAccountNumber closes the account for reason, retrying on serialization failures. The account must have
+// a zero balance, and keeps its row and its transactions.
 // userID is the ID of the user who initiated the request
-func (s *AccountService) DeleteAccountByAccountNumber(ctx context.Context, accountNumber int32, idempotencyKey string, userID uuid.UUID) error {
-	var (
-		attempt int
-		backoff int
-		err     error
-	)
-
-	backoff = 2
-
-	for attempt := range maxRetries {
-		err = s.deleteAccountByAccountNumberTx(ctx, accountNumber, idempotencyKey, userID)
-		if err == nil {
-			return nil
-		}
-		// Check for serialization failure (Postgres error code 40001: https://www.postgresql.org/docs/current/mvcc-serialization-failure-handling.html)
-		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "40001" {
-			log.Printf("Serialization failure, retrying DeleteAccountByAccountNumber (attempt %d): %v", attempt+1, err)
-			time.Sleep(time.Duration(backoff) * 100 * time.Millisecond) // Exponential backoff
-			backoff *= 2
-			continue
-		}
-		break // Non-retryable error
+func (s *AccountService) CloseAccountByAccountNumber(ctx context.Context, accountNumber int64, reason string, idempotencyKey string, userID uuid.UUID) error {
+	if !accountnumber.Valid(accountNumber) {
+		log.Printf("CloseAccountByAccountNumber: Invalid account number %v\n", accountNumber)
+		return model.ErrInvalidAccountNumber
+	}
+	if reason == "" {
+		reason = defaultClosureReason
 	}
-	log.Printf("DeleteAccountByAccountNumber: Failed to delete account after %d attempts: %v\n", attempt+1, err)
-	return err
-}
-
-// use serializable isolation level for the transaction
-// userID is the ID of the user who initiated the request
-func (s *AccountService) deleteAccountByAccountNumberTx(ctx context.Context, accountNumber int32, idempotencyKey string, userID uuid.UUID) error {
-	var (
-		tx      *sql.Tx
-		err     error
-		account *model.Account
-	)
 
-	// Start a transaction
-	tx, err = s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	var account *model.Account
+	err := s.txRunner.Run(ctx, sql.LevelSerializable, func(tx *sql.Tx) error {
+		var err error
+		account, err = s.closeAccountByAccountNumberTx(ctx, tx, accountNumber, reason, idempotencyKey, userID)
+		return err
+	})
 	if err != nil {
-		log.Printf("deleteAccountByAccountNumberTx: Failed to begin transaction: %v\n", err)
-		return model.ErrInternalServer
+		log.Printf("CloseAccountByAccountNumber: Failed to close account: %v\n", err)
+		return err
 	}
-	defer tx.Rollback()
+	go cache.Invalidate(ctx, account.AccountID)
+	return nil
+}
 
+// use serializable isolation level for the transaction. It returns the account, closed by this request or by the
+// request it replays.
+// userID is the ID of the user who initiated the request
+func (s *AccountService) closeAccountByAccountNumberTx(ctx context.Context, tx *sql.Tx, accountNumber int64, reason string, idempotencyKey string, userID uuid.UUID) (*model.Account, error) {
 	// Create a new repository with the transaction
 	txRepo := s.repo.WithTx(tx)
 
 	// First check if account belongs to user
-	account, err = txRepo.GetAccountByAccountNumber(ctx, accountNumber)
+	account, err := txRepo.GetAccountByAccountNumber(ctx, accountNumber)
 	if err != nil {
-		log.Printf("deleteAccountByAccountNumberTx: Failed to get account: %v\n", err)
+		log.Printf("closeAccountByAccountNumberTx: Failed to get account: %v\n", err)
 		if err == sql.ErrNoRows {
-			return model.ErrInvalidArgument
+			return nil, model.ErrAccountNotFound
 		}
-		return model.ErrInternalServer
+		return nil, model.Internal(err)
 	}
 
 	// Check ownership
 	if account.UserID != userID {
-		log.Printf("deleteAccountByAccountNumberTx: Unauthorized deletion attempt for account number %v by user %v\n",
+		log.Printf("closeAccountByAccountNumberTx: Unauthorized closure attempt for account number %v by user %v\n",
 			accountNumber, userID)
-		return model.ErrNotAuthorized
+		return nil, model.ErrNotAuthorized
+	}
+
+	requestHash, err := idempotency.RequestHash(struct {
+		AccountNumber int64
+		Reason        string
+	}{accountNumber, reason})
+	if err != nil {
+		log.Printf("closeAccountByAccountNumberTx: Failed to hash request: %v\n", err)
+		return nil, model.ErrInternalServer
 	}
 
-	key, err := txRepo.GetOrClaimIdempotencyKey(ctx, &model.IdempotencyKey{
-		KeyID:  idempotencyKey,
-		UserID: userID,
-		Status: "PENDING",
+	// the RPC kept its name from when accounts were deleted
+	key, ran, err := s.claimIdempotencyKey(ctx, tx, txRepo, &model.IdempotencyKey{
+		KeyID:       idempotencyKey,
+		UserID:      userID,
+		RPCName:     "DeleteAccountByAccountNumber",
+		RequestHash: requestHash,
 	})
-	if err == nil {
-		if key.Status != "PENDING" { // "PENDING" implies that we (the current transaction) is the first one to create the idempotency key. Otherwise, we blocked while another transaction inserted the same key.
-			log.Printf("deleteAccountByAccountNumberTx: idempotency key already exists: %v\n", err)
-			return nil
-		}
-	} else if err != sql.ErrNoRows {
-		log.Printf("deleteAccountByAccountNumberTx: Failed to get idempotency key: %v\n", err)
-		return model.ErrInternalServer
+	if err != nil {
+		return nil, err
+	}
+	if !ran {
+		return account, nil
 	}
 
-	// Delete the account in the database
-	err = txRepo.DeleteAccountByAccountNumber(ctx, accountNumber)
+	// locked so that no transaction changes the balance or the status between the checks and the closure
+	account, err = txRepo.GetAccountByIDForUpdate(ctx, account.AccountID)
 	if err != nil {
-		log.Printf("deleteAccountByAccountNumberTx: Failed to delete account: %v\n", err)
-		if err == sql.ErrNoRows {
-			return model.ErrInvalidArgument
-		}
-		return model.ErrInternalServer
+		log.Printf("closeAccountByAccountNumberTx: Failed to lock account: %v\n", err)
+		return nil, model.Internal(err)
+	}
+	if account.Status == model.AccountStatusClosed {
+		return nil, failIdempotencyKey(ctx, tx, txRepo, key, model.ErrAccountClosed)
+	}
+	if !model.CanTransition(account.Status, model.AccountStatusClosed) {
+		log.Printf("closeAccountByAccountNumberTx: Account %v can't be closed from %v\n", account.AccountID, account.Status)
+		return nil, failIdempotencyKey(ctx, tx, txRepo, key, model.ErrInvalidStatusTransition)
+	}
+	if account.Balance != 0 {
+		log.Printf("closeAccountByAccountNumberTx: Account %v has a balance of %v\n", account.AccountID, account.Balance)
+		return nil, failIdempotencyKey(ctx, tx, txRepo, key, model.ErrNonZeroBalance)
+	}
+	if account.Held != 0 {
+		log.Printf("closeAccountByAccountNumberTx: Account %v has %v held\n", account.AccountID, account.Held)
+		return nil, failIdempotencyKey(ctx, tx, txRepo, key, model.ErrActiveHolds)
+	}
+
+	closedAccount, err := txRepo.CloseAccount(ctx, account.AccountID, account.Status, reason)
+	if err != nil {
+		log.Printf("closeAccountByAccountNumberTx: Failed to close account: %v\n", err)
+		return nil, model.Internal(err)
 	}
 
 	// Update the idempotency key
 	key.Status = "COMPLETED"
 	key.ResponseMessage = string("success")
 	if _, err = txRepo.UpdateIdempotencyKey(ctx, key); err != nil {
-		log.Printf("deleteAccountByAccountNumberTx: Failed to update idempotency key: %v\n", err)
-		return model.ErrInternalServer
+		log.Printf("closeAccountByAccountNumberTx: Failed to update idempotency key: %v\n", err)
+		return nil, model.Internal(err)
 	}
-
-	// Commit transaction
-	if err := tx.Commit(); err != nil {
-		log.Printf("deleteAccountByAccountNumberTx: Failed to commit transaction: %v\n", err)
-		return model.ErrInternalServer
-	}
-	go cache.Invalidate(ctx, account.AccountID)
-	return nil
+	return closedAccount, nil
 }
 
-// delete the idempotency key given its ID with exponential backoff retries
-// should be used internally only so we don't need to check for ownership
-func (s *AccountService) DeleteIdempotencyKeyByID(ctx context.Context, idempotencyKey string) error {
-	var (
-		attempt int
-		backoff int
-		err     error
-	)
-
-	backoff = 2
+// UpdateAccountStatus lets the owner of an account freeze it, e.g. when a card is lost, and make a frozen or dormant
+// account active again. The other transitions, such as flagging dormant accounts, aren't up to the owner.
+// userID is the ID of the user who initiated the request
+func (s *AccountService) UpdateAccountStatus(ctx context.Context, accountID uuid.UUID, status string, idempotencyKey string, userID uuid.UUID) (*model.Account, error) {
+	if status != model.AccountStatusActive && status != model.AccountStatusFrozen {
+		log.Printf("UpdateAccountStatus: Owners can't set the status %v\n", status)
+		return nil, model.ErrInvalidArgument
+	}
 
-	for attempt := range maxRetries {
-		err = s.deleteIdempotencyKeyByIDTx(ctx, idempotencyKey)
-		if err == nil {
-			return nil
-		}
-		// Check for serialization failure (Postgres error code 40001: https://www.postgresql.org/docs/current/mvcc-serialization-failure-handling.html)
-		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "40001" {
-			log.Printf("Serialization failure, retrying DeleteIdempotencyKeyByID (attempt %d): %v", attempt+1, err)
-			time.Sleep(time.Duration(backoff) * 100 * time.Millisecond) // Exponential backoff
-			backoff *= 2
-			continue
-		}
-		break // Non-retryable error
+	var res *model.Account
+	err := s.txRunner.Run(ctx, sql.LevelReadCommitted, func(tx *sql.Tx) error {
+		var err error
+		res, err = s.updateAccountStatusTx(ctx, tx, accountID, status, idempotencyKey, userID)
+		return err
+	})
+	if err != nil {
+		log.Printf("UpdateAccountStatus: Failed to update account status: %v\n", err)
+		return nil, err
 	}
-	log.Printf("DeleteIdempotencyKeyByID: Failed to delete idempotency key after %d attempts: %v\n", attempt+1, err)
-	return err
+	go cache.Invalidate(ctx, accountID)
+	return res, nil
 }
 
-// use serializable isolation level for the transaction
 // userID is the ID of the user who initiated the request
-func (s *AccountService) deleteIdempotencyKeyByIDTx(ctx context.Context, idempotencyKey string) error {
-	var (
-		tx  *sql.Tx
-		err error
-	)
+func (s *AccountService) updateAccountStatusTx(ctx context.Context, tx *sql.Tx, accountID uuid.UUID, status string, idempotencyKey string, userID uuid.UUID) (*model.Account, error) {
+	// Create a new repository with the transaction
+	txRepo := s.repo.WithTx(tx)
 
-	// Start a transaction
-	tx, err = s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	// locked so that no transaction runs against the status we are leaving
+	account, err := txRepo.GetAccountByIDForUpdate(ctx, accountID)
 	if err != nil {
-		log.Printf("deleteIdempotencyKeyByIDTx: Failed to begin transaction: %v\n", err)
-		return model.ErrInternalServer
+		log.Printf("updateAccountStatusTx: Failed to get account: %v\n", err)
+		if err == sql.ErrNoRows {
+			return nil, model.ErrAccountNotFound
+		}
+		return nil, model.Internal(err)
 	}
-	defer tx.Rollback()
 
-	// Create a new repository with the transaction
-	txRepo := s.repo.WithTx(tx)
+	// Check ownership
+	if account.UserID != userID {
+		log.Printf("updateAccountStatusTx: Unauthorized status change attempt for account %v by user %v\n",
+			accountID, userID)
+		return nil, model.ErrNotAuthorized
+	}
 
-	err = txRepo.DeleteIdempotencyKeyByID(ctx, idempotencyKey)
+	requestHash, err := idempotency.RequestHash(struct {
+		AccountID uuid.UUID
+		Status    string
+	}{accountID, status})
 	if err != nil {
-		log.Printf("deleteIdempotencyKeyByIDTx: Failed to delete idempotency key: %v\n", err)
-		return model.ErrInternalServer
+		log.Printf("updateAccountStatusTx: Failed to hash request: %v\n", err)
+		return nil, model.ErrInternalServer
 	}
 
-	// Commit transaction
-	if err := tx.Commit(); err != nil {
-		log.Printf("deleteIdempotencyKeyByIDTx: Failed to commit transaction: %v\n", err)
-		return model.ErrInternalServer
+	key, ran, err := s.claimIdempotencyKey(ctx, tx, txRepo, &model.IdempotencyKey{
+		KeyID:       idempotencyKey,
+		UserID:      userID,
+		RPCName:     "UpdateAccountStatus",
+		RequestHash: requestHash,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !ran {
+		cachedAccount := &model.Account{}
+		err := json.Unmarshal([]byte(key.ResponseMessage), cachedAccount)
+		if err != nil {
+			log.Printf("updateAccountStatusTx: Failed to unmarshal account: %v\n", err)
+			return nil, model.ErrInternalServer
+		}
+		return cachedAccount, nil
+	}
+
+	updatedAccount := account
+	switch {
+	case account.Status == status: // nothing to do
+	case account.Status == model.AccountStatusPending:
+		return nil, failIdempotencyKey(ctx, tx, txRepo, key, model.ErrAccountNotActive)
+	case account.Status == model.AccountStatusClosed:
+		return nil, failIdempotencyKey(ctx, tx, txRepo, key, model.ErrAccountClosed)
+	case !model.CanTransition(account.Status, status):
+		log.Printf("updateAccountStatusTx: Account %v can't go from %v to %v\n", accountID, account.Status, status)
+		return nil, failIdempotencyKey(ctx, tx, txRepo, key, model.ErrInvalidStatusTransition)
+	default:
+		updatedAccount, err = txRepo.UpdateAccountStatus(ctx, accountID, account.Status, status)
+		if err != nil {
+			log.Printf("updateAccountStatusTx: Failed to update account status: %v\n", err)
+			return nil, model.Internal(err)
+		}
 	}
-	return nil
-}
-
-// create transaction with exponential backoff retries
-// userID is the ID of the user who initiated the request
-func (s *AccountService) CreateTransaction(ctx context.Context, transaction *model.Transaction, idempotencyKey string, userID uuid.UUID) (*model.Transaction, error) {
-	var (
-		attempt int
-		backoff int
-		res     *model.Transaction
-		err     error
-	)
 
-	backoff = 2
+	// Update the idempotency key status
+	key.Status = "COMPLETED"
+	marshalled, err := json.Marshal(updatedAccount)
+	if err != nil {
+		log.Printf("updateAccountStatusTx: Failed to marshal account: %v\n", err)
+		return nil, model.ErrInternalServer
+	}
+	key.ResponseMessage = string(marshalled)
+	if _, err = txRepo.UpdateIdempotencyKey(ctx, key); err != nil {
+		log.Printf("updateAccountStatusTx: Failed to update idempotency key: %v\n", err)
+		return nil, model.Internal(err)
+	}
+	return updatedAccount, nil
+}
 
-	for attempt = range maxRetries {
-		res, err = s.createTransactionTx(ctx, transaction, idempotencyKey, userID)
-		if err == nil {
-			// invalidate cache
-			go cache.Invalidate(ctx, transaction.AccountID)
-			return res, nil
-		}
-		// Check for serialization failure (Postgres error code 40001: https://www.postgresql.org/docs/current/mvcc-serialization-failure-handling.html)
-		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "40001" {
-			log.Printf("Serialization failure, retrying CreateTransaction (attempt %d): %v", attempt+1, err)
-			time.Sleep(time.Duration(backoff) * 100 * time.Millisecond) // Exponential backoff
-			backoff *= 2
-			continue
+// delete the idempotency key given its ID, retrying on serialization failures
+// should be used internally only so we don't need to check for ownership
+func (s *AccountService) DeleteIdempotencyKeyByID(ctx context.Context, idempotencyKey string) error {
+	// use serializable isolation level for the transaction
+	err := s.txRunner.Run(ctx, sql.LevelSerializable, func(tx *sql.Tx) error {
+		if err := s.repo.WithTx(tx).DeleteIdempotencyKeyByID(ctx, idempotencyKey); err != nil {
+			log.Printf("DeleteIdempotencyKeyByID: Failed to delete idempotency key: %v\n", err)
+			return model.Internal(err)
 		}
-		break // Non-retryable error
+		return nil
+	})
+	if err != nil {
+		log.Printf("DeleteIdempotencyKeyByID: Failed to delete idempotency key %v: %v\n", idempotencyKey, err)
+		return err
 	}
-	log.Printf("CreateTransaction: Failed to create transaction after %d attempts: %v\n", attempt+1, err)
-	return nil, err
+	return nil
 }
 
+// create transaction, retrying on serialization failures and deadlocks
+// The transfer service may credit the account of any user with the TRANSFER_CREDIT leg of a transfer, caller being the
+// identity of the calling service. The transaction is then made by the owner of the source account.
 // userID is the ID of the user who initiated the request
-func (s *AccountService) createTransactionTx(ctx context.Context, transaction *model.Transaction, idempotencyKey string, userID uuid.UUID) (*model.Transaction, error) {
+func (s *AccountService) CreateTransaction(ctx context.Context, transaction *model.Transaction, idempotencyKey string, userID uuid.UUID, caller string) (*model.Transaction, error) {
 	var (
-		tx                 *sql.Tx
-		err                error
-		account            *model.Account
-		createdTransaction *model.Transaction
+		res      *model.Transaction
+		postings []posting
 	)
-
-	// Start a transaction
-	tx, err = s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	err := s.txRunner.Run(ctx, sql.LevelReadCommitted, func(tx *sql.Tx) error {
+		var err error
+		res, postings, err = s.createTransactionTx(ctx, tx, transaction, idempotencyKey, userID, caller)
+		return err
+	})
 	if err != nil {
-		log.Printf("createTransactionTx: Failed to begin transaction: %v\n", err)
-		return nil, model.ErrInternalServer
+		log.Printf("CreateTransaction: Failed to create transaction: %v\n", err)
+		return nil, err
 	}
-	defer tx.Rollback()
 
+	// invalidate cache
+	go cache.Invalidate(ctx, transaction.AccountID)
+
+	// published once committed so that watchers never see a transaction that is rolled back.
+	// A watcher missing the event because Redis is down still gets the balance with the next event or snapshot.
+	publish(ctx, postings)
+	return res, nil
+}
+
+// createTransactionTx returns the transaction, and the postings to publish: the transaction and the fees it triggered.
+// There are none if the request was replayed, since the events were published with the original request.
+// userID is the ID of the user who initiated the request
+func (s *AccountService) createTransactionTx(ctx context.Context, tx *sql.Tx, transaction *model.Transaction, idempotencyKey string, userID uuid.UUID, caller string) (*model.Transaction, []posting, error) {
 	// Create a new repository with the transaction
 	txRepo := s.repo.WithTx(tx)
 
-	// First check if account belongs to user
-	account, err = txRepo.GetAccountByID(ctx, transaction.AccountID)
+	// First check if account belongs to user. It is locked so that its status can't change until we commit.
+	account, err := txRepo.GetAccountByIDForUpdate(ctx, transaction.AccountID)
 	if err != nil {
 		log.Printf("createTransactionTx: Failed to get account: %v\n", err)
 		if err == sql.ErrNoRows {
-			return nil, model.ErrInvalidArgument
+			return nil, nil, model.ErrAccountNotFound
 		}
-		return nil, model.ErrInternalServer
+		return nil, nil, model.Internal(err)
 	}
 
-	// Check ownership
-	if account.UserID != userID {
+	// Check ownership. A transfer may credit the account of another user.
+	isTransferCredit := caller == mtls.TransferService && transaction.TransactionType == "TRANSFER_CREDIT"
+	if !isTransferCredit && account.UserID != userID {
 		log.Printf("createTransactionTx: Unauthorized balance modification attempt for account %v by user %v\n",
 			account.AccountID, userID)
-		return nil, model.ErrNotAuthorized
+		return nil, nil, model.ErrNotAuthorized
+	}
+
+	requestHash, err := idempotency.RequestHash(struct {
+		AccountID       uuid.UUID
+		Amount          int64
+		Currency        string
+		TransactionType string
+		TransferID      uuid.NullUUID
+	}{transaction.AccountID, transaction.Amount, transaction.Currency, transaction.TransactionType, transaction.TransferID})
+	if err != nil {
+		log.Printf("createTransactionTx: Failed to hash request: %v\n", err)
+		return nil, nil, model.ErrInternalServer
 	}
 
-	// Try to insert idempotency key with status "PENDING".
-	// the statement will block if another concurrent transactional already to inserts the same key, even if it hasn't committed yet.
-	key, err := txRepo.GetOrClaimIdempotencyKey(ctx, &model.IdempotencyKey{
-		KeyID:  idempotencyKey,
-		UserID: userID,
-		Status: "PENDING",
+	key, ran, err := s.claimIdempotencyKey(ctx, tx, txRepo, &model.IdempotencyKey{
+		KeyID:       idempotencyKey,
+		UserID:      userID,
+		RPCName:     "CreateTransaction",
+		RequestHash: requestHash,
 	})
-	if err == nil {
-		if key.Status != "PENDING" { // "PENDING" implies that we (the current transaction) is the first one to create the idempotency key. Otherwise, we blocked while another transaction inserted the same key.
-			log.Printf("createTransactionTx: idempotency key already exists: %v\n", key)
-			cachedTransaction := &model.Transaction{}
-			err := json.Unmarshal([]byte(key.ResponseMessage), cachedTransaction)
-			if err != nil {
-				log.Printf("createTransactionTx: Failed to unmarshal transaction: %v\n", err)
-				return nil, model.ErrInternalServer
-			}
-			return cachedTransaction, nil
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ran {
+		cachedTransaction := &model.Transaction{}
+		err := json.Unmarshal([]byte(key.ResponseMessage), cachedTransaction)
+		if err != nil {
+			log.Printf("createTransactionTx: Failed to unmarshal transaction: %v\n", err)
+			return nil, nil, model.ErrInternalServer
 		}
-	} else {
-		log.Printf("createTransactionTx: Failed to get idempotency key: %v\n", err)
-		return nil, model.ErrInternalServer
+		return cachedTransaction, nil, nil
 	}
 
 	// Check if the transaction amount is valid
 	if transaction.Amount == 0 {
 		log.Printf("createTransactionTx: Invalid transaction amount = 0\n")
-		return nil, model.ErrInvalidArgument
+		return nil, nil, failIdempotencyKey(ctx, tx, txRepo, key, model.ErrInvalidArgument)
+	}
+	// amounts are never converted: an account only takes transactions in its own currency
+	if transaction.Currency != account.Currency {
+		log.Printf("createTransactionTx: Transaction in %q on account %v in %v\n", transaction.Currency, account.AccountID, account.Currency)
+		return nil, nil, failIdempotencyKey(ctx, tx, txRepo, key, model.ErrCurrencyMismatch)
+	}
+	if err = checkAccountStatus(account, transaction.Amount); err != nil {
+		log.Printf("createTransactionTx: Account %v is %v\n", account.AccountID, account.Status)
+		return nil, nil, failIdempotencyKey(ctx, tx, txRepo, key, err)
+	}
+	// the lock-up period only depends on the account, which is checked before anything is written
+	if transaction.Amount < 0 && time.Now().Before(account.MaturesAt) {
+		log.Printf("createTransactionTx: Debit of account %v before it matures on %v\n", account.AccountID, account.MaturesAt)
+		return nil, nil, failIdempotencyKey(ctx, tx, txRepo, key, model.ErrAccountLocked)
 	}
 
-	// Create the transaction in the database
-	createdTransaction, err = txRepo.CreateTransaction(ctx, transaction)
+	// Create the transaction in the database, with an ID of ours whatever the caller set. It is COMPLETED since the
+	// balance is updated by the same database transaction.
+	newTransaction := *transaction
+	newTransaction.TransactionID = uuid.New()
+	newTransaction.Status = "COMPLETED"
+	createdTransaction, err := txRepo.CreateTransaction(ctx, &newTransaction)
 	if err != nil {
 		log.Printf("createTransactionTx: Failed to create transaction: %v\n", err)
-		return nil, model.ErrInternalServer
+		return nil, nil, model.Internal(err)
 	}
 
 	// Update the account balance in the database
-	_, err = txRepo.AddToAccountBalance(ctx, account.AccountNumber, transaction.Amount)
+	updatedAccount, err := txRepo.AddToAccountBalance(ctx, account.AccountNumber, transaction.Amount)
 	if err != nil {
 		log.Printf("createTransactionTx: Failed to update balance: %v\n", err)
 		if err == sql.ErrNoRows {
-			return nil, model.ErrInvalidArgument
+			return nil, nil, failIdempotencyKey(ctx, tx, txRepo, key, model.ErrAccountNotFound)
 		}
-		return nil, model.ErrInternalServer
+		return nil, nil, model.Internal(err)
+	}
+	postings := []posting{{transaction: createdTransaction, account: updatedAccount}}
+	if transaction.Amount < 0 {
+		product, err := txRepo.GetAccountProductByCode(ctx, account.ProductCode)
+		if err != nil {
+			log.Printf("createTransactionTx: Failed to get product %v: %v\n", account.ProductCode, err)
+			return nil, nil, model.Internal(err)
+		}
+		// checked on the updated row rather than on account, which concurrent transactions may have changed since we read it.
+		// The amount reserved by the holds of the account can't be debited.
+		if updatedAccount.Available() < -product.OverdraftLimit {
+			log.Printf("createTransactionTx: Insufficient funds on account %v\n", account.AccountID)
+			// the retries of the request get the same error, even if the account was credited since
+			return nil, nil, failIdempotencyKey(ctx, tx, txRepo, key, model.ErrInsufficientFunds)
+		}
+		if err = checkWithdrawalRules(ctx, txRepo, updatedAccount, product); err != nil {
+			if errors.Is(err, model.ErrInternalServer) {
+				return nil, nil, err
+			}
+			return nil, nil, failIdempotencyKey(ctx, tx, txRepo, key, err)
+		}
+		if err = checkLimits(ctx, txRepo, updatedAccount, product, -transaction.Amount); err != nil {
+			if errors.Is(err, model.ErrInternalServer) {
+				return nil, nil, err
+			}
+			return nil, nil, failIdempotencyKey(ctx, tx, txRepo, key, err)
+		}
+
+		fees, err := chargeFees(ctx, txRepo, updatedAccount, product, createdTransaction)
+		if err != nil {
+			return nil, nil, err
+		}
+		postings = append(postings, fees...)
 	}
 
 	// Update the idempotency key status
@@ -497,28 +674,69 @@ func (s *AccountService) createTransactionTx(ctx context.Context, transaction *m
 	marshalled, err := json.Marshal(createdTransaction)
 	if err != nil {
 		log.Printf("createTransactionTx: Failed to marshal transaction: %v\n", err)
-		return nil, model.ErrInternalServer
+		return nil, nil, model.ErrInternalServer
 	}
 	key.ResponseMessage = string(marshalled)
 	if _, err = txRepo.UpdateIdempotencyKey(ctx, key); err != nil {
 		log.Printf("createTransactionTx: Failed to update idempotency key: %v\n", err)
-		return nil, model.ErrInternalServer
+		return nil, nil, model.Internal(err)
 	}
+	return createdTransaction, postings, nil
+}
 
-	// Commit transaction
-	if err = tx.Commit(); err != nil {
-		log.Printf("createTransactionTx: Failed to commit transaction: %v\n", err)
-		return nil, model.ErrInternalServer
+// checkAccountStatus returns why an account with its status can't take a transaction of amount, if it can't.
+// Frozen and dormant accounts can still be credited.
+func checkAccountStatus(account *model.Account, amount int64) error {
+	switch account.Status {
+	case model.AccountStatusPending:
+		return model.ErrAccountNotActive
+	case model.AccountStatusClosed:
+		return model.ErrAccountClosed
+	case model.AccountStatusFrozen:
+		if amount < 0 {
+			return model.ErrAccountFrozen
+		}
+	case model.AccountStatusDormant:
+		if amount < 0 {
+			return model.ErrAccountDormant
+		}
 	}
+	return nil
+}
 
-	return createdTransaction, nil
+// checkWithdrawalRules checks the rules of product, the product of account, the account as updated by a debit or a hold
+// that was already created, and returns the rule the debit breaks.
+// It must be called after the balance was updated: the row lock taken by the update makes the concurrent debits of the
+// account wait for our commit, so that they count the debit created by this transaction.
+func checkWithdrawalRules(ctx context.Context, txRepo *repository.AccountRepository, account *model.Account, product *model.AccountProduct) error {
+	// products with an overdraft have no minimum balance
+	if product.MinBalance > 0 && account.Available() < product.MinBalance {
+		log.Printf("checkWithdrawalRules: Balance of account %v below the minimum balance\n", account.AccountID)
+		return model.ErrMinimumBalance
+	}
+
+	if product.MonthlyWithdrawalLimit > 0 {
+		now := time.Now().UTC()
+		monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		withdrawals, err := txRepo.CountWithdrawalsSince(ctx, account.AccountID, monthStart)
+		if err != nil {
+			log.Printf("checkWithdrawalRules: Failed to count withdrawals: %v\n", err)
+			return model.Internal(err)
+		}
+		// the count includes the debit being checked
+		if withdrawals > int64(product.MonthlyWithdrawalLimit) {
+			log.Printf("checkWithdrawalRules: Monthly withdrawal limit of account %v exceeded\n", account.AccountID)
+			return model.ErrWithdrawalLimitExceeded
+		}
+	}
+	return nil
 }
 
 // // I think AddToAccountBalance is clearer than UpdateAccountBalance cause Update can mean "set" it to this amount instead of adding/substracting to it
 // // AddToAccountBalance adds the given amount (could be negative) to the account balance and retries on serialization failure
 // // with exponential backoff. It returns the updated account.
 // // userID is the ID of the user who initiated the request
-// func (s *AccountService) AddToAccountBalance(ctx context.Context, accountNumber int32, amount int64, idempotencyKey string, userID uuid.UUID) (*model.Account, error) {
+// func (s *AccountService) AddToAccountBalance(ctx context.Context, accountNumber int64, amount int64, idempotencyKey string, userID uuid.UUID) (*model.Account, error) {
 // 	var (
 // 		err error
 // 		res *model.Account
@@ -541,7 +759,7 @@ func (s *AccountService) createTransactionTx(ctx context.Context, transaction *m
 // }
 
 // // userID is the ID of the user who initiated the request
-// func (s *AccountService) addToAccountBalanceTx(ctx context.Context, accountNumber int32, amount int64, idempotencyKey string, userID uuid.UUID) (*model.Account, error) {
+// func (s *AccountService) addToAccountBalanceTx(ctx context.Context, accountNumber int64, amount int64, idempotencyKey string, userID uuid.UUID) (*model.Account, error) {
 // 	var (
 // 		tx             *sql.Tx
 // 		err            error
@@ -634,7 +852,7 @@ func (s *AccountService) GetTransactionsByAccountID(ctx context.Context, account
 	if err != nil {
 		log.Printf("GetTransactionsByAccountID: Failed to get account: %v\n", err)
 		if err == sql.ErrNoRows {
-			return nil, model.ErrInvalidArgument
+			return nil, model.ErrAccountNotFound
 		}
 		return nil, model.ErrInternalServer
 	}
@@ -659,7 +877,12 @@ func (s *AccountService) GetTransactionsByAccountID(ctx context.Context, account
 
 // Check if an account exists and belongs to the given user
 // userID is the ID of the user who initiated the request
-func (s *AccountService) ValidateAccountNumber(ctx context.Context, accountNumber int32, userID uuid.UUID) (bool, error) {
+func (s *AccountService) ValidateAccountNumber(ctx context.Context, accountNumber int64, userID uuid.UUID) (bool, error) {
+	// a malformed account number can't be one of the user's accounts
+	if !accountnumber.Valid(accountNumber) {
+		return false, nil
+	}
+
 	account, err := s.repo.GetAccountByAccountNumber(ctx, accountNumber)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -674,17 +897,24 @@ func (s *AccountService) ValidateAccountNumber(ctx context.Context, accountNumbe
 		return false, nil
 	}
 
-	return true, nil
+	// closed accounts can't take transfers anymore
+	return account.Status != model.AccountStatusClosed, nil
 }
 
 // Function to check if account has sufficient balance and belongs to the user
+// amount must be in the currency of the account.
 // userID is the ID of the user who initiated the request
-func (s *AccountService) HasSufficientBalance(ctx context.Context, accountNumber int32, amount int64, userID uuid.UUID) (bool, error) {
+func (s *AccountService) HasSufficientBalance(ctx context.Context, accountNumber int64, amount money.Money, userID uuid.UUID) (bool, error) {
+	if !accountnumber.Valid(accountNumber) {
+		log.Printf("HasSufficientBalance: Invalid account number %v\n", accountNumber)
+		return false, model.ErrInvalidAccountNumber
+	}
+
 	account, err := s.repo.GetAccountByAccountNumber(ctx, accountNumber)
 	if err != nil {
 		log.Printf("HasSufficientBalance: Failed to get account: %v\n", err)
 		if err == sql.ErrNoRows {
-			return false, model.ErrInvalidArgument
+			return false, model.ErrAccountNotFound
 		}
 		return false, model.ErrInternalServer
 	}
@@ -696,5 +926,29 @@ func (s *AccountService) HasSufficientBalance(ctx context.Context, accountNumber
 		return false, model.ErrNotAuthorized
 	}
 
-	return account.Balance >= amount, nil
+	if amount.Currency != account.Currency {
+		log.Printf("HasSufficientBalance: Amount in %q on account %v in %v\n", amount.Currency, accountNumber, account.Currency)
+		return false, model.ErrCurrencyMismatch
+	}
+
+	if err = checkAccountStatus(account, -amount.Units); err != nil {
+		log.Printf("HasSufficientBalance: Account %v is %v\n", accountNumber, account.Status)
+		return false, err
+	}
+
+	product, err := s.repo.GetAccountProductByCode(ctx, account.ProductCode)
+	if err != nil {
+		log.Printf("HasSufficientBalance: Failed to get product %v: %v\n", account.ProductCode, err)
+		return false, model.ErrInternalServer
+	}
+
+	// the debit must leave at least the minimum balance of the account, or stay within its overdraft, without the amount
+	// reserved by the holds of the account
+	remaining, err := money.Money{Currency: account.Currency, Units: account.Available()}.Sub(amount)
+	if err != nil {
+		// only overflows for amounts no account can hold
+		log.Printf("HasSufficientBalance: Failed to subtract %v from the balance of account %v: %v\n", amount, accountNumber, err)
+		return false, nil
+	}
+	return remaining.Units >= product.MinBalance-product.OverdraftLimit, nil
 }
@@ -1,15 +1,22 @@
 package service
 
 import (
+	"account/internal/accountnumber"
+	"account/internal/fee"
+	"account/internal/interest"
+	"account/internal/mtls"
 	"account/model"
+	"account/money"
 	"account/repository"
 	"account/utils"
 	"context"
 	"fmt"
 	"log"
+	"math/big"
 	"os"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
@@ -58,7 +65,7 @@ func TestCreateAccount_Success(t *testing.T) {
 		go func() {
 			key := utils.RandomIdempotencyKey()
 			user := utils.RandomUser()
-			account, err := service.CreateAccount(context.Background(), user, key, user.UserID)
+			account, err := service.CreateAccount(context.Background(), user, "", key, user.UserID)
 			if err != nil {
 				errChan <- err
 				return
@@ -77,13 +84,10 @@ func TestCreateAccount_Success(t *testing.T) {
 		require.NotEmpty(t, account)
 		require.NotEqual(t, uuid.Nil, account.AccountID)
 		require.NotZero(t, account.AccountNumber)
+		require.True(t, accountnumber.Valid(account.AccountNumber))
 
 		// Cleanup the accounts we created to test
-		_key := utils.RandomIdempotencyKey()
-		err = service.DeleteAccountByAccountNumber(context.Background(), account.AccountNumber, _key, account.UserID)
-		require.NoError(t, err)
-		err = service.DeleteIdempotencyKeyByID(context.Background(), _key)
-		require.NoError(t, err)
+		deleteAccount(t, account.AccountID)
 		err = service.DeleteIdempotencyKeyByID(context.Background(), key)
 		require.NoError(t, err)
 	}
@@ -92,7 +96,7 @@ func TestCreateAccount_Success(t *testing.T) {
 func TestGetAccountByAccountNumber_Success(t *testing.T) {
 	user := utils.RandomUser()
 	key := utils.RandomIdempotencyKey()
-	createdAccount, err := service.CreateAccount(context.Background(), user, key, user.UserID)
+	createdAccount, err := service.CreateAccount(context.Background(), user, "", key, user.UserID)
 	require.NoError(t, err)
 
 	// delete the idempotency key we used to create the account
@@ -104,19 +108,33 @@ func TestGetAccountByAccountNumber_Success(t *testing.T) {
 	require.Equal(t, createdAccount, retrievedAccount)
 
 	// Cleanup the account we created to test
-	key = utils.RandomIdempotencyKey()
-	err = service.DeleteAccountByAccountNumber(context.Background(), createdAccount.AccountNumber, key, user.UserID)
-	require.NoError(t, err)
+	deleteAccount(t, createdAccount.AccountID)
+}
 
-	err = service.DeleteIdempotencyKeyByID(context.Background(), key)
+// malformed account numbers are rejected before looking the account up
+func TestAccountNumberValidation_Fail(t *testing.T) {
+	user := utils.RandomUser()
+	accountNumber := utils.RandomAccountNumber()
+	typo := accountNumber - accountNumber%10 + (accountNumber%10+1)%10 // wrong check digit
+
+	_, err := service.GetAccountByAccountNumber(context.Background(), typo, user.UserID)
+	require.ErrorIs(t, err, model.ErrInvalidAccountNumber)
+	err = service.CloseAccountByAccountNumber(context.Background(), typo, "", utils.RandomIdempotencyKey(), user.UserID)
+	require.ErrorIs(t, err, model.ErrInvalidAccountNumber)
+	_, err = service.HasSufficientBalance(context.Background(), typo, money.Money{Currency: money.DefaultCurrency, Units: 1}, user.UserID)
+	require.ErrorIs(t, err, model.ErrInvalidAccountNumber)
+	valid, err := service.ValidateAccountNumber(context.Background(), typo, user.UserID)
 	require.NoError(t, err)
+	require.False(t, valid)
 }
 
-func TestDeleteAccountByAccountNumber_Success(t *testing.T) {
+// closing an account requires a zero balance, and the closed account and its transactions are kept
+func TestCloseAccountByAccountNumber_Success(t *testing.T) {
 	user := utils.RandomUser()
+	user.Balance = 100
 	key := utils.RandomIdempotencyKey()
 
-	createdAccount, err := service.CreateAccount(context.Background(), user, key, user.UserID)
+	createdAccount, err := service.CreateAccount(context.Background(), user, "", key, user.UserID)
 	require.NoError(t, err)
 
 	// delete the idempotency key we used to create the account
@@ -124,22 +142,117 @@ func TestDeleteAccountByAccountNumber_Success(t *testing.T) {
 	require.NoError(t, err)
 
 	key = utils.RandomIdempotencyKey()
-	err = service.DeleteAccountByAccountNumber(context.Background(), createdAccount.AccountNumber, key, user.UserID)
+	err = service.CloseAccountByAccountNumber(context.Background(), createdAccount.AccountNumber, "", key, user.UserID)
+	require.ErrorIs(t, err, model.ErrNonZeroBalance)
+	err = service.DeleteIdempotencyKeyByID(context.Background(), key)
 	require.NoError(t, err)
 
+	withdrawal := utils.RandomTransaction()
+	withdrawal.AccountID = createdAccount.AccountID
+	withdrawal.TransactionType = "DEBIT"
+	withdrawal.Amount = -user.Balance
+	key = utils.RandomIdempotencyKey()
+	_, err = service.CreateTransaction(context.Background(), withdrawal, key, user.UserID, mtls.APIGateway)
+	require.NoError(t, err)
+	err = service.DeleteIdempotencyKeyByID(context.Background(), key)
+	require.NoError(t, err)
+
+	key = utils.RandomIdempotencyKey()
+	err = service.CloseAccountByAccountNumber(context.Background(), createdAccount.AccountNumber, "moving abroad", key, user.UserID)
+	require.NoError(t, err)
 	err = service.DeleteIdempotencyKeyByID(context.Background(), key)
 	require.NoError(t, err)
 
 	res, err := service.GetAccountByAccountNumber(context.Background(), createdAccount.AccountNumber, user.UserID)
-	require.Error(t, err)
-	require.Nil(t, res)
+	require.NoError(t, err)
+	require.Equal(t, model.AccountStatusClosed, res.Status)
+	require.Equal(t, "moving abroad", res.ClosureReason)
+	transactions, err := service.GetTransactionsByAccountID(context.Background(), createdAccount.AccountID, user.UserID)
+	require.NoError(t, err)
+	require.Len(t, transactions, 1)
+
+	// closed accounts can't take any transaction
+	deposit := utils.RandomTransaction()
+	deposit.AccountID = createdAccount.AccountID
+	deposit.TransactionType = "CREDIT"
+	deposit.Amount = 1
+	key = utils.RandomIdempotencyKey()
+	_, err = service.CreateTransaction(context.Background(), deposit, key, user.UserID, mtls.APIGateway)
+	require.ErrorIs(t, err, model.ErrAccountClosed)
+	err = service.DeleteIdempotencyKeyByID(context.Background(), key)
+	require.NoError(t, err)
+
+	// Cleanup the account and transaction we created to test
+	deleteAccount(t, createdAccount.AccountID)
+}
+
+// frozen and dormant accounts can be credited but not debited until their owner makes them active again
+func TestUpdateAccountStatus_Success(t *testing.T) {
+	user := utils.RandomUser()
+	user.Balance = 100
+	key := utils.RandomIdempotencyKey()
+	createdAccount, err := service.CreateAccount(context.Background(), user, "", key, user.UserID)
+	require.NoError(t, err)
+	err = service.DeleteIdempotencyKeyByID(context.Background(), key)
+	require.NoError(t, err)
+
+	withdrawal := utils.RandomTransaction()
+	withdrawal.AccountID = createdAccount.AccountID
+	withdrawal.TransactionType = "DEBIT"
+	withdrawal.Amount = -1
+	deposit := utils.RandomTransaction()
+	deposit.AccountID = createdAccount.AccountID
+	deposit.TransactionType = "CREDIT"
+	deposit.Amount = 1
+	createTransaction := func(transaction *model.Transaction) error {
+		key := utils.RandomIdempotencyKey()
+		_, err := service.CreateTransaction(context.Background(), transaction, key, user.UserID, mtls.APIGateway)
+		require.NoError(t, service.DeleteIdempotencyKeyByID(context.Background(), key))
+		return err
+	}
+	updateStatus := func(status string) *model.Account {
+		key := utils.RandomIdempotencyKey()
+		account, err := service.UpdateAccountStatus(context.Background(), createdAccount.AccountID, status, key, user.UserID)
+		require.NoError(t, err)
+		require.NoError(t, service.DeleteIdempotencyKeyByID(context.Background(), key))
+		return account
+	}
+
+	account := updateStatus(model.AccountStatusFrozen)
+	require.Equal(t, model.AccountStatusFrozen, account.Status)
+	require.ErrorIs(t, createTransaction(withdrawal), model.ErrAccountFrozen)
+	require.NoError(t, createTransaction(deposit))
+
+	account = updateStatus(model.AccountStatusActive)
+	require.Equal(t, model.AccountStatusActive, account.Status)
+	require.NoError(t, createTransaction(withdrawal))
+
+	// accounts are flagged as dormant by the janitor
+	_, err = db.ExecContext(context.Background(), "UPDATE accounts SET status = 'DORMANT' WHERE id = $1", createdAccount.AccountID)
+	require.NoError(t, err)
+	require.ErrorIs(t, createTransaction(withdrawal), model.ErrAccountDormant)
+	require.NoError(t, createTransaction(deposit))
+	updateStatus(model.AccountStatusActive)
+	require.NoError(t, createTransaction(withdrawal))
+
+	// owners can't close an account through its status
+	key = utils.RandomIdempotencyKey()
+	_, err = service.UpdateAccountStatus(context.Background(), createdAccount.AccountID, model.AccountStatusClosed, key, user.UserID)
+	require.ErrorIs(t, err, model.ErrInvalidArgument)
+
+	finalAccount, err := service.GetAccountByAccountNumber(context.Background(), createdAccount.AccountNumber, user.UserID)
+	require.NoError(t, err)
+	require.Equal(t, user.Balance, finalAccount.Balance)
+
+	// Cleanup the account and transactions we created to test
+	deleteAccount(t, createdAccount.AccountID)
 }
 
 // Create 1 single transaction
 func TestCreateTransaction_Success(t *testing.T) {
 	key := utils.RandomIdempotencyKey()
 	user := utils.RandomUser()
-	createdAccount, err := service.CreateAccount(context.Background(), user, key, user.UserID)
+	createdAccount, err := service.CreateAccount(context.Background(), user, "", key, user.UserID)
 	require.NoError(t, err)
 
 	// delete the idempotency key we used to create the account
@@ -149,7 +262,7 @@ func TestCreateTransaction_Success(t *testing.T) {
 	transaction := utils.RandomTransaction()
 	transaction.AccountID = createdAccount.AccountID
 	key = utils.RandomIdempotencyKey()
-	createdTransaction, err := service.CreateTransaction(context.Background(), transaction, key, user.UserID)
+	createdTransaction, err := service.CreateTransaction(context.Background(), transaction, key, user.UserID, mtls.APIGateway)
 	require.NoError(t, err)
 	require.NotEmpty(t, createdTransaction)
 	require.Equal(t, transaction.AccountID, createdTransaction.AccountID)
@@ -158,20 +271,14 @@ func TestCreateTransaction_Success(t *testing.T) {
 	require.NoError(t, err)
 
 	// Cleanup the account and transaction we created to test
-	// the transaction table uses ON DELETE CASCADE so deleting an account automatically deletes all transactions associated with it
-	key = utils.RandomIdempotencyKey()
-	err = service.DeleteAccountByAccountNumber(context.Background(), createdAccount.AccountNumber, key, user.UserID)
-	require.NoError(t, err)
-
-	err = service.DeleteIdempotencyKeyByID(context.Background(), key)
-	require.NoError(t, err)
+	deleteAccount(t, createdAccount.AccountID)
 }
 
 // Multiple concurrent goroutines that create different transactions (different idempotency keys) to the same account should update the account balance correctly
 func TestCreateMultipleTransactions_Success(t *testing.T) {
 	user := utils.RandomUser()
 	key := utils.RandomIdempotencyKey()
-	createdAccount, err := service.CreateAccount(context.Background(), user, key, user.UserID)
+	createdAccount, err := service.CreateAccount(context.Background(), user, "", key, user.UserID)
 	require.NoError(t, err)
 
 	// delete the idempotency key we used to create the account
@@ -189,7 +296,7 @@ func TestCreateMultipleTransactions_Success(t *testing.T) {
 			key := utils.RandomIdempotencyKey()
 			transaction := utils.RandomTransaction()
 			transaction.AccountID = createdAccount.AccountID
-			result, err := service.CreateTransaction(context.Background(), transaction, key, user.UserID)
+			result, err := service.CreateTransaction(context.Background(), transaction, key, user.UserID, mtls.APIGateway)
 			if err != nil {
 				errChan <- err
 				return
@@ -218,19 +325,14 @@ func TestCreateMultipleTransactions_Success(t *testing.T) {
 	require.Equal(t, balance, finalAccount.Balance)
 
 	// Cleanup the account and transactions we created to test
-	// the transaction table uses ON DELETE CASCADE so deleting an account automatically deletes all transactions associated with it
-	key = utils.RandomIdempotencyKey()
-	err = service.DeleteAccountByAccountNumber(context.Background(), createdAccount.AccountNumber, key, user.UserID)
-	require.NoError(t, err)
-	err = service.DeleteIdempotencyKeyByID(context.Background(), key)
-	require.NoError(t, err)
+	deleteAccount(t, createdAccount.AccountID)
 }
 
 // Multiple concurrent goroutines that create the same transactions (same idempotency keys) should update the account balance only once
 func TestCreateMultipleTransactionsIdempotencyKey_Success(t *testing.T) {
 	user := utils.RandomUser()
 	key := utils.RandomIdempotencyKey()
-	createdAccount, err := service.CreateAccount(context.Background(), user, key, user.UserID)
+	createdAccount, err := service.CreateAccount(context.Background(), user, "", key, user.UserID)
 	require.NoError(t, err)
 
 	// delete the idempotency key we used to create the account
@@ -252,7 +354,7 @@ func TestCreateMultipleTransactionsIdempotencyKey_Success(t *testing.T) {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			service.CreateTransaction(context.Background(), transaction, key, user.UserID)
+			service.CreateTransaction(context.Background(), transaction, key, user.UserID, mtls.APIGateway)
 		}()
 	}
 
@@ -268,11 +370,1042 @@ func TestCreateMultipleTransactionsIdempotencyKey_Success(t *testing.T) {
 	require.Equal(t, expectedBalance, finalAccount.Balance)
 
 	// Cleanup the account and transaction we created to test
-	// the transaction table uses ON DELETE CASCADE so deleting an account automatically deletes all transactions associated with it
+	deleteAccount(t, createdAccount.AccountID)
+}
+
+// Multiple concurrent goroutines that send the same overdraft (same idempotency key) should all get insufficient funds,
+// and so should a retry once the account has enough funds: the failure is recorded with the key
+func TestCreateTransactionFailedIdempotencyKey_Success(t *testing.T) {
+	user := utils.RandomUser()
+	key := utils.RandomIdempotencyKey()
+	createdAccount, err := service.CreateAccount(context.Background(), user, "", key, user.UserID)
+	require.NoError(t, err)
+
+	// delete the idempotency key we used to create the account
+	err = service.DeleteIdempotencyKeyByID(context.Background(), key)
+	require.NoError(t, err)
+
+	overdraftKey := utils.RandomIdempotencyKey()
+	overdraft := utils.RandomTransaction()
+	overdraft.AccountID = createdAccount.AccountID
+	overdraft.TransactionType = "DEBIT"
+	overdraft.Amount = -(createdAccount.Balance + 1)
+
+	numTransactions := 30
+	errChan := make(chan error, numTransactions)
+
+	for range numTransactions {
+		go func() {
+			_, err := service.CreateTransaction(context.Background(), overdraft, overdraftKey, user.UserID, mtls.APIGateway)
+			errChan <- err
+		}()
+	}
+
+	for range numTransactions {
+		err := <-errChan
+		require.ErrorIs(t, err, model.ErrInsufficientFunds)
+	}
+
+	// credit the account so that the overdraft would now succeed
+	key = utils.RandomIdempotencyKey()
+	deposit := utils.RandomTransaction()
+	deposit.AccountID = createdAccount.AccountID
+	deposit.TransactionType = "CREDIT"
+	deposit.Amount = createdAccount.Balance + 1
+	_, err = service.CreateTransaction(context.Background(), deposit, key, user.UserID, mtls.APIGateway)
+	require.NoError(t, err)
+	err = service.DeleteIdempotencyKeyByID(context.Background(), key)
+	require.NoError(t, err)
+
+	// a retry of the overdraft still gets the recorded failure
+	_, err = service.CreateTransaction(context.Background(), overdraft, overdraftKey, user.UserID, mtls.APIGateway)
+	require.ErrorIs(t, err, model.ErrInsufficientFunds)
+
+	err = service.DeleteIdempotencyKeyByID(context.Background(), overdraftKey)
+	require.NoError(t, err)
+
+	finalAccount, err := service.GetAccountByAccountNumber(context.Background(), createdAccount.AccountNumber, user.UserID)
+	require.NoError(t, err)
+	require.Equal(t, createdAccount.Balance+deposit.Amount, finalAccount.Balance)
+
+	// Cleanup the account and transactions we created to test
+	deleteAccount(t, createdAccount.AccountID)
+}
+
+// An idempotency key left PENDING by an attempt that died should block its retries until its lease expires, then be
+// taken over by a single one of multiple concurrent retries
+func TestCreateTransactionPendingIdempotencyKey_Success(t *testing.T) {
+	user := utils.RandomUser()
+	key := utils.RandomIdempotencyKey()
+	createdAccount, err := service.CreateAccount(context.Background(), user, "", key, user.UserID)
+	require.NoError(t, err)
+
+	// delete the idempotency key we used to create the account
+	err = service.DeleteIdempotencyKeyByID(context.Background(), key)
+	require.NoError(t, err)
+
+	transaction := utils.RandomTransaction()
+	transaction.AccountID = createdAccount.AccountID
+
+	// a claim whose lease hasn't expired is still held by its attempt
+	heldKey := utils.RandomIdempotencyKey()
+	commitPendingIdempotencyKey(t, heldKey, user.UserID, time.Minute)
+	_, err = service.CreateTransaction(context.Background(), transaction, heldKey, user.UserID, mtls.APIGateway)
+	require.ErrorIs(t, err, model.ErrIdempotencyKeyInProgress)
+	err = service.DeleteIdempotencyKeyByID(context.Background(), heldKey)
+	require.NoError(t, err)
+
+	// a claim whose lease expired was abandoned
+	abandonedKey := utils.RandomIdempotencyKey()
+	commitPendingIdempotencyKey(t, abandonedKey, user.UserID, 0)
+
+	numTransactions := 30
+	errChan := make(chan error, numTransactions)
+	results := make(chan *model.Transaction, numTransactions)
+
+	for range numTransactions {
+		go func() {
+			result, err := service.CreateTransaction(context.Background(), transaction, abandonedKey, user.UserID, mtls.APIGateway)
+			if err != nil {
+				errChan <- err
+				return
+			}
+			results <- result
+			errChan <- nil
+		}()
+	}
+
+	var transactionID uuid.UUID
+	for range numTransactions {
+		err := <-errChan
+		require.NoError(t, err)
+		result := <-results
+		// every retry gets the transaction created by the one that took the key over
+		if transactionID == uuid.Nil {
+			transactionID = result.TransactionID
+		}
+		require.Equal(t, transactionID, result.TransactionID)
+	}
+
+	err = service.DeleteIdempotencyKeyByID(context.Background(), abandonedKey)
+	require.NoError(t, err)
+
+	finalAccount, err := service.GetAccountByAccountNumber(context.Background(), createdAccount.AccountNumber, user.UserID)
+	require.NoError(t, err)
+	require.Equal(t, createdAccount.Balance+transaction.Amount, finalAccount.Balance)
+
+	// Cleanup the account and transaction we created to test
+	deleteAccount(t, createdAccount.AccountID)
+}
+
+// deleteAccount deletes the account and its transactions, which closing the account would keep.
+func deleteAccount(t *testing.T, accountID uuid.UUID) {
+	_, err := db.ExecContext(context.Background(), "DELETE FROM interest_postings WHERE account_id = $1", accountID)
+	require.NoError(t, err)
+	_, err = db.ExecContext(context.Background(), "DELETE FROM maintenance_fee_periods WHERE account_id = $1", accountID)
+	require.NoError(t, err)
+	_, err = db.ExecContext(context.Background(), "DELETE FROM interest_accruals WHERE account_id = $1", accountID)
+	require.NoError(t, err)
+	_, err = db.ExecContext(context.Background(), "DELETE FROM transactions WHERE account_id = $1", accountID)
+	require.NoError(t, err)
+	_, err = db.ExecContext(context.Background(), "DELETE FROM holds WHERE account_id = $1", accountID)
+	require.NoError(t, err)
+	_, err = db.ExecContext(context.Background(), "DELETE FROM accounts WHERE id = $1", accountID)
+	require.NoError(t, err)
+}
+
+// commitPendingIdempotencyKey claims key and commits it as PENDING, the way an attempt dying between its claim and its
+// outcome would leave it. Its empty request hash matches any request.
+func commitPendingIdempotencyKey(t *testing.T, key string, userID uuid.UUID, lease time.Duration) {
+	tx, err := db.BeginTx(context.Background(), nil)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	_, err = repository.NewAccountRepository(db).WithTx(tx).GetOrClaimIdempotencyKey(context.Background(), &model.IdempotencyKey{
+		KeyID:   key,
+		UserID:  userID,
+		RPCName: "CreateTransaction",
+		ClaimID: uuid.New(),
+		Status:  "PENDING",
+	}, lease)
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+}
+
+// A savings account allows a limited number of debits per month, and the debits over the limit don't change the balance
+func TestSavingsWithdrawalLimit_Fail(t *testing.T) {
+	user := utils.RandomUser()
+	user.Balance = 1000
+	key := utils.RandomIdempotencyKey()
+	createdAccount, err := service.CreateAccount(context.Background(), user, "SAVINGS", key, user.UserID)
+	require.NoError(t, err)
+	require.Equal(t, model.AccountSavings, createdAccount.AccountType)
+	err = service.DeleteIdempotencyKeyByID(context.Background(), key)
+	require.NoError(t, err)
+
+	product, err := service.repo.GetAccountProductByCode(context.Background(), "SAVINGS")
+	require.NoError(t, err)
+	require.Positive(t, product.MonthlyWithdrawalLimit)
+
+	withdrawal := utils.RandomTransaction()
+	withdrawal.AccountID = createdAccount.AccountID
+	withdrawal.TransactionType = "DEBIT"
+	withdrawal.Amount = -1
+	for range product.MonthlyWithdrawalLimit {
+		key = utils.RandomIdempotencyKey()
+		_, err = service.CreateTransaction(context.Background(), withdrawal, key, user.UserID, mtls.APIGateway)
+		require.NoError(t, err)
+		err = service.DeleteIdempotencyKeyByID(context.Background(), key)
+		require.NoError(t, err)
+	}
+
+	key = utils.RandomIdempotencyKey()
+	_, err = service.CreateTransaction(context.Background(), withdrawal, key, user.UserID, mtls.APIGateway)
+	require.ErrorIs(t, err, model.ErrWithdrawalLimitExceeded)
+	err = service.DeleteIdempotencyKeyByID(context.Background(), key)
+	require.NoError(t, err)
+
+	finalAccount, err := service.GetAccountByAccountNumber(context.Background(), createdAccount.AccountNumber, user.UserID)
+	require.NoError(t, err)
+	require.Equal(t, user.Balance-int64(product.MonthlyWithdrawalLimit), finalAccount.Balance)
+
+	// Cleanup the account and transactions we created to test
+	deleteAccount(t, createdAccount.AccountID)
+}
+
+// A term deposit must be opened with its minimum balance and can't be debited before it matures
+func TestTermDepositRules_Fail(t *testing.T) {
+	product, err := service.repo.GetAccountProductByCode(context.Background(), "TERM_DEPOSIT_12M")
+	require.NoError(t, err)
+
+	user := utils.RandomUser()
+	user.Balance = product.MinBalance - 1
+	key := utils.RandomIdempotencyKey()
+	_, err = service.CreateAccount(context.Background(), user, product.Code, key, user.UserID)
+	require.ErrorIs(t, err, model.ErrMinimumBalance)
+	err = service.DeleteIdempotencyKeyByID(context.Background(), key)
+	require.NoError(t, err)
+
+	key = utils.RandomIdempotencyKey()
+	_, err = service.CreateAccount(context.Background(), user, "NO_SUCH_PRODUCT", key, user.UserID)
+	require.ErrorIs(t, err, model.ErrUnknownProduct)
+	err = service.DeleteIdempotencyKeyByID(context.Background(), key)
+	require.NoError(t, err)
+
+	user.Balance = product.MinBalance + 100
+	key = utils.RandomIdempotencyKey()
+	createdAccount, err := service.CreateAccount(context.Background(), user, product.Code, key, user.UserID)
+	require.NoError(t, err)
+	require.Equal(t, model.AccountTermDeposit, createdAccount.AccountType)
+	require.WithinDuration(t, time.Now().AddDate(0, 0, int(product.LockUpDays)), createdAccount.MaturesAt, time.Minute)
+	err = service.DeleteIdempotencyKeyByID(context.Background(), key)
+	require.NoError(t, err)
+
+	withdrawal := utils.RandomTransaction()
+	withdrawal.AccountID = createdAccount.AccountID
+	withdrawal.TransactionType = "DEBIT"
+	withdrawal.Amount = -1
+	key = utils.RandomIdempotencyKey()
+	_, err = service.CreateTransaction(context.Background(), withdrawal, key, user.UserID, mtls.APIGateway)
+	require.ErrorIs(t, err, model.ErrAccountLocked)
+	err = service.DeleteIdempotencyKeyByID(context.Background(), key)
+	require.NoError(t, err)
+
+	// Cleanup the account we created to test
+	deleteAccount(t, createdAccount.AccountID)
+}
+
+// An account only takes transactions and balance checks in its own currency
+func TestCurrencyMismatch_Fail(t *testing.T) {
+	user := utils.RandomUser()
+	user.Currency = "EUR"
+	key := utils.RandomIdempotencyKey()
+	createdAccount, err := service.CreateAccount(context.Background(), user, "", key, user.UserID)
+	require.NoError(t, err)
+	require.Equal(t, "EUR", createdAccount.Currency)
+	err = service.DeleteIdempotencyKeyByID(context.Background(), key)
+	require.NoError(t, err)
+
+	deposit := utils.RandomTransaction()
+	deposit.AccountID = createdAccount.AccountID
+	deposit.TransactionType = "CREDIT"
+	deposit.Currency = "GBP"
+	key = utils.RandomIdempotencyKey()
+	_, err = service.CreateTransaction(context.Background(), deposit, key, user.UserID, mtls.APIGateway)
+	require.ErrorIs(t, err, model.ErrCurrencyMismatch)
+	err = service.DeleteIdempotencyKeyByID(context.Background(), key)
+	require.NoError(t, err)
+
+	deposit.Currency = "EUR"
+	key = utils.RandomIdempotencyKey()
+	createdTransaction, err := service.CreateTransaction(context.Background(), deposit, key, user.UserID, mtls.APIGateway)
+	require.NoError(t, err)
+	require.Equal(t, "EUR", createdTransaction.Currency)
+	err = service.DeleteIdempotencyKeyByID(context.Background(), key)
+	require.NoError(t, err)
+
+	_, err = service.HasSufficientBalance(context.Background(), createdAccount.AccountNumber, money.Money{Currency: "USD", Units: 1}, user.UserID)
+	require.ErrorIs(t, err, model.ErrCurrencyMismatch)
+	sufficient, err := service.HasSufficientBalance(context.Background(), createdAccount.AccountNumber, money.Money{Currency: "EUR", Units: 1}, user.UserID)
+	require.NoError(t, err)
+	require.True(t, sufficient)
+
+	user.Currency = "XXX"
+	_, err = service.CreateAccount(context.Background(), user, "", utils.RandomIdempotencyKey(), user.UserID)
+	require.ErrorIs(t, err, model.ErrUnsupportedCurrency)
+
+	// Cleanup the account we created to test
+	deleteAccount(t, createdAccount.AccountID)
+}
+
+// runJob runs a janitor task the way the janitor does, until a batch comes back short
+func runJob(t *testing.T, job func(context.Context, time.Time, int32) (int64, error), asOf time.Time) {
+	for {
+		n, err := job(context.Background(), asOf, 100)
+		require.NoError(t, err)
+		if n < 100 {
+			return
+		}
+	}
+}
+
+// Interest accrues daily on a savings account and is posted once per month, whatever the number of runs
+func TestInterest_Success(t *testing.T) {
+	user := utils.RandomUser()
+	user.Balance = 1_000_000
+	key := utils.RandomIdempotencyKey()
+	createdAccount, err := service.CreateAccount(context.Background(), user, "SAVINGS", key, user.UserID)
+	require.NoError(t, err)
+	err = service.DeleteIdempotencyKeyByID(context.Background(), key)
+	require.NoError(t, err)
+
+	// two months later, the month the account was opened in and the next one are over
+	today := interest.Day(time.Now())
+	thisMonth := today.AddDate(0, 0, 1-today.Day())
+	asOf := thisMonth.AddDate(0, 2, 0)
+	for range 2 {
+		runJob(t, service.AccrueInterest, asOf)
+		runJob(t, service.PostInterest, asOf)
+	}
+
+	account, err := service.repo.GetAccountByID(context.Background(), createdAccount.AccountID)
+	require.NoError(t, err)
+	require.Equal(t, asOf.AddDate(0, 0, -1), account.InterestAccruedThrough.UTC())
+	postings, err := service.repo.GetInterestPostingsByAccountID(context.Background(), createdAccount.AccountID)
+	require.NoError(t, err)
+	require.Len(t, postings, 2)
+	require.Equal(t, thisMonth, postings[0].Period.UTC())
+	require.Equal(t, thisMonth.AddDate(0, 1, 0), postings[1].Period.UTC())
+
+	// 10,000.00 at 2% earns about 0.55 a day, and the fractions carried from the first month are posted with the second
+	schedule, err := interestSchedule(context.Background(), service.repo, "SAVINGS")
+	require.NoError(t, err)
+	daily, err := interest.Accrue(user.Balance, schedule.On(today), interest.ACT365, today)
+	require.NoError(t, err)
+	days := int64(asOf.Sub(today).Hours() / 24)
+	require.Positive(t, postings[0].Posted)
+	total, _ := interest.Split(new(big.Rat).Mul(daily, big.NewRat(days, 1)))
+	require.InDelta(t, total, postings[0].Posted+postings[1].Posted, 1)
+	require.Equal(t, user.Balance+postings[0].Posted+postings[1].Posted, account.Balance)
+
+	transactions, err := service.repo.GetTransactionsByAccountID(context.Background(), createdAccount.AccountID)
+	require.NoError(t, err)
+	require.Len(t, transactions, 2)
+	for _, transaction := range transactions {
+		require.Equal(t, model.TransactionInterest, transaction.TransactionType)
+		require.Equal(t, account.Currency, transaction.Currency)
+	}
+
+	// Cleanup the account and transactions we created to test
+	deleteAccount(t, createdAccount.AccountID)
+}
+
+// The debit of a transfer is charged the transfer fee, which the support staff can reverse once
+func TestTransferFeeReversal_Success(t *testing.T) {
+	user := utils.RandomUser()
+	user.Balance = 10_000
+	key := utils.RandomIdempotencyKey()
+	createdAccount, err := service.CreateAccount(context.Background(), user, "", key, user.UserID)
+	require.NoError(t, err)
+	err = service.DeleteIdempotencyKeyByID(context.Background(), key)
+	require.NoError(t, err)
+
+	debit := utils.RandomTransaction()
+	debit.AccountID = createdAccount.AccountID
+	debit.TransactionType = "TRANSFER_DEBIT"
+	debit.Amount = -1_000
+	key = utils.RandomIdempotencyKey()
+	created, err := service.CreateTransaction(context.Background(), debit, key, user.UserID, mtls.APIGateway)
+	require.NoError(t, err)
+	err = service.DeleteIdempotencyKeyByID(context.Background(), key)
+	require.NoError(t, err)
+
+	rule, err := service.repo.GetFeeRuleByCode(context.Background(), "TRANSFER_USD")
+	require.NoError(t, err)
+	transactions, err := service.repo.GetTransactionsByAccountID(context.Background(), createdAccount.AccountID)
+	require.NoError(t, err)
+	require.Len(t, transactions, 2)
+	var charged *model.Transaction
+	for _, transaction := range transactions {
+		if transaction.TransactionType == model.TransactionFee {
+			charged = transaction
+		}
+	}
+	require.NotNil(t, charged)
+	require.Equal(t, rule.Code, charged.FeeRule)
+	require.Equal(t, uuid.NullUUID{UUID: created.TransactionID, Valid: true}, charged.CausedBy)
+	require.Equal(t, -fee.Compute(rule, debit.Amount, user.Balance+debit.Amount), charged.Amount)
+
+	account, err := service.repo.GetAccountByID(context.Background(), createdAccount.AccountID)
+	require.NoError(t, err)
+	require.Equal(t, user.Balance+debit.Amount+charged.Amount, account.Balance)
+
+	// only fees can be reversed
+	_, err = service.ReverseFee(context.Background(), created.TransactionID, "not a fee")
+	require.ErrorIs(t, err, model.ErrNotAFee)
+
+	reversal, err := service.ReverseFee(context.Background(), charged.TransactionID, "goodwill")
+	require.NoError(t, err)
+	require.Equal(t, model.TransactionFeeReversal, reversal.TransactionType)
+	require.Equal(t, -charged.Amount, reversal.Amount)
+	require.Equal(t, "goodwill", reversal.Memo)
+	again, err := service.ReverseFee(context.Background(), charged.TransactionID, "goodwill")
+	require.NoError(t, err)
+	require.Equal(t, reversal.TransactionID, again.TransactionID)
+
+	charged, err = service.repo.GetTransactionByID(context.Background(), charged.TransactionID)
+	require.NoError(t, err)
+	require.Equal(t, "REVERSED", charged.Status)
+	account, err = service.repo.GetAccountByID(context.Background(), createdAccount.AccountID)
+	require.NoError(t, err)
+	require.Equal(t, user.Balance+debit.Amount, account.Balance)
+
+	// Cleanup the account and transactions we created to test
+	deleteAccount(t, createdAccount.AccountID)
+}
+
+// The transfer service credits the accounts of other users, on behalf of the owner of the source account
+func TestCreateTransaction_TransferCredit(t *testing.T) {
+	sender, recipient := utils.RandomUser(), utils.RandomUser()
+	key := utils.RandomIdempotencyKey()
+	createdAccount, err := service.CreateAccount(context.Background(), recipient, "", key, recipient.UserID)
+	require.NoError(t, err)
+	err = service.DeleteIdempotencyKeyByID(context.Background(), key)
+	require.NoError(t, err)
+
+	credit := func(transactionType, caller string) (*model.Transaction, error) {
+		return service.CreateTransaction(context.Background(), &model.Transaction{
+			AccountID:       createdAccount.AccountID,
+			Amount:          1_000,
+			Currency:        createdAccount.Currency,
+			TransactionType: transactionType,
+			TransferID:      uuid.NullUUID{UUID: uuid.New(), Valid: true},
+		}, utils.RandomIdempotencyKey(), sender.UserID, caller)
+	}
+
+	// only the credit leg of a transfer, and only from the transfer service
+	_, err = credit("CREDIT", mtls.TransferService)
+	require.ErrorIs(t, err, model.ErrNotAuthorized)
+	_, err = credit("TRANSFER_CREDIT", mtls.APIGateway)
+	require.ErrorIs(t, err, model.ErrNotAuthorized)
+	_, err = credit("TRANSFER_DEBIT", mtls.TransferService)
+	require.ErrorIs(t, err, model.ErrNotAuthorized)
+
+	created, err := credit("TRANSFER_CREDIT", mtls.TransferService)
+	require.NoError(t, err)
+	require.Equal(t, createdAccount.AccountID, created.AccountID)
+	account, err := service.repo.GetAccountByID(context.Background(), createdAccount.AccountID)
+	require.NoError(t, err)
+	require.Equal(t, createdAccount.Balance+1_000, account.Balance)
+
+	// Cleanup the account and transaction we created to test
+	deleteAccount(t, createdAccount.AccountID)
+}
+
+// A hold lowers the available balance until it is captured, voided or expires, and only its capture debits the account
+func TestHolds_Success(t *testing.T) {
+	user := utils.RandomUser()
+	user.Balance = 10_000
+	key := utils.RandomIdempotencyKey()
+	createdAccount, err := service.CreateAccount(context.Background(), user, "", key, user.UserID)
+	require.NoError(t, err)
+	err = service.DeleteIdempotencyKeyByID(context.Background(), key)
+	require.NoError(t, err)
+
+	placeHold := func(amount int64, transactionType string, ttl time.Duration) (*model.Hold, error) {
+		return service.PlaceHold(context.Background(), &model.Hold{
+			AccountID:       createdAccount.AccountID,
+			Amount:          amount,
+			Currency:        createdAccount.Currency,
+			TransactionType: transactionType,
+		}, ttl, utils.RandomIdempotencyKey(), user.UserID)
+	}
+	requireBalances := func(balance, held int64) {
+		account, err := service.repo.GetAccountByID(context.Background(), createdAccount.AccountID)
+		require.NoError(t, err)
+		require.Equal(t, balance, account.Balance)
+		require.Equal(t, held, account.Held)
+	}
+
+	hold, err := placeHold(3_000, "DEBIT", 0)
+	require.NoError(t, err)
+	require.Equal(t, model.HoldStatusActive, hold.Status)
+	requireBalances(10_000, 3_000)
+
+	// only 7_000 is available, for holds and debits alike
+	_, err = placeHold(8_000, "DEBIT", 0)
+	require.ErrorIs(t, err, model.ErrInsufficientFunds)
+	debit := utils.RandomTransaction()
+	debit.AccountID = createdAccount.AccountID
+	debit.TransactionType = "DEBIT"
+	debit.Amount = -8_000
+	_, err = service.CreateTransaction(context.Background(), debit, utils.RandomIdempotencyKey(), user.UserID, mtls.APIGateway)
+	require.ErrorIs(t, err, model.ErrInsufficientFunds)
+
+	// a partial capture debits the captured amount and releases the rest
+	_, err = service.CaptureHold(context.Background(), hold.HoldID, money.Money{Currency: createdAccount.Currency, Units: 3_001}, utils.RandomIdempotencyKey(), user.UserID, mtls.APIGateway)
+	require.ErrorIs(t, err, model.ErrCaptureExceedsHold)
+	captured, err := service.CaptureHold(context.Background(), hold.HoldID, money.Money{Currency: createdAccount.Currency, Units: 2_000}, utils.RandomIdempotencyKey(), user.UserID, mtls.APIGateway)
+	require.NoError(t, err)
+	require.Equal(t, int64(-2_000), captured.Amount)
+	require.Equal(t, "DEBIT", captured.TransactionType)
+	require.Equal(t, uuid.NullUUID{UUID: hold.HoldID, Valid: true}, captured.HoldID)
+	requireBalances(8_000, 0)
+	_, err = service.CaptureHold(context.Background(), hold.HoldID, money.Money{}, utils.RandomIdempotencyKey(), user.UserID, mtls.APIGateway)
+	require.ErrorIs(t, err, model.ErrHoldNotActive)
+
+	// a voided hold releases everything, and voiding it again changes nothing
+	hold, err = placeHold(1_000, "DEBIT", 0)
+	require.NoError(t, err)
+	requireBalances(8_000, 1_000)
+	voided, err := service.VoidHold(context.Background(), hold.HoldID, utils.RandomIdempotencyKey(), user.UserID, mtls.APIGateway)
+	require.NoError(t, err)
+	require.Equal(t, model.HoldStatusVoided, voided.Status)
+	requireBalances(8_000, 0)
+	voided, err = service.VoidHold(context.Background(), hold.HoldID, utils.RandomIdempotencyKey(), user.UserID, mtls.APIGateway)
+	require.NoError(t, err)
+	require.Equal(t, model.HoldStatusVoided, voided.Status)
+	requireBalances(8_000, 0)
+
+	// the holds of transfers are only settled by the transfer service
+	hold, err = placeHold(1_000, "TRANSFER_DEBIT", 0)
+	require.NoError(t, err)
+	_, err = service.VoidHold(context.Background(), hold.HoldID, utils.RandomIdempotencyKey(), user.UserID, mtls.APIGateway)
+	require.ErrorIs(t, err, model.ErrNotAuthorized)
+	captured, err = service.CaptureHold(context.Background(), hold.HoldID, money.Money{}, utils.RandomIdempotencyKey(), user.UserID, mtls.TransferService)
+	require.NoError(t, err)
+	require.Equal(t, int64(-1_000), captured.Amount)
+	requireBalances(7_000, 0)
+
+	// expired holds are released by the janitor, and can't be captured anymore
+	hold, err = placeHold(1_000, "DEBIT", time.Hour)
+	require.NoError(t, err)
+	requireBalances(7_000, 1_000)
+	runJob(t, service.ExpireHolds, time.Now().Add(2*time.Hour))
+	requireBalances(7_000, 0)
+	_, err = service.CaptureHold(context.Background(), hold.HoldID, money.Money{}, utils.RandomIdempotencyKey(), user.UserID, mtls.APIGateway)
+	require.ErrorIs(t, err, model.ErrHoldExpired)
+
+	// Cleanup the account and transactions we created to test
+	deleteAccount(t, createdAccount.AccountID)
+}
+
+// Reversals offset a transaction in one or several parts, never for more than its amount
+func TestReverseTransaction_Success(t *testing.T) {
+	user := utils.RandomUser()
+	user.Balance = 10_000
+	key := utils.RandomIdempotencyKey()
+	createdAccount, err := service.CreateAccount(context.Background(), user, "", key, user.UserID)
+	require.NoError(t, err)
+	err = service.DeleteIdempotencyKeyByID(context.Background(), key)
+	require.NoError(t, err)
+
+	createTransaction := func(transactionType string, amount int64) *model.Transaction {
+		transaction := utils.RandomTransaction()
+		transaction.AccountID = createdAccount.AccountID
+		transaction.TransactionType = transactionType
+		transaction.Amount = amount
+		transaction.Status = "COMPLETED"
+		created, err := service.CreateTransaction(context.Background(), transaction, utils.RandomIdempotencyKey(), user.UserID, mtls.APIGateway)
+		require.NoError(t, err)
+		return created
+	}
+	reverse := func(transactionID uuid.UUID, units int64, key string, userID uuid.UUID, caller string) (*model.Transaction, error) {
+		amount := money.Money{}
+		if units != 0 {
+			amount = money.Money{Currency: createdAccount.Currency, Units: units}
+		}
+		return service.ReverseTransaction(context.Background(), transactionID, amount, "refund", key, userID, caller)
+	}
+	requireBalance := func(balance int64) {
+		account, err := service.repo.GetAccountByID(context.Background(), createdAccount.AccountID)
+		require.NoError(t, err)
+		require.Equal(t, balance, account.Balance)
+	}
+	credit := createTransaction("CREDIT", 5_000)
+	debit := createTransaction("DEBIT", -4_000)
+	requireBalance(11_000)
+
+	// refunding a debit is for the support staff
+	_, err = reverse(debit.TransactionID, 0, utils.RandomIdempotencyKey(), user.UserID, mtls.APIGateway)
+	require.ErrorIs(t, err, model.ErrNotAuthorized)
+
+	key = utils.RandomIdempotencyKey()
+	reversal, err := reverse(debit.TransactionID, 1_000, key, uuid.Nil, mtls.SupportConsole)
+	require.NoError(t, err)
+	require.Equal(t, model.TransactionReversal, reversal.TransactionType)
+	require.Equal(t, int64(1_000), reversal.Amount)
+	require.Equal(t, uuid.NullUUID{UUID: debit.TransactionID, Valid: true}, reversal.CausedBy)
+	require.Equal(t, "refund", reversal.Memo)
+	requireBalance(12_000)
+	replayed, err := reverse(debit.TransactionID, 1_000, key, uuid.Nil, mtls.SupportConsole)
+	require.NoError(t, err)
+	require.Equal(t, reversal.TransactionID, replayed.TransactionID)
+	requireBalance(12_000)
+	// reversed in part only
+	debit, err = service.repo.GetTransactionByID(context.Background(), debit.TransactionID)
+	require.NoError(t, err)
+	require.Equal(t, "COMPLETED", debit.Status)
+
+	// 3_000 remain to reverse
+	_, err = reverse(debit.TransactionID, 3_001, utils.RandomIdempotencyKey(), uuid.Nil, mtls.SupportConsole)
+	require.ErrorIs(t, err, model.ErrReversalExceedsRemaining)
+	reversal, err = reverse(debit.TransactionID, 0, utils.RandomIdempotencyKey(), uuid.Nil, mtls.SupportConsole)
+	require.NoError(t, err)
+	require.Equal(t, int64(3_000), reversal.Amount)
+	requireBalance(15_000)
+	debit, err = service.repo.GetTransactionByID(context.Background(), debit.TransactionID)
+	require.NoError(t, err)
+	require.Equal(t, "REVERSED", debit.Status)
+	_, err = reverse(debit.TransactionID, 0, utils.RandomIdempotencyKey(), uuid.Nil, mtls.SupportConsole)
+	require.ErrorIs(t, err, model.ErrAlreadyReversed)
+
+	// the owner may give back a credit, but not reverse a reversal
+	reversal, err = reverse(credit.TransactionID, 0, utils.RandomIdempotencyKey(), user.UserID, mtls.APIGateway)
+	require.NoError(t, err)
+	require.Equal(t, int64(-5_000), reversal.Amount)
+	requireBalance(10_000)
+	_, err = reverse(reversal.TransactionID, 0, utils.RandomIdempotencyKey(), uuid.Nil, mtls.SupportConsole)
+	require.ErrorIs(t, err, model.ErrNotReversible)
+
+	transactions, err := service.repo.GetTransactionsByAccountID(context.Background(), createdAccount.AccountID)
+	require.NoError(t, err)
+	require.Len(t, transactions, 5)
+
+	// Cleanup the account and transactions we created to test
+	deleteAccount(t, createdAccount.AccountID)
+}
+
+// The transactions are reversible as CreateTransaction records them, whatever the status of the request, and so are the
+// ones it recorded as PENDING before completing them.
+func TestReverseTransaction_Posted(t *testing.T) {
+	user := utils.RandomUser()
+	user.Balance = 10_000
+	key := utils.RandomIdempotencyKey()
+	createdAccount, err := service.CreateAccount(context.Background(), user, "", key, user.UserID)
+	require.NoError(t, err)
+	err = service.DeleteIdempotencyKeyByID(context.Background(), key)
+	require.NoError(t, err)
+
+	// the status of the gRPC request is ignored
+	credit, err := service.CreateTransaction(context.Background(), &model.Transaction{
+		AccountID:       createdAccount.AccountID,
+		Amount:          2_000,
+		Currency:        createdAccount.Currency,
+		TransactionType: "CREDIT",
+		Status:          "PENDING",
+	}, utils.RandomIdempotencyKey(), user.UserID, mtls.APIGateway)
+	require.NoError(t, err)
+	require.Equal(t, "COMPLETED", credit.Status)
+	_, err = service.ReverseTransaction(context.Background(), credit.TransactionID, money.Money{}, "refund", utils.RandomIdempotencyKey(), user.UserID, mtls.APIGateway)
+	require.NoError(t, err)
+
+	legacy, err := service.repo.CreateTransaction(context.Background(), &model.Transaction{
+		TransactionID:   uuid.New(),
+		AccountID:       createdAccount.AccountID,
+		Amount:          1_000,
+		Currency:        createdAccount.Currency,
+		TransactionType: "CREDIT",
+		Status:          "PENDING",
+	})
+	require.NoError(t, err)
+	_, err = service.ReverseTransaction(context.Background(), legacy.TransactionID, money.Money{}, "refund", utils.RandomIdempotencyKey(), user.UserID, mtls.APIGateway)
+	require.NoError(t, err)
+	legacy, err = service.repo.GetTransactionByID(context.Background(), legacy.TransactionID)
+	require.NoError(t, err)
+	require.Equal(t, "REVERSED", legacy.Status)
+
+	account, err := service.repo.GetAccountByID(context.Background(), createdAccount.AccountID)
+	require.NoError(t, err)
+	require.Equal(t, int64(9_000), account.Balance)
+
+	// Cleanup the account and transactions we created to test
+	deleteAccount(t, createdAccount.AccountID)
+}
+
+func TestAccountLimits_Success(t *testing.T) {
+	user := utils.RandomUser()
+	user.Balance = 100_000
+	key := utils.RandomIdempotencyKey()
+	createdAccount, err := service.CreateAccount(context.Background(), user, "", key, user.UserID)
+	require.NoError(t, err)
+	err = service.DeleteIdempotencyKeyByID(context.Background(), key)
+	require.NoError(t, err)
+
+	debit := func(amount int64) error {
+		transaction := utils.RandomTransaction()
+		transaction.AccountID = createdAccount.AccountID
+		transaction.TransactionType = "DEBIT"
+		transaction.Amount = -amount
+		_, err := service.CreateTransaction(context.Background(), transaction, utils.RandomIdempotencyKey(), user.UserID, mtls.APIGateway)
+		return err
+	}
+	require.NoError(t, debit(1_000))
+
+	// the owner may lower the limits, not raise them above the ceiling
 	key = utils.RandomIdempotencyKey()
-	err = service.DeleteAccountByAccountNumber(context.Background(), createdAccount.AccountNumber, key, user.UserID)
+	limits, err := service.UpdateAccountLimits(context.Background(), createdAccount.AccountID,
+		model.Limits{MaxSingleDebit: 5_000, DailyDebitTotal: 8_000}, createdAccount.Currency, key, user.UserID, mtls.APIGateway)
+	require.NoError(t, err)
+	require.Equal(t, int64(5_000), limits.Effective.MaxSingleDebit)
+	require.Equal(t, int64(8_000), limits.Effective.DailyDebitTotal)
+	require.Equal(t, int64(1_000_000), limits.Ceiling.MaxSingleDebit)
+	require.Equal(t, int64(1_000), limits.Usage.DailyDebitTotal)
+	require.Equal(t, int64(1), limits.Usage.DailyDebitCount)
+	replayed, err := service.UpdateAccountLimits(context.Background(), createdAccount.AccountID,
+		model.Limits{MaxSingleDebit: 5_000, DailyDebitTotal: 8_000}, createdAccount.Currency, key, user.UserID, mtls.APIGateway)
+	require.NoError(t, err)
+	require.Equal(t, limits, replayed)
+	_, err = service.UpdateAccountLimits(context.Background(), createdAccount.AccountID,
+		model.Limits{MaxSingleDebit: 4_000}, createdAccount.Currency, key, user.UserID, mtls.APIGateway)
+	require.ErrorIs(t, err, model.ErrIdempotencyKeyReused)
+	_, err = service.UpdateAccountLimits(context.Background(), createdAccount.AccountID,
+		model.Limits{MaxSingleDebit: 1_000_001}, createdAccount.Currency, utils.RandomIdempotencyKey(), user.UserID, mtls.APIGateway)
+	require.ErrorIs(t, err, model.ErrLimitIncreaseNotAllowed)
+	_, err = service.UpdateAccountLimits(context.Background(), createdAccount.AccountID,
+		model.Limits{MaxSingleDebit: 4_000}, "EUR", utils.RandomIdempotencyKey(), user.UserID, mtls.APIGateway)
+	require.ErrorIs(t, err, model.ErrCurrencyMismatch)
+
+	var exceeded *model.LimitExceededError
+	err = debit(5_001)
+	require.ErrorAs(t, err, &exceeded)
+	require.Equal(t, model.LimitMaxSingleDebit, exceeded.Limit)
+	require.NoError(t, debit(5_000))
+	// 2_000 remain of the daily total
+	err = debit(2_001)
+	require.ErrorAs(t, err, &exceeded)
+	require.Equal(t, model.LimitDailyDebitTotal, exceeded.Limit)
+	require.Equal(t, int64(8_000), exceeded.Allowed)
+	require.Equal(t, int64(2_000), exceeded.Remaining)
+	require.NoError(t, debit(2_000))
+
+	// the owner may raise them back up to the ceiling, the limits they leave unset going back to it
+	limits, err = service.UpdateAccountLimits(context.Background(), createdAccount.AccountID,
+		model.Limits{DailyDebitTotal: 8_000}, createdAccount.Currency, utils.RandomIdempotencyKey(), user.UserID, mtls.APIGateway)
+	require.NoError(t, err)
+	require.Equal(t, int64(1_000_000), limits.Effective.MaxSingleDebit)
+	require.Equal(t, int64(8_000), limits.Effective.DailyDebitTotal)
+	require.Equal(t, model.Limits{DailyDebitTotal: 8_000}, limits.User)
+
+	// the support staff may raise or lower the ceiling, the lower limits of the owner still applying
+	limits, err = service.UpdateAccountLimits(context.Background(), createdAccount.AccountID,
+		model.Limits{MaxSingleDebit: 7_000, DailyDebitTotal: 20_000}, createdAccount.Currency, utils.RandomIdempotencyKey(), uuid.Nil, mtls.SupportConsole)
+	require.NoError(t, err)
+	require.Equal(t, int64(8_000), limits.Effective.DailyDebitTotal)
+	require.Equal(t, int64(7_000), limits.Effective.MaxSingleDebit)
+	require.Equal(t, int64(20_000), limits.Ceiling.DailyDebitTotal)
+	_, err = service.UpdateAccountLimits(context.Background(), createdAccount.AccountID,
+		model.Limits{MaxSingleDebit: 7_001}, createdAccount.Currency, utils.RandomIdempotencyKey(), user.UserID, mtls.APIGateway)
+	require.ErrorIs(t, err, model.ErrLimitIncreaseNotAllowed)
+
+	limits, err = service.GetAccountLimits(context.Background(), createdAccount.AccountID, user.UserID, mtls.APIGateway)
+	require.NoError(t, err)
+	require.Equal(t, int64(8_000), limits.Usage.DailyDebitTotal)
+	require.Equal(t, int64(3), limits.Usage.DailyDebitCount)
+	_, err = service.GetAccountLimits(context.Background(), createdAccount.AccountID, uuid.New(), mtls.APIGateway)
+	require.ErrorIs(t, err, model.ErrNotAuthorized)
+
+	// Cleanup the account and transactions we created to test
+	deleteAccount(t, createdAccount.AccountID)
+}
+
+// The limits of a user apply to the debits of all their accounts in the currency together.
+func TestUserLimits_Success(t *testing.T) {
+	user := utils.RandomUser()
+	user.Balance = 10_000
+	var accountIDs []uuid.UUID
+	for range 2 {
+		key := utils.RandomIdempotencyKey()
+		createdAccount, err := service.CreateAccount(context.Background(), user, "", key, user.UserID)
+		require.NoError(t, err)
+		err = service.DeleteIdempotencyKeyByID(context.Background(), key)
+		require.NoError(t, err)
+		accountIDs = append(accountIDs, createdAccount.AccountID)
+	}
+
+	debit := func(accountID uuid.UUID, amount int64) error {
+		transaction := utils.RandomTransaction()
+		transaction.AccountID = accountID
+		transaction.TransactionType = "DEBIT"
+		transaction.Amount = -amount
+		_, err := service.CreateTransaction(context.Background(), transaction, utils.RandomIdempotencyKey(), user.UserID, mtls.APIGateway)
+		return err
+	}
+	require.NoError(t, debit(accountIDs[0], 1_000))
+
+	limits, err := service.GetUserLimits(context.Background(), user.UserID, user.Currency)
+	require.NoError(t, err)
+	require.Equal(t, model.Limits{}, limits.Limits)
+	require.Equal(t, int64(1_000), limits.Usage.DailyDebitTotal)
+
+	key := utils.RandomIdempotencyKey()
+	limits, err = service.UpdateUserLimits(context.Background(), user.UserID, user.Currency, model.Limits{DailyDebitTotal: 3_000}, key)
+	require.NoError(t, err)
+	require.Equal(t, int64(3_000), limits.Limits.DailyDebitTotal)
+	replayed, err := service.UpdateUserLimits(context.Background(), user.UserID, user.Currency, model.Limits{DailyDebitTotal: 3_000}, key)
+	require.NoError(t, err)
+	require.Equal(t, limits, replayed)
+	_, err = service.UpdateUserLimits(context.Background(), user.UserID, user.Currency, model.Limits{DailyDebitTotal: 4_000}, key)
+	require.ErrorIs(t, err, model.ErrIdempotencyKeyReused)
+	_, err = service.UpdateUserLimits(context.Background(), user.UserID, "XYZ", model.Limits{DailyDebitTotal: 4_000}, utils.RandomIdempotencyKey())
+	require.ErrorIs(t, err, model.ErrUnsupportedCurrency)
+
+	// 2_000 remain of the daily total of the user, whichever account is debited
+	require.NoError(t, debit(accountIDs[1], 1_500))
+	var exceeded *model.LimitExceededError
+	err = debit(accountIDs[0], 501)
+	require.ErrorAs(t, err, &exceeded)
+	require.Equal(t, model.LimitUserDailyDebitTotal, exceeded.Limit)
+	require.Equal(t, int64(3_000), exceeded.Allowed)
+	require.Equal(t, int64(500), exceeded.Remaining)
+	require.NoError(t, debit(accountIDs[0], 500))
+
+	limits, err = service.GetUserLimits(context.Background(), user.UserID, user.Currency)
+	require.NoError(t, err)
+	require.Equal(t, int64(3_000), limits.Usage.DailyDebitTotal)
+	require.Equal(t, int64(3), limits.Usage.DailyDebitCount)
+
+	// removing the limits lifts them
+	_, err = service.UpdateUserLimits(context.Background(), user.UserID, user.Currency, model.Limits{}, utils.RandomIdempotencyKey())
+	require.NoError(t, err)
+	require.NoError(t, debit(accountIDs[1], 1_000))
+
+	// Cleanup the accounts, transactions and limits we created to test
+	for _, accountID := range accountIDs {
+		deleteAccount(t, accountID)
+	}
+	_, err = db.ExecContext(context.Background(), "DELETE FROM user_limits WHERE user_id = $1", user.UserID)
+	require.NoError(t, err)
+}
+
+// A hold counts against the limits once, on the day it was placed, whenever it is captured.
+func TestAccountLimits_CapturedHold(t *testing.T) {
+	user := utils.RandomUser()
+	user.Balance = 10_000
+	key := utils.RandomIdempotencyKey()
+	createdAccount, err := service.CreateAccount(context.Background(), user, "", key, user.UserID)
+	require.NoError(t, err)
+	err = service.DeleteIdempotencyKeyByID(context.Background(), key)
+	require.NoError(t, err)
+
+	placeHold := func(amount int64) *model.Hold {
+		hold, err := service.PlaceHold(context.Background(), &model.Hold{
+			AccountID:       createdAccount.AccountID,
+			Amount:          amount,
+			Currency:        createdAccount.Currency,
+			TransactionType: "DEBIT",
+		}, 0, utils.RandomIdempotencyKey(), user.UserID)
+		require.NoError(t, err)
+		return hold
+	}
+	capture := func(hold *model.Hold) {
+		_, err := service.CaptureHold(context.Background(), hold.HoldID, money.Money{}, utils.RandomIdempotencyKey(), user.UserID, mtls.APIGateway)
+		require.NoError(t, err)
+	}
+	requireDailyUsage := func(total, count int64) {
+		limits, err := service.GetAccountLimits(context.Background(), createdAccount.AccountID, user.UserID, mtls.APIGateway)
+		require.NoError(t, err)
+		require.Equal(t, total, limits.Usage.DailyDebitTotal)
+		require.Equal(t, count, limits.Usage.DailyDebitCount)
+	}
+
+	hold := placeHold(2_000)
+	requireDailyUsage(2_000, 1)
+	capture(hold)
+	requireDailyUsage(2_000, 1)
+
+	// placed yesterday, so it doesn't count today once captured either
+	hold = placeHold(1_000)
+	_, err = db.ExecContext(context.Background(), "UPDATE holds SET created_at = created_at - INTERVAL '1 day' WHERE id = $1", hold.HoldID)
+	require.NoError(t, err)
+	requireDailyUsage(2_000, 1)
+	capture(hold)
+	requireDailyUsage(2_000, 1)
+
+	// Cleanup the account and transactions we created to test
+	deleteAccount(t, createdAccount.AccountID)
+}
+
+// creditAccount credits amount to the account of the user and returns the credit.
+func creditAccount(t *testing.T, account *model.Account, amount int64) *model.Transaction {
+	credit, err := service.CreateTransaction(context.Background(), &model.Transaction{
+		AccountID:       account.AccountID,
+		Amount:          amount,
+		Currency:        account.Currency,
+		TransactionType: "CREDIT",
+	}, utils.RandomIdempotencyKey(), account.UserID, mtls.APIGateway)
+	require.NoError(t, err)
+	return credit
+}
+
+// giveBack reverses amount of a credit, all of what remains of it if amount is zero, as the owner of its account.
+func giveBack(credit *model.Transaction, amount int64, userID uuid.UUID) (*model.Transaction, error) {
+	reversed := money.Money{}
+	if amount != 0 {
+		reversed = money.Money{Currency: credit.Currency, Units: amount}
+	}
+	return service.ReverseTransaction(context.Background(), credit.TransactionID, reversed, "refund", utils.RandomIdempotencyKey(), userID, mtls.APIGateway)
+}
+
+// A credit given back by the owner is a debit within their limits, which counts towards them.
+func TestReverseTransaction_Limits(t *testing.T) {
+	user := utils.RandomUser()
+	user.Balance = 10_000
+	key := utils.RandomIdempotencyKey()
+	createdAccount, err := service.CreateAccount(context.Background(), user, "", key, user.UserID)
+	require.NoError(t, err)
+	err = service.DeleteIdempotencyKeyByID(context.Background(), key)
+	require.NoError(t, err)
+	_, err = service.UpdateAccountLimits(context.Background(), createdAccount.AccountID,
+		model.Limits{MaxSingleDebit: 1_000, DailyDebitTotal: 1_500}, createdAccount.Currency, utils.RandomIdempotencyKey(), user.UserID, mtls.APIGateway)
+	require.NoError(t, err)
+
+	credit := creditAccount(t, createdAccount, 2_000)
+	var exceeded *model.LimitExceededError
+	_, err = giveBack(credit, 0, user.UserID)
+	require.ErrorAs(t, err, &exceeded)
+	require.Equal(t, model.LimitMaxSingleDebit, exceeded.Limit)
+
+	_, err = giveBack(credit, 1_000, user.UserID)
+	require.NoError(t, err)
+	limits, err := service.GetAccountLimits(context.Background(), createdAccount.AccountID, user.UserID, mtls.APIGateway)
+	require.NoError(t, err)
+	require.Equal(t, int64(1_000), limits.Usage.DailyDebitTotal)
+	require.Equal(t, int64(1), limits.Usage.DailyDebitCount)
+	_, err = giveBack(credit, 1_000, user.UserID)
+	require.ErrorAs(t, err, &exceeded)
+	require.Equal(t, model.LimitDailyDebitTotal, exceeded.Limit)
+
+	// the corrections of the support staff aren't held to the limits of the owner
+	_, err = service.ReverseTransaction(context.Background(), credit.TransactionID, money.Money{}, "correction", utils.RandomIdempotencyKey(), uuid.Nil, mtls.SupportConsole)
+	require.NoError(t, err)
+
+	// Cleanup the account and transactions we created to test
+	deleteAccount(t, createdAccount.AccountID)
+}
+
+// A credit given back counts as a withdrawal of a savings account, whose withdrawals per month are limited.
+func TestReverseTransaction_WithdrawalLimit(t *testing.T) {
+	user := utils.RandomUser()
+	user.Balance = 1000
+	key := utils.RandomIdempotencyKey()
+	createdAccount, err := service.CreateAccount(context.Background(), user, "SAVINGS", key, user.UserID)
+	require.NoError(t, err)
+	err = service.DeleteIdempotencyKeyByID(context.Background(), key)
+	require.NoError(t, err)
+	product, err := service.repo.GetAccountProductByCode(context.Background(), "SAVINGS")
+	require.NoError(t, err)
+
+	credit := creditAccount(t, createdAccount, 100)
+	withdrawal := utils.RandomTransaction()
+	withdrawal.AccountID = createdAccount.AccountID
+	withdrawal.TransactionType = "DEBIT"
+	withdrawal.Amount = -1
+	for range product.MonthlyWithdrawalLimit - 1 {
+		_, err = service.CreateTransaction(context.Background(), withdrawal, utils.RandomIdempotencyKey(), user.UserID, mtls.APIGateway)
+		require.NoError(t, err)
+	}
+
+	_, err = giveBack(credit, 10, user.UserID)
+	require.NoError(t, err)
+	_, err = giveBack(credit, 10, user.UserID)
+	require.ErrorIs(t, err, model.ErrWithdrawalLimitExceeded)
+	_, err = service.CreateTransaction(context.Background(), withdrawal, utils.RandomIdempotencyKey(), user.UserID, mtls.APIGateway)
+	require.ErrorIs(t, err, model.ErrWithdrawalLimitExceeded)
+
+	// Cleanup the account and transactions we created to test
+	deleteAccount(t, createdAccount.AccountID)
+}
+
+// A term deposit can't give back a credit before it matures, any more than it can be debited.
+func TestReverseTransaction_LockUp(t *testing.T) {
+	product, err := service.repo.GetAccountProductByCode(context.Background(), "TERM_DEPOSIT_12M")
+	require.NoError(t, err)
+	user := utils.RandomUser()
+	user.Balance = product.MinBalance
+	key := utils.RandomIdempotencyKey()
+	createdAccount, err := service.CreateAccount(context.Background(), user, product.Code, key, user.UserID)
+	require.NoError(t, err)
+	err = service.DeleteIdempotencyKeyByID(context.Background(), key)
 	require.NoError(t, err)
 
+	credit := creditAccount(t, createdAccount, 100)
+	_, err = giveBack(credit, 0, user.UserID)
+	require.ErrorIs(t, err, model.ErrAccountLocked)
+
+	// once it matures, it can
+	_, err = db.ExecContext(context.Background(), "UPDATE accounts SET matures_at = NOW() - INTERVAL '1 day' WHERE id = $1", createdAccount.AccountID)
+	require.NoError(t, err)
+	_, err = giveBack(credit, 0, user.UserID)
+	require.NoError(t, err)
+
+	// Cleanup the account and transactions we created to test
+	deleteAccount(t, createdAccount.AccountID)
+}
+
+// A credit given back into the overdraft is charged the overdraft fee, like a debit.
+func TestReverseTransaction_Fees(t *testing.T) {
+	const productCode = "TEST_OVERDRAFT"
+	_, err := db.ExecContext(context.Background(), `INSERT INTO account_products (code, account_type, display_name, min_balance, monthly_withdrawal_limit, lock_up_days, overdraft_limit)
+		VALUES ($1, 'CHECKING', 'Test Overdraft', 0, 0, 0, 10000) ON CONFLICT (code) DO NOTHING`, productCode)
+	require.NoError(t, err)
+	user := utils.RandomUser()
+	user.Balance = 0
+	key := utils.RandomIdempotencyKey()
+	createdAccount, err := service.CreateAccount(context.Background(), user, productCode, key, user.UserID)
+	require.NoError(t, err)
 	err = service.DeleteIdempotencyKeyByID(context.Background(), key)
 	require.NoError(t, err)
+
+	credit := creditAccount(t, createdAccount, 2_000)
+	spent := utils.RandomTransaction()
+	spent.AccountID = createdAccount.AccountID
+	spent.TransactionType = "DEBIT"
+	spent.Amount = -2_000
+	_, err = service.CreateTransaction(context.Background(), spent, utils.RandomIdempotencyKey(), user.UserID, mtls.APIGateway)
+	require.NoError(t, err)
+
+	reversal, err := giveBack(credit, 1_000, user.UserID)
+	require.NoError(t, err)
+	transactions, err := service.repo.GetTransactionsByAccountID(context.Background(), createdAccount.AccountID)
+	require.NoError(t, err)
+	var fees []*model.Transaction
+	for _, transaction := range transactions {
+		if transaction.TransactionType == model.TransactionFee {
+			fees = append(fees, transaction)
+		}
+	}
+	require.Len(t, fees, 1)
+	require.Equal(t, "OVERDRAFT_USD", fees[0].FeeRule)
+	require.Equal(t, uuid.NullUUID{UUID: reversal.TransactionID, Valid: true}, fees[0].CausedBy)
+
+	// the corrections of the support staff aren't charged
+	_, err = service.ReverseTransaction(context.Background(), credit.TransactionID, money.Money{}, "correction", utils.RandomIdempotencyKey(), uuid.Nil, mtls.SupportConsole)
+	require.NoError(t, err)
+	account, err := service.repo.GetAccountByID(context.Background(), createdAccount.AccountID)
+	require.NoError(t, err)
+	require.Equal(t, -2_000+fees[0].Amount, account.Balance)
+
+	// Cleanup the account and transactions we created to test
+	deleteAccount(t, createdAccount.AccountID)
 }
@@ -0,0 +1,261 @@
+package service
+
+import (
+	"account/internal/cache"
+	"account/internal/events"
+	"account/internal/fee"
+	"account/internal/interest"
+	"account/model"
+	"account/repository"
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// posting is a transaction committed on an account, and the account as it updated it, to publish once committed.
+type posting struct {
+	transaction *model.Transaction
+	account     *model.Account
+}
+
+// publish publishes the committed postings, in order, see CreateTransaction.
+func publish(ctx context.Context, postings []posting) {
+	for _, p := range postings {
+		if _, err := events.Publish(ctx, p.account, p.transaction); err != nil {
+			log.Printf("publish: Failed to publish transaction event: %v\n", err)
+		}
+	}
+}
+
+// chargeFees charges the fees triggered by cause, a debit of account, and returns them. account is the account as
+// updated by the debit, and product its product.
+// A transfer debit triggers the transfer fees on the transferred amount, and a debit leaving the balance below zero the
+// overdraft fees on the overdrawn amount.
+func chargeFees(ctx context.Context, txRepo *repository.AccountRepository, account *model.Account, product *model.AccountProduct, cause *model.Transaction) ([]posting, error) {
+	bases := map[string]int64{}
+	if cause.TransactionType == "TRANSFER_DEBIT" {
+		bases[model.FeeTriggerTransfer] = -cause.Amount
+	}
+	if account.Balance < 0 {
+		bases[model.FeeTriggerOverdraft] = fee.OverdraftBase(cause.Amount, account.Balance)
+	}
+
+	var fees []posting
+	for _, trigger := range []string{model.FeeTriggerTransfer, model.FeeTriggerOverdraft} {
+		base, ok := bases[trigger]
+		if !ok {
+			continue
+		}
+		rules, err := txRepo.ListFeeRules(ctx, trigger, account)
+		if err != nil {
+			log.Printf("chargeFees: Failed to list %v fee rules: %v\n", trigger, err)
+			return nil, model.Internal(err)
+		}
+		for _, rule := range rules {
+			amount := fee.Payable(fee.Compute(rule, base, account.Balance), account.Available(), product.OverdraftLimit)
+			if amount == 0 {
+				continue
+			}
+			p, err := postFee(ctx, txRepo, account, rule, amount, uuid.New(), uuid.NullUUID{UUID: cause.TransactionID, Valid: true})
+			if err != nil {
+				return nil, err
+			}
+			fees = append(fees, p)
+			account = p.account
+		}
+	}
+	return fees, nil
+}
+
+// postFee debits a fee of amount from account with the transaction id, charged by rule for causedBy if valid.
+// Fees are posted by the bank, so they don't count as activity of the account.
+func postFee(ctx context.Context, txRepo *repository.AccountRepository, account *model.Account, rule *model.FeeRule, amount int64, id uuid.UUID, causedBy uuid.NullUUID) (posting, error) {
+	transaction, err := txRepo.CreateTransaction(ctx, &model.Transaction{
+		TransactionID:   id,
+		AccountID:       account.AccountID,
+		Amount:          -amount,
+		Currency:        account.Currency,
+		TransactionType: model.TransactionFee,
+		Status:          "COMPLETED",
+		CausedBy:        causedBy,
+		FeeRule:         rule.Code,
+	})
+	if err != nil {
+		log.Printf("postFee: Failed to create transaction: %v\n", err)
+		return posting{}, model.Internal(err)
+	}
+	updatedAccount, err := txRepo.AddToAccountBalanceWithoutActivity(ctx, account.AccountID, -amount)
+	if err != nil {
+		log.Printf("postFee: Failed to update balance: %v\n", err)
+		return posting{}, model.Internal(err)
+	}
+	return posting{transaction: transaction, account: updatedAccount}, nil
+}
+
+// ChargeMaintenanceFees charges at most batchSize monthly fees for the month before the month of asOf (UTC), and
+// returns how many it charged or waived. It is a janitor.JobFunc.
+// A monthly fee is charged at most once per month and account, so running it again never charges it twice. It is
+// waived according to the balance of the account when it is charged.
+func (s *AccountService) ChargeMaintenanceFees(ctx context.Context, asOf time.Time, batchSize int32) (int64, error) {
+	day := interest.Day(asOf)
+	period := day.AddDate(0, -1, 1-day.Day())
+	fees, err := s.repo.ListMaintenanceFeesToCharge(ctx, period, batchSize)
+	if err != nil {
+		log.Printf("ChargeMaintenanceFees: Failed to list fees: %v\n", err)
+		return 0, model.Internal(err)
+	}
+
+	for i, maintenanceFee := range fees {
+		var charged []posting
+		err := s.txRunner.Run(ctx, sql.LevelReadCommitted, func(tx *sql.Tx) error {
+			var err error
+			charged, err = s.chargeMaintenanceFeeTx(ctx, tx, maintenanceFee, period)
+			return err
+		})
+		if err != nil {
+			log.Printf("ChargeMaintenanceFees: Failed to charge %v on account %v: %v\n", maintenanceFee.FeeRule, maintenanceFee.AccountID, err)
+			return int64(i), err
+		}
+		if len(charged) > 0 {
+			go cache.Invalidate(ctx, maintenanceFee.AccountID)
+			publish(ctx, charged)
+		}
+	}
+	return int64(len(fees)), nil
+}
+
+// chargeMaintenanceFeeTx charges a monthly fee for the month starting on period, and returns the fee to publish, if it
+// wasn't waived or charged already.
+func (s *AccountService) chargeMaintenanceFeeTx(ctx context.Context, tx *sql.Tx, maintenanceFee repository.MaintenanceFee, period time.Time) ([]posting, error) {
+	txRepo := s.repo.WithTx(tx)
+
+	account, err := txRepo.GetAccountByIDForUpdate(ctx, maintenanceFee.AccountID)
+	if err != nil {
+		log.Printf("chargeMaintenanceFeeTx: Failed to get account: %v\n", err)
+		return nil, model.Internal(err)
+	}
+	// closed or rejected since it was listed
+	if account.Status == model.AccountStatusPending || account.Status == model.AccountStatusClosed {
+		return nil, nil
+	}
+	product, err := txRepo.GetAccountProductByCode(ctx, account.ProductCode)
+	if err != nil {
+		log.Printf("chargeMaintenanceFeeTx: Failed to get product %v: %v\n", account.ProductCode, err)
+		return nil, model.Internal(err)
+	}
+	rule, err := txRepo.GetFeeRuleByCode(ctx, maintenanceFee.FeeRule)
+	if err != nil {
+		log.Printf("chargeMaintenanceFeeTx: Failed to get fee rule %v: %v\n", maintenanceFee.FeeRule, err)
+		return nil, model.Internal(err)
+	}
+
+	amount := fee.Payable(fee.Compute(rule, account.Balance, account.Balance), account.Available(), product.OverdraftLimit)
+	var transactionID uuid.NullUUID
+	if amount > 0 {
+		transactionID = uuid.NullUUID{UUID: uuid.New(), Valid: true}
+	}
+	created, err := txRepo.CreateMaintenanceFeePeriod(ctx, account.AccountID, rule.Code, period, transactionID)
+	if err != nil {
+		log.Printf("chargeMaintenanceFeeTx: Failed to record the fee: %v\n", err)
+		return nil, model.Internal(err)
+	}
+	if !created || amount == 0 {
+		return nil, nil
+	}
+
+	charged, err := postFee(ctx, txRepo, account, rule, amount, transactionID.UUID, uuid.NullUUID{})
+	if err != nil {
+		return nil, err
+	}
+	return []posting{charged}, nil
+}
+
+// ReverseFee refunds a fee with a FEE_REVERSAL transaction and marks the fee REVERSED, for the support staff.
+// reason is recorded with the reversal. A fee is reversed at most once: reversing it again returns the same reversal.
+func (s *AccountService) ReverseFee(ctx context.Context, feeID uuid.UUID, reason string) (*model.Transaction, error) {
+	var (
+		res      *model.Transaction
+		postings []posting
+	)
+	err := s.txRunner.Run(ctx, sql.LevelReadCommitted, func(tx *sql.Tx) error {
+		var err error
+		res, postings, err = s.reverseFeeTx(ctx, tx, feeID, reason)
+		return err
+	})
+	if err != nil {
+		log.Printf("ReverseFee: Failed to reverse fee %v: %v\n", feeID, err)
+		return nil, err
+	}
+
+	if len(postings) > 0 {
+		go cache.Invalidate(ctx, res.AccountID)
+		publish(ctx, postings)
+	}
+	return res, nil
+}
+
+// reverseFeeTx returns the reversal of a fee, and the postings to publish, none if the fee was reversed already.
+func (s *AccountService) reverseFeeTx(ctx context.Context, tx *sql.Tx, feeID uuid.UUID, reason string) (*model.Transaction, []posting, error) {
+	txRepo := s.repo.WithTx(tx)
+
+	charged, err := txRepo.GetTransactionByID(ctx, feeID)
+	if err != nil {
+		log.Printf("reverseFeeTx: Failed to get transaction: %v\n", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, model.ErrTransactionNotFound
+		}
+		return nil, nil, model.Internal(err)
+	}
+	if charged.TransactionType != model.TransactionFee {
+		log.Printf("reverseFeeTx: Transaction %v is a %v\n", feeID, charged.TransactionType)
+		return nil, nil, model.ErrNotAFee
+	}
+
+	// locked so that concurrent reversals of the fee are serialized: the second one finds the first
+	account, err := txRepo.GetAccountByIDForUpdate(ctx, charged.AccountID)
+	if err != nil {
+		log.Printf("reverseFeeTx: Failed to get account: %v\n", err)
+		return nil, nil, model.Internal(err)
+	}
+	reversal, err := txRepo.GetFeeReversal(ctx, feeID)
+	if err == nil {
+		return reversal, nil, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("reverseFeeTx: Failed to get the reversal: %v\n", err)
+		return nil, nil, model.Internal(err)
+	}
+	// a closed account has a zero balance for good
+	if account.Status == model.AccountStatusClosed {
+		return nil, nil, model.ErrAccountClosed
+	}
+
+	reversal, err = txRepo.CreateTransaction(ctx, &model.Transaction{
+		TransactionID:   uuid.New(),
+		AccountID:       account.AccountID,
+		Amount:          -charged.Amount,
+		Currency:        charged.Currency,
+		TransactionType: model.TransactionFeeReversal,
+		Status:          "COMPLETED",
+		CausedBy:        uuid.NullUUID{UUID: feeID, Valid: true},
+		Memo:            reason,
+	})
+	if err != nil {
+		log.Printf("reverseFeeTx: Failed to create transaction: %v\n", err)
+		return nil, nil, model.Internal(err)
+	}
+	if err = txRepo.UpdateTransactionStatus(ctx, feeID, "REVERSED"); err != nil {
+		log.Printf("reverseFeeTx: Failed to update fee: %v\n", err)
+		return nil, nil, model.Internal(err)
+	}
+	updatedAccount, err := txRepo.AddToAccountBalanceWithoutActivity(ctx, account.AccountID, reversal.Amount)
+	if err != nil {
+		log.Printf("reverseFeeTx: Failed to update balance: %v\n", err)
+		return nil, nil, model.Internal(err)
+	}
+	return reversal, []posting{{transaction: reversal, account: updatedAccount}}, nil
+}
@@ -0,0 +1,484 @@
+package service
+
+import (
+	"account/internal/cache"
+	"account/internal/idempotency"
+	"account/internal/mtls"
+	"account/model"
+	"account/money"
+	"account/repository"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// defaultHoldTTL is how long a hold reserves its amount when neither the request nor the config says otherwise.
+const defaultHoldTTL = 7 * 24 * time.Hour
+
+// PlaceHold reserves hold.Amount (positive) of hold.AccountID, to be debited with a transaction of
+// hold.TransactionType when the hold is captured. The hold expires after ttl, or after the TTL of the service if ttl
+// is 0. It fails the way a debit of the same amount would, except that nothing is debited yet.
+// userID is the ID of the user who initiated the request
+func (s *AccountService) PlaceHold(ctx context.Context, hold *model.Hold, ttl time.Duration, idempotencyKey string, userID uuid.UUID) (*model.Hold, error) {
+	if ttl == 0 {
+		ttl = s.holdTTL
+	}
+
+	var res *model.Hold
+	err := s.txRunner.Run(ctx, sql.LevelReadCommitted, func(tx *sql.Tx) error {
+		var err error
+		res, err = s.placeHoldTx(ctx, tx, hold, ttl, idempotencyKey, userID)
+		return err
+	})
+	if err != nil {
+		log.Printf("PlaceHold: Failed to place hold: %v\n", err)
+		return nil, err
+	}
+	// the available balance changed, not the balance: there is no event to publish
+	go cache.Invalidate(ctx, hold.AccountID)
+	return res, nil
+}
+
+// userID is the ID of the user who initiated the request
+func (s *AccountService) placeHoldTx(ctx context.Context, tx *sql.Tx, hold *model.Hold, ttl time.Duration, idempotencyKey string, userID uuid.UUID) (*model.Hold, error) {
+	txRepo := s.repo.WithTx(tx)
+
+	// locked so that its status and its available balance can't change until we commit
+	account, err := txRepo.GetAccountByIDForUpdate(ctx, hold.AccountID)
+	if err != nil {
+		log.Printf("placeHoldTx: Failed to get account: %v\n", err)
+		if err == sql.ErrNoRows {
+			return nil, model.ErrAccountNotFound
+		}
+		return nil, model.Internal(err)
+	}
+	if account.UserID != userID {
+		log.Printf("placeHoldTx: Unauthorized hold attempt on account %v by user %v\n", account.AccountID, userID)
+		return nil, model.ErrNotAuthorized
+	}
+
+	requestHash, err := idempotency.RequestHash(struct {
+		AccountID       uuid.UUID
+		Amount          int64
+		Currency        string
+		TransactionType string
+		TransferID      uuid.NullUUID
+		TTL             time.Duration
+	}{hold.AccountID, hold.Amount, hold.Currency, hold.TransactionType, hold.TransferID, ttl})
+	if err != nil {
+		log.Printf("placeHoldTx: Failed to hash request: %v\n", err)
+		return nil, model.ErrInternalServer
+	}
+
+	key, ran, err := s.claimIdempotencyKey(ctx, tx, txRepo, &model.IdempotencyKey{
+		KeyID:       idempotencyKey,
+		UserID:      userID,
+		RPCName:     "PlaceHold",
+		RequestHash: requestHash,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !ran {
+		cachedHold := &model.Hold{}
+		if err := json.Unmarshal([]byte(key.ResponseMessage), cachedHold); err != nil {
+			log.Printf("placeHoldTx: Failed to unmarshal hold: %v\n", err)
+			return nil, model.ErrInternalServer
+		}
+		return cachedHold, nil
+	}
+
+	if hold.Amount <= 0 || ttl <= 0 {
+		log.Printf("placeHoldTx: Invalid hold of %v for %v\n", hold.Amount, ttl)
+		return nil, failIdempotencyKey(ctx, tx, txRepo, key, model.ErrInvalidArgument)
+	}
+	if hold.Currency != account.Currency {
+		log.Printf("placeHoldTx: Hold in %q on account %v in %v\n", hold.Currency, account.AccountID, account.Currency)
+		return nil, failIdempotencyKey(ctx, tx, txRepo, key, model.ErrCurrencyMismatch)
+	}
+	if err = checkAccountStatus(account, -hold.Amount); err != nil {
+		log.Printf("placeHoldTx: Account %v is %v\n", account.AccountID, account.Status)
+		return nil, failIdempotencyKey(ctx, tx, txRepo, key, err)
+	}
+	if time.Now().Before(account.MaturesAt) {
+		log.Printf("placeHoldTx: Hold on account %v before it matures on %v\n", account.AccountID, account.MaturesAt)
+		return nil, failIdempotencyKey(ctx, tx, txRepo, key, model.ErrAccountLocked)
+	}
+
+	newHold := *hold
+	newHold.ExpiresAt = time.Now().Add(ttl)
+	createdHold, err := txRepo.CreateHold(ctx, &newHold)
+	if err != nil {
+		log.Printf("placeHoldTx: Failed to create hold: %v\n", err)
+		return nil, model.Internal(err)
+	}
+	updatedAccount, err := txRepo.AddToAccountHeld(ctx, account.AccountID, hold.Amount)
+	if err != nil {
+		log.Printf("placeHoldTx: Failed to update held amount: %v\n", err)
+		return nil, model.Internal(err)
+	}
+
+	// the hold is checked like the debit it will be captured as, so that capturing it never fails on these rules
+	product, err := txRepo.GetAccountProductByCode(ctx, account.ProductCode)
+	if err != nil {
+		log.Printf("placeHoldTx: Failed to get product %v: %v\n", account.ProductCode, err)
+		return nil, model.Internal(err)
+	}
+	if updatedAccount.Available() < -product.OverdraftLimit {
+		log.Printf("placeHoldTx: Insufficient funds on account %v\n", account.AccountID)
+		return nil, failIdempotencyKey(ctx, tx, txRepo, key, model.ErrInsufficientFunds)
+	}
+	if err = checkWithdrawalRules(ctx, txRepo, updatedAccount, product); err != nil {
+		if errors.Is(err, model.ErrInternalServer) {
+			return nil, err
+		}
+		return nil, failIdempotencyKey(ctx, tx, txRepo, key, err)
+	}
+	// this is where transfers are limited, since capturing their hold checks nothing
+	if err = checkLimits(ctx, txRepo, updatedAccount, product, hold.Amount); err != nil {
+		if errors.Is(err, model.ErrInternalServer) {
+			return nil, err
+		}
+		return nil, failIdempotencyKey(ctx, tx, txRepo, key, err)
+	}
+
+	key.Status = "COMPLETED"
+	marshalled, err := json.Marshal(createdHold)
+	if err != nil {
+		log.Printf("placeHoldTx: Failed to marshal hold: %v\n", err)
+		return nil, model.ErrInternalServer
+	}
+	key.ResponseMessage = string(marshalled)
+	if _, err = txRepo.UpdateIdempotencyKey(ctx, key); err != nil {
+		log.Printf("placeHoldTx: Failed to update idempotency key: %v\n", err)
+		return nil, model.Internal(err)
+	}
+	return createdHold, nil
+}
+
+// CaptureHold posts amount of an ACTIVE hold as a transaction of the type of the hold, and releases the rest of the
+// hold. amount is the whole hold if it is zero. It returns the transaction, after which it charges the fees of the debit.
+// The funds were reserved by the hold, so the capture only fails if the hold is no longer ACTIVE: it is captured even
+// if the account was frozen since. Nor are the limits checked again: the debit counts against the limits of the day the
+// hold was placed, which were checked then.
+// Only the transfer service may capture the holds of transfers, caller being the identity of the calling service.
+// userID is the ID of the user who initiated the request
+func (s *AccountService) CaptureHold(ctx context.Context, holdID uuid.UUID, amount money.Money, idempotencyKey string, userID uuid.UUID, caller string) (*model.Transaction, error) {
+	var (
+		res      *model.Transaction
+		postings []posting
+	)
+	err := s.txRunner.Run(ctx, sql.LevelReadCommitted, func(tx *sql.Tx) error {
+		var err error
+		res, postings, err = s.captureHoldTx(ctx, tx, holdID, amount, idempotencyKey, userID, caller)
+		return err
+	})
+	if err != nil {
+		log.Printf("CaptureHold: Failed to capture hold %v: %v\n", holdID, err)
+		return nil, err
+	}
+
+	go cache.Invalidate(ctx, res.AccountID)
+	publish(ctx, postings)
+	return res, nil
+}
+
+// captureHoldTx returns the transaction capturing the hold, and the postings to publish: the transaction and the fees
+// it triggered. There are none if the request was replayed.
+// userID is the ID of the user who initiated the request
+func (s *AccountService) captureHoldTx(ctx context.Context, tx *sql.Tx, holdID uuid.UUID, amount money.Money, idempotencyKey string, userID uuid.UUID, caller string) (*model.Transaction, []posting, error) {
+	txRepo := s.repo.WithTx(tx)
+
+	hold, account, err := s.lockHold(ctx, txRepo, holdID, userID, caller)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	requestHash, err := idempotency.RequestHash(struct {
+		HoldID   uuid.UUID
+		Amount   int64
+		Currency string
+	}{holdID, amount.Units, amount.Currency})
+	if err != nil {
+		log.Printf("captureHoldTx: Failed to hash request: %v\n", err)
+		return nil, nil, model.ErrInternalServer
+	}
+
+	key, ran, err := s.claimIdempotencyKey(ctx, tx, txRepo, &model.IdempotencyKey{
+		KeyID:       idempotencyKey,
+		UserID:      userID,
+		RPCName:     "CaptureHold",
+		RequestHash: requestHash,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ran {
+		cachedTransaction := &model.Transaction{}
+		if err := json.Unmarshal([]byte(key.ResponseMessage), cachedTransaction); err != nil {
+			log.Printf("captureHoldTx: Failed to unmarshal transaction: %v\n", err)
+			return nil, nil, model.ErrInternalServer
+		}
+		return cachedTransaction, nil, nil
+	}
+
+	if err = checkHoldActive(hold); err != nil {
+		log.Printf("captureHoldTx: Hold %v is %v, expiring at %v\n", holdID, hold.Status, hold.ExpiresAt)
+		return nil, nil, failIdempotencyKey(ctx, tx, txRepo, key, err)
+	}
+	captured := hold.Amount
+	if amount.Units != 0 {
+		if amount.Currency != hold.Currency {
+			log.Printf("captureHoldTx: Capture in %q of hold %v in %v\n", amount.Currency, holdID, hold.Currency)
+			return nil, nil, failIdempotencyKey(ctx, tx, txRepo, key, model.ErrCurrencyMismatch)
+		}
+		captured = amount.Units
+	}
+	if captured < 0 {
+		return nil, nil, failIdempotencyKey(ctx, tx, txRepo, key, model.ErrInvalidArgument)
+	}
+	if captured > hold.Amount {
+		log.Printf("captureHoldTx: Capture of %v on hold %v of %v\n", captured, holdID, hold.Amount)
+		return nil, nil, failIdempotencyKey(ctx, tx, txRepo, key, model.ErrCaptureExceedsHold)
+	}
+
+	if _, err = txRepo.SettleHold(ctx, holdID, model.HoldStatusCaptured, captured); err != nil {
+		log.Printf("captureHoldTx: Failed to capture hold: %v\n", err)
+		return nil, nil, model.Internal(err)
+	}
+	if _, err = txRepo.AddToAccountHeld(ctx, account.AccountID, -hold.Amount); err != nil {
+		log.Printf("captureHoldTx: Failed to release held amount: %v\n", err)
+		return nil, nil, model.Internal(err)
+	}
+	createdTransaction, err := txRepo.CreateTransaction(ctx, &model.Transaction{
+		AccountID:       account.AccountID,
+		Amount:          -captured,
+		Currency:        hold.Currency,
+		TransactionType: hold.TransactionType,
+		Status:          "COMPLETED",
+		TransferID:      hold.TransferID,
+		HoldID:          uuid.NullUUID{UUID: holdID, Valid: true},
+	})
+	if err != nil {
+		log.Printf("captureHoldTx: Failed to create transaction: %v\n", err)
+		return nil, nil, model.Internal(err)
+	}
+	updatedAccount, err := txRepo.AddToAccountBalance(ctx, account.AccountNumber, -captured)
+	if err != nil {
+		log.Printf("captureHoldTx: Failed to update balance: %v\n", err)
+		return nil, nil, model.Internal(err)
+	}
+	postings := []posting{{transaction: createdTransaction, account: updatedAccount}}
+
+	product, err := txRepo.GetAccountProductByCode(ctx, account.ProductCode)
+	if err != nil {
+		log.Printf("captureHoldTx: Failed to get product %v: %v\n", account.ProductCode, err)
+		return nil, nil, model.Internal(err)
+	}
+	fees, err := chargeFees(ctx, txRepo, updatedAccount, product, createdTransaction)
+	if err != nil {
+		return nil, nil, err
+	}
+	postings = append(postings, fees...)
+
+	key.Status = "COMPLETED"
+	marshalled, err := json.Marshal(createdTransaction)
+	if err != nil {
+		log.Printf("captureHoldTx: Failed to marshal transaction: %v\n", err)
+		return nil, nil, model.ErrInternalServer
+	}
+	key.ResponseMessage = string(marshalled)
+	if _, err = txRepo.UpdateIdempotencyKey(ctx, key); err != nil {
+		log.Printf("captureHoldTx: Failed to update idempotency key: %v\n", err)
+		return nil, nil, model.Internal(err)
+	}
+	return createdTransaction, postings, nil
+}
+
+// VoidHold releases an ACTIVE hold without debiting anything. Voiding a hold that was voided or expired already
+// returns it as is.
+// Only the transfer service may void the holds of transfers, caller being the identity of the calling service.
+// userID is the ID of the user who initiated the request
+func (s *AccountService) VoidHold(ctx context.Context, holdID uuid.UUID, idempotencyKey string, userID uuid.UUID, caller string) (*model.Hold, error) {
+	var res *model.Hold
+	err := s.txRunner.Run(ctx, sql.LevelReadCommitted, func(tx *sql.Tx) error {
+		var err error
+		res, err = s.voidHoldTx(ctx, tx, holdID, idempotencyKey, userID, caller)
+		return err
+	})
+	if err != nil {
+		log.Printf("VoidHold: Failed to void hold %v: %v\n", holdID, err)
+		return nil, err
+	}
+	go cache.Invalidate(ctx, res.AccountID)
+	return res, nil
+}
+
+// userID is the ID of the user who initiated the request
+func (s *AccountService) voidHoldTx(ctx context.Context, tx *sql.Tx, holdID uuid.UUID, idempotencyKey string, userID uuid.UUID, caller string) (*model.Hold, error) {
+	txRepo := s.repo.WithTx(tx)
+
+	hold, account, err := s.lockHold(ctx, txRepo, holdID, userID, caller)
+	if err != nil {
+		return nil, err
+	}
+
+	requestHash, err := idempotency.RequestHash(struct {
+		HoldID uuid.UUID
+	}{holdID})
+	if err != nil {
+		log.Printf("voidHoldTx: Failed to hash request: %v\n", err)
+		return nil, model.ErrInternalServer
+	}
+
+	key, ran, err := s.claimIdempotencyKey(ctx, tx, txRepo, &model.IdempotencyKey{
+		KeyID:       idempotencyKey,
+		UserID:      userID,
+		RPCName:     "VoidHold",
+		RequestHash: requestHash,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !ran {
+		cachedHold := &model.Hold{}
+		if err := json.Unmarshal([]byte(key.ResponseMessage), cachedHold); err != nil {
+			log.Printf("voidHoldTx: Failed to unmarshal hold: %v\n", err)
+			return nil, model.ErrInternalServer
+		}
+		return cachedHold, nil
+	}
+
+	voidedHold := hold
+	switch hold.Status {
+	case model.HoldStatusVoided, model.HoldStatusExpired: // released already
+	case model.HoldStatusCaptured:
+		log.Printf("voidHoldTx: Hold %v was captured\n", holdID)
+		return nil, failIdempotencyKey(ctx, tx, txRepo, key, model.ErrHoldNotActive)
+	default:
+		voidedHold, err = txRepo.SettleHold(ctx, holdID, model.HoldStatusVoided, 0)
+		if err != nil {
+			log.Printf("voidHoldTx: Failed to void hold: %v\n", err)
+			return nil, model.Internal(err)
+		}
+		if _, err = txRepo.AddToAccountHeld(ctx, account.AccountID, -hold.Amount); err != nil {
+			log.Printf("voidHoldTx: Failed to release held amount: %v\n", err)
+			return nil, model.Internal(err)
+		}
+	}
+
+	key.Status = "COMPLETED"
+	marshalled, err := json.Marshal(voidedHold)
+	if err != nil {
+		log.Printf("voidHoldTx: Failed to marshal hold: %v\n", err)
+		return nil, model.ErrInternalServer
+	}
+	key.ResponseMessage = string(marshalled)
+	if _, err = txRepo.UpdateIdempotencyKey(ctx, key); err != nil {
+		log.Printf("voidHoldTx: Failed to update idempotency key: %v\n", err)
+		return nil, model.Internal(err)
+	}
+	return voidedHold, nil
+}
+
+// lockHold returns a hold and its account, locked so that the hold can't be settled concurrently, once it checked that
+// the user owns the account and that caller may settle the hold.
+func (s *AccountService) lockHold(ctx context.Context, txRepo *repository.AccountRepository, holdID uuid.UUID, userID uuid.UUID, caller string) (*model.Hold, *model.Account, error) {
+	hold, err := txRepo.GetHoldByID(ctx, holdID)
+	if err != nil {
+		log.Printf("lockHold: Failed to get hold: %v\n", err)
+		if err == sql.ErrNoRows {
+			return nil, nil, model.ErrHoldNotFound
+		}
+		return nil, nil, model.Internal(err)
+	}
+	// every change of a hold is made with its account locked
+	account, err := txRepo.GetAccountByIDForUpdate(ctx, hold.AccountID)
+	if err != nil {
+		log.Printf("lockHold: Failed to get account: %v\n", err)
+		return nil, nil, model.Internal(err)
+	}
+	if account.UserID != userID {
+		log.Printf("lockHold: Unauthorized access attempt to hold %v by user %v\n", holdID, userID)
+		return nil, nil, model.ErrNotAuthorized
+	}
+	// otherwise the owner could void the hold of a transfer whose credit was posted
+	if hold.TransactionType == "TRANSFER_DEBIT" && caller != mtls.TransferService {
+		log.Printf("lockHold: %q may not settle hold %v of transfer %v\n", caller, holdID, hold.TransferID.UUID)
+		return nil, nil, model.ErrNotAuthorized
+	}
+	// read again now that nothing can change it
+	hold, err = txRepo.GetHoldByID(ctx, holdID)
+	if err != nil {
+		log.Printf("lockHold: Failed to get hold: %v\n", err)
+		return nil, nil, model.Internal(err)
+	}
+	return hold, account, nil
+}
+
+// checkHoldActive returns why a hold can't be captured, if it can't. A hold past its expiry is expired, even if the
+// janitor didn't release it yet.
+func checkHoldActive(hold *model.Hold) error {
+	switch {
+	case hold.Status == model.HoldStatusExpired:
+		return model.ErrHoldExpired
+	case hold.Status != model.HoldStatusActive:
+		return model.ErrHoldNotActive
+	case !time.Now().Before(hold.ExpiresAt):
+		return model.ErrHoldExpired
+	}
+	return nil
+}
+
+// ExpireHolds releases at most batchSize ACTIVE holds that expired before now, and returns how many it released. It is
+// a janitor.JobFunc. The holds captured or voided since they were listed aren't counted, which may end the run of the
+// janitor early: its next run expires the rest.
+func (s *AccountService) ExpireHolds(ctx context.Context, now time.Time, batchSize int32) (int64, error) {
+	holds, err := s.repo.ListExpiredHolds(ctx, now, batchSize)
+	if err != nil {
+		log.Printf("ExpireHolds: Failed to list holds: %v\n", err)
+		return 0, model.Internal(err)
+	}
+
+	var expired int64
+	for _, hold := range holds {
+		settled := false
+		err := s.txRunner.Run(ctx, sql.LevelReadCommitted, func(tx *sql.Tx) error {
+			settled = false
+			txRepo := s.repo.WithTx(tx)
+			if _, err := txRepo.GetAccountByIDForUpdate(ctx, hold.AccountID); err != nil {
+				log.Printf("ExpireHolds: Failed to get account: %v\n", err)
+				return model.Internal(err)
+			}
+			if _, err := txRepo.SettleHold(ctx, hold.HoldID, model.HoldStatusExpired, 0); err != nil {
+				// settled since it was listed
+				if errors.Is(err, sql.ErrNoRows) {
+					settled = true
+					return nil
+				}
+				log.Printf("ExpireHolds: Failed to expire hold: %v\n", err)
+				return model.Internal(err)
+			}
+			if _, err := txRepo.AddToAccountHeld(ctx, hold.AccountID, -hold.Amount); err != nil {
+				log.Printf("ExpireHolds: Failed to release held amount: %v\n", err)
+				return model.Internal(err)
+			}
+			return nil
+		})
+		if err != nil {
+			log.Printf("ExpireHolds: Failed to expire hold %v: %v\n", hold.HoldID, err)
+			return expired, err
+		}
+		if settled {
+			continue
+		}
+		expired++
+		go cache.Invalidate(ctx, hold.AccountID)
+	}
+	return expired, nil
+}
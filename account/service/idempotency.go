@@ -0,0 +1,104 @@
+package service
+
+import (
+	"account/internal/idempotency"
+	"account/model"
+	"account/repository"
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// defaultIdempotencyLease is how long an attempt holds the idempotency key of its request. A key still PENDING after
+// that was abandoned and may be taken over by a retry, and a retry doesn't wait longer than that for a concurrent
+// attempt holding the key.
+const defaultIdempotencyLease = 30 * time.Second
+
+// claimSavepoint is taken right after an idempotency key is claimed, so that failIdempotencyKey can undo the request
+// and keep the claim.
+const claimSavepoint = "idempotency_key_claimed"
+
+// claimIdempotencyKey claims the idempotency key of a request for this attempt, and returns whether the request should
+// run. If it shouldn't, the request already completed and the returned key holds its response.
+// The errors of the request are returned as is if it already failed, and the ones of a key reused for a different
+// request or still held by a concurrent attempt are returned too.
+func (s *AccountService) claimIdempotencyKey(ctx context.Context, tx *sql.Tx, txRepo *repository.AccountRepository, claim *model.IdempotencyKey) (*model.IdempotencyKey, bool, error) {
+	claim.ClaimID = uuid.New()
+	claim.Status = "PENDING"
+
+	// the statement will block if another concurrent transaction already inserted the same key, even if it hasn't committed yet.
+	key, err := txRepo.GetOrClaimIdempotencyKey(ctx, claim, s.idempotencyLease)
+	if err != nil {
+		log.Printf("claimIdempotencyKey: Failed to get idempotency key: %v\n", err)
+		// Postgres error code 55P03 (lock_not_available): the concurrent transaction holding the key outlived the lease
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "55P03" {
+			return nil, false, model.ErrIdempotencyKeyInProgress
+		}
+		return nil, false, model.Internal(err)
+	}
+
+	if !key.Matches(claim.RPCName, claim.RequestHash) {
+		log.Printf("claimIdempotencyKey: idempotency key %v reused for a different request\n", key.KeyID)
+		return nil, false, model.ErrIdempotencyKeyReused
+	}
+
+	if key.ClaimID != claim.ClaimID {
+		switch key.Status {
+		case "COMPLETED":
+			log.Printf("claimIdempotencyKey: idempotency key already exists: %v\n", key.KeyID)
+			idempotency.MarkReplayed(ctx)
+			return key, false, nil
+		case "FAILED":
+			log.Printf("claimIdempotencyKey: idempotency key already failed: %v\n", key.KeyID)
+			st, err := idempotency.DecodeError(key.ResponseMessage)
+			if err != nil {
+				log.Printf("claimIdempotencyKey: Failed to decode error: %v\n", err)
+				return nil, false, model.ErrInternalServer
+			}
+			idempotency.MarkReplayed(ctx)
+			return nil, false, st.Err()
+		default: // PENDING, held by another attempt whose lease hasn't expired
+			log.Printf("claimIdempotencyKey: idempotency key %v held by another attempt\n", key.KeyID)
+			return nil, false, model.ErrIdempotencyKeyInProgress
+		}
+	}
+
+	if _, err = tx.ExecContext(ctx, "SAVEPOINT "+claimSavepoint); err != nil {
+		log.Printf("claimIdempotencyKey: Failed to create savepoint: %v\n", err)
+		return nil, false, model.Internal(err)
+	}
+	return key, true, nil
+}
+
+// failIdempotencyKey records cause, a failure of the request that retrying it can't fix, as the outcome of its
+// idempotency key and commits tx, so that the retries of the request get the same error instead of running it again.
+// Everything the request did since the key was claimed is rolled back. It returns cause, or the error that prevented
+// recording it.
+func failIdempotencyKey(ctx context.Context, tx *sql.Tx, txRepo *repository.AccountRepository, key *model.IdempotencyKey, cause error) error {
+	if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+claimSavepoint); err != nil {
+		log.Printf("failIdempotencyKey: Failed to roll back to savepoint: %v\n", err)
+		return model.Internal(err)
+	}
+
+	encoded, err := idempotency.EncodeError(cause)
+	if err != nil {
+		log.Printf("failIdempotencyKey: Failed to encode error: %v\n", err)
+		return model.ErrInternalServer
+	}
+	key.Status = "FAILED"
+	key.ResponseMessage = encoded
+	if _, err = txRepo.UpdateIdempotencyKey(ctx, key); err != nil {
+		log.Printf("failIdempotencyKey: Failed to update idempotency key: %v\n", err)
+		return model.Internal(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("failIdempotencyKey: Failed to commit transaction: %v\n", err)
+		return model.Internal(err)
+	}
+	return cause
+}
@@ -0,0 +1,249 @@
+package service
+
+import (
+	"account/internal/cache"
+	"account/internal/interest"
+	"account/model"
+	"account/repository"
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"math/big"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AccrueInterest accrues the interest of at most batchSize accounts for every day up to the day before asOf (UTC), the
+// last day that has ended, and returns how many accounts it accrued for. It is a janitor.JobFunc, so that the
+// janitor runs it with the current time, and the tests with any time.
+// Every account accrues in its own transaction, and no longer needs to accrue once it did.
+func (s *AccountService) AccrueInterest(ctx context.Context, asOf time.Time, batchSize int32) (int64, error) {
+	through := interest.Day(asOf).AddDate(0, 0, -1)
+	accountIDs, err := s.repo.ListAccountsToAccrue(ctx, through, batchSize)
+	if err != nil {
+		log.Printf("AccrueInterest: Failed to list accounts: %v\n", err)
+		return 0, model.Internal(err)
+	}
+
+	for i, accountID := range accountIDs {
+		err := s.txRunner.Run(ctx, sql.LevelReadCommitted, func(tx *sql.Tx) error {
+			return s.accrueInterestTx(ctx, tx, accountID, through)
+		})
+		if err != nil {
+			log.Printf("AccrueInterest: Failed to accrue interest of account %v: %v\n", accountID, err)
+			return int64(i), err
+		}
+	}
+	return int64(len(accountIDs)), nil
+}
+
+// accrueInterestTx accrues the interest of an account for the days after the last one it accrued for, up to through.
+// The account is locked, so that its balance and its transactions don't change until we commit.
+func (s *AccountService) accrueInterestTx(ctx context.Context, tx *sql.Tx, accountID uuid.UUID, through time.Time) error {
+	txRepo := s.repo.WithTx(tx)
+
+	account, err := txRepo.GetAccountByIDForUpdate(ctx, accountID)
+	if err != nil {
+		log.Printf("accrueInterestTx: Failed to get account: %v\n", err)
+		return model.Internal(err)
+	}
+	// closed or rejected since it was listed
+	if account.Status == model.AccountStatusPending || account.Status == model.AccountStatusClosed {
+		return nil
+	}
+	from := interest.Day(account.CreatedAt)
+	if !account.InterestAccruedThrough.IsZero() {
+		from = interest.Day(account.InterestAccruedThrough).AddDate(0, 0, 1)
+	}
+	if from.After(through) {
+		return nil
+	}
+
+	product, err := txRepo.GetAccountProductByCode(ctx, account.ProductCode)
+	if err != nil {
+		log.Printf("accrueInterestTx: Failed to get product %v: %v\n", account.ProductCode, err)
+		return model.Internal(err)
+	}
+	schedule, err := interestSchedule(ctx, txRepo, product.Code)
+	if err != nil {
+		return err
+	}
+
+	for day := from; !day.After(through); day = day.AddDate(0, 0, 1) {
+		// the balance at the end of the day is the one at the start of the next
+		balance, err := txRepo.GetEndOfDayBalance(ctx, accountID, day.AddDate(0, 0, 1))
+		if err != nil {
+			log.Printf("accrueInterestTx: Failed to get the balance of account %v on %v: %v\n", accountID, day, err)
+			return model.Internal(err)
+		}
+		rate := schedule.On(day)
+		amount, err := interest.Accrue(balance, rate, product.DayCount, day)
+		if err != nil {
+			log.Printf("accrueInterestTx: Failed to accrue interest of account %v: %v\n", accountID, err)
+			return model.Internal(err)
+		}
+		err = txRepo.CreateInterestAccrual(ctx, &model.InterestAccrual{
+			AccountID:   accountID,
+			AccrualDate: day,
+			EODBalance:  balance,
+			AnnualRate:  interest.Format(rate),
+			Amount:      interest.Format(amount),
+		})
+		if err != nil {
+			log.Printf("accrueInterestTx: Failed to create accrual: %v\n", err)
+			return model.Internal(err)
+		}
+	}
+
+	if err = txRepo.SetInterestAccruedThrough(ctx, accountID, through); err != nil {
+		log.Printf("accrueInterestTx: Failed to update account: %v\n", err)
+		return model.Internal(err)
+	}
+	return nil
+}
+
+// interestSchedule returns the rates of the product code.
+func interestSchedule(ctx context.Context, txRepo *repository.AccountRepository, code string) (interest.Schedule, error) {
+	rates, err := txRepo.ListProductInterestRates(ctx, code)
+	if err != nil {
+		log.Printf("interestSchedule: Failed to list the rates of product %v: %v\n", code, err)
+		return nil, model.Internal(err)
+	}
+	schedule := make([]interest.Rate, len(rates))
+	for i, rate := range rates {
+		annualRate, err := interest.Parse(rate.AnnualRate)
+		if err != nil {
+			log.Printf("interestSchedule: Invalid rate of product %v: %v\n", code, err)
+			return nil, model.Internal(err)
+		}
+		schedule[i] = interest.Rate{EffectiveFrom: rate.EffectiveFrom, AnnualRate: annualRate}
+	}
+	return interest.NewSchedule(schedule), nil
+}
+
+// PostInterest posts the interest accrued over at most batchSize months before the month of asOf (UTC), oldest first,
+// as INTEREST transactions, and returns how many months it posted. It is a janitor.JobFunc, to run after
+// AccrueInterest.
+// Only the whole minor units of the interest are credited, and the remainder is carried to the next month. A month is
+// posted at most once per account, so running it again, or concurrently, never pays the interest twice.
+func (s *AccountService) PostInterest(ctx context.Context, asOf time.Time, batchSize int32) (int64, error) {
+	day := interest.Day(asOf)
+	before := day.AddDate(0, 0, 1-day.Day())
+	periods, err := s.repo.ListPeriodsToPost(ctx, before, batchSize)
+	if err != nil {
+		log.Printf("PostInterest: Failed to list periods: %v\n", err)
+		return 0, model.Internal(err)
+	}
+
+	for i, period := range periods {
+		var (
+			transaction    *model.Transaction
+			updatedAccount *model.Account
+		)
+		err := s.txRunner.Run(ctx, sql.LevelReadCommitted, func(tx *sql.Tx) error {
+			var err error
+			transaction, updatedAccount, err = s.postInterestTx(ctx, tx, period.AccountID, period.Period)
+			return err
+		})
+		if err != nil {
+			log.Printf("PostInterest: Failed to post interest of account %v for %v: %v\n", period.AccountID, period.Period, err)
+			return int64(i), err
+		}
+		if transaction == nil {
+			continue
+		}
+
+		go cache.Invalidate(ctx, period.AccountID)
+		publish(ctx, []posting{{transaction: transaction, account: updatedAccount}})
+	}
+	return int64(len(periods)), nil
+}
+
+// postInterestTx posts the interest of an account over the month starting on period, and returns the INTEREST
+// transaction and the account it credited. Both are nil if there was nothing to credit, or the month was posted
+// already.
+func (s *AccountService) postInterestTx(ctx context.Context, tx *sql.Tx, accountID uuid.UUID, period time.Time) (*model.Transaction, *model.Account, error) {
+	txRepo := s.repo.WithTx(tx)
+
+	// locked so that concurrent postings of the account are serialized, each one carrying the remainder of the previous
+	account, err := txRepo.GetAccountByIDForUpdate(ctx, accountID)
+	if err != nil {
+		log.Printf("postInterestTx: Failed to get account: %v\n", err)
+		return nil, nil, model.Internal(err)
+	}
+
+	sum, err := txRepo.SumInterestAccruals(ctx, accountID, period)
+	if err != nil {
+		log.Printf("postInterestTx: Failed to sum accruals: %v\n", err)
+		return nil, nil, model.Internal(err)
+	}
+	accrued, err := interest.Parse(sum)
+	if err != nil {
+		log.Printf("postInterestTx: Invalid accruals: %v\n", err)
+		return nil, nil, model.Internal(err)
+	}
+	last, err := txRepo.GetLastInterestPosting(ctx, accountID, period)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		log.Printf("postInterestTx: Failed to get the last posting: %v\n", err)
+		return nil, nil, model.Internal(err)
+	default:
+		carried, err := interest.Parse(last.Carried)
+		if err != nil {
+			log.Printf("postInterestTx: Invalid carried interest: %v\n", err)
+			return nil, nil, model.Internal(err)
+		}
+		accrued.Add(accrued, carried)
+	}
+
+	posted, carried := splitInterest(accrued, account)
+	posting := &model.InterestPosting{
+		AccountID: accountID,
+		Period:    period,
+		Accrued:   interest.Format(accrued),
+		Posted:    posted,
+		Carried:   interest.Format(carried),
+	}
+	if posted > 0 {
+		posting.TransactionID = uuid.NullUUID{UUID: uuid.New(), Valid: true}
+	}
+	created, err := txRepo.CreateInterestPosting(ctx, posting)
+	if err != nil {
+		log.Printf("postInterestTx: Failed to create posting: %v\n", err)
+		return nil, nil, model.Internal(err)
+	}
+	if !created || posted == 0 {
+		return nil, nil, nil
+	}
+
+	transaction, err := txRepo.CreateTransaction(ctx, &model.Transaction{
+		TransactionID:   posting.TransactionID.UUID,
+		AccountID:       accountID,
+		Amount:          posted,
+		Currency:        account.Currency,
+		TransactionType: model.TransactionInterest,
+		Status:          "COMPLETED",
+	})
+	if err != nil {
+		log.Printf("postInterestTx: Failed to create transaction: %v\n", err)
+		return nil, nil, model.Internal(err)
+	}
+	updatedAccount, err := txRepo.AddToAccountBalanceWithoutActivity(ctx, accountID, posted)
+	if err != nil {
+		log.Printf("postInterestTx: Failed to update balance: %v\n", err)
+		return nil, nil, model.Internal(err)
+	}
+	return transaction, updatedAccount, nil
+}
+
+// splitInterest splits the interest accrued by account into the whole minor units to credit and the remainder to
+// carry. A closed account can't be credited anymore: it forfeits its interest, which is recorded as carried.
+func splitInterest(accrued *big.Rat, account *model.Account) (int64, *big.Rat) {
+	if account.Status == model.AccountStatusClosed {
+		return 0, accrued
+	}
+	return interest.Split(accrued)
+}
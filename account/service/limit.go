@@ -0,0 +1,421 @@
+package service
+
+import (
+	"account/internal/idempotency"
+	"account/internal/mtls"
+	"account/model"
+	"account/money"
+	"account/repository"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GetAccountLimits returns the limits applying to the debits of an account and how much of them is used.
+// The support staff may view the limits of any account, caller being the identity of the calling service.
+// userID is the ID of the user who initiated the request, uuid.Nil for the support staff
+func (s *AccountService) GetAccountLimits(ctx context.Context, accountID uuid.UUID, userID uuid.UUID, caller string) (*model.AccountLimits, error) {
+	account, err := s.repo.GetAccountByID(ctx, accountID)
+	if err != nil {
+		log.Printf("GetAccountLimits: Failed to get account: %v\n", err)
+		if err == sql.ErrNoRows {
+			return nil, model.ErrAccountNotFound
+		}
+		return nil, model.ErrInternalServer
+	}
+	if caller != mtls.SupportConsole && account.UserID != userID {
+		log.Printf("GetAccountLimits: Unauthorized access attempt for account id %v by user %v\n", accountID, userID)
+		return nil, model.ErrNotAuthorized
+	}
+	limits, err := accountLimits(ctx, s.repo, account)
+	if err != nil {
+		return nil, model.Internal(err)
+	}
+	return limits, nil
+}
+
+// UpdateAccountLimits overrides the limits of an account, and returns its limits as updated. The amounts of limits are
+// in currency, which must be the currency of the account unless limits has no amounts.
+// The owner of the account replaces the limits they set, which must be at most the ceiling of the account, the limits
+// of its product as replaced by the support staff: a limit of limits that is 0 goes back to the ceiling. This way the
+// owner can lower the limits and raise them back, but a stolen session can't raise them above what the bank allows.
+// The support staff replace the limits of the product of the account instead, raising or lowering the ceiling, a limit
+// of limits that is 0 being the one of the product. The lower limits set by the owner still apply.
+// userID is the ID of the user who initiated the request, uuid.Nil for the support staff
+func (s *AccountService) UpdateAccountLimits(ctx context.Context, accountID uuid.UUID, limits model.Limits, currency string, idempotencyKey string, userID uuid.UUID, caller string) (*model.AccountLimits, error) {
+	if limits.MaxSingleDebit < 0 || limits.DailyDebitTotal < 0 || limits.DailyDebitCount < 0 || limits.MonthlyDebitTotal < 0 {
+		return nil, model.ErrInvalidArgument
+	}
+
+	var res *model.AccountLimits
+	err := s.txRunner.Run(ctx, sql.LevelReadCommitted, func(tx *sql.Tx) error {
+		var err error
+		res, err = s.updateAccountLimitsTx(ctx, tx, accountID, limits, currency, idempotencyKey, userID, caller)
+		return err
+	})
+	if err != nil {
+		log.Printf("UpdateAccountLimits: Failed to update limits of account %v: %v\n", accountID, err)
+		return nil, err
+	}
+	return res, nil
+}
+
+// userID is the ID of the user who initiated the request
+func (s *AccountService) updateAccountLimitsTx(ctx context.Context, tx *sql.Tx, accountID uuid.UUID, limits model.Limits, currency string, idempotencyKey string, userID uuid.UUID, caller string) (*model.AccountLimits, error) {
+	txRepo := s.repo.WithTx(tx)
+
+	// locked so that the debits of the account wait for the new limits, and concurrent updates compare with each other
+	account, err := txRepo.GetAccountByIDForUpdate(ctx, accountID)
+	if err != nil {
+		log.Printf("updateAccountLimitsTx: Failed to get account: %v\n", err)
+		if err == sql.ErrNoRows {
+			return nil, model.ErrAccountNotFound
+		}
+		return nil, model.Internal(err)
+	}
+	staff := caller == mtls.SupportConsole
+	if !staff && account.UserID != userID {
+		log.Printf("updateAccountLimitsTx: Unauthorized limits update attempt for account %v by user %v\n", accountID, userID)
+		return nil, model.ErrNotAuthorized
+	}
+
+	requestHash, err := idempotency.RequestHash(struct {
+		AccountID uuid.UUID
+		Limits    model.Limits
+		Currency  string
+	}{accountID, limits, currency})
+	if err != nil {
+		log.Printf("updateAccountLimitsTx: Failed to hash request: %v\n", err)
+		return nil, model.ErrInternalServer
+	}
+
+	key, ran, err := s.claimIdempotencyKey(ctx, tx, txRepo, &model.IdempotencyKey{
+		KeyID:       idempotencyKey,
+		UserID:      userID,
+		RPCName:     "UpdateAccountLimits",
+		RequestHash: requestHash,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !ran {
+		cachedLimits := &model.AccountLimits{}
+		if err := json.Unmarshal([]byte(key.ResponseMessage), cachedLimits); err != nil {
+			log.Printf("updateAccountLimitsTx: Failed to unmarshal limits: %v\n", err)
+			return nil, model.ErrInternalServer
+		}
+		return cachedLimits, nil
+	}
+
+	if currency != "" && currency != account.Currency {
+		log.Printf("updateAccountLimitsTx: Limits in %q of account %v in %v\n", currency, accountID, account.Currency)
+		return nil, failIdempotencyKey(ctx, tx, txRepo, key, model.ErrCurrencyMismatch)
+	}
+
+	if staff {
+		if _, err = txRepo.SetAccountLimits(ctx, accountID, model.LimitLevelAccount, limits); err != nil {
+			log.Printf("updateAccountLimitsTx: Failed to set limits: %v\n", err)
+			return nil, model.Internal(err)
+		}
+	} else {
+		current, err := accountLimits(ctx, txRepo, account)
+		if err != nil {
+			return nil, model.Internal(err)
+		}
+		if current.Ceiling.Raises(limits) {
+			log.Printf("updateAccountLimitsTx: User %v tried to raise the limits of account %v above its ceiling\n", userID, accountID)
+			return nil, failIdempotencyKey(ctx, tx, txRepo, key, model.ErrLimitIncreaseNotAllowed)
+		}
+		if _, err = txRepo.SetAccountLimits(ctx, accountID, model.LimitLevelUser, limits); err != nil {
+			log.Printf("updateAccountLimitsTx: Failed to set limits: %v\n", err)
+			return nil, model.Internal(err)
+		}
+	}
+
+	updated, err := accountLimits(ctx, txRepo, account)
+	if err != nil {
+		return nil, model.Internal(err)
+	}
+
+	key.Status = "COMPLETED"
+	marshalled, err := json.Marshal(updated)
+	if err != nil {
+		log.Printf("updateAccountLimitsTx: Failed to marshal limits: %v\n", err)
+		return nil, model.ErrInternalServer
+	}
+	key.ResponseMessage = string(marshalled)
+	if _, err = txRepo.UpdateIdempotencyKey(ctx, key); err != nil {
+		log.Printf("updateAccountLimitsTx: Failed to update idempotency key: %v\n", err)
+		return nil, model.Internal(err)
+	}
+	return updated, nil
+}
+
+// GetUserLimits returns the limits of the debits of all the accounts of a user in currency together, and how much of
+// them is used. The limits are 0 if none were set.
+// userID is the user whose limits are returned, which the handler checks the caller may see
+func (s *AccountService) GetUserLimits(ctx context.Context, userID uuid.UUID, currency string) (*model.UserLimits, error) {
+	if !money.Supported(currency) {
+		log.Printf("GetUserLimits: Unsupported currency %q\n", currency)
+		return nil, model.ErrUnsupportedCurrency
+	}
+	limits, err := userLimits(ctx, s.repo, userID, currency)
+	if err != nil {
+		return nil, model.Internal(err)
+	}
+	return limits, nil
+}
+
+// UpdateUserLimits replaces the limits of the debits of all the accounts of a user in currency together, a limit of
+// limits that is 0 being removed, and returns them as updated. Unlike the limits of an account, they may be raised as
+// well as lowered: the limits of each account still apply, so they can only restrict the debits further.
+// userID is the user whose limits are updated, which the handler checks the caller may update
+func (s *AccountService) UpdateUserLimits(ctx context.Context, userID uuid.UUID, currency string, limits model.Limits, idempotencyKey string) (*model.UserLimits, error) {
+	if !money.Supported(currency) {
+		log.Printf("UpdateUserLimits: Unsupported currency %q\n", currency)
+		return nil, model.ErrUnsupportedCurrency
+	}
+	if limits.MaxSingleDebit < 0 || limits.DailyDebitTotal < 0 || limits.DailyDebitCount < 0 || limits.MonthlyDebitTotal < 0 {
+		return nil, model.ErrInvalidArgument
+	}
+
+	var res *model.UserLimits
+	err := s.txRunner.Run(ctx, sql.LevelReadCommitted, func(tx *sql.Tx) error {
+		var err error
+		res, err = s.updateUserLimitsTx(ctx, tx, userID, currency, limits, idempotencyKey)
+		return err
+	})
+	if err != nil {
+		log.Printf("UpdateUserLimits: Failed to update limits of user %v in %v: %v\n", userID, currency, err)
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *AccountService) updateUserLimitsTx(ctx context.Context, tx *sql.Tx, userID uuid.UUID, currency string, limits model.Limits, idempotencyKey string) (*model.UserLimits, error) {
+	txRepo := s.repo.WithTx(tx)
+
+	requestHash, err := idempotency.RequestHash(struct {
+		UserID   uuid.UUID
+		Currency string
+		Limits   model.Limits
+	}{userID, currency, limits})
+	if err != nil {
+		log.Printf("updateUserLimitsTx: Failed to hash request: %v\n", err)
+		return nil, model.ErrInternalServer
+	}
+
+	key, ran, err := s.claimIdempotencyKey(ctx, tx, txRepo, &model.IdempotencyKey{
+		KeyID:       idempotencyKey,
+		UserID:      userID,
+		RPCName:     "UpdateUserLimits",
+		RequestHash: requestHash,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !ran {
+		cachedLimits := &model.UserLimits{}
+		if err := json.Unmarshal([]byte(key.ResponseMessage), cachedLimits); err != nil {
+			log.Printf("updateUserLimitsTx: Failed to unmarshal limits: %v\n", err)
+			return nil, model.ErrInternalServer
+		}
+		return cachedLimits, nil
+	}
+
+	// locked until we commit, so that the debits of the accounts of the user wait for the new limits
+	if _, err = txRepo.SetUserLimits(ctx, userID, currency, limits); err != nil {
+		log.Printf("updateUserLimitsTx: Failed to set limits: %v\n", err)
+		return nil, model.Internal(err)
+	}
+	updated, err := userLimits(ctx, txRepo, userID, currency)
+	if err != nil {
+		return nil, model.Internal(err)
+	}
+
+	key.Status = "COMPLETED"
+	marshalled, err := json.Marshal(updated)
+	if err != nil {
+		log.Printf("updateUserLimitsTx: Failed to marshal limits: %v\n", err)
+		return nil, model.ErrInternalServer
+	}
+	key.ResponseMessage = string(marshalled)
+	if _, err = txRepo.UpdateIdempotencyKey(ctx, key); err != nil {
+		log.Printf("updateUserLimitsTx: Failed to update idempotency key: %v\n", err)
+		return nil, model.Internal(err)
+	}
+	return updated, nil
+}
+
+// accountLimits returns the limits of account and their usage.
+func accountLimits(ctx context.Context, repo *repository.AccountRepository, account *model.Account) (*model.AccountLimits, error) {
+	product, err := repo.GetAccountProductByCode(ctx, account.ProductCode)
+	if err != nil {
+		log.Printf("accountLimits: Failed to get product %v: %v\n", account.ProductCode, err)
+		return nil, err
+	}
+	effective, ceiling, user, err := effectiveLimits(ctx, repo, account, product)
+	if err != nil {
+		return nil, err
+	}
+	usage, err := limitUsage(ctx, repo, account.AccountID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &model.AccountLimits{Currency: account.Currency, Effective: effective, Ceiling: ceiling, User: user, Usage: usage}, nil
+}
+
+// effectiveLimits returns the limits applying to the debits of account, whose product is product: its ceiling, the
+// limits of the product as replaced by the support staff, lowered by the limits its owner set. It also returns the
+// ceiling and the limits of the owner.
+func effectiveLimits(ctx context.Context, repo *repository.AccountRepository, account *model.Account, product *model.AccountProduct) (model.Limits, model.Limits, model.Limits, error) {
+	overrides, err := repo.GetAccountLimits(ctx, account.AccountID)
+	if err != nil {
+		log.Printf("effectiveLimits: Failed to get limits of account %v: %v\n", account.AccountID, err)
+		return model.Limits{}, model.Limits{}, model.Limits{}, err
+	}
+	ceiling := product.Limits.Override(overrides[model.LimitLevelAccount])
+	user := overrides[model.LimitLevelUser]
+	return ceiling.Lower(user), ceiling, user, nil
+}
+
+// userLimits returns the limits of the accounts of a user in currency and their usage.
+func userLimits(ctx context.Context, repo *repository.AccountRepository, userID uuid.UUID, currency string) (*model.UserLimits, error) {
+	limits, err := repo.GetUserLimits(ctx, userID, currency)
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("userLimits: Failed to get limits of user %v in %v: %v\n", userID, currency, err)
+		return nil, err
+	}
+	usage, err := userLimitUsage(ctx, repo, userID, currency, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &model.UserLimits{UserID: userID, Currency: currency, Limits: limits, Usage: usage}, nil
+}
+
+// limitPeriods returns the start of the day and of the month of now, which the limits apply to.
+func limitPeriods(now time.Time) (time.Time, time.Time) {
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC),
+		time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// limitUsage returns how much of its limits an account used on the day and in the month of now.
+func limitUsage(ctx context.Context, repo *repository.AccountRepository, accountID uuid.UUID, now time.Time) (model.LimitUsage, error) {
+	dayStart, monthStart := limitPeriods(now)
+
+	var (
+		usage model.LimitUsage
+		err   error
+	)
+	if usage.DailyDebitTotal, err = repo.SumWithdrawalsSince(ctx, accountID, dayStart); err != nil {
+		log.Printf("limitUsage: Failed to sum withdrawals: %v\n", err)
+		return usage, err
+	}
+	if usage.DailyDebitCount, err = repo.CountWithdrawalsSince(ctx, accountID, dayStart); err != nil {
+		log.Printf("limitUsage: Failed to count withdrawals: %v\n", err)
+		return usage, err
+	}
+	if usage.MonthlyDebitTotal, err = repo.SumWithdrawalsSince(ctx, accountID, monthStart); err != nil {
+		log.Printf("limitUsage: Failed to sum withdrawals: %v\n", err)
+		return usage, err
+	}
+	return usage, nil
+}
+
+// userLimitUsage returns how much of their limits together the accounts of a user in currency used on the day and in
+// the month of now.
+func userLimitUsage(ctx context.Context, repo *repository.AccountRepository, userID uuid.UUID, currency string, now time.Time) (model.LimitUsage, error) {
+	dayStart, monthStart := limitPeriods(now)
+
+	var (
+		usage model.LimitUsage
+		err   error
+	)
+	if usage.DailyDebitTotal, usage.DailyDebitCount, err = repo.SumUserWithdrawalsSince(ctx, userID, currency, dayStart); err != nil {
+		log.Printf("userLimitUsage: Failed to sum withdrawals: %v\n", err)
+		return usage, err
+	}
+	if usage.MonthlyDebitTotal, _, err = repo.SumUserWithdrawalsSince(ctx, userID, currency, monthStart); err != nil {
+		log.Printf("userLimitUsage: Failed to sum withdrawals: %v\n", err)
+		return usage, err
+	}
+	return usage, nil
+}
+
+// limitNames are the names that LimitExceededError reports for the limits of an account or of a user.
+type limitNames struct {
+	maxSingleDebit, dailyDebitTotal, dailyDebitCount, monthlyDebitTotal string
+}
+
+var (
+	accountLimitNames = limitNames{model.LimitMaxSingleDebit, model.LimitDailyDebitTotal, model.LimitDailyDebitCount, model.LimitMonthlyDebitTotal}
+	userLimitNames    = limitNames{model.LimitUserMaxSingleDebit, model.LimitUserDailyDebitTotal, model.LimitUserDailyDebitCount, model.LimitUserMonthlyDebitTotal}
+)
+
+// checkLimits checks the limits of account, whose product is product, and the limits of its owner in its currency, and
+// returns a *model.LimitExceededError for the limit that a debit or a hold of amount (positive), already created,
+// exceeds.
+// Like checkWithdrawalRules, it must be called after the balance or the held amount was updated, so that the
+// concurrent debits of the account wait for our commit and count the debit created by this transaction.
+func checkLimits(ctx context.Context, txRepo *repository.AccountRepository, account *model.Account, product *model.AccountProduct, amount int64) error {
+	limits, _, _, err := effectiveLimits(ctx, txRepo, account, product)
+	if err != nil {
+		return model.Internal(err)
+	}
+	now := time.Now()
+	err = exceededLimit(limits, accountLimitNames, amount, func() (model.LimitUsage, error) {
+		return limitUsage(ctx, txRepo, account.AccountID, now)
+	})
+	if err != nil {
+		log.Printf("checkLimits: Debit of %v on account %v: %v\n", amount, account.AccountID, err)
+		return err
+	}
+
+	// the limits of the user cover all their accounts in the currency. They are locked so that the debits of the other
+	// accounts wait for our commit and count this debit, as the debits of account do, even if the user sets limits
+	// meanwhile: the row is created if the user set none yet.
+	userLimits, err := txRepo.GetUserLimitsForUpdate(ctx, account.UserID, account.Currency)
+	if err != nil {
+		log.Printf("checkLimits: Failed to get limits of user %v: %v\n", account.UserID, err)
+		return model.Internal(err)
+	}
+	err = exceededLimit(userLimits, userLimitNames, amount, func() (model.LimitUsage, error) {
+		return userLimitUsage(ctx, txRepo, account.UserID, account.Currency, now)
+	})
+	if err != nil {
+		log.Printf("checkLimits: Debit of %v on account %v of user %v: %v\n", amount, account.AccountID, account.UserID, err)
+	}
+	return err
+}
+
+// exceededLimit returns a *model.LimitExceededError, with the name of names, for the limit of limits that a debit or a
+// hold of amount exceeds. usage returns the usage of the limits, the debit included, and is only called if limits has
+// limits on the usage.
+func exceededLimit(limits model.Limits, names limitNames, amount int64, usage func() (model.LimitUsage, error)) error {
+	if limits.MaxSingleDebit > 0 && amount > limits.MaxSingleDebit {
+		return &model.LimitExceededError{Limit: names.maxSingleDebit, Allowed: limits.MaxSingleDebit, Remaining: limits.MaxSingleDebit}
+	}
+	if limits.DailyDebitTotal == 0 && limits.DailyDebitCount == 0 && limits.MonthlyDebitTotal == 0 {
+		return nil
+	}
+
+	used, err := usage()
+	if err != nil {
+		return model.Internal(err)
+	}
+	if limits.DailyDebitTotal > 0 && used.DailyDebitTotal > limits.DailyDebitTotal {
+		return &model.LimitExceededError{Limit: names.dailyDebitTotal, Allowed: limits.DailyDebitTotal, Remaining: max(0, limits.DailyDebitTotal-(used.DailyDebitTotal-amount))}
+	}
+	if limits.DailyDebitCount > 0 && used.DailyDebitCount > int64(limits.DailyDebitCount) {
+		return &model.LimitExceededError{Limit: names.dailyDebitCount, Allowed: int64(limits.DailyDebitCount), Remaining: max(0, int64(limits.DailyDebitCount)-(used.DailyDebitCount-1))}
+	}
+	if limits.MonthlyDebitTotal > 0 && used.MonthlyDebitTotal > limits.MonthlyDebitTotal {
+		return &model.LimitExceededError{Limit: names.monthlyDebitTotal, Allowed: limits.MonthlyDebitTotal, Remaining: max(0, limits.MonthlyDebitTotal-(used.MonthlyDebitTotal-amount))}
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"account/internal/cache"
+	"account/internal/idempotency"
+	"account/internal/mtls"
+	"account/model"
+	"account/money"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReverseTransaction offsets amount of a transaction with a REVERSAL transaction, and marks the transaction REVERSED once
+// its reversals add up to its amount. amount is what remains to reverse of the transaction if it is zero, so that a transaction can be refunded in several
+// partial reversals but never for more than its amount. reason is recorded with the reversal.
+// The support staff may reverse the CREDIT, DEBIT and INTEREST transactions of any account, caller being the identity
+// of the calling service. The owner of an account may only give back its credits: refunding a debit the customer made
+// is for the support staff. Giving back a credit is a debit of the owner, checked and charged like the debits of
+// CreateTransaction, whereas the reversals of the support staff are corrections that only need the funds.
+// userID is the ID of the user who initiated the request, uuid.Nil for the support staff
+func (s *AccountService) ReverseTransaction(ctx context.Context, transactionID uuid.UUID, amount money.Money, reason string, idempotencyKey string, userID uuid.UUID, caller string) (*model.Transaction, error) {
+	var (
+		res      *model.Transaction
+		postings []posting
+	)
+	err := s.txRunner.Run(ctx, sql.LevelReadCommitted, func(tx *sql.Tx) error {
+		var err error
+		res, postings, err = s.reverseTransactionTx(ctx, tx, transactionID, amount, reason, idempotencyKey, userID, caller)
+		return err
+	})
+	if err != nil {
+		log.Printf("ReverseTransaction: Failed to reverse transaction %v: %v\n", transactionID, err)
+		return nil, err
+	}
+
+	go cache.Invalidate(ctx, res.AccountID)
+	publish(ctx, postings)
+	return res, nil
+}
+
+// reverseTransactionTx returns the reversal, and the postings to publish, none if the request was replayed.
+// userID is the ID of the user who initiated the request
+func (s *AccountService) reverseTransactionTx(ctx context.Context, tx *sql.Tx, transactionID uuid.UUID, amount money.Money, reason string, idempotencyKey string, userID uuid.UUID, caller string) (*model.Transaction, []posting, error) {
+	txRepo := s.repo.WithTx(tx)
+
+	original, err := txRepo.GetTransactionByID(ctx, transactionID)
+	if err != nil {
+		log.Printf("reverseTransactionTx: Failed to get transaction: %v\n", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, model.ErrTransactionNotFound
+		}
+		return nil, nil, model.Internal(err)
+	}
+	// locked so that concurrent reversals of the transaction are serialized: the second one counts the first
+	account, err := txRepo.GetAccountByIDForUpdate(ctx, original.AccountID)
+	if err != nil {
+		log.Printf("reverseTransactionTx: Failed to get account: %v\n", err)
+		return nil, nil, model.Internal(err)
+	}
+	staff := caller == mtls.SupportConsole
+	if !staff && account.UserID != userID {
+		log.Printf("reverseTransactionTx: Unauthorized reversal attempt of transaction %v by user %v\n", transactionID, userID)
+		return nil, nil, model.ErrNotAuthorized
+	}
+	if !staff && original.TransactionType != "CREDIT" {
+		log.Printf("reverseTransactionTx: %q may not reverse the %s transaction %v\n", caller, original.TransactionType, transactionID)
+		return nil, nil, model.ErrNotAuthorized
+	}
+
+	requestHash, err := idempotency.RequestHash(struct {
+		TransactionID uuid.UUID
+		Amount        int64
+		Currency      string
+		Reason        string
+	}{transactionID, amount.Units, amount.Currency, reason})
+	if err != nil {
+		log.Printf("reverseTransactionTx: Failed to hash request: %v\n", err)
+		return nil, nil, model.ErrInternalServer
+	}
+
+	key, ran, err := s.claimIdempotencyKey(ctx, tx, txRepo, &model.IdempotencyKey{
+		KeyID:       idempotencyKey,
+		UserID:      userID,
+		RPCName:     "ReverseTransaction",
+		RequestHash: requestHash,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ran {
+		cachedTransaction := &model.Transaction{}
+		if err := json.Unmarshal([]byte(key.ResponseMessage), cachedTransaction); err != nil {
+			log.Printf("reverseTransactionTx: Failed to unmarshal transaction: %v\n", err)
+			return nil, nil, model.ErrInternalServer
+		}
+		return cachedTransaction, nil, nil
+	}
+
+	// the legs of a transfer are reversed together by reversing the transfer, and fees with ReverseFee
+	switch original.TransactionType {
+	case "CREDIT", "DEBIT", model.TransactionInterest:
+	default:
+		log.Printf("reverseTransactionTx: Transaction %v is a %v\n", transactionID, original.TransactionType)
+		return nil, nil, failIdempotencyKey(ctx, tx, txRepo, key, model.ErrNotReversible)
+	}
+	// the transactions posted before CreateTransaction completed them are PENDING, although their amount is in the balance
+	if original.Status == "FAILED" {
+		log.Printf("reverseTransactionTx: Transaction %v is %v\n", transactionID, original.Status)
+		return nil, nil, failIdempotencyKey(ctx, tx, txRepo, key, model.ErrNotReversible)
+	}
+
+	reversed, err := txRepo.SumReversals(ctx, transactionID)
+	if err != nil {
+		log.Printf("reverseTransactionTx: Failed to sum reversals: %v\n", err)
+		return nil, nil, model.Internal(err)
+	}
+	remaining := abs(original.Amount) - abs(reversed)
+	if remaining == 0 {
+		log.Printf("reverseTransactionTx: Transaction %v was reversed in full\n", transactionID)
+		return nil, nil, failIdempotencyKey(ctx, tx, txRepo, key, model.ErrAlreadyReversed)
+	}
+	units := remaining
+	if amount.Units != 0 {
+		if amount.Currency != original.Currency {
+			log.Printf("reverseTransactionTx: Reversal in %q of transaction %v in %v\n", amount.Currency, transactionID, original.Currency)
+			return nil, nil, failIdempotencyKey(ctx, tx, txRepo, key, model.ErrCurrencyMismatch)
+		}
+		units = amount.Units
+	}
+	if units < 0 {
+		return nil, nil, failIdempotencyKey(ctx, tx, txRepo, key, model.ErrInvalidArgument)
+	}
+	if units > remaining {
+		log.Printf("reverseTransactionTx: Reversal of %v on transaction %v with %v remaining\n", units, transactionID, remaining)
+		return nil, nil, failIdempotencyKey(ctx, tx, txRepo, key, model.ErrReversalExceedsRemaining)
+	}
+	// the reversal has the opposite sign of the transaction
+	if original.Amount > 0 {
+		units = -units
+	}
+	if err = checkAccountStatus(account, units); err != nil {
+		log.Printf("reverseTransactionTx: Account %v is %v\n", account.AccountID, account.Status)
+		return nil, nil, failIdempotencyKey(ctx, tx, txRepo, key, err)
+	}
+	if units < 0 && !staff && time.Now().Before(account.MaturesAt) {
+		log.Printf("reverseTransactionTx: Debit of account %v before it matures on %v\n", account.AccountID, account.MaturesAt)
+		return nil, nil, failIdempotencyKey(ctx, tx, txRepo, key, model.ErrAccountLocked)
+	}
+
+	reversal, err := txRepo.CreateTransaction(ctx, &model.Transaction{
+		TransactionID:   uuid.New(),
+		AccountID:       account.AccountID,
+		Amount:          units,
+		Currency:        original.Currency,
+		TransactionType: model.TransactionReversal,
+		Status:          "COMPLETED",
+		CausedBy:        uuid.NullUUID{UUID: transactionID, Valid: true},
+		Memo:            reason,
+	})
+	if err != nil {
+		log.Printf("reverseTransactionTx: Failed to create transaction: %v\n", err)
+		return nil, nil, model.Internal(err)
+	}
+	if abs(units) == remaining {
+		if err = txRepo.UpdateTransactionStatus(ctx, transactionID, "REVERSED"); err != nil {
+			log.Printf("reverseTransactionTx: Failed to update transaction: %v\n", err)
+			return nil, nil, model.Internal(err)
+		}
+	}
+	// a correction rather than activity of the owner
+	updatedAccount, err := txRepo.AddToAccountBalanceWithoutActivity(ctx, account.AccountID, units)
+	if err != nil {
+		log.Printf("reverseTransactionTx: Failed to update balance: %v\n", err)
+		return nil, nil, model.Internal(err)
+	}
+	postings := []posting{{transaction: reversal, account: updatedAccount}}
+	if units < 0 {
+		product, err := txRepo.GetAccountProductByCode(ctx, account.ProductCode)
+		if err != nil {
+			log.Printf("reverseTransactionTx: Failed to get product %v: %v\n", account.ProductCode, err)
+			return nil, nil, model.Internal(err)
+		}
+		// giving back a credit that was spent already would overdraw the account
+		if updatedAccount.Available() < -product.OverdraftLimit {
+			log.Printf("reverseTransactionTx: Insufficient funds on account %v\n", account.AccountID)
+			return nil, nil, failIdempotencyKey(ctx, tx, txRepo, key, model.ErrInsufficientFunds)
+		}
+		if !staff {
+			if err = checkWithdrawalRules(ctx, txRepo, updatedAccount, product); err != nil {
+				if errors.Is(err, model.ErrInternalServer) {
+					return nil, nil, err
+				}
+				return nil, nil, failIdempotencyKey(ctx, tx, txRepo, key, err)
+			}
+			if err = checkLimits(ctx, txRepo, updatedAccount, product, -units); err != nil {
+				if errors.Is(err, model.ErrInternalServer) {
+					return nil, nil, err
+				}
+				return nil, nil, failIdempotencyKey(ctx, tx, txRepo, key, err)
+			}
+
+			fees, err := chargeFees(ctx, txRepo, updatedAccount, product, reversal)
+			if err != nil {
+				return nil, nil, err
+			}
+			postings = append(postings, fees...)
+		}
+	}
+
+	key.Status = "COMPLETED"
+	marshalled, err := json.Marshal(reversal)
+	if err != nil {
+		log.Printf("reverseTransactionTx: Failed to marshal transaction: %v\n", err)
+		return nil, nil, model.ErrInternalServer
+	}
+	key.ResponseMessage = string(marshalled)
+	if _, err = txRepo.UpdateIdempotencyKey(ctx, key); err != nil {
+		log.Printf("reverseTransactionTx: Failed to update idempotency key: %v\n", err)
+		return nil, nil, model.Internal(err)
+	}
+	return reversal, postings, nil
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"account/internal/events"
+	"account/model"
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AccountWatch follows the events of one account, see WatchAccount.
+type AccountWatch struct {
+	accountID uuid.UUID
+	// Initial are the events to send before the ones returned by Next: a snapshot of the account, unless the watch
+	// resumes after an event that is still retained.
+	Initial []*model.AccountEvent
+
+	cursor  string // ID of the last event read
+	version int64  // version of the account after the last event sent
+}
+
+// WatchAccount starts following the events of an account, after checking that the user owns it.
+// afterEventID is the ID of the last event the client received, or empty for a new watch.
+// userID is the ID of the user who initiated the request
+func (s *AccountService) WatchAccount(ctx context.Context, accountID uuid.UUID, afterEventID string, userID uuid.UUID) (*AccountWatch, error) {
+	// the stream position is read before the account, so that the events committed in between are sent after the snapshot
+	first, last, err := events.Bounds(ctx, accountID)
+	if err != nil {
+		log.Printf("WatchAccount: Failed to read the event stream: %v\n", err)
+		return nil, model.ErrInternalServer
+	}
+
+	account, err := s.repo.GetAccountByID(ctx, accountID)
+	if err != nil {
+		log.Printf("WatchAccount: Failed to get account: %v\n", err)
+		if err == sql.ErrNoRows {
+			return nil, model.ErrAccountNotFound
+		}
+		return nil, model.ErrInternalServer
+	}
+
+	// Check ownership
+	if account.UserID != userID {
+		log.Printf("WatchAccount: Unauthorized watch attempt for account %v by user %v\n", accountID, userID)
+		return nil, model.ErrNotAuthorized
+	}
+
+	if afterEventID != "" && events.Retained(afterEventID, first, last) {
+		return &AccountWatch{accountID: accountID, cursor: afterEventID}, nil
+	}
+	if last == "" {
+		last = "0-0"
+	}
+	return &AccountWatch{
+		accountID: accountID,
+		Initial: []*model.AccountEvent{{
+			EventID:   last,
+			Type:      model.EventSnapshot,
+			AccountID: accountID,
+			Balance:   account.Balance,
+			Currency:  account.Currency,
+			Version:   account.Version,
+		}},
+		cursor:  last,
+		version: account.Version,
+	}, nil
+}
+
+// Next returns the events published since the previous call, waiting up to block for one.
+// It returns no event and no error if nothing happened in time, so that the caller can send a heartbeat.
+// Events are published after their commit, so two transactions on the account may publish them in the reverse
+// order: the late one is dropped since the event before it already carries a newer balance.
+func (w *AccountWatch) Next(ctx context.Context, block time.Duration) ([]*model.AccountEvent, error) {
+	read, err := events.Read(ctx, w.accountID, w.cursor, block)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		log.Printf("AccountWatch.Next: Failed to read the event stream: %v\n", err)
+		return nil, model.ErrInternalServer
+	}
+
+	var fresh []*model.AccountEvent
+	for _, event := range read {
+		w.cursor = event.EventID
+		if event.Version <= w.version {
+			continue
+		}
+		w.version = event.Version
+		fresh = append(fresh, event)
+	}
+	return fresh, nil
+}
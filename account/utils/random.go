@@ -1,7 +1,9 @@
 package utils
 
 import (
+	"account/internal/accountnumber"
 	"account/model"
+	"account/money"
 	"math/rand"
 
 	"github.com/google/uuid"
@@ -37,13 +39,19 @@ func RandomEmail() string {
 
 func RandomUser() *model.User {
 	return &model.User{
-		UserID:  uuid.New(),
-		Balance: int64(RandMinMax(0, 100_000_000_000_000)),
+		UserID:   uuid.New(),
+		Balance:  int64(RandMinMax(0, 100_000_000_000_000)),
+		Currency: money.DefaultCurrency,
 	}
 }
 
-func RandomAccountNumber() int32 {
-	return int32(RandMinMax(1_000_000_000, 2_000_999_999))
+// returns a valid account number of the highest prefix, which the tests keep for themselves
+func RandomAccountNumber() int64 {
+	n, err := accountnumber.New(accountnumber.MaxPrefix, RandMinMax[int64](0, 9_999_999))
+	if err != nil {
+		panic(err)
+	}
+	return n
 }
 
 func RandomAccount() *model.Account {
@@ -52,6 +60,9 @@ func RandomAccount() *model.Account {
 		UserID:        uuid.New(),
 		AccountNumber: RandomAccountNumber(),
 		Balance:       int64(RandMinMax(1, 100)),
+		Currency:      money.DefaultCurrency,
+		ProductCode:   model.DefaultProductCode,
+		AccountType:   model.AccountChecking,
 	}
 }
 
@@ -87,6 +98,7 @@ func RandomTransaction() *model.Transaction {
 		Status:          RandomTransactionStatus(),
 		TransferID:      RandomTransferID(),
 		Amount:          int64(RandMinMax(1, 100)),
+		Currency:        money.DefaultCurrency,
 	}
 }
 
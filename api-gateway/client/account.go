@@ -1,20 +1,81 @@
 package client
 
 import (
-	proto "buf.build/gen/go/banking-app/account/grpc/go/_gogrpc"
+	"account/proto"
+	"api-gateway/config"
+	"api-gateway/internal/mtls"
+	"context"
+	"time"
+
+	"github.com/sony/gobreaker/v2"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
+// accountReads are the idempotent account RPCs that are safe to retry and hedge.
+var accountReads = []string{
+	"GetAccountsByUserId",
+	"GetAccountByAccountNumber",
+	"GetAccountByAccountId",
+	"GetTransactionsByAccountId",
+	"ValidateAccountNumber",
+	"HasSufficientBalance",
+}
+
+// accountStreams are the long-lived account RPCs, which have no deadline.
+var accountStreams = []string{
+	"WatchAccount",
+}
+
 type AccountClient struct {
 	proto.AccountServiceClient
+	healthpb.HealthClient
+	conn       *grpc.ClientConn
+	breaker    *gobreaker.TwoStepCircuitBreaker[any]
+	hedgeDelay time.Duration
 }
 
-func NewAccountClient(connString string) *AccountClient {
-	conn, err := grpc.NewClient(connString, grpc.WithTransportCredentials(insecure.NewCredentials()))
+func NewAccountClient(connString string, cfg config.ClientConfig, tlsCfg config.TLSConfig) *AccountClient {
+	creds, err := transportCredentials(tlsCfg, mtls.AccountService)
+	if err != nil {
+		panic(err)
+	}
+	service := proto.AccountService_ServiceDesc.ServiceName
+	breaker := newBreaker(service, cfg)
+	conn, err := grpc.NewClient(connString,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithDefaultServiceConfig(serviceConfig(service, accountReads, accountStreams, cfg)),
+		grpc.WithChainUnaryInterceptor(breakerInterceptor(breaker), forwardTokenInterceptor(), idempotencyInterceptor()),
+		grpc.WithChainStreamInterceptor(forwardTokenStreamInterceptor()),
+	)
 	if err != nil {
 		panic(err)
 	}
 	client := proto.NewAccountServiceClient(conn)
-	return &AccountClient{client}
+	return &AccountClient{client, healthpb.NewHealthClient(conn), conn, breaker, cfg.HedgeDelay}
+}
+
+// GetAccountByAccountId sends a second, hedged request if the first one is slower than the configured hedge delay.
+// Hedging is disabled when the delay is 0.
+func (c *AccountClient) GetAccountByAccountId(ctx context.Context, in *proto.GetAccountByAccountIdRequest, opts ...grpc.CallOption) (*proto.Account, error) {
+	if c.hedgeDelay <= 0 {
+		return c.AccountServiceClient.GetAccountByAccountId(ctx, in, opts...)
+	}
+	return hedge(ctx, "GetAccountByAccountId", c.hedgeDelay, func(ctx context.Context) (*proto.Account, error) {
+		return c.AccountServiceClient.GetAccountByAccountId(ctx, in, opts...)
+	})
+}
+
+// ServiceName is the name the account service reports its health under.
+func (c *AccountClient) ServiceName() string {
+	return proto.AccountService_ServiceDesc.ServiceName
+}
+
+// BreakerState is the state of the circuit breaker guarding the account service.
+func (c *AccountClient) BreakerState() string {
+	return c.breaker.State().String()
+}
+
+func (c *AccountClient) Close() error {
+	return c.conn.Close()
 }
@@ -1,20 +1,56 @@
 package client
 
 import (
-	proto "buf.build/gen/go/banking-app/auth/grpc/go/_gogrpc"
+	"api-gateway/config"
+	"api-gateway/internal/mtls"
+	"auth/proto"
+
+	"github.com/sony/gobreaker/v2"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
+// authReads are the idempotent auth RPCs that are safe to retry.
+var authReads = []string{
+	"GetUserProfileById",
+}
+
 type AuthClient struct {
 	proto.AuthServiceClient
+	healthpb.HealthClient
+	conn    *grpc.ClientConn
+	breaker *gobreaker.TwoStepCircuitBreaker[any]
 }
 
-func NewAuthClient(connString string) *AuthClient {
-	conn, err := grpc.NewClient(connString, grpc.WithTransportCredentials(insecure.NewCredentials()))
+func NewAuthClient(connString string, cfg config.ClientConfig, tlsCfg config.TLSConfig) *AuthClient {
+	creds, err := transportCredentials(tlsCfg, mtls.AuthService)
+	if err != nil {
+		panic(err)
+	}
+	service := proto.AuthService_ServiceDesc.ServiceName
+	breaker := newBreaker(service, cfg)
+	conn, err := grpc.NewClient(connString,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithDefaultServiceConfig(serviceConfig(service, authReads, nil, cfg)),
+		grpc.WithChainUnaryInterceptor(breakerInterceptor(breaker), forwardTokenInterceptor(), idempotencyInterceptor()),
+	)
 	if err != nil {
 		panic(err)
 	}
 	client := proto.NewAuthServiceClient(conn)
-	return &AuthClient{client}
+	return &AuthClient{client, healthpb.NewHealthClient(conn), conn, breaker}
+}
+
+// ServiceName is the name the auth service reports its health under.
+func (c *AuthClient) ServiceName() string {
+	return proto.AuthService_ServiceDesc.ServiceName
+}
+
+// BreakerState is the state of the circuit breaker guarding the auth service.
+func (c *AuthClient) BreakerState() string {
+	return c.breaker.State().String()
+}
+
+func (c *AuthClient) Close() error {
+	return c.conn.Close()
 }
@@ -0,0 +1,39 @@
+package client
+
+import (
+	"api-gateway/middleware"
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// replayedHeader is the gRPC header set by the microservices on the responses replayed from an idempotency key.
+const replayedHeader = "idempotent-replayed"
+
+// idempotencyInterceptor sets the idempotency_key field of every request that has one to the Idempotency-Key header
+// validated by middleware.Idempotency, so that the REST API doesn't need the key in the body as well, and reports the
+// replayed responses back to the middleware.
+func idempotencyInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		key, ok := middleware.IdempotencyKey(ctx)
+		if !ok {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		if msg, ok := req.(proto.Message); ok {
+			m := msg.ProtoReflect()
+			if field := m.Descriptor().Fields().ByName("idempotency_key"); field != nil && field.Kind() == protoreflect.StringKind {
+				m.Set(field, protoreflect.ValueOfString(key))
+			}
+		}
+
+		var header metadata.MD
+		err := invoker(ctx, method, req, reply, cc, append(opts, grpc.Header(&header))...)
+		if values := header.Get(replayedHeader); len(values) > 0 && values[0] == "true" {
+			middleware.MarkReplayed(ctx)
+		}
+		return err
+	}
+}
@@ -0,0 +1,66 @@
+package client
+
+import (
+	"account/proto"
+	"api-gateway/middleware"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// idempotencyContext returns the context of a request that went through middleware.Idempotency with key, and the
+// recorder of its response.
+func idempotencyContext(t *testing.T, key string, call func(ctx context.Context)) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/transactions", nil)
+	r.Header.Set(middleware.IdempotencyKeyHeader, key)
+	w := httptest.NewRecorder()
+	middleware.Idempotency(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})).ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+	return w
+}
+
+func TestIdempotencyInterceptor(t *testing.T) {
+	const key = "0f8fad5b-d9cb-469f-a165-70867728950e"
+	interceptor := idempotencyInterceptor()
+	invoke := func(ctx context.Context, req any, replayed bool) {
+		invoker := func(_ context.Context, _ string, _, _ any, _ *grpc.ClientConn, opts ...grpc.CallOption) error {
+			if replayed {
+				for _, opt := range opts {
+					if header, ok := opt.(grpc.HeaderCallOption); ok {
+						*header.HeaderAddr = metadata.Pairs(replayedHeader, "true")
+					}
+				}
+			}
+			return nil
+		}
+		require.NoError(t, interceptor(ctx, "/account.AccountService/CreateTransaction", req, nil, nil, invoker))
+	}
+
+	// the key of the header replaces the one of the body
+	req := &proto.CreateTransactionRequest{IdempotencyKey: "from-the-body"}
+	w := idempotencyContext(t, key, func(ctx context.Context) { invoke(ctx, req, false) })
+	require.Equal(t, key, req.IdempotencyKey)
+	require.Empty(t, w.Header().Get(middleware.IdempotentReplayedHeader))
+
+	// the replayed responses are reported to the middleware
+	w = idempotencyContext(t, key, func(ctx context.Context) { invoke(ctx, &proto.CreateTransactionRequest{}, true) })
+	require.Equal(t, "true", w.Header().Get(middleware.IdempotentReplayedHeader))
+
+	// requests without the field are left as they are
+	getReq := &proto.GetAccountByAccountIdRequest{AccountId: "id"}
+	idempotencyContext(t, key, func(ctx context.Context) { invoke(ctx, getReq, false) })
+	require.Equal(t, "id", getReq.AccountId)
+
+	// so are requests that didn't go through the middleware
+	req = &proto.CreateTransactionRequest{IdempotencyKey: "from-the-body"}
+	invoke(context.Background(), req, false)
+	require.Equal(t, "from-the-body", req.IdempotencyKey)
+}
@@ -0,0 +1,23 @@
+package client
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	breakerState = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "gateway_circuit_breaker_state",
+		Help: "State of the circuit breaker of each downstream service: 0 closed, 1 half-open, 2 open.",
+	}, []string{"service"})
+
+	breakerRejections = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_circuit_breaker_rejections_total",
+		Help: "Requests failed fast because the circuit breaker of the downstream service was open.",
+	}, []string{"service"})
+
+	hedgedRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_hedged_requests_total",
+		Help: "Hedged attempts sent because the first attempt was slower than the hedge delay.",
+	}, []string{"method"})
+)
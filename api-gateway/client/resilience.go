@@ -0,0 +1,220 @@
+package client
+
+import (
+	"api-gateway/config"
+	"api-gateway/internal/mtls"
+	"api-gateway/middleware"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sony/gobreaker/v2"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// methodName identifies one RPC in a gRPC service config.
+type methodName struct {
+	Service string `json:"service"`
+	Method  string `json:"method,omitempty"` // empty matches every method of the service
+}
+
+type retryPolicy struct {
+	MaxAttempts          int      `json:"maxAttempts"`
+	InitialBackoff       string   `json:"initialBackoff"`
+	MaxBackoff           string   `json:"maxBackoff"`
+	BackoffMultiplier    float64  `json:"backoffMultiplier"`
+	RetryableStatusCodes []string `json:"retryableStatusCodes"`
+}
+
+type methodConfig struct {
+	Name        []methodName `json:"name"`
+	Timeout     string       `json:"timeout,omitempty"`
+	RetryPolicy *retryPolicy `json:"retryPolicy,omitempty"`
+}
+
+// serviceConfig builds the gRPC service config of a downstream service.
+// Every method gets cfg.WriteTimeout and no retries, except the given read-only methods which get cfg.ReadTimeout
+// and are retried on UNAVAILABLE. Writes are never retried by the transport: a retry after the request reached the
+// server could execute a money movement twice.
+// The given long-lived streams get no timeout at all, they end when the caller goes away.
+func serviceConfig(service string, reads, streams []string, cfg config.ClientConfig) string {
+	readNames := make([]methodName, len(reads))
+	for i, method := range reads {
+		readNames[i] = methodName{Service: service, Method: method}
+	}
+
+	sc := struct {
+		MethodConfig []methodConfig `json:"methodConfig"`
+	}{
+		MethodConfig: []methodConfig{
+			{
+				Name:    []methodName{{Service: service}},
+				Timeout: grpcDuration(cfg.WriteTimeout),
+			},
+			{
+				Name:    readNames,
+				Timeout: grpcDuration(cfg.ReadTimeout),
+				RetryPolicy: &retryPolicy{
+					MaxAttempts:          cfg.MaxRetryAttempts,
+					InitialBackoff:       "0.1s",
+					MaxBackoff:           "1s",
+					BackoffMultiplier:    2,
+					RetryableStatusCodes: []string{"UNAVAILABLE"},
+				},
+			},
+		},
+	}
+	if len(streams) > 0 {
+		streamNames := make([]methodName, len(streams))
+		for i, method := range streams {
+			streamNames[i] = methodName{Service: service, Method: method}
+		}
+		sc.MethodConfig = append(sc.MethodConfig, methodConfig{Name: streamNames})
+	}
+	out, err := json.Marshal(sc)
+	if err != nil {
+		panic(err) // only static types are marshalled
+	}
+	return string(out)
+}
+
+// grpcDuration formats d the way the service config JSON expects, e.g. "1.5s".
+func grpcDuration(d time.Duration) string {
+	return fmt.Sprintf("%gs", d.Seconds())
+}
+
+// transportCredentials returns the mTLS credentials to call the given service, or plaintext credentials if TLS is disabled.
+func transportCredentials(tlsCfg config.TLSConfig, service string) (credentials.TransportCredentials, error) {
+	if !tlsCfg.Enabled() {
+		return insecure.NewCredentials(), nil
+	}
+	return mtls.ClientCredentials(tlsCfg, service)
+}
+
+// newBreaker creates the circuit breaker guarding every RPC to the given service.
+func newBreaker(service string, cfg config.ClientConfig) *gobreaker.TwoStepCircuitBreaker[any] {
+	threshold := uint32(cfg.BreakerFailureThreshold)
+	cb := gobreaker.NewTwoStepCircuitBreaker[any](gobreaker.Settings{
+		Name:    service,
+		Timeout: cfg.BreakerOpenTimeout,
+		ReadyToTrip: func(counts gobreaker.Counts) bool {
+			return counts.ConsecutiveFailures >= threshold
+		},
+		OnStateChange: func(name string, from, to gobreaker.State) {
+			breakerState.WithLabelValues(name).Set(float64(to))
+		},
+	})
+	breakerState.WithLabelValues(service).Set(float64(gobreaker.StateClosed))
+	return cb
+}
+
+// breakerInterceptor fails fast with UNAVAILABLE while the breaker is open instead of letting requests pile up
+// behind a struggling service. Only errors that indicate an unhealthy service trip the breaker; business errors
+// such as NOT_FOUND or INVALID_ARGUMENT are successful round trips.
+// Health checks bypass the breaker so that /readyz always reflects the real state of the service.
+func breakerInterceptor(cb *gobreaker.TwoStepCircuitBreaker[any]) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if strings.HasPrefix(method, "/grpc.health.v1.Health/") {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		done, err := cb.Allow()
+		if err != nil {
+			breakerRejections.WithLabelValues(cb.Name()).Inc()
+			return status.Errorf(codes.Unavailable, "%s is unavailable: %v", cb.Name(), err)
+		}
+		err = invoker(ctx, method, req, reply, cc, opts...)
+		done(!isServiceFailure(err))
+		return err
+	}
+}
+
+// forwardTokenInterceptor forwards the access token of the end user, if any, in the "authorization" metadata.
+// The microservices verify it themselves and take the user from it instead of trusting the user ID in the request.
+func forwardTokenInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if token, ok := middleware.AccessToken(ctx); ok {
+			ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// forwardTokenStreamInterceptor is the forwardTokenInterceptor of streaming RPCs, e.g. WatchAccount.
+// Streams bypass the circuit breaker: they are long-lived, so their outcome says little about the health of the service.
+func forwardTokenStreamInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if token, ok := middleware.AccessToken(ctx); ok {
+			ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+		}
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
+
+// isServiceFailure reports whether err says that the service is failing, rather than rejecting the request.
+// RESOURCE_EXHAUSTED is only a failure without a google.rpc.ErrorInfo: the services set one on their business errors,
+// e.g. LIMIT_EXCEEDED for a debit over a limit of the user, which says nothing about their health.
+func isServiceFailure(err error) bool {
+	st := status.Convert(err)
+	switch st.Code() {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Unknown:
+		return true
+	case codes.ResourceExhausted:
+		for _, detail := range st.Details() {
+			if _, ok := detail.(*errdetails.ErrorInfo); ok {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// hedge calls call, and if it hasn't returned after delay, calls it a second time and returns whichever succeeds first.
+// It must only be used for idempotent reads. The losing attempt is cancelled.
+// An error from the first attempt before the hedge is sent is returned as-is: retrying failures is the job of the retry policy.
+func hedge[T any](ctx context.Context, method string, delay time.Duration, call func(context.Context) (T, error)) (T, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		res T
+		err error
+	}
+	results := make(chan result, 2)
+	attempt := func() {
+		res, err := call(ctx)
+		results <- result{res, err}
+	}
+
+	go attempt()
+	inFlight := 1
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	hedged := false
+
+	for {
+		select {
+		case <-timer.C:
+			hedged = true
+			inFlight++
+			hedgedRequests.WithLabelValues(method).Inc()
+			go attempt()
+		case r := <-results:
+			inFlight--
+			if r.err == nil || !hedged || inFlight == 0 {
+				return r.res, r.err
+			}
+			// the other attempt may still succeed
+		}
+	}
+}
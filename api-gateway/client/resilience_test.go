@@ -0,0 +1,178 @@
+package client
+
+import (
+	"api-gateway/config"
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sony/gobreaker/v2"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func testClientConfig() config.ClientConfig {
+	return config.ClientConfig{
+		ReadTimeout:             2 * time.Second,
+		WriteTimeout:            10 * time.Second,
+		MaxRetryAttempts:        3,
+		BreakerFailureThreshold: 2,
+		BreakerOpenTimeout:      50 * time.Millisecond,
+	}
+}
+
+func TestServiceConfig(t *testing.T) {
+	var sc struct {
+		MethodConfig []methodConfig `json:"methodConfig"`
+	}
+	err := json.Unmarshal([]byte(serviceConfig("pkg.Service", []string{"Get"}, []string{"Watch"}, testClientConfig())), &sc)
+	require.NoError(t, err)
+	require.Len(t, sc.MethodConfig, 3)
+
+	// writes are never retried
+	writes := sc.MethodConfig[0]
+	require.Equal(t, []methodName{{Service: "pkg.Service"}}, writes.Name)
+	require.Equal(t, "10s", writes.Timeout)
+	require.Nil(t, writes.RetryPolicy)
+
+	reads := sc.MethodConfig[1]
+	require.Equal(t, []methodName{{Service: "pkg.Service", Method: "Get"}}, reads.Name)
+	require.Equal(t, "2s", reads.Timeout)
+	require.NotNil(t, reads.RetryPolicy)
+	require.Equal(t, 3, reads.RetryPolicy.MaxAttempts)
+	require.Equal(t, []string{"UNAVAILABLE"}, reads.RetryPolicy.RetryableStatusCodes)
+
+	streams := sc.MethodConfig[2]
+	require.Equal(t, []methodName{{Service: "pkg.Service", Method: "Watch"}}, streams.Name)
+	require.Empty(t, streams.Timeout)
+	require.Nil(t, streams.RetryPolicy)
+}
+
+func TestIsServiceFailure(t *testing.T) {
+	limitExceeded, err := status.New(codes.ResourceExhausted, "daily_debit_amount limit exceeded").WithDetails(&errdetails.ErrorInfo{
+		Reason: "LIMIT_EXCEEDED",
+		Domain: "account.banking-app",
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{status.Error(codes.Unavailable, ""), true},
+		{status.Error(codes.DeadlineExceeded, ""), true},
+		{status.Error(codes.ResourceExhausted, ""), true},
+		{limitExceeded.Err(), false},       // a business error
+		{errors.New("not a status"), true}, // codes.Unknown
+		{status.Error(codes.NotFound, ""), false},
+		{status.Error(codes.InvalidArgument, ""), false},
+		{status.Error(codes.FailedPrecondition, ""), false},
+		{status.Error(codes.Internal, ""), false},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, isServiceFailure(tt.err), "%v", tt.err)
+	}
+}
+
+func TestBreakerInterceptor(t *testing.T) {
+	cb := newBreaker("test-breaker", testClientConfig())
+	interceptor := breakerInterceptor(cb)
+	call := func(method string, err error) (bool, error) {
+		invoked := false
+		invoker := func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error {
+			invoked = true
+			return err
+		}
+		return invoked, interceptor(context.Background(), method, nil, nil, nil, invoker)
+	}
+
+	// business errors are successful round trips
+	for range 3 {
+		invoked, err := call("/pkg.Service/Get", status.Error(codes.NotFound, "not found"))
+		require.True(t, invoked)
+		require.Equal(t, codes.NotFound, status.Code(err))
+	}
+	require.Equal(t, gobreaker.StateClosed, cb.State())
+
+	// the threshold of consecutive failures opens the breaker, which then fails fast
+	for range 2 {
+		invoked, _ := call("/pkg.Service/Get", status.Error(codes.Unavailable, "down"))
+		require.True(t, invoked)
+	}
+	require.Equal(t, gobreaker.StateOpen, cb.State())
+	invoked, err := call("/pkg.Service/Get", nil)
+	require.False(t, invoked)
+	require.Equal(t, codes.Unavailable, status.Code(err))
+
+	// health checks bypass it
+	invoked, err = call("/grpc.health.v1.Health/Check", nil)
+	require.True(t, invoked)
+	require.NoError(t, err)
+
+	// after the open timeout, a failed probe opens it again and a successful one closes it
+	time.Sleep(60 * time.Millisecond)
+	require.Equal(t, gobreaker.StateHalfOpen, cb.State())
+	invoked, _ = call("/pkg.Service/Get", status.Error(codes.DeadlineExceeded, "slow"))
+	require.True(t, invoked)
+	require.Equal(t, gobreaker.StateOpen, cb.State())
+
+	time.Sleep(60 * time.Millisecond)
+	invoked, err = call("/pkg.Service/Get", nil)
+	require.True(t, invoked)
+	require.NoError(t, err)
+	require.Equal(t, gobreaker.StateClosed, cb.State())
+}
+
+func TestHedge(t *testing.T) {
+	// a fast first attempt isn't hedged
+	calls := 0
+	res, err := hedge(context.Background(), "test", 50*time.Millisecond, func(context.Context) (int, error) {
+		calls++
+		return 1, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, res)
+	require.Equal(t, 1, calls)
+
+	// a slow first attempt loses to the hedge, and is cancelled
+	var n atomic.Int32
+	cancelled := make(chan struct{})
+	res, err = hedge(context.Background(), "test", 10*time.Millisecond, func(ctx context.Context) (int, error) {
+		if n.Add(1) == 1 {
+			<-ctx.Done()
+			close(cancelled)
+			return 0, ctx.Err()
+		}
+		return 2, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, res)
+	<-cancelled
+
+	// a failure before the hedge is sent is returned as is
+	failure := status.Error(codes.NotFound, "not found")
+	_, err = hedge(context.Background(), "test", 50*time.Millisecond, func(context.Context) (int, error) {
+		return 0, failure
+	})
+	require.Equal(t, failure, err)
+
+	// once hedged, a failure waits for the other attempt
+	n.Store(0)
+	res, err = hedge(context.Background(), "test", 10*time.Millisecond, func(ctx context.Context) (int, error) {
+		if n.Add(1) == 1 {
+			time.Sleep(20 * time.Millisecond)
+			return 0, status.Error(codes.Unavailable, "down")
+		}
+		time.Sleep(30 * time.Millisecond)
+		return 3, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, res)
+}
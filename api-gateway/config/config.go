@@ -0,0 +1,243 @@
+// Package config loads and validates the configuration of the API Gateway.
+//
+// Values are resolved in order of increasing precedence:
+//  1. the defaults returned by Default()
+//  2. the optional YAML file pointed to by the CONFIG_FILE environment variable
+//  3. environment variables
+//
+// Load fails if a required field is missing or a value is malformed, so a misconfigured
+// gateway refuses to start instead of silently falling back to a default.
+package config
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+type Config struct {
+	HTTPPort        int           `yaml:"http_port"`
+	AdminPort       int           `yaml:"admin_port"` // serves /debug/config. 0 disables the admin server.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+
+	Services ServicesConfig `yaml:"services"`
+	Clients  ClientConfig   `yaml:"clients"`
+	TLS      TLSConfig      `yaml:"tls"`
+	JWT      JWTConfig      `yaml:"jwt"`
+	CORS     CORSConfig     `yaml:"cors"`
+}
+
+// ServicesConfig holds the gRPC addresses (host:port) of the downstream microservices.
+type ServicesConfig struct {
+	AuthURL     string `yaml:"auth_url"`
+	AccountURL  string `yaml:"account_url"`
+	TransferURL string `yaml:"transfer_url"`
+}
+
+// ClientConfig tunes the resilience of the gRPC clients to the downstream microservices.
+type ClientConfig struct {
+	ReadTimeout             time.Duration `yaml:"read_timeout"`              // per-attempt deadline of idempotent reads
+	WriteTimeout            time.Duration `yaml:"write_timeout"`             // deadline of every other RPC
+	MaxRetryAttempts        int           `yaml:"max_retry_attempts"`        // attempts for reads failing with UNAVAILABLE, including the first one
+	BreakerFailureThreshold int           `yaml:"breaker_failure_threshold"` // consecutive failures that open the circuit breaker
+	BreakerOpenTimeout      time.Duration `yaml:"breaker_open_timeout"`      // how long the breaker stays open before letting a probe through
+	HedgeDelay              time.Duration `yaml:"hedge_delay"`               // delay before hedging GetAccountByAccountId. 0 disables hedging.
+}
+
+// TLSConfig points to the PEM files of the mTLS credentials shared by the internal services.
+// TLS is disabled when all of them are empty, which is only meant for local development.
+type TLSConfig struct {
+	CAFile   string `yaml:"ca_file"`
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+}
+
+type JWTConfig struct {
+	SecretKey           string        `yaml:"secret_key"`            // base64 encoded HMAC key shared with the auth service
+	AccessTokenDuration time.Duration `yaml:"access_token_duration"` // max age of the fingerprint cookie. Should match the auth service.
+
+	key []byte // SecretKey decoded once by Validate
+}
+
+type CORSConfig struct {
+	AllowedOrigins []string `yaml:"allowed_origins"`
+}
+
+func Default() *Config {
+	return &Config{
+		HTTPPort:        3000,
+		AdminPort:       9100,
+		ShutdownTimeout: 30 * time.Second,
+		Clients: ClientConfig{
+			ReadTimeout:             2 * time.Second,
+			WriteTimeout:            10 * time.Second,
+			MaxRetryAttempts:        3,
+			BreakerFailureThreshold: 5,
+			BreakerOpenTimeout:      10 * time.Second,
+		},
+		JWT: JWTConfig{
+			AccessTokenDuration: 15 * time.Minute,
+		},
+		CORS: CORSConfig{
+			AllowedOrigins: []string{"http://localhost:3000"}, // frontend origin
+		},
+	}
+}
+
+// Load builds the config from the defaults, the optional CONFIG_FILE and the environment, then validates it.
+func Load() (*Config, error) {
+	cfg := Default()
+
+	var env envLoader
+	var path string
+	env.string(&path, "CONFIG_FILE")
+	if path != "" {
+		if err := loadFile(path, cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	env.int(&cfg.HTTPPort, "HTTP_PORT")
+	env.int(&cfg.AdminPort, "ADMIN_HTTP_PORT")
+	env.duration(&cfg.ShutdownTimeout, "SHUTDOWN_TIMEOUT")
+
+	env.string(&cfg.Services.AuthURL, "AUTH_SERVICE_URL")
+	env.string(&cfg.Services.AccountURL, "ACCOUNT_SERVICE_URL")
+	env.string(&cfg.Services.TransferURL, "TRANSFER_SERVICE_URL")
+
+	env.duration(&cfg.Clients.ReadTimeout, "CLIENT_READ_TIMEOUT")
+	env.duration(&cfg.Clients.WriteTimeout, "CLIENT_WRITE_TIMEOUT")
+	env.int(&cfg.Clients.MaxRetryAttempts, "CLIENT_MAX_RETRY_ATTEMPTS")
+	env.int(&cfg.Clients.BreakerFailureThreshold, "CIRCUIT_BREAKER_FAILURE_THRESHOLD")
+	env.duration(&cfg.Clients.BreakerOpenTimeout, "CIRCUIT_BREAKER_OPEN_TIMEOUT")
+	env.duration(&cfg.Clients.HedgeDelay, "HEDGE_DELAY")
+	env.string(&cfg.TLS.CAFile, "TLS_CA_FILE")
+	env.string(&cfg.TLS.CertFile, "TLS_CERT_FILE")
+	env.string(&cfg.TLS.KeyFile, "TLS_KEY_FILE")
+
+	env.string(&cfg.JWT.SecretKey, "JWT_SECRET_KEY")
+	env.duration(&cfg.JWT.AccessTokenDuration, "ACCESS_TOKEN_DURATION")
+
+	env.list(&cfg.CORS.AllowedOrigins, "CORS_ALLOWED_ORIGINS")
+
+	if err := errors.Join(env.errs...); err != nil {
+		return nil, fmt.Errorf("invalid environment: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	return cfg, nil
+}
+
+func (c *Config) Validate() error {
+	var errs []error
+	errs = append(errs, validatePort("http_port", c.HTTPPort))
+	if c.AdminPort != 0 {
+		errs = append(errs, validatePort("admin_port", c.AdminPort))
+	}
+	if c.ShutdownTimeout <= 0 {
+		errs = append(errs, errors.New("shutdown_timeout must be positive"))
+	}
+	// the transfer service isn't routed by the gateway yet, so its address is optional
+	if c.Services.AuthURL == "" {
+		errs = append(errs, errors.New("services.auth_url is required"))
+	}
+	if c.Services.AccountURL == "" {
+		errs = append(errs, errors.New("services.account_url is required"))
+	}
+	if len(c.CORS.AllowedOrigins) == 0 {
+		errs = append(errs, errors.New("cors.allowed_origins must not be empty"))
+	}
+	for _, origin := range c.CORS.AllowedOrigins {
+		if origin == "*" {
+			errs = append(errs, errors.New("cors.allowed_origins must not contain \"*\" since credentials are allowed"))
+		}
+	}
+	errs = append(errs, c.Clients.Validate(), c.TLS.Validate(), c.JWT.Validate())
+	return errors.Join(errs...)
+}
+
+func (c *ClientConfig) Validate() error {
+	var errs []error
+	if c.ReadTimeout <= 0 {
+		errs = append(errs, errors.New("clients.read_timeout must be positive"))
+	}
+	if c.WriteTimeout <= 0 {
+		errs = append(errs, errors.New("clients.write_timeout must be positive"))
+	}
+	// gRPC silently caps maxAttempts at 5
+	if c.MaxRetryAttempts < 2 || c.MaxRetryAttempts > 5 {
+		errs = append(errs, errors.New("clients.max_retry_attempts must be between 2 and 5"))
+	}
+	if c.BreakerFailureThreshold < 1 {
+		errs = append(errs, errors.New("clients.breaker_failure_threshold must be at least 1"))
+	}
+	if c.BreakerOpenTimeout <= 0 {
+		errs = append(errs, errors.New("clients.breaker_open_timeout must be positive"))
+	}
+	if c.HedgeDelay < 0 {
+		errs = append(errs, errors.New("clients.hedge_delay must not be negative"))
+	} else if c.HedgeDelay >= c.ReadTimeout {
+		errs = append(errs, errors.New("clients.hedge_delay must be shorter than clients.read_timeout"))
+	}
+	return errors.Join(errs...)
+}
+
+func (c *TLSConfig) Enabled() bool {
+	return c.CAFile != "" || c.CertFile != "" || c.KeyFile != ""
+}
+
+func (c *TLSConfig) Validate() error {
+	if c.Enabled() && (c.CAFile == "" || c.CertFile == "" || c.KeyFile == "") {
+		return errors.New("tls.ca_file, tls.cert_file and tls.key_file must be set together")
+	}
+	return nil
+}
+
+// Validate also decodes the secret key, so Key() is only usable after a successful Validate.
+func (c *JWTConfig) Validate() error {
+	var errs []error
+	if c.SecretKey == "" {
+		errs = append(errs, errors.New("jwt.secret_key is required"))
+	} else if key, err := base64.StdEncoding.DecodeString(c.SecretKey); err != nil {
+		errs = append(errs, errors.New("jwt.secret_key must be base64 encoded"))
+	} else {
+		c.key = key
+	}
+	if c.AccessTokenDuration <= 0 {
+		errs = append(errs, errors.New("jwt.access_token_duration must be positive"))
+	}
+	return errors.Join(errs...)
+}
+
+// Key returns the decoded HMAC key used to verify access tokens.
+func (c *JWTConfig) Key() []byte {
+	return c.key
+}
+
+// Redacted returns a copy of the config with every secret masked.
+func (c *Config) Redacted() *Config {
+	cp := *c
+	cp.JWT.SecretKey = redact(c.JWT.SecretKey)
+	cp.JWT.key = nil
+	cp.CORS.AllowedOrigins = append([]string(nil), c.CORS.AllowedOrigins...)
+	return &cp
+}
+
+// DumpHandler serves the redacted effective config as YAML, in the same format accepted by CONFIG_FILE.
+func (c *Config) DumpHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		out, err := yaml.Marshal(c.Redacted())
+		if err != nil {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/yaml")
+		w.Header().Set("Cache-Control", "no-store")
+		w.Write(out)
+	})
+}
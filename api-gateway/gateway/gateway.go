@@ -0,0 +1,97 @@
+// Package gateway serves the REST API generated from the google.api.http annotations of the microservice protos.
+//
+// The routes, request decoding and response encoding are generated by grpc-gateway, so adding an RPC to the REST API
+// only takes an annotation in the .proto file. The mux is mounted in the chi router, so every request still goes
+// through the chi middleware (request ID, logging, CORS and the JWT validation of the protected routes).
+package gateway
+
+import (
+	accountpb "account/proto"
+	"api-gateway/client"
+	"api-gateway/model"
+	"api-gateway/utils"
+	authpb "auth/proto"
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// maxBodyBytes is the largest request body accepted.
+const maxBodyBytes = 262144
+
+// PublicRoutes are the method and path of the REST routes that don't require an access token.
+var PublicRoutes = []struct{ Method, Path string }{
+	{http.MethodPost, "/users"},
+	{http.MethodPost, "/auth/login"},
+}
+
+// NewHandler returns the REST API handler, which serves the routes under /api/v1 by calling the given clients.
+// The TransferService is not registered yet since the transfer service is not deployed.
+// fingerprintMaxAge is the lifetime of the fingerprint cookie set on login.
+func NewHandler(ctx context.Context, authClient *client.AuthClient, accountClient *client.AccountClient, fingerprintMaxAge time.Duration) (http.Handler, error) {
+	mux := runtime.NewServeMux(
+		runtime.WithErrorHandler(func(_ context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+			utils.WriteGRPCErrorToHTTP(w, r, err)
+		}),
+		runtime.WithRoutingErrorHandler(func(_ context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, r *http.Request, httpStatus int) {
+			utils.WriteError(w, r, httpStatus, http.StatusText(httpStatus))
+		}),
+		// No HTTP header is forwarded as metadata: the access token is forwarded by the gRPC clients once the auth
+		// middleware validated it, a client must not be able to send one the gateway didn't check.
+		runtime.WithIncomingHeaderMatcher(func(string) (string, bool) { return "", false }),
+		runtime.WithOutgoingHeaderMatcher(func(string) (string, bool) { return "", false }),
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
+			MarshalOptions:   protojson.MarshalOptions{EmitUnpopulated: true},
+			UnmarshalOptions: protojson.UnmarshalOptions{DiscardUnknown: false},
+		}),
+		runtime.WithForwardResponseOption(fingerprintCookie(fingerprintMaxAge)),
+	)
+
+	if err := authpb.RegisterAuthServiceHandlerClient(ctx, mux, authClient); err != nil {
+		return nil, err
+	}
+	if err := accountpb.RegisterAccountServiceHandlerClient(ctx, mux, accountClient); err != nil {
+		return nil, err
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// grpc-gateway forwards the Authorization header whatever the header matcher says. It is removed from a copy of
+		// the request, so that the one of the caller is left as it is.
+		r = r.Clone(r.Context())
+		r.Header.Del("Authorization")
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+		mux.ServeHTTP(w, r)
+	}), nil
+}
+
+// fingerprintCookie moves the fingerprint of the login and renew responses to the fingerprint cookie, so that it can't
+// be read by scripts.
+func fingerprintCookie(loginMaxAge time.Duration) func(context.Context, http.ResponseWriter, proto.Message) error {
+	return func(_ context.Context, w http.ResponseWriter, resp proto.Message) error {
+		switch resp := resp.(type) {
+		case *authpb.LoginResponse:
+			setFingerprintCookie(w, resp.Fingerprint, int(loginMaxAge.Seconds()))
+			resp.Fingerprint = ""
+		case *authpb.RenewAccessTokenResponse:
+			setFingerprintCookie(w, resp.Fingerprint, int(resp.AccessTokenDuration))
+			resp.Fingerprint = ""
+		}
+		return nil
+	}
+}
+
+func setFingerprintCookie(w http.ResponseWriter, fingerprint string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     model.FingerprintCookieName,
+		Value:    fingerprint,
+		MaxAge:   maxAge,
+		SameSite: http.SameSiteStrictMode,
+		HttpOnly: true,
+		Secure:   false, // TODO: set to true during production
+	})
+}
@@ -0,0 +1,118 @@
+package gateway
+
+import (
+	accountpb "account/proto"
+	"api-gateway/client"
+	"api-gateway/model"
+	"api-gateway/utils"
+	authpb "auth/proto"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// fakeAccounts records the calls made through the REST API. Only the RPCs the tests call are implemented.
+type fakeAccounts struct {
+	accountpb.AccountServiceClient
+	req *accountpb.GetAccountByAccountIdRequest
+	md  metadata.MD
+}
+
+func (f *fakeAccounts) GetAccountByAccountId(ctx context.Context, req *accountpb.GetAccountByAccountIdRequest, _ ...grpc.CallOption) (*accountpb.Account, error) {
+	f.req = req
+	f.md, _ = metadata.FromOutgoingContext(ctx)
+	if req.AccountId == "missing" {
+		return nil, status.Error(codes.NotFound, "account not found")
+	}
+	return &accountpb.Account{AccountId: req.AccountId, AccountNumber: 42}, nil
+}
+
+type fakeAuth struct {
+	authpb.AuthServiceClient
+	req *authpb.LoginRequest
+}
+
+func (f *fakeAuth) Login(_ context.Context, req *authpb.LoginRequest, _ ...grpc.CallOption) (*authpb.LoginResponse, error) {
+	f.req = req
+	return &authpb.LoginResponse{AccessToken: "access", RefreshToken: "refresh", Fingerprint: "fingerprint", AccessTokenDuration: 900}, nil
+}
+
+func newTestHandler(t *testing.T) (http.Handler, *fakeAuth, *fakeAccounts) {
+	auth := &fakeAuth{}
+	accounts := &fakeAccounts{}
+	h, err := NewHandler(context.Background(), &client.AuthClient{AuthServiceClient: auth}, &client.AccountClient{AccountServiceClient: accounts}, time.Hour)
+	require.NoError(t, err)
+	return h, auth, accounts
+}
+
+func TestNewHandler_Routes(t *testing.T) {
+	h, _, accounts := newTestHandler(t)
+
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/accounts/0f8fad5b-d9cb-469f-a165-70867728950e", nil)
+	r.Header.Set("Authorization", "Bearer forged")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "0f8fad5b-d9cb-469f-a165-70867728950e", accounts.req.AccountId)
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	require.Equal(t, "0f8fad5b-d9cb-469f-a165-70867728950e", body["accountId"])
+	// the access token is forwarded by the clients once checked, never from the request itself
+	require.Empty(t, accounts.md.Get("authorization"))
+	require.Equal(t, "Bearer forged", r.Header.Get("Authorization"), "the request of the caller is left as it is")
+
+	// the errors of the services and of the routing are problem+json
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/accounts/missing", nil))
+	require.Equal(t, http.StatusNotFound, w.Code)
+	require.Equal(t, utils.ProblemContentType, w.Header().Get("Content-Type"))
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/unknown", nil))
+	require.Equal(t, http.StatusNotFound, w.Code)
+	require.Equal(t, utils.ProblemContentType, w.Header().Get("Content-Type"))
+}
+
+func TestNewHandler_FingerprintCookie(t *testing.T) {
+	h, auth, _ := newTestHandler(t)
+
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(`{"email":"a@b.c","password":"secret"}`))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "a@b.c", auth.req.Email)
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 1)
+	require.Equal(t, model.FingerprintCookieName, cookies[0].Name)
+	require.Equal(t, "fingerprint", cookies[0].Value)
+	require.Equal(t, 3600, cookies[0].MaxAge)
+	require.True(t, cookies[0].HttpOnly)
+
+	// the fingerprint is only readable from the cookie, not by scripts
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	require.Empty(t, body["fingerprint"])
+	require.Equal(t, "access", body["accessToken"])
+}
+
+func TestNewHandler_BodyLimit(t *testing.T) {
+	h, auth, _ := newTestHandler(t)
+
+	body := `{"email":"a@b.c","password":"` + strings.Repeat("x", maxBodyBytes) + `"}`
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(body)))
+	require.GreaterOrEqual(t, w.Code, 400)
+	require.Nil(t, auth.req)
+}
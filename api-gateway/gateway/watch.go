@@ -0,0 +1,204 @@
+package gateway
+
+import (
+	accountpb "account/proto"
+	"api-gateway/client"
+	"api-gateway/utils"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/go-chi/chi/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const (
+	// sseRetry is how long a browser waits before reconnecting a broken event stream.
+	sseRetry = 3 * time.Second
+	// wsWriteTimeout is how long a WebSocket client may take to accept a message before it is disconnected.
+	wsWriteTimeout = 10 * time.Second
+)
+
+// WatchHandler relays the WatchAccount stream of the account service to browsers, over Server-Sent Events or
+// WebSocket. Every message is an AccountEvent in its protobuf JSON form, the same as the rest of /api/v1.
+//
+// A client resumes a broken stream by sending the event_id of the last event it received, in the Last-Event-ID header
+// (sent by EventSource when it reconnects) or in the last_event_id query parameter.
+type WatchHandler struct {
+	client         *client.AccountClient
+	originPatterns []string // hosts allowed to open a WebSocket from another origin
+}
+
+// NewWatchHandler creates the handler of the account event streams.
+// allowedOrigins are the CORS origins of the gateway, e.g. "http://localhost:3000".
+func NewWatchHandler(accountClient *client.AccountClient, allowedOrigins []string) *WatchHandler {
+	var patterns []string
+	for _, origin := range allowedOrigins {
+		if u, err := url.Parse(origin); err == nil && u.Host != "" {
+			patterns = append(patterns, u.Host)
+		}
+	}
+	return &WatchHandler{client: accountClient, originPatterns: patterns}
+}
+
+// TokenFromQuery lets the event streams take the access token from the access_token query parameter, since neither
+// EventSource nor the browser WebSocket API can set the Authorization header. The token is still bound to the
+// fingerprint cookie, so a token leaked through a URL is useless on its own.
+func TokenFromQuery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token := r.URL.Query().Get("access_token"); token != "" && r.Header.Get("Authorization") == "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ServeSSE serves GET /api/v1/accounts/{accountID}/events as a text/event-stream.
+// Heartbeats are sent as comments, which EventSource ignores.
+func (h *WatchHandler) ServeSSE(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		utils.WriteError(w, r, http.StatusInternalServerError, "Streaming is not supported")
+		return
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	stream, err := h.open(r.Context(), r, lastEventID)
+	if err != nil {
+		log.Printf("ServeSSE: %v\n", err)
+		utils.WriteGRPCErrorToHTTP(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // proxies must not buffer the stream
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", sseRetry.Milliseconds())
+	flusher.Flush()
+
+	for {
+		event, err := stream.Recv()
+		if err != nil {
+			// the browser reconnects on its own, with the Last-Event-ID of the last event it received
+			logStreamEnd("ServeSSE", err)
+			return
+		}
+		if event.Type == "HEARTBEAT" {
+			_, err = io.WriteString(w, ": heartbeat\n\n")
+		} else {
+			var data []byte
+			if data, err = protojson.Marshal(event); err == nil {
+				_, err = fmt.Fprintf(w, "id: %s\ndata: %s\n\n", event.EventId, data)
+			}
+		}
+		if err != nil {
+			log.Printf("ServeSSE: %v\n", err)
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// ServeWebSocket serves GET /api/v1/accounts/{accountID}/ws. Every event, heartbeats included, is sent as a text
+// message. Messages from the client are ignored.
+// When the account service ends the stream, the connection is closed with 1013 (try again later) if the client
+// should reconnect, e.g. during a deploy.
+func (h *WatchHandler) ServeWebSocket(w http.ResponseWriter, r *http.Request) {
+	// the stream outlives the request context once the connection is hijacked, so it is cancelled when the socket closes
+	ctx, cancel := context.WithCancel(context.WithoutCancel(r.Context()))
+	defer cancel()
+
+	stream, err := h.open(ctx, r, r.URL.Query().Get("last_event_id"))
+	if err != nil {
+		log.Printf("ServeWebSocket: %v\n", err)
+		utils.WriteGRPCErrorToHTTP(w, r, err)
+		return
+	}
+
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{OriginPatterns: h.originPatterns})
+	if err != nil {
+		log.Printf("ServeWebSocket: %v\n", err) // Accept already wrote the response
+		return
+	}
+	defer conn.CloseNow()
+	context.AfterFunc(conn.CloseRead(ctx), cancel)
+
+	for {
+		event, err := stream.Recv()
+		if err != nil {
+			logStreamEnd("ServeWebSocket", err)
+			conn.Close(closeStatus(err), "")
+			return
+		}
+		data, err := protojson.Marshal(event)
+		if err != nil {
+			log.Printf("ServeWebSocket: %v\n", err)
+			conn.Close(websocket.StatusInternalError, "")
+			return
+		}
+		writeCtx, cancelWrite := context.WithTimeout(ctx, wsWriteTimeout)
+		err = conn.Write(writeCtx, websocket.MessageText, data)
+		cancelWrite()
+		if err != nil {
+			log.Printf("ServeWebSocket: %v\n", err)
+			return
+		}
+	}
+}
+
+// open starts the WatchAccount stream, and waits until the account service authorized it so that errors such as
+// PERMISSION_DENIED are returned as a regular HTTP error rather than in the middle of the stream.
+func (h *WatchHandler) open(ctx context.Context, r *http.Request, lastEventID string) (grpc.ServerStreamingClient[accountpb.AccountEvent], error) {
+	stream, err := h.client.WatchAccount(ctx, &accountpb.WatchAccountRequest{
+		AccountId:    chi.URLParam(r, "accountID"),
+		AfterEventId: lastEventID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	header, err := stream.Header()
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		// the stream ended without headers, the status tells why
+		if _, err = stream.Recv(); err == nil || errors.Is(err, io.EOF) {
+			err = status.Error(codes.Internal, "stream ended before it started")
+		}
+		return nil, err
+	}
+	return stream, nil
+}
+
+func closeStatus(err error) websocket.StatusCode {
+	switch status.Code(err) {
+	case codes.OK:
+		return websocket.StatusNormalClosure
+	case codes.Unavailable:
+		return websocket.StatusTryAgainLater
+	case codes.Canceled:
+		return websocket.StatusGoingAway
+	default:
+		return websocket.StatusInternalError
+	}
+}
+
+func logStreamEnd(handler string, err error) {
+	if errors.Is(err, io.EOF) || status.Code(err) == codes.Canceled {
+		return
+	}
+	log.Printf("%s: stream ended: %v\n", handler, err)
+}
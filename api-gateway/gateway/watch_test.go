@@ -0,0 +1,304 @@
+package gateway
+
+import (
+	accountpb "account/proto"
+	"api-gateway/client"
+	"api-gateway/middleware"
+	"api-gateway/model"
+	"api-gateway/utils"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	testAccountID   = "0f8fad5b-d9cb-469f-a165-70867728950e"
+	testFingerprint = "fingerprint"
+)
+
+var testJWTKey = []byte("test-key")
+
+// fakeWatch stands for the WatchAccount stream of the account service. The stream sends events, then ends with
+// endErr, or waits until it is cancelled if endErr is nil. A stream with openErr ends before it starts, as when the
+// account service rejects the watch.
+type fakeWatch struct {
+	accountpb.AccountServiceClient
+	openErr error
+	events  []*accountpb.AccountEvent
+	endErr  error
+
+	mu        sync.Mutex
+	reqs      []*accountpb.WatchAccountRequest
+	cancelled chan struct{} // closed once the stream is cancelled
+}
+
+func newFakeWatch(events ...*accountpb.AccountEvent) *fakeWatch {
+	return &fakeWatch{events: events, cancelled: make(chan struct{})}
+}
+
+func (f *fakeWatch) WatchAccount(ctx context.Context, req *accountpb.WatchAccountRequest, _ ...grpc.CallOption) (grpc.ServerStreamingClient[accountpb.AccountEvent], error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.reqs = append(f.reqs, req)
+	return &fakeEventStream{ctx: ctx, watch: f}, nil
+}
+
+func (f *fakeWatch) requests() []*accountpb.WatchAccountRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*accountpb.WatchAccountRequest(nil), f.reqs...)
+}
+
+func (f *fakeWatch) requireCancelled(t *testing.T) {
+	t.Helper()
+	select {
+	case <-f.cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream not cancelled")
+	}
+}
+
+type fakeEventStream struct {
+	grpc.ClientStream
+	ctx   context.Context
+	watch *fakeWatch
+	sent  int
+}
+
+func (s *fakeEventStream) Header() (metadata.MD, error) {
+	if s.watch.openErr != nil {
+		return nil, nil
+	}
+	return metadata.MD{}, nil
+}
+
+func (s *fakeEventStream) Recv() (*accountpb.AccountEvent, error) {
+	if s.watch.openErr != nil {
+		return nil, s.watch.openErr
+	}
+	if s.sent < len(s.watch.events) {
+		s.sent++
+		return s.watch.events[s.sent-1], nil
+	}
+	if s.watch.endErr != nil {
+		return nil, s.watch.endErr
+	}
+	<-s.ctx.Done()
+	close(s.watch.cancelled)
+	return nil, status.FromContextError(s.ctx.Err()).Err()
+}
+
+// newWatchRouter routes the event streams to a WatchHandler of watch, behind the same middlewares as in main.
+func newWatchRouter(watch *fakeWatch) http.Handler {
+	h := NewWatchHandler(&client.AccountClient{AccountServiceClient: watch}, nil)
+	auth := middleware.NewAuthMiddleware(testJWTKey)
+	r := chi.NewRouter()
+	r.With(TokenFromQuery, auth).Get("/api/v1/accounts/{accountID}/events", h.ServeSSE)
+	r.With(TokenFromQuery, auth).Get("/api/v1/accounts/{accountID}/ws", h.ServeWebSocket)
+	return r
+}
+
+// signToken returns an access token of a new user, bound to testFingerprint.
+func signToken(t *testing.T) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &model.JWTClaim{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   uuid.NewString(),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+		FingerprintHash: utils.HashSha256(testFingerprint),
+	}).SignedString(testJWTKey)
+	require.NoError(t, err)
+	return token
+}
+
+// sseRequest returns a request of the event stream authenticated by an access token in the query.
+func sseRequest(t *testing.T, query string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/accounts/"+testAccountID+"/events?access_token="+signToken(t)+query, nil)
+	r.AddCookie(&http.Cookie{Name: model.FingerprintCookieName, Value: testFingerprint})
+	return r
+}
+
+func testEvents() []*accountpb.AccountEvent {
+	return []*accountpb.AccountEvent{
+		{EventId: "0-0", Type: "SNAPSHOT", AccountId: testAccountID, Balance: &accountpb.Money{Currency: "USD", Units: 100}},
+		{Type: "HEARTBEAT", AccountId: testAccountID},
+		{EventId: "7-0", Type: "TRANSACTION", AccountId: testAccountID, Balance: &accountpb.Money{Currency: "USD", Units: 150}},
+	}
+}
+
+func TestServeSSE(t *testing.T) {
+	watch := newFakeWatch(testEvents()...)
+	watch.endErr = status.Error(codes.Unavailable, "server is shutting down")
+	w := httptest.NewRecorder()
+	newWatchRouter(watch).ServeHTTP(w, sseRequest(t, ""))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	require.Equal(t, testAccountID, watch.requests()[0].AccountId)
+	require.Empty(t, watch.requests()[0].AfterEventId)
+	frames := strings.Split(strings.TrimSuffix(w.Body.String(), "\n\n"), "\n\n")
+	require.Len(t, frames, 4)
+	require.Equal(t, "retry: 3000", frames[0])
+	require.True(t, strings.HasPrefix(frames[1], "id: 0-0\ndata: {"), frames[1])
+	require.Contains(t, frames[1], `"type":"SNAPSHOT"`)
+	// heartbeats are comments, which EventSource ignores
+	require.Equal(t, ": heartbeat", frames[2])
+	require.True(t, strings.HasPrefix(frames[3], "id: 7-0\ndata: {"), frames[3])
+}
+
+// A client resumes from the Last-Event-ID header sent by EventSource, or else from the last_event_id query parameter.
+func TestServeSSE_Resume(t *testing.T) {
+	watch := newFakeWatch()
+	watch.endErr = status.Error(codes.Unavailable, "server is shutting down")
+	router := newWatchRouter(watch)
+
+	r := sseRequest(t, "&last_event_id=3-0")
+	r.Header.Set("Last-Event-ID", "5-0")
+	router.ServeHTTP(httptest.NewRecorder(), r)
+	router.ServeHTTP(httptest.NewRecorder(), sseRequest(t, "&last_event_id=3-0"))
+
+	reqs := watch.requests()
+	require.Len(t, reqs, 2)
+	require.Equal(t, "5-0", reqs[0].AfterEventId)
+	require.Equal(t, "3-0", reqs[1].AfterEventId)
+}
+
+func TestServeSSE_Authentication(t *testing.T) {
+	watch := newFakeWatch()
+	router := newWatchRouter(watch)
+
+	tests := []struct {
+		name string
+		r    func() *http.Request
+	}{
+		{"no token", func() *http.Request {
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/accounts/"+testAccountID+"/events", nil)
+			r.AddCookie(&http.Cookie{Name: model.FingerprintCookieName, Value: testFingerprint})
+			return r
+		}},
+		{"invalid token", func() *http.Request {
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/accounts/"+testAccountID+"/events?access_token=forged", nil)
+			r.AddCookie(&http.Cookie{Name: model.FingerprintCookieName, Value: testFingerprint})
+			return r
+		}},
+		// the token of the query is still bound to the fingerprint cookie
+		{"no fingerprint", func() *http.Request {
+			return httptest.NewRequest(http.MethodGet, "/api/v1/accounts/"+testAccountID+"/events?access_token="+signToken(t), nil)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, tt.r())
+			require.Equal(t, http.StatusUnauthorized, w.Code)
+		})
+	}
+	require.Empty(t, watch.requests())
+}
+
+// The rejections of the account service, such as the watch of the account of another user, are regular HTTP errors.
+func TestServeSSE_Rejected(t *testing.T) {
+	watch := newFakeWatch()
+	watch.openErr = status.Error(codes.PermissionDenied, "not authorized")
+	w := httptest.NewRecorder()
+	newWatchRouter(watch).ServeHTTP(w, sseRequest(t, ""))
+
+	require.Equal(t, http.StatusForbidden, w.Code)
+	require.Equal(t, utils.ProblemContentType, w.Header().Get("Content-Type"))
+}
+
+// The stream to the account service is cancelled when the client goes away.
+func TestServeSSE_Teardown(t *testing.T) {
+	watch := newFakeWatch(testEvents()...)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		newWatchRouter(watch).ServeHTTP(httptest.NewRecorder(), sseRequest(t, "").WithContext(ctx))
+		close(done)
+	}()
+
+	cancel()
+	watch.requireCancelled(t)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler didn't return")
+	}
+}
+
+// dialWebSocket opens the WebSocket of the account through server, authenticated by an access token in the query.
+func dialWebSocket(t *testing.T, server *httptest.Server, query string) (*websocket.Conn, *http.Response, error) {
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v1/accounts/" + testAccountID + "/ws?access_token=" + signToken(t) + query
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return websocket.Dial(ctx, url, &websocket.DialOptions{
+		HTTPHeader: http.Header{"Cookie": {model.FingerprintCookieName + "=" + testFingerprint}},
+	})
+}
+
+func TestServeWebSocket(t *testing.T) {
+	watch := newFakeWatch(testEvents()...)
+	watch.endErr = status.Error(codes.Unavailable, "server is shutting down")
+	server := httptest.NewServer(newWatchRouter(watch))
+	defer server.Close()
+
+	conn, _, err := dialWebSocket(t, server, "&last_event_id=5-0")
+	require.NoError(t, err)
+	defer conn.CloseNow()
+	require.Equal(t, "5-0", watch.requests()[0].AfterEventId)
+
+	// every event is a text message, heartbeats included
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, want := range []string{`"type":"SNAPSHOT"`, `"type":"HEARTBEAT"`, `"eventId":"7-0"`} {
+		typ, data, err := conn.Read(ctx)
+		require.NoError(t, err)
+		require.Equal(t, websocket.MessageText, typ)
+		require.Contains(t, string(data), want)
+	}
+
+	// the end of the stream during a deploy tells the client to reconnect
+	_, _, err = conn.Read(ctx)
+	require.Equal(t, websocket.StatusTryAgainLater, websocket.CloseStatus(err))
+}
+
+func TestServeWebSocket_Rejected(t *testing.T) {
+	watch := newFakeWatch()
+	watch.openErr = status.Error(codes.PermissionDenied, "not authorized")
+	server := httptest.NewServer(newWatchRouter(watch))
+	defer server.Close()
+
+	_, resp, err := dialWebSocket(t, server, "")
+	require.Error(t, err)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(body), "not authorized")
+}
+
+// The stream to the account service is cancelled when the client closes the WebSocket.
+func TestServeWebSocket_Teardown(t *testing.T) {
+	watch := newFakeWatch()
+	server := httptest.NewServer(newWatchRouter(watch))
+	defer server.Close()
+
+	conn, _, err := dialWebSocket(t, server, "")
+	require.NoError(t, err)
+	require.NoError(t, conn.Close(websocket.StatusNormalClosure, ""))
+	watch.requireCancelled(t)
+}
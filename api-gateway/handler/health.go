@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const downstreamHealthTimeout = 2 * time.Second
+
+// Downstream is a gRPC service whose grpc.health.v1 status is aggregated by /readyz.
+type Downstream interface {
+	healthpb.HealthClient
+	ServiceName() string
+	BreakerState() string
+}
+
+type HealthResponse struct {
+	Status   string            `json:"status"`
+	Services map[string]string `json:"services,omitempty"`
+	Breakers map[string]string `json:"breakers,omitempty"` // circuit breaker state of each downstream service
+}
+
+type HealthHandler struct {
+	downstreams  map[string]Downstream
+	shuttingDown atomic.Bool
+}
+
+// NewHealthHandler creates a handler that reports the health of the gateway and of the given downstream services keyed by a display name.
+func NewHealthHandler(downstreams map[string]Downstream) *HealthHandler {
+	return &HealthHandler{downstreams: downstreams}
+}
+
+// SetShuttingDown makes /readyz fail so that the load balancer stops routing new requests to us while we drain.
+func (h *HealthHandler) SetShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+// HealthzHandler is the liveness probe: the gateway process is up and able to serve HTTP.
+func (h *HealthHandler) HealthzHandler(w http.ResponseWriter, r *http.Request) {
+	writeHealthResponse(w, http.StatusOK, &HealthResponse{Status: "ok"})
+}
+
+// ReadyzHandler is the readiness probe: the gateway is not shutting down and all downstream services report SERVING.
+// Circuit breaker states are reported but don't affect readiness: an open breaker means the downstream service is
+// struggling, which the health checks already capture, and taking every gateway instance out of rotation because of
+// it would only turn fast 503s into connection errors.
+func (h *HealthHandler) ReadyzHandler(w http.ResponseWriter, r *http.Request) {
+	if h.shuttingDown.Load() {
+		writeHealthResponse(w, http.StatusServiceUnavailable, &HealthResponse{Status: "shutting down"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), downstreamHealthTimeout)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		ready    = true
+		services = make(map[string]string, len(h.downstreams))
+		breakers = make(map[string]string, len(h.downstreams))
+	)
+	for name, downstream := range h.downstreams {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			status := checkDownstream(ctx, downstream)
+			mu.Lock()
+			defer mu.Unlock()
+			services[name] = status
+			breakers[name] = downstream.BreakerState()
+			if status != healthpb.HealthCheckResponse_SERVING.String() {
+				ready = false
+			}
+		}()
+	}
+	wg.Wait()
+
+	res := &HealthResponse{Status: "ok", Services: services, Breakers: breakers}
+	httpStatus := http.StatusOK
+	if !ready {
+		res.Status = "unavailable"
+		httpStatus = http.StatusServiceUnavailable
+	}
+	writeHealthResponse(w, httpStatus, res)
+}
+
+func checkDownstream(ctx context.Context, downstream Downstream) string {
+	res, err := downstream.Check(ctx, &healthpb.HealthCheckRequest{Service: downstream.ServiceName()})
+	if err != nil {
+		log.Printf("ReadyzHandler: health check of %s failed: %v", downstream.ServiceName(), err)
+		return "UNREACHABLE"
+	}
+	return res.Status.String()
+}
+
+func writeHealthResponse(w http.ResponseWriter, httpStatus int, res *HealthResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(httpStatus)
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		log.Printf("writeHealthResponse: couldn't encode response: %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// fakeDownstream reports status, or fails the health check with err.
+type fakeDownstream struct {
+	healthpb.HealthClient
+	name    string
+	status  healthpb.HealthCheckResponse_ServingStatus
+	err     error
+	breaker string
+}
+
+func (d *fakeDownstream) Check(_ context.Context, req *healthpb.HealthCheckRequest, _ ...grpc.CallOption) (*healthpb.HealthCheckResponse, error) {
+	if req.Service != d.name {
+		return nil, status.Error(codes.NotFound, "unknown service")
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	return &healthpb.HealthCheckResponse{Status: d.status}, nil
+}
+
+func (d *fakeDownstream) ServiceName() string  { return d.name }
+func (d *fakeDownstream) BreakerState() string { return d.breaker }
+
+func readyz(t *testing.T, h *HealthHandler) (int, HealthResponse) {
+	w := httptest.NewRecorder()
+	h.ReadyzHandler(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	var res HealthResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	return w.Code, res
+}
+
+func TestReadyzHandler(t *testing.T) {
+	auth := &fakeDownstream{name: "proto.AuthService", status: healthpb.HealthCheckResponse_SERVING, breaker: "closed"}
+	account := &fakeDownstream{name: "proto.AccountService", status: healthpb.HealthCheckResponse_SERVING, breaker: "closed"}
+	h := NewHealthHandler(map[string]Downstream{"auth": auth, "account": account})
+
+	code, res := readyz(t, h)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "ok", res.Status)
+	require.Equal(t, map[string]string{"auth": "SERVING", "account": "SERVING"}, res.Services)
+	require.Equal(t, map[string]string{"auth": "closed", "account": "closed"}, res.Breakers)
+
+	// a downstream service whose dependency is down makes the gateway unready
+	account.status = healthpb.HealthCheckResponse_NOT_SERVING
+	code, res = readyz(t, h)
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Equal(t, "unavailable", res.Status)
+	require.Equal(t, map[string]string{"auth": "SERVING", "account": "NOT_SERVING"}, res.Services)
+
+	// so does one that can't be reached
+	account.status = healthpb.HealthCheckResponse_SERVING
+	auth.err = status.Error(codes.Unavailable, "connection refused")
+	code, res = readyz(t, h)
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Equal(t, "UNREACHABLE", res.Services["auth"])
+
+	// an open breaker is reported without affecting readiness
+	auth.err = nil
+	account.breaker = "open"
+	code, res = readyz(t, h)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "open", res.Breakers["account"])
+
+	h.SetShuttingDown()
+	code, res = readyz(t, h)
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Equal(t, "shutting down", res.Status)
+	require.Empty(t, res.Services)
+}
+
+func TestHealthzHandler(t *testing.T) {
+	h := NewHealthHandler(nil)
+	h.SetShuttingDown()
+	w := httptest.NewRecorder()
+	h.HealthzHandler(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	// the process is still alive while it drains
+	require.Equal(t, http.StatusOK, w.Code)
+}
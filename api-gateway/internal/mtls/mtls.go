@@ -0,0 +1,77 @@
+// Package mtls builds the mutual TLS credentials used to call the internal services.
+//
+// Every service certificate is issued by the development CA (see tools/devca) and carries its identity as a
+// URI SAN of the form spiffe://banking-app/<identity>, e.g. spiffe://banking-app/api-gateway.
+package mtls
+
+import (
+	"api-gateway/config"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"google.golang.org/grpc/credentials"
+)
+
+const TrustDomain = "banking-app"
+
+// Identities of the services, as encoded in their certificates.
+const (
+	APIGateway      = "api-gateway"
+	AuthService     = "auth-service"
+	AccountService  = "account-service"
+	TransferService = "transfer-service"
+)
+
+// ClientCredentials presents our certificate to the server and checks that the server is the expected service,
+// on top of the usual hostname verification.
+func ClientCredentials(cfg config.TLSConfig, server string) (credentials.TransportCredentials, error) {
+	cert, pool, err := load(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		MinVersion:   tls.VersionTLS13,
+		VerifyConnection: func(state tls.ConnectionState) error {
+			if len(state.PeerCertificates) == 0 {
+				return errors.New("mtls: server presented no certificate")
+			}
+			if id, _ := identityFromCert(state.PeerCertificates[0]); id != server {
+				return fmt.Errorf("mtls: expected server %q, got %q", server, id)
+			}
+			return nil
+		},
+	}), nil
+}
+
+func load(cfg config.TLSConfig) (tls.Certificate, *x509.CertPool, error) {
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return tls.Certificate{}, nil, fmt.Errorf("mtls: failed to load key pair: %w", err)
+	}
+	caPEM, err := os.ReadFile(cfg.CAFile)
+	if err != nil {
+		return tls.Certificate{}, nil, fmt.Errorf("mtls: failed to read CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return tls.Certificate{}, nil, fmt.Errorf("mtls: no certificate found in %s", cfg.CAFile)
+	}
+	return cert, pool, nil
+}
+
+func identityFromCert(cert *x509.Certificate) (string, bool) {
+	for _, uri := range cert.URIs {
+		if uri.Scheme == "spiffe" && uri.Host == TrustDomain {
+			if id := strings.TrimPrefix(uri.Path, "/"); id != "" {
+				return id, true
+			}
+		}
+	}
+	return "", false
+}
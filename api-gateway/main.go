@@ -2,81 +2,156 @@ package main
 
 import (
 	"api-gateway/client"
+	"api-gateway/config"
+	"api-gateway/gateway"
+	"api-gateway/openapi"
+	"api-gateway/utils"
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
-	"os"
-	"strconv"
+	"os/signal"
+	"syscall"
 
 	"api-gateway/handler" // my HTTP handlers
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware" // Chi's built-in middleware
 	"github.com/go-chi/cors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	myMiddleware "api-gateway/middleware" // my custom AuthMiddleware
 )
 
 func main() {
-	// TODO: initialize gRPC clients to microservices
-	// authConn, err := grpc.Dial("auth-service-address:port", grpc.WithInsecure()) // Use secure credentials in production
-	authClient := client.NewAuthClient(os.Getenv("AUTH_SERVICE_URL"))
-	authHandler := handler.NewAuthHandler(authClient)
-	accountClient := client.NewAccountClient(os.Getenv("ACCOUNT_SERVICE_URL"))
-	accountHandler := handler.NewAccountHandler(accountClient)
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	if !cfg.TLS.Enabled() {
+		log.Println("WARNING: TLS is disabled, downstream services can't authenticate the gateway")
+	}
+	authClient := client.NewAuthClient(cfg.Services.AuthURL, cfg.Clients, cfg.TLS)
+	accountClient := client.NewAccountClient(cfg.Services.AccountURL, cfg.Clients, cfg.TLS)
+	restHandler, err := gateway.NewHandler(context.Background(), authClient, accountClient, cfg.JWT.AccessTokenDuration)
+	if err != nil {
+		log.Fatalf("Failed to create the REST API handler: %v", err)
+	}
+	watchHandler := gateway.NewWatchHandler(accountClient, cfg.CORS.AllowedOrigins)
+	healthHandler := handler.NewHealthHandler(map[string]handler.Downstream{
+		"auth":    authClient,
+		"account": accountClient,
+	})
 
 	r := chi.NewRouter()
 
 	// --- Setup CORS for frontend access ---
 	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"http://localhost:3000"}, // frontend origin
+		AllowedOrigins:   cfg.CORS.AllowedOrigins,
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Content-Type", "Authorization", "Accept", "Idempotency-Key"},
-		ExposedHeaders:   []string{"Content-Length"},
+		ExposedHeaders:   []string{"Content-Length", "Idempotency-Key", "Idempotent-Replayed"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
 
+	// unknown routes get the same problem+json body as every other error
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		utils.WriteError(w, r, http.StatusNotFound, "Not found")
+	})
+	r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		utils.WriteError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+	})
+
+	// --- Liveness and readiness probes (outside /api so they bypass the JWT middleware) ---
+	r.Get("/healthz", healthHandler.HealthzHandler)
+	r.Get("/readyz", healthHandler.ReadyzHandler)
+
+	// OpenAPI document of /api/v1, outside of the /api sub-router since its URLFormat middleware strips the .json extension
+	r.Get("/api/openapi.json", openapi.Handler().ServeHTTP)
+
 	r.Route("/api", func(r chi.Router) {
 		// --- Global Middleware (applies to all routes) ---
 		r.Use(middleware.RequestID)
 		r.Use(middleware.Logger)
 		r.Use(middleware.Recoverer) // Catches panics and returns 500
 		r.Use(middleware.URLFormat)
+		r.Use(myMiddleware.Idempotency) // every mutating route takes an Idempotency-Key
+
+		authMiddleware := myMiddleware.NewAuthMiddleware(cfg.JWT.Key())
 
-		// --- Public Endpoints (do NOT require JWT validation) ---
-		r.Post("/login", authHandler.LoginHandler)
-		r.Post("/register", authHandler.CreateUserHandler)
-
-		// --- Protected Endpoints (require JWT validation) ---
-		// Create a sub-router or group where the AuthMiddleware will be applied.
-		r.Group(func(r chi.Router) {
-			r.Use(myMiddleware.AuthMiddleware) // JWT valdiation happens in this middleware
-
-			// TODO: add more routes to microservices
-			// user management
-			r.Get("/profile", authHandler.GetUserProfileHandler)
-			// r.Delete("/delete-user", authHandler.DeleteUserHandler)
-			r.Post("/renew-token", authHandler.RenewAccessTokenHandler)
-			// account management
-			r.Get("/all-accounts", accountHandler.GetAccountsByUserIDHandler)
-			r.Get("/account", accountHandler.GetAccountHandler)
-			r.Post("/create-account", accountHandler.CreateAccountHandler)
-			r.Post("/delete-account", accountHandler.DeleteAccountByAccountNumberHandler)
-
-			// transaction management
-			r.Post("/create-transaction", accountHandler.CreateTransactionHandler)
-			r.Get("/transactions", accountHandler.GetTransactionsByAccountIDHandler)
+		// --- REST API generated from the google.api.http annotations of the protos ---
+		r.Route("/v1", func(r chi.Router) {
+			for _, route := range gateway.PublicRoutes {
+				r.Method(route.Method, route.Path, restHandler)
+			}
+			// event streams of an account, the access token may be sent in the query since browsers can't set headers on them
+			r.With(gateway.TokenFromQuery, authMiddleware).Get("/accounts/{accountID}/events", watchHandler.ServeSSE)
+			r.With(gateway.TokenFromQuery, authMiddleware).Get("/accounts/{accountID}/ws", watchHandler.ServeWebSocket)
+			r.With(authMiddleware).Handle("/*", restHandler)
 		})
 	})
 
+	// internal-only admin server exposing the redacted effective config and the Prometheus metrics
+	adminServer := newAdminServer(cfg)
+	if adminServer != nil {
+		go func() {
+			if err := adminServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Printf("Admin server failed: %v", err)
+			}
+		}()
+	}
+
 	// Start the HTTP server
-	_port := os.Getenv("HTTP_PORT")
-	var port int
-	var err error
-	if port, err = strconv.Atoi(_port); err != nil {
-		port = 3000
+	server := &http.Server{
+		Addr:    fmt.Sprintf(":%d", cfg.HTTPPort),
+		Handler: r, // Use r (the Chi router) as the handler
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Printf("API Gateway listening on port %d", cfg.HTTPPort)
+		serveErr <- server.ListenAndServe()
+	}()
+
+	// drain in-flight HTTP requests on SIGINT/SIGTERM so rolling deploys don't drop money movements
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	select {
+	case err := <-serveErr:
+		log.Fatal(err)
+	case <-ctx.Done():
+	}
+
+	log.Println("Shutting down: draining in-flight requests")
+	healthHandler.SetShuttingDown()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("Graceful shutdown failed: %v", err)
+	}
+	if adminServer != nil {
+		adminServer.Close()
+	}
+	authClient.Close()
+	accountClient.Close()
+	log.Println("API Gateway stopped")
+}
+
+// newAdminServer returns the internal admin HTTP server, or nil if it is disabled.
+// It listens on a separate port so that it is never exposed through the public router.
+func newAdminServer(cfg *config.Config) *http.Server {
+	if cfg.AdminPort == 0 {
+		return nil
+	}
+	mux := http.NewServeMux()
+	mux.Handle("GET /debug/config", cfg.DumpHandler())
+	mux.Handle("GET /metrics", promhttp.Handler())
+	return &http.Server{
+		Addr:    fmt.Sprintf(":%d", cfg.AdminPort),
+		Handler: mux,
 	}
-	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", port), r)) // Use r (the Chi router) as the handler
-	log.Printf("API Gateway listening on port %s", port)
 }
@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"api-gateway/utils"
+	"context"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// IdempotencyKeyHeader is the request header carrying the idempotency key of a mutating request, echoed in the response.
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set to "true" on the responses replayed from the idempotency key of an earlier request.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// IdempotencyContextKey is the key of the idempotency key of the request and of its replayed status.
+const IdempotencyContextKey contextKey = "idempotency"
+
+type idempotency struct {
+	key      string
+	replayed atomic.Bool
+}
+
+// Idempotency requires a UUID Idempotency-Key header on every mutating request (POST, PUT, PATCH and DELETE), following
+// the IETF Idempotency-Key HTTP header draft: a request without one is rejected with 400, so that two different
+// requests never share the empty key. The key is echoed in the response, along with Idempotent-Replayed: true when the
+// microservice replayed the response of an earlier request with the same key instead of executing it again.
+// Safe methods go through untouched.
+func Idempotency(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if key == "" {
+			utils.WriteError(w, r, http.StatusBadRequest, "Idempotency-Key header is required")
+			return
+		}
+		// only the canonical form, the one accepted by the microservices
+		if id, err := uuid.Parse(key); err != nil || id.String() != strings.ToLower(key) {
+			utils.WriteError(w, r, http.StatusBadRequest, "Idempotency-Key header must be a UUID")
+			return
+		}
+
+		state := &idempotency{key: key}
+		w.Header().Set(IdempotencyKeyHeader, key)
+		ctx := context.WithValue(r.Context(), IdempotencyContextKey, state)
+		next.ServeHTTP(&replayWriter{ResponseWriter: w, state: state}, r.WithContext(ctx))
+	})
+}
+
+// IdempotencyKey returns the validated idempotency key of the request, if it went through the Idempotency middleware.
+func IdempotencyKey(ctx context.Context) (string, bool) {
+	state, ok := ctx.Value(IdempotencyContextKey).(*idempotency)
+	if !ok {
+		return "", false
+	}
+	return state.key, true
+}
+
+// MarkReplayed records that the response of the request was replayed, so that the Idempotency middleware sets the
+// Idempotent-Replayed header. It is called by the gRPC clients when the microservice says so.
+func MarkReplayed(ctx context.Context) {
+	if state, ok := ctx.Value(IdempotencyContextKey).(*idempotency); ok {
+		state.replayed.Store(true)
+	}
+}
+
+// replayWriter sets the Idempotent-Replayed header right before the response headers are written, since the handler
+// only learns the replayed status once the RPC returned.
+type replayWriter struct {
+	http.ResponseWriter
+	state       *idempotency
+	wroteHeader bool
+}
+
+func (w *replayWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if w.state.replayed.Load() {
+			w.Header().Set(IdempotentReplayedHeader, "true")
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *replayWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *replayWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotency(t *testing.T) {
+	const key = "0f8fad5b-d9cb-469f-a165-70867728950e"
+	tests := []struct {
+		name   string
+		method string
+		key    string
+		status int
+	}{
+		{"safe method without key", http.MethodGet, "", http.StatusOK},
+		{"safe method with invalid key", http.MethodGet, "not-a-uuid", http.StatusOK},
+		{"post", http.MethodPost, key, http.StatusOK},
+		{"put", http.MethodPut, key, http.StatusOK},
+		{"patch", http.MethodPatch, key, http.StatusOK},
+		{"delete", http.MethodDelete, key, http.StatusOK},
+		{"upper case", http.MethodPost, "0F8FAD5B-D9CB-469F-A165-70867728950E", http.StatusOK},
+		{"missing key", http.MethodPost, "", http.StatusBadRequest},
+		{"not a UUID", http.MethodPost, "not-a-uuid", http.StatusBadRequest},
+		{"no hyphens", http.MethodPost, "0f8fad5bd9cb469fa16570867728950e", http.StatusBadRequest},
+		{"braces", http.MethodPost, "{" + key + "}", http.StatusBadRequest},
+		{"URN", http.MethodPost, "urn:uuid:" + key, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotKey string
+			var gotOK bool
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotKey, gotOK = IdempotencyKey(r.Context())
+				w.WriteHeader(http.StatusOK)
+			})
+			r := httptest.NewRequest(tt.method, "/api/v1/accounts", nil)
+			if tt.key != "" {
+				r.Header.Set(IdempotencyKeyHeader, tt.key)
+			}
+			w := httptest.NewRecorder()
+			Idempotency(next).ServeHTTP(w, r)
+
+			require.Equal(t, tt.status, w.Code)
+			if tt.status != http.StatusOK || tt.method == http.MethodGet {
+				require.False(t, gotOK)
+				require.Empty(t, w.Header().Get(IdempotencyKeyHeader))
+				return
+			}
+			require.True(t, gotOK)
+			require.Equal(t, tt.key, gotKey)
+			require.Equal(t, tt.key, w.Header().Get(IdempotencyKeyHeader))
+			require.Empty(t, w.Header().Get(IdempotentReplayedHeader))
+		})
+	}
+}
+
+func TestIdempotency_Replayed(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(w http.ResponseWriter)
+	}{
+		{"WriteHeader", func(w http.ResponseWriter) { w.WriteHeader(http.StatusCreated) }},
+		{"Write", func(w http.ResponseWriter) { _, _ = w.Write([]byte("{}")) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				MarkReplayed(r.Context())
+				tt.write(w)
+			})
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/accounts", nil)
+			r.Header.Set(IdempotencyKeyHeader, "0f8fad5b-d9cb-469f-a165-70867728950e")
+			w := httptest.NewRecorder()
+			Idempotency(next).ServeHTTP(w, r)
+			require.Equal(t, "true", w.Header().Get(IdempotentReplayedHeader))
+		})
+	}
+
+	// marking a request outside of the middleware does nothing
+	MarkReplayed(httptest.NewRequest(http.MethodPost, "/", nil).Context())
+}
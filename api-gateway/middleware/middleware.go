@@ -15,44 +15,53 @@ type contextKey string
 const (
 	// UserIDContextKey is the key for the **authenticated** user ID to pass down to handlers
 	UserIDContextKey contextKey = "requestingUserID"
+	// AccessTokenContextKey is the key for the validated JWT, forwarded to the microservices so they can verify the user themselves
+	AccessTokenContextKey contextKey = "accessToken"
 )
 
-// AuthMiddleware validates the JWT token from the Authorization header.
+// NewAuthMiddleware returns a middleware that validates the JWT token from the Authorization header
+// against jwtSecretKey, the decoded key from config.JWTConfig.Key().
 // If valid, it extracts claims and adds them to the request context.
 // If invalid or missing, it writes an HTTP error response.
-func AuthMiddleware(next http.Handler) http.Handler {
+func NewAuthMiddleware(jwtSecretKey []byte) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return authMiddleware(next, jwtSecretKey)
+	}
+}
+
+func authMiddleware(next http.Handler, jwtSecretKey []byte) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// 1. Extract JWT and fingerprint from Authorization header
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
-			http.Error(w, "Authorization token is not provided", http.StatusUnauthorized)
+			utils.WriteError(w, r, http.StatusUnauthorized, "Authorization token is not provided")
 			return
 		}
 
 		if !strings.HasPrefix(authHeader, "Bearer ") {
-			http.Error(w, "Invalid authorization format. Must be 'Bearer <token>'", http.StatusUnauthorized)
+			utils.WriteError(w, r, http.StatusUnauthorized, "Invalid authorization format. Must be 'Bearer <token>'")
 			return
 		}
 		jwtToken := strings.TrimPrefix(authHeader, "Bearer ")
 
 		fingerprintCookie, err := r.Cookie(model.FingerprintCookieName)
 		if err != nil {
-			http.Error(w, "Invalid fingerprint cookie", http.StatusUnauthorized)
+			utils.WriteError(w, r, http.StatusUnauthorized, "Invalid fingerprint cookie")
 			return
 		}
 		fingerprint := fingerprintCookie.Value
 
 		// 2. Validate the JWT
-		claims, err := utils.ValidateJWT(jwtToken, fingerprint) // Your utility function to validate JWT
+		claims, err := utils.ValidateJWT(jwtToken, fingerprint, jwtSecretKey) // Your utility function to validate JWT
 		if err != nil {
 			// Log the error for debugging on the server side
 			fmt.Printf("JWT validation failed for request to %s: %v\n", r.URL.Path, err)
-			http.Error(w, "Invalid or expired JWT token", http.StatusUnauthorized)
+			utils.WriteError(w, r, http.StatusUnauthorized, "Invalid or expired JWT token")
 			return
 		}
 
 		if claims.Subject == "" {
-			http.Error(w, "Invalid JWT subject", http.StatusUnauthorized)
+			utils.WriteError(w, r, http.StatusUnauthorized, "Invalid JWT subject")
 			return
 		}
 
@@ -60,8 +69,15 @@ func AuthMiddleware(next http.Handler) http.Handler {
 		// This makes the userID (and other claims) available to downstream handlers.
 		ctx := r.Context()
 		ctx = context.WithValue(ctx, UserIDContextKey, claims.Subject) // Using Subject for user ID
+		ctx = context.WithValue(ctx, AccessTokenContextKey, jwtToken)
 
 		// 4. Call the next handler in the chain with the updated context
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// AccessToken returns the validated JWT of the request, if the request went through the auth middleware.
+func AccessToken(ctx context.Context) (string, bool) {
+	token, ok := ctx.Value(AccessTokenContextKey).(string)
+	return token, ok && token != ""
+}
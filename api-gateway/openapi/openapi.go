@@ -0,0 +1,35 @@
+// Package openapi serves the OpenAPI v3 document of the REST API.
+//
+// openapi.yaml is generated by protoc-gen-openapi from the google.api.http annotations of the microservice protos,
+// run `make openapi` after changing them.
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed openapi.yaml
+var document []byte
+
+// Handler serves the OpenAPI document as JSON, the form expected by most client generators.
+func Handler() http.Handler {
+	var doc map[string]any
+	if err := yaml.Unmarshal(document, &doc); err != nil {
+		panic(err) // the embedded document is generated
+	}
+	out, err := json.Marshal(doc)
+	if err != nil {
+		panic(err)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write(out); err != nil {
+			log.Printf("openapi.Handler: %v\n", err)
+		}
+	})
+}
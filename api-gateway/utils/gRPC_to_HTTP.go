@@ -2,52 +2,210 @@ package utils
 
 import (
 	"encoding/json"
+	"log"
 	"net/http"
 
+	"github.com/go-chi/chi/v5/middleware"
+	"google.golang.org/genproto/googleapis/rpc/code"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
 )
 
-type ErrorResponse struct {
-	Error string `json:"error"`
+// ProblemContentType is the media type of every error response of the gateway.
+const ProblemContentType = "application/problem+json"
+
+// Problem is the body of every error response of the gateway, following RFC 9457 (problem details for HTTP APIs).
+// type, title, status, detail and instance are the members defined by the RFC, the others are extensions.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+
+	// Code is the canonical gRPC status code name, e.g. "FAILED_PRECONDITION", also set for errors raised by the gateway itself.
+	Code string `json:"code"`
+	// Message repeats Detail so that clients of the previous {"error": ...} body only need to look up a new key.
+	Message string `json:"message"`
+	// Details are the google.rpc error details attached by the microservice, e.g. google.rpc.ErrorInfo, in their
+	// protobuf JSON form: {"@type": "type.googleapis.com/google.rpc.ErrorInfo", "reason": "INSUFFICIENT_FUNDS", ...}.
+	Details []json.RawMessage `json:"details,omitempty"`
+	// Violations are the field violations of a google.rpc.BadRequest detail, flattened for convenience.
+	Violations []FieldViolation `json:"violations,omitempty"`
+	RequestID  string           `json:"request_id,omitempty"`
+}
+
+// FieldViolation is one broken validation rule of the request, e.g. {"field": "amount", "reason": "INT64_GT"}.
+type FieldViolation struct {
+	Field       string `json:"field"`
+	Reason      string `json:"reason,omitempty"`
+	Description string `json:"description"`
 }
 
-// WriteGRPCErrorToHTTP converts a gRPC error to an appropriate HTTP response
-// and writes it to the http.ResponseWriter using the predefined error messages
-func WriteGRPCErrorToHTTP(w http.ResponseWriter, err error) {
+// WriteError writes an error raised by the gateway itself, e.g. a malformed request body, as a problem+json response.
+func WriteError(w http.ResponseWriter, r *http.Request, httpStatus int, message string) {
+	writeProblem(w, r, &Problem{
+		Status:  httpStatus,
+		Code:    codeName(codeForHTTPStatus(httpStatus)),
+		Message: message,
+	})
+}
+
+// WriteGRPCErrorToHTTP converts an error returned by a microservice to a problem+json response.
+// Every gRPC code is mapped to its HTTP equivalent. The message of server-side failures is replaced by a generic one
+// so that internals don't leak to the client.
+func WriteGRPCErrorToHTTP(w http.ResponseWriter, r *http.Request, err error) {
 	st, ok := status.FromError(err)
 	if !ok {
 		// If it's not a gRPC status error, return a generic server error
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(ErrorResponse{Error: "Internal server error"})
+		WriteError(w, r, http.StatusInternalServerError, "Internal server error")
 		return
 	}
 
-	var httpStatus int
-	errorMessage := st.Message()
+	httpStatus := HTTPStatusFromCode(st.Code())
+	message := st.Message()
 
 	switch st.Code() {
 	case codes.InvalidArgument:
-		httpStatus = http.StatusBadRequest
 		// Handle specific invalid JWT errors
-		if errorMessage == "invalid JWT" {
+		if message == "invalid JWT" {
 			httpStatus = http.StatusUnauthorized
 		}
-	case codes.AlreadyExists:
-		httpStatus = http.StatusConflict
+	case codes.Unavailable:
+		// the downstream service is down or its circuit breaker is open
+		message = "Service temporarily unavailable"
+	case codes.DeadlineExceeded:
+		message = "Request timed out"
+	case codes.Canceled:
+		message = "Request canceled"
+	case codes.Unknown, codes.Internal, codes.DataLoss:
+		message = "Internal server error"
+	}
 
+	problem := &Problem{
+		Status:  httpStatus,
+		Code:    codeName(st.Code()),
+		Message: message,
+	}
+	if httpStatus < http.StatusInternalServerError {
+		problem.Details, problem.Violations = details(st)
+	}
+	writeProblem(w, r, problem)
+}
+
+// HTTPStatusFromCode maps a gRPC code to the HTTP status of the response, following the mapping of google.rpc.Code.
+// FAILED_PRECONDITION is the exception: 422 tells the client the request was understood but can't be applied to the
+// current state of the resource, e.g. a debit larger than the balance, which a plain 400 wouldn't.
+func HTTPStatusFromCode(c codes.Code) int {
+	switch c {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499 // client closed request
+	case codes.InvalidArgument, codes.OutOfRange:
+		return http.StatusBadRequest
 	case codes.Unauthenticated:
-		httpStatus = http.StatusUnauthorized
-		// Both not authorized and not authenticated map to 401
-	case codes.Internal:
-		httpStatus = http.StatusInternalServerError
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.FailedPrecondition:
+		return http.StatusUnprocessableEntity
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	default: // Unknown, Internal, DataLoss
+		return http.StatusInternalServerError
+	}
+}
+
+// codeForHTTPStatus is the inverse of HTTPStatusFromCode, used to give the errors of the gateway itself a code too.
+func codeForHTTPStatus(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnsupportedMediaType:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusUnprocessableEntity:
+		return codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
 	default:
-		// For any other gRPC code, return internal server error
-		httpStatus = http.StatusInternalServerError
-		errorMessage = "Internal server error"
+		return codes.Internal
 	}
+}
+
+// codeName formats a gRPC code the way google.rpc.Code names it, e.g. "FAILED_PRECONDITION" for codes.FailedPrecondition.
+func codeName(c codes.Code) string {
+	return code.Code(c).String()
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(httpStatus)
-	json.NewEncoder(w).Encode(ErrorResponse{Error: errorMessage})
+// details renders the error details of a status in their protobuf JSON form, and extracts its field violations.
+func details(st *status.Status) ([]json.RawMessage, []FieldViolation) {
+	var (
+		rendered   []json.RawMessage
+		violations []FieldViolation
+	)
+	for _, detail := range st.Proto().GetDetails() {
+		out, err := protojson.Marshal(detail)
+		if err != nil {
+			log.Printf("WriteGRPCErrorToHTTP: couldn't render %s: %v", detail.GetTypeUrl(), err)
+			continue
+		}
+		rendered = append(rendered, out)
+	}
+	for _, detail := range st.Details() {
+		badRequest, ok := detail.(*errdetails.BadRequest)
+		if !ok {
+			continue
+		}
+		for _, v := range badRequest.GetFieldViolations() {
+			violations = append(violations, FieldViolation{
+				Field:       v.GetField(),
+				Reason:      v.GetReason(),
+				Description: v.GetDescription(),
+			})
+		}
+	}
+	return rendered, violations
+}
+
+func writeProblem(w http.ResponseWriter, r *http.Request, problem *Problem) {
+	problem.Type = "about:blank"
+	problem.Title = http.StatusText(problem.Status)
+	if problem.Title == "" {
+		problem.Title = problem.Code // 499 has no standard status text
+	}
+	problem.Detail = problem.Message
+	problem.Instance = r.URL.Path
+	problem.RequestID = middleware.GetReqID(r.Context())
+
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(problem.Status)
+	if err := json.NewEncoder(w).Encode(problem); err != nil {
+		log.Printf("writeProblem: %v", err)
+	}
 }
@@ -0,0 +1,112 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestHTTPStatusFromCode(t *testing.T) {
+	tests := []struct {
+		code codes.Code
+		want int
+	}{
+		{codes.OK, http.StatusOK},
+		{codes.Canceled, 499},
+		{codes.Unknown, http.StatusInternalServerError},
+		{codes.InvalidArgument, http.StatusBadRequest},
+		{codes.DeadlineExceeded, http.StatusGatewayTimeout},
+		{codes.NotFound, http.StatusNotFound},
+		{codes.AlreadyExists, http.StatusConflict},
+		{codes.PermissionDenied, http.StatusForbidden},
+		{codes.ResourceExhausted, http.StatusTooManyRequests},
+		{codes.FailedPrecondition, http.StatusUnprocessableEntity},
+		{codes.Aborted, http.StatusConflict},
+		{codes.OutOfRange, http.StatusBadRequest},
+		{codes.Unimplemented, http.StatusNotImplemented},
+		{codes.Internal, http.StatusInternalServerError},
+		{codes.Unavailable, http.StatusServiceUnavailable},
+		{codes.DataLoss, http.StatusInternalServerError},
+		{codes.Unauthenticated, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, HTTPStatusFromCode(tt.code), tt.code.String())
+	}
+}
+
+func writeGRPCError(t *testing.T, err error) (*httptest.ResponseRecorder, Problem) {
+	w := httptest.NewRecorder()
+	WriteGRPCErrorToHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/transactions", nil), err)
+	require.Equal(t, ProblemContentType, w.Header().Get("Content-Type"))
+	var problem Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	return w, problem
+}
+
+func TestWriteGRPCErrorToHTTP(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		status  int
+		code    string
+		message string
+	}{
+		{"business error", status.Error(codes.FailedPrecondition, "insufficient funds"), http.StatusUnprocessableEntity, "FAILED_PRECONDITION", "insufficient funds"},
+		{"invalid JWT", status.Error(codes.InvalidArgument, "invalid JWT"), http.StatusUnauthorized, "INVALID_ARGUMENT", "invalid JWT"},
+		{"internal error hidden", status.Error(codes.Internal, "pq: relation does not exist"), http.StatusInternalServerError, "INTERNAL", "Internal server error"},
+		{"unavailable", status.Error(codes.Unavailable, "connection refused"), http.StatusServiceUnavailable, "UNAVAILABLE", "Service temporarily unavailable"},
+		{"timeout", status.Error(codes.DeadlineExceeded, "deadline"), http.StatusGatewayTimeout, "DEADLINE_EXCEEDED", "Request timed out"},
+		{"canceled", status.Error(codes.Canceled, "canceled"), 499, "CANCELLED", "Request canceled"},
+		{"not a status", errors.New("boom"), http.StatusInternalServerError, "INTERNAL", "Internal server error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, problem := writeGRPCError(t, tt.err)
+			require.Equal(t, tt.status, w.Code)
+			require.Equal(t, tt.status, problem.Status)
+			require.Equal(t, tt.code, problem.Code)
+			require.Equal(t, tt.message, problem.Message)
+			require.Equal(t, tt.message, problem.Detail)
+			require.Equal(t, "about:blank", problem.Type)
+			require.NotEmpty(t, problem.Title)
+			require.Equal(t, "/api/v1/transactions", problem.Instance)
+		})
+	}
+}
+
+func TestWriteGRPCErrorToHTTP_Details(t *testing.T) {
+	st, err := status.New(codes.InvalidArgument, "invalid request").WithDetails(&errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{
+			{Field: "amount", Reason: "INT64_GT", Description: "value must be greater than 0"},
+		},
+	})
+	require.NoError(t, err)
+	_, problem := writeGRPCError(t, st.Err())
+	require.Len(t, problem.Details, 1)
+	require.Contains(t, string(problem.Details[0]), "type.googleapis.com/google.rpc.BadRequest")
+	require.Equal(t, []FieldViolation{{Field: "amount", Reason: "INT64_GT", Description: "value must be greater than 0"}}, problem.Violations)
+
+	// the details of server-side failures aren't leaked
+	st, err = status.New(codes.Internal, "failed").WithDetails(&errdetails.ErrorInfo{Reason: "DB_DOWN"})
+	require.NoError(t, err)
+	_, problem = writeGRPCError(t, st.Err())
+	require.Empty(t, problem.Details)
+}
+
+func TestWriteError(t *testing.T) {
+	w := httptest.NewRecorder()
+	WriteError(w, httptest.NewRequest(http.MethodPost, "/api/v1/accounts", nil), http.StatusBadRequest, "Idempotency-Key header is required")
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	var problem Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	require.Equal(t, "INVALID_ARGUMENT", problem.Code)
+	require.Equal(t, "Bad Request", problem.Title)
+	require.Equal(t, "Idempotency-Key header is required", problem.Detail)
+}
@@ -2,9 +2,7 @@ package utils
 
 import (
 	"api-gateway/model"
-	"encoding/base64"
 	"fmt"
-	"os"
 	"time"
 
 	"github.com/pkg/errors"
@@ -13,18 +11,14 @@ import (
 	"github.com/google/uuid"
 )
 
-func ValidateJWT(jwtToken string, fingerprintCookie string) (*model.JWTClaim, error) {
-	tmp := os.Getenv("JWT_SECRET_KEY")
-	if tmp == "" {
-		return nil, errors.New("ValidateJWT: JWT secret key is not set in environment variables")
-	}
-	secret, err := base64.StdEncoding.DecodeString(tmp)
-	if err != nil {
-		return nil, errors.Errorf("ValidateJWT: failed to decode JWT secret key: %v", err)
+// secret is the decoded key from config.JWTConfig.Key().
+func ValidateJWT(jwtToken string, fingerprintCookie string, secret []byte) (*model.JWTClaim, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("ValidateJWT: JWT secret key is not set")
 	}
 	token, err := jwt.ParseWithClaims(jwtToken, &model.JWTClaim{},
 		func(token *jwt.Token) (any, error) {
-			return []byte(secret), nil
+			return secret, nil
 		})
 	if err != nil {
 		return nil, err
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "REDACTED"
+
+// envLoader overrides config values with environment variables.
+// Unlike a bare os.Getenv with a fallback, a malformed value is recorded as an error instead of being silently ignored.
+// An empty variable is treated as unset, since docker-compose passes unset variables through as "".
+type envLoader struct {
+	errs []error
+}
+
+func (l *envLoader) string(dst *string, key string) {
+	if v, ok := lookupEnv(key); ok {
+		*dst = v
+	}
+}
+
+func (l *envLoader) int(dst *int, key string) {
+	v, ok := lookupEnv(key)
+	if !ok {
+		return
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s: invalid integer %q", key, v))
+		return
+	}
+	*dst = n
+}
+
+func (l *envLoader) duration(dst *time.Duration, key string) {
+	v, ok := lookupEnv(key)
+	if !ok {
+		return
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s: invalid duration %q", key, v))
+		return
+	}
+	*dst = d
+}
+
+// list parses a comma-separated variable, e.g. "redis-node1:6380,redis-node2:6381".
+func (l *envLoader) list(dst *[]string, key string) {
+	v, ok := lookupEnv(key)
+	if !ok {
+		return
+	}
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	*dst = items
+}
+
+func lookupEnv(key string) (string, bool) {
+	v, ok := os.LookupEnv(key)
+	v = strings.TrimSpace(v)
+	return v, ok && v != ""
+}
+
+// loadFile decodes the YAML file at path into cfg. Unknown keys are rejected to catch typos.
+func loadFile(path string, cfg any) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer f.Close()
+
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+func validatePort(name string, port int) error {
+	if port <= 0 || port > 65535 {
+		return fmt.Errorf("%s: port %d out of range", name, port)
+	}
+	return nil
+}
+
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return redacted
+}
@@ -8,3 +8,12 @@ SELECT * FROM refresh_tokens WHERE token = $1;
 
 -- name: DeleteRefreshToken :exec
 DELETE FROM refresh_tokens WHERE token = $1;
+
+-- name: DeleteRefreshTokensByExpiredAt :execrows
+-- Deletes at most batch_size refresh tokens that expired before expired_before.
+DELETE FROM refresh_tokens
+WHERE id IN (
+    SELECT id FROM refresh_tokens
+    WHERE expired_at < sqlc.arg(expired_before)::timestamptz
+    LIMIT sqlc.arg(batch_size)
+);
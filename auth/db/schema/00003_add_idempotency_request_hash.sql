@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- rpc_name and request_hash identify the request an idempotency key was claimed for, so that a key reused for a
+-- different request is rejected instead of replaying the response of the first one.
+-- Keys claimed before this migration have empty values and match any request until they expire.
+ALTER TABLE idempotency_keys ADD COLUMN rpc_name TEXT NOT NULL DEFAULT '';
+ALTER TABLE idempotency_keys ADD COLUMN request_hash TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE idempotency_keys DROP COLUMN request_hash;
+ALTER TABLE idempotency_keys DROP COLUMN rpc_name;
+-- +goose StatementEnd
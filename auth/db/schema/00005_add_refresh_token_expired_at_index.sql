@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- the janitor deletes expired refresh tokens in batches, looked up by expired_at
+CREATE INDEX idx_refresh_token_expired_at ON refresh_tokens (expired_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_refresh_token_expired_at;
+-- +goose StatementEnd
@@ -7,6 +7,9 @@ package sqlc
 
 import (
 	"context"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 const deleteIdempotencyKeyByID = `-- name: DeleteIdempotencyKeyByID :exec
@@ -19,18 +22,32 @@ func (q *Queries) DeleteIdempotencyKeyByID(ctx context.Context, keyID string) er
 	return err
 }
 
-const deleteIdempotencyKeysByExpiredAt = `-- name: DeleteIdempotencyKeysByExpiredAt :exec
+const deleteIdempotencyKeysByExpiredAt = `-- name: DeleteIdempotencyKeysByExpiredAt :execrows
 DELETE FROM idempotency_keys
-WHERE expired_at < NOW()
+WHERE (key_id) IN (
+    SELECT key_id FROM idempotency_keys
+    WHERE expired_at < $1::timestamptz
+    LIMIT $2
+)
 `
 
-func (q *Queries) DeleteIdempotencyKeysByExpiredAt(ctx context.Context) error {
-	_, err := q.db.ExecContext(ctx, deleteIdempotencyKeysByExpiredAt)
-	return err
+type DeleteIdempotencyKeysByExpiredAtParams struct {
+	ExpiredBefore time.Time `json:"expired_before"`
+	BatchSize     int32     `json:"batch_size"`
+}
+
+// Deletes at most batch_size keys that expired before expired_before, so that the janitor purges large backlogs in
+// short transactions.
+func (q *Queries) DeleteIdempotencyKeysByExpiredAt(ctx context.Context, arg DeleteIdempotencyKeysByExpiredAtParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteIdempotencyKeysByExpiredAt, arg.ExpiredBefore, arg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
 const getIdempotencyKeyByID = `-- name: GetIdempotencyKeyByID :one
-SELECT key_id, status, response_message, created_at, updated_at, expired_at FROM idempotency_keys WHERE key_id = $1
+SELECT key_id, status, response_message, created_at, updated_at, expired_at, rpc_name, request_hash, claim_id, lease_expires_at FROM idempotency_keys WHERE key_id = $1
 `
 
 func (q *Queries) GetIdempotencyKeyByID(ctx context.Context, keyID string) (IdempotencyKey, error) {
@@ -43,6 +60,10 @@ func (q *Queries) GetIdempotencyKeyByID(ctx context.Context, keyID string) (Idem
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.ExpiredAt,
+		&i.RpcName,
+		&i.RequestHash,
+		&i.ClaimID,
+		&i.LeaseExpiresAt,
 	)
 	return i, err
 }
@@ -51,38 +72,70 @@ const getOrClaimIdempotencyKey = `-- name: GetOrClaimIdempotencyKey :one
 INSERT INTO idempotency_keys (
      -- Insert a new idempotency key. If concurrenct transactions already created the key, its status should be "COMPLETED" or "FAILED". 
      -- Else, we're the first to create it, and we set it to "PENDING".
-     -- This statement will blockResponseMessage if there are concurrent transactions inserting the same row, even if they haven't been committed/rollbacked.
+     -- This statement will block if there are concurrent transactions inserting the same row, even if they haven't been committed/rollbacked.
+     -- The claim is ours if the returned claim_id is the one we passed.
     key_id,
+    rpc_name,
+    request_hash,
+    claim_id,
     status,            
     response_message,     
     created_at,
     updated_at,
-    expired_at
+    expired_at,
+    lease_expires_at
 )
 VALUES (
-    $1,  
+    $1,
+    $2,
+    $3,
+    $4,
     'PENDING',
     'placeholder', 
     NOW(),
     NOW(),
-    NOW() + interval '24 hours'
+    NOW() + interval '24 hours',
+    NOW() + $5::float8 * interval '1 second'
 )
 ON CONFLICT (key_id)
 DO UPDATE SET
-    -- Only touch 'updated_at' if the existing status is 'PENDING', to signify this transaction is actively looking at it.
-    -- If it's already 'COMPLETED' or 'FAILED', we don't want to modify it here; RETURNING * will give us its state.
-    -- The 'status' is NOT changed here by the DO UPDATE clause if it was already terminal.
-    -- If it was PENDING, it remains PENDING. If it was a new insert, it gets the $3 status.
+    -- A key still 'PENDING' after its lease expired was abandoned by the attempt that claimed it: take it over.
+    -- Otherwise the key is left as is, RETURNING * gives its state: 'COMPLETED' or 'FAILED' if the request already ran,
+    -- or 'PENDING' with the claim_id of another attempt if it is still running.
+    claim_id = CASE
+                    WHEN idempotency_keys.status = 'PENDING' AND idempotency_keys.lease_expires_at < NOW()
+                        THEN EXCLUDED.claim_id
+                    ELSE idempotency_keys.claim_id
+               END,
+    lease_expires_at = CASE
+                    WHEN idempotency_keys.status = 'PENDING' AND idempotency_keys.lease_expires_at < NOW()
+                        THEN EXCLUDED.lease_expires_at
+                    ELSE idempotency_keys.lease_expires_at
+                 END,
     updated_at = CASE
-                    WHEN idempotency_keys.status = 'PENDING' -- $3 is 'PENDING'
+                    WHEN idempotency_keys.status = 'PENDING' AND idempotency_keys.lease_expires_at < NOW()
                         THEN NOW()
-                    ELSE idempotency_keys.updated_at -- Keep existing updated_at if it was COMPLETED/FAILED or different pending
+                    ELSE idempotency_keys.updated_at
                  END
-RETURNING key_id, status, response_message, created_at, updated_at, expired_at
+RETURNING key_id, status, response_message, created_at, updated_at, expired_at, rpc_name, request_hash, claim_id, lease_expires_at
 `
 
-func (q *Queries) GetOrClaimIdempotencyKey(ctx context.Context, keyID string) (IdempotencyKey, error) {
-	row := q.db.QueryRowContext(ctx, getOrClaimIdempotencyKey, keyID)
+type GetOrClaimIdempotencyKeyParams struct {
+	KeyID        string        `json:"key_id"`
+	RpcName      string        `json:"rpc_name"`
+	RequestHash  string        `json:"request_hash"`
+	ClaimID      uuid.NullUUID `json:"claim_id"`
+	LeaseSeconds float64       `json:"lease_seconds"`
+}
+
+func (q *Queries) GetOrClaimIdempotencyKey(ctx context.Context, arg GetOrClaimIdempotencyKeyParams) (IdempotencyKey, error) {
+	row := q.db.QueryRowContext(ctx, getOrClaimIdempotencyKey,
+		arg.KeyID,
+		arg.RpcName,
+		arg.RequestHash,
+		arg.ClaimID,
+		arg.LeaseSeconds,
+	)
 	var i IdempotencyKey
 	err := row.Scan(
 		&i.KeyID,
@@ -91,25 +144,46 @@ func (q *Queries) GetOrClaimIdempotencyKey(ctx context.Context, keyID string) (I
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.ExpiredAt,
+		&i.RpcName,
+		&i.RequestHash,
+		&i.ClaimID,
+		&i.LeaseExpiresAt,
 	)
 	return i, err
 }
 
+const setLockTimeout = `-- name: SetLockTimeout :exec
+SELECT set_config('lock_timeout', $1::text, true)
+`
+
+// Sets lock_timeout until the end of the current transaction, e.g. '30000ms', or '0' to wait forever.
+func (q *Queries) SetLockTimeout(ctx context.Context, timeout string) error {
+	_, err := q.db.ExecContext(ctx, setLockTimeout, timeout)
+	return err
+}
+
 const updateIdempotencyKey = `-- name: UpdateIdempotencyKey :one
 UPDATE idempotency_keys
 SET status = $1, response_message = $2, updated_at = NOW(), expired_at = NOW() + interval '24 hours'
-WHERE key_id = $3
-RETURNING key_id, status, response_message, created_at, updated_at, expired_at
+WHERE key_id = $3 AND claim_id = $4
+RETURNING key_id, status, response_message, created_at, updated_at, expired_at, rpc_name, request_hash, claim_id, lease_expires_at
 `
 
 type UpdateIdempotencyKeyParams struct {
-	Status          string `json:"status"`
-	ResponseMessage string `json:"response_message"`
-	KeyID           string `json:"key_id"`
+	Status          string        `json:"status"`
+	ResponseMessage string        `json:"response_message"`
+	KeyID           string        `json:"key_id"`
+	ClaimID         uuid.NullUUID `json:"claim_id"`
 }
 
+// Only the attempt holding the claim may complete the key, a late attempt whose claim was taken over updates nothing.
 func (q *Queries) UpdateIdempotencyKey(ctx context.Context, arg UpdateIdempotencyKeyParams) (IdempotencyKey, error) {
-	row := q.db.QueryRowContext(ctx, updateIdempotencyKey, arg.Status, arg.ResponseMessage, arg.KeyID)
+	row := q.db.QueryRowContext(ctx, updateIdempotencyKey,
+		arg.Status,
+		arg.ResponseMessage,
+		arg.KeyID,
+		arg.ClaimID,
+	)
 	var i IdempotencyKey
 	err := row.Scan(
 		&i.KeyID,
@@ -118,6 +192,10 @@ func (q *Queries) UpdateIdempotencyKey(ctx context.Context, arg UpdateIdempotenc
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.ExpiredAt,
+		&i.RpcName,
+		&i.RequestHash,
+		&i.ClaimID,
+		&i.LeaseExpiresAt,
 	)
 	return i, err
 }
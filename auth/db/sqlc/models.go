@@ -12,12 +12,16 @@ import (
 )
 
 type IdempotencyKey struct {
-	KeyID           string       `json:"key_id"`
-	Status          string       `json:"status"`
-	ResponseMessage string       `json:"response_message"`
-	CreatedAt       sql.NullTime `json:"created_at"`
-	UpdatedAt       sql.NullTime `json:"updated_at"`
-	ExpiredAt       sql.NullTime `json:"expired_at"`
+	KeyID           string        `json:"key_id"`
+	Status          string        `json:"status"`
+	ResponseMessage string        `json:"response_message"`
+	CreatedAt       sql.NullTime  `json:"created_at"`
+	UpdatedAt       sql.NullTime  `json:"updated_at"`
+	ExpiredAt       sql.NullTime  `json:"expired_at"`
+	RpcName         string        `json:"rpc_name"`
+	RequestHash     string        `json:"request_hash"`
+	ClaimID         uuid.NullUUID `json:"claim_id"`
+	LeaseExpiresAt  time.Time     `json:"lease_expires_at"`
 }
 
 type RefreshToken struct {
@@ -55,6 +55,29 @@ func (q *Queries) DeleteRefreshToken(ctx context.Context, token string) error {
 	return err
 }
 
+const deleteRefreshTokensByExpiredAt = `-- name: DeleteRefreshTokensByExpiredAt :execrows
+DELETE FROM refresh_tokens
+WHERE id IN (
+    SELECT id FROM refresh_tokens
+    WHERE expired_at < $1::timestamptz
+    LIMIT $2
+)
+`
+
+type DeleteRefreshTokensByExpiredAtParams struct {
+	ExpiredBefore time.Time `json:"expired_before"`
+	BatchSize     int32     `json:"batch_size"`
+}
+
+// Deletes at most batch_size refresh tokens that expired before expired_before.
+func (q *Queries) DeleteRefreshTokensByExpiredAt(ctx context.Context, arg DeleteRefreshTokensByExpiredAtParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteRefreshTokensByExpiredAt, arg.ExpiredBefore, arg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const getRefreshToken = `-- name: GetRefreshToken :one
 SELECT id, user_id, token, expired_at, created_at FROM refresh_tokens WHERE token = $1
 `
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"auth/internal/identity"
 	"auth/model"
 	"auth/proto"
 	"auth/service"
@@ -36,7 +37,7 @@ func (h *AuthHandler) CreateUser(ctx context.Context, req *proto.CreateUserReque
 }
 
 func (h *AuthHandler) GetUserProfileById(ctx context.Context, req *proto.GetUserProfileByIdRequest) (*proto.GetUserProfileByIdResponse, error) {
-	userID, err := uuid.Parse(req.UserId)
+	userID, err := identity.UserID(ctx)
 	if err != nil {
 		return &proto.GetUserProfileByIdResponse{}, err
 	}
@@ -47,7 +48,7 @@ func (h *AuthHandler) GetUserProfileById(ctx context.Context, req *proto.GetUser
 }
 
 func (h *AuthHandler) DeleteUser(ctx context.Context, req *proto.DeleteUserRequest) (*proto.DeleteUserResponse, error) {
-	userID, err := uuid.Parse(req.UserId)
+	userID, err := identity.UserID(ctx)
 	if err != nil {
 		return &proto.DeleteUserResponse{}, err
 	}
@@ -79,7 +80,7 @@ func (h *AuthHandler) Login(ctx context.Context, req *proto.LoginRequest) (*prot
 }
 
 func (h *AuthHandler) RenewAccessToken(ctx context.Context, req *proto.RenewAccessTokenRequest) (*proto.RenewAccessTokenResponse, error) {
-	userID, err := uuid.Parse(req.UserId)
+	userID, err := identity.UserID(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,21 @@
+package handler
+
+import (
+	"auth/internal/mtls"
+	"auth/proto"
+)
+
+// Policy lists which services may call each RPC of the auth service. Only the API Gateway talks to it.
+var Policy = mtls.Policy{
+	proto.AuthService_CreateUser_FullMethodName:         {mtls.APIGateway},
+	proto.AuthService_DeleteUser_FullMethodName:         {mtls.APIGateway},
+	proto.AuthService_Login_FullMethodName:              {mtls.APIGateway},
+	proto.AuthService_RenewAccessToken_FullMethodName:   {mtls.APIGateway},
+	proto.AuthService_GetUserProfileById_FullMethodName: {mtls.APIGateway},
+}
+
+// PublicMethods are the RPCs made before the user has an access token. Every other RPC requires one.
+var PublicMethods = []string{
+	proto.AuthService_CreateUser_FullMethodName,
+	proto.AuthService_Login_FullMethodName,
+}
@@ -0,0 +1,143 @@
+// Package janitor periodically purges the rows that outlived their use, such as expired idempotency keys and refresh
+// tokens.
+//
+// Every replica runs the janitor, but a run only proceeds in the replica that takes a Postgres advisory lock, so the
+// replicas don't delete the same rows concurrently. Rows are deleted in batches, each in its own short transaction,
+// so that purging a large backlog doesn't hold many row locks or block the requests for long.
+package janitor
+
+import (
+	"auth/config"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// lockID is the key of the advisory lock held by the replica running the janitor: "janitor" in ASCII, then 2 for the
+// auth database.
+const lockID int64 = 0x6a616e69746f7202
+
+// ErrNotLeader is returned by RunOnce when another replica holds the lock and is purging already.
+var ErrNotLeader = errors.New("janitor: another instance is running")
+
+// PurgeFunc deletes at most batchSize rows that expired before expiredBefore, and returns how many it deleted.
+type PurgeFunc func(ctx context.Context, expiredBefore time.Time, batchSize int32) (int64, error)
+
+type task struct {
+	table     string
+	retention time.Duration
+	purge     PurgeFunc
+}
+
+type Janitor struct {
+	db        *sqlx.DB
+	interval  time.Duration
+	batchSize int32
+	tasks     []task
+}
+
+// New creates a Janitor with the schedule and batch size of cfg. Register what it purges with AddTask.
+func New(db *sqlx.DB, cfg config.JanitorConfig) *Janitor {
+	return &Janitor{
+		db:        db,
+		interval:  cfg.Interval,
+		batchSize: int32(cfg.BatchSize),
+	}
+}
+
+// AddTask registers purge, which deletes the rows of table expired for longer than retention.
+func (j *Janitor) AddTask(table string, retention time.Duration, purge PurgeFunc) {
+	j.tasks = append(j.tasks, task{table: table, retention: retention, purge: purge})
+}
+
+// Run purges every interval until ctx is cancelled. It returns right away if the scheduled runs are disabled.
+func (j *Janitor) Run(ctx context.Context) {
+	if j.interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := j.RunOnce(ctx); err != nil && !errors.Is(err, ErrNotLeader) && ctx.Err() == nil {
+				log.Printf("janitor: %v\n", err)
+			}
+		}
+	}
+}
+
+// RunOnce runs every task until it has nothing left to purge, and fails with ErrNotLeader if another replica is
+// running them already.
+func (j *Janitor) RunOnce(ctx context.Context) error {
+	// session-level advisory locks belong to a connection, so the lock is taken and released on a dedicated one.
+	// If the connection breaks, Postgres releases the lock with the session.
+	conn, err := j.db.Conn(ctx)
+	if err != nil {
+		runs.WithLabelValues("failed").Inc()
+		return fmt.Errorf("failed to get a connection: %w", err)
+	}
+	defer conn.Close()
+
+	var leader bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", lockID).Scan(&leader); err != nil {
+		runs.WithLabelValues("failed").Inc()
+		return fmt.Errorf("failed to take the advisory lock: %w", err)
+	}
+	if !leader {
+		runs.WithLabelValues("skipped").Inc()
+		return ErrNotLeader
+	}
+	defer func() {
+		// released even if ctx was cancelled, the connection goes back to the pool
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockID); err != nil {
+			log.Printf("janitor: Failed to release the advisory lock: %v\n", err)
+		}
+	}()
+
+	var errs []error
+	for _, t := range j.tasks {
+		if err := j.purge(ctx, t); err != nil {
+			errs = append(errs, fmt.Errorf("failed to purge %s: %w", t.table, err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		runs.WithLabelValues("failed").Inc()
+		return err
+	}
+	runs.WithLabelValues("succeeded").Inc()
+	lastSuccess.SetToCurrentTime()
+	return nil
+}
+
+// purge deletes the expired rows of t batch by batch, until a batch comes back short.
+func (j *Janitor) purge(ctx context.Context, t task) error {
+	// fixed for the whole run, so that rows expiring meanwhile don't keep the loop going
+	expiredBefore := time.Now().Add(-t.retention)
+	var total int64
+	for {
+		n, err := t.purge(ctx, expiredBefore, j.batchSize)
+		if err != nil {
+			return err
+		}
+		total += n
+		rowsPurged.WithLabelValues(t.table).Add(float64(n))
+		if n < int64(j.batchSize) {
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+	if total > 0 {
+		log.Printf("janitor: purged %d rows from %s\n", total, t.table)
+	}
+	return nil
+}
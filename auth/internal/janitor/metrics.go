@@ -0,0 +1,23 @@
+package janitor
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	rowsPurged = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_janitor_rows_purged_total",
+		Help: "Expired rows deleted by the janitor, per table.",
+	}, []string{"table"})
+
+	runs = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_janitor_runs_total",
+		Help: "Janitor runs by result: succeeded, failed, or skipped because another replica held the lock.",
+	}, []string{"result"})
+
+	lastSuccess = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "auth_janitor_last_success_timestamp_seconds",
+		Help: "Unix time of the last janitor run that purged every table.",
+	})
+)
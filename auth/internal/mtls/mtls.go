@@ -0,0 +1,146 @@
+// Package mtls builds the mutual TLS credentials used between the internal services and authorizes
+// callers by the service identity in their verified client certificate.
+//
+// Every service certificate is issued by the development CA (see tools/devca) and carries its identity as a
+// URI SAN of the form spiffe://banking-app/<identity>, e.g. spiffe://banking-app/api-gateway.
+package mtls
+
+import (
+	"auth/config"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const TrustDomain = "banking-app"
+
+// Identities of the services, as encoded in their certificates.
+const (
+	APIGateway      = "api-gateway"
+	AuthService     = "auth-service"
+	AccountService  = "account-service"
+	TransferService = "transfer-service"
+)
+
+// ServerCredentials requires every client to present a certificate signed by the CA.
+func ServerCredentials(cfg config.TLSConfig) (credentials.TransportCredentials, error) {
+	cert, pool, err := load(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS13,
+	}), nil
+}
+
+// ClientCredentials presents our certificate to the server and checks that the server is the expected service,
+// on top of the usual hostname verification.
+func ClientCredentials(cfg config.TLSConfig, server string) (credentials.TransportCredentials, error) {
+	cert, pool, err := load(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		MinVersion:   tls.VersionTLS13,
+		VerifyConnection: func(state tls.ConnectionState) error {
+			if len(state.PeerCertificates) == 0 {
+				return errors.New("mtls: server presented no certificate")
+			}
+			if id, _ := identityFromCert(state.PeerCertificates[0]); id != server {
+				return fmt.Errorf("mtls: expected server %q, got %q", server, id)
+			}
+			return nil
+		},
+	}), nil
+}
+
+func load(cfg config.TLSConfig) (tls.Certificate, *x509.CertPool, error) {
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return tls.Certificate{}, nil, fmt.Errorf("mtls: failed to load key pair: %w", err)
+	}
+	caPEM, err := os.ReadFile(cfg.CAFile)
+	if err != nil {
+		return tls.Certificate{}, nil, fmt.Errorf("mtls: failed to read CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return tls.Certificate{}, nil, fmt.Errorf("mtls: no certificate found in %s", cfg.CAFile)
+	}
+	return cert, pool, nil
+}
+
+// Identity returns the identity of the calling service from its verified client certificate.
+// It returns false if the connection isn't mutually authenticated.
+func Identity(ctx context.Context) (string, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", false
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return "", false
+	}
+	return identityFromCert(info.State.VerifiedChains[0][0])
+}
+
+func identityFromCert(cert *x509.Certificate) (string, bool) {
+	for _, uri := range cert.URIs {
+		if uri.Scheme == "spiffe" && uri.Host == TrustDomain {
+			if id := strings.TrimPrefix(uri.Path, "/"); id != "" {
+				return id, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Policy lists the identities allowed to call each full method name, e.g. proto.AccountService_CreateAccount_FullMethodName.
+// Methods missing from the policy are denied to everyone, except the grpc.health.v1 service.
+type Policy map[string][]string
+
+// RequestCheck authorizes a call based on its content, for rules that can't be expressed per method.
+type RequestCheck func(ctx context.Context, identity string, req any) error
+
+// UnaryServerInterceptor rejects calls whose caller identity is not allowed by policy, then runs check if it's not nil.
+// When TLS is disabled (local development only), callers have no identity and the policy is skipped,
+// but check still runs with an empty identity so that privileged requests are always rejected.
+func UnaryServerInterceptor(policy Policy, tlsEnabled bool, check RequestCheck) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if strings.HasPrefix(info.FullMethod, "/grpc.health.v1.Health/") {
+			return handler(ctx, req)
+		}
+
+		identity, ok := Identity(ctx)
+		if tlsEnabled {
+			if !ok {
+				return nil, status.Error(codes.Unauthenticated, "missing client certificate identity")
+			}
+			if !slices.Contains(policy[info.FullMethod], identity) {
+				return nil, status.Errorf(codes.PermissionDenied, "%s may not call %s", identity, info.FullMethod)
+			}
+		}
+		if check != nil {
+			if err := check(ctx, identity, req); err != nil {
+				return nil, err
+			}
+		}
+		return handler(ctx, req)
+	}
+}
@@ -1,29 +1,62 @@
 package main
 
 import (
+	"auth/config"
 	"auth/db/initialize"
 	"auth/handler"
+	"auth/internal/health"
+	"auth/internal/identity"
+	"auth/internal/janitor"
+	"auth/internal/mtls"
+	"auth/internal/validation"
 	"auth/proto"
 	"auth/repository"
 	"auth/service"
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net"
+	"net/http"
 	"os"
-	"strconv"
+	"os/signal"
+	"syscall"
+	"time"
 
 	_ "github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 func main() {
-	db := initialize.ConnectDB()
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	// `main healthcheck` is used by the docker-compose health check
+	if len(os.Args) > 1 && os.Args[1] == "healthcheck" {
+		os.Exit(runHealthcheck(cfg))
+	}
+
+	db := initialize.ConnectDB(cfg.DB)
 	defer db.Close()
 	authRepo := repository.NewAuthRepository(db)
 	if authRepo == nil {
 		log.Fatalf("Failed to create auth repository")
 	}
-	authService := service.NewAuthService(authRepo, db)
+	authJanitor := janitor.New(db, cfg.Janitor)
+	authJanitor.AddTask("idempotency_keys", cfg.Janitor.IdempotencyKeyRetention, authRepo.DeleteExpiredIdempotencyKeys)
+	authJanitor.AddTask("refresh_tokens", cfg.Janitor.RefreshTokenRetention, authRepo.DeleteExpiredRefreshTokens)
+
+	// `main janitor` purges the expired rows once, e.g. from a cron job when the scheduled runs are disabled
+	if len(os.Args) > 1 && os.Args[1] == "janitor" {
+		os.Exit(runJanitor(authJanitor))
+	}
+
+	authService := service.NewAuthService(authRepo, db, cfg.JWT).WithMaxRetries(cfg.MaxRetries)
 	if authService == nil {
 		log.Fatalf("Failed to create auth service")
 	}
@@ -32,32 +65,149 @@ func main() {
 		log.Fatalf("Failed to create auth handler")
 	}
 
-	// Set JWT secret key
-	//jwtSecretKey := make([]byte, 32)
-	//_, err := rand.Read(jwtSecretKey)
-	//if err != nil {
-	//	log.Fatalf("failed to generate JWT secret key: %w", err)
-	//}
-	//os.Setenv("JWT_SECRET_KEY", base64.StdEncoding.EncodeToString(jwtSecretKey))
+	// cancelled on SIGINT/SIGTERM so that we can drain in-flight RPCs before exiting
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// report NOT_SERVING whenever Postgres is unreachable
+	healthChecker := health.NewChecker(cfg.HealthCheckInterval, proto.AuthService_ServiceDesc.ServiceName)
+	healthChecker.AddCheck("postgres", db.PingContext)
+	go healthChecker.Run(ctx)
 
-	_port := os.Getenv("GRPC_PORT")
-	var port int
-	var err error
-	if port, err = strconv.Atoi(_port); err != nil {
-		port = 50001
+	// purge expired rows in the background, in one replica at a time
+	go authJanitor.Run(ctx)
+
+	// internal-only admin server exposing the redacted effective config and the metrics
+	adminServer := newAdminServer(cfg)
+	if adminServer != nil {
+		go func() {
+			if err := adminServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Printf("Admin server failed: %v", err)
+			}
+		}()
 	}
 
 	// start the server
-	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.GRPCPort))
 	if err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 	defer listener.Close()
 
-	grpcServer := grpc.NewServer()
+	// mTLS between internal services. Callers are authorized by the identity in their client certificate,
+	// and the end user by the access token the caller forwards in the request metadata.
+	// Requests are then checked against the buf.validate rules of the .proto file before reaching the handlers.
+	serverOpts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(
+			mtls.UnaryServerInterceptor(handler.Policy, cfg.TLS.Enabled(), nil),
+			identity.UnaryServerInterceptor(cfg.JWT.Key(), handler.PublicMethods...),
+			validation.UnaryServerInterceptor(),
+		),
+	}
+	if cfg.TLS.Enabled() {
+		creds, err := mtls.ServerCredentials(cfg.TLS)
+		if err != nil {
+			log.Fatalf("Failed to load TLS credentials: %v", err)
+		}
+		serverOpts = append(serverOpts, grpc.Creds(creds))
+	} else {
+		log.Println("WARNING: TLS is disabled, callers can't be authenticated")
+	}
+	grpcServer := grpc.NewServer(serverOpts...)
 	proto.RegisterAuthServiceServer(grpcServer, authHandler)
-	fmt.Printf("Server started on port %d\n", port)
-	if grpcServer.Serve(listener); err != nil {
+	healthpb.RegisterHealthServer(grpcServer, healthChecker.Server())
+
+	serveErr := make(chan error, 1)
+	go func() {
+		fmt.Printf("Server started on port %d\n", cfg.GRPCPort)
+		serveErr <- grpcServer.Serve(listener)
+	}()
+
+	select {
+	case err := <-serveErr:
 		log.Fatalf("Failed to serve: %v", err)
+	case <-ctx.Done():
+	}
+
+	log.Println("Shutting down: draining in-flight RPCs")
+	healthChecker.Shutdown()
+	gracefulStop(grpcServer, cfg.ShutdownTimeout)
+	if adminServer != nil {
+		adminServer.Close()
+	}
+	log.Println("Server stopped")
+}
+
+// newAdminServer returns the internal admin HTTP server, or nil if it is disabled.
+func newAdminServer(cfg *config.Config) *http.Server {
+	if cfg.AdminPort == 0 {
+		return nil
+	}
+	mux := http.NewServeMux()
+	mux.Handle("GET /debug/config", cfg.DumpHandler())
+	mux.Handle("GET /metrics", promhttp.Handler())
+	return &http.Server{
+		Addr:    fmt.Sprintf(":%d", cfg.AdminPort),
+		Handler: mux,
+	}
+}
+
+// gracefulStop stops accepting new RPCs and waits for in-flight RPCs to finish.
+// If they don't finish within the timeout, the remaining RPCs are cancelled.
+func gracefulStop(grpcServer *grpc.Server, timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		log.Printf("Graceful shutdown timed out after %v, forcing stop\n", timeout)
+		grpcServer.Stop()
+	}
+}
+
+// runHealthcheck queries the grpc.health.v1 service of the local server and returns the process exit code.
+// With TLS enabled, we present our own certificate, which the server accepts since health checks bypass the authorization policy.
+func runHealthcheck(cfg *config.Config) int {
+	creds := insecure.NewCredentials()
+	if cfg.TLS.Enabled() {
+		var err error
+		if creds, err = mtls.ClientCredentials(cfg.TLS, mtls.AuthService); err != nil {
+			log.Printf("healthcheck: %v\n", err)
+			return 1
+		}
+	}
+	conn, err := grpc.NewClient(fmt.Sprintf("localhost:%d", cfg.GRPCPort), grpc.WithTransportCredentials(creds))
+	if err != nil {
+		log.Printf("healthcheck: %v\n", err)
+		return 1
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	res, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		log.Printf("healthcheck: %v\n", err)
+		return 1
+	}
+	if res.Status != healthpb.HealthCheckResponse_SERVING {
+		log.Printf("healthcheck: status %v\n", res.Status)
+		return 1
+	}
+	return 0
+}
+
+// runJanitor purges the expired rows once and returns the process exit code.
+func runJanitor(j *janitor.Janitor) int {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	if err := j.RunOnce(ctx); err != nil {
+		log.Printf("janitor: %v\n", err)
+		return 1
 	}
+	return 0
 }
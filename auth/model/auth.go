@@ -6,7 +6,6 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 )
 
 type User struct {
@@ -65,23 +64,37 @@ type JWTClaim struct {
 
 type IdempotencyKey struct {
 	KeyID string `json:"key_id"`
+	// RPCName and RequestHash identify the request the key was claimed for, see idempotency.RequestHash
+	RPCName     string `json:"rpc_name"`
+	RequestHash string `json:"request_hash"`
+	// ClaimID identifies the attempt holding the key while it is PENDING
+	ClaimID uuid.UUID `json:"claim_id"`
 
 	Status          string `json:"status"`
 	ResponseMessage string `json:"responseBody"`
 }
 
+// Matches reports whether the key was claimed by the same RPC with the same parameters.
+// Keys claimed before the request hash was stored match any request.
+func (k *IdempotencyKey) Matches(rpcName, requestHash string) bool {
+	if k.RequestHash == "" {
+		return true
+	}
+	return k.RPCName == rpcName && k.RequestHash == requestHash
+}
+
 var (
-	ErrInternalServer    error = status.Error(codes.Internal, "internal server error")
-	ErrInvalidArgument   error = status.Error(codes.InvalidArgument, "invalid argument")
-	ErrUserAlreadyExists error = status.Error(codes.AlreadyExists, "user already exists")
-	ErrNotAuthorized     error = status.Error(codes.Unauthenticated, "not authorized")
-	ErrNotAuthenticated  error = status.Error(codes.Unauthenticated, "invalid credentials")
+	ErrInternalServer           error = newError(codes.Internal, "INTERNAL", "internal server error")
+	ErrInvalidArgument          error = newError(codes.InvalidArgument, "INVALID_ARGUMENT", "invalid argument")
+	ErrUserAlreadyExists        error = newError(codes.AlreadyExists, "USER_ALREADY_EXISTS", "user already exists")
+	ErrNotAuthorized            error = newError(codes.PermissionDenied, "NOT_AUTHORIZED", "not authorized")
+	ErrNotAuthenticated         error = newError(codes.Unauthenticated, "INVALID_CREDENTIALS", "invalid credentials")
+	ErrInvalidRefreshToken      error = newError(codes.Unauthenticated, "INVALID_REFRESH_TOKEN", "invalid refresh token")
+	ErrIdempotencyKeyReused     error = newError(codes.FailedPrecondition, "IDEMPOTENCY_KEY_REUSED", "idempotency key was already used for a different request")
+	ErrIdempotencyKeyInProgress error = newError(codes.Aborted, "IDEMPOTENCY_KEY_IN_PROGRESS", "a request with the same idempotency key is in progress")
 )
 
 var (
-	TokenShortDuration     time.Duration = 15 * time.Minute
-	TokenAbsoluteDuration  time.Duration = 4 * time.Hour
-	RefreshTokenDuration   time.Duration = 24 * time.Hour
-	FingerprintCookieName  string        = "fingerprint"
-	RefreshTokenCookieName string        = "refreshToken"
+	FingerprintCookieName  string = "fingerprint"
+	RefreshTokenCookieName string = "refreshToken"
 )
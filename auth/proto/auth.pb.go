@@ -1,6 +1,6 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
-// 	protoc-gen-go v1.36.10
+// 	protoc-gen-go v1.36.6
 // 	protoc        (unknown)
 // source: auth.proto
 
@@ -8,6 +8,7 @@ package proto
 
 import (
 	_ "buf.build/gen/go/bufbuild/protovalidate/protocolbuffers/go/buf/validate"
+	_ "google.golang.org/genproto/googleapis/api/annotations"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	reflect "reflect"
@@ -67,10 +68,11 @@ func (x *UserProfile) GetEmail() string {
 }
 
 type CreateUserRequest struct {
-	state          protoimpl.MessageState `protogen:"open.v1"`
-	Email          string                 `protobuf:"bytes,1,opt,name=email,proto3" json:"email,omitempty"`
-	Password       string                 `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
-	IdempotencyKey string                 `protobuf:"bytes,3,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Email string                 `protobuf:"bytes,1,opt,name=email,proto3" json:"email,omitempty"`
+	// bcrypt ignores everything after the first 72 bytes
+	Password       string `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
+	IdempotencyKey string `protobuf:"bytes,3,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
 	unknownFields  protoimpl.UnknownFields
 	sizeCache      protoimpl.SizeCache
 }
@@ -170,9 +172,11 @@ func (x *CreateUserResponse) GetUserId() string {
 	return ""
 }
 
+// user_id is deprecated and ignored: the user is taken from the access token forwarded in the request metadata.
 type GetUserProfileByIdRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Deprecated: Marked as deprecated in auth.proto.
+	UserId        string `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -207,6 +211,7 @@ func (*GetUserProfileByIdRequest) Descriptor() ([]byte, []int) {
 	return file_auth_proto_rawDescGZIP(), []int{3}
 }
 
+// Deprecated: Marked as deprecated in auth.proto.
 func (x *GetUserProfileByIdRequest) GetUserId() string {
 	if x != nil {
 		return x.UserId
@@ -258,13 +263,14 @@ func (x *GetUserProfileByIdResponse) GetProfile() *UserProfile {
 	return nil
 }
 
-// user_id is the ID of the user associated with the JWT token of the request validated at the API Gateway.
+// user_id is deprecated and ignored: the user is taken from the access token forwarded in the request metadata.
 // target_user_id is the ID of the user to delte.
 type DeleteUserRequest struct {
-	state          protoimpl.MessageState `protogen:"open.v1"`
-	UserId         string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	TargetUserId   string                 `protobuf:"bytes,2,opt,name=target_user_id,json=targetUserId,proto3" json:"target_user_id,omitempty"`
-	IdempotencyKey string                 `protobuf:"bytes,4,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Deprecated: Marked as deprecated in auth.proto.
+	UserId         string `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	TargetUserId   string `protobuf:"bytes,2,opt,name=target_user_id,json=targetUserId,proto3" json:"target_user_id,omitempty"`
+	IdempotencyKey string `protobuf:"bytes,4,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
 	unknownFields  protoimpl.UnknownFields
 	sizeCache      protoimpl.SizeCache
 }
@@ -299,6 +305,7 @@ func (*DeleteUserRequest) Descriptor() ([]byte, []int) {
 	return file_auth_proto_rawDescGZIP(), []int{5}
 }
 
+// Deprecated: Marked as deprecated in auth.proto.
 func (x *DeleteUserRequest) GetUserId() string {
 	if x != nil {
 		return x.UserId
@@ -500,12 +507,13 @@ func (x *LoginResponse) GetRefreshTokenDuration() int32 {
 	return 0
 }
 
-// user_id is most of the time the ID associated with the JWT token of the request validated at the API Gateway.
+// user_id is deprecated and ignored: the user is taken from the access token forwarded in the request metadata.
 type RenewAccessTokenRequest struct {
-	state          protoimpl.MessageState `protogen:"open.v1"`
-	UserId         string                 `protobuf:"bytes,4,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	RefreshToken   string                 `protobuf:"bytes,1,opt,name=refresh_token,json=refreshToken,proto3" json:"refresh_token,omitempty"`
-	IdempotencyKey string                 `protobuf:"bytes,3,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Deprecated: Marked as deprecated in auth.proto.
+	UserId         string `protobuf:"bytes,4,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	RefreshToken   string `protobuf:"bytes,1,opt,name=refresh_token,json=refreshToken,proto3" json:"refresh_token,omitempty"`
+	IdempotencyKey string `protobuf:"bytes,3,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
 	unknownFields  protoimpl.UnknownFields
 	sizeCache      protoimpl.SizeCache
 }
@@ -540,6 +548,7 @@ func (*RenewAccessTokenRequest) Descriptor() ([]byte, []int) {
 	return file_auth_proto_rawDescGZIP(), []int{9}
 }
 
+// Deprecated: Marked as deprecated in auth.proto.
 func (x *RenewAccessTokenRequest) GetUserId() string {
 	if x != nil {
 		return x.UserId
@@ -626,21 +635,22 @@ var File_auth_proto protoreflect.FileDescriptor
 const file_auth_proto_rawDesc = "" +
 	"\n" +
 	"\n" +
-	"auth.proto\x12\x05proto\x1a\x1bbuf/validate/validate.proto\",\n" +
+	"auth.proto\x12\x05proto\x1a\x1bbuf/validate/validate.proto\x1a\x1cgoogle/api/annotations.proto\",\n" +
 	"\vUserProfile\x12\x1d\n" +
-	"\x05email\x18\x01 \x01(\tB\a\xbaH\x04r\x02`\x01R\x05email\"\x89\x01\n" +
-	"\x11CreateUserRequest\x12\x1d\n" +
-	"\x05email\x18\x01 \x01(\tB\a\xbaH\x04r\x02`\x01R\x05email\x12\"\n" +
-	"\bpassword\x18\x02 \x01(\tB\x06\xbaH\x03\xc8\x01\x01R\bpassword\x121\n" +
+	"\x05email\x18\x01 \x01(\tB\a\xbaH\x04r\x02`\x01R\x05email\"\x8f\x01\n" +
+	"\x11CreateUserRequest\x12 \n" +
+	"\x05email\x18\x01 \x01(\tB\n" +
+	"\xbaH\ar\x05\x18\xfe\x01`\x01R\x05email\x12%\n" +
+	"\bpassword\x18\x02 \x01(\tB\t\xbaH\x06r\x04\x10\b(HR\bpassword\x121\n" +
 	"\x0fidempotency_key\x18\x03 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\x0eidempotencyKey\"7\n" +
 	"\x12CreateUserResponse\x12!\n" +
-	"\auser_id\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\x06userId\">\n" +
-	"\x19GetUserProfileByIdRequest\x12!\n" +
-	"\auser_id\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\x06userId\"J\n" +
+	"\auser_id\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\x06userId\"8\n" +
+	"\x19GetUserProfileByIdRequest\x12\x1b\n" +
+	"\auser_id\x18\x01 \x01(\tB\x02\x18\x01R\x06userId\"J\n" +
 	"\x1aGetUserProfileByIdResponse\x12,\n" +
-	"\aprofile\x18\x01 \x01(\v2\x12.proto.UserProfileR\aprofile\"\x99\x01\n" +
-	"\x11DeleteUserRequest\x12!\n" +
-	"\auser_id\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\x06userId\x12.\n" +
+	"\aprofile\x18\x01 \x01(\v2\x12.proto.UserProfileR\aprofile\"\x93\x01\n" +
+	"\x11DeleteUserRequest\x12\x1b\n" +
+	"\auser_id\x18\x01 \x01(\tB\x02\x18\x01R\x06userId\x12.\n" +
 	"\x0etarget_user_id\x18\x02 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\ftargetUserId\x121\n" +
 	"\x0fidempotency_key\x18\x04 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\x0eidempotencyKey\"\x14\n" +
 	"\x12DeleteUserResponse\"\x84\x01\n" +
@@ -654,23 +664,23 @@ const file_auth_proto_rawDesc = "" +
 	"\faccess_token\x18\x05 \x01(\tR\vaccessToken\x12 \n" +
 	"\vfingerprint\x18\x06 \x01(\tR\vfingerprint\x122\n" +
 	"\x15access_token_duration\x18\x01 \x01(\x05R\x13accessTokenDuration\x124\n" +
-	"\x16refresh_token_duration\x18\x02 \x01(\x05R\x14refreshTokenDuration\"\x9c\x01\n" +
-	"\x17RenewAccessTokenRequest\x12!\n" +
-	"\auser_id\x18\x04 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\x06userId\x12+\n" +
+	"\x16refresh_token_duration\x18\x02 \x01(\x05R\x14refreshTokenDuration\"\x96\x01\n" +
+	"\x17RenewAccessTokenRequest\x12\x1b\n" +
+	"\auser_id\x18\x04 \x01(\tB\x02\x18\x01R\x06userId\x12+\n" +
 	"\rrefresh_token\x18\x01 \x01(\tB\x06\xbaH\x03\xc8\x01\x01R\frefreshToken\x121\n" +
 	"\x0fidempotency_key\x18\x03 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\x0eidempotencyKey\"\x93\x01\n" +
 	"\x18RenewAccessTokenResponse\x12!\n" +
 	"\faccess_token\x18\x04 \x01(\tR\vaccessToken\x12 \n" +
 	"\vfingerprint\x18\x02 \x01(\tR\vfingerprint\x122\n" +
-	"\x15access_token_duration\x18\x01 \x01(\x05R\x13accessTokenDuration2\x81\x03\n" +
-	"\vAuthService\x12C\n" +
+	"\x15access_token_duration\x18\x01 \x01(\x05R\x13accessTokenDuration2\xeb\x03\n" +
+	"\vAuthService\x12[\n" +
 	"\n" +
-	"CreateUser\x12\x18.proto.CreateUserRequest\x1a\x19.proto.CreateUserResponse\"\x00\x12C\n" +
+	"CreateUser\x12\x18.proto.CreateUserRequest\x1a\x19.proto.CreateUserResponse\"\x18\x82\xd3\xe4\x93\x02\x12:\x01*\"\r/api/v1/users\x12C\n" +
 	"\n" +
-	"DeleteUser\x12\x18.proto.DeleteUserRequest\x1a\x19.proto.DeleteUserResponse\"\x00\x124\n" +
-	"\x05Login\x12\x13.proto.LoginRequest\x1a\x14.proto.LoginResponse\"\x00\x12U\n" +
-	"\x10RenewAccessToken\x12\x1e.proto.RenewAccessTokenRequest\x1a\x1f.proto.RenewAccessTokenResponse\"\x00\x12[\n" +
-	"\x12GetUserProfileById\x12 .proto.GetUserProfileByIdRequest\x1a!.proto.GetUserProfileByIdResponse\"\x00BS\n" +
+	"DeleteUser\x12\x18.proto.DeleteUserRequest\x1a\x19.proto.DeleteUserResponse\"\x00\x12Q\n" +
+	"\x05Login\x12\x13.proto.LoginRequest\x1a\x14.proto.LoginResponse\"\x1d\x82\xd3\xe4\x93\x02\x17:\x01*\"\x12/api/v1/auth/login\x12r\n" +
+	"\x10RenewAccessToken\x12\x1e.proto.RenewAccessTokenRequest\x1a\x1f.proto.RenewAccessTokenResponse\"\x1d\x82\xd3\xe4\x93\x02\x17:\x01*\"\x12/api/v1/auth/renew\x12s\n" +
+	"\x12GetUserProfileById\x12 .proto.GetUserProfileByIdRequest\x1a!.proto.GetUserProfileByIdResponse\"\x18\x82\xd3\xe4\x93\x02\x12\x12\x10/api/v1/users/meBS\n" +
 	"\tcom.protoB\tAuthProtoP\x01Z\a.;proto\xa2\x02\x03PXX\xaa\x02\x05Proto\xca\x02\x05Proto\xe2\x02\x11Proto\\GPBMetadata\xea\x02\x05Protob\x06proto3"
 
 var (
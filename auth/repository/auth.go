@@ -7,6 +7,8 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
@@ -189,21 +191,54 @@ func (r *AuthRepository) GetRefreshToken(ctx context.Context, tokenString string
 	return convertToModelRefreshTokenRepo(token), nil
 }
 
-func (r *AuthRepository) GetOrClaimIdempotencyKey(ctx context.Context, idempotencyKey *model.IdempotencyKey) (*model.IdempotencyKey, error) {
-	key, err := r.queries.GetOrClaimIdempotencyKey(ctx, idempotencyKey.KeyID)
+// DeleteExpiredRefreshTokens deletes at most batchSize refresh tokens that expired before expiredBefore, and returns how
+// many it deleted.
+func (r *AuthRepository) DeleteExpiredRefreshTokens(ctx context.Context, expiredBefore time.Time, batchSize int32) (int64, error) {
+	return r.queries.DeleteRefreshTokensByExpiredAt(ctx, sqlc.DeleteRefreshTokensByExpiredAtParams{
+		ExpiredBefore: expiredBefore,
+		BatchSize:     batchSize,
+	})
+}
+
+// GetOrClaimIdempotencyKey claims idempotencyKey for the attempt idempotencyKey.ClaimID, or returns the key as is if
+// another attempt claimed it: the claim is ours if the returned ClaimID is the one we passed.
+// lease is how long the claim holds before another attempt may take over the key if it is still PENDING. It also
+// bounds how long the call waits for a concurrent transaction claiming the same key, after which it fails with the
+// lock_not_available error (55P03). It must be called in a transaction.
+func (r *AuthRepository) GetOrClaimIdempotencyKey(ctx context.Context, idempotencyKey *model.IdempotencyKey, lease time.Duration) (*model.IdempotencyKey, error) {
+	if err := r.queries.SetLockTimeout(ctx, fmt.Sprintf("%dms", lease.Milliseconds())); err != nil {
+		return nil, err
+	}
+	key, err := r.queries.GetOrClaimIdempotencyKey(ctx, sqlc.GetOrClaimIdempotencyKeyParams{
+		KeyID:        idempotencyKey.KeyID,
+		RpcName:      idempotencyKey.RPCName,
+		RequestHash:  idempotencyKey.RequestHash,
+		ClaimID:      uuid.NullUUID{UUID: idempotencyKey.ClaimID, Valid: true},
+		LeaseSeconds: lease.Seconds(),
+	})
 	if err != nil {
 		return nil, err
 	}
+	// the timeout only applies to the claim, the rest of the transaction waits for its locks as usual
+	if err = r.queries.SetLockTimeout(ctx, "0"); err != nil {
+		return nil, err
+	}
 	return &model.IdempotencyKey{
 		KeyID:           key.KeyID,
+		RPCName:         key.RpcName,
+		RequestHash:     key.RequestHash,
+		ClaimID:         key.ClaimID.UUID,
 		Status:          key.Status,
 		ResponseMessage: key.ResponseMessage,
 	}, nil
 }
 
+// UpdateIdempotencyKey sets the outcome of a key claimed by the attempt idempotencyKey.ClaimID.
+// It fails with sql.ErrNoRows if another attempt took the key over.
 func (r *AuthRepository) UpdateIdempotencyKey(ctx context.Context, idempotencyKey *model.IdempotencyKey) (*model.IdempotencyKey, error) {
 	_, err := r.queries.UpdateIdempotencyKey(ctx, sqlc.UpdateIdempotencyKeyParams{
 		KeyID:           idempotencyKey.KeyID,
+		ClaimID:         uuid.NullUUID{UUID: idempotencyKey.ClaimID, Valid: true},
 		Status:          idempotencyKey.Status,
 		ResponseMessage: idempotencyKey.ResponseMessage,
 	})
@@ -220,3 +255,12 @@ func (r *AuthRepository) DeleteIdempotencyKeyByID(ctx context.Context, idempoten
 	}
 	return nil
 }
+
+// DeleteExpiredIdempotencyKeys deletes at most batchSize idempotency keys that expired before expiredBefore, and returns
+// how many it deleted.
+func (r *AuthRepository) DeleteExpiredIdempotencyKeys(ctx context.Context, expiredBefore time.Time, batchSize int32) (int64, error) {
+	return r.queries.DeleteIdempotencyKeysByExpiredAt(ctx, sqlc.DeleteIdempotencyKeysByExpiredAtParams{
+		ExpiredBefore: expiredBefore,
+		BatchSize:     batchSize,
+	})
+}
@@ -1,12 +1,16 @@
 package repository
 
 import (
+	"auth/config"
 	"auth/db/initialize"
 	"auth/db/sqlc"
+	"auth/model"
 	"auth/utils"
 	"context"
 	"fmt"
+	"log"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
@@ -19,7 +23,11 @@ var (
 )
 
 func setupTestDB() func(t *testing.T) {
-	testDB = initialize.ConnectDB()
+	cfg, err := config.LoadDB()
+	if err != nil {
+		log.Fatalf("setupTestDB: %v", err)
+	}
+	testDB = initialize.ConnectDB(cfg)
 	testRepo = NewAuthRepository(testDB)
 
 	// Return a teardown function that will be run after each test
@@ -75,3 +83,48 @@ func TestCreateUser_Success(t *testing.T) {
 	fmt.Println("Passed TestCreateUser_Success")
 	// tx.Commit() // for testing that it does commit if this line runs.
 }
+
+func TestDeleteExpiredRefreshTokens_Success(t *testing.T) {
+	teardown := setupTestDB()
+	defer teardown(t)
+
+	// create a transaction so we can rollback after testing.
+	tx, err := testDB.BeginTx(context.Background(), nil)
+	require.NoError(t, err)
+	defer tx.Rollback()
+	txRepo := testRepo.WithTx(tx)
+
+	createUserArg, err := randomCreateUserParams()
+	require.NoError(t, err)
+	user, err := txRepo.queries.CreateUser(context.Background(), createUserArg)
+	require.NoError(t, err)
+
+	// far enough in the past that no other token of the database expired before
+	expiredBefore := time.Date(2000, 1, 2, 0, 0, 0, 0, time.UTC)
+	for _, expiredAt := range []time.Time{
+		expiredBefore.Add(-3 * time.Hour),
+		expiredBefore.Add(-2 * time.Hour),
+		expiredBefore.Add(-time.Hour),
+		expiredBefore.Add(time.Hour),
+	} {
+		_, err = txRepo.CreateRefreshToken(context.Background(), &model.RefreshTokenRepo{
+			UserID:    user.ID,
+			Token:     utils.RandomString(32),
+			ExpiredAt: expiredAt,
+		})
+		require.NoError(t, err)
+	}
+
+	// at most batchSize tokens are deleted at a time
+	deleted, err := txRepo.DeleteExpiredRefreshTokens(context.Background(), expiredBefore, 2)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), deleted)
+	deleted, err = txRepo.DeleteExpiredRefreshTokens(context.Background(), expiredBefore, 2)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), deleted)
+
+	var remaining int
+	err = tx.QueryRowContext(context.Background(), "SELECT COUNT(*) FROM refresh_tokens WHERE user_id = $1", user.ID).Scan(&remaining)
+	require.NoError(t, err)
+	require.Equal(t, 1, remaining)
+}
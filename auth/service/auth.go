@@ -11,6 +11,9 @@
 package service
 
 import (
+	"auth/config"
+	"auth/internal/idempotency"
+	"auth/internal/txn"
 	"auth/model"
 	"auth/repository"
 	"auth/utils"
@@ -26,19 +29,41 @@ import (
 	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
 	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc/status"
 )
 
+const defaultMaxRetries = 3
+
 type AuthService struct {
-	repo *repository.AuthRepository
-	db   *sqlx.DB
+	repo             *repository.AuthRepository
+	db               *sqlx.DB
+	txRunner         *txn.Runner
+	jwt              config.JWTConfig
+	idempotencyLease time.Duration
 }
 
 // r and db should be created in the main function and passed to the service
 // sqlx.DB object maintains a connection pool internally, and will attempt to connect when a connection is first needed.
-func NewAuthService(repo *repository.AuthRepository, db *sqlx.DB) *AuthService {
+// jwtConfig must have been validated, so that its secret key is already decoded.
+func NewAuthService(repo *repository.AuthRepository, db *sqlx.DB, jwtConfig config.JWTConfig) *AuthService {
+	return &AuthService{
+		repo:             repo,
+		db:               db,
+		txRunner:         txn.NewRunner(db, defaultMaxRetries),
+		jwt:              jwtConfig,
+		idempotencyLease: defaultIdempotencyLease,
+	}
+}
+
+// WithMaxRetries returns a new AuthService that makes at most n attempts for transactions that hit a serialization
+// failure or a deadlock.
+func (s *AuthService) WithMaxRetries(n int) *AuthService {
 	return &AuthService{
-		repo: repo,
-		db:   db,
+		repo:             s.repo,
+		db:               s.db,
+		txRunner:         txn.NewRunner(s.db, n),
+		jwt:              s.jwt,
+		idempotencyLease: s.idempotencyLease,
 	}
 }
 
@@ -53,70 +78,81 @@ func (s *AuthService) GetUserProfileByID(ctx context.Context, userID uuid.UUID)
 }
 
 func (s *AuthService) CreateUser(ctx context.Context, user *model.User, idempotencyKey string) (*model.User, error) {
-	createdUser, err := s.createUserTx(ctx, user, idempotencyKey)
+	var createdUser *model.User
+	err := s.txRunner.Run(ctx, sql.LevelDefault, func(tx *sql.Tx) error {
+		var err error
+		createdUser, err = s.createUserTx(ctx, tx, user, idempotencyKey)
+		return err
+	})
 	if err != nil {
 		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == pgerrcode.UniqueViolation {
 			return nil, model.ErrUserAlreadyExists
 		}
+		if _, ok := status.FromError(err); ok { // already one of the model errors
+			return nil, err
+		}
 		log.Printf("CreateUser: %v", err)
 		return nil, model.ErrInternalServer
 	}
 	return createdUser, nil
 }
 
-func (s *AuthService) createUserTx(ctx context.Context, user *model.User, idempotencyKey string) (*model.User, error) {
-	tx, err := s.db.BeginTx(ctx, nil)
-	if err != nil {
-		log.Printf("createUserTx: failed to beign transaction: %v", err)
-		return nil, model.ErrInternalServer
-	}
-	defer tx.Rollback()
-
+// user isn't modified, so that the transaction can be retried with it
+func (s *AuthService) createUserTx(ctx context.Context, tx *sql.Tx, user *model.User, idempotencyKey string) (*model.User, error) {
 	txRepo := s.repo.WithTx(tx)
 
 	// check if user already exists
-	_, err = txRepo.GetUserByEmail(ctx, user.Email)
+	_, err := txRepo.GetUserByEmail(ctx, user.Email)
 	if err == nil {
 		return user, errors.New("user already exists")
 	}
 
+	requestHash, err := idempotency.RequestHash(struct{ Email string }{user.Email})
+	if err != nil {
+		log.Printf("createUserTx: Failed to hash request: %v\n", err)
+		return nil, model.ErrInternalServer
+	}
+
 	// check if this is a duplicate request
-	// Try to insert idempotency key with status "PENDING".
-	// the statement will block if another concurrent transactional already to inserts the same key, even if it hasn't committed yet.
-	key, err := txRepo.GetOrClaimIdempotencyKey(ctx, &model.IdempotencyKey{
-		KeyID:  idempotencyKey,
-		Status: "PENDING",
+	key, ran, err := s.claimIdempotencyKey(ctx, tx, txRepo, &model.IdempotencyKey{
+		KeyID:       idempotencyKey,
+		RPCName:     "CreateUser",
+		RequestHash: requestHash,
 	})
-	if err == nil {
-		if key.Status != "PENDING" { // "PENDING" implies that we (the current transaction) is the first one to create the idempotency key. Otherwise, we blocked while another transaction inserted the same key.
-			log.Printf("createUserTx: idempotency key already exists: %v\n", key)
-			cachedTransaction := &model.User{}
-			err := json.Unmarshal([]byte(key.ResponseMessage), cachedTransaction)
-			if err != nil {
-				log.Printf("createTransactionTx: Failed to unmarshal transaction: %v\n", err)
-				return nil, model.ErrInternalServer
-			}
-			return cachedTransaction, nil
+	if err != nil {
+		return nil, err
+	}
+	if !ran {
+		cachedTransaction := &model.User{}
+		err := json.Unmarshal([]byte(key.ResponseMessage), cachedTransaction)
+		if err != nil {
+			log.Printf("createUserTx: Failed to unmarshal transaction: %v\n", err)
+			return nil, model.ErrInternalServer
 		}
-	} else {
-		log.Printf("createTransactionTx: Failed to get idempotency key: %v\n", err)
-		return nil, model.ErrInternalServer
+		return cachedTransaction, nil
 	}
 
 	passwordHash, err := utils.HashPassword(user.Password)
 	if err != nil {
 		return nil, model.ErrInternalServer
 	}
-	user.Password = passwordHash
-	user.UserID = uuid.New()
-	user, err = txRepo.CreateUser(ctx, user)
+	createdUser, err := txRepo.CreateUser(ctx, &model.User{
+		UserID:   uuid.New(),
+		Email:    user.Email,
+		Password: passwordHash,
+	})
 	if err != nil {
-		return nil, model.ErrInternalServer
+		log.Printf("createUserTx: Failed to create user: %v\n", err)
+		// a concurrent request registered the same email with another idempotency key
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == pgerrcode.UniqueViolation {
+			return nil, failIdempotencyKey(ctx, tx, txRepo, key, model.ErrUserAlreadyExists)
+		}
+		return nil, model.Internal(err)
 	}
 
 	// Update the idempotency key status
 	key.Status = "COMPLETED"
-	marshalled, err := json.Marshal(user)
+	marshalled, err := json.Marshal(createdUser)
 	if err != nil {
 		log.Printf("createAccountTx: Failed to marshal transaction: %v\n", err)
 		return nil, model.ErrInternalServer
@@ -125,15 +161,10 @@ func (s *AuthService) createUserTx(ctx context.Context, user *model.User, idempo
 
 	if _, err = txRepo.UpdateIdempotencyKey(ctx, key); err != nil {
 		log.Printf("createAccountTx: Failed to create idempotency key: %v\n", err)
-		return nil, model.ErrInternalServer
+		return nil, model.Internal(err)
 	}
 
-	if err = tx.Commit(); err != nil {
-		log.Printf("createAccountTx: Failed to commit transaction: %v\n", err)
-		return nil, model.ErrInternalServer
-	}
-
-	return user, nil
+	return createdUser, nil
 }
 
 func (s *AuthService) UpdateUser(ctx context.Context, user *model.User) (*model.User, error) {
@@ -175,72 +206,79 @@ func (s *AuthService) DeleteUser(ctx context.Context, userID uuid.UUID, targetUs
 }
 
 func (s *AuthService) Login(ctx context.Context, user *model.User, idempotencyKey string) (*model.LoginResult, error) {
-	tx, err := s.db.BeginTx(ctx, nil)
+	var res *model.LoginResult
+	err := s.txRunner.Run(ctx, sql.LevelDefault, func(tx *sql.Tx) error {
+		var err error
+		res, err = s.loginTx(ctx, tx, user.Email, user.Password, idempotencyKey)
+		return err
+	})
 	if err != nil {
-		log.Printf("Login: failed to beign transaction: %v", err)
-		return nil, model.ErrInternalServer
+		return nil, err
 	}
-	defer tx.Rollback()
+	return res, nil
+}
 
+func (s *AuthService) loginTx(ctx context.Context, tx *sql.Tx, email string, password string, idempotencyKey string) (*model.LoginResult, error) {
 	txRepo := s.repo.WithTx(tx)
 
-	password := user.Password
-	user, err = txRepo.GetUserByEmail(ctx, user.Email)
+	user, err := txRepo.GetUserByEmail(ctx, email)
 	if err != nil {
 		log.Printf("Login: failed to get user: %v", err)
 		return nil, model.ErrNotAuthenticated
 	}
 
-	// user variable has been overwritten by the value fetched from db, so the field Password should contain the stored hash
+	// user was fetched from db, so the field Password contains the stored hash
 	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
 		log.Printf("Login: password hash mismatch for user %v: %v", user.Email, err)
 		return nil, model.ErrNotAuthenticated
 	}
 
-	accessToken, err := utils.RandomAccessToken(user.UserID)
+	accessToken, err := utils.RandomAccessToken(user.UserID, s.jwt.Key(), s.jwt.AccessTokenDuration)
 	if err != nil {
 		log.Printf("Login: %v", err)
 		return nil, model.ErrInternalServer
 	}
 
+	requestHash, err := idempotency.RequestHash(struct{ Email string }{user.Email})
+	if err != nil {
+		log.Printf("Login: Failed to hash request: %v\n", err)
+		return nil, model.ErrInternalServer
+	}
+
 	// check if this is a duplicate request. If so, we shouldn't genereate another refresh token
-	// Try to insert idempotency key with status "PENDING".
-	// the statement will block if another concurrent transactional already to inserts the same key, even if it hasn't committed yet.
-	key, err := txRepo.GetOrClaimIdempotencyKey(ctx, &model.IdempotencyKey{
-		KeyID:  idempotencyKey,
-		Status: "PENDING",
+	key, ran, err := s.claimIdempotencyKey(ctx, tx, txRepo, &model.IdempotencyKey{
+		KeyID:       idempotencyKey,
+		RPCName:     "Login",
+		RequestHash: requestHash,
 	})
-	if err == nil {
-		if key.Status != "PENDING" { // "PENDING" implies that we (the current transaction) is the first one to create the idempotency key. Otherwise, we blocked while another transaction inserted the same key.
-			log.Printf("Login: idempotency key already exists: %v\n", key)
-			cachedTransaction := &model.LoginResult{}
-			err := json.Unmarshal([]byte(key.ResponseMessage), cachedTransaction)
-			if err != nil {
-				log.Printf("Login: Failed to unmarshal transaction: %v\n", err)
-				return nil, model.ErrInternalServer
-			}
-			return cachedTransaction, nil
+	if err != nil {
+		return nil, err
+	}
+	if !ran {
+		cachedTransaction := &model.LoginResult{}
+		err := json.Unmarshal([]byte(key.ResponseMessage), cachedTransaction)
+		if err != nil {
+			log.Printf("Login: Failed to unmarshal transaction: %v\n", err)
+			return nil, model.ErrInternalServer
 		}
-	} else {
-		log.Printf("Login: Failed to get idempotency key: %v\n", err)
-		return nil, model.ErrInternalServer
+		return cachedTransaction, nil
 	}
 
-	refreshToken, err := utils.RandomRefreshToken()
+	refreshToken, err := utils.RandomRefreshToken(s.jwt.RefreshTokenDuration)
 	if err != nil {
 		log.Printf("Login: %v", err)
 		return nil, model.ErrInternalServer
 	}
 
-	// Store refresh token in db
-	_, err = s.repo.CreateRefreshToken(ctx, &model.RefreshTokenRepo{
+	// Store refresh token in db, in the transaction so that no refresh token outlives a failed login
+	_, err = txRepo.CreateRefreshToken(ctx, &model.RefreshTokenRepo{
 		UserID:    user.UserID,
 		Token:     refreshToken.Token,
 		ExpiredAt: time.Now().Add(time.Duration(refreshToken.Duration)),
 	})
 	if err != nil {
 		log.Printf("Login: failed to store refresh token in db: %v", err)
-		return nil, model.ErrInternalServer
+		return nil, model.Internal(err)
 	}
 
 	ret := &model.LoginResult{
@@ -263,12 +301,7 @@ func (s *AuthService) Login(ctx context.Context, user *model.User, idempotencyKe
 
 	if _, err = txRepo.UpdateIdempotencyKey(ctx, key); err != nil {
 		log.Printf("Login: Failed to create idempotency key: %v\n", err)
-		return nil, model.ErrInternalServer
-	}
-
-	if err = tx.Commit(); err != nil {
-		log.Printf("Login: Failed to commit transaction: %v\n", err)
-		return nil, model.ErrInternalServer
+		return nil, model.Internal(err)
 	}
 
 	return ret, nil
@@ -280,7 +313,7 @@ func (s *AuthService) RenewAccessToken(ctx context.Context, userID uuid.UUID, re
 	if err != nil {
 		log.Printf("RenewAccessToken: %v", err)
 		if err == sql.ErrNoRows {
-			return nil, model.ErrNotAuthorized
+			return nil, model.ErrInvalidRefreshToken
 		}
 		return nil, model.ErrInternalServer
 	}
@@ -295,49 +328,60 @@ func (s *AuthService) RenewAccessToken(ctx context.Context, userID uuid.UUID, re
 	// check userID of refresh_token is the same as the requesting userID
 	if user.UserID != token.UserID {
 		log.Printf("RenewAccessToken: Unauthorized attempt to renew token for user %v from user %v", userID, token.UserID)
-		return nil, model.ErrNotAuthorized
+		return nil, model.ErrInvalidRefreshToken
 	}
 
 	// check refresh_token expiration time
 	if time.Now().After(token.ExpiredAt) {
-		return nil, model.ErrNotAuthenticated
+		return nil, model.ErrInvalidRefreshToken
 	}
 
-	// begin a transaction
-	tx, err := s.db.BeginTx(ctx, nil)
+	var res *model.AccessToken
+	err = s.txRunner.Run(ctx, sql.LevelDefault, func(tx *sql.Tx) error {
+		var err error
+		res, err = s.renewAccessTokenTx(ctx, tx, user, refresh_token, idempotencyKey)
+		return err
+	})
 	if err != nil {
-		log.Printf("RenewAccessToken: failed to beign transaction: %v", err)
-		return nil, model.ErrInternalServer
+		return nil, err
 	}
-	defer tx.Rollback()
+	return res, nil
+}
 
+// user is the owner of refresh_token, checked by RenewAccessToken
+func (s *AuthService) renewAccessTokenTx(ctx context.Context, tx *sql.Tx, user *model.User, refresh_token string, idempotencyKey string) (*model.AccessToken, error) {
 	txRepo := s.repo.WithTx(tx)
 
+	requestHash, err := idempotency.RequestHash(struct {
+		UserID       uuid.UUID
+		RefreshToken string
+	}{user.UserID, refresh_token})
+	if err != nil {
+		log.Printf("RenewAccessToken: Failed to hash request: %v\n", err)
+		return nil, model.ErrInternalServer
+	}
+
 	// check if this is a duplicate request. If so, we shouldn't genereate another refresh token
-	// Try to insert idempotency key with status "PENDING".
-	// the statement will block if another concurrent transactional already to inserts the same key, even if it hasn't committed yet.
-	key, err := txRepo.GetOrClaimIdempotencyKey(ctx, &model.IdempotencyKey{
-		KeyID:  idempotencyKey,
-		Status: "PENDING",
+	key, ran, err := s.claimIdempotencyKey(ctx, tx, txRepo, &model.IdempotencyKey{
+		KeyID:       idempotencyKey,
+		RPCName:     "RenewAccessToken",
+		RequestHash: requestHash,
 	})
-	if err == nil {
-		if key.Status != "PENDING" { // "PENDING" implies that we (the current transaction) is the first one to create the idempotency key. Otherwise, we blocked while another transaction inserted the same key.
-			log.Printf("RenewAccessToken: idempotency key already eagexists: %v\n", key)
-			cachedTransaction := &model.AccessToken{}
-			err := json.Unmarshal([]byte(key.ResponseMessage), cachedTransaction)
-			if err != nil {
-				log.Printf("RenewAccessToken: Failed to unmarshal transaction: %v\n", err)
-				return nil, model.ErrInternalServer
-			}
-			return cachedTransaction, nil
+	if err != nil {
+		return nil, err
+	}
+	if !ran {
+		cachedTransaction := &model.AccessToken{}
+		err := json.Unmarshal([]byte(key.ResponseMessage), cachedTransaction)
+		if err != nil {
+			log.Printf("RenewAccessToken: Failed to unmarshal transaction: %v\n", err)
+			return nil, model.ErrInternalServer
 		}
-	} else {
-		log.Printf("RenewAccessToken: Failed to get idempotency key: %v\n", err)
-		return nil, model.ErrInternalServer
+		return cachedTransaction, nil
 	}
 
 	// generate a new access token
-	accessToken, err := utils.RandomAccessToken(user.UserID)
+	accessToken, err := utils.RandomAccessToken(user.UserID, s.jwt.Key(), s.jwt.AccessTokenDuration)
 	if err != nil {
 		log.Printf("RenewAccessToken: %v", err)
 		return nil, model.ErrInternalServer
@@ -353,12 +397,7 @@ func (s *AuthService) RenewAccessToken(ctx context.Context, userID uuid.UUID, re
 
 	if _, err = txRepo.UpdateIdempotencyKey(ctx, key); err != nil {
 		log.Printf("RenewAccessToken: Failed to create idempotency key: %v\n", err)
-		return nil, model.ErrInternalServer
-	}
-
-	if err = tx.Commit(); err != nil {
-		log.Printf("RenewAccessToken: Failed to commit transaction: %v\n", err)
-		return nil, model.ErrInternalServer
+		return nil, model.Internal(err)
 	}
 
 	return accessToken, nil
@@ -2,9 +2,7 @@ package utils
 
 import (
 	"auth/model"
-	"encoding/base64"
 	"fmt"
-	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -12,15 +10,10 @@ import (
 	"github.com/pkg/errors"
 )
 
-func RandomAccessToken(userID uuid.UUID) (*model.AccessToken, error) {
-	// Get JWT Secret Key from environment variable
-	tmp := os.Getenv("JWT_SECRET_KEY")
-	if tmp == "" {
-		return nil, errors.New("RandomAccessToken: JWT secret key is not set in environment variables")
-	}
-	jwtSecretKey, err := base64.StdEncoding.DecodeString(tmp)
-	if err != nil {
-		return nil, errors.Errorf("RandomAccessToken: failed to decode JWT secret key: %v", err)
+// jwtSecretKey is the decoded key from config.JWTConfig.Key(), and duration is the lifetime of the token.
+func RandomAccessToken(userID uuid.UUID, jwtSecretKey []byte, duration time.Duration) (*model.AccessToken, error) {
+	if len(jwtSecretKey) == 0 {
+		return nil, errors.New("RandomAccessToken: JWT secret key is not set")
 	}
 
 	// Generate fingerprint for JWT
@@ -33,7 +26,7 @@ func RandomAccessToken(userID uuid.UUID) (*model.AccessToken, error) {
 	fingerprintHash := HashSha256(fingerprintValue)
 
 	// Prepare JWT Claims
-	expirationTime := time.Now().Add(model.TokenShortDuration)
+	expirationTime := time.Now().Add(duration)
 	claim := &model.JWTClaim{
 		FingerprintHash: fingerprintHash,
 		RegisteredClaims: jwt.RegisteredClaims{
@@ -55,11 +48,11 @@ func RandomAccessToken(userID uuid.UUID) (*model.AccessToken, error) {
 	return &model.AccessToken{
 		Token:       signedAccessToken,
 		Fingerprint: fingerprintValue,
-		Duration:    int(model.TokenShortDuration),
+		Duration:    int(duration),
 	}, nil
 }
 
-func RandomRefreshToken() (*model.RefreshToken, error) {
+func RandomRefreshToken(duration time.Duration) (*model.RefreshToken, error) {
 	// generate refresh token
 	refreshToken, err := GenerateSecureRandomString(32) // 32 bytes gives 43 URL-safe characters
 	if err != nil {
@@ -68,7 +61,7 @@ func RandomRefreshToken() (*model.RefreshToken, error) {
 
 	return &model.RefreshToken{
 		Token:    refreshToken,
-		Duration: int(model.RefreshTokenDuration),
+		Duration: int(duration),
 	}, nil
 }
 
@@ -21,18 +21,18 @@ set -e
 # =========== CREATE N_USERS with accounts + JWTs =================
 for i in $(seq 1 $N_USERS); do
     EMAIL="testuser$i@example.com"
-    PASSWORD="pass"
+    PASSWORD="password"
 
     # Register
     ikey=$(uuidgen) # idempotency key
-    curl -s -X POST "$API_GW/api/register" \
+    curl -s -X POST "$API_GW/api/v1/users" \
         -H "Idempotency-Key: $ikey" \
         -H "Content-Type: application/json" \
         -d "{\"email\": \"$EMAIL\", \"password\": \"$PASSWORD\"}" >/dev/null
 
     # Login to get JWT and fingerprint
     ikey=$(uuidgen) # idempotency key
-    LOGIN_RES=$(curl -i -s -X POST "$API_GW/api/login" \
+    LOGIN_RES=$(curl -i -s -X POST "$API_GW/api/v1/auth/login" \
         -H "Idempotency-Key: $ikey" \
         -H "Content-Type: application/json" \
         -d "{\"email\": \"$EMAIL\", \"password\": \"$PASSWORD\"}")
@@ -53,12 +53,12 @@ for i in $(seq 1 $N_USERS); do
 
     # Create account
     ikey=$(uuidgen) # idempotency key
-    ACC_RES=$(curl -s -X POST "$API_GW/api/create-account" \
+    ACC_RES=$(curl -s -X POST "$API_GW/api/v1/accounts" \
         -H "Idempotency-Key: $ikey" \
         -H "Authorization: Bearer $JWT" \
         -b "fingerprint=$FINGERPRINT" \
         -H "Content-Type: application/json" \
-        -d "{\"balance\":10$i}")
+        -d "{\"balance\":{\"currency\":\"USD\",\"units\":\"10$i\"}}")
 
     # echo "ACC_RES: $ACC_RES"
 
@@ -75,7 +75,7 @@ done
 while IFS='|' read -r JWT FP ACC_ID ACC_NUM; do
     for _ in $(seq 1 $READS_PER_USER); do
         cat <<EOF >>$READ_TARGETS
-GET $API_GW/api/account?accountID=$ACC_ID
+GET $API_GW/api/v1/accounts/$ACC_ID
 Authorization: Bearer $JWT
 Cookie: fingerprint=$FP
 
@@ -89,13 +89,13 @@ while IFS='|' read -r JWT FP ACC_ID ACC_NUM; do
     body_file="./json_body/deposit_$ACC_ID.json"
 
     cat >$body_file <<EOF
-{"accountId":"$ACC_ID","amount":100,"transactionType":"CREDIT"}
+{"amount":{"currency":"USD","units":"100"},"transactionType":"CREDIT"}
 EOF
 
     for _ in $(seq 1 $DEPOSITS_PER_USER); do
         ikey=$(uuidgen)
         cat <<EOF >>$DEPOSIT_TARGETS
-POST $API_GW/api/create-transaction
+POST $API_GW/api/v1/accounts/$ACC_ID/transactions
 Authorization: Bearer $JWT
 Cookie: fingerprint=$FP
 Idempotency-Key: $ikey
@@ -110,7 +110,7 @@ done <$JWT_FILE
 echo ""
 echo "=== CACHE WARM UP ==="
 while IFS='|' read -r JWT FP ACC_ID ACC_NUM; do
-    curl -s -o /dev/null -H "Authorization: Bearer $JWT" -b"fingerprint=$FP" "$API_GW/api/account?accountID=$ACC_ID" &
+    curl -s -o /dev/null -H "Authorization: Bearer $JWT" -b"fingerprint=$FP" "$API_GW/api/v1/accounts/$ACC_ID" &
 done <$JWT_FILE
 
 echo ""
@@ -1,11 +1,58 @@
 import type { LoginCredentials, LoginResponse, AuthTokens, User } from '$lib/types/auth';
 import type { Account, CreateTransactionRequest, Transaction } from '$lib/types/account';
 
-const API_BASE_URL = 'http://localhost:18000/api';
+const API_BASE_URL = 'http://localhost:18000/api/v1';
 
-const PROTECTED_ENDPOINTS = ['/profile', 'create-account', '/all-accounts', '/account', '/delete-account', 'create-transaction', 'transactions'];
+// Currency of the amounts sent without one, the same default as the account service
+const DEFAULT_CURRENCY = 'USD';
+
+// Every endpoint but these requires an access token
+const PUBLIC_ENDPOINTS = ['/users', '/auth/login'];
 function isProtectedEndpoint(endpoint: string): boolean {
-    return PROTECTED_ENDPOINTS.some(protectedEndpoint => endpoint.includes(protectedEndpoint));
+    return !PUBLIC_ENDPOINTS.includes(endpoint);
+}
+
+// Wire format of the /api/v1 responses: int64 fields, such as account numbers and amounts, are JSON strings
+interface ApiMoney {
+    currency: string;
+    units: string;
+}
+
+interface ApiAccount {
+    accountId: string;
+    accountNumber: string;
+    balance?: ApiMoney;
+}
+
+interface ApiTransaction {
+    transactionId: string;
+    accountId: string;
+    timestamp: string;
+    transactionType: string;
+    status: string;
+    transferId: string;
+    amount?: ApiMoney;
+}
+
+function toAccount(account: ApiAccount): Account {
+    return {
+        accountId: account.accountId,
+        accountNumber: account.accountNumber,
+        balance: Number(account.balance?.units ?? 0),
+        currency: account.balance?.currency ?? DEFAULT_CURRENCY,
+    };
+}
+
+function toTransaction(transaction: ApiTransaction): Transaction {
+    return {
+        transactionId: transaction.transactionId,
+        accountId: transaction.accountId,
+        amount: Number(transaction.amount?.units ?? 0),
+        timestamp: Number(transaction.timestamp),
+        transactionType: transaction.transactionType,
+        status: transaction.status,
+        ...(transaction.transferId && { transferId: transaction.transferId }),
+    };
 }
 
 // Token provider interface for dependency injection.
@@ -74,23 +121,25 @@ export const api = {
     },
 
     async login(credentials: LoginCredentials): Promise<LoginResponse> {
-        return fetchApi<LoginResponse>('/login', {
+        const res = await fetchApi<Omit<LoginResponse, 'email'>>('/auth/login', {
             method: 'POST',
             body: JSON.stringify(credentials),
         });
+        return { ...res, email: credentials.email };
     },
 
-    async register(credentials: LoginCredentials): Promise<LoginResponse> {
-        return fetchApi<LoginResponse>('/register', {
+    async register(credentials: LoginCredentials): Promise<{ userId: string }> {
+        return fetchApi<{ userId: string }>('/users', {
             method: 'POST',
             body: JSON.stringify(credentials),
         });
     },
 
+    // the access token is sent in the Authorization header, and the new fingerprint comes back in a cookie
     async renewToken(authTokens: AuthTokens): Promise<{ accessToken: string }> {
-        return fetchApi<{ accessToken: string }>('/renew-token', {
+        return fetchApi<{ accessToken: string }>('/auth/renew', {
             method: 'POST',
-            body: JSON.stringify(authTokens),
+            body: JSON.stringify({ refreshToken: authTokens.refreshToken }),
         });
     },
 
@@ -103,48 +152,55 @@ export const api = {
     },
 
     async getProfile(): Promise<User> {
-        return fetchApi<User>('/profile', {
+        const res = await fetchApi<{ profile?: { email: string } }>('/users/me', {
             method: 'GET',
-        })
+        });
+        return { email: res.profile?.email ?? '' } as User;
     },
 
     async createAccount(balance: number): Promise<Account> {
-        return fetchApi<Account>('/create-account', {
+        const res = await fetchApi<{ accountId: string; accountNumber: string }>('/accounts', {
             method: 'POST',
-            body: JSON.stringify({ balance }),
-        })
+            body: JSON.stringify({ balance: { currency: DEFAULT_CURRENCY, units: String(balance) } }),
+        });
+        return { ...res, balance, currency: DEFAULT_CURRENCY };
     },
 
     async getAccounts(): Promise<{ accounts: Account[] }> {
-        return fetchApi<{ accounts: Account[] }>('/all-accounts', {
+        const res = await fetchApi<{ accounts?: ApiAccount[] }>('/accounts', {
             method: 'GET',
-        })
+        });
+        return { accounts: (res.accounts ?? []).map(toAccount) };
     },
 
-    async getAccount(accountNumber: string): Promise<{account : Account}> {
-        return fetchApi<{account : Account}>(`/account?accountNumber=${encodeURIComponent(accountNumber)}`, {
+    async getAccount(accountNumber: string): Promise<Account> {
+        const res = await fetchApi<ApiAccount>(`/account-numbers/${encodeURIComponent(accountNumber)}`, {
             method: 'GET',
-        })
+        });
+        return toAccount(res);
     },
 
-    async deleteAccount(accountNumber: string): Promise<{ success: boolean }> {
-        return fetchApi<{ success: boolean }>('/delete-account', {
+    async deleteAccount(accountNumber: string): Promise<void> {
+        await fetchApi<object>(`/account-numbers/${encodeURIComponent(accountNumber)}`, {
             method: 'DELETE',
-            body: JSON.stringify({ 'accountNumber': accountNumber })
-        })
+        });
     },
 
     async createTransaction(transaction: CreateTransactionRequest): Promise<{ transactionId: string }> {
-        return fetchApi<{ transactionId: string }>('/create-transaction', {
+        return fetchApi<{ transactionId: string }>(`/accounts/${encodeURIComponent(transaction.accountId)}/transactions`, {
             method: 'POST',
-            body: JSON.stringify(transaction),
+            body: JSON.stringify({
+                transactionType: transaction.transactionType,
+                amount: { currency: transaction.currency ?? DEFAULT_CURRENCY, units: String(transaction.amount) },
+            }),
         });
     },
 
     async getTransactionsByAccountId(accountId: string): Promise<Transaction[]> {
-        return fetchApi<Transaction[]>(`/transactions?accountId=${encodeURIComponent(accountId)}`, {
+        const res = await fetchApi<{ transactions?: ApiTransaction[] }>(`/accounts/${encodeURIComponent(accountId)}/transactions`, {
             method: 'GET',
         });
+        return (res.transactions ?? []).map(toTransaction);
     }
 };
 
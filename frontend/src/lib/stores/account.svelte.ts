@@ -53,8 +53,7 @@ class AccountStore {
         this.error = null;
 
         try {
-            const resp = await api.getAccount(accountNumber);
-            return resp.account;
+            return await api.getAccount(accountNumber);
         } catch (err) {
             this.error = err instanceof Error ? err.message : 'Failed to fetch account';
             throw err;
@@ -68,12 +67,10 @@ class AccountStore {
         this.error = null;
         
         try {
-            const result = await api.deleteAccount(accountNumber);
-            if (result.success) {
-                // Refresh accounts list after deletion
-                await this.fetchAllAccounts();
-            }
-            return result;
+            await api.deleteAccount(accountNumber);
+            // Refresh accounts list after deletion
+            await this.fetchAllAccounts();
+            return { success: true };
         } catch (error) {
             this.error = error instanceof Error ? error.message : 'Failed to delete account';
             throw error;
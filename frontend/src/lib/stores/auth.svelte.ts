@@ -146,8 +146,9 @@ class AuthStore {
                 console.warn('One of access token, refresh token, or fingerprint is missing, cannot renew');
                 return false;
             }
-            await api.renewToken(tokens);
-            // Token is renewed via cookies, no need to handle response
+            const response = await api.renewToken(tokens);
+            // the new fingerprint is set in a cookie, and bound to the new access token
+            this.setAccessToken(response.accessToken);
             return true;
         } catch (error) {
             console.error('Token renewal failed:', error);
@@ -5,6 +5,7 @@ export interface Account {
     accountId: string;
     accountNumber: string;
     balance: number;
+    currency: string;
 }
 
 export interface Transaction {
@@ -20,6 +21,7 @@ export interface Transaction {
 export interface CreateTransactionRequest {
     accountId: string;
     amount: number;
+    currency?: string; // the default currency if not set
     transactionType: 'DEPOSIT' | 'WITHDRAWAL' | 'TRANSFER_CREDIT' | 'TRANSFER_DEBIT';
 }
 
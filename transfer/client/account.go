@@ -0,0 +1,63 @@
+package client
+
+import (
+	"account/proto"
+	"context"
+	"transfer/config"
+	"transfer/internal/identity"
+	"transfer/internal/mtls"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+// onBehalfOfKey is the metadata key of the user the transfer service acts for when there is no access token.
+const onBehalfOfKey = "x-on-behalf-of"
+
+// AccountClient calls the account service to move money between accounts.
+// The account service only accepts TRANSFER_DEBIT and TRANSFER_CREDIT transactions, and the holds of TRANSFER_DEBIT
+// ones, from the transfer service, which it recognizes by the identity in our client certificate.
+// Every call carries the access token of the request being served, since the account service authorizes the user too.
+// Only the transfer service may read and credit the destination account of a transfer, which may belong to another
+// user.
+type AccountClient struct {
+	proto.AccountServiceClient
+	conn *grpc.ClientConn
+}
+
+func NewAccountClient(connString string, tlsCfg config.TLSConfig) (*AccountClient, error) {
+	var creds credentials.TransportCredentials = insecure.NewCredentials()
+	if tlsCfg.Enabled() {
+		var err error
+		if creds, err = mtls.ClientCredentials(tlsCfg, mtls.AccountService); err != nil {
+			return nil, err
+		}
+	}
+	conn, err := grpc.NewClient(connString, grpc.WithTransportCredentials(creds), grpc.WithUnaryInterceptor(forwardAuthorization))
+	if err != nil {
+		return nil, err
+	}
+	return &AccountClient{proto.NewAccountServiceClient(conn), conn}, nil
+}
+
+func (c *AccountClient) Close() error {
+	return c.conn.Close()
+}
+
+// OnBehalfOf returns a context whose calls act for the user userID, for the work done without a request of the user,
+// such as executing standing orders. The account service only trusts it from the transfer service.
+func OnBehalfOf(ctx context.Context, userID uuid.UUID) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, onBehalfOfKey, userID.String())
+}
+
+// forwardAuthorization copies the access token of the incoming request, if any, to the outgoing call.
+func forwardAuthorization(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(identity.MetadataKey); len(values) > 0 {
+		ctx = metadata.AppendToOutgoingContext(ctx, identity.MetadataKey, values[0])
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
@@ -0,0 +1,249 @@
+// Package config loads and validates the configuration of the transfer microservice.
+//
+// Values are resolved in order of increasing precedence:
+//  1. the defaults returned by Default()
+//  2. the optional YAML file pointed to by the CONFIG_FILE environment variable
+//  3. environment variables
+//
+// Load fails if a required field is missing or a value is malformed, so a misconfigured
+// service refuses to start instead of silently falling back to a default.
+package config
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+type Config struct {
+	GRPCPort          int    `yaml:"grpc_port"`
+	AccountServiceURL string `yaml:"account_service_url"` // gRPC address (host:port) of the account service
+
+	TLS       TLSConfig       `yaml:"tls"`
+	JWT       JWTConfig       `yaml:"jwt"`
+	DB        DBConfig        `yaml:"db"`
+	FX        FXConfig        `yaml:"fx"`
+	Scheduler SchedulerConfig `yaml:"scheduler"`
+}
+
+// SchedulerConfig sets how the standing orders are executed, and the transfers left PENDING finished.
+type SchedulerConfig struct {
+	Interval     time.Duration `yaml:"interval"`      // between runs. 0 disables the scheduler.
+	BatchSize    int           `yaml:"batch_size"`    // orders or executions claimed per statement
+	MaxAttempts  int           `yaml:"max_attempts"`  // per occurrence, before it fails and is notified
+	RetryBackoff time.Duration `yaml:"retry_backoff"` // multiplied by the number of attempts so far
+	Lease        time.Duration `yaml:"lease"`         // after which an attempt that didn't record its outcome is retried
+	// where the failed executions are posted as JSON, see notify.WebhookNotifier. Empty to only log them.
+	FailureWebhookURL string `yaml:"failure_webhook_url"`
+}
+
+// FXConfig sets how the transfers between accounts of different currencies are priced.
+type FXConfig struct {
+	// YAML file of the mid-market rates, see fx.LoadStaticProvider. Empty to only allow same-currency transfers.
+	RatesFile string        `yaml:"rates_file"`
+	SpreadBps int           `yaml:"spread_bps"` // margin taken on the mid rate, in basis points
+	QuoteTTL  time.Duration `yaml:"quote_ttl"`  // how long a quote can be used
+}
+
+// TLSConfig points to the PEM files of the mTLS credentials shared by the internal services.
+// TLS is disabled when all of them are empty, which is only meant for local development.
+type TLSConfig struct {
+	CAFile   string `yaml:"ca_file"`
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+}
+
+// JWTConfig holds the key used to verify the access tokens forwarded by the API Gateway.
+type JWTConfig struct {
+	SecretKey string `yaml:"secret_key"` // base64 encoded HMAC key shared with the auth service
+
+	key []byte // SecretKey decoded once by Validate
+}
+
+type DBConfig struct {
+	Host     string `yaml:"host"`
+	Port     string `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	Name     string `yaml:"name"`
+	SSLMode  string `yaml:"ssl_mode"`
+}
+
+func Default() *Config {
+	return &Config{
+		GRPCPort: 50003,
+		DB: DBConfig{
+			SSLMode: "disable",
+		},
+		FX: FXConfig{
+			SpreadBps: 50,
+			QuoteTTL:  30 * time.Second,
+		},
+		Scheduler: SchedulerConfig{
+			Interval:     time.Minute,
+			BatchSize:    100,
+			MaxAttempts:  3,
+			RetryBackoff: time.Hour,
+			Lease:        5 * time.Minute,
+		},
+	}
+}
+
+// Load builds the config from the defaults, the optional CONFIG_FILE and the environment, then validates it.
+func Load() (*Config, error) {
+	cfg := Default()
+
+	var env envLoader
+	var path string
+	env.string(&path, "CONFIG_FILE")
+	if path != "" {
+		if err := loadFile(path, cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	env.int(&cfg.GRPCPort, "GRPC_PORT")
+	env.string(&cfg.AccountServiceURL, "ACCOUNT_SERVICE_URL")
+	env.string(&cfg.TLS.CAFile, "TLS_CA_FILE")
+	env.string(&cfg.TLS.CertFile, "TLS_CERT_FILE")
+	env.string(&cfg.TLS.KeyFile, "TLS_KEY_FILE")
+	env.string(&cfg.JWT.SecretKey, "JWT_SECRET_KEY")
+	cfg.DB.loadEnv(&env)
+	env.string(&cfg.FX.RatesFile, "FX_RATES_FILE")
+	env.int(&cfg.FX.SpreadBps, "FX_SPREAD_BPS")
+	env.duration(&cfg.FX.QuoteTTL, "FX_QUOTE_TTL")
+	env.duration(&cfg.Scheduler.Interval, "SCHEDULER_INTERVAL")
+	env.int(&cfg.Scheduler.BatchSize, "SCHEDULER_BATCH_SIZE")
+	env.int(&cfg.Scheduler.MaxAttempts, "SCHEDULER_MAX_ATTEMPTS")
+	env.duration(&cfg.Scheduler.RetryBackoff, "SCHEDULER_RETRY_BACKOFF")
+	env.duration(&cfg.Scheduler.Lease, "SCHEDULER_LEASE")
+	env.string(&cfg.Scheduler.FailureWebhookURL, "SCHEDULER_FAILURE_WEBHOOK_URL")
+
+	if err := errors.Join(env.errs...); err != nil {
+		return nil, fmt.Errorf("invalid environment: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	return cfg, nil
+}
+
+// LoadDB loads only the database section. It is meant for integration tests that don't need the rest of the service.
+func LoadDB() (DBConfig, error) {
+	cfg := Default().DB
+	var env envLoader
+	cfg.loadEnv(&env)
+	if err := errors.Join(env.errs...); err != nil {
+		return cfg, err
+	}
+	return cfg, cfg.Validate()
+}
+
+func (c *DBConfig) loadEnv(env *envLoader) {
+	env.string(&c.Host, "TRANSFER_DB_HOST")
+	env.string(&c.Port, "TRANSFER_DB_PORT")
+	env.string(&c.User, "TRANSFER_DB_USER")
+	env.string(&c.Password, "TRANSFER_DB_PASSWORD")
+	env.string(&c.Name, "TRANSFER_DB_NAME")
+	env.string(&c.SSLMode, "TRANSFER_DB_SSL_MODE")
+}
+
+func (c *Config) Validate() error {
+	var errs []error
+	errs = append(errs, validatePort("grpc_port", c.GRPCPort))
+	if c.AccountServiceURL == "" {
+		errs = append(errs, errors.New("account_service_url is required"))
+	}
+	errs = append(errs, c.TLS.Validate(), c.JWT.Validate(), c.DB.Validate(), c.FX.Validate(), c.Scheduler.Validate())
+	return errors.Join(errs...)
+}
+
+func (c *FXConfig) Validate() error {
+	var errs []error
+	if c.SpreadBps < 0 || c.SpreadBps >= 10_000 {
+		errs = append(errs, fmt.Errorf("fx.spread_bps: %d out of range [0, 10000)", c.SpreadBps))
+	}
+	if c.QuoteTTL <= 0 {
+		errs = append(errs, errors.New("fx.quote_ttl must be positive"))
+	}
+	return errors.Join(errs...)
+}
+
+func (c *SchedulerConfig) Validate() error {
+	var errs []error
+	if c.Interval < 0 {
+		errs = append(errs, errors.New("scheduler.interval must not be negative"))
+	}
+	if c.BatchSize < 1 {
+		errs = append(errs, errors.New("scheduler.batch_size must be at least 1"))
+	}
+	if c.MaxAttempts < 1 {
+		errs = append(errs, errors.New("scheduler.max_attempts must be at least 1"))
+	}
+	if c.RetryBackoff < 0 {
+		errs = append(errs, errors.New("scheduler.retry_backoff must not be negative"))
+	}
+	if c.Lease <= 0 {
+		errs = append(errs, errors.New("scheduler.lease must be positive"))
+	}
+	if c.FailureWebhookURL != "" {
+		if u, err := url.Parse(c.FailureWebhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("scheduler.failure_webhook_url: %q is not an http(s) URL", c.FailureWebhookURL))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c *TLSConfig) Enabled() bool {
+	return c.CAFile != "" || c.CertFile != "" || c.KeyFile != ""
+}
+
+func (c *TLSConfig) Validate() error {
+	if c.Enabled() && (c.CAFile == "" || c.CertFile == "" || c.KeyFile == "") {
+		return errors.New("tls.ca_file, tls.cert_file and tls.key_file must be set together")
+	}
+	return nil
+}
+
+// Validate also decodes the secret key, so Key() is only usable after a successful Validate.
+func (c *JWTConfig) Validate() error {
+	if c.SecretKey == "" {
+		return errors.New("jwt.secret_key is required")
+	}
+	key, err := base64.StdEncoding.DecodeString(c.SecretKey)
+	if err != nil {
+		return errors.New("jwt.secret_key must be base64 encoded")
+	}
+	c.key = key
+	return nil
+}
+
+// Key returns the decoded HMAC key used to verify access tokens.
+func (c *JWTConfig) Key() []byte {
+	return c.key
+}
+
+func (c *DBConfig) Validate() error {
+	var errs []error
+	required := []struct{ name, value string }{
+		{"db.host", c.Host},
+		{"db.port", c.Port},
+		{"db.user", c.User},
+		{"db.password", c.Password},
+		{"db.name", c.Name},
+	}
+	for _, field := range required {
+		if field.value == "" {
+			errs = append(errs, fmt.Errorf("%s is required", field.name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// DSN returns the lib/pq connection string.
+func (c *DBConfig) DSN() string {
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+		c.Host, c.Port, c.User, c.Password, c.Name, c.SSLMode)
+}
@@ -0,0 +1,15 @@
+-- name: CreateQuote :one
+INSERT INTO fx_quotes (id, source_currency, source_amount, target_currency, target_amount, mid_rate, rate, spread_bps, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING *;
+
+-- name: GetQuoteByID :one
+SELECT * FROM fx_quotes WHERE id = $1;
+
+-- UseQuote marks the quote as used if it is neither used nor expired. The row stays locked until the transaction
+-- commits, so a concurrent attempt to use it waits and then finds it used.
+-- name: UseQuote :one
+UPDATE fx_quotes
+SET used_at = NOW()
+WHERE id = $1 AND used_at IS NULL AND expires_at > NOW()
+RETURNING *;
@@ -0,0 +1,85 @@
+-- name: CreateStandingOrder :one
+INSERT INTO standing_orders (id, idempotency_key, user_id, from_account_id, to_account_id, currency, amount, sweep_above, schedule, starts_at, ends_at, max_executions, next_run_at, memo)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+RETURNING *;
+
+-- name: GetStandingOrderByID :one
+SELECT * FROM standing_orders WHERE id = $1;
+
+-- name: GetStandingOrderByIdempotencyKey :one
+SELECT * FROM standing_orders WHERE user_id = $1 AND idempotency_key = $2;
+
+-- name: ListStandingOrdersByAccountID :many
+SELECT * FROM standing_orders WHERE from_account_id = $1 ORDER BY created_at;
+
+-- name: CancelStandingOrder :one
+-- only cancels the order if it is still ACTIVE
+UPDATE standing_orders
+SET status = 'CANCELLED', next_run_at = NULL
+WHERE id = $1 AND status = 'ACTIVE'
+RETURNING *;
+
+-- name: ListDueStandingOrders :many
+-- the orders stay locked until the transaction commits, and are skipped by the concurrent workers meanwhile
+SELECT * FROM standing_orders
+WHERE status = 'ACTIVE' AND next_run_at <= sqlc.arg(now)::timestamptz
+ORDER BY next_run_at
+LIMIT sqlc.arg(batch_size)
+FOR UPDATE SKIP LOCKED;
+
+-- name: AdvanceStandingOrder :one
+-- moves the order to its next occurrence, or to its final status if it has none
+UPDATE standing_orders
+SET next_run_at = sqlc.narg(next_run_at), status = sqlc.arg(status), executions = executions + 1
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: CreateStandingOrderExecution :exec
+-- an occurrence is executed at most once, even if it is scheduled again
+INSERT INTO standing_order_executions (id, standing_order_id, scheduled_at, amount, next_attempt_at)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (standing_order_id, scheduled_at) DO NOTHING;
+
+-- name: ClaimDueStandingOrderExecutions :many
+-- the claimed executions are leased until lease_until, after which another worker retries them if this one didn't
+-- record their outcome
+UPDATE standing_order_executions
+SET attempts = attempts + 1, next_attempt_at = sqlc.arg(lease_until)::timestamptz
+WHERE id IN (
+    SELECT id FROM standing_order_executions
+    WHERE status = 'PENDING' AND next_attempt_at <= sqlc.arg(now)::timestamptz
+    ORDER BY next_attempt_at
+    LIMIT sqlc.arg(batch_size)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: SetStandingOrderExecutionAmount :one
+-- keeps the amount set by a previous attempt
+UPDATE standing_order_executions
+SET amount = COALESCE(amount, sqlc.arg(amount))
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: CompleteStandingOrderExecution :exec
+UPDATE standing_order_executions
+SET status = 'SUCCEEDED', transfer_id = sqlc.arg(transfer_id), last_error = NULL
+WHERE id = sqlc.arg(id) AND status = 'PENDING';
+
+-- name: SettleStandingOrderExecution :exec
+-- gives a PENDING execution its final status, FAILED or SKIPPED
+UPDATE standing_order_executions
+SET status = sqlc.arg(status), failures = failures + sqlc.arg(failed)::int, last_error = sqlc.arg(last_error)
+WHERE id = sqlc.arg(id) AND status = 'PENDING';
+
+-- name: RetryStandingOrderExecution :exec
+UPDATE standing_order_executions
+SET failures = failures + sqlc.arg(failed)::int, next_attempt_at = sqlc.arg(next_attempt_at), last_error = sqlc.arg(last_error)
+WHERE id = sqlc.arg(id) AND status = 'PENDING';
+
+-- name: SkipStandingOrderExecutions :exec
+-- skips the PENDING executions of a cancelled order that have no transfer in flight: the ones whose every attempt
+-- failed, if any. The others finish their retries.
+UPDATE standing_order_executions
+SET status = 'SKIPPED', last_error = 'standing order cancelled'
+WHERE standing_order_id = $1 AND status = 'PENDING' AND attempts = failures;
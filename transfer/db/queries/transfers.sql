@@ -1,6 +1,6 @@
 -- name: CreateTransfer :one
-INSERT INTO transfers (id, idempotency_key, from_account_id, to_account_id, amount, status)
-VALUES ($1, $2, $3, $4, $5, $6)
+INSERT INTO transfers (id, idempotency_key, from_account_id, to_account_id, amount, status, currency, to_amount, to_currency, fx_rate, quote_id, user_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 RETURNING *;
 
 -- name: GetTransfersByFromID :many
@@ -13,7 +13,7 @@ SELECT * FROM transfers WHERE to_account_id = $1;
 SELECT * FROM transfers WHERE id = $1;
 
 -- name: GetTransferByIdempotencyKey :one
-SELECT * FROM transfers WHERE idempotency_key = $1;
+SELECT * FROM transfers WHERE user_id = $1 AND idempotency_key = $2;
 
 -- name: ListTransfers :many
 SELECT * FROM transfers ORDER BY updated_at LIMIT $1;
@@ -24,3 +24,22 @@ SET status = sqlc.arg(status)
 WHERE id = sqlc.arg(id)
 RETURNING *;
 
+
+-- name: SetTransferHold :exec
+UPDATE transfers
+SET hold_id = sqlc.arg(hold_id)
+WHERE id = sqlc.arg(id) AND hold_id IS NULL;
+
+-- name: ClaimPendingTransfers :many
+-- the claimed transfers are updated, which leases them until their updated_at is before updated_before again
+UPDATE transfers
+SET updated_at = NOW()
+WHERE id IN (
+    SELECT t.id FROM transfers t
+    WHERE t.status = 'PENDING' AND t.hold_id IS NOT NULL AND t.user_id IS NOT NULL
+      AND t.updated_at < sqlc.arg(updated_before)
+    ORDER BY t.updated_at
+    LIMIT sqlc.arg(batch_size)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
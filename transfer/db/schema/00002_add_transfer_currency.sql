@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- currency is the ISO 4217 code of the amount, which is in minor units of it. Both accounts of a transfer are in this
+-- currency. The transfers that existed before currencies are in USD.
+ALTER TABLE transfers ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD' CHECK (currency ~ '^[A-Z]{3}$');
+ALTER TABLE transfers ALTER COLUMN currency DROP DEFAULT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE transfers DROP COLUMN currency;
+-- +goose StatementEnd
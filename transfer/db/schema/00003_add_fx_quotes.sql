@@ -0,0 +1,45 @@
+-- +goose Up
+-- +goose StatementBegin
+-- fx_quotes are the prices offered for cross-currency transfers: source_amount debited in source_currency is credited
+-- as target_amount in target_currency. rate is the rate of the quote, the mid_rate of the provider minus a spread of
+-- spread_bps basis points. A quote is used by at most one transfer, before it expires.
+CREATE TABLE fx_quotes (
+    id UUID PRIMARY KEY,
+    source_currency CHAR(3) NOT NULL CHECK (source_currency ~ '^[A-Z]{3}$'),
+    source_amount BIGINT NOT NULL CHECK (source_amount > 0),
+    target_currency CHAR(3) NOT NULL CHECK (target_currency ~ '^[A-Z]{3}$'),
+    target_amount BIGINT NOT NULL CHECK (target_amount > 0),
+    mid_rate NUMERIC(24, 12) NOT NULL CHECK (mid_rate > 0),
+    rate NUMERIC(24, 12) NOT NULL CHECK (rate > 0),
+    spread_bps INT NOT NULL CHECK (spread_bps >= 0),
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CHECK (source_currency <> target_currency)
+);
+
+-- the credited leg of a transfer. It only differs from the debited one for cross-currency transfers, which record the
+-- rate and the quote they were executed at.
+ALTER TABLE transfers ADD COLUMN to_amount BIGINT CHECK (to_amount > 0);
+ALTER TABLE transfers ADD COLUMN to_currency CHAR(3) CHECK (to_currency ~ '^[A-Z]{3}$');
+UPDATE transfers SET to_amount = amount, to_currency = currency;
+ALTER TABLE transfers ALTER COLUMN to_amount SET NOT NULL;
+ALTER TABLE transfers ALTER COLUMN to_currency SET NOT NULL;
+ALTER TABLE transfers ADD COLUMN fx_rate NUMERIC(24, 12) CHECK (fx_rate > 0);
+ALTER TABLE transfers ADD COLUMN quote_id UUID UNIQUE REFERENCES fx_quotes (id);
+ALTER TABLE transfers ADD CONSTRAINT chk_transfers_fx CHECK (
+    (currency = to_currency AND amount = to_amount AND fx_rate IS NULL AND quote_id IS NULL)
+    OR (currency <> to_currency AND fx_rate IS NOT NULL AND quote_id IS NOT NULL)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE transfers DROP CONSTRAINT chk_transfers_fx;
+ALTER TABLE transfers DROP COLUMN quote_id;
+ALTER TABLE transfers DROP COLUMN fx_rate;
+ALTER TABLE transfers DROP COLUMN to_currency;
+ALTER TABLE transfers DROP COLUMN to_amount;
+DROP TABLE fx_quotes;
+-- +goose StatementEnd
@@ -0,0 +1,76 @@
+-- +goose Up
+-- +goose StatementBegin
+-- standing_orders transfer amount, or for sweeps what the source account has available above sweep_above, from the
+-- account from_account_id of user_id to to_account_id, at every occurrence of schedule (see package schedule) from
+-- starts_at until ends_at, at most max_executions times. next_run_at is the next occurrence to execute, NULL once the
+-- order is COMPLETED or CANCELLED. Amounts are minor units of currency, the currency of both accounts.
+CREATE TABLE standing_orders (
+    id UUID PRIMARY KEY,
+    idempotency_key VARCHAR(100) UNIQUE NOT NULL,
+    user_id UUID NOT NULL,
+    from_account_id UUID NOT NULL,
+    to_account_id UUID NOT NULL,
+    currency CHAR(3) NOT NULL CHECK (currency ~ '^[A-Z]{3}$'),
+    amount BIGINT CHECK (amount > 0),
+    sweep_above BIGINT CHECK (sweep_above >= 0),
+    schedule TEXT NOT NULL,
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ,
+    max_executions INT CHECK (max_executions > 0),
+    executions INT NOT NULL DEFAULT 0, -- occurrences scheduled so far
+    next_run_at TIMESTAMPTZ,
+    status VARCHAR(20) NOT NULL DEFAULT 'ACTIVE' CHECK (status IN ('ACTIVE', 'COMPLETED', 'CANCELLED')),
+    memo TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CHECK (from_account_id <> to_account_id),
+    CHECK ((amount IS NULL) <> (sweep_above IS NULL)),
+    CHECK (ends_at IS NULL OR ends_at > starts_at),
+    CHECK ((status = 'ACTIVE') = (next_run_at IS NOT NULL)),
+    CHECK (max_executions IS NULL OR executions <= max_executions)
+);
+
+CREATE INDEX idx_standing_orders_from_account_id ON standing_orders (from_account_id);
+CREATE INDEX idx_standing_orders_next_run_at ON standing_orders (next_run_at) WHERE status = 'ACTIVE';
+
+CREATE TRIGGER trigger_update_timestamp_standing_orders
+BEFORE UPDATE ON standing_orders
+FOR EACH ROW EXECUTE FUNCTION update_timestamp();
+
+-- standing_order_executions are the occurrences of the standing orders, executed as transfers. amount is set by the
+-- first attempt, which computes it for sweeps, so that the retries transfer the same amount. attempts counts the
+-- attempts that were claimed, failures the ones whose transfer FAILED, which the next attempt can't retry: the
+-- idempotency key of the transfer of an attempt is derived from the execution and its failures. A PENDING execution
+-- is retried from next_attempt_at. SKIPPED executions had nothing to sweep, or their order was cancelled first.
+CREATE TABLE standing_order_executions (
+    id UUID PRIMARY KEY,
+    standing_order_id UUID NOT NULL REFERENCES standing_orders (id),
+    scheduled_at TIMESTAMPTZ NOT NULL,
+    amount BIGINT CHECK (amount > 0),
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'SUCCEEDED', 'FAILED', 'SKIPPED')),
+    transfer_id UUID REFERENCES transfers (id),
+    attempts INT NOT NULL DEFAULT 0,
+    failures INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL,
+    last_error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    UNIQUE (standing_order_id, scheduled_at),
+    CHECK ((status = 'SUCCEEDED') = (transfer_id IS NOT NULL))
+);
+
+CREATE INDEX idx_standing_order_executions_next_attempt_at ON standing_order_executions (next_attempt_at)
+    WHERE status = 'PENDING';
+
+CREATE TRIGGER trigger_update_timestamp_standing_order_executions
+BEFORE UPDATE ON standing_order_executions
+FOR EACH ROW EXECUTE FUNCTION update_timestamp();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE standing_order_executions;
+DROP TABLE standing_orders;
+-- +goose StatementEnd
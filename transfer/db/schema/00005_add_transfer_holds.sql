@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+-- hold_id is the hold placed on the source account by the first leg of the transfer, and user_id the owner of the
+-- account, on whose behalf a PENDING transfer with a hold is finished by the scheduler. Both are NULL for the
+-- transfers made before holds, which are never finished that way.
+ALTER TABLE transfers ADD COLUMN user_id UUID;
+ALTER TABLE transfers ADD COLUMN hold_id UUID;
+
+CREATE INDEX idx_transfers_pending ON transfers (updated_at) WHERE status = 'PENDING' AND hold_id IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_transfers_pending;
+ALTER TABLE transfers DROP COLUMN hold_id;
+ALTER TABLE transfers DROP COLUMN user_id;
+-- +goose StatementEnd
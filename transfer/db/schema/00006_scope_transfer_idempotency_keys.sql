@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- idempotency keys are chosen by the clients, so they are only unique per user: a key reused by another user is a
+-- different transfer, rather than the replay of a transfer of someone else. The transfers made before user_id have no
+-- user, and can't be replayed anymore.
+ALTER TABLE transfers DROP CONSTRAINT transfers_idempotency_key_key;
+DROP INDEX idx_transfer_idempotency_key;
+CREATE UNIQUE INDEX idx_transfers_user_id_idempotency_key ON transfers (user_id, idempotency_key);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_transfers_user_id_idempotency_key;
+CREATE INDEX idx_transfer_idempotency_key ON transfers (idempotency_key);
+ALTER TABLE transfers ADD CONSTRAINT transfers_idempotency_key_key UNIQUE (idempotency_key);
+-- +goose StatementEnd
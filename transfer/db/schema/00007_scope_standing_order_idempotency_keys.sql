@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- idempotency keys are chosen by the clients, so they are only unique per user, like the ones of the transfers.
+ALTER TABLE standing_orders DROP CONSTRAINT standing_orders_idempotency_key_key;
+ALTER TABLE standing_orders ADD CONSTRAINT standing_orders_user_id_idempotency_key_key UNIQUE (user_id, idempotency_key);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE standing_orders DROP CONSTRAINT standing_orders_user_id_idempotency_key_key;
+ALTER TABLE standing_orders ADD CONSTRAINT standing_orders_idempotency_key_key UNIQUE (idempotency_key);
+-- +goose StatementEnd
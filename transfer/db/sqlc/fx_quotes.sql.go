@@ -0,0 +1,111 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: fx_quotes.sql
+
+package sqlc
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const createQuote = `-- name: CreateQuote :one
+INSERT INTO fx_quotes (id, source_currency, source_amount, target_currency, target_amount, mid_rate, rate, spread_bps, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING id, source_currency, source_amount, target_currency, target_amount, mid_rate, rate, spread_bps, expires_at, used_at, created_at
+`
+
+type CreateQuoteParams struct {
+	ID             uuid.UUID `json:"id"`
+	SourceCurrency string    `json:"source_currency"`
+	SourceAmount   int64     `json:"source_amount"`
+	TargetCurrency string    `json:"target_currency"`
+	TargetAmount   int64     `json:"target_amount"`
+	MidRate        string    `json:"mid_rate"`
+	Rate           string    `json:"rate"`
+	SpreadBps      int32     `json:"spread_bps"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
+
+func (q *Queries) CreateQuote(ctx context.Context, arg CreateQuoteParams) (FxQuote, error) {
+	row := q.db.QueryRowContext(ctx, createQuote,
+		arg.ID,
+		arg.SourceCurrency,
+		arg.SourceAmount,
+		arg.TargetCurrency,
+		arg.TargetAmount,
+		arg.MidRate,
+		arg.Rate,
+		arg.SpreadBps,
+		arg.ExpiresAt,
+	)
+	var i FxQuote
+	err := row.Scan(
+		&i.ID,
+		&i.SourceCurrency,
+		&i.SourceAmount,
+		&i.TargetCurrency,
+		&i.TargetAmount,
+		&i.MidRate,
+		&i.Rate,
+		&i.SpreadBps,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getQuoteByID = `-- name: GetQuoteByID :one
+SELECT id, source_currency, source_amount, target_currency, target_amount, mid_rate, rate, spread_bps, expires_at, used_at, created_at FROM fx_quotes WHERE id = $1
+`
+
+func (q *Queries) GetQuoteByID(ctx context.Context, id uuid.UUID) (FxQuote, error) {
+	row := q.db.QueryRowContext(ctx, getQuoteByID, id)
+	var i FxQuote
+	err := row.Scan(
+		&i.ID,
+		&i.SourceCurrency,
+		&i.SourceAmount,
+		&i.TargetCurrency,
+		&i.TargetAmount,
+		&i.MidRate,
+		&i.Rate,
+		&i.SpreadBps,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const useQuote = `-- name: UseQuote :one
+UPDATE fx_quotes
+SET used_at = NOW()
+WHERE id = $1 AND used_at IS NULL AND expires_at > NOW()
+RETURNING id, source_currency, source_amount, target_currency, target_amount, mid_rate, rate, spread_bps, expires_at, used_at, created_at
+`
+
+// UseQuote marks the quote as used if it is neither used nor expired. The row stays locked until the transaction
+// commits, so a concurrent attempt to use it waits and then finds it used.
+func (q *Queries) UseQuote(ctx context.Context, id uuid.UUID) (FxQuote, error) {
+	row := q.db.QueryRowContext(ctx, useQuote, id)
+	var i FxQuote
+	err := row.Scan(
+		&i.ID,
+		&i.SourceCurrency,
+		&i.SourceAmount,
+		&i.TargetCurrency,
+		&i.TargetAmount,
+		&i.MidRate,
+		&i.Rate,
+		&i.SpreadBps,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
@@ -6,17 +6,75 @@ package sqlc
 
 import (
 	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
 )
 
-type Transfer struct {
+type FxQuote struct {
 	ID             uuid.UUID    `json:"id"`
-	IdempotencyKey string       `json:"idempotency_key"`
-	FromAccountID  uuid.UUID    `json:"from_account_id"`
-	ToAccountID    uuid.UUID    `json:"to_account_id"`
-	Amount         int64        `json:"amount"`
-	Status         string       `json:"status"`
-	CreatedAt      sql.NullTime `json:"created_at"`
-	UpdatedAt      sql.NullTime `json:"updated_at"`
+	SourceCurrency string       `json:"source_currency"`
+	SourceAmount   int64        `json:"source_amount"`
+	TargetCurrency string       `json:"target_currency"`
+	TargetAmount   int64        `json:"target_amount"`
+	MidRate        string       `json:"mid_rate"`
+	Rate           string       `json:"rate"`
+	SpreadBps      int32        `json:"spread_bps"`
+	ExpiresAt      time.Time    `json:"expires_at"`
+	UsedAt         sql.NullTime `json:"used_at"`
+	CreatedAt      time.Time    `json:"created_at"`
+}
+
+type StandingOrder struct {
+	ID             uuid.UUID      `json:"id"`
+	IdempotencyKey string         `json:"idempotency_key"`
+	UserID         uuid.UUID      `json:"user_id"`
+	FromAccountID  uuid.UUID      `json:"from_account_id"`
+	ToAccountID    uuid.UUID      `json:"to_account_id"`
+	Currency       string         `json:"currency"`
+	Amount         sql.NullInt64  `json:"amount"`
+	SweepAbove     sql.NullInt64  `json:"sweep_above"`
+	Schedule       string         `json:"schedule"`
+	StartsAt       time.Time      `json:"starts_at"`
+	EndsAt         sql.NullTime   `json:"ends_at"`
+	MaxExecutions  sql.NullInt32  `json:"max_executions"`
+	Executions     int32          `json:"executions"`
+	NextRunAt      sql.NullTime   `json:"next_run_at"`
+	Status         string         `json:"status"`
+	Memo           sql.NullString `json:"memo"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
+
+type StandingOrderExecution struct {
+	ID              uuid.UUID      `json:"id"`
+	StandingOrderID uuid.UUID      `json:"standing_order_id"`
+	ScheduledAt     time.Time      `json:"scheduled_at"`
+	Amount          sql.NullInt64  `json:"amount"`
+	Status          string         `json:"status"`
+	TransferID      uuid.NullUUID  `json:"transfer_id"`
+	Attempts        int32          `json:"attempts"`
+	Failures        int32          `json:"failures"`
+	NextAttemptAt   time.Time      `json:"next_attempt_at"`
+	LastError       sql.NullString `json:"last_error"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+}
+
+type Transfer struct {
+	ID             uuid.UUID      `json:"id"`
+	IdempotencyKey string         `json:"idempotency_key"`
+	FromAccountID  uuid.UUID      `json:"from_account_id"`
+	ToAccountID    uuid.UUID      `json:"to_account_id"`
+	Amount         int64          `json:"amount"`
+	Status         string         `json:"status"`
+	CreatedAt      sql.NullTime   `json:"created_at"`
+	UpdatedAt      sql.NullTime   `json:"updated_at"`
+	Currency       string         `json:"currency"`
+	ToAmount       int64          `json:"to_amount"`
+	ToCurrency     string         `json:"to_currency"`
+	FxRate         sql.NullString `json:"fx_rate"`
+	QuoteID        uuid.NullUUID  `json:"quote_id"`
+	UserID         uuid.NullUUID  `json:"user_id"`
+	HoldID         uuid.NullUUID  `json:"hold_id"`
 }
@@ -0,0 +1,511 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: standing_orders.sql
+
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const advanceStandingOrder = `-- name: AdvanceStandingOrder :one
+UPDATE standing_orders
+SET next_run_at = $1, status = $2, executions = executions + 1
+WHERE id = $3
+RETURNING id, idempotency_key, user_id, from_account_id, to_account_id, currency, amount, sweep_above, schedule, starts_at, ends_at, max_executions, executions, next_run_at, status, memo, created_at, updated_at
+`
+
+type AdvanceStandingOrderParams struct {
+	NextRunAt sql.NullTime `json:"next_run_at"`
+	Status    string       `json:"status"`
+	ID        uuid.UUID    `json:"id"`
+}
+
+// moves the order to its next occurrence, or to its final status if it has none
+func (q *Queries) AdvanceStandingOrder(ctx context.Context, arg AdvanceStandingOrderParams) (StandingOrder, error) {
+	row := q.db.QueryRowContext(ctx, advanceStandingOrder, arg.NextRunAt, arg.Status, arg.ID)
+	var i StandingOrder
+	err := row.Scan(
+		&i.ID,
+		&i.IdempotencyKey,
+		&i.UserID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Currency,
+		&i.Amount,
+		&i.SweepAbove,
+		&i.Schedule,
+		&i.StartsAt,
+		&i.EndsAt,
+		&i.MaxExecutions,
+		&i.Executions,
+		&i.NextRunAt,
+		&i.Status,
+		&i.Memo,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const cancelStandingOrder = `-- name: CancelStandingOrder :one
+UPDATE standing_orders
+SET status = 'CANCELLED', next_run_at = NULL
+WHERE id = $1 AND status = 'ACTIVE'
+RETURNING id, idempotency_key, user_id, from_account_id, to_account_id, currency, amount, sweep_above, schedule, starts_at, ends_at, max_executions, executions, next_run_at, status, memo, created_at, updated_at
+`
+
+// only cancels the order if it is still ACTIVE
+func (q *Queries) CancelStandingOrder(ctx context.Context, id uuid.UUID) (StandingOrder, error) {
+	row := q.db.QueryRowContext(ctx, cancelStandingOrder, id)
+	var i StandingOrder
+	err := row.Scan(
+		&i.ID,
+		&i.IdempotencyKey,
+		&i.UserID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Currency,
+		&i.Amount,
+		&i.SweepAbove,
+		&i.Schedule,
+		&i.StartsAt,
+		&i.EndsAt,
+		&i.MaxExecutions,
+		&i.Executions,
+		&i.NextRunAt,
+		&i.Status,
+		&i.Memo,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const claimDueStandingOrderExecutions = `-- name: ClaimDueStandingOrderExecutions :many
+UPDATE standing_order_executions
+SET attempts = attempts + 1, next_attempt_at = $1::timestamptz
+WHERE id IN (
+    SELECT id FROM standing_order_executions
+    WHERE status = 'PENDING' AND next_attempt_at <= $2::timestamptz
+    ORDER BY next_attempt_at
+    LIMIT $3
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, standing_order_id, scheduled_at, amount, status, transfer_id, attempts, failures, next_attempt_at, last_error, created_at, updated_at
+`
+
+type ClaimDueStandingOrderExecutionsParams struct {
+	LeaseUntil time.Time `json:"lease_until"`
+	Now        time.Time `json:"now"`
+	BatchSize  int32     `json:"batch_size"`
+}
+
+// the claimed executions are leased until lease_until, after which another worker retries them if this one didn't
+// record their outcome
+func (q *Queries) ClaimDueStandingOrderExecutions(ctx context.Context, arg ClaimDueStandingOrderExecutionsParams) ([]StandingOrderExecution, error) {
+	rows, err := q.db.QueryContext(ctx, claimDueStandingOrderExecutions, arg.LeaseUntil, arg.Now, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []StandingOrderExecution
+	for rows.Next() {
+		var i StandingOrderExecution
+		if err := rows.Scan(
+			&i.ID,
+			&i.StandingOrderID,
+			&i.ScheduledAt,
+			&i.Amount,
+			&i.Status,
+			&i.TransferID,
+			&i.Attempts,
+			&i.Failures,
+			&i.NextAttemptAt,
+			&i.LastError,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const completeStandingOrderExecution = `-- name: CompleteStandingOrderExecution :exec
+UPDATE standing_order_executions
+SET status = 'SUCCEEDED', transfer_id = $1, last_error = NULL
+WHERE id = $2 AND status = 'PENDING'
+`
+
+type CompleteStandingOrderExecutionParams struct {
+	TransferID uuid.NullUUID `json:"transfer_id"`
+	ID         uuid.UUID     `json:"id"`
+}
+
+func (q *Queries) CompleteStandingOrderExecution(ctx context.Context, arg CompleteStandingOrderExecutionParams) error {
+	_, err := q.db.ExecContext(ctx, completeStandingOrderExecution, arg.TransferID, arg.ID)
+	return err
+}
+
+const createStandingOrder = `-- name: CreateStandingOrder :one
+INSERT INTO standing_orders (id, idempotency_key, user_id, from_account_id, to_account_id, currency, amount, sweep_above, schedule, starts_at, ends_at, max_executions, next_run_at, memo)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+RETURNING id, idempotency_key, user_id, from_account_id, to_account_id, currency, amount, sweep_above, schedule, starts_at, ends_at, max_executions, executions, next_run_at, status, memo, created_at, updated_at
+`
+
+type CreateStandingOrderParams struct {
+	ID             uuid.UUID      `json:"id"`
+	IdempotencyKey string         `json:"idempotency_key"`
+	UserID         uuid.UUID      `json:"user_id"`
+	FromAccountID  uuid.UUID      `json:"from_account_id"`
+	ToAccountID    uuid.UUID      `json:"to_account_id"`
+	Currency       string         `json:"currency"`
+	Amount         sql.NullInt64  `json:"amount"`
+	SweepAbove     sql.NullInt64  `json:"sweep_above"`
+	Schedule       string         `json:"schedule"`
+	StartsAt       time.Time      `json:"starts_at"`
+	EndsAt         sql.NullTime   `json:"ends_at"`
+	MaxExecutions  sql.NullInt32  `json:"max_executions"`
+	NextRunAt      sql.NullTime   `json:"next_run_at"`
+	Memo           sql.NullString `json:"memo"`
+}
+
+func (q *Queries) CreateStandingOrder(ctx context.Context, arg CreateStandingOrderParams) (StandingOrder, error) {
+	row := q.db.QueryRowContext(ctx, createStandingOrder,
+		arg.ID,
+		arg.IdempotencyKey,
+		arg.UserID,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Currency,
+		arg.Amount,
+		arg.SweepAbove,
+		arg.Schedule,
+		arg.StartsAt,
+		arg.EndsAt,
+		arg.MaxExecutions,
+		arg.NextRunAt,
+		arg.Memo,
+	)
+	var i StandingOrder
+	err := row.Scan(
+		&i.ID,
+		&i.IdempotencyKey,
+		&i.UserID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Currency,
+		&i.Amount,
+		&i.SweepAbove,
+		&i.Schedule,
+		&i.StartsAt,
+		&i.EndsAt,
+		&i.MaxExecutions,
+		&i.Executions,
+		&i.NextRunAt,
+		&i.Status,
+		&i.Memo,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createStandingOrderExecution = `-- name: CreateStandingOrderExecution :exec
+INSERT INTO standing_order_executions (id, standing_order_id, scheduled_at, amount, next_attempt_at)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (standing_order_id, scheduled_at) DO NOTHING
+`
+
+type CreateStandingOrderExecutionParams struct {
+	ID              uuid.UUID     `json:"id"`
+	StandingOrderID uuid.UUID     `json:"standing_order_id"`
+	ScheduledAt     time.Time     `json:"scheduled_at"`
+	Amount          sql.NullInt64 `json:"amount"`
+	NextAttemptAt   time.Time     `json:"next_attempt_at"`
+}
+
+// an occurrence is executed at most once, even if it is scheduled again
+func (q *Queries) CreateStandingOrderExecution(ctx context.Context, arg CreateStandingOrderExecutionParams) error {
+	_, err := q.db.ExecContext(ctx, createStandingOrderExecution,
+		arg.ID,
+		arg.StandingOrderID,
+		arg.ScheduledAt,
+		arg.Amount,
+		arg.NextAttemptAt,
+	)
+	return err
+}
+
+const getStandingOrderByID = `-- name: GetStandingOrderByID :one
+SELECT id, idempotency_key, user_id, from_account_id, to_account_id, currency, amount, sweep_above, schedule, starts_at, ends_at, max_executions, executions, next_run_at, status, memo, created_at, updated_at FROM standing_orders WHERE id = $1
+`
+
+func (q *Queries) GetStandingOrderByID(ctx context.Context, id uuid.UUID) (StandingOrder, error) {
+	row := q.db.QueryRowContext(ctx, getStandingOrderByID, id)
+	var i StandingOrder
+	err := row.Scan(
+		&i.ID,
+		&i.IdempotencyKey,
+		&i.UserID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Currency,
+		&i.Amount,
+		&i.SweepAbove,
+		&i.Schedule,
+		&i.StartsAt,
+		&i.EndsAt,
+		&i.MaxExecutions,
+		&i.Executions,
+		&i.NextRunAt,
+		&i.Status,
+		&i.Memo,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getStandingOrderByIdempotencyKey = `-- name: GetStandingOrderByIdempotencyKey :one
+SELECT id, idempotency_key, user_id, from_account_id, to_account_id, currency, amount, sweep_above, schedule, starts_at, ends_at, max_executions, executions, next_run_at, status, memo, created_at, updated_at FROM standing_orders WHERE user_id = $1 AND idempotency_key = $2
+`
+
+type GetStandingOrderByIdempotencyKeyParams struct {
+	UserID         uuid.UUID `json:"user_id"`
+	IdempotencyKey string    `json:"idempotency_key"`
+}
+
+func (q *Queries) GetStandingOrderByIdempotencyKey(ctx context.Context, arg GetStandingOrderByIdempotencyKeyParams) (StandingOrder, error) {
+	row := q.db.QueryRowContext(ctx, getStandingOrderByIdempotencyKey, arg.UserID, arg.IdempotencyKey)
+	var i StandingOrder
+	err := row.Scan(
+		&i.ID,
+		&i.IdempotencyKey,
+		&i.UserID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Currency,
+		&i.Amount,
+		&i.SweepAbove,
+		&i.Schedule,
+		&i.StartsAt,
+		&i.EndsAt,
+		&i.MaxExecutions,
+		&i.Executions,
+		&i.NextRunAt,
+		&i.Status,
+		&i.Memo,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listDueStandingOrders = `-- name: ListDueStandingOrders :many
+SELECT id, idempotency_key, user_id, from_account_id, to_account_id, currency, amount, sweep_above, schedule, starts_at, ends_at, max_executions, executions, next_run_at, status, memo, created_at, updated_at FROM standing_orders
+WHERE status = 'ACTIVE' AND next_run_at <= $1::timestamptz
+ORDER BY next_run_at
+LIMIT $2
+FOR UPDATE SKIP LOCKED
+`
+
+type ListDueStandingOrdersParams struct {
+	Now       time.Time `json:"now"`
+	BatchSize int32     `json:"batch_size"`
+}
+
+// the orders stay locked until the transaction commits, and are skipped by the concurrent workers meanwhile
+func (q *Queries) ListDueStandingOrders(ctx context.Context, arg ListDueStandingOrdersParams) ([]StandingOrder, error) {
+	rows, err := q.db.QueryContext(ctx, listDueStandingOrders, arg.Now, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []StandingOrder
+	for rows.Next() {
+		var i StandingOrder
+		if err := rows.Scan(
+			&i.ID,
+			&i.IdempotencyKey,
+			&i.UserID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Currency,
+			&i.Amount,
+			&i.SweepAbove,
+			&i.Schedule,
+			&i.StartsAt,
+			&i.EndsAt,
+			&i.MaxExecutions,
+			&i.Executions,
+			&i.NextRunAt,
+			&i.Status,
+			&i.Memo,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStandingOrdersByAccountID = `-- name: ListStandingOrdersByAccountID :many
+SELECT id, idempotency_key, user_id, from_account_id, to_account_id, currency, amount, sweep_above, schedule, starts_at, ends_at, max_executions, executions, next_run_at, status, memo, created_at, updated_at FROM standing_orders WHERE from_account_id = $1 ORDER BY created_at
+`
+
+func (q *Queries) ListStandingOrdersByAccountID(ctx context.Context, fromAccountID uuid.UUID) ([]StandingOrder, error) {
+	rows, err := q.db.QueryContext(ctx, listStandingOrdersByAccountID, fromAccountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []StandingOrder
+	for rows.Next() {
+		var i StandingOrder
+		if err := rows.Scan(
+			&i.ID,
+			&i.IdempotencyKey,
+			&i.UserID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Currency,
+			&i.Amount,
+			&i.SweepAbove,
+			&i.Schedule,
+			&i.StartsAt,
+			&i.EndsAt,
+			&i.MaxExecutions,
+			&i.Executions,
+			&i.NextRunAt,
+			&i.Status,
+			&i.Memo,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const retryStandingOrderExecution = `-- name: RetryStandingOrderExecution :exec
+UPDATE standing_order_executions
+SET failures = failures + $1::int, next_attempt_at = $2, last_error = $3
+WHERE id = $4 AND status = 'PENDING'
+`
+
+type RetryStandingOrderExecutionParams struct {
+	Failed        int32          `json:"failed"`
+	NextAttemptAt time.Time      `json:"next_attempt_at"`
+	LastError     sql.NullString `json:"last_error"`
+	ID            uuid.UUID      `json:"id"`
+}
+
+func (q *Queries) RetryStandingOrderExecution(ctx context.Context, arg RetryStandingOrderExecutionParams) error {
+	_, err := q.db.ExecContext(ctx, retryStandingOrderExecution,
+		arg.Failed,
+		arg.NextAttemptAt,
+		arg.LastError,
+		arg.ID,
+	)
+	return err
+}
+
+const setStandingOrderExecutionAmount = `-- name: SetStandingOrderExecutionAmount :one
+UPDATE standing_order_executions
+SET amount = COALESCE(amount, $1)
+WHERE id = $2
+RETURNING id, standing_order_id, scheduled_at, amount, status, transfer_id, attempts, failures, next_attempt_at, last_error, created_at, updated_at
+`
+
+type SetStandingOrderExecutionAmountParams struct {
+	Amount sql.NullInt64 `json:"amount"`
+	ID     uuid.UUID     `json:"id"`
+}
+
+// keeps the amount set by a previous attempt
+func (q *Queries) SetStandingOrderExecutionAmount(ctx context.Context, arg SetStandingOrderExecutionAmountParams) (StandingOrderExecution, error) {
+	row := q.db.QueryRowContext(ctx, setStandingOrderExecutionAmount, arg.Amount, arg.ID)
+	var i StandingOrderExecution
+	err := row.Scan(
+		&i.ID,
+		&i.StandingOrderID,
+		&i.ScheduledAt,
+		&i.Amount,
+		&i.Status,
+		&i.TransferID,
+		&i.Attempts,
+		&i.Failures,
+		&i.NextAttemptAt,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const settleStandingOrderExecution = `-- name: SettleStandingOrderExecution :exec
+UPDATE standing_order_executions
+SET status = $1, failures = failures + $2::int, last_error = $3
+WHERE id = $4 AND status = 'PENDING'
+`
+
+type SettleStandingOrderExecutionParams struct {
+	Status    string         `json:"status"`
+	Failed    int32          `json:"failed"`
+	LastError sql.NullString `json:"last_error"`
+	ID        uuid.UUID      `json:"id"`
+}
+
+// gives a PENDING execution its final status, FAILED or SKIPPED
+func (q *Queries) SettleStandingOrderExecution(ctx context.Context, arg SettleStandingOrderExecutionParams) error {
+	_, err := q.db.ExecContext(ctx, settleStandingOrderExecution,
+		arg.Status,
+		arg.Failed,
+		arg.LastError,
+		arg.ID,
+	)
+	return err
+}
+
+const skipStandingOrderExecutions = `-- name: SkipStandingOrderExecutions :exec
+UPDATE standing_order_executions
+SET status = 'SKIPPED', last_error = 'standing order cancelled'
+WHERE standing_order_id = $1 AND status = 'PENDING' AND attempts = failures
+`
+
+// skips the PENDING executions of a cancelled order that have no transfer in flight: the ones whose every attempt
+// failed, if any. The others finish their retries.
+func (q *Queries) SkipStandingOrderExecutions(ctx context.Context, standingOrderID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, skipStandingOrderExecutions, standingOrderID)
+	return err
+}
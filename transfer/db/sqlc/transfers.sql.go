@@ -7,23 +7,89 @@ package sqlc
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
 )
 
+const claimPendingTransfers = `-- name: ClaimPendingTransfers :many
+UPDATE transfers
+SET updated_at = NOW()
+WHERE id IN (
+    SELECT t.id FROM transfers t
+    WHERE t.status = 'PENDING' AND t.hold_id IS NOT NULL AND t.user_id IS NOT NULL
+      AND t.updated_at < $1
+    ORDER BY t.updated_at
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, idempotency_key, from_account_id, to_account_id, amount, status, created_at, updated_at, currency, to_amount, to_currency, fx_rate, quote_id, user_id, hold_id
+`
+
+type ClaimPendingTransfersParams struct {
+	UpdatedBefore sql.NullTime `json:"updated_before"`
+	BatchSize     int32        `json:"batch_size"`
+}
+
+// the claimed transfers are updated, which leases them until their updated_at is before updated_before again
+func (q *Queries) ClaimPendingTransfers(ctx context.Context, arg ClaimPendingTransfersParams) ([]Transfer, error) {
+	rows, err := q.db.QueryContext(ctx, claimPendingTransfers, arg.UpdatedBefore, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Transfer
+	for rows.Next() {
+		var i Transfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.IdempotencyKey,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Currency,
+			&i.ToAmount,
+			&i.ToCurrency,
+			&i.FxRate,
+			&i.QuoteID,
+			&i.UserID,
+			&i.HoldID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const createTransfer = `-- name: CreateTransfer :one
-INSERT INTO transfers (id, idempotency_key, from_account_id, to_account_id, amount, status)
-VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING id, idempotency_key, from_account_id, to_account_id, amount, status, created_at, updated_at
+INSERT INTO transfers (id, idempotency_key, from_account_id, to_account_id, amount, status, currency, to_amount, to_currency, fx_rate, quote_id, user_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+RETURNING id, idempotency_key, from_account_id, to_account_id, amount, status, created_at, updated_at, currency, to_amount, to_currency, fx_rate, quote_id, user_id, hold_id
 `
 
 type CreateTransferParams struct {
-	ID             uuid.UUID `json:"id"`
-	IdempotencyKey string    `json:"idempotency_key"`
-	FromAccountID  uuid.UUID `json:"from_account_id"`
-	ToAccountID    uuid.UUID `json:"to_account_id"`
-	Amount         int64     `json:"amount"`
-	Status         string    `json:"status"`
+	ID             uuid.UUID      `json:"id"`
+	IdempotencyKey string         `json:"idempotency_key"`
+	FromAccountID  uuid.UUID      `json:"from_account_id"`
+	ToAccountID    uuid.UUID      `json:"to_account_id"`
+	Amount         int64          `json:"amount"`
+	Status         string         `json:"status"`
+	Currency       string         `json:"currency"`
+	ToAmount       int64          `json:"to_amount"`
+	ToCurrency     string         `json:"to_currency"`
+	FxRate         sql.NullString `json:"fx_rate"`
+	QuoteID        uuid.NullUUID  `json:"quote_id"`
+	UserID         uuid.NullUUID  `json:"user_id"`
 }
 
 func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error) {
@@ -34,6 +100,12 @@ func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams)
 		arg.ToAccountID,
 		arg.Amount,
 		arg.Status,
+		arg.Currency,
+		arg.ToAmount,
+		arg.ToCurrency,
+		arg.FxRate,
+		arg.QuoteID,
+		arg.UserID,
 	)
 	var i Transfer
 	err := row.Scan(
@@ -45,12 +117,19 @@ func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams)
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Currency,
+		&i.ToAmount,
+		&i.ToCurrency,
+		&i.FxRate,
+		&i.QuoteID,
+		&i.UserID,
+		&i.HoldID,
 	)
 	return i, err
 }
 
 const getTransferByID = `-- name: GetTransferByID :one
-SELECT id, idempotency_key, from_account_id, to_account_id, amount, status, created_at, updated_at FROM transfers WHERE id = $1
+SELECT id, idempotency_key, from_account_id, to_account_id, amount, status, created_at, updated_at, currency, to_amount, to_currency, fx_rate, quote_id, user_id, hold_id FROM transfers WHERE id = $1
 `
 
 func (q *Queries) GetTransferByID(ctx context.Context, id uuid.UUID) (Transfer, error) {
@@ -65,16 +144,28 @@ func (q *Queries) GetTransferByID(ctx context.Context, id uuid.UUID) (Transfer,
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Currency,
+		&i.ToAmount,
+		&i.ToCurrency,
+		&i.FxRate,
+		&i.QuoteID,
+		&i.UserID,
+		&i.HoldID,
 	)
 	return i, err
 }
 
 const getTransferByIdempotencyKey = `-- name: GetTransferByIdempotencyKey :one
-SELECT id, idempotency_key, from_account_id, to_account_id, amount, status, created_at, updated_at FROM transfers WHERE idempotency_key = $1
+SELECT id, idempotency_key, from_account_id, to_account_id, amount, status, created_at, updated_at, currency, to_amount, to_currency, fx_rate, quote_id, user_id, hold_id FROM transfers WHERE user_id = $1 AND idempotency_key = $2
 `
 
-func (q *Queries) GetTransferByIdempotencyKey(ctx context.Context, idempotencyKey string) (Transfer, error) {
-	row := q.db.QueryRowContext(ctx, getTransferByIdempotencyKey, idempotencyKey)
+type GetTransferByIdempotencyKeyParams struct {
+	UserID         uuid.NullUUID `json:"user_id"`
+	IdempotencyKey string        `json:"idempotency_key"`
+}
+
+func (q *Queries) GetTransferByIdempotencyKey(ctx context.Context, arg GetTransferByIdempotencyKeyParams) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, getTransferByIdempotencyKey, arg.UserID, arg.IdempotencyKey)
 	var i Transfer
 	err := row.Scan(
 		&i.ID,
@@ -85,12 +176,19 @@ func (q *Queries) GetTransferByIdempotencyKey(ctx context.Context, idempotencyKe
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Currency,
+		&i.ToAmount,
+		&i.ToCurrency,
+		&i.FxRate,
+		&i.QuoteID,
+		&i.UserID,
+		&i.HoldID,
 	)
 	return i, err
 }
 
 const getTransfersByFromID = `-- name: GetTransfersByFromID :many
-SELECT id, idempotency_key, from_account_id, to_account_id, amount, status, created_at, updated_at FROM transfers WHERE from_account_id = $1
+SELECT id, idempotency_key, from_account_id, to_account_id, amount, status, created_at, updated_at, currency, to_amount, to_currency, fx_rate, quote_id, user_id, hold_id FROM transfers WHERE from_account_id = $1
 `
 
 func (q *Queries) GetTransfersByFromID(ctx context.Context, fromAccountID uuid.UUID) ([]Transfer, error) {
@@ -111,6 +209,13 @@ func (q *Queries) GetTransfersByFromID(ctx context.Context, fromAccountID uuid.U
 			&i.Status,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Currency,
+			&i.ToAmount,
+			&i.ToCurrency,
+			&i.FxRate,
+			&i.QuoteID,
+			&i.UserID,
+			&i.HoldID,
 		); err != nil {
 			return nil, err
 		}
@@ -126,7 +231,7 @@ func (q *Queries) GetTransfersByFromID(ctx context.Context, fromAccountID uuid.U
 }
 
 const getTransfersByToID = `-- name: GetTransfersByToID :many
-SELECT id, idempotency_key, from_account_id, to_account_id, amount, status, created_at, updated_at FROM transfers WHERE to_account_id = $1
+SELECT id, idempotency_key, from_account_id, to_account_id, amount, status, created_at, updated_at, currency, to_amount, to_currency, fx_rate, quote_id, user_id, hold_id FROM transfers WHERE to_account_id = $1
 `
 
 func (q *Queries) GetTransfersByToID(ctx context.Context, toAccountID uuid.UUID) ([]Transfer, error) {
@@ -147,6 +252,13 @@ func (q *Queries) GetTransfersByToID(ctx context.Context, toAccountID uuid.UUID)
 			&i.Status,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Currency,
+			&i.ToAmount,
+			&i.ToCurrency,
+			&i.FxRate,
+			&i.QuoteID,
+			&i.UserID,
+			&i.HoldID,
 		); err != nil {
 			return nil, err
 		}
@@ -162,7 +274,7 @@ func (q *Queries) GetTransfersByToID(ctx context.Context, toAccountID uuid.UUID)
 }
 
 const listTransfers = `-- name: ListTransfers :many
-SELECT id, idempotency_key, from_account_id, to_account_id, amount, status, created_at, updated_at FROM transfers ORDER BY updated_at LIMIT $1
+SELECT id, idempotency_key, from_account_id, to_account_id, amount, status, created_at, updated_at, currency, to_amount, to_currency, fx_rate, quote_id, user_id, hold_id FROM transfers ORDER BY updated_at LIMIT $1
 `
 
 func (q *Queries) ListTransfers(ctx context.Context, limit int32) ([]Transfer, error) {
@@ -183,6 +295,13 @@ func (q *Queries) ListTransfers(ctx context.Context, limit int32) ([]Transfer, e
 			&i.Status,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Currency,
+			&i.ToAmount,
+			&i.ToCurrency,
+			&i.FxRate,
+			&i.QuoteID,
+			&i.UserID,
+			&i.HoldID,
 		); err != nil {
 			return nil, err
 		}
@@ -197,11 +316,27 @@ func (q *Queries) ListTransfers(ctx context.Context, limit int32) ([]Transfer, e
 	return items, nil
 }
 
+const setTransferHold = `-- name: SetTransferHold :exec
+UPDATE transfers
+SET hold_id = $1
+WHERE id = $2 AND hold_id IS NULL
+`
+
+type SetTransferHoldParams struct {
+	HoldID uuid.NullUUID `json:"hold_id"`
+	ID     uuid.UUID     `json:"id"`
+}
+
+func (q *Queries) SetTransferHold(ctx context.Context, arg SetTransferHoldParams) error {
+	_, err := q.db.ExecContext(ctx, setTransferHold, arg.HoldID, arg.ID)
+	return err
+}
+
 const updateTransferStatus = `-- name: UpdateTransferStatus :exec
 UPDATE transfers
 SET status = $1
 WHERE id = $2
-RETURNING id, idempotency_key, from_account_id, to_account_id, amount, status, created_at, updated_at
+RETURNING id, idempotency_key, from_account_id, to_account_id, amount, status, created_at, updated_at, currency, to_amount, to_currency, fx_rate, quote_id, user_id, hold_id
 `
 
 type UpdateTransferStatusParams struct {
@@ -0,0 +1,16 @@
+package handler
+
+import (
+	"transfer/internal/mtls"
+	"transfer/proto"
+)
+
+// Policy lists which services may call each RPC of the transfer service. Only the API Gateway talks to it, on behalf
+// of the end users.
+var Policy = mtls.Policy{
+	proto.TransferService_CreateTransfer_FullMethodName:      {mtls.APIGateway},
+	proto.TransferService_CreateQuote_FullMethodName:         {mtls.APIGateway},
+	proto.TransferService_CreateStandingOrder_FullMethodName: {mtls.APIGateway},
+	proto.TransferService_ListStandingOrders_FullMethodName:  {mtls.APIGateway},
+	proto.TransferService_CancelStandingOrder_FullMethodName: {mtls.APIGateway},
+}
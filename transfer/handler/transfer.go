@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"account/money"
+	accountpb "account/proto"
+	"context"
+	"log"
+	"time"
+	"transfer/model"
+	"transfer/proto"
+	"transfer/service"
+
+	"github.com/google/uuid"
+)
+
+type TransferHandler struct {
+	proto.UnimplementedTransferServiceServer
+	service *service.TransferService
+}
+
+func NewTransferHandler(service *service.TransferService) *TransferHandler {
+	return &TransferHandler{service: service}
+}
+
+func toProtoMoney(currency string, units int64) *accountpb.Money {
+	return &accountpb.Money{Currency: currency, Units: units}
+}
+
+func (h *TransferHandler) CreateTransfer(ctx context.Context, req *proto.CreateTransferRequest) (*proto.CreateTransferResponse, error) {
+	fromAccountID, err := uuid.Parse(req.FromAccountId)
+	if err != nil {
+		log.Printf("gRPC CreateTransfer: Failed to parse source account ID: %v\n", err)
+		return nil, model.ErrInvalidArgument
+	}
+	toAccountID, err := uuid.Parse(req.ToAccountId)
+	if err != nil {
+		log.Printf("gRPC CreateTransfer: Failed to parse destination account ID: %v\n", err)
+		return nil, model.ErrInvalidArgument
+	}
+	var quoteID uuid.NullUUID
+	if req.QuoteId != "" {
+		id, err := uuid.Parse(req.QuoteId)
+		if err != nil {
+			log.Printf("gRPC CreateTransfer: Failed to parse quote ID: %v\n", err)
+			return nil, model.ErrInvalidArgument
+		}
+		quoteID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+	if !money.Supported(req.Amount.GetCurrency()) {
+		log.Printf("gRPC CreateTransfer: Unsupported currency %q\n", req.Amount.GetCurrency())
+		return nil, model.ErrUnsupportedCurrency
+	}
+
+	transfer, err := h.service.CreateTransfer(ctx, &model.Transfer{
+		FromAccountID:  fromAccountID,
+		ToAccountID:    toAccountID,
+		Amount:         req.Amount.GetUnits(),
+		Currency:       req.Amount.GetCurrency(),
+		QuoteID:        quoteID,
+		IdempotencyKey: req.IdempotencyKey,
+	})
+	if err != nil {
+		log.Printf("gRPC CreateTransfer: Failed to create transfer: %v\n", err)
+		return nil, err
+	}
+
+	return &proto.CreateTransferResponse{
+		TransferId:     transfer.TransferID.String(),
+		IdempotencyKey: transfer.IdempotencyKey,
+		Debited:        toProtoMoney(transfer.Currency, transfer.Amount),
+		Credited:       toProtoMoney(transfer.ToCurrency, transfer.ToAmount),
+		Rate:           transfer.Rate,
+	}, nil
+}
+
+func (h *TransferHandler) CreateQuote(ctx context.Context, req *proto.CreateQuoteRequest) (*proto.Quote, error) {
+	amount := money.Money{Currency: req.Amount.GetCurrency(), Units: req.Amount.GetUnits()}
+	quote, err := h.service.CreateQuote(ctx, amount, req.TargetCurrency)
+	if err != nil {
+		log.Printf("gRPC CreateQuote: Failed to create quote: %v\n", err)
+		return nil, err
+	}
+
+	return &proto.Quote{
+		QuoteId:      quote.QuoteID.String(),
+		Amount:       toProtoMoney(quote.Currency, quote.Amount),
+		TargetAmount: toProtoMoney(quote.TargetCurrency, quote.TargetAmount),
+		MidRate:      quote.MidRate,
+		Rate:         quote.Rate,
+		SpreadBps:    quote.SpreadBps,
+		ExpiresAt:    quote.ExpiresAt.Unix(),
+	}, nil
+}
+
+func (h *TransferHandler) CreateStandingOrder(ctx context.Context, req *proto.CreateStandingOrderRequest) (*proto.StandingOrder, error) {
+	fromAccountID, err := uuid.Parse(req.FromAccountId)
+	if err != nil {
+		log.Printf("gRPC CreateStandingOrder: Failed to parse source account ID: %v\n", err)
+		return nil, model.ErrInvalidArgument
+	}
+	toAccountID, err := uuid.Parse(req.ToAccountId)
+	if err != nil {
+		log.Printf("gRPC CreateStandingOrder: Failed to parse destination account ID: %v\n", err)
+		return nil, model.ErrInvalidArgument
+	}
+	order := &model.StandingOrder{
+		FromAccountID:  fromAccountID,
+		ToAccountID:    toAccountID,
+		Schedule:       req.Schedule,
+		MaxExecutions:  req.MaxExecutions,
+		Memo:           req.Memo,
+		IdempotencyKey: req.IdempotencyKey,
+	}
+	switch transfer := req.Transfer.(type) {
+	case *proto.CreateStandingOrderRequest_Amount:
+		order.Currency, order.Amount = transfer.Amount.GetCurrency(), transfer.Amount.GetUnits()
+	case *proto.CreateStandingOrderRequest_SweepAbove:
+		order.Currency, order.Sweep, order.SweepAbove = transfer.SweepAbove.GetCurrency(), true, transfer.SweepAbove.GetUnits()
+	}
+	if !money.Supported(order.Currency) {
+		log.Printf("gRPC CreateStandingOrder: Unsupported currency %q\n", order.Currency)
+		return nil, model.ErrUnsupportedCurrency
+	}
+	if req.StartsAt != 0 {
+		order.StartsAt = time.Unix(req.StartsAt, 0)
+	}
+	if req.EndsAt != 0 {
+		order.EndsAt = time.Unix(req.EndsAt, 0)
+	}
+
+	createdOrder, err := h.service.CreateStandingOrder(ctx, order)
+	if err != nil {
+		log.Printf("gRPC CreateStandingOrder: Failed to create standing order: %v\n", err)
+		return nil, err
+	}
+	return toProtoStandingOrder(createdOrder), nil
+}
+
+func (h *TransferHandler) ListStandingOrders(ctx context.Context, req *proto.ListStandingOrdersRequest) (*proto.ListStandingOrdersResponse, error) {
+	accountID, err := uuid.Parse(req.AccountId)
+	if err != nil {
+		log.Printf("gRPC ListStandingOrders: Failed to parse account ID: %v\n", err)
+		return nil, model.ErrInvalidArgument
+	}
+	orders, err := h.service.ListStandingOrders(ctx, accountID)
+	if err != nil {
+		log.Printf("gRPC ListStandingOrders: Failed to list standing orders: %v\n", err)
+		return nil, err
+	}
+
+	res := &proto.ListStandingOrdersResponse{StandingOrders: make([]*proto.StandingOrder, len(orders))}
+	for i, order := range orders {
+		res.StandingOrders[i] = toProtoStandingOrder(order)
+	}
+	return res, nil
+}
+
+func (h *TransferHandler) CancelStandingOrder(ctx context.Context, req *proto.CancelStandingOrderRequest) (*proto.StandingOrder, error) {
+	orderID, err := uuid.Parse(req.StandingOrderId)
+	if err != nil {
+		log.Printf("gRPC CancelStandingOrder: Failed to parse standing order ID: %v\n", err)
+		return nil, model.ErrInvalidArgument
+	}
+	order, err := h.service.CancelStandingOrder(ctx, orderID)
+	if err != nil {
+		log.Printf("gRPC CancelStandingOrder: Failed to cancel standing order: %v\n", err)
+		return nil, err
+	}
+	return toProtoStandingOrder(order), nil
+}
+
+func toProtoStandingOrder(order *model.StandingOrder) *proto.StandingOrder {
+	res := &proto.StandingOrder{
+		StandingOrderId: order.StandingOrderID.String(),
+		FromAccountId:   order.FromAccountID.String(),
+		ToAccountId:     order.ToAccountID.String(),
+		Schedule:        order.Schedule,
+		StartsAt:        order.StartsAt.Unix(),
+		MaxExecutions:   order.MaxExecutions,
+		Executions:      order.Executions,
+		Status:          order.Status,
+		Memo:            order.Memo,
+		IdempotencyKey:  order.IdempotencyKey,
+		CreatedAt:       order.CreatedAt.Unix(),
+	}
+	if order.Sweep {
+		res.SweepAbove = toProtoMoney(order.Currency, order.SweepAbove)
+	} else {
+		res.Amount = toProtoMoney(order.Currency, order.Amount)
+	}
+	if !order.EndsAt.IsZero() {
+		res.EndsAt = order.EndsAt.Unix()
+	}
+	if !order.NextRunAt.IsZero() {
+		res.NextRunAt = order.NextRunAt.Unix()
+	}
+	return res
+}
@@ -0,0 +1,70 @@
+// Package fx prices the transfers between accounts of different currencies.
+//
+// Rates come from a RateProvider as mid-market rates. A Quoter turns them into quotes that a transfer can be executed
+// at: the rate of a quote is the mid rate minus a spread, and it is only valid until the quote expires. Rates are exact
+// rationals, rounded to RateDecimals decimals, and converted amounts are rounded down to the minor unit of their
+// currency, so a conversion never credits more than the rate allows.
+package fx
+
+import (
+	"account/money"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// RateDecimals is the number of decimals rates are rounded to, as stored by the transfer database.
+const RateDecimals = 12
+
+var (
+	ErrNoRate      = errors.New("fx: no rate for the currency pair")
+	ErrInvalidRate = errors.New("fx: invalid rate")
+)
+
+// RateProvider gives the mid-market rates between currencies.
+type RateProvider interface {
+	// Rate returns how many major units of to one major unit of from is worth, or ErrNoRate.
+	Rate(ctx context.Context, from, to string) (*big.Rat, error)
+}
+
+// ParseRate parses a decimal rate such as "1.0850", which must be positive.
+func ParseRate(s string) (*big.Rat, error) {
+	rate, ok := new(big.Rat).SetString(s)
+	if !ok || rate.Sign() <= 0 {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidRate, s)
+	}
+	return rate, nil
+}
+
+// FormatRate formats rate as a decimal rounded to RateDecimals decimals.
+func FormatRate(rate *big.Rat) string {
+	return rate.FloatString(RateDecimals)
+}
+
+// Convert converts amount to the currency to at rate, in major units of to per major unit of the currency of amount.
+// The result is rounded toward zero to the minor unit of to.
+func Convert(amount money.Money, to string, rate *big.Rat) (money.Money, error) {
+	fromDigits, err := money.MinorUnits(amount.Currency)
+	if err != nil {
+		return money.Money{}, err
+	}
+	toDigits, err := money.MinorUnits(to)
+	if err != nil {
+		return money.Money{}, err
+	}
+
+	// units * rate * 10^toDigits / 10^fromDigits
+	num := new(big.Int).Mul(big.NewInt(amount.Units), rate.Num())
+	num.Mul(num, pow10(toDigits))
+	den := new(big.Int).Mul(rate.Denom(), pow10(fromDigits))
+	units := num.Quo(num, den)
+	if !units.IsInt64() {
+		return money.Money{}, money.ErrOverflow
+	}
+	return money.Money{Currency: to, Units: units.Int64()}, nil
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
@@ -0,0 +1,105 @@
+package fx
+
+import (
+	"account/money"
+	"context"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func rate(t *testing.T, s string) *big.Rat {
+	r, err := ParseRate(s)
+	require.NoError(t, err)
+	return r
+}
+
+func TestConvert(t *testing.T) {
+	for _, tc := range []struct {
+		amount   money.Money
+		to, rate string
+		expected money.Money
+	}{
+		{money.Money{Currency: "EUR", Units: 10_000}, "USD", "1.085", money.Money{Currency: "USD", Units: 10_850}},
+		// rounded down to the cent
+		{money.Money{Currency: "EUR", Units: 1}, "USD", "1.085", money.Money{Currency: "USD", Units: 1}},
+		{money.Money{Currency: "USD", Units: 10_000}, "GBP", "0.787401574803", money.Money{Currency: "GBP", Units: 7874}},
+		// currencies with different minor units
+		{money.Money{Currency: "USD", Units: 10_000}, "JPY", "149.5", money.Money{Currency: "JPY", Units: 14_950}},
+		{money.Money{Currency: "JPY", Units: 14_950}, "USD", "0.0066889632", money.Money{Currency: "USD", Units: 9999}},
+		{money.Money{Currency: "USD", Units: 100}, "BHD", "0.376", money.Money{Currency: "BHD", Units: 376}},
+	} {
+		converted, err := Convert(tc.amount, tc.to, rate(t, tc.rate))
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, converted, "%v at %s", tc.amount, tc.rate)
+	}
+
+	_, err := Convert(money.Money{Currency: "USD", Units: 1 << 62}, "JPY", rate(t, "1000"))
+	require.ErrorIs(t, err, money.ErrOverflow)
+	_, err = Convert(money.Money{Currency: "USD", Units: 1}, "XXX", rate(t, "1"))
+	require.ErrorIs(t, err, money.ErrUnknownCurrency)
+}
+
+func TestParseRate(t *testing.T) {
+	require.Equal(t, "1.085000000000", FormatRate(rate(t, "1.085")))
+	for _, s := range []string{"", "0", "-1.2", "abc"} {
+		_, err := ParseRate(s)
+		require.ErrorIs(t, err, ErrInvalidRate, s)
+	}
+}
+
+func TestStaticProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("EUR/USD: \"1.25\"\nUSD/JPY: \"150\"\n"), 0o600))
+	p, err := LoadStaticProvider(path)
+	require.NoError(t, err)
+
+	r, err := p.Rate(context.Background(), "EUR", "USD")
+	require.NoError(t, err)
+	require.Equal(t, "1.25", r.FloatString(2))
+	// the opposite pair is the inverse
+	r, err = p.Rate(context.Background(), "USD", "EUR")
+	require.NoError(t, err)
+	require.Equal(t, "0.80", r.FloatString(2))
+	_, err = p.Rate(context.Background(), "EUR", "JPY")
+	require.ErrorIs(t, err, ErrNoRate)
+
+	require.NoError(t, os.WriteFile(path, []byte("EURUSD: \"1.25\"\n"), 0o600))
+	_, err = LoadStaticProvider(path)
+	require.Error(t, err)
+	require.NoError(t, os.WriteFile(path, []byte("EUR/USD: \"-1\"\n"), 0o600))
+	_, err = LoadStaticProvider(path)
+	require.ErrorIs(t, err, ErrInvalidRate)
+}
+
+func TestQuote(t *testing.T) {
+	provider := NewMemoryProvider()
+	provider.Set("EUR", "USD", rate(t, "1.10"))
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	quoter := NewQuoter(provider, 100, 30*time.Second).WithClock(func() time.Time { return now })
+
+	quote, err := quoter.Quote(context.Background(), money.Money{Currency: "EUR", Units: 10_000}, "USD")
+	require.NoError(t, err)
+	require.Equal(t, "1.100000000000", quote.MidRate)
+	// 1% spread
+	require.Equal(t, "1.089000000000", quote.Rate)
+	require.Equal(t, int64(10_890), quote.TargetAmount)
+	require.Equal(t, "USD", quote.TargetCurrency)
+	require.Equal(t, now.Add(30*time.Second), quote.ExpiresAt)
+
+	// the rates can change between quotes
+	provider.Set("USD", "EUR", rate(t, "0.5"))
+	quote, err = quoter.Quote(context.Background(), money.Money{Currency: "EUR", Units: 10_000}, "USD")
+	require.NoError(t, err)
+	require.Equal(t, "2.000000000000", quote.MidRate)
+
+	_, err = quoter.Quote(context.Background(), money.Money{Currency: "EUR", Units: 1}, "JPY")
+	require.ErrorIs(t, err, ErrNoRate)
+	provider.Set("JPY", "USD", rate(t, "0.0066"))
+	_, err = quoter.Quote(context.Background(), money.Money{Currency: "JPY", Units: 1}, "USD")
+	require.ErrorIs(t, err, ErrZeroAmount)
+}
@@ -0,0 +1,94 @@
+package fx
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// rates maps "FROM/TO" currency pairs to their rate.
+type rates map[string]*big.Rat
+
+func pair(from, to string) string {
+	return from + "/" + to
+}
+
+// lookup returns the rate of the pair, or the inverse of the rate of the opposite pair if only that one is known.
+func (r rates) lookup(from, to string) (*big.Rat, error) {
+	if rate, ok := r[pair(from, to)]; ok {
+		return new(big.Rat).Set(rate), nil
+	}
+	if rate, ok := r[pair(to, from)]; ok {
+		return new(big.Rat).Inv(rate), nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrNoRate, pair(from, to))
+}
+
+// StaticProvider serves fixed rates loaded from a file.
+type StaticProvider struct {
+	rates rates
+}
+
+// LoadStaticProvider reads the rates of a YAML file mapping currency pairs to decimal rates, such as
+//
+//	EUR/USD: "1.0850" # 1 EUR is worth 1.0850 USD
+//	GBP/USD: "1.2700"
+//
+// The rate of the opposite pair is the inverse, unless the file has both.
+func LoadStaticProvider(path string) (*StaticProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rates file: %w", err)
+	}
+	var raw map[string]string
+	if err = yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse rates file %s: %w", path, err)
+	}
+
+	p := &StaticProvider{rates: make(rates, len(raw))}
+	for key, value := range raw {
+		from, to, ok := strings.Cut(key, "/")
+		if !ok || from == "" || to == "" || from == to {
+			return nil, fmt.Errorf("rates file %s: invalid currency pair %q", path, key)
+		}
+		rate, err := ParseRate(value)
+		if err != nil {
+			return nil, fmt.Errorf("rates file %s: %s: %w", path, key, err)
+		}
+		p.rates[pair(from, to)] = rate
+	}
+	return p, nil
+}
+
+func (p *StaticProvider) Rate(_ context.Context, from, to string) (*big.Rat, error) {
+	return p.rates.lookup(from, to)
+}
+
+// MemoryProvider serves rates that can be changed at any time. It is meant for tests.
+type MemoryProvider struct {
+	mu    sync.RWMutex
+	rates rates
+}
+
+func NewMemoryProvider() *MemoryProvider {
+	return &MemoryProvider{rates: make(rates)}
+}
+
+// Set sets the rate of the pair from/to, and removes the one of the opposite pair so that it is the inverse.
+func (p *MemoryProvider) Set(from, to string, rate *big.Rat) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.rates[pair(from, to)] = new(big.Rat).Set(rate)
+	delete(p.rates, pair(to, from))
+}
+
+func (p *MemoryProvider) Rate(_ context.Context, from, to string) (*big.Rat, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.rates.lookup(from, to)
+}
@@ -0,0 +1,72 @@
+package fx
+
+import (
+	"account/money"
+	"context"
+	"errors"
+	"math/big"
+	"time"
+	"transfer/model"
+
+	"github.com/google/uuid"
+)
+
+// ErrZeroAmount is returned for quotes whose amount would be converted to nothing.
+var ErrZeroAmount = errors.New("fx: converted amount rounds to zero")
+
+// Quoter prices cross-currency transfers from the rates of a RateProvider.
+type Quoter struct {
+	provider  RateProvider
+	spreadBps int32         // margin taken on the mid rate, in basis points
+	ttl       time.Duration // how long quotes are valid
+	now       func() time.Time
+}
+
+func NewQuoter(provider RateProvider, spreadBps int32, ttl time.Duration) *Quoter {
+	return &Quoter{provider: provider, spreadBps: spreadBps, ttl: ttl, now: time.Now}
+}
+
+// WithClock returns a new Quoter that timestamps its quotes with now.
+func (q *Quoter) WithClock(now func() time.Time) *Quoter {
+	cp := *q
+	cp.now = now
+	return &cp
+}
+
+// Quote prices the conversion of amount to the currency to, which must differ from the currency of amount.
+// The quote isn't stored: it is only valid once the caller has.
+func (q *Quoter) Quote(ctx context.Context, amount money.Money, to string) (*model.Quote, error) {
+	mid, err := q.provider.Rate(ctx, amount.Currency, to)
+	if err != nil {
+		return nil, err
+	}
+	// rounded first, so that the target amount is the one the stored rate gives
+	midRate, err := ParseRate(FormatRate(mid))
+	if err != nil {
+		return nil, err
+	}
+	spread := big.NewRat(int64(10_000-q.spreadBps), 10_000)
+	rate, err := ParseRate(FormatRate(new(big.Rat).Mul(midRate, spread)))
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := Convert(amount, to, rate)
+	if err != nil {
+		return nil, err
+	}
+	if target.Units <= 0 {
+		return nil, ErrZeroAmount
+	}
+	return &model.Quote{
+		QuoteID:        uuid.New(),
+		Amount:         amount.Units,
+		Currency:       amount.Currency,
+		TargetAmount:   target.Units,
+		TargetCurrency: target.Currency,
+		MidRate:        FormatRate(midRate),
+		Rate:           FormatRate(rate),
+		SpreadBps:      q.spreadBps,
+		ExpiresAt:      q.now().Add(q.ttl),
+	}, nil
+}
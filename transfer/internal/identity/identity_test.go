@@ -0,0 +1,64 @@
+package identity
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var testKey = []byte("test-key")
+
+func signToken(t *testing.T, userID uuid.UUID, key []byte) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Subject:   userID.String(),
+		Issuer:    Issuer,
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+	}).SignedString(key)
+	require.NoError(t, err)
+	return token
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	const method = "/proto.TransferService/CreateTransfer"
+	userID := uuid.New()
+	withMetadata := func(kv ...string) context.Context {
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs(kv...))
+	}
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		method string
+		want   codes.Code
+	}{
+		{"valid token", withMetadata(MetadataKey, "Bearer "+signToken(t, userID, testKey)), method, codes.OK},
+		{"no token", context.Background(), method, codes.Unauthenticated},
+		{"not a bearer token", withMetadata(MetadataKey, signToken(t, userID, testKey)), method, codes.Unauthenticated},
+		{"other key", withMetadata(MetadataKey, "Bearer "+signToken(t, userID, []byte("other-key"))), method, codes.Unauthenticated},
+		{"health check", context.Background(), "/grpc.health.v1.Health/Check", codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got context.Context
+			handler := func(ctx context.Context, _ any) (any, error) {
+				got = ctx
+				return nil, nil
+			}
+			_, err := UnaryServerInterceptor(testKey)(tt.ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+			require.Equal(t, tt.want, status.Code(err))
+			if tt.want == codes.OK && tt.method == method {
+				id, err := UserID(got)
+				require.NoError(t, err)
+				require.Equal(t, userID, id)
+			}
+		})
+	}
+}
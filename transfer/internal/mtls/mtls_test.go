@@ -0,0 +1,57 @@
+package mtls
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// peerContext returns a context whose peer presented a verified certificate of the service identity.
+func peerContext(identity string) context.Context {
+	cert := &x509.Certificate{URIs: []*url.URL{{Scheme: "spiffe", Host: TrustDomain, Path: "/" + identity}}}
+	return peer.NewContext(context.Background(), &peer.Peer{
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}},
+	})
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	const method = "/proto.TransferService/CreateTransfer"
+	policy := Policy{method: {APIGateway}}
+
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		method     string
+		tlsEnabled bool
+		want       codes.Code
+	}{
+		{"allowed", peerContext(APIGateway), method, true, codes.OK},
+		{"not in policy", peerContext(AccountService), method, true, codes.PermissionDenied},
+		{"method not in policy", peerContext(APIGateway), "/proto.TransferService/Unknown", true, codes.PermissionDenied},
+		{"no certificate", context.Background(), method, true, codes.Unauthenticated},
+		{"health check", context.Background(), "/grpc.health.v1.Health/Check", true, codes.OK},
+		{"TLS disabled", context.Background(), method, false, codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			handler := func(context.Context, any) (any, error) {
+				called = true
+				return nil, nil
+			}
+			interceptor := UnaryServerInterceptor(policy, tt.tlsEnabled, nil)
+			_, err := interceptor(tt.ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+			require.Equal(t, tt.want, status.Code(err))
+			require.Equal(t, tt.want == codes.OK, called)
+		})
+	}
+}
@@ -0,0 +1,87 @@
+// Package notify tells the owners of standing orders, or whoever notifies them, that an execution of their order
+// failed for good.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+	"transfer/model"
+)
+
+// Notifier is told about the executions of standing orders that failed after their last attempt.
+type Notifier interface {
+	StandingOrderFailed(ctx context.Context, order *model.StandingOrder, execution *model.StandingOrderExecution) error
+}
+
+// LogNotifier only logs the failures, for when no webhook is configured.
+type LogNotifier struct{}
+
+func (LogNotifier) StandingOrderFailed(_ context.Context, order *model.StandingOrder, execution *model.StandingOrderExecution) error {
+	log.Printf("notify: Execution %v of standing order %v of user %v failed: %s\n",
+		execution.ExecutionID, order.StandingOrderID, order.UserID, execution.LastError)
+	return nil
+}
+
+// Event is the JSON body posted by WebhookNotifier.
+type Event struct {
+	Type            string    `json:"type"` // "standing_order.execution_failed"
+	StandingOrderID string    `json:"standing_order_id"`
+	ExecutionID     string    `json:"execution_id"`
+	UserID          string    `json:"user_id"`
+	FromAccountID   string    `json:"from_account_id"`
+	ToAccountID     string    `json:"to_account_id"`
+	Amount          int64     `json:"amount"` // in minor units of Currency
+	Currency        string    `json:"currency"`
+	ScheduledAt     time.Time `json:"scheduled_at"`
+	Attempts        int32     `json:"attempts"`
+	Error           string    `json:"error"`
+}
+
+// WebhookNotifier posts an Event to a URL, such as the endpoint of the service sending the emails to the customers.
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookNotifier creates a WebhookNotifier posting to url, giving up on a request after timeout.
+func NewWebhookNotifier(url string, timeout time.Duration) *WebhookNotifier {
+	return &WebhookNotifier{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+func (n *WebhookNotifier) StandingOrderFailed(ctx context.Context, order *model.StandingOrder, execution *model.StandingOrderExecution) error {
+	body, err := json.Marshal(Event{
+		Type:            "standing_order.execution_failed",
+		StandingOrderID: order.StandingOrderID.String(),
+		ExecutionID:     execution.ExecutionID.String(),
+		UserID:          order.UserID.String(),
+		FromAccountID:   order.FromAccountID.String(),
+		ToAccountID:     order.ToAccountID.String(),
+		Amount:          execution.Amount,
+		Currency:        order.Currency,
+		ScheduledAt:     execution.ScheduledAt,
+		Attempts:        execution.Attempts,
+		Error:           execution.LastError,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook responded %s", resp.Status)
+	}
+	return nil
+}
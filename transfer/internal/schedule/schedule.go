@@ -0,0 +1,361 @@
+// Package schedule computes the occurrences of the schedules of standing orders, written either as a cron expression
+// or as an iCalendar recurrence rule (RFC 5545). Occurrences fall on whole minutes, in UTC.
+//
+// Cron expressions have the 5 fields "minute hour day-of-month month day-of-week", each "*" or a list of values,
+// ranges and steps such as "1,15", "1-5" or "*/2", or are one of @daily, @weekly, @monthly and @yearly. As in cron, a
+// day matches if either its day of month or its day of week does when both fields are restricted.
+//
+// Recurrence rules support FREQ (DAILY, WEEKLY, MONTHLY or YEARLY), INTERVAL, BYDAY (plain days such as MO),
+// BYMONTHDAY (negative values count from the end of the month), BYMONTH, BYHOUR and BYMINUTE. The start of the
+// schedule is the DTSTART of the rule: it anchors INTERVAL, and gives the day and time of the occurrences the rule
+// doesn't set. COUNT and UNTIL aren't supported, since a standing order has its own end and maximum number of
+// executions.
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalid is wrapped by the errors of Parse.
+var ErrInvalid = errors.New("invalid schedule")
+
+// horizonYears is how far Next looks for an occurrence before deciding there is none. Eight years cover the leap days,
+// which can be eight years apart.
+const horizonYears = 8
+
+// maxInterval bounds the INTERVAL of a rule, which Next walks through day by day.
+const maxInterval = 100
+
+// Schedule is a series of occurrences.
+type Schedule interface {
+	// Next returns the first occurrence strictly after t, or the zero time if there is none.
+	Next(t time.Time) time.Time
+}
+
+// Parse parses expr, a cron expression or a recurrence rule optionally prefixed with "RRULE:", of a schedule starting
+// at start. The occurrences of a cron expression before start are skipped as well.
+func Parse(expr string, start time.Time) (Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	start = start.UTC().Truncate(time.Minute)
+	if rule, ok := strings.CutPrefix(expr, "RRULE:"); ok || strings.Contains(expr, "FREQ=") {
+		r, err := parseRule(rule, start)
+		if err != nil {
+			return nil, err
+		}
+		return r, nil
+	}
+	c, err := parseCron(expr, start)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// First returns the first occurrence of s at or after t, or the zero time if there is none.
+func First(s Schedule, t time.Time) time.Time {
+	return s.Next(t.Add(-time.Nanosecond))
+}
+
+// days calls match for every day from the day of t, for horizonYears times interval, and returns the first time it
+// returns a non-zero time.
+func days(t time.Time, interval int, match func(day time.Time) time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	end := day.AddDate(horizonYears*interval, 0, 0)
+	for ; day.Before(end); day = day.AddDate(0, 0, 1) {
+		if next := match(day); !next.IsZero() {
+			return next
+		}
+	}
+	return time.Time{}
+}
+
+// timeOfDay returns the first time of day, at one of hours and minutes, strictly after t and not before start.
+func timeOfDay(day time.Time, hours, minutes []int, t, start time.Time) time.Time {
+	for _, hour := range hours {
+		for _, minute := range minutes {
+			next := day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+			if next.After(t) && !next.Before(start) {
+				return next
+			}
+		}
+	}
+	return time.Time{}
+}
+
+type cron struct {
+	start                       time.Time
+	minutes, hours              []int
+	monthDays, months, weekDays []int
+	anyMonthDay, anyWeekDay     bool
+}
+
+var cronMacros = map[string]string{
+	"@daily":    "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+}
+
+func parseCron(expr string, start time.Time) (*cron, error) {
+	if macro, ok := cronMacros[expr]; ok {
+		expr = macro
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("%w: cron expression %q must have 5 fields", ErrInvalid, expr)
+	}
+	c := &cron{start: start, anyMonthDay: fields[2] == "*", anyWeekDay: fields[4] == "*"}
+	var err error
+	if c.minutes, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, err
+	}
+	if c.hours, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, err
+	}
+	if c.monthDays, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, err
+	}
+	if c.months, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, err
+	}
+	if c.weekDays, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, err
+	}
+	// 7 is Sunday too
+	for i, d := range c.weekDays {
+		if d == 7 {
+			c.weekDays[i] = 0
+		}
+	}
+	return c, nil
+}
+
+// parseCronField returns the sorted values of a field between min and max.
+func parseCronField(field string, min, max int) ([]int, error) {
+	var values []int
+	for _, item := range strings.Split(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepStr); err != nil || step <= 0 {
+				return nil, fmt.Errorf("%w: invalid step in %q", ErrInvalid, item)
+			}
+		}
+		lo, hi := min, max
+		if rng != "*" {
+			loStr, hiStr, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = strconv.Atoi(loStr); err != nil {
+				return nil, fmt.Errorf("%w: invalid value in %q", ErrInvalid, item)
+			}
+			hi = lo
+			if isRange {
+				if hi, err = strconv.Atoi(hiStr); err != nil {
+					return nil, fmt.Errorf("%w: invalid range in %q", ErrInvalid, item)
+				}
+			} else if hasStep {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return nil, fmt.Errorf("%w: %q out of range [%d, %d]", ErrInvalid, item, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			values = append(values, v)
+		}
+	}
+	slices.Sort(values)
+	return slices.Compact(values), nil
+}
+
+func (c *cron) matchesDay(day time.Time) bool {
+	if !slices.Contains(c.months, int(day.Month())) {
+		return false
+	}
+	monthDay := slices.Contains(c.monthDays, day.Day())
+	weekDay := slices.Contains(c.weekDays, int(day.Weekday()))
+	switch {
+	case c.anyMonthDay && c.anyWeekDay:
+		return true
+	case c.anyMonthDay:
+		return weekDay
+	case c.anyWeekDay:
+		return monthDay
+	}
+	return monthDay || weekDay
+}
+
+func (c *cron) Next(t time.Time) time.Time {
+	t = t.UTC()
+	return days(t, 1, func(day time.Time) time.Time {
+		if !c.matchesDay(day) {
+			return time.Time{}
+		}
+		return timeOfDay(day, c.hours, c.minutes, t, c.start)
+	})
+}
+
+type rule struct {
+	start          time.Time
+	freq           string
+	interval       int
+	weekDays       []time.Weekday
+	monthDays      []int
+	months         []int
+	hours, minutes []int
+}
+
+var ruleWeekDays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+func parseRule(expr string, start time.Time) (*rule, error) {
+	r := &rule{start: start, interval: 1}
+	for _, part := range strings.Split(expr, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: invalid rule part %q", ErrInvalid, part)
+		}
+		var err error
+		switch name {
+		case "FREQ":
+			if !slices.Contains([]string{"DAILY", "WEEKLY", "MONTHLY", "YEARLY"}, value) {
+				return nil, fmt.Errorf("%w: unsupported FREQ %q", ErrInvalid, value)
+			}
+			r.freq = value
+		case "INTERVAL":
+			if r.interval, err = strconv.Atoi(value); err != nil || r.interval < 1 || r.interval > maxInterval {
+				return nil, fmt.Errorf("%w: INTERVAL %q out of range [1, %d]", ErrInvalid, value, maxInterval)
+			}
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				weekDay, ok := ruleWeekDays[day]
+				if !ok {
+					return nil, fmt.Errorf("%w: unsupported BYDAY %q", ErrInvalid, day)
+				}
+				r.weekDays = append(r.weekDays, weekDay)
+			}
+		case "BYMONTHDAY":
+			if r.monthDays, err = parseRuleList(value, -31, 31); err != nil {
+				return nil, err
+			}
+			if slices.Contains(r.monthDays, 0) {
+				return nil, fmt.Errorf("%w: BYMONTHDAY can't be 0", ErrInvalid)
+			}
+		case "BYMONTH":
+			if r.months, err = parseRuleList(value, 1, 12); err != nil {
+				return nil, err
+			}
+		case "BYHOUR":
+			if r.hours, err = parseRuleList(value, 0, 23); err != nil {
+				return nil, err
+			}
+		case "BYMINUTE":
+			if r.minutes, err = parseRuleList(value, 0, 59); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("%w: unsupported rule part %s", ErrInvalid, name)
+		}
+	}
+	if r.freq == "" {
+		return nil, fmt.Errorf("%w: FREQ is required", ErrInvalid)
+	}
+	if len(r.weekDays) > 0 && r.freq != "WEEKLY" && r.freq != "DAILY" {
+		return nil, fmt.Errorf("%w: BYDAY is only supported with FREQ=DAILY or FREQ=WEEKLY", ErrInvalid)
+	}
+
+	// what the rule doesn't set is taken from its start
+	if r.hours == nil {
+		r.hours = []int{start.Hour()}
+	}
+	if r.minutes == nil {
+		r.minutes = []int{start.Minute()}
+	}
+	if r.weekDays == nil && r.freq == "WEEKLY" {
+		r.weekDays = []time.Weekday{start.Weekday()}
+	}
+	if r.monthDays == nil && (r.freq == "MONTHLY" || r.freq == "YEARLY") {
+		r.monthDays = []int{start.Day()}
+	}
+	if r.months == nil && r.freq == "YEARLY" {
+		r.months = []int{int(start.Month())}
+	}
+	return r, nil
+}
+
+// parseRuleList returns the sorted values of a comma-separated list of integers between min and max.
+func parseRuleList(value string, min, max int) ([]int, error) {
+	var values []int
+	for _, item := range strings.Split(value, ",") {
+		v, err := strconv.Atoi(item)
+		if err != nil || v < min || v > max {
+			return nil, fmt.Errorf("%w: %q out of range [%d, %d]", ErrInvalid, item, min, max)
+		}
+		values = append(values, v)
+	}
+	slices.Sort(values)
+	return slices.Compact(values), nil
+}
+
+// inPeriod reports whether day is in a period of the rule, i.e. a multiple of INTERVAL periods after its start.
+func (r *rule) inPeriod(day time.Time) bool {
+	var periods int
+	switch r.freq {
+	case "DAILY":
+		periods = int(day.Sub(time.Date(r.start.Year(), r.start.Month(), r.start.Day(), 0, 0, 0, 0, time.UTC)).Hours() / 24)
+	case "WEEKLY":
+		// weeks start on Monday, the default WKST
+		periods = int(weekStart(day).Sub(weekStart(r.start)).Hours() / (24 * 7))
+	case "MONTHLY":
+		periods = (day.Year()-r.start.Year())*12 + int(day.Month()) - int(r.start.Month())
+	case "YEARLY":
+		periods = day.Year() - r.start.Year()
+	}
+	return periods%r.interval == 0
+}
+
+func weekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, time.UTC)
+}
+
+func (r *rule) matchesDay(day time.Time) bool {
+	if !r.inPeriod(day) {
+		return false
+	}
+	if r.months != nil && !slices.Contains(r.months, int(day.Month())) {
+		return false
+	}
+	if r.weekDays != nil && !slices.Contains(r.weekDays, day.Weekday()) {
+		return false
+	}
+	if r.monthDays != nil {
+		// negative days count from the end of the month, -1 being its last day
+		daysInMonth := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+		return slices.Contains(r.monthDays, day.Day()) || slices.Contains(r.monthDays, day.Day()-daysInMonth-1)
+	}
+	return true
+}
+
+func (r *rule) Next(t time.Time) time.Time {
+	t = t.UTC()
+	from := t
+	if from.Before(r.start) {
+		from = r.start
+	}
+	return days(from, r.interval, func(day time.Time) time.Time {
+		if !r.matchesDay(day) {
+			return time.Time{}
+		}
+		return timeOfDay(day, r.hours, r.minutes, t, r.start)
+	})
+}
@@ -0,0 +1,82 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse("2006-01-02 15:04", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// occurrences returns the first n occurrences of expr at or after start.
+func occurrences(t *testing.T, expr string, start time.Time, n int) []string {
+	s, err := Parse(expr, start)
+	require.NoError(t, err)
+	var res []string
+	for next := First(s, start); !next.IsZero() && len(res) < n; next = s.Next(next) {
+		res = append(res, next.Format("2006-01-02 15:04"))
+	}
+	return res
+}
+
+func TestCron(t *testing.T) {
+	start := date("2026-01-30 10:00")
+	for _, tc := range []struct {
+		expr     string
+		expected []string
+	}{
+		{"0 9 1 * *", []string{"2026-02-01 09:00", "2026-03-01 09:00", "2026-04-01 09:00"}},
+		{"@monthly", []string{"2026-02-01 00:00", "2026-03-01 00:00", "2026-04-01 00:00"}},
+		{"30 10 * * 1-5", []string{"2026-01-30 10:30", "2026-02-02 10:30", "2026-02-03 10:30"}},
+		{"0 */12 * * *", []string{"2026-01-30 12:00", "2026-01-31 00:00", "2026-01-31 12:00"}},
+		// the start is an occurrence
+		{"0 10 30 * *", []string{"2026-01-30 10:00", "2026-03-30 10:00", "2026-04-30 10:00"}},
+		// either the day of month or the day of week
+		{"0 0 1 * 0", []string{"2026-02-01 00:00", "2026-02-08 00:00", "2026-02-15 00:00"}},
+		{"0 0 29 2 *", []string{"2028-02-29 00:00", "2032-02-29 00:00"}},
+	} {
+		require.Equal(t, tc.expected, occurrences(t, tc.expr, start, len(tc.expected)), tc.expr)
+	}
+}
+
+func TestRule(t *testing.T) {
+	start := date("2026-01-31 08:15")
+	for _, tc := range []struct {
+		expr     string
+		expected []string
+	}{
+		// months without a 31st are skipped, as in RFC 5545
+		{"FREQ=MONTHLY", []string{"2026-01-31 08:15", "2026-03-31 08:15", "2026-05-31 08:15"}},
+		{"RRULE:FREQ=MONTHLY;BYMONTHDAY=-1;BYHOUR=18;BYMINUTE=0", []string{"2026-01-31 18:00", "2026-02-28 18:00", "2026-03-31 18:00"}},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR", []string{"2026-02-09 08:15", "2026-02-13 08:15", "2026-02-23 08:15"}},
+		{"FREQ=DAILY;INTERVAL=10", []string{"2026-01-31 08:15", "2026-02-10 08:15", "2026-02-20 08:15"}},
+		{"FREQ=YEARLY;BYMONTH=6;BYMONTHDAY=15", []string{"2026-06-15 08:15", "2027-06-15 08:15", "2028-06-15 08:15"}},
+	} {
+		require.Equal(t, tc.expected, occurrences(t, tc.expr, start, len(tc.expected)), tc.expr)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"0 9 1 *",
+		"60 * * * *",
+		"0 9 0 * *",
+		"*/0 * * * *",
+		"FREQ=HOURLY",
+		"FREQ=MONTHLY;COUNT=3",
+		"FREQ=MONTHLY;BYDAY=MO",
+		"FREQ=MONTHLY;BYMONTHDAY=0",
+		"INTERVAL=2",
+	} {
+		_, err := Parse(expr, date("2026-01-01 00:00"))
+		require.ErrorIs(t, err, ErrInvalid, expr)
+	}
+}
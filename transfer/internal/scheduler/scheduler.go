@@ -0,0 +1,97 @@
+// Package scheduler periodically runs the batch jobs of the transfer service, such as executing the standing orders:
+// it creates the executions of their due occurrences, then attempts the due executions.
+//
+// Every replica runs the scheduler. The orders and executions are claimed with SELECT ... FOR UPDATE SKIP LOCKED, so
+// the replicas share the work instead of waiting for each other, and no row is processed twice at the same time.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+	"transfer/config"
+)
+
+// BatchFunc processes at most batchSize rows due before now, and returns how many it processed.
+type BatchFunc func(ctx context.Context, now time.Time, batchSize int32) (int64, error)
+
+type task struct {
+	name string
+	run  BatchFunc
+}
+
+type Scheduler struct {
+	interval  time.Duration
+	batchSize int32
+	tasks     []task
+}
+
+// New creates a Scheduler with the schedule and batch size of cfg. Register what it runs with AddTask.
+func New(cfg config.SchedulerConfig) *Scheduler {
+	return &Scheduler{
+		interval:  cfg.Interval,
+		batchSize: int32(cfg.BatchSize),
+	}
+}
+
+// AddTask registers run, which is called in the order of registration.
+func (s *Scheduler) AddTask(name string, run BatchFunc) {
+	s.tasks = append(s.tasks, task{name: name, run: run})
+}
+
+// Run runs the tasks every interval until ctx is cancelled. It returns right away if the scheduler is disabled.
+func (s *Scheduler) Run(ctx context.Context) {
+	if s.interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.RunOnce(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("scheduler: %v\n", err)
+			}
+		}
+	}
+}
+
+// RunOnce runs every task until it has nothing left due.
+func (s *Scheduler) RunOnce(ctx context.Context) error {
+	var errs []error
+	for _, t := range s.tasks {
+		if err := s.run(ctx, t); err != nil {
+			errs = append(errs, fmt.Errorf("failed to run %s: %w", t.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// run runs t batch by batch, until a batch comes back short.
+func (s *Scheduler) run(ctx context.Context, t task) error {
+	// fixed for the whole run, so that rows falling due meanwhile don't keep the loop going
+	now := time.Now()
+	var total int64
+	for {
+		n, err := t.run(ctx, now, s.batchSize)
+		total += n
+		if err != nil {
+			return err
+		}
+		if n < int64(s.batchSize) {
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+	if total > 0 {
+		log.Printf("scheduler: %s processed %d rows\n", t.name, total)
+	}
+	return nil
+}
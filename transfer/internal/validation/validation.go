@@ -0,0 +1,64 @@
+// Package validation enforces the buf.validate rules declared in the .proto files on every incoming request,
+// so that handlers only ever see well-formed requests.
+//
+// This is a copy of account/internal/validation, without the stream interceptor: each service is its own Go module
+// and cannot import the internal packages of another. Keep both in sync, since the gateway relies on the same
+// google.rpc.BadRequest details from every service.
+package validation
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+
+	"buf.build/go/protovalidate"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// UnaryServerInterceptor rejects a request that breaks any of its rules with InvalidArgument.
+// The status carries a google.rpc.BadRequest detail with one field violation per broken rule.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		msg, ok := req.(proto.Message)
+		if !ok {
+			return handler(ctx, req)
+		}
+		if err := protovalidate.Validate(msg); err != nil {
+			log.Printf("validation: %s: %v\n", info.FullMethod, err)
+			return nil, toStatus(err)
+		}
+		return handler(ctx, req)
+	}
+}
+
+func toStatus(err error) error {
+	var valErr *protovalidate.ValidationError
+	if !errors.As(err, &valErr) {
+		// the rules themselves failed to compile or evaluate, which is a bug on our side, not in the request
+		return status.Error(codes.Internal, "internal server error")
+	}
+
+	badRequest := &errdetails.BadRequest{}
+	for _, v := range valErr.Violations {
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       protovalidate.FieldPathString(v.Proto.GetField()),
+			Description: v.Proto.GetMessage(),
+			Reason:      reason(v.Proto.GetRuleId()),
+		})
+	}
+	st, detailsErr := status.New(codes.InvalidArgument, "invalid argument").WithDetails(badRequest)
+	if detailsErr != nil {
+		return status.Error(codes.InvalidArgument, "invalid argument")
+	}
+	return st.Err()
+}
+
+// reason turns a rule ID such as "string.uuid" into the UPPER_SNAKE_CASE form expected by google.rpc.BadRequest.
+func reason(ruleID string) string {
+	return strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(ruleID))
+}
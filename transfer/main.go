@@ -1,25 +1,132 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net"
-	"os"
+	"os/signal"
+	"syscall"
+	"time"
+	"transfer/client"
+	"transfer/config"
 	"transfer/db/initialize"
+	"transfer/handler"
+	"transfer/internal/fx"
+	"transfer/internal/identity"
+	"transfer/internal/mtls"
+	"transfer/internal/notify"
+	"transfer/internal/scheduler"
+	"transfer/internal/validation"
+	"transfer/proto"
+	"transfer/repository"
+	"transfer/service"
 
 	_ "github.com/lib/pq"
+	"google.golang.org/grpc"
 )
 
+// webhookTimeout bounds a notification of a failed execution of a standing order.
+const webhookTimeout = 10 * time.Second
+
 func main() {
-	db := initialize.ConnectDB()
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	db := initialize.ConnectDB(cfg.DB)
 	defer db.Close()
 
+	if !cfg.TLS.Enabled() {
+		log.Println("WARNING: TLS is disabled, the account service will reject transfer transactions")
+	}
+	accountClient, err := client.NewAccountClient(cfg.AccountServiceURL, cfg.TLS)
+	if err != nil {
+		log.Fatalf("Failed to create account client: %v", err)
+	}
+	defer accountClient.Close()
+
+	var rates fx.RateProvider = fx.NewMemoryProvider()
+	if cfg.FX.RatesFile != "" {
+		if rates, err = fx.LoadStaticProvider(cfg.FX.RatesFile); err != nil {
+			log.Fatalf("Failed to load FX rates: %v", err)
+		}
+	} else {
+		log.Println("WARNING: no FX rates file, transfers between accounts of different currencies are disabled")
+	}
+	quoter := fx.NewQuoter(rates, int32(cfg.FX.SpreadBps), cfg.FX.QuoteTTL)
+
+	transferRepo := repository.NewTransferRepository(db)
+	var notifier notify.Notifier = notify.LogNotifier{}
+	if cfg.Scheduler.FailureWebhookURL != "" {
+		notifier = notify.NewWebhookNotifier(cfg.Scheduler.FailureWebhookURL, webhookTimeout)
+	}
+	transferService := service.NewTransferService(transferRepo, db, accountClient, quoter).
+		WithStandingOrderRetries(cfg.Scheduler.MaxAttempts, cfg.Scheduler.RetryBackoff, cfg.Scheduler.Lease).
+		WithNotifier(notifier)
+	transferHandler := handler.NewTransferHandler(transferService)
+
+	// the due occurrences of the standing orders are scheduled, then executed, then the transfers left PENDING are
+	// finished
+	standingOrders := scheduler.New(cfg.Scheduler)
+	standingOrders.AddTask("schedule standing orders", transferService.ScheduleStandingOrders)
+	standingOrders.AddTask("execute standing orders", transferService.ExecuteStandingOrders)
+	standingOrders.AddTask("finish pending transfers", transferService.FinishPendingTransfers)
+
+	// cancelled on SIGINT/SIGTERM, which stops the scheduler and drains in-flight RPCs before exiting
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	schedulerDone := make(chan struct{})
+	go func() {
+		standingOrders.Run(ctx)
+		close(schedulerDone)
+	}()
+
 	// start the server
-	listener, err := net.Listen("tcp", fmt.Sprintf(":%s", os.Getenv("ACCOUNT_PORT")))
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.GRPCPort))
 	if err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 	defer listener.Close()
 
-	fmt.Printf("Server started on port %s\n", os.Getenv("ACCOUNT_PORT"))
+	// mTLS between internal services. Callers are authorized by the identity in their client certificate,
+	// and the end user by the access token the caller forwards in the request metadata.
+	// Requests are then checked against the buf.validate rules of transfer_service.proto before reaching the handlers.
+	serverOpts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(
+			mtls.UnaryServerInterceptor(handler.Policy, cfg.TLS.Enabled(), nil),
+			identity.UnaryServerInterceptor(cfg.JWT.Key()),
+			validation.UnaryServerInterceptor(),
+		),
+	}
+	if cfg.TLS.Enabled() {
+		creds, err := mtls.ServerCredentials(cfg.TLS)
+		if err != nil {
+			log.Fatalf("Failed to load TLS credentials: %v", err)
+		}
+		serverOpts = append(serverOpts, grpc.Creds(creds))
+	} else {
+		log.Println("WARNING: TLS is disabled, callers can't be authenticated")
+	}
+	grpcServer := grpc.NewServer(serverOpts...)
+	proto.RegisterTransferServiceServer(grpcServer, transferHandler)
+
+	serveErr := make(chan error, 1)
+	go func() {
+		fmt.Printf("Server started on port %d\n", cfg.GRPCPort)
+		serveErr <- grpcServer.Serve(listener)
+	}()
+
+	select {
+	case err := <-serveErr:
+		log.Fatalf("Failed to serve: %v", err)
+	case <-ctx.Done():
+	}
+
+	log.Println("Shutting down: draining in-flight RPCs")
+	grpcServer.GracefulStop()
+	<-schedulerDone
+	log.Println("Server stopped")
 }
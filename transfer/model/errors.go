@@ -0,0 +1,39 @@
+package model
+
+import (
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorDomain is the domain of the google.rpc.ErrorInfo attached to the errors of the transfer service.
+const ErrorDomain = "transfer.banking-app"
+
+var (
+	ErrInternalServer       error = newError(codes.Internal, "INTERNAL", "internal server error")
+	ErrInvalidArgument      error = newError(codes.InvalidArgument, "INVALID_ARGUMENT", "invalid argument")
+	ErrUnsupportedCurrency  error = newError(codes.InvalidArgument, "UNSUPPORTED_CURRENCY", "unsupported currency")
+	ErrCurrencyMismatch     error = newError(codes.InvalidArgument, "CURRENCY_MISMATCH", "currency doesn't match the currency of the account")
+	ErrTransferFailed       error = newError(codes.FailedPrecondition, "TRANSFER_FAILED", "transfer failed")
+	ErrTransferNotFound     error = newError(codes.NotFound, "TRANSFER_NOT_FOUND", "transfer not found")
+	ErrQuoteRequired        error = newError(codes.FailedPrecondition, "QUOTE_REQUIRED", "a quote is required to transfer between accounts of different currencies")
+	ErrQuoteNotFound        error = newError(codes.NotFound, "QUOTE_NOT_FOUND", "quote not found")
+	ErrQuoteExpired         error = newError(codes.FailedPrecondition, "QUOTE_EXPIRED", "quote expired or was already used")
+	ErrQuoteMismatch        error = newError(codes.InvalidArgument, "QUOTE_MISMATCH", "quote doesn't match the amount and currencies of the transfer")
+	ErrRateUnavailable      error = newError(codes.Unavailable, "RATE_UNAVAILABLE", "no exchange rate for these currencies")
+	ErrIdempotencyKeyReused error = newError(codes.FailedPrecondition, "IDEMPOTENCY_KEY_REUSED", "idempotency key was already used for a different request")
+	ErrInvalidSchedule      error = newError(codes.InvalidArgument, "INVALID_SCHEDULE", "invalid schedule, or no occurrence between its start and end")
+	ErrOrderNotFound        error = newError(codes.NotFound, "STANDING_ORDER_NOT_FOUND", "standing order not found")
+	ErrNotAuthorized        error = newError(codes.PermissionDenied, "NOT_AUTHORIZED", "not authorized")
+	ErrStandingOrderEnded   error = newError(codes.FailedPrecondition, "STANDING_ORDER_ENDED", "standing order already completed")
+)
+
+// newError builds a status error carrying a google.rpc.ErrorInfo, so that clients can branch on a stable reason
+// such as "QUOTE_EXPIRED" instead of parsing the message.
+func newError(code codes.Code, reason, message string) error {
+	st, err := status.New(code, message).WithDetails(&errdetails.ErrorInfo{Reason: reason, Domain: ErrorDomain})
+	if err != nil {
+		panic(err) // only fails if the detail can't be marshalled, which a static ErrorInfo always can
+	}
+	return st.Err()
+}
@@ -0,0 +1,61 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Statuses of StandingOrder
+const (
+	StandingOrderActive    = "ACTIVE"
+	StandingOrderCompleted = "COMPLETED" // past its end or its maximum number of executions
+	StandingOrderCancelled = "CANCELLED" // by its owner
+)
+
+// StandingOrder transfers Amount from the account FromAccountID of UserID to ToAccountID at every occurrence of
+// Schedule from StartsAt until EndsAt, at most MaxExecutions times. Sweeps transfer what FromAccountID has available
+// above SweepAbove instead. Both accounts are in Currency.
+type StandingOrder struct {
+	StandingOrderID uuid.UUID `json:"standing_order_id"`
+	UserID          uuid.UUID `json:"user_id"`
+	FromAccountID   uuid.UUID `json:"from_account_id"`
+	ToAccountID     uuid.UUID `json:"to_account_id"`
+	Currency        string    `json:"currency"`
+	Amount          int64     `json:"amount"` // in minor units of Currency, 0 for sweeps
+	Sweep           bool      `json:"sweep"`
+	SweepAbove      int64     `json:"sweep_above"` // in minor units of Currency, for sweeps
+	Schedule        string    `json:"schedule"`    // cron expression or RRULE, see package schedule
+	StartsAt        time.Time `json:"starts_at"`
+	EndsAt          time.Time `json:"ends_at"`        // zero if the order doesn't end
+	MaxExecutions   int32     `json:"max_executions"` // 0 for no maximum
+	Executions      int32     `json:"executions"`     // occurrences scheduled so far
+	NextRunAt       time.Time `json:"next_run_at"`    // zero unless ACTIVE
+	Status          string    `json:"status"`
+	Memo            string    `json:"memo,omitempty"`
+	IdempotencyKey  string    `json:"idempotency_key"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// Statuses of StandingOrderExecution
+const (
+	ExecutionPending   = "PENDING"
+	ExecutionSucceeded = "SUCCEEDED"
+	ExecutionFailed    = "FAILED"  // after its last attempt
+	ExecutionSkipped   = "SKIPPED" // nothing to sweep, or its order was cancelled
+)
+
+// StandingOrderExecution is an occurrence of a standing order, executed as a transfer of Amount. Attempts counts its
+// claimed attempts, and Failures the attempts whose transfer FAILED.
+type StandingOrderExecution struct {
+	ExecutionID     uuid.UUID     `json:"execution_id"`
+	StandingOrderID uuid.UUID     `json:"standing_order_id"`
+	ScheduledAt     time.Time     `json:"scheduled_at"`
+	Amount          int64         `json:"amount"` // 0 until the first attempt of a sweep computes it
+	Status          string        `json:"status"`
+	TransferID      uuid.NullUUID `json:"transfer_id"`
+	Attempts        int32         `json:"attempts"`
+	Failures        int32         `json:"failures"`
+	NextAttemptAt   time.Time     `json:"next_attempt_at"`
+	LastError       string        `json:"last_error,omitempty"`
+}
@@ -1,14 +1,51 @@
 package model
 
-import "github.com/google/uuid"
+import (
+	"time"
 
+	"github.com/google/uuid"
+)
+
+// Transfer moves Amount of Currency out of the account FromAccountID, and ToAmount of ToCurrency into the account
+// ToAccountID. Both legs are the same unless the accounts have different currencies, in which case the transfer is
+// executed at the Rate of the quote QuoteID.
 type Transfer struct {
-	TransferID     uuid.UUID `json:"transfer_id"`
-	FromAccountID  uuid.UUID `json:"from_account_id"`
-	ToAccountID    uuid.UUID `json:"to_account_id"`
+	TransferID     uuid.UUID     `json:"transfer_id"`
+	FromAccountID  uuid.UUID     `json:"from_account_id"`
+	ToAccountID    uuid.UUID     `json:"to_account_id"`
+	Amount         int64         `json:"amount"`   // debited, in minor units of Currency
+	Currency       string        `json:"currency"` // currency of the account FromAccountID
+	ToAmount       int64         `json:"to_amount"`
+	ToCurrency     string        `json:"to_currency"`
+	Rate           string        `json:"rate,omitempty"` // decimal rate of cross-currency transfers, see fx.ParseRate
+	QuoteID        uuid.NullUUID `json:"quote_id"`
+	IdempotencyKey string        `json:"idempotency_key"`
+	Status         string        `json:"status"`
+	UserID         uuid.NullUUID `json:"user_id"` // owner of the account FromAccountID, unset for transfers made before holds
+	HoldID         uuid.NullUUID `json:"hold_id"` // on the account FromAccountID, set once placed
+}
+
+// Statuses of Transfer
+const (
+	TransferPending   = "PENDING"
+	TransferCompleted = "COMPLETED"
+	TransferFailed    = "FAILED"
+)
+
+// Quote is the price of a cross-currency transfer: Amount of Currency is credited as TargetAmount of TargetCurrency.
+// Rate is MidRate, the rate of the provider, minus a spread of SpreadBps basis points. Rates are decimal strings of
+// how many major units of TargetCurrency a major unit of Currency is worth.
+type Quote struct {
+	QuoteID        uuid.UUID `json:"quote_id"`
 	Amount         int64     `json:"amount"`
-	IdempotencyKey string    `json:"idempotency_key"`
-	Status         string    `json:"status"`
+	Currency       string    `json:"currency"`
+	TargetAmount   int64     `json:"target_amount"`
+	TargetCurrency string    `json:"target_currency"`
+	MidRate        string    `json:"mid_rate"`
+	Rate           string    `json:"rate"`
+	SpreadBps      int32     `json:"spread_bps"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	UsedAt         time.Time `json:"used_at"` // zero until a transfer uses the quote
 }
 
 type User struct {
@@ -20,5 +57,6 @@ type Account struct {
 	AccountID     uuid.UUID `json:"account_id"`
 	UserID        uuid.UUID `json:"user_id"`
 	Balance       int64     `json:"balance"`
+	Currency      string    `json:"currency"`
 	AccountNumber int64     `json:"account_number"`
 }
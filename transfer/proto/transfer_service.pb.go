@@ -0,0 +1,959 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: transfer_service.proto
+
+package proto
+
+import (
+	proto "account/proto"
+	_ "buf.build/gen/go/bufbuild/protovalidate/protocolbuffers/go/buf/validate"
+	_ "google.golang.org/genproto/googleapis/api/annotations"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type CreateTransferRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	FromAccountId  string                 `protobuf:"bytes,1,opt,name=from_account_id,json=fromAccountId,proto3" json:"from_account_id,omitempty"`
+	ToAccountId    string                 `protobuf:"bytes,2,opt,name=to_account_id,json=toAccountId,proto3" json:"to_account_id,omitempty"`
+	IdempotencyKey string                 `protobuf:"bytes,4,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	// to debit from the source account, in its currency. The destination account is credited amount converted at
+	// the rate of quote_id if its currency differs.
+	Amount *proto.Money `protobuf:"bytes,5,opt,name=amount,proto3" json:"amount,omitempty"`
+	// required when the accounts have different currencies, and must be empty otherwise.
+	// The quote must be for amount and for the currency of the destination account, or the request fails with
+	// QUOTE_MISMATCH.
+	QuoteId       string `protobuf:"bytes,6,opt,name=quote_id,json=quoteId,proto3" json:"quote_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateTransferRequest) Reset() {
+	*x = CreateTransferRequest{}
+	mi := &file_transfer_service_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateTransferRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateTransferRequest) ProtoMessage() {}
+
+func (x *CreateTransferRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_transfer_service_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateTransferRequest.ProtoReflect.Descriptor instead.
+func (*CreateTransferRequest) Descriptor() ([]byte, []int) {
+	return file_transfer_service_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CreateTransferRequest) GetFromAccountId() string {
+	if x != nil {
+		return x.FromAccountId
+	}
+	return ""
+}
+
+func (x *CreateTransferRequest) GetToAccountId() string {
+	if x != nil {
+		return x.ToAccountId
+	}
+	return ""
+}
+
+func (x *CreateTransferRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+func (x *CreateTransferRequest) GetAmount() *proto.Money {
+	if x != nil {
+		return x.Amount
+	}
+	return nil
+}
+
+func (x *CreateTransferRequest) GetQuoteId() string {
+	if x != nil {
+		return x.QuoteId
+	}
+	return ""
+}
+
+type CreateTransferResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Status         int64                  `protobuf:"varint,1,opt,name=status,proto3" json:"status,omitempty"`
+	Error          string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	TransferId     string                 `protobuf:"bytes,3,opt,name=transfer_id,json=transferId,proto3" json:"transfer_id,omitempty"`
+	IdempotencyKey string                 `protobuf:"bytes,4,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	Debited        *proto.Money           `protobuf:"bytes,5,opt,name=debited,proto3" json:"debited,omitempty"`   // from the source account
+	Credited       *proto.Money           `protobuf:"bytes,6,opt,name=credited,proto3" json:"credited,omitempty"` // to the destination account, in its currency
+	Rate           string                 `protobuf:"bytes,7,opt,name=rate,proto3" json:"rate,omitempty"`         // rate of the quote, for cross-currency transfers
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *CreateTransferResponse) Reset() {
+	*x = CreateTransferResponse{}
+	mi := &file_transfer_service_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateTransferResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateTransferResponse) ProtoMessage() {}
+
+func (x *CreateTransferResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_transfer_service_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateTransferResponse.ProtoReflect.Descriptor instead.
+func (*CreateTransferResponse) Descriptor() ([]byte, []int) {
+	return file_transfer_service_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CreateTransferResponse) GetStatus() int64 {
+	if x != nil {
+		return x.Status
+	}
+	return 0
+}
+
+func (x *CreateTransferResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *CreateTransferResponse) GetTransferId() string {
+	if x != nil {
+		return x.TransferId
+	}
+	return ""
+}
+
+func (x *CreateTransferResponse) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+func (x *CreateTransferResponse) GetDebited() *proto.Money {
+	if x != nil {
+		return x.Debited
+	}
+	return nil
+}
+
+func (x *CreateTransferResponse) GetCredited() *proto.Money {
+	if x != nil {
+		return x.Credited
+	}
+	return nil
+}
+
+func (x *CreateTransferResponse) GetRate() string {
+	if x != nil {
+		return x.Rate
+	}
+	return ""
+}
+
+type CreateQuoteRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// to debit from the source account, in its currency
+	Amount *proto.Money `protobuf:"bytes,1,opt,name=amount,proto3" json:"amount,omitempty"`
+	// currency of the destination account
+	TargetCurrency string `protobuf:"bytes,2,opt,name=target_currency,json=targetCurrency,proto3" json:"target_currency,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *CreateQuoteRequest) Reset() {
+	*x = CreateQuoteRequest{}
+	mi := &file_transfer_service_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateQuoteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateQuoteRequest) ProtoMessage() {}
+
+func (x *CreateQuoteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_transfer_service_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateQuoteRequest.ProtoReflect.Descriptor instead.
+func (*CreateQuoteRequest) Descriptor() ([]byte, []int) {
+	return file_transfer_service_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CreateQuoteRequest) GetAmount() *proto.Money {
+	if x != nil {
+		return x.Amount
+	}
+	return nil
+}
+
+func (x *CreateQuoteRequest) GetTargetCurrency() string {
+	if x != nil {
+		return x.TargetCurrency
+	}
+	return ""
+}
+
+// Quote credits target_amount for amount. Rates are decimal strings of how many major units of the target currency
+// a major unit of the source currency is worth: rate is mid_rate minus a spread of spread_bps basis points.
+type Quote struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	QuoteId       string                 `protobuf:"bytes,1,opt,name=quote_id,json=quoteId,proto3" json:"quote_id,omitempty"`
+	Amount        *proto.Money           `protobuf:"bytes,2,opt,name=amount,proto3" json:"amount,omitempty"`
+	TargetAmount  *proto.Money           `protobuf:"bytes,3,opt,name=target_amount,json=targetAmount,proto3" json:"target_amount,omitempty"`
+	MidRate       string                 `protobuf:"bytes,4,opt,name=mid_rate,json=midRate,proto3" json:"mid_rate,omitempty"`
+	Rate          string                 `protobuf:"bytes,5,opt,name=rate,proto3" json:"rate,omitempty"`
+	SpreadBps     int32                  `protobuf:"varint,6,opt,name=spread_bps,json=spreadBps,proto3" json:"spread_bps,omitempty"`
+	ExpiresAt     int64                  `protobuf:"varint,7,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"` // unix time
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Quote) Reset() {
+	*x = Quote{}
+	mi := &file_transfer_service_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Quote) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Quote) ProtoMessage() {}
+
+func (x *Quote) ProtoReflect() protoreflect.Message {
+	mi := &file_transfer_service_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Quote.ProtoReflect.Descriptor instead.
+func (*Quote) Descriptor() ([]byte, []int) {
+	return file_transfer_service_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Quote) GetQuoteId() string {
+	if x != nil {
+		return x.QuoteId
+	}
+	return ""
+}
+
+func (x *Quote) GetAmount() *proto.Money {
+	if x != nil {
+		return x.Amount
+	}
+	return nil
+}
+
+func (x *Quote) GetTargetAmount() *proto.Money {
+	if x != nil {
+		return x.TargetAmount
+	}
+	return nil
+}
+
+func (x *Quote) GetMidRate() string {
+	if x != nil {
+		return x.MidRate
+	}
+	return ""
+}
+
+func (x *Quote) GetRate() string {
+	if x != nil {
+		return x.Rate
+	}
+	return ""
+}
+
+func (x *Quote) GetSpreadBps() int32 {
+	if x != nil {
+		return x.SpreadBps
+	}
+	return 0
+}
+
+func (x *Quote) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+type CreateStandingOrderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FromAccountId string                 `protobuf:"bytes,1,opt,name=from_account_id,json=fromAccountId,proto3" json:"from_account_id,omitempty"`
+	ToAccountId   string                 `protobuf:"bytes,2,opt,name=to_account_id,json=toAccountId,proto3" json:"to_account_id,omitempty"`
+	// Types that are valid to be assigned to Transfer:
+	//
+	//	*CreateStandingOrderRequest_Amount
+	//	*CreateStandingOrderRequest_SweepAbove
+	Transfer isCreateStandingOrderRequest_Transfer `protobuf_oneof:"transfer"`
+	// a cron expression such as "0 9 1 * *", or an iCalendar recurrence rule such as
+	// "RRULE:FREQ=MONTHLY;BYMONTHDAY=-1;BYHOUR=9;BYMINUTE=0". Occurrences are in UTC.
+	Schedule       string `protobuf:"bytes,5,opt,name=schedule,proto3" json:"schedule,omitempty"`
+	StartsAt       int64  `protobuf:"varint,6,opt,name=starts_at,json=startsAt,proto3" json:"starts_at,omitempty"`                // unix time, now if 0
+	EndsAt         int64  `protobuf:"varint,7,opt,name=ends_at,json=endsAt,proto3" json:"ends_at,omitempty"`                      // unix time, 0 if the order doesn't end
+	MaxExecutions  int32  `protobuf:"varint,8,opt,name=max_executions,json=maxExecutions,proto3" json:"max_executions,omitempty"` // 0 for no maximum
+	Memo           string `protobuf:"bytes,9,opt,name=memo,proto3" json:"memo,omitempty"`
+	IdempotencyKey string `protobuf:"bytes,10,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *CreateStandingOrderRequest) Reset() {
+	*x = CreateStandingOrderRequest{}
+	mi := &file_transfer_service_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateStandingOrderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateStandingOrderRequest) ProtoMessage() {}
+
+func (x *CreateStandingOrderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_transfer_service_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateStandingOrderRequest.ProtoReflect.Descriptor instead.
+func (*CreateStandingOrderRequest) Descriptor() ([]byte, []int) {
+	return file_transfer_service_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CreateStandingOrderRequest) GetFromAccountId() string {
+	if x != nil {
+		return x.FromAccountId
+	}
+	return ""
+}
+
+func (x *CreateStandingOrderRequest) GetToAccountId() string {
+	if x != nil {
+		return x.ToAccountId
+	}
+	return ""
+}
+
+func (x *CreateStandingOrderRequest) GetTransfer() isCreateStandingOrderRequest_Transfer {
+	if x != nil {
+		return x.Transfer
+	}
+	return nil
+}
+
+func (x *CreateStandingOrderRequest) GetAmount() *proto.Money {
+	if x != nil {
+		if x, ok := x.Transfer.(*CreateStandingOrderRequest_Amount); ok {
+			return x.Amount
+		}
+	}
+	return nil
+}
+
+func (x *CreateStandingOrderRequest) GetSweepAbove() *proto.Money {
+	if x != nil {
+		if x, ok := x.Transfer.(*CreateStandingOrderRequest_SweepAbove); ok {
+			return x.SweepAbove
+		}
+	}
+	return nil
+}
+
+func (x *CreateStandingOrderRequest) GetSchedule() string {
+	if x != nil {
+		return x.Schedule
+	}
+	return ""
+}
+
+func (x *CreateStandingOrderRequest) GetStartsAt() int64 {
+	if x != nil {
+		return x.StartsAt
+	}
+	return 0
+}
+
+func (x *CreateStandingOrderRequest) GetEndsAt() int64 {
+	if x != nil {
+		return x.EndsAt
+	}
+	return 0
+}
+
+func (x *CreateStandingOrderRequest) GetMaxExecutions() int32 {
+	if x != nil {
+		return x.MaxExecutions
+	}
+	return 0
+}
+
+func (x *CreateStandingOrderRequest) GetMemo() string {
+	if x != nil {
+		return x.Memo
+	}
+	return ""
+}
+
+func (x *CreateStandingOrderRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+type isCreateStandingOrderRequest_Transfer interface {
+	isCreateStandingOrderRequest_Transfer()
+}
+
+type CreateStandingOrderRequest_Amount struct {
+	// transferred at every occurrence, in the currency of both accounts
+	Amount *proto.Money `protobuf:"bytes,3,opt,name=amount,proto3,oneof"`
+}
+
+type CreateStandingOrderRequest_SweepAbove struct {
+	// sweeps transfer what the source account has available above sweep_above, if anything, in its currency
+	SweepAbove *proto.Money `protobuf:"bytes,4,opt,name=sweep_above,json=sweepAbove,proto3,oneof"`
+}
+
+func (*CreateStandingOrderRequest_Amount) isCreateStandingOrderRequest_Transfer() {}
+
+func (*CreateStandingOrderRequest_SweepAbove) isCreateStandingOrderRequest_Transfer() {}
+
+type ListStandingOrdersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AccountId     string                 `protobuf:"bytes,1,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListStandingOrdersRequest) Reset() {
+	*x = ListStandingOrdersRequest{}
+	mi := &file_transfer_service_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListStandingOrdersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListStandingOrdersRequest) ProtoMessage() {}
+
+func (x *ListStandingOrdersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_transfer_service_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListStandingOrdersRequest.ProtoReflect.Descriptor instead.
+func (*ListStandingOrdersRequest) Descriptor() ([]byte, []int) {
+	return file_transfer_service_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListStandingOrdersRequest) GetAccountId() string {
+	if x != nil {
+		return x.AccountId
+	}
+	return ""
+}
+
+type ListStandingOrdersResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	StandingOrders []*StandingOrder       `protobuf:"bytes,1,rep,name=standing_orders,json=standingOrders,proto3" json:"standing_orders,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ListStandingOrdersResponse) Reset() {
+	*x = ListStandingOrdersResponse{}
+	mi := &file_transfer_service_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListStandingOrdersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListStandingOrdersResponse) ProtoMessage() {}
+
+func (x *ListStandingOrdersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_transfer_service_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListStandingOrdersResponse.ProtoReflect.Descriptor instead.
+func (*ListStandingOrdersResponse) Descriptor() ([]byte, []int) {
+	return file_transfer_service_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListStandingOrdersResponse) GetStandingOrders() []*StandingOrder {
+	if x != nil {
+		return x.StandingOrders
+	}
+	return nil
+}
+
+type CancelStandingOrderRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	StandingOrderId string                 `protobuf:"bytes,1,opt,name=standing_order_id,json=standingOrderId,proto3" json:"standing_order_id,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *CancelStandingOrderRequest) Reset() {
+	*x = CancelStandingOrderRequest{}
+	mi := &file_transfer_service_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelStandingOrderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelStandingOrderRequest) ProtoMessage() {}
+
+func (x *CancelStandingOrderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_transfer_service_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelStandingOrderRequest.ProtoReflect.Descriptor instead.
+func (*CancelStandingOrderRequest) Descriptor() ([]byte, []int) {
+	return file_transfer_service_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *CancelStandingOrderRequest) GetStandingOrderId() string {
+	if x != nil {
+		return x.StandingOrderId
+	}
+	return ""
+}
+
+type StandingOrder struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	StandingOrderId string                 `protobuf:"bytes,1,opt,name=standing_order_id,json=standingOrderId,proto3" json:"standing_order_id,omitempty"`
+	FromAccountId   string                 `protobuf:"bytes,2,opt,name=from_account_id,json=fromAccountId,proto3" json:"from_account_id,omitempty"`
+	ToAccountId     string                 `protobuf:"bytes,3,opt,name=to_account_id,json=toAccountId,proto3" json:"to_account_id,omitempty"`
+	Amount          *proto.Money           `protobuf:"bytes,4,opt,name=amount,proto3" json:"amount,omitempty"`                           // unset for sweeps
+	SweepAbove      *proto.Money           `protobuf:"bytes,5,opt,name=sweep_above,json=sweepAbove,proto3" json:"sweep_above,omitempty"` // unset unless the order is a sweep
+	Schedule        string                 `protobuf:"bytes,6,opt,name=schedule,proto3" json:"schedule,omitempty"`
+	StartsAt        int64                  `protobuf:"varint,7,opt,name=starts_at,json=startsAt,proto3" json:"starts_at,omitempty"`                // unix time
+	EndsAt          int64                  `protobuf:"varint,8,opt,name=ends_at,json=endsAt,proto3" json:"ends_at,omitempty"`                      // unix time, 0 if the order doesn't end
+	MaxExecutions   int32                  `protobuf:"varint,9,opt,name=max_executions,json=maxExecutions,proto3" json:"max_executions,omitempty"` // 0 for no maximum
+	Executions      int32                  `protobuf:"varint,10,opt,name=executions,proto3" json:"executions,omitempty"`                           // occurrences scheduled so far
+	NextRunAt       int64                  `protobuf:"varint,11,opt,name=next_run_at,json=nextRunAt,proto3" json:"next_run_at,omitempty"`          // unix time of the next occurrence, 0 unless ACTIVE
+	Status          string                 `protobuf:"bytes,12,opt,name=status,proto3" json:"status,omitempty"`                                    // "ACTIVE", "COMPLETED" or "CANCELLED"
+	Memo            string                 `protobuf:"bytes,13,opt,name=memo,proto3" json:"memo,omitempty"`
+	IdempotencyKey  string                 `protobuf:"bytes,14,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	CreatedAt       int64                  `protobuf:"varint,15,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"` // unix time
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *StandingOrder) Reset() {
+	*x = StandingOrder{}
+	mi := &file_transfer_service_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StandingOrder) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StandingOrder) ProtoMessage() {}
+
+func (x *StandingOrder) ProtoReflect() protoreflect.Message {
+	mi := &file_transfer_service_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StandingOrder.ProtoReflect.Descriptor instead.
+func (*StandingOrder) Descriptor() ([]byte, []int) {
+	return file_transfer_service_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *StandingOrder) GetStandingOrderId() string {
+	if x != nil {
+		return x.StandingOrderId
+	}
+	return ""
+}
+
+func (x *StandingOrder) GetFromAccountId() string {
+	if x != nil {
+		return x.FromAccountId
+	}
+	return ""
+}
+
+func (x *StandingOrder) GetToAccountId() string {
+	if x != nil {
+		return x.ToAccountId
+	}
+	return ""
+}
+
+func (x *StandingOrder) GetAmount() *proto.Money {
+	if x != nil {
+		return x.Amount
+	}
+	return nil
+}
+
+func (x *StandingOrder) GetSweepAbove() *proto.Money {
+	if x != nil {
+		return x.SweepAbove
+	}
+	return nil
+}
+
+func (x *StandingOrder) GetSchedule() string {
+	if x != nil {
+		return x.Schedule
+	}
+	return ""
+}
+
+func (x *StandingOrder) GetStartsAt() int64 {
+	if x != nil {
+		return x.StartsAt
+	}
+	return 0
+}
+
+func (x *StandingOrder) GetEndsAt() int64 {
+	if x != nil {
+		return x.EndsAt
+	}
+	return 0
+}
+
+func (x *StandingOrder) GetMaxExecutions() int32 {
+	if x != nil {
+		return x.MaxExecutions
+	}
+	return 0
+}
+
+func (x *StandingOrder) GetExecutions() int32 {
+	if x != nil {
+		return x.Executions
+	}
+	return 0
+}
+
+func (x *StandingOrder) GetNextRunAt() int64 {
+	if x != nil {
+		return x.NextRunAt
+	}
+	return 0
+}
+
+func (x *StandingOrder) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *StandingOrder) GetMemo() string {
+	if x != nil {
+		return x.Memo
+	}
+	return ""
+}
+
+func (x *StandingOrder) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+func (x *StandingOrder) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+var File_transfer_service_proto protoreflect.FileDescriptor
+
+const file_transfer_service_proto_rawDesc = "" +
+	"\n" +
+	"\x16transfer_service.proto\x12\x05proto\x1a\x1bbuf/validate/validate.proto\x1a\x1cgoogle/api/annotations.proto\x1a\vmoney.proto\"\xc8\x03\n" +
+	"\x15CreateTransferRequest\x120\n" +
+	"\x0ffrom_account_id\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\rfromAccountId\x12,\n" +
+	"\rto_account_id\x18\x02 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\vtoAccountId\x121\n" +
+	"\x0fidempotency_key\x18\x04 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\x0eidempotencyKey\x12i\n" +
+	"\x06amount\x18\x05 \x01(\v2\f.proto.MoneyBC\xbaH@\xba\x01:\n" +
+	"\x0famount.positive\x12\x17amount must be positive\x1a\x0ethis.units > 0\xc8\x01\x01R\x06amount\x12&\n" +
+	"\bquote_id\x18\x06 \x01(\tB\v\xbaH\b\xd8\x01\x01r\x03\xb0\x01\x01R\aquoteId:\x82\x01\xbaH\x7f\x1a}\n" +
+	"\x1atransfer.distinct_accounts\x123from_account_id and to_account_id must be different\x1a*this.from_account_id != this.to_account_idJ\x04\b\x03\x10\x04\"\xf6\x01\n" +
+	"\x16CreateTransferResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\x03R\x06status\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\x12\x1f\n" +
+	"\vtransfer_id\x18\x03 \x01(\tR\n" +
+	"transferId\x12'\n" +
+	"\x0fidempotency_key\x18\x04 \x01(\tR\x0eidempotencyKey\x12&\n" +
+	"\adebited\x18\x05 \x01(\v2\f.proto.MoneyR\adebited\x12(\n" +
+	"\bcredited\x18\x06 \x01(\v2\f.proto.MoneyR\bcredited\x12\x12\n" +
+	"\x04rate\x18\a \x01(\tR\x04rate\"\xc7\x02\n" +
+	"\x12CreateQuoteRequest\x12i\n" +
+	"\x06amount\x18\x01 \x01(\v2\f.proto.MoneyBC\xbaH@\xba\x01:\n" +
+	"\x0famount.positive\x12\x17amount must be positive\x1a\x0ethis.units > 0\xc8\x01\x01R\x06amount\x12:\n" +
+	"\x0ftarget_currency\x18\x02 \x01(\tB\x11\xbaH\x0er\f2\n" +
+	"^[A-Z]{3}$R\x0etargetCurrency:\x89\x01\xbaH\x85\x01\x1a\x82\x01\n" +
+	"\x19quote.distinct_currencies\x127target_currency must differ from the currency of amount\x1a,this.amount.currency != this.target_currency\"\xe8\x01\n" +
+	"\x05Quote\x12\x19\n" +
+	"\bquote_id\x18\x01 \x01(\tR\aquoteId\x12$\n" +
+	"\x06amount\x18\x02 \x01(\v2\f.proto.MoneyR\x06amount\x121\n" +
+	"\rtarget_amount\x18\x03 \x01(\v2\f.proto.MoneyR\ftargetAmount\x12\x19\n" +
+	"\bmid_rate\x18\x04 \x01(\tR\amidRate\x12\x12\n" +
+	"\x04rate\x18\x05 \x01(\tR\x04rate\x12\x1d\n" +
+	"\n" +
+	"spread_bps\x18\x06 \x01(\x05R\tspreadBps\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\a \x01(\x03R\texpiresAt\"\xf6\x06\n" +
+	"\x1aCreateStandingOrderRequest\x120\n" +
+	"\x0ffrom_account_id\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\rfromAccountId\x12,\n" +
+	"\rto_account_id\x18\x02 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\vtoAccountId\x12h\n" +
+	"\x06amount\x18\x03 \x01(\v2\f.proto.MoneyB@\xbaH=\xba\x01:\n" +
+	"\x0famount.positive\x12\x17amount must be positive\x1a\x0ethis.units > 0H\x00R\x06amount\x12\x84\x01\n" +
+	"\vsweep_above\x18\x04 \x01(\v2\f.proto.MoneyBS\xbaHP\xba\x01M\n" +
+	"\x18sweep_above.not_negative\x12 sweep_above must not be negative\x1a\x0fthis.units >= 0H\x00R\n" +
+	"sweepAbove\x12&\n" +
+	"\bschedule\x18\x05 \x01(\tB\n" +
+	"\xbaH\ar\x05\x10\x01\x18\xc8\x01R\bschedule\x12$\n" +
+	"\tstarts_at\x18\x06 \x01(\x03B\a\xbaH\x04\"\x02(\x00R\bstartsAt\x12 \n" +
+	"\aends_at\x18\a \x01(\x03B\a\xbaH\x04\"\x02(\x00R\x06endsAt\x12.\n" +
+	"\x0emax_executions\x18\b \x01(\x05B\a\xbaH\x04\x1a\x02(\x00R\rmaxExecutions\x12\x1c\n" +
+	"\x04memo\x18\t \x01(\tB\b\xbaH\x05r\x03\x18\x8c\x01R\x04memo\x121\n" +
+	"\x0fidempotency_key\x18\n" +
+	" \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\x0eidempotencyKey:\x82\x02\xbaH\xfe\x01\x1a\x83\x01\n" +
+	" standing_order.distinct_accounts\x123from_account_id and to_account_id must be different\x1a*this.from_account_id != this.to_account_id\x1av\n" +
+	"\x1fstanding_order.ends_after_start\x12\x1fends_at must be after starts_at\x1a2this.ends_at == 0 || this.ends_at > this.starts_atB\x11\n" +
+	"\btransfer\x12\x05\xbaH\x02\b\x01\"D\n" +
+	"\x19ListStandingOrdersRequest\x12'\n" +
+	"\n" +
+	"account_id\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\taccountId\"[\n" +
+	"\x1aListStandingOrdersResponse\x12=\n" +
+	"\x0fstanding_orders\x18\x01 \x03(\v2\x14.proto.StandingOrderR\x0estandingOrders\"R\n" +
+	"\x1aCancelStandingOrderRequest\x124\n" +
+	"\x11standing_order_id\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\x0fstandingOrderId\"\x89\x04\n" +
+	"\rStandingOrder\x12*\n" +
+	"\x11standing_order_id\x18\x01 \x01(\tR\x0fstandingOrderId\x12&\n" +
+	"\x0ffrom_account_id\x18\x02 \x01(\tR\rfromAccountId\x12\"\n" +
+	"\rto_account_id\x18\x03 \x01(\tR\vtoAccountId\x12$\n" +
+	"\x06amount\x18\x04 \x01(\v2\f.proto.MoneyR\x06amount\x12-\n" +
+	"\vsweep_above\x18\x05 \x01(\v2\f.proto.MoneyR\n" +
+	"sweepAbove\x12\x1a\n" +
+	"\bschedule\x18\x06 \x01(\tR\bschedule\x12\x1b\n" +
+	"\tstarts_at\x18\a \x01(\x03R\bstartsAt\x12\x17\n" +
+	"\aends_at\x18\b \x01(\x03R\x06endsAt\x12%\n" +
+	"\x0emax_executions\x18\t \x01(\x05R\rmaxExecutions\x12\x1e\n" +
+	"\n" +
+	"executions\x18\n" +
+	" \x01(\x05R\n" +
+	"executions\x12\x1e\n" +
+	"\vnext_run_at\x18\v \x01(\x03R\tnextRunAt\x12\x16\n" +
+	"\x06status\x18\f \x01(\tR\x06status\x12\x12\n" +
+	"\x04memo\x18\r \x01(\tR\x04memo\x12'\n" +
+	"\x0fidempotency_key\x18\x0e \x01(\tR\x0eidempotencyKey\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x0f \x01(\x03R\tcreatedAt2\xd4\x04\n" +
+	"\x0fTransferService\x12k\n" +
+	"\x0eCreateTransfer\x12\x1c.proto.CreateTransferRequest\x1a\x1d.proto.CreateTransferResponse\"\x1c\x82\xd3\xe4\x93\x02\x16:\x01*\"\x11/api/v1/transfers\x12T\n" +
+	"\vCreateQuote\x12\x19.proto.CreateQuoteRequest\x1a\f.proto.Quote\"\x1c\x82\xd3\xe4\x93\x02\x16:\x01*\"\x11/api/v1/fx/quotes\x12r\n" +
+	"\x13CreateStandingOrder\x12!.proto.CreateStandingOrderRequest\x1a\x14.proto.StandingOrder\"\"\x82\xd3\xe4\x93\x02\x1c:\x01*\"\x17/api/v1/standing-orders\x12z\n" +
+	"\x12ListStandingOrders\x12 .proto.ListStandingOrdersRequest\x1a!.proto.ListStandingOrdersResponse\"\x1f\x82\xd3\xe4\x93\x02\x19\x12\x17/api/v1/standing-orders\x12\x8d\x01\n" +
+	"\x13CancelStandingOrder\x12!.proto.CancelStandingOrderRequest\x1a\x14.proto.StandingOrder\"=\x82\xd3\xe4\x93\x027:\x01*\"2/api/v1/standing-orders/{standing_order_id}/cancelB^\n" +
+	"\tcom.protoB\x14TransferServiceProtoP\x01Z\a./proto\xa2\x02\x03PXX\xaa\x02\x05Proto\xca\x02\x05Proto\xe2\x02\x11Proto\\GPBMetadata\xea\x02\x05Protob\x06proto3"
+
+var (
+	file_transfer_service_proto_rawDescOnce sync.Once
+	file_transfer_service_proto_rawDescData []byte
+)
+
+func file_transfer_service_proto_rawDescGZIP() []byte {
+	file_transfer_service_proto_rawDescOnce.Do(func() {
+		file_transfer_service_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_transfer_service_proto_rawDesc), len(file_transfer_service_proto_rawDesc)))
+	})
+	return file_transfer_service_proto_rawDescData
+}
+
+var file_transfer_service_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_transfer_service_proto_goTypes = []any{
+	(*CreateTransferRequest)(nil),      // 0: proto.CreateTransferRequest
+	(*CreateTransferResponse)(nil),     // 1: proto.CreateTransferResponse
+	(*CreateQuoteRequest)(nil),         // 2: proto.CreateQuoteRequest
+	(*Quote)(nil),                      // 3: proto.Quote
+	(*CreateStandingOrderRequest)(nil), // 4: proto.CreateStandingOrderRequest
+	(*ListStandingOrdersRequest)(nil),  // 5: proto.ListStandingOrdersRequest
+	(*ListStandingOrdersResponse)(nil), // 6: proto.ListStandingOrdersResponse
+	(*CancelStandingOrderRequest)(nil), // 7: proto.CancelStandingOrderRequest
+	(*StandingOrder)(nil),              // 8: proto.StandingOrder
+	(*proto.Money)(nil),                // 9: proto.Money
+}
+var file_transfer_service_proto_depIdxs = []int32{
+	9,  // 0: proto.CreateTransferRequest.amount:type_name -> proto.Money
+	9,  // 1: proto.CreateTransferResponse.debited:type_name -> proto.Money
+	9,  // 2: proto.CreateTransferResponse.credited:type_name -> proto.Money
+	9,  // 3: proto.CreateQuoteRequest.amount:type_name -> proto.Money
+	9,  // 4: proto.Quote.amount:type_name -> proto.Money
+	9,  // 5: proto.Quote.target_amount:type_name -> proto.Money
+	9,  // 6: proto.CreateStandingOrderRequest.amount:type_name -> proto.Money
+	9,  // 7: proto.CreateStandingOrderRequest.sweep_above:type_name -> proto.Money
+	8,  // 8: proto.ListStandingOrdersResponse.standing_orders:type_name -> proto.StandingOrder
+	9,  // 9: proto.StandingOrder.amount:type_name -> proto.Money
+	9,  // 10: proto.StandingOrder.sweep_above:type_name -> proto.Money
+	0,  // 11: proto.TransferService.CreateTransfer:input_type -> proto.CreateTransferRequest
+	2,  // 12: proto.TransferService.CreateQuote:input_type -> proto.CreateQuoteRequest
+	4,  // 13: proto.TransferService.CreateStandingOrder:input_type -> proto.CreateStandingOrderRequest
+	5,  // 14: proto.TransferService.ListStandingOrders:input_type -> proto.ListStandingOrdersRequest
+	7,  // 15: proto.TransferService.CancelStandingOrder:input_type -> proto.CancelStandingOrderRequest
+	1,  // 16: proto.TransferService.CreateTransfer:output_type -> proto.CreateTransferResponse
+	3,  // 17: proto.TransferService.CreateQuote:output_type -> proto.Quote
+	8,  // 18: proto.TransferService.CreateStandingOrder:output_type -> proto.StandingOrder
+	6,  // 19: proto.TransferService.ListStandingOrders:output_type -> proto.ListStandingOrdersResponse
+	8,  // 20: proto.TransferService.CancelStandingOrder:output_type -> proto.StandingOrder
+	16, // [16:21] is the sub-list for method output_type
+	11, // [11:16] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
+}
+
+func init() { file_transfer_service_proto_init() }
+func file_transfer_service_proto_init() {
+	if File_transfer_service_proto != nil {
+		return
+	}
+	file_transfer_service_proto_msgTypes[4].OneofWrappers = []any{
+		(*CreateStandingOrderRequest_Amount)(nil),
+		(*CreateStandingOrderRequest_SweepAbove)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_transfer_service_proto_rawDesc), len(file_transfer_service_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   9,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_transfer_service_proto_goTypes,
+		DependencyIndexes: file_transfer_service_proto_depIdxs,
+		MessageInfos:      file_transfer_service_proto_msgTypes,
+	}.Build()
+	File_transfer_service_proto = out.File
+	file_transfer_service_proto_goTypes = nil
+	file_transfer_service_proto_depIdxs = nil
+}
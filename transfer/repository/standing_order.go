@@ -0,0 +1,234 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+	"transfer/db/sqlc"
+	"transfer/model"
+
+	"github.com/google/uuid"
+)
+
+func convertToModelStandingOrder(order sqlc.StandingOrder) *model.StandingOrder {
+	return &model.StandingOrder{
+		StandingOrderID: order.ID,
+		UserID:          order.UserID,
+		FromAccountID:   order.FromAccountID,
+		ToAccountID:     order.ToAccountID,
+		Currency:        order.Currency,
+		Amount:          order.Amount.Int64,
+		Sweep:           order.SweepAbove.Valid,
+		SweepAbove:      order.SweepAbove.Int64,
+		Schedule:        order.Schedule,
+		StartsAt:        order.StartsAt,
+		EndsAt:          order.EndsAt.Time,
+		MaxExecutions:   order.MaxExecutions.Int32,
+		Executions:      order.Executions,
+		NextRunAt:       order.NextRunAt.Time,
+		Status:          order.Status,
+		Memo:            order.Memo.String,
+		IdempotencyKey:  order.IdempotencyKey,
+		CreatedAt:       order.CreatedAt,
+	}
+}
+
+func convertToModelExecution(execution sqlc.StandingOrderExecution) *model.StandingOrderExecution {
+	return &model.StandingOrderExecution{
+		ExecutionID:     execution.ID,
+		StandingOrderID: execution.StandingOrderID,
+		ScheduledAt:     execution.ScheduledAt,
+		Amount:          execution.Amount.Int64,
+		Status:          execution.Status,
+		TransferID:      execution.TransferID,
+		Attempts:        execution.Attempts,
+		Failures:        execution.Failures,
+		NextAttemptAt:   execution.NextAttemptAt,
+		LastError:       execution.LastError.String,
+	}
+}
+
+// nullTime is NULL for the zero time.
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+// CreateStandingOrder creates an ACTIVE standing order, with a new ID.
+func (r *TransferRepository) CreateStandingOrder(ctx context.Context, order *model.StandingOrder) (*model.StandingOrder, error) {
+	createdOrder, err := r.queries.CreateStandingOrder(ctx, sqlc.CreateStandingOrderParams{
+		ID:             uuid.New(),
+		IdempotencyKey: order.IdempotencyKey,
+		UserID:         order.UserID,
+		FromAccountID:  order.FromAccountID,
+		ToAccountID:    order.ToAccountID,
+		Currency:       order.Currency,
+		Amount:         sql.NullInt64{Int64: order.Amount, Valid: !order.Sweep},
+		SweepAbove:     sql.NullInt64{Int64: order.SweepAbove, Valid: order.Sweep},
+		Schedule:       order.Schedule,
+		StartsAt:       order.StartsAt,
+		EndsAt:         nullTime(order.EndsAt),
+		MaxExecutions:  sql.NullInt32{Int32: order.MaxExecutions, Valid: order.MaxExecutions > 0},
+		NextRunAt:      nullTime(order.NextRunAt),
+		Memo:           sql.NullString{String: order.Memo, Valid: order.Memo != ""},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return convertToModelStandingOrder(createdOrder), nil
+}
+
+func (r *TransferRepository) GetStandingOrderByID(ctx context.Context, id uuid.UUID) (*model.StandingOrder, error) {
+	order, err := r.queries.GetStandingOrderByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return convertToModelStandingOrder(order), nil
+}
+
+// GetStandingOrderByIdempotencyKey returns the standing order the user created with an idempotency key.
+func (r *TransferRepository) GetStandingOrderByIdempotencyKey(ctx context.Context, userID uuid.UUID, idempotencyKey string) (*model.StandingOrder, error) {
+	order, err := r.queries.GetStandingOrderByIdempotencyKey(ctx, sqlc.GetStandingOrderByIdempotencyKeyParams{
+		UserID:         userID,
+		IdempotencyKey: idempotencyKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return convertToModelStandingOrder(order), nil
+}
+
+// ListStandingOrdersByAccountID returns the standing orders debiting an account, the oldest first.
+func (r *TransferRepository) ListStandingOrdersByAccountID(ctx context.Context, accountID uuid.UUID) ([]*model.StandingOrder, error) {
+	orders, err := r.queries.ListStandingOrdersByAccountID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*model.StandingOrder, len(orders))
+	for i, order := range orders {
+		res[i] = convertToModelStandingOrder(order)
+	}
+	return res, nil
+}
+
+// CancelStandingOrder cancels an ACTIVE standing order. It returns sql.ErrNoRows if the order isn't ACTIVE anymore.
+func (r *TransferRepository) CancelStandingOrder(ctx context.Context, id uuid.UUID) (*model.StandingOrder, error) {
+	order, err := r.queries.CancelStandingOrder(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return convertToModelStandingOrder(order), nil
+}
+
+// ListDueStandingOrders returns at most batchSize ACTIVE standing orders whose next occurrence is before now, the
+// most overdue first. They stay locked until the transaction commits, and are skipped by the other transactions.
+func (r *TransferRepository) ListDueStandingOrders(ctx context.Context, now time.Time, batchSize int32) ([]*model.StandingOrder, error) {
+	orders, err := r.queries.ListDueStandingOrders(ctx, sqlc.ListDueStandingOrdersParams{Now: now, BatchSize: batchSize})
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*model.StandingOrder, len(orders))
+	for i, order := range orders {
+		res[i] = convertToModelStandingOrder(order)
+	}
+	return res, nil
+}
+
+// AdvanceStandingOrder counts an execution of the standing order, and moves it to its next occurrence nextRunAt with
+// the status status. nextRunAt is zero unless status is ACTIVE.
+func (r *TransferRepository) AdvanceStandingOrder(ctx context.Context, id uuid.UUID, nextRunAt time.Time, status string) (*model.StandingOrder, error) {
+	order, err := r.queries.AdvanceStandingOrder(ctx, sqlc.AdvanceStandingOrderParams{
+		NextRunAt: nullTime(nextRunAt),
+		Status:    status,
+		ID:        id,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return convertToModelStandingOrder(order), nil
+}
+
+// CreateStandingOrderExecution creates the PENDING execution of the occurrence scheduledAt of a standing order, due
+// at nextAttemptAt, unless the occurrence has one already. amount is 0 for sweeps.
+func (r *TransferRepository) CreateStandingOrderExecution(ctx context.Context, orderID uuid.UUID, scheduledAt time.Time, amount int64, nextAttemptAt time.Time) error {
+	return r.queries.CreateStandingOrderExecution(ctx, sqlc.CreateStandingOrderExecutionParams{
+		ID:              uuid.New(),
+		StandingOrderID: orderID,
+		ScheduledAt:     scheduledAt,
+		Amount:          sql.NullInt64{Int64: amount, Valid: amount > 0},
+		NextAttemptAt:   nextAttemptAt,
+	})
+}
+
+// ClaimDueStandingOrderExecutions starts an attempt of at most batchSize PENDING executions due before now, and
+// leases them until leaseUntil.
+func (r *TransferRepository) ClaimDueStandingOrderExecutions(ctx context.Context, now, leaseUntil time.Time, batchSize int32) ([]*model.StandingOrderExecution, error) {
+	executions, err := r.queries.ClaimDueStandingOrderExecutions(ctx, sqlc.ClaimDueStandingOrderExecutionsParams{
+		LeaseUntil: leaseUntil,
+		Now:        now,
+		BatchSize:  batchSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*model.StandingOrderExecution, len(executions))
+	for i, execution := range executions {
+		res[i] = convertToModelExecution(execution)
+	}
+	return res, nil
+}
+
+// SetStandingOrderExecutionAmount sets the amount of an execution, unless a previous attempt set it already, and
+// returns the execution with the amount it keeps.
+func (r *TransferRepository) SetStandingOrderExecutionAmount(ctx context.Context, id uuid.UUID, amount int64) (*model.StandingOrderExecution, error) {
+	execution, err := r.queries.SetStandingOrderExecutionAmount(ctx, sqlc.SetStandingOrderExecutionAmountParams{
+		Amount: sql.NullInt64{Int64: amount, Valid: true},
+		ID:     id,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return convertToModelExecution(execution), nil
+}
+
+// CompleteStandingOrderExecution records that a PENDING execution succeeded with the transfer transferID.
+func (r *TransferRepository) CompleteStandingOrderExecution(ctx context.Context, id uuid.UUID, transferID uuid.UUID) error {
+	return r.queries.CompleteStandingOrderExecution(ctx, sqlc.CompleteStandingOrderExecutionParams{
+		TransferID: uuid.NullUUID{UUID: transferID, Valid: true},
+		ID:         id,
+	})
+}
+
+// SettleStandingOrderExecution gives a PENDING execution its final status, FAILED or SKIPPED, because of lastError.
+// failed tells whether the transfer of the last attempt FAILED.
+func (r *TransferRepository) SettleStandingOrderExecution(ctx context.Context, id uuid.UUID, status string, failed bool, lastError string) error {
+	return r.queries.SettleStandingOrderExecution(ctx, sqlc.SettleStandingOrderExecutionParams{
+		Status:    status,
+		Failed:    boolToInt32(failed),
+		LastError: sql.NullString{String: lastError, Valid: lastError != ""},
+		ID:        id,
+	})
+}
+
+// RetryStandingOrderExecution schedules the next attempt of a PENDING execution at nextAttemptAt, after an attempt that
+// failed because of lastError. failed tells whether the transfer of the attempt FAILED.
+func (r *TransferRepository) RetryStandingOrderExecution(ctx context.Context, id uuid.UUID, failed bool, nextAttemptAt time.Time, lastError string) error {
+	return r.queries.RetryStandingOrderExecution(ctx, sqlc.RetryStandingOrderExecutionParams{
+		Failed:        boolToInt32(failed),
+		NextAttemptAt: nextAttemptAt,
+		LastError:     sql.NullString{String: lastError, Valid: lastError != ""},
+		ID:            id,
+	})
+}
+
+// SkipStandingOrderExecutions skips the PENDING executions of a cancelled standing order that have no transfer in
+// flight.
+func (r *TransferRepository) SkipStandingOrderExecutions(ctx context.Context, orderID uuid.UUID) error {
+	return r.queries.SkipStandingOrderExecutions(ctx, orderID)
+}
+
+func boolToInt32(b bool) int32 {
+	if b {
+		return 1
+	}
+	return 0
+}
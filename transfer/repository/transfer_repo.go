@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"time"
 	"transfer/db/sqlc"
 	"transfer/model"
 
@@ -35,7 +36,14 @@ func convertToModelTransfer(transfer sqlc.Transfer) *model.Transfer {
 		ToAccountID:    transfer.ToAccountID,
 		IdempotencyKey: transfer.IdempotencyKey,
 		Amount:         transfer.Amount,
+		Currency:       transfer.Currency,
+		ToAmount:       transfer.ToAmount,
+		ToCurrency:     transfer.ToCurrency,
+		Rate:           transfer.FxRate.String,
+		QuoteID:        transfer.QuoteID,
 		Status:         transfer.Status,
+		UserID:         transfer.UserID,
+		HoldID:         transfer.HoldID,
 	}
 }
 
@@ -47,7 +55,28 @@ func convertToCreateTransferParams(transfer *model.Transfer) sqlc.CreateTransfer
 		ToAccountID:    transfer.ToAccountID,
 		IdempotencyKey: transfer.IdempotencyKey,
 		Amount:         transfer.Amount,
+		Currency:       transfer.Currency,
+		ToAmount:       transfer.ToAmount,
+		ToCurrency:     transfer.ToCurrency,
+		FxRate:         sql.NullString{String: transfer.Rate, Valid: transfer.Rate != ""},
+		QuoteID:        transfer.QuoteID,
 		Status:         transfer.Status,
+		UserID:         transfer.UserID,
+	}
+}
+
+func convertToModelQuote(quote sqlc.FxQuote) *model.Quote {
+	return &model.Quote{
+		QuoteID:        quote.ID,
+		Amount:         quote.SourceAmount,
+		Currency:       quote.SourceCurrency,
+		TargetAmount:   quote.TargetAmount,
+		TargetCurrency: quote.TargetCurrency,
+		MidRate:        quote.MidRate,
+		Rate:           quote.Rate,
+		SpreadBps:      quote.SpreadBps,
+		ExpiresAt:      quote.ExpiresAt,
+		UsedAt:         quote.UsedAt.Time,
 	}
 }
 
@@ -96,3 +125,81 @@ func (r *TransferRepository) GetTransfersByToID(ctx context.Context, toAccountID
 	}
 	return modelTransfers, nil
 }
+
+// UpdateTransferStatus sets the status of the transfer.
+func (r *TransferRepository) UpdateTransferStatus(ctx context.Context, id uuid.UUID, status string) error {
+	return r.queries.UpdateTransferStatus(ctx, sqlc.UpdateTransferStatusParams{ID: id, Status: status})
+}
+
+// SetTransferHold records the hold placed for the transfer, unless one was recorded already.
+func (r *TransferRepository) SetTransferHold(ctx context.Context, id, holdID uuid.UUID) error {
+	return r.queries.SetTransferHold(ctx, sqlc.SetTransferHoldParams{
+		ID:     id,
+		HoldID: uuid.NullUUID{UUID: holdID, Valid: true},
+	})
+}
+
+// ClaimPendingTransfers leases at most batchSize PENDING transfers with a hold that weren't updated since
+// updatedBefore, until the lease runs out as well.
+func (r *TransferRepository) ClaimPendingTransfers(ctx context.Context, updatedBefore time.Time, batchSize int32) ([]*model.Transfer, error) {
+	transfers, err := r.queries.ClaimPendingTransfers(ctx, sqlc.ClaimPendingTransfersParams{
+		UpdatedBefore: sql.NullTime{Time: updatedBefore, Valid: true},
+		BatchSize:     batchSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*model.Transfer, len(transfers))
+	for i, transfer := range transfers {
+		res[i] = convertToModelTransfer(transfer)
+	}
+	return res, nil
+}
+
+// GetTransferByIdempotencyKey returns the transfer the user made with an idempotency key.
+func (r *TransferRepository) GetTransferByIdempotencyKey(ctx context.Context, userID uuid.UUID, idempotencyKey string) (*model.Transfer, error) {
+	transfer, err := r.queries.GetTransferByIdempotencyKey(ctx, sqlc.GetTransferByIdempotencyKeyParams{
+		UserID:         uuid.NullUUID{UUID: userID, Valid: true},
+		IdempotencyKey: idempotencyKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return convertToModelTransfer(transfer), nil
+}
+
+func (r *TransferRepository) CreateQuote(ctx context.Context, quote *model.Quote) (*model.Quote, error) {
+	createdQuote, err := r.queries.CreateQuote(ctx, sqlc.CreateQuoteParams{
+		ID:             quote.QuoteID,
+		SourceCurrency: quote.Currency,
+		SourceAmount:   quote.Amount,
+		TargetCurrency: quote.TargetCurrency,
+		TargetAmount:   quote.TargetAmount,
+		MidRate:        quote.MidRate,
+		Rate:           quote.Rate,
+		SpreadBps:      quote.SpreadBps,
+		ExpiresAt:      quote.ExpiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return convertToModelQuote(createdQuote), nil
+}
+
+func (r *TransferRepository) GetQuoteByID(ctx context.Context, id uuid.UUID) (*model.Quote, error) {
+	quote, err := r.queries.GetQuoteByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return convertToModelQuote(quote), nil
+}
+
+// UseQuote marks the quote as used and returns it, or returns sql.ErrNoRows if it is unknown, expired or already used.
+// The quote stays locked until the transaction commits.
+func (r *TransferRepository) UseQuote(ctx context.Context, id uuid.UUID) (*model.Quote, error) {
+	quote, err := r.queries.UseQuote(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return convertToModelQuote(quote), nil
+}
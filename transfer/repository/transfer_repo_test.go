@@ -1,8 +1,12 @@
 package repository
 
 import (
+	"account/money"
 	"context"
+	"database/sql"
 	"testing"
+	"time"
+	"transfer/config"
 	"transfer/db/initialize"
 	"transfer/model"
 	"transfer/utils"
@@ -14,7 +18,9 @@ import (
 
 // setupTestDB initializes a test database connection and returns a teardown function.
 func setupTestDB(t *testing.T) (*sqlx.DB, func()) {
-	db := initialize.ConnectDB()
+	cfg, err := config.LoadDB()
+	require.NoError(t, err)
+	db := initialize.ConnectDB(cfg)
 	return db, func() {
 		err := db.Close()
 		require.NoError(t, err)
@@ -38,6 +44,9 @@ func TestCreateTransfer_Success(t *testing.T) {
 		ToAccountID:    account2.AccountID,
 		IdempotencyKey: uuid.NewString(),
 		Amount:         100,
+		Currency:       money.DefaultCurrency,
+		ToAmount:       100,
+		ToCurrency:     money.DefaultCurrency,
 		Status:         "PENDING",
 	}
 	createdTransfer, err := repo.CreateTransfer(context.Background(), transfer)
@@ -58,13 +67,18 @@ func TestCreateTransfer_Idempotency(t *testing.T) {
 
 	// Create a transfer
 	idempotencyKey := uuid.NewString()
+	owner := uuid.NullUUID{UUID: uuid.New(), Valid: true}
 	transfer1 := &model.Transfer{
 		TransferID:     uuid.New(),
 		FromAccountID:  account1.AccountID,
 		ToAccountID:    account2.AccountID,
 		IdempotencyKey: idempotencyKey,
 		Amount:         100,
+		Currency:       money.DefaultCurrency,
+		ToAmount:       100,
+		ToCurrency:     money.DefaultCurrency,
 		Status:         "PENDING",
+		UserID:         owner,
 	}
 	_, err := repo.CreateTransfer(context.Background(), transfer1)
 	require.NoError(t, err)
@@ -76,10 +90,22 @@ func TestCreateTransfer_Idempotency(t *testing.T) {
 		ToAccountID:    account2.AccountID,
 		IdempotencyKey: idempotencyKey,
 		Amount:         200,
+		Currency:       money.DefaultCurrency,
+		ToAmount:       200,
+		ToCurrency:     money.DefaultCurrency,
 		Status:         "PENDING",
+		UserID:         owner,
 	}
 	_, err = repo.CreateTransfer(context.Background(), transfer2)
 	require.Error(t, err)
+
+	// the key is only unique per user
+	transfer2.UserID = uuid.NullUUID{UUID: uuid.New(), Valid: true}
+	_, err = repo.CreateTransfer(context.Background(), transfer2)
+	require.NoError(t, err)
+	fetched, err := repo.GetTransferByIdempotencyKey(context.Background(), owner.UUID, idempotencyKey)
+	require.NoError(t, err)
+	require.Equal(t, transfer1.TransferID, fetched.TransferID)
 }
 
 // Tests that creating multiple transfers concurrently doesn't give an error
@@ -105,6 +131,9 @@ func TestCreateMultipleTransfers_Success(t *testing.T) {
 				ToAccountID:    account2.AccountID,
 				IdempotencyKey: uuid.NewString(),
 				Amount:         100,
+				Currency:       money.DefaultCurrency,
+				ToAmount:       100,
+				ToCurrency:     money.DefaultCurrency,
 				Status:         "PENDING",
 			}
 			createdTransfer, err := repo.CreateTransfer(context.Background(), transfer)
@@ -141,6 +170,9 @@ func TestGetTransferByID_Success(t *testing.T) {
 		ToAccountID:    account2.AccountID,
 		IdempotencyKey: uuid.NewString(),
 		Amount:         100,
+		Currency:       money.DefaultCurrency,
+		ToAmount:       100,
+		ToCurrency:     money.DefaultCurrency,
 		Status:         "PENDING",
 	}
 	createdTransfer, err := repo.CreateTransfer(context.Background(), transfer)
@@ -151,3 +183,151 @@ func TestGetTransferByID_Success(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, createdTransfer, retrievedTransfer)
 }
+
+// A quote is used by a single cross-currency transfer, before it expires
+func TestUseQuote_Success(t *testing.T) {
+	db, teardown := setupTestDB(t)
+	defer teardown()
+	repo := NewTransferRepository(db)
+
+	quote := &model.Quote{
+		QuoteID:        uuid.New(),
+		Amount:         10_000,
+		Currency:       "EUR",
+		TargetAmount:   10_890,
+		TargetCurrency: "USD",
+		MidRate:        "1.100000000000",
+		Rate:           "1.089000000000",
+		SpreadBps:      100,
+		ExpiresAt:      time.Now().Add(time.Minute).Truncate(time.Microsecond),
+	}
+	createdQuote, err := repo.CreateQuote(context.Background(), quote)
+	require.NoError(t, err)
+	require.Equal(t, quote.QuoteID, createdQuote.QuoteID)
+	require.Equal(t, quote.Rate, createdQuote.Rate)
+	require.True(t, createdQuote.UsedAt.IsZero())
+
+	tx, err := db.BeginTx(context.Background(), nil)
+	require.NoError(t, err)
+	defer tx.Rollback()
+	usedQuote, err := repo.WithTx(tx).UseQuote(context.Background(), quote.QuoteID)
+	require.NoError(t, err)
+	require.False(t, usedQuote.UsedAt.IsZero())
+
+	transfer := utils.RandomTransfer()
+	transfer.Status = "PENDING"
+	transfer.Currency = quote.Currency
+	transfer.Amount = quote.Amount
+	transfer.ToCurrency = quote.TargetCurrency
+	transfer.ToAmount = quote.TargetAmount
+	transfer.Rate = quote.Rate
+	transfer.QuoteID = uuid.NullUUID{UUID: quote.QuoteID, Valid: true}
+	createdTransfer, err := repo.WithTx(tx).CreateTransfer(context.Background(), transfer)
+	require.NoError(t, err)
+	require.Equal(t, quote.Rate, createdTransfer.Rate)
+	require.NoError(t, tx.Commit())
+
+	// already used
+	_, err = repo.UseQuote(context.Background(), quote.QuoteID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// expired
+	quote.QuoteID = uuid.New()
+	quote.ExpiresAt = time.Now().Add(-time.Second)
+	_, err = repo.CreateQuote(context.Background(), quote)
+	require.NoError(t, err)
+	_, err = repo.UseQuote(context.Background(), quote.QuoteID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+// Tests that a standing order can be cancelled only once, and is listed with its account.
+func TestCancelStandingOrder_Success(t *testing.T) {
+	db, teardown := setupTestDB(t)
+	defer teardown()
+	repo := NewTransferRepository(db)
+	ctx := context.Background()
+
+	startsAt := time.Now().Truncate(time.Second)
+	order := &model.StandingOrder{
+		UserID:         uuid.New(),
+		FromAccountID:  utils.RandomAccount().AccountID,
+		ToAccountID:    utils.RandomAccount().AccountID,
+		Currency:       money.DefaultCurrency,
+		Amount:         100,
+		Schedule:       "0 9 1 * *",
+		StartsAt:       startsAt,
+		NextRunAt:      startsAt.Add(time.Hour),
+		IdempotencyKey: uuid.NewString(),
+	}
+	createdOrder, err := repo.CreateStandingOrder(ctx, order)
+	require.NoError(t, err)
+	require.Equal(t, model.StandingOrderActive, createdOrder.Status)
+	require.True(t, createdOrder.EndsAt.IsZero())
+
+	fetchedOrder, err := repo.GetStandingOrderByIdempotencyKey(ctx, order.UserID, order.IdempotencyKey)
+	require.NoError(t, err)
+	require.Equal(t, createdOrder.StandingOrderID, fetchedOrder.StandingOrderID)
+	_, err = repo.GetStandingOrderByIdempotencyKey(ctx, uuid.New(), order.IdempotencyKey)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	cancelledOrder, err := repo.CancelStandingOrder(ctx, createdOrder.StandingOrderID)
+	require.NoError(t, err)
+	require.Equal(t, model.StandingOrderCancelled, cancelledOrder.Status)
+	require.True(t, cancelledOrder.NextRunAt.IsZero())
+	_, err = repo.CancelStandingOrder(ctx, createdOrder.StandingOrderID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	orders, err := repo.ListStandingOrdersByAccountID(ctx, order.FromAccountID)
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+	require.Equal(t, model.StandingOrderCancelled, orders[0].Status)
+}
+
+// Tests that only the PENDING transfers with a hold are claimed, and stay leased until they are claimed again.
+func TestClaimPendingTransfers_Success(t *testing.T) {
+	db, teardown := setupTestDB(t)
+	defer teardown()
+	repo := NewTransferRepository(db)
+	ctx := context.Background()
+
+	newTransfer := func() *model.Transfer {
+		transfer, err := repo.CreateTransfer(ctx, &model.Transfer{
+			TransferID:     uuid.New(),
+			FromAccountID:  utils.RandomAccount().AccountID,
+			ToAccountID:    utils.RandomAccount().AccountID,
+			IdempotencyKey: uuid.NewString(),
+			Amount:         100,
+			Currency:       money.DefaultCurrency,
+			ToAmount:       100,
+			ToCurrency:     money.DefaultCurrency,
+			Status:         model.TransferPending,
+			UserID:         uuid.NullUUID{UUID: uuid.New(), Valid: true},
+		})
+		require.NoError(t, err)
+		return transfer
+	}
+	withHold, withoutHold := newTransfer(), newTransfer()
+	holdID := uuid.New()
+	require.NoError(t, repo.SetTransferHold(ctx, withHold.TransferID, holdID))
+	// the first hold is kept
+	require.NoError(t, repo.SetTransferHold(ctx, withHold.TransferID, uuid.New()))
+
+	claimedIDs := func(before time.Time) map[uuid.UUID]*model.Transfer {
+		claimed, err := repo.ClaimPendingTransfers(ctx, before, 1000)
+		require.NoError(t, err)
+		ids := make(map[uuid.UUID]*model.Transfer, len(claimed))
+		for _, transfer := range claimed {
+			ids[transfer.TransferID] = transfer
+		}
+		return ids
+	}
+	before := time.Now()
+	claimed := claimedIDs(before)
+	require.Contains(t, claimed, withHold.TransferID)
+	require.NotContains(t, claimed, withoutHold.TransferID)
+	require.Equal(t, uuid.NullUUID{UUID: holdID, Valid: true}, claimed[withHold.TransferID].HoldID)
+	require.Equal(t, withHold.UserID, claimed[withHold.TransferID].UserID)
+
+	// leased by the first claim
+	require.NotContains(t, claimedIDs(before), withHold.TransferID)
+}
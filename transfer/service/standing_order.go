@@ -0,0 +1,363 @@
+package service
+
+import (
+	accountpb "account/proto"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+	"transfer/client"
+	"transfer/internal/identity"
+	"transfer/internal/notify"
+	"transfer/internal/schedule"
+	"transfer/model"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	defaultMaxAttempts  = 3
+	defaultRetryBackoff = time.Hour
+	defaultLease        = 5 * time.Minute
+)
+
+// WithStandingOrderRetries returns a new TransferService that makes at most maxAttempts attempts to execute an
+// occurrence of a standing order, waiting backoff times the number of attempts so far between them. An attempt that
+// didn't record its outcome within lease, e.g. because the worker crashed, is retried.
+func (s *TransferService) WithStandingOrderRetries(maxAttempts int, backoff, lease time.Duration) *TransferService {
+	cp := *s
+	cp.maxAttempts = int32(maxAttempts)
+	cp.retryBackoff = backoff
+	cp.lease = lease
+	return &cp
+}
+
+// WithNotifier returns a new TransferService that tells n about the executions of standing orders that failed after
+// their last attempt.
+func (s *TransferService) WithNotifier(n notify.Notifier) *TransferService {
+	cp := *s
+	cp.notifier = n
+	return &cp
+}
+
+// CreateStandingOrder creates a standing order transferring from one account of the user to another at every
+// occurrence of its schedule, from order.StartsAt, or from now if it is zero. Both accounts must be in order.Currency.
+// The schedule must have an occurrence before order.EndsAt, if the order ends.
+// An order with the idempotency key of a previous order of the user returns the previous one.
+func (s *TransferService) CreateStandingOrder(ctx context.Context, order *model.StandingOrder) (*model.StandingOrder, error) {
+	if (!order.Sweep && order.Amount <= 0) || (order.Sweep && order.SweepAbove < 0) || order.MaxExecutions < 0 ||
+		order.FromAccountID == order.ToAccountID {
+		return nil, model.ErrInvalidArgument
+	}
+
+	userID, err := identity.UserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := s.accounts.GetAccountByAccountId(ctx, &accountpb.GetAccountByAccountIdRequest{AccountId: order.FromAccountID.String()})
+	if err != nil {
+		log.Printf("CreateStandingOrder: Failed to get source account %v: %v\n", order.FromAccountID, err)
+		return nil, err
+	}
+	to, err := s.accounts.GetAccountByAccountId(ctx, &accountpb.GetAccountByAccountIdRequest{AccountId: order.ToAccountID.String()})
+	if err != nil {
+		log.Printf("CreateStandingOrder: Failed to get destination account %v: %v\n", order.ToAccountID, err)
+		return nil, err
+	}
+	if from.GetUserId() != userID.String() || to.GetUserId() != userID.String() {
+		log.Printf("CreateStandingOrder: Account %v or %v is not an account of user %v\n", order.FromAccountID, order.ToAccountID, userID)
+		return nil, model.ErrNotAuthorized
+	}
+	if from.GetBalance().GetCurrency() != order.Currency || to.GetBalance().GetCurrency() != order.Currency {
+		log.Printf("CreateStandingOrder: Standing order in %q from %v to %v\n", order.Currency, from.GetBalance().GetCurrency(), to.GetBalance().GetCurrency())
+		return nil, model.ErrCurrencyMismatch
+	}
+	existing, err := s.repo.GetStandingOrderByIdempotencyKey(ctx, userID, order.IdempotencyKey)
+	if err == nil {
+		return replayStandingOrder(existing, order)
+	}
+	if err != sql.ErrNoRows {
+		log.Printf("CreateStandingOrder: Failed to get standing order by idempotency key: %v\n", err)
+		return nil, model.ErrInternalServer
+	}
+
+	record := *order
+	record.UserID = userID
+	if record.StartsAt.IsZero() {
+		record.StartsAt = time.Now()
+	}
+	sched, err := schedule.Parse(record.Schedule, record.StartsAt)
+	if err != nil {
+		log.Printf("CreateStandingOrder: Failed to parse schedule %q: %v\n", record.Schedule, err)
+		return nil, model.ErrInvalidSchedule
+	}
+	record.NextRunAt = schedule.First(sched, record.StartsAt)
+	if record.NextRunAt.IsZero() || (!record.EndsAt.IsZero() && record.NextRunAt.After(record.EndsAt)) {
+		log.Printf("CreateStandingOrder: Schedule %q has no occurrence from %v until %v\n", record.Schedule, record.StartsAt, record.EndsAt)
+		return nil, model.ErrInvalidSchedule
+	}
+
+	createdOrder, err := s.repo.CreateStandingOrder(ctx, &record)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			// a concurrent request with the same idempotency key created it first
+			if existing, err := s.repo.GetStandingOrderByIdempotencyKey(ctx, userID, order.IdempotencyKey); err == nil {
+				return replayStandingOrder(existing, order)
+			}
+		}
+		log.Printf("CreateStandingOrder: Failed to create standing order: %v\n", err)
+		return nil, model.ErrInternalServer
+	}
+	return createdOrder, nil
+}
+
+// replayStandingOrder returns the standing order created for a previous request of the user with the same idempotency
+// key as req.
+func replayStandingOrder(existing, req *model.StandingOrder) (*model.StandingOrder, error) {
+	if existing.FromAccountID != req.FromAccountID || existing.ToAccountID != req.ToAccountID ||
+		existing.Currency != req.Currency || existing.Amount != req.Amount || existing.Sweep != req.Sweep ||
+		existing.SweepAbove != req.SweepAbove || existing.Schedule != req.Schedule ||
+		(!req.StartsAt.IsZero() && !existing.StartsAt.Equal(req.StartsAt)) || !existing.EndsAt.Equal(req.EndsAt) ||
+		existing.MaxExecutions != req.MaxExecutions || existing.Memo != req.Memo {
+		log.Printf("replayStandingOrder: Idempotency key of standing order %v reused for a different request\n", existing.StandingOrderID)
+		return nil, model.ErrIdempotencyKeyReused
+	}
+	return existing, nil
+}
+
+// ListStandingOrders returns the standing orders debiting an account of the user, the oldest first.
+func (s *TransferService) ListStandingOrders(ctx context.Context, accountID uuid.UUID) ([]*model.StandingOrder, error) {
+	userID, err := identity.UserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	account, err := s.accounts.GetAccountByAccountId(ctx, &accountpb.GetAccountByAccountIdRequest{AccountId: accountID.String()})
+	if err != nil {
+		log.Printf("ListStandingOrders: Failed to get account %v: %v\n", accountID, err)
+		return nil, err
+	}
+	if account.GetUserId() != userID.String() {
+		log.Printf("ListStandingOrders: Account %v is not an account of user %v\n", accountID, userID)
+		return nil, model.ErrNotAuthorized
+	}
+	orders, err := s.repo.ListStandingOrdersByAccountID(ctx, accountID)
+	if err != nil {
+		log.Printf("ListStandingOrders: Failed to list standing orders: %v\n", err)
+		return nil, model.ErrInternalServer
+	}
+	return orders, nil
+}
+
+// CancelStandingOrder cancels a standing order of the user, and skips its pending executions. The executions whose
+// transfer may be in flight still finish. Cancelling a cancelled order returns it, and a completed one fails with
+// ErrStandingOrderEnded.
+func (s *TransferService) CancelStandingOrder(ctx context.Context, orderID uuid.UUID) (*model.StandingOrder, error) {
+	userID, err := identity.UserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := s.repo.GetStandingOrderByID(ctx, orderID)
+	if err != nil {
+		log.Printf("CancelStandingOrder: Failed to get standing order %v: %v\n", orderID, err)
+		if err == sql.ErrNoRows {
+			return nil, model.ErrOrderNotFound
+		}
+		return nil, model.ErrInternalServer
+	}
+	if order.UserID != userID {
+		log.Printf("CancelStandingOrder: Standing order %v is not an order of user %v\n", orderID, userID)
+		return nil, model.ErrNotAuthorized
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("CancelStandingOrder: Failed to begin transaction: %v\n", err)
+		return nil, model.ErrInternalServer
+	}
+	defer tx.Rollback()
+	txRepo := s.repo.WithTx(tx)
+
+	cancelled, err := txRepo.CancelStandingOrder(ctx, orderID)
+	if err == sql.ErrNoRows {
+		// the order isn't ACTIVE anymore, it may have completed since we read it
+		if order, err = txRepo.GetStandingOrderByID(ctx, orderID); err != nil {
+			log.Printf("CancelStandingOrder: Failed to get standing order %v: %v\n", orderID, err)
+			return nil, model.ErrInternalServer
+		}
+		if order.Status == model.StandingOrderCompleted {
+			return nil, model.ErrStandingOrderEnded
+		}
+		return order, nil
+	}
+	if err != nil {
+		log.Printf("CancelStandingOrder: Failed to cancel standing order %v: %v\n", orderID, err)
+		return nil, model.ErrInternalServer
+	}
+	if err = txRepo.SkipStandingOrderExecutions(ctx, orderID); err != nil {
+		log.Printf("CancelStandingOrder: Failed to skip executions of standing order %v: %v\n", orderID, err)
+		return nil, model.ErrInternalServer
+	}
+	if err = tx.Commit(); err != nil {
+		log.Printf("CancelStandingOrder: Failed to commit transaction: %v\n", err)
+		return nil, model.ErrInternalServer
+	}
+	return cancelled, nil
+}
+
+// ScheduleStandingOrders creates the executions of the occurrences due before now of at most batchSize standing
+// orders, one occurrence per order, and moves the orders to their next occurrence. An order completes once it is past
+// its end or has reached its maximum number of executions. It returns how many orders it advanced, so that it can be
+// called until it returns less than batchSize, catching up with the occurrences missed meanwhile.
+// Concurrent workers advance different orders.
+func (s *TransferService) ScheduleStandingOrders(ctx context.Context, now time.Time, batchSize int32) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	txRepo := s.repo.WithTx(tx)
+
+	orders, err := txRepo.ListDueStandingOrders(ctx, now, batchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list due standing orders: %w", err)
+	}
+	for _, order := range orders {
+		// validated when the order was created
+		sched, err := schedule.Parse(order.Schedule, order.StartsAt)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse schedule of standing order %v: %w", order.StandingOrderID, err)
+		}
+		occurrence := order.NextRunAt
+		if err = txRepo.CreateStandingOrderExecution(ctx, order.StandingOrderID, occurrence, order.Amount, occurrence); err != nil {
+			return 0, fmt.Errorf("failed to create execution of standing order %v: %w", order.StandingOrderID, err)
+		}
+
+		next, status := sched.Next(occurrence), model.StandingOrderActive
+		if next.IsZero() || (!order.EndsAt.IsZero() && next.After(order.EndsAt)) ||
+			(order.MaxExecutions > 0 && order.Executions+1 >= order.MaxExecutions) {
+			next, status = time.Time{}, model.StandingOrderCompleted
+		}
+		if _, err = txRepo.AdvanceStandingOrder(ctx, order.StandingOrderID, next, status); err != nil {
+			return 0, fmt.Errorf("failed to advance standing order %v: %w", order.StandingOrderID, err)
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return int64(len(orders)), nil
+}
+
+// ExecuteStandingOrders attempts at most batchSize executions of standing orders due before now, each as a transfer
+// made on behalf of the owner of the order. It returns how many it attempted, so that it can be called until it
+// returns less than batchSize. Concurrent workers claim different executions.
+// The transfer of an occurrence has an idempotency key derived from the occurrence, so that retrying an attempt whose
+// outcome is unknown doesn't transfer twice. An attempt whose transfer FAILED is retried with a new transfer, until
+// the execution runs out of attempts, fails and is notified.
+func (s *TransferService) ExecuteStandingOrders(ctx context.Context, now time.Time, batchSize int32) (int64, error) {
+	executions, err := s.repo.ClaimDueStandingOrderExecutions(ctx, now, now.Add(s.lease), batchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim due executions: %w", err)
+	}
+	var errs []error
+	for _, execution := range executions {
+		if err := s.executeStandingOrder(ctx, now, execution); err != nil {
+			// retried once its lease expires
+			errs = append(errs, fmt.Errorf("failed to record execution %v: %w", execution.ExecutionID, err))
+		}
+	}
+	return int64(len(executions)), errors.Join(errs...)
+}
+
+// executeStandingOrder makes an attempt of a claimed execution and records its outcome. It only fails if the outcome
+// couldn't be recorded.
+func (s *TransferService) executeStandingOrder(ctx context.Context, now time.Time, execution *model.StandingOrderExecution) error {
+	order, err := s.repo.GetStandingOrderByID(ctx, execution.StandingOrderID)
+	if err != nil {
+		return err
+	}
+	key := executionIdempotencyKey(execution)
+	// the transfers are made as the user, who doesn't make the request
+	ctx = identity.NewContext(client.OnBehalfOf(ctx, order.UserID), identity.Principal{UserID: order.UserID})
+
+	if order.Status == model.StandingOrderCancelled {
+		// finished only if a previous attempt may have transferred already
+		if _, err := s.repo.GetTransferByIdempotencyKey(ctx, order.UserID, key); err == sql.ErrNoRows {
+			return s.repo.SettleStandingOrderExecution(ctx, execution.ExecutionID, model.ExecutionSkipped, false, "standing order cancelled")
+		}
+	}
+
+	if order.Sweep && execution.Amount == 0 {
+		// the amount is fixed by the first attempt, so that the retries transfer the same
+		account, err := s.accounts.GetAccountByAccountId(ctx, &accountpb.GetAccountByAccountIdRequest{AccountId: order.FromAccountID.String()})
+		if err != nil {
+			log.Printf("executeStandingOrder: Failed to get account %v: %v\n", order.FromAccountID, err)
+			return s.retryStandingOrder(ctx, now, order, execution, key, err)
+		}
+		amount := account.GetAvailableBalance().GetUnits() - order.SweepAbove
+		if amount <= 0 {
+			return s.repo.SettleStandingOrderExecution(ctx, execution.ExecutionID, model.ExecutionSkipped, false, "nothing to sweep")
+		}
+		if execution, err = s.repo.SetStandingOrderExecutionAmount(ctx, execution.ExecutionID, amount); err != nil {
+			return err
+		}
+	}
+
+	transfer, err := s.CreateTransfer(ctx, &model.Transfer{
+		FromAccountID:  order.FromAccountID,
+		ToAccountID:    order.ToAccountID,
+		Amount:         execution.Amount,
+		Currency:       order.Currency,
+		IdempotencyKey: key,
+	})
+	if err != nil {
+		log.Printf("executeStandingOrder: Failed to execute standing order %v scheduled at %v: %v\n", order.StandingOrderID, execution.ScheduledAt, err)
+		return s.retryStandingOrder(ctx, now, order, execution, key, err)
+	}
+	return s.repo.CompleteStandingOrderExecution(ctx, execution.ExecutionID, transfer.TransferID)
+}
+
+// retryStandingOrder records an attempt of an execution that failed because of cause, and schedules the next attempt,
+// or fails the execution and notifies it if it was the last one. An execution whose transfer is PENDING is retried
+// whatever its attempts, since only a retry can tell whether it transferred.
+func (s *TransferService) retryStandingOrder(ctx context.Context, now time.Time, order *model.StandingOrder, execution *model.StandingOrderExecution, key string, cause error) error {
+	failed, inFlight := false, false
+	transfer, err := s.repo.GetTransferByIdempotencyKey(ctx, order.UserID, key)
+	switch {
+	case err == nil:
+		failed = transfer.Status == model.TransferFailed
+		inFlight = transfer.Status == model.TransferPending
+	case err != sql.ErrNoRows:
+		log.Printf("retryStandingOrder: Failed to get transfer by idempotency key: %v\n", err)
+		inFlight = true
+	}
+
+	lastError := cause.Error()
+	if !inFlight && execution.Attempts >= s.maxAttempts {
+		if err := s.repo.SettleStandingOrderExecution(ctx, execution.ExecutionID, model.ExecutionFailed, failed, lastError); err != nil {
+			return err
+		}
+		failedExecution := *execution
+		failedExecution.Status = model.ExecutionFailed
+		failedExecution.LastError = lastError
+		if err := s.notifier.StandingOrderFailed(ctx, order, &failedExecution); err != nil {
+			log.Printf("retryStandingOrder: Failed to notify failure of execution %v: %v\n", execution.ExecutionID, err)
+		}
+		return nil
+	}
+	nextAttemptAt := now.Add(s.retryBackoff * time.Duration(execution.Attempts))
+	return s.repo.RetryStandingOrderExecution(ctx, execution.ExecutionID, failed, nextAttemptAt, lastError)
+}
+
+// executionIdempotencyKey returns the idempotency key of the transfer of an execution, the same for every attempt
+// until a transfer of the execution FAILED.
+func executionIdempotencyKey(execution *model.StandingOrderExecution) string {
+	occurrence := execution.ScheduledAt.UTC().Format(time.RFC3339) + "/" + strconv.Itoa(int(execution.Failures))
+	return uuid.NewSHA1(execution.StandingOrderID, []byte(occurrence)).String()
+}
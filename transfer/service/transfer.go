@@ -0,0 +1,350 @@
+package service
+
+import (
+	"account/money"
+	accountpb "account/proto"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+	"transfer/client"
+	"transfer/internal/fx"
+	"transfer/internal/identity"
+	"transfer/internal/notify"
+	"transfer/model"
+	"transfer/repository"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TransferService moves money between accounts through the account service: a transfer reserves the amount on the
+// source account with a hold, credits the destination account with a TRANSFER_CREDIT transaction, then captures the
+// hold as a TRANSFER_DEBIT one.
+// The transfer is recorded before its legs are posted, and the legs have idempotency keys derived from its ID, so that
+// retrying a transfer left PENDING by a crash posts each leg at most once. See FinishPendingTransfers for the transfers
+// whose request isn't retried.
+type TransferService struct {
+	repo     *repository.TransferRepository
+	db       *sqlx.DB
+	accounts accountpb.AccountServiceClient
+	quoter   *fx.Quoter
+
+	// executions of standing orders
+	maxAttempts  int32
+	retryBackoff time.Duration
+	lease        time.Duration
+	notifier     notify.Notifier
+}
+
+// r and db should be created in the main function and passed to the service.
+// accounts must forward the access token of the user, which the account service authorizes every leg with.
+func NewTransferService(r *repository.TransferRepository, db *sqlx.DB, accounts accountpb.AccountServiceClient, quoter *fx.Quoter) *TransferService {
+	return &TransferService{
+		repo:         r,
+		db:           db,
+		accounts:     accounts,
+		quoter:       quoter,
+		maxAttempts:  defaultMaxAttempts,
+		retryBackoff: defaultRetryBackoff,
+		lease:        defaultLease,
+		notifier:     notify.LogNotifier{},
+	}
+}
+
+// CreateQuote prices the transfer of amount to an account in the currency to, and stores the quote so that a single
+// transfer can use it until it expires.
+func (s *TransferService) CreateQuote(ctx context.Context, amount money.Money, to string) (*model.Quote, error) {
+	if !money.Supported(amount.Currency) || !money.Supported(to) {
+		log.Printf("CreateQuote: Unsupported currency %q or %q\n", amount.Currency, to)
+		return nil, model.ErrUnsupportedCurrency
+	}
+	if amount.Currency == to || amount.Units <= 0 {
+		return nil, model.ErrInvalidArgument
+	}
+
+	quote, err := s.quoter.Quote(ctx, amount, to)
+	if err != nil {
+		log.Printf("CreateQuote: Failed to price %v in %v: %v\n", amount, to, err)
+		switch {
+		case errors.Is(err, fx.ErrNoRate):
+			return nil, model.ErrRateUnavailable
+		case errors.Is(err, fx.ErrZeroAmount), errors.Is(err, money.ErrOverflow):
+			return nil, model.ErrInvalidArgument
+		}
+		return nil, model.ErrInternalServer
+	}
+
+	createdQuote, err := s.repo.CreateQuote(ctx, quote)
+	if err != nil {
+		log.Printf("CreateQuote: Failed to create quote: %v\n", err)
+		return nil, model.ErrInternalServer
+	}
+	return createdQuote, nil
+}
+
+// CreateTransfer transfers transfer.Amount of transfer.Currency from transfer.FromAccountID, an account of the user, to
+// transfer.ToAccountID, an account of any user.
+// The accounts must have different currencies if and only if transfer.QuoteID is set, the credited leg being the
+// target amount of the quote.
+// A transfer with the idempotency key of a previous transfer of the user returns the previous one, after finishing it if
+// it is still PENDING, or ErrTransferFailed if it failed. Idempotency keys are scoped to the user, so the transfer of
+// another user with the same key is a new one.
+func (s *TransferService) CreateTransfer(ctx context.Context, transfer *model.Transfer) (*model.Transfer, error) {
+	userID, err := identity.UserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.GetTransferByIdempotencyKey(ctx, userID, transfer.IdempotencyKey)
+	if err == nil {
+		return s.replayTransfer(ctx, existing, transfer)
+	}
+	if err != sql.ErrNoRows {
+		log.Printf("CreateTransfer: Failed to get transfer by idempotency key: %v\n", err)
+		return nil, model.ErrInternalServer
+	}
+
+	from, err := s.accounts.GetAccountByAccountId(ctx, &accountpb.GetAccountByAccountIdRequest{AccountId: transfer.FromAccountID.String()})
+	if err != nil {
+		log.Printf("CreateTransfer: Failed to get source account %v: %v\n", transfer.FromAccountID, err)
+		return nil, err
+	}
+	if from.GetUserId() != userID.String() {
+		log.Printf("CreateTransfer: Account %v is not an account of user %v\n", transfer.FromAccountID, userID)
+		return nil, model.ErrNotAuthorized
+	}
+	to, err := s.accounts.GetAccountByAccountId(ctx, &accountpb.GetAccountByAccountIdRequest{AccountId: transfer.ToAccountID.String()})
+	if err != nil {
+		log.Printf("CreateTransfer: Failed to get destination account %v: %v\n", transfer.ToAccountID, err)
+		return nil, err
+	}
+	if from.GetBalance().GetCurrency() != transfer.Currency {
+		log.Printf("CreateTransfer: Transfer in %q from account %v in %v\n", transfer.Currency, transfer.FromAccountID, from.GetBalance().GetCurrency())
+		return nil, model.ErrCurrencyMismatch
+	}
+	toCurrency := to.GetBalance().GetCurrency()
+	if toCurrency != transfer.Currency && !transfer.QuoteID.Valid {
+		log.Printf("CreateTransfer: Transfer from %v to %v without a quote\n", transfer.Currency, toCurrency)
+		return nil, model.ErrQuoteRequired
+	}
+	if toCurrency == transfer.Currency && transfer.QuoteID.Valid {
+		log.Printf("CreateTransfer: Quote %v for a transfer in a single currency\n", transfer.QuoteID.UUID)
+		return nil, model.ErrQuoteMismatch
+	}
+
+	// the user is the owner of the source account, on whose behalf the transfer is finished if it is left PENDING
+	createdTransfer, err := s.recordTransfer(ctx, transfer, userID, toCurrency)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			// a concurrent request with the same idempotency key recorded it first
+			if existing, err := s.repo.GetTransferByIdempotencyKey(ctx, userID, transfer.IdempotencyKey); err == nil {
+				return s.replayTransfer(ctx, existing, transfer)
+			}
+		}
+		return nil, err
+	}
+	return s.executeTransfer(ctx, createdTransfer)
+}
+
+// recordTransfer stores the transfer of the account of owner as PENDING, using its quote if it has one so that no other
+// transfer can.
+func (s *TransferService) recordTransfer(ctx context.Context, transfer *model.Transfer, owner uuid.UUID, toCurrency string) (*model.Transfer, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("recordTransfer: Failed to begin transaction: %v\n", err)
+		return nil, model.ErrInternalServer
+	}
+	defer tx.Rollback()
+	txRepo := s.repo.WithTx(tx)
+
+	record := *transfer
+	record.TransferID = uuid.New()
+	record.Status = model.TransferPending
+	record.ToAmount = transfer.Amount
+	record.ToCurrency = toCurrency
+	record.Rate = ""
+	record.UserID = uuid.NullUUID{UUID: owner, Valid: true}
+	record.HoldID = uuid.NullUUID{}
+	if transfer.QuoteID.Valid {
+		// the quote stays locked until we commit, and is released if the transfer isn't recorded
+		quote, err := txRepo.UseQuote(ctx, transfer.QuoteID.UUID)
+		if err != nil {
+			log.Printf("recordTransfer: Failed to use quote %v: %v\n", transfer.QuoteID.UUID, err)
+			if err != sql.ErrNoRows {
+				return nil, model.ErrInternalServer
+			}
+			if _, err = s.repo.GetQuoteByID(ctx, transfer.QuoteID.UUID); err == sql.ErrNoRows {
+				return nil, model.ErrQuoteNotFound
+			}
+			return nil, model.ErrQuoteExpired
+		}
+		if quote.Amount != transfer.Amount || quote.Currency != transfer.Currency || quote.TargetCurrency != toCurrency {
+			log.Printf("recordTransfer: Quote %v is for %v %v in %v\n", quote.QuoteID, quote.Amount, quote.Currency, quote.TargetCurrency)
+			return nil, model.ErrQuoteMismatch
+		}
+		record.ToAmount = quote.TargetAmount
+		record.Rate = quote.Rate
+	}
+
+	createdTransfer, err := txRepo.CreateTransfer(ctx, &record)
+	if err != nil {
+		log.Printf("recordTransfer: Failed to create transfer: %v\n", err)
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		log.Printf("recordTransfer: Failed to commit transaction: %v\n", err)
+		return nil, model.ErrInternalServer
+	}
+	return createdTransfer, nil
+}
+
+// replayTransfer returns the transfer recorded for a previous request of the user with the same idempotency key as req.
+// existing was made by the user of ctx, on whose behalf it is finished if it is still PENDING.
+func (s *TransferService) replayTransfer(ctx context.Context, existing, req *model.Transfer) (*model.Transfer, error) {
+	if existing.FromAccountID != req.FromAccountID || existing.ToAccountID != req.ToAccountID ||
+		existing.Amount != req.Amount || existing.Currency != req.Currency || existing.QuoteID != req.QuoteID {
+		log.Printf("replayTransfer: Idempotency key of transfer %v reused for a different request\n", existing.TransferID)
+		return nil, model.ErrIdempotencyKeyReused
+	}
+	switch existing.Status {
+	case model.TransferPending:
+		return s.executeTransfer(ctx, existing)
+	case model.TransferFailed:
+		return nil, model.ErrTransferFailed
+	}
+	return existing, nil
+}
+
+// executeTransfer posts the legs of a PENDING transfer and records its outcome. The debited amount is reserved by a
+// hold on the source account first, then the destination account is credited, then the hold is captured. If the
+// credit is rejected, the hold is voided and the transfer fails with the error of the credit, so that the source
+// account is never debited, nor charged the fees of the transfer.
+// The transfer stays PENDING when the outcome of a leg is unknown, to be finished by a retry of the request or, once
+// its hold is recorded, by FinishPendingTransfers.
+func (s *TransferService) executeTransfer(ctx context.Context, transfer *model.Transfer) (*model.Transfer, error) {
+	hold, err := s.accounts.PlaceHold(ctx, &accountpb.PlaceHoldRequest{
+		AccountId:       transfer.FromAccountID.String(),
+		Amount:          &accountpb.Money{Currency: transfer.Currency, Units: transfer.Amount},
+		TransactionType: "TRANSFER_DEBIT",
+		TransferId:      transfer.TransferID.String(),
+		IdempotencyKey:  legIdempotencyKey(transfer, "hold"),
+	})
+	if err != nil {
+		log.Printf("executeTransfer: Failed to hold funds of account %v for transfer %v: %v\n", transfer.FromAccountID, transfer.TransferID, err)
+		if !rejected(err) {
+			return nil, err
+		}
+		return nil, s.failTransfer(ctx, transfer, err)
+	}
+	if !transfer.HoldID.Valid {
+		// recorded before the credit, so that a transfer whose capture fails is found by FinishPendingTransfers
+		holdID, err := uuid.Parse(hold.HoldId)
+		if err == nil {
+			err = s.repo.SetTransferHold(ctx, transfer.TransferID, holdID)
+		}
+		if err != nil {
+			log.Printf("executeTransfer: Failed to record hold %v of transfer %v: %v\n", hold.HoldId, transfer.TransferID, err)
+			return nil, model.ErrInternalServer
+		}
+	}
+
+	credit := &accountpb.CreateTransactionRequest{
+		AccountId:       transfer.ToAccountID.String(),
+		Amount:          &accountpb.Money{Currency: transfer.ToCurrency, Units: transfer.ToAmount},
+		TransactionType: "TRANSFER_CREDIT",
+		TransferId:      transfer.TransferID.String(),
+		IdempotencyKey:  legIdempotencyKey(transfer, "credit"),
+	}
+	if _, err := s.accounts.CreateTransaction(ctx, credit); err != nil {
+		log.Printf("executeTransfer: Failed to credit account %v for transfer %v: %v\n", transfer.ToAccountID, transfer.TransferID, err)
+		if !rejected(err) {
+			return nil, err
+		}
+		void := &accountpb.VoidHoldRequest{
+			HoldId:         hold.HoldId,
+			IdempotencyKey: legIdempotencyKey(transfer, "void"),
+		}
+		if _, voidErr := s.accounts.VoidHold(ctx, void); voidErr != nil {
+			// left PENDING, so that retrying the request tries the credit and the void again. The hold expires anyway.
+			log.Printf("executeTransfer: Failed to void hold %v for transfer %v: %v\n", hold.HoldId, transfer.TransferID, voidErr)
+			return nil, err
+		}
+		return nil, s.failTransfer(ctx, transfer, err)
+	}
+
+	capture := &accountpb.CaptureHoldRequest{
+		HoldId:         hold.HoldId,
+		IdempotencyKey: legIdempotencyKey(transfer, "capture"),
+	}
+	if _, err := s.accounts.CaptureHold(ctx, capture); err != nil {
+		// left PENDING whatever the error: the credit was posted, so the transfer can't fail anymore.
+		// FinishPendingTransfers retries the capture long before the hold expires.
+		log.Printf("executeTransfer: Failed to capture hold %v for transfer %v: %v\n", hold.HoldId, transfer.TransferID, err)
+		return nil, err
+	}
+
+	if err := s.repo.UpdateTransferStatus(ctx, transfer.TransferID, model.TransferCompleted); err != nil {
+		log.Printf("executeTransfer: Failed to complete transfer %v: %v\n", transfer.TransferID, err)
+		return nil, model.ErrInternalServer
+	}
+	completed := *transfer
+	completed.Status = model.TransferCompleted
+	return &completed, nil
+}
+
+// FinishPendingTransfers retries at most batchSize transfers that were left PENDING after their hold was placed and
+// weren't updated within the lease before now, e.g. because capturing the hold failed after the credit was posted. They
+// are retried on behalf of the owner of the source account, the legs posted already being replayed, so that the
+// source account is debited before the hold expires and releases the funds. It returns how many it retried, so that it
+// can be called until it returns less than batchSize. A retried transfer that is still PENDING is retried again once
+// its lease expires.
+func (s *TransferService) FinishPendingTransfers(ctx context.Context, now time.Time, batchSize int32) (int64, error) {
+	transfers, err := s.repo.ClaimPendingTransfers(ctx, now.Add(-s.lease), batchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim pending transfers: %w", err)
+	}
+	for _, transfer := range transfers {
+		if _, err := s.executeTransfer(client.OnBehalfOf(ctx, transfer.UserID.UUID), transfer); err != nil && !rejected(err) {
+			log.Printf("FinishPendingTransfers: Transfer %v is still PENDING: %v\n", transfer.TransferID, err)
+		}
+	}
+	return int64(len(transfers)), nil
+}
+
+// failTransfer records that the transfer failed because of cause, and returns cause.
+func (s *TransferService) failTransfer(ctx context.Context, transfer *model.Transfer, cause error) error {
+	if err := s.repo.UpdateTransferStatus(ctx, transfer.TransferID, model.TransferFailed); err != nil {
+		log.Printf("failTransfer: Failed to fail transfer %v: %v\n", transfer.TransferID, err)
+	}
+	return cause
+}
+
+// rejected reports whether err is a rejection of a leg by the account service, which the same request would get
+// again. Other errors, e.g. timeouts, leave it unknown whether the leg was posted.
+func rejected(err error) bool {
+	switch status.Code(err) {
+	case codes.InvalidArgument, codes.NotFound, codes.FailedPrecondition, codes.PermissionDenied, codes.OutOfRange:
+		return true
+	case codes.ResourceExhausted:
+		// with an ErrorInfo, a limit of the debits exceeded rather than the account service running out of resources
+		for _, detail := range status.Convert(err).Details() {
+			if _, ok := detail.(*errdetails.ErrorInfo); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// legIdempotencyKey returns the idempotency key of a leg of the transfer, the same for every attempt of the transfer.
+func legIdempotencyKey(transfer *model.Transfer, leg string) string {
+	return uuid.NewSHA1(transfer.TransferID, []byte(leg)).String()
+}
@@ -0,0 +1,295 @@
+package service
+
+import (
+	"account/money"
+	accountpb "account/proto"
+	"context"
+	"sync"
+	"testing"
+	"time"
+	"transfer/config"
+	"transfer/db/initialize"
+	"transfer/internal/identity"
+	"transfer/model"
+	"transfer/repository"
+	"transfer/utils"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func setupTestDB(t *testing.T) *sqlx.DB {
+	cfg, err := config.LoadDB()
+	require.NoError(t, err)
+	db := initialize.ConnectDB(cfg)
+	t.Cleanup(func() { require.NoError(t, db.Close()) })
+	return db
+}
+
+// fakeAccounts stands for the account service. It posts the legs of the transfers of the test, recording the user each
+// call acts for, and fails the legs of the other transfers as if the service were unavailable, unless transferIDs is
+// nil. It returns the accounts of the test, whoever owns them.
+type fakeAccounts struct {
+	accountpb.AccountServiceClient
+	accounts    map[string]*accountpb.Account
+	transferIDs map[string]bool
+	holdID      string
+	creditErr   error
+	captureErr  error
+
+	mu    sync.Mutex
+	calls []fakeCall
+}
+
+type fakeCall struct {
+	method     string
+	onBehalfOf string
+}
+
+func (f *fakeAccounts) record(ctx context.Context, method, transferID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if transferID != "" && f.transferIDs != nil && !f.transferIDs[transferID] {
+		return status.Error(codes.Unavailable, "unavailable")
+	}
+	md, _ := metadata.FromOutgoingContext(ctx)
+	call := fakeCall{method: method}
+	if values := md.Get("x-on-behalf-of"); len(values) > 0 {
+		call.onBehalfOf = values[0]
+	}
+	f.calls = append(f.calls, call)
+	return nil
+}
+
+func (f *fakeAccounts) methods() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var methods []string
+	for _, call := range f.calls {
+		methods = append(methods, call.method)
+	}
+	return methods
+}
+
+func (f *fakeAccounts) GetAccountByAccountId(_ context.Context, req *accountpb.GetAccountByAccountIdRequest, _ ...grpc.CallOption) (*accountpb.Account, error) {
+	account, ok := f.accounts[req.AccountId]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "account not found")
+	}
+	return account, nil
+}
+
+func (f *fakeAccounts) PlaceHold(ctx context.Context, req *accountpb.PlaceHoldRequest, _ ...grpc.CallOption) (*accountpb.Hold, error) {
+	if err := f.record(ctx, "PlaceHold", req.TransferId); err != nil {
+		return nil, err
+	}
+	return &accountpb.Hold{HoldId: f.holdID, AccountId: req.AccountId, Amount: req.Amount}, nil
+}
+
+func (f *fakeAccounts) CreateTransaction(ctx context.Context, req *accountpb.CreateTransactionRequest, _ ...grpc.CallOption) (*accountpb.CreateTransactionResponse, error) {
+	if err := f.record(ctx, "CreateTransaction", req.TransferId); err != nil {
+		return nil, err
+	}
+	if f.creditErr != nil {
+		return nil, f.creditErr
+	}
+	return &accountpb.CreateTransactionResponse{}, nil
+}
+
+func (f *fakeAccounts) CaptureHold(ctx context.Context, req *accountpb.CaptureHoldRequest, _ ...grpc.CallOption) (*accountpb.Transaction, error) {
+	if err := f.record(ctx, "CaptureHold", ""); err != nil {
+		return nil, err
+	}
+	if f.captureErr != nil {
+		return nil, f.captureErr
+	}
+	return &accountpb.Transaction{}, nil
+}
+
+func (f *fakeAccounts) VoidHold(ctx context.Context, req *accountpb.VoidHoldRequest, _ ...grpc.CallOption) (*accountpb.Hold, error) {
+	if err := f.record(ctx, "VoidHold", ""); err != nil {
+		return nil, err
+	}
+	return &accountpb.Hold{HoldId: req.HoldId}, nil
+}
+
+// pendingTransfer records a transfer left PENDING after its hold was placed, last updated an hour ago.
+func pendingTransfer(t *testing.T, db *sqlx.DB, repo *repository.TransferRepository, owner uuid.UUID, holdID uuid.UUID) *model.Transfer {
+	ctx := context.Background()
+	transfer, err := repo.CreateTransfer(ctx, &model.Transfer{
+		TransferID:     uuid.New(),
+		FromAccountID:  utils.RandomAccount().AccountID,
+		ToAccountID:    utils.RandomAccount().AccountID,
+		IdempotencyKey: uuid.NewString(),
+		Amount:         100,
+		Currency:       money.DefaultCurrency,
+		ToAmount:       100,
+		ToCurrency:     money.DefaultCurrency,
+		Status:         model.TransferPending,
+		UserID:         uuid.NullUUID{UUID: owner, Valid: true},
+	})
+	require.NoError(t, err)
+	require.NoError(t, repo.SetTransferHold(ctx, transfer.TransferID, holdID))
+	_, err = db.ExecContext(ctx, "UPDATE transfers SET updated_at = NOW() - INTERVAL '1 hour' WHERE id = $1", transfer.TransferID)
+	require.NoError(t, err)
+	transfer.HoldID = uuid.NullUUID{UUID: holdID, Valid: true}
+	return transfer
+}
+
+func requireTransferStatus(t *testing.T, repo *repository.TransferRepository, transferID uuid.UUID, want string) {
+	t.Helper()
+	transfer, err := repo.GetTransferByID(context.Background(), transferID)
+	require.NoError(t, err)
+	require.Equal(t, want, transfer.Status)
+}
+
+// A transfer whose capture failed after the credit was posted is completed on behalf of its owner.
+func TestFinishPendingTransfers_Success(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewTransferRepository(db)
+	owner, holdID := uuid.New(), uuid.New()
+	transfer := pendingTransfer(t, db, repo, owner, holdID)
+
+	accounts := &fakeAccounts{transferIDs: map[string]bool{transfer.TransferID.String(): true}, holdID: holdID.String()}
+	s := NewTransferService(repo, db, accounts, nil)
+
+	// the capture fails again: the transfer stays PENDING, leased until it is retried
+	accounts.captureErr = status.Error(codes.Unavailable, "unavailable")
+	n, err := s.FinishPendingTransfers(context.Background(), time.Now(), 1000)
+	require.NoError(t, err)
+	require.Positive(t, n)
+	require.Equal(t, []string{"PlaceHold", "CreateTransaction", "CaptureHold"}, accounts.methods())
+	for _, call := range accounts.calls {
+		require.Equal(t, owner.String(), call.onBehalfOf, call.method)
+	}
+	requireTransferStatus(t, repo, transfer.TransferID, model.TransferPending)
+
+	_, err = s.FinishPendingTransfers(context.Background(), time.Now(), 1000)
+	require.NoError(t, err)
+	require.Len(t, accounts.methods(), 3)
+
+	// once the lease expires, the legs are replayed and the capture goes through
+	accounts.captureErr = nil
+	_, err = db.ExecContext(context.Background(), "UPDATE transfers SET updated_at = NOW() - INTERVAL '1 hour' WHERE id = $1", transfer.TransferID)
+	require.NoError(t, err)
+	_, err = s.FinishPendingTransfers(context.Background(), time.Now(), 1000)
+	require.NoError(t, err)
+	require.Equal(t, []string{"PlaceHold", "CreateTransaction", "CaptureHold", "PlaceHold", "CreateTransaction", "CaptureHold"}, accounts.methods())
+	requireTransferStatus(t, repo, transfer.TransferID, model.TransferCompleted)
+}
+
+// A transfer whose credit is rejected when it is finished fails, its hold voided.
+func TestFinishPendingTransfers_Rejected(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewTransferRepository(db)
+	holdID := uuid.New()
+	transfer := pendingTransfer(t, db, repo, uuid.New(), holdID)
+
+	accounts := &fakeAccounts{
+		transferIDs: map[string]bool{transfer.TransferID.String(): true},
+		holdID:      holdID.String(),
+		creditErr:   status.Error(codes.FailedPrecondition, "account frozen"),
+	}
+	s := NewTransferService(repo, db, accounts, nil)
+
+	_, err := s.FinishPendingTransfers(context.Background(), time.Now(), 1000)
+	require.NoError(t, err)
+	require.Equal(t, []string{"PlaceHold", "CreateTransaction", "VoidHold"}, accounts.methods())
+	requireTransferStatus(t, repo, transfer.TransferID, model.TransferFailed)
+}
+
+// A user transfers to the account of another user, but not from it.
+func TestCreateTransfer_OtherUser(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewTransferRepository(db)
+	sender, recipient := uuid.New(), uuid.New()
+	from, to := uuid.New(), uuid.New()
+	accounts := &fakeAccounts{
+		accounts: map[string]*accountpb.Account{
+			from.String(): {AccountId: from.String(), UserId: sender.String(), Balance: &accountpb.Money{Currency: money.DefaultCurrency, Units: 10_000}},
+			to.String():   {AccountId: to.String(), UserId: recipient.String(), Balance: &accountpb.Money{Currency: money.DefaultCurrency}},
+		},
+		holdID: uuid.NewString(),
+	}
+	s := NewTransferService(repo, db, accounts, nil)
+	ctx := identity.NewContext(context.Background(), identity.Principal{UserID: sender})
+
+	transfer, err := s.CreateTransfer(ctx, &model.Transfer{
+		FromAccountID:  from,
+		ToAccountID:    to,
+		Amount:         1_000,
+		Currency:       money.DefaultCurrency,
+		IdempotencyKey: uuid.NewString(),
+	})
+	require.NoError(t, err)
+	require.Equal(t, model.TransferCompleted, transfer.Status)
+	require.Equal(t, uuid.NullUUID{UUID: sender, Valid: true}, transfer.UserID)
+	require.Equal(t, []string{"PlaceHold", "CreateTransaction", "CaptureHold"}, accounts.methods())
+	requireTransferStatus(t, repo, transfer.TransferID, model.TransferCompleted)
+
+	_, err = s.CreateTransfer(ctx, &model.Transfer{
+		FromAccountID:  to,
+		ToAccountID:    from,
+		Amount:         1_000,
+		Currency:       money.DefaultCurrency,
+		IdempotencyKey: uuid.NewString(),
+	})
+	require.ErrorIs(t, err, model.ErrNotAuthorized)
+	require.Len(t, accounts.methods(), 3)
+}
+
+// Idempotency keys are scoped to the user: the same key makes a new transfer for another user, and replays the
+// transfer of the user.
+func TestCreateTransfer_IdempotencyKeyPerUser(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewTransferRepository(db)
+	alice, bob := uuid.New(), uuid.New()
+	aliceAccount, bobAccount := uuid.New(), uuid.New()
+	accounts := &fakeAccounts{
+		accounts: map[string]*accountpb.Account{
+			aliceAccount.String(): {AccountId: aliceAccount.String(), UserId: alice.String(), Balance: &accountpb.Money{Currency: money.DefaultCurrency, Units: 10_000}},
+			bobAccount.String():   {AccountId: bobAccount.String(), UserId: bob.String(), Balance: &accountpb.Money{Currency: money.DefaultCurrency, Units: 10_000}},
+		},
+		holdID: uuid.NewString(),
+	}
+	s := NewTransferService(repo, db, accounts, nil)
+	key := uuid.NewString()
+
+	aliceCtx := identity.NewContext(context.Background(), identity.Principal{UserID: alice})
+	aliceTransfer := &model.Transfer{FromAccountID: aliceAccount, ToAccountID: bobAccount, Amount: 1_000, Currency: money.DefaultCurrency, IdempotencyKey: key}
+	first, err := s.CreateTransfer(aliceCtx, aliceTransfer)
+	require.NoError(t, err)
+
+	bobCtx := identity.NewContext(context.Background(), identity.Principal{UserID: bob})
+	bobTransfer, err := s.CreateTransfer(bobCtx, &model.Transfer{FromAccountID: bobAccount, ToAccountID: aliceAccount, Amount: 1_000, Currency: money.DefaultCurrency, IdempotencyKey: key})
+	require.NoError(t, err)
+	require.NotEqual(t, first.TransferID, bobTransfer.TransferID)
+	require.Equal(t, uuid.NullUUID{UUID: bob, Valid: true}, bobTransfer.UserID)
+
+	// the request of alice sent by another user with her key is a new transfer, from an account that isn't his
+	_, err = s.CreateTransfer(bobCtx, aliceTransfer)
+	require.ErrorIs(t, err, model.ErrNotAuthorized)
+
+	replayed, err := s.CreateTransfer(aliceCtx, aliceTransfer)
+	require.NoError(t, err)
+	require.Equal(t, first.TransferID, replayed.TransferID)
+	require.Len(t, accounts.methods(), 6)
+}
+
+func TestRejected(t *testing.T) {
+	limitExceeded, err := status.New(codes.ResourceExhausted, "daily_debit_total limit exceeded").WithDetails(&errdetails.ErrorInfo{Reason: "LIMIT_EXCEEDED"})
+	require.NoError(t, err)
+
+	require.True(t, rejected(status.Error(codes.FailedPrecondition, "insufficient funds")))
+	require.True(t, rejected(limitExceeded.Err()))
+	// the account service or the transport running out of resources may have posted the leg or not
+	require.False(t, rejected(status.Error(codes.ResourceExhausted, "too many requests")))
+	require.False(t, rejected(status.Error(codes.Unavailable, "unavailable")))
+	require.False(t, rejected(status.Error(codes.DeadlineExceeded, "deadline exceeded")))
+}
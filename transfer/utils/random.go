@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"account/money"
 	"math/rand"
 	"transfer/model"
 
@@ -38,11 +39,15 @@ func RandomUser() *model.User {
 }
 
 func RandomTransfer() *model.Transfer {
+	amount := int64(RandMinMax(1, 100_000_000))
 	return &model.Transfer{
 		FromAccountID:  uuid.New(),
 		ToAccountID:    uuid.New(),
 		IdempotencyKey: RandomString(10),
-		Amount:         int64(RandMinMax(1, 100_000_000)),
+		Amount:         amount,
+		Currency:       money.DefaultCurrency,
+		ToAmount:       amount,
+		ToCurrency:     money.DefaultCurrency,
 	}
 }
 